@@ -0,0 +1,150 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConditionCompliant is true when every namespace of a BackupComplianceReport had a successful backup within the RPO
+	ConditionCompliant = "Compliant"
+	// CompliantReasonRPOMet is the reason of the Compliant condition when no namespace breaches the RPO
+	CompliantReasonRPOMet = "RPOMet"
+	// CompliantReasonRPOBreached is the reason of the Compliant condition when namespaces breach the RPO
+	CompliantReasonRPOBreached = "RPOBreached"
+	// CompliantReasonEvaluationFailed is the reason of the Compliant condition when the report could not be evaluated
+	CompliantReasonEvaluationFailed = "EvaluationFailed"
+)
+
+// BackupComplianceReportSpec defines the namespaces tracked by the report and their recovery point objective
+type BackupComplianceReportSpec struct {
+	// rpo is the recovery point objective, the maximum age of the last successful backup of a namespace
+	// +kubebuilder:default="24h"
+	// +optional
+	RPO metav1.Duration `json:"rpo,omitempty"`
+	// namespaceSelector selects the tracked namespaces. All namespaces are tracked when unset.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// excludedNamespaces are namespaces not tracked, wildcards such as openshift-* are supported
+	// +optional
+	ExcludedNamespaces []string `json:"excludedNamespaces,omitempty"`
+	// groupByLabel is the namespace label whose values group the namespaces in status.groups, for instance a team or
+	// application label
+	// +optional
+	GroupByLabel string `json:"groupByLabel,omitempty"`
+	// evaluationInterval is how often the report is evaluated, besides backup and schedule changes
+	// +kubebuilder:default="5m"
+	// +optional
+	EvaluationInterval metav1.Duration `json:"evaluationInterval,omitempty"`
+}
+
+// BackupComplianceReportStatus reports the last successful backup and RPO breaches of the tracked namespaces
+type BackupComplianceReportStatus struct {
+	// conditions of the report, Compliant is true when no namespace breaches the RPO
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// lastEvaluationTime is when the report was last evaluated
+	// +optional
+	LastEvaluationTime *metav1.Time `json:"lastEvaluationTime,omitempty"`
+	// namespaceCount is the number of tracked namespaces
+	// +optional
+	NamespaceCount int `json:"namespaceCount,omitempty"`
+	// breachedNamespaceCount is the number of tracked namespaces without a successful backup within the RPO
+	// +optional
+	BreachedNamespaceCount int `json:"breachedNamespaceCount,omitempty"`
+	// uncoveredNamespaceCount is the number of tracked namespaces not included in any enabled Schedule
+	// +optional
+	UncoveredNamespaceCount int `json:"uncoveredNamespaceCount,omitempty"`
+	// breachedNamespaces lists the first namespaces without a successful backup within the RPO, the oldest first
+	// +optional
+	BreachedNamespaces []NamespaceComplianceStatus `json:"breachedNamespaces,omitempty"`
+	// uncoveredNamespaces lists the first namespaces not included in any enabled Schedule
+	// +optional
+	UncoveredNamespaces []string `json:"uncoveredNamespaces,omitempty"`
+	// groups reports the namespaces of each value of spec.groupByLabel
+	// +optional
+	Groups []NamespaceGroupComplianceStatus `json:"groups,omitempty"`
+}
+
+// NamespaceComplianceStatus is the last successful backup of a namespace
+type NamespaceComplianceStatus struct {
+	// namespace name
+	Namespace string `json:"namespace"`
+	// group is the value of the spec.groupByLabel label of the namespace
+	// +optional
+	Group string `json:"group,omitempty"`
+	// lastSuccessfulBackup is the name of the last completed backup including the namespace
+	// +optional
+	LastSuccessfulBackup string `json:"lastSuccessfulBackup,omitempty"`
+	// lastSuccessfulBackupTime is the completion time of the last completed backup including the namespace
+	// +optional
+	LastSuccessfulBackupTime *metav1.Time `json:"lastSuccessfulBackupTime,omitempty"`
+	// covered is true when an enabled Schedule includes the namespace
+	// +optional
+	Covered bool `json:"covered,omitempty"`
+}
+
+// NamespaceGroupComplianceStatus reports the namespaces of a value of spec.groupByLabel
+type NamespaceGroupComplianceStatus struct {
+	// name is the label value, empty for the namespaces without the label
+	Name string `json:"name"`
+	// namespaceCount is the number of tracked namespaces of the group
+	NamespaceCount int `json:"namespaceCount"`
+	// breachedNamespaceCount is the number of namespaces of the group without a successful backup within the RPO
+	// +optional
+	BreachedNamespaceCount int `json:"breachedNamespaceCount,omitempty"`
+	// uncoveredNamespaceCount is the number of namespaces of the group not included in any enabled Schedule
+	// +optional
+	UncoveredNamespaceCount int `json:"uncoveredNamespaceCount,omitempty"`
+	// lastSuccessfulBackupTime is the oldest last successful backup of the namespaces of the group, unset when a
+	// namespace of the group has none
+	// +optional
+	LastSuccessfulBackupTime *metav1.Time `json:"lastSuccessfulBackupTime,omitempty"`
+}
+
+// +kubebuilder:printcolumn:name="RPO",type=string,JSONPath=".spec.rpo"
+// +kubebuilder:printcolumn:name="Namespaces",type=integer,JSONPath=".status.namespaceCount"
+// +kubebuilder:printcolumn:name="Breached",type=integer,JSONPath=".status.breachedNamespaceCount"
+// +kubebuilder:printcolumn:name="Uncovered",type=integer,JSONPath=".status.uncoveredNamespaceCount"
+// +kubebuilder:printcolumn:name="LastEvaluation",type=date,JSONPath=".status.lastEvaluationTime"
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=backupcompliancereports,shortName=bcr
+
+// BackupComplianceReport reports the namespaces without a successful backup within a recovery point objective and
+// the namespaces not covered by any Schedule, from the Velero Backups and Schedules of its namespace
+type BackupComplianceReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BackupComplianceReportSpec   `json:"spec,omitempty"`
+	Status BackupComplianceReportStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// BackupComplianceReportList contains a list of BackupComplianceReport
+type BackupComplianceReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BackupComplianceReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BackupComplianceReport{}, &BackupComplianceReportList{})
+}
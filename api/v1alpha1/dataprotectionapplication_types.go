@@ -34,6 +34,13 @@ const ReconciledReasonComplete = "Complete"
 const ReconciledReasonError = "Error"
 const ReconcileCompleteMessage = "Reconcile complete"
 
+// ConditionCredentialsHealthyPrefix prefixes the name of a BSL or VSL secret to form its credential health condition type
+const ConditionCredentialsHealthyPrefix = "CredentialsHealthy-"
+const CredentialsReasonAuthenticated = "Authenticated"
+const CredentialsReasonAuthenticationFailed = "AuthenticationFailed"
+const CredentialsReasonExpiringSoon = "ExpiringSoon"
+const CredentialsReasonNotChecked = "NotChecked"
+
 const OadpOperatorLabel = "openshift.io/oadp"
 
 // +kubebuilder:validation:Enum=aws;legacy-aws;gcp;azure;csi;vsm;openshift;kubevirt;hypershift
@@ -339,6 +346,20 @@ type VeleroConfig struct {
 	// LoadAffinityConfig is the config for data path load affinity.
 	// +optional
 	LoadAffinityConfig []*LoadAffinity `json:"loadAffinity,omitempty"`
+	// PodDisruptionBudget configures the PodDisruptionBudget the operator owns for the Velero Deployment
+	// +optional
+	PodDisruptionBudget *VeleroPodDisruptionBudget `json:"podDisruptionBudget,omitempty"`
+}
+
+// VeleroPodDisruptionBudget configures the PodDisruptionBudget of the Velero Deployment
+type VeleroPodDisruptionBudget struct {
+	// enable defines whether the operator creates the PodDisruptionBudget. Defaults to true.
+	// +optional
+	Enable *bool `json:"enable,omitempty"`
+	// protectInProgressOperations prevents the eviction of the Velero pod, for example by a node drain, while a backup
+	// or restore is in progress, so it is not failed by the Velero restart. Defaults to false.
+	// +optional
+	ProtectInProgressOperations *bool `json:"protectInProgressOperations,omitempty"`
 }
 
 // PodConfig defines the pod configuration options
@@ -362,6 +383,20 @@ type PodConfig struct {
 	// env defines the list of environment variables to be supplied to podSpec
 	// +optional
 	Env []corev1.EnvVar `json:"env,omitempty"`
+	// priorityClassName defines the PriorityClass of the Pod
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// topologySpreadConstraints defines how the Pods are spread across topology domains
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	// affinity defines the scheduling constraints of the Pod, with the fields of the Pod affinity. The fields are
+	// validated when the Pods are created. The nodeAffinity can not be set together with loadAffinity, which defines the
+	// nodeAffinity of the Pod.
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
 }
 
 type NodeAgentCommonFields struct {
@@ -513,11 +548,38 @@ type NodeAgentConfig struct {
 	// Embedding NodeAgentConfigMapSettings
 	// +optional
 	NodeAgentConfigMapSettings `json:",inline"`
+	// maintenanceWindows override loadConcurrency and loadAffinity in the node-agent ConfigMap while a window is
+	// active, for example to allow heavy data movement on busy nodes only during off-peak hours.
+	// When several windows are active, the first one in the list applies.
+	// +optional
+	MaintenanceWindows []NodeAgentMaintenanceWindow `json:"maintenanceWindows,omitempty"`
 	// Embedding KopiaRepoOptions
 	// +optional
 	KopiaRepoOptions `json:",inline"`
 }
 
+// NodeAgentMaintenanceWindow is a recurring time window overriding the data path load settings of the node-agent
+type NodeAgentMaintenanceWindow struct {
+	// name of the window, reported in status while the window is active
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// schedule is the cron expression of the window start, for example "0 22 * * 1-5" for 22:00 on weekdays
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+	// duration of the window
+	Duration metav1.Duration `json:"duration"`
+	// timeZone is the IANA time zone of the schedule, UTC by default
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+	// loadConcurrency replaces loadConcurrency while the window is active
+	// +optional
+	LoadConcurrency *LoadConcurrency `json:"loadConcurrency,omitempty"`
+	// loadAffinity replaces loadAffinity of the data mover pods while the window is active.
+	// The NodeAgent DaemonSet keeps its affinity, the selected nodes must run a NodeAgent.
+	// +optional
+	LoadAffinityConfig []*LoadAffinity `json:"loadAffinity,omitempty"`
+}
+
 type KopiaRepoOptions struct {
 	// CacheLimitMB specifies the size limit(in MB) for the local data cache
 	// +kubebuilder:validation:Minimum=0
@@ -555,6 +617,11 @@ type RepositoryMaintenanceConfig struct {
 	// PodResources is the config for the CPU and memory resources setting.
 	// +optional
 	PodResources *kube.PodResources `json:"podResources,omitempty"`
+
+	// PriorityClassName is the PriorityClass of the maintenance job pods. Velero only reads it from the "global" key,
+	// which defaults to the priorityClassName of spec.configuration.velero.podConfig.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
 }
 
 // ApplicationConfig defines the configuration for the Data Protection Application
@@ -621,6 +688,15 @@ type BackupLocation struct {
 	Velero *velero.BackupStorageLocationSpec `json:"velero,omitempty"`
 	// +optional
 	CloudStorage *CloudStorageLocation `json:"bucket,omitempty"`
+	// externalCredential mounts the credentials of the velero backup location from an external secret store
+	// instead of a Secret. It cannot be set together with velero.credential or the credentialsFile config.
+	// +optional
+	ExternalCredential *ExternalCredential `json:"externalCredential,omitempty"`
+	// mountCredential mounts the velero.credential secret key in the Velero and NodeAgent pods and uses the
+	// mounted file as the credentialsFile of the location, instead of Velero reading the secret at runtime.
+	// Requires velero.credential and cannot be set together with externalCredential or the credentialsFile config.
+	// +optional
+	MountCredential bool `json:"mountCredential,omitempty"`
 }
 
 // SnapshotLocation defines the configuration for the DPA snapshot store
@@ -630,6 +706,27 @@ type SnapshotLocation struct {
 	// +optional
 	Name   string                             `json:"name,omitempty"`
 	Velero *velero.VolumeSnapshotLocationSpec `json:"velero"`
+	// externalCredential mounts the credentials of the snapshot location from an external secret store
+	// instead of a Secret. It cannot be set together with velero.credential or the credentialsFile config.
+	// +optional
+	ExternalCredential *ExternalCredential `json:"externalCredential,omitempty"`
+	// mountCredential mounts the velero.credential secret key in the Velero and NodeAgent pods and uses the
+	// mounted file as the credentialsFile of the location, instead of Velero reading the secret at runtime.
+	// Requires velero.credential and cannot be set together with externalCredential or the credentialsFile config.
+	// +optional
+	MountCredential bool `json:"mountCredential,omitempty"`
+}
+
+// ExternalCredential references credentials provided by the Secrets Store CSI driver.
+// The volume is mounted in the Velero and NodeAgent pods and the location uses the mounted file as its credentialsFile.
+type ExternalCredential struct {
+	// secretProviderClass is the name of the SecretProviderClass in the DataProtectionApplication namespace
+	// +kubebuilder:validation:MinLength=1
+	SecretProviderClass string `json:"secretProviderClass"`
+	// key is the file holding the credentials in the mounted volume, the objectName or objectAlias
+	// of the object in the SecretProviderClass. Its content uses the same format as the credential secret key.
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
 }
 
 // We need to create enforcement structures for the BSL spec fields, because the Velero BSL spec
@@ -688,6 +785,107 @@ type EnforceBackupStorageLocationSpec struct {
 	ValidationFrequency *metav1.Duration `json:"validationFrequency,omitempty"`
 }
 
+// CredentialHealthCheck configures the periodic authentication check of the BSL and VSL credentials
+type CredentialHealthCheck struct {
+	// enable turns on the credential health check. Each BSL and VSL secret is reported
+	// in a CredentialsHealthy-<secret name> condition of the DataProtectionApplication.
+	// +optional
+	Enable bool `json:"enable,omitempty"`
+	// interval between checks. Defaults to 1h.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// expiryWarningPeriod is how long before an Azure client secret or GCP service account key expires
+	// its condition reports ExpiringSoon. Defaults to 720h (30 days).
+	// +optional
+	ExpiryWarningPeriod *metav1.Duration `json:"expiryWarningPeriod,omitempty"`
+}
+
+// CloudIdentity describes a short-lived cloud identity. The operator generates a secret named after the
+// identity that BSLs and VSLs reference in their credential.
+// Exactly one of aws, gcp or azure must be set.
+type CloudIdentity struct {
+	// name of the generated secret in the DataProtectionApplication namespace
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// aws assumes an IAM role with the projected service account token (AWS STS), with an X.509 certificate
+	// (IAM Roles Anywhere) or uses the EKS Pod Identity agent. The secret key is "credentials".
+	// +optional
+	AWS *AWSCloudIdentity `json:"aws,omitempty"`
+	// gcp impersonates a service account through a workload identity pool (GCP WIF).
+	// The secret key is "service_account.json".
+	// +optional
+	GCP *GCPCloudIdentity `json:"gcp,omitempty"`
+	// azure authenticates as a federated Azure client (Azure workload identity).
+	// The secret key is "azurekey". Velero supports one Azure client per installation.
+	// +optional
+	Azure *AzureCloudIdentity `json:"azure,omitempty"`
+}
+
+// AWSCloudIdentity is an AWS IAM role assumed with web identity, an AWS IAM role assumed through
+// IAM Roles Anywhere, or the role associated with the velero service account by EKS Pod Identity.
+// At most one of rolesAnywhere or podIdentity can be set; when neither is set the role is assumed with web identity.
+type AWSCloudIdentity struct {
+	// roleARN of the IAM role to assume. Required for web identity and IAM Roles Anywhere,
+	// must be empty with podIdentity.
+	// +optional
+	RoleARN string `json:"roleARN,omitempty"`
+	// rolesAnywhere obtains credentials for roleARN with an X.509 certificate through IAM Roles Anywhere.
+	// The generated profile runs the AWS signing helper as its credential_process.
+	// +optional
+	RolesAnywhere *AWSRolesAnywhere `json:"rolesAnywhere,omitempty"`
+	// podIdentity uses the credentials of the EKS Pod Identity association of the velero service account.
+	// The Velero and NodeAgent pods get the container credentials environment and the pod identity token.
+	// +optional
+	PodIdentity bool `json:"podIdentity,omitempty"`
+}
+
+// AWSRolesAnywhere configures IAM Roles Anywhere for an AWS cloud identity
+type AWSRolesAnywhere struct {
+	// trustAnchorARN of the IAM Roles Anywhere trust anchor that issued the certificate
+	// +kubebuilder:validation:MinLength=1
+	TrustAnchorARN string `json:"trustAnchorARN"`
+	// profileARN of the IAM Roles Anywhere profile
+	// +kubebuilder:validation:MinLength=1
+	ProfileARN string `json:"profileARN"`
+	// certificateSecret is a kubernetes.io/tls secret in the DataProtectionApplication namespace with the
+	// certificate (tls.crt) and private key (tls.key). It is mounted in the Velero and NodeAgent pods.
+	// +kubebuilder:validation:MinLength=1
+	CertificateSecret string `json:"certificateSecret"`
+	// signingHelperPath is the path of the aws_signing_helper binary in the Velero image.
+	// Defaults to aws_signing_helper, resolved from PATH.
+	// +optional
+	SigningHelperPath string `json:"signingHelperPath,omitempty"`
+}
+
+// GCPCloudIdentity is a GCP service account impersonated through workload identity federation
+type GCPCloudIdentity struct {
+	// serviceAccountEmail of the GCP service account to impersonate
+	// +kubebuilder:validation:MinLength=1
+	ServiceAccountEmail string `json:"serviceAccountEmail"`
+	// projectNumber of the project that owns the workload identity pool
+	// +kubebuilder:validation:MinLength=1
+	ProjectNumber string `json:"projectNumber"`
+	// poolID of the workload identity pool
+	// +kubebuilder:validation:MinLength=1
+	PoolID string `json:"poolID"`
+	// providerID of the workload identity pool provider
+	// +kubebuilder:validation:MinLength=1
+	ProviderID string `json:"providerID"`
+}
+
+// AzureCloudIdentity is an Azure client with a federated identity credential for the velero service account
+type AzureCloudIdentity struct {
+	// clientID of the Azure application or managed identity
+	// +kubebuilder:validation:MinLength=1
+	ClientID string `json:"clientID"`
+	// tenantID of the Azure tenant
+	// +kubebuilder:validation:MinLength=1
+	TenantID string `json:"tenantID"`
+	// subscriptionID of the Azure subscription
+	// +kubebuilder:validation:MinLength=1
+	SubscriptionID string `json:"subscriptionID"`
+}
+
 type NonAdmin struct {
 	// Enables non admin feature, by default is disabled
 	// +optional
@@ -726,6 +924,10 @@ type NonAdmin struct {
 	// By default 2m
 	// +optional
 	BackupSyncPeriod *metav1.Duration `json:"backupSyncPeriod,omitempty"`
+
+	// Pod specific configuration of the non admin controller
+	// +optional
+	PodConfig *PodConfig `json:"podConfig,omitempty"`
 }
 
 // DataMover defines the various config for DPA data mover
@@ -878,6 +1080,137 @@ type DataProtectionApplicationSpec struct {
 	// +kubebuilder:default=text
 	// +optional
 	LogFormat LogFormat `json:"logFormat,omitempty"`
+	// credentialHealthCheck periodically authenticates with the BSL and VSL credentials
+	// +optional
+	CredentialHealthCheck *CredentialHealthCheck `json:"credentialHealthCheck,omitempty"`
+	// cloudIdentities describes short-lived cloud identities (AWS STS, GCP WIF, Azure workload identity).
+	// Each identity becomes its own generated secret, referenced by name from BSL and VSL credentials.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	CloudIdentities []CloudIdentity `json:"cloudIdentities,omitempty"`
+	// credentialsRequests generates a Cloud Credential Operator CredentialsRequest for each cloud provider of the
+	// backup and snapshot locations, with the permissions Velero, its plugins and CloudStorage need.
+	// +optional
+	CredentialsRequests *CredentialsRequests `json:"credentialsRequests,omitempty"`
+	// resourceRecommendations recommends the resource requests and limits of Velero and the NodeAgent from their
+	// observed usage, and optionally applies them
+	// +optional
+	ResourceRecommendations *ResourceRecommendations `json:"resourceRecommendations,omitempty"`
+	// networkPolicy makes the operator reconcile the NetworkPolicies of the Velero, NodeAgent and non admin controller
+	// pods, for namespaces denying network traffic by default
+	// +optional
+	NetworkPolicy *NetworkPolicyConfig `json:"networkPolicy,omitempty"`
+	// schedulePolicies declares tiered backup schedules reconciled into Velero Schedules in the
+	// DataProtectionApplication namespace
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	SchedulePolicies []SchedulePolicy `json:"schedulePolicies,omitempty"`
+}
+
+// SchedulePolicy is a set of backup schedule tiers of the namespaces matching a label selector. Each tier is
+// reconciled into a Velero Schedule named <policy>-<tier>, Schedules of removed policies and tiers are deleted.
+type SchedulePolicy struct {
+	// name of the policy, the prefix of the names of its Velero Schedules
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=40
+	Name string `json:"name"`
+	// namespaceSelector selects the namespaces backed up by the policy. The Schedules of a policy selecting no
+	// namespace are paused.
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
+	// storageLocation is the backup storage location of the backups. Defaults to the default backup storage location.
+	// +optional
+	StorageLocation string `json:"storageLocation,omitempty"`
+	// snapshotMoveData moves the volume snapshot data of the backups to the backup storage location
+	// +optional
+	SnapshotMoveData *bool `json:"snapshotMoveData,omitempty"`
+	// defaultVolumesToFsBackup backs up all volumes of the backups with the file system backup
+	// +optional
+	DefaultVolumesToFsBackup *bool `json:"defaultVolumesToFsBackup,omitempty"`
+	// tiers are the schedules of the policy, for instance hourly backups kept for 24h, daily backups kept for 7d and
+	// weekly backups kept for 4w
+	// +kubebuilder:validation:MinItems=1
+	// +listType=map
+	// +listMapKey=name
+	Tiers []ScheduleTier `json:"tiers"`
+}
+
+// ScheduleTier is a backup schedule and retention of a SchedulePolicy
+type ScheduleTier struct {
+	// name of the tier, the suffix of the name of its Velero Schedule
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=20
+	Name string `json:"name"`
+	// schedule is the cron expression of the backups, for instance "0 * * * *" for hourly backups
+	Schedule string `json:"schedule"`
+	// ttl is the retention of the backups, for instance 24h, 168h or 672h
+	TTL metav1.Duration `json:"ttl"`
+	// snapshotMoveData overrides the snapshotMoveData of the policy for the tier
+	// +optional
+	SnapshotMoveData *bool `json:"snapshotMoveData,omitempty"`
+	// defaultVolumesToFsBackup overrides the defaultVolumesToFsBackup of the policy for the tier
+	// +optional
+	DefaultVolumesToFsBackup *bool `json:"defaultVolumesToFsBackup,omitempty"`
+}
+
+// NetworkPolicyConfig configures the NetworkPolicies of the OADP pods. The policies allow egress to the kube-apiserver,
+// the cluster DNS, the backup and snapshot locations and the proxies of the pods, and ingress to the metrics ports
+// from the monitoring namespace only.
+type NetworkPolicyConfig struct {
+	// enable makes the operator create the NetworkPolicies. Disabling it deletes them.
+	// +optional
+	Enable bool `json:"enable,omitempty"`
+	// monitoringNamespace is the namespace allowed to scrape the metrics ports. Defaults to openshift-monitoring.
+	// +optional
+	MonitoringNamespace string `json:"monitoringNamespace,omitempty"`
+	// providerCIDRs are the CIDRs of the cloud provider endpoints reached on port 443 by the backup storage locations
+	// without s3Url and by the volume snapshot locations. When empty, these locations are reached through the cluster
+	// proxy if it is set, else port 443 is allowed to any destination.
+	// +optional
+	ProviderCIDRs []string `json:"providerCIDRs,omitempty"`
+	// dnsNamespace is the namespace of the cluster DNS pods. Defaults to openshift-dns, or to the kube-dns pods of
+	// kube-system when openshift-dns does not exist.
+	// +optional
+	DNSNamespace string `json:"dnsNamespace,omitempty"`
+}
+
+// ResourceRecommendations configures the resource recommender of the Velero and NodeAgent containers
+type ResourceRecommendations struct {
+	// enable samples the container usage of Velero and the NodeAgent from the metrics API and publishes
+	// recommended requests and limits in status.resourceRecommendations
+	// +optional
+	Enable bool `json:"enable,omitempty"`
+	// apply sets the recommended requests and limits on the Velero Deployment and the NodeAgent DaemonSet, in place of
+	// podConfig.resourceAllocations, once enough usage has been observed
+	// +optional
+	Apply bool `json:"apply,omitempty"`
+	// velero bounds the recommendations of the Velero container
+	// +optional
+	Velero *ResourceBounds `json:"velero,omitempty"`
+	// nodeAgent bounds the recommendations of the NodeAgent container
+	// +optional
+	NodeAgent *ResourceBounds `json:"nodeAgent,omitempty"`
+}
+
+// ResourceBounds limits the recommended requests and limits of a container
+type ResourceBounds struct {
+	// minAllowed is the minimum recommended request and limit of each resource
+	// +optional
+	MinAllowed corev1.ResourceList `json:"minAllowed,omitempty"`
+	// maxAllowed is the maximum recommended request and limit of each resource
+	// +optional
+	MaxAllowed corev1.ResourceList `json:"maxAllowed,omitempty"`
+}
+
+// CredentialsRequests configures the generation of cloudcredential.openshift.io CredentialsRequests
+type CredentialsRequests struct {
+	// enable generates a CredentialsRequest in the DataProtectionApplication namespace for each provider of the
+	// backup and snapshot locations. The permissions are derived from the locations: object storage for backup
+	// locations, bucket creation and deletion for locations using CloudStorage, and volume snapshots for snapshot
+	// locations. Requests of providers that are no longer used are deleted.
+	// +optional
+	Enable bool `json:"enable,omitempty"`
 }
 
 // DataProtectionApplicationStatus defines the observed state of DataProtectionApplication
@@ -885,6 +1218,240 @@ type DataProtectionApplicationStatus struct {
 	// Conditions defines the observed state of DataProtectionApplication
 	//+operator-sdk:csv:customresourcedefinitions:type=status
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// lastCredentialHealthCheck is when the BSL and VSL credentials were last checked
+	// +optional
+	LastCredentialHealthCheck *metav1.Time `json:"lastCredentialHealthCheck,omitempty"`
+	// cloudIdentities lists the secrets generated for spec.cloudIdentities
+	// +optional
+	CloudIdentities []CloudIdentityStatus `json:"cloudIdentities,omitempty"`
+	// locationCredentials reports the credentials each backup and snapshot location resolves to
+	// +optional
+	LocationCredentials []LocationCredentialStatus `json:"locationCredentials,omitempty"`
+	// credentialsRequests lists the CredentialsRequests generated for spec.credentialsRequests
+	// +optional
+	CredentialsRequests []CredentialsRequestStatus `json:"credentialsRequests,omitempty"`
+	// nodeAgent reports the NodeAgent of each node and the pods it cannot back up
+	// +optional
+	NodeAgent *NodeAgentStatus `json:"nodeAgent,omitempty"`
+	// nodeAgentMaintenanceWindow is the active spec.configuration.nodeAgent.maintenanceWindows entry, unset outside of
+	// the windows
+	// +optional
+	NodeAgentMaintenanceWindow *NodeAgentMaintenanceWindowStatus `json:"nodeAgentMaintenanceWindow,omitempty"`
+	// resourceRecommendations are the resources recommended for the Velero and NodeAgent containers when
+	// spec.resourceRecommendations is enabled
+	// +optional
+	ResourceRecommendations []ResourceRecommendationStatus `json:"resourceRecommendations,omitempty"`
+	// schedulePolicies reports the Velero Schedules of spec.schedulePolicies and their last successful backups
+	// +optional
+	SchedulePolicies []SchedulePolicyStatus `json:"schedulePolicies,omitempty"`
+}
+
+// SchedulePolicyStatus reports the namespaces and the tiers of a SchedulePolicy
+type SchedulePolicyStatus struct {
+	// name of the policy
+	Name string `json:"name"`
+	// namespaces selected by the policy
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+	// tiers reports the Velero Schedule of each tier
+	// +optional
+	Tiers []ScheduleTierStatus `json:"tiers,omitempty"`
+}
+
+// ScheduleTierStatus reports the Velero Schedule of a ScheduleTier and its last successful backup
+type ScheduleTierStatus struct {
+	// name of the tier
+	Name string `json:"name"`
+	// schedule is the name of the Velero Schedule of the tier
+	Schedule string `json:"schedule"`
+	// paused is true when the policy selects no namespace
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+	// lastSuccessfulBackup is the name of the last completed backup of the Schedule
+	// +optional
+	LastSuccessfulBackup string `json:"lastSuccessfulBackup,omitempty"`
+	// lastSuccessfulBackupTime is the completion time of the last completed backup of the Schedule
+	// +optional
+	LastSuccessfulBackupTime *metav1.Time `json:"lastSuccessfulBackupTime,omitempty"`
+}
+
+// ResourceRecommendationStatus is the resource recommendation of a container and the usage it derives from
+type ResourceRecommendationStatus struct {
+	// component is the recommended container: velero or node-agent
+	Component string `json:"component"`
+	// requests recommended for the container
+	// +optional
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+	// limits recommended for the container
+	// +optional
+	Limits corev1.ResourceList `json:"limits,omitempty"`
+	// peak is the highest usage of the container, across its pods, observed since windowStart
+	// +optional
+	Peak corev1.ResourceList `json:"peak,omitempty"`
+	// previousPeak is the highest usage of the container observed in the window before windowStart
+	// +optional
+	PreviousPeak corev1.ResourceList `json:"previousPeak,omitempty"`
+	// windowStart is the start of the current usage window
+	WindowStart metav1.Time `json:"windowStart"`
+	// lastSample is when the usage was last sampled
+	// +optional
+	LastSample *metav1.Time `json:"lastSample,omitempty"`
+	// samples is the number of usage samples taken
+	// +optional
+	Samples int `json:"samples,omitempty"`
+	// applied is true when the recommendation is set on the container
+	// +optional
+	Applied bool `json:"applied,omitempty"`
+}
+
+// NodeAgentMaintenanceWindowStatus describes the active node-agent maintenance window
+type NodeAgentMaintenanceWindowStatus struct {
+	// name of the window
+	Name string `json:"name"`
+	// start of the current occurrence of the window
+	Start metav1.Time `json:"start"`
+	// end of the current occurrence of the window
+	End metav1.Time `json:"end"`
+}
+
+// NodeAgentStatus reports where the NodeAgent runs and the workloads without a ready NodeAgent on their node
+type NodeAgentStatus struct {
+	// nodeCount is the number of nodes of the cluster
+	// +optional
+	NodeCount int `json:"nodeCount,omitempty"`
+	// nodes reports the NodeAgent of the first 100 nodes of the cluster, nodes without a ready NodeAgent first
+	// +optional
+	Nodes []NodeAgentNodeStatus `json:"nodes,omitempty"`
+	// unprotectedPodCount is the number of pods mounting persistent volume claims on nodes without a ready NodeAgent,
+	// counted on the first 50 of those nodes
+	// +optional
+	UnprotectedPodCount int `json:"unprotectedPodCount,omitempty"`
+	// unprotectedPods lists the first pods mounting persistent volume claims on nodes without a ready NodeAgent,
+	// file system backups and data movement of their volumes fail
+	// +optional
+	UnprotectedPods []UnprotectedPod `json:"unprotectedPods,omitempty"`
+	// lastUnprotectedPodScan is when the pods of the nodes without a ready NodeAgent were last listed
+	// +optional
+	LastUnprotectedPodScan *metav1.Time `json:"lastUnprotectedPodScan,omitempty"`
+}
+
+// NodeAgentNodeReason explains why a node has no ready NodeAgent
+// +kubebuilder:validation:Enum=ExcludedByLoadAffinity;TaintNotTolerated;Pending;NotReady;CrashLoopBackOff
+type NodeAgentNodeReason string
+
+const (
+	// NodeAgentNodeExcludedByLoadAffinity means the node does not match the loadAffinity of the NodeAgent
+	NodeAgentNodeExcludedByLoadAffinity NodeAgentNodeReason = "ExcludedByLoadAffinity"
+	// NodeAgentNodeTaintNotTolerated means the NodeAgent does not tolerate a taint of the node
+	NodeAgentNodeTaintNotTolerated NodeAgentNodeReason = "TaintNotTolerated"
+	// NodeAgentNodePending means the NodeAgent pod of the node is not created or not running yet
+	NodeAgentNodePending NodeAgentNodeReason = "Pending"
+	// NodeAgentNodeNotReady means the NodeAgent pod of the node is running but not ready
+	NodeAgentNodeNotReady NodeAgentNodeReason = "NotReady"
+	// NodeAgentNodeCrashLoopBackOff means the NodeAgent container of the node is crash looping
+	NodeAgentNodeCrashLoopBackOff NodeAgentNodeReason = "CrashLoopBackOff"
+)
+
+// NodeAgentNodeStatus describes the NodeAgent of a node
+type NodeAgentNodeStatus struct {
+	// node name
+	Node string `json:"node"`
+	// scheduled is true when the NodeAgent is expected to run on the node
+	Scheduled bool `json:"scheduled"`
+	// ready is true when the NodeAgent pod of the node is ready
+	Ready bool `json:"ready"`
+	// reason the node has no ready NodeAgent, or ExcludedByLoadAffinity when its ready NodeAgent is outside the
+	// loadAffinity of the active maintenance window
+	// +optional
+	Reason NodeAgentNodeReason `json:"reason,omitempty"`
+	// pod is the NodeAgent pod of the node
+	// +optional
+	Pod string `json:"pod,omitempty"`
+	// restarts of the NodeAgent container
+	// +optional
+	Restarts int32 `json:"restarts,omitempty"`
+	// concurrencyRule is the index of the loadConcurrency.perNodeConfig rule setting the concurrency of the node,
+	// unset when the global concurrency applies
+	// +optional
+	ConcurrencyRule *int `json:"concurrencyRule,omitempty"`
+	// concurrency is the number of data path operations the NodeAgent of the node runs at the same time
+	Concurrency int `json:"concurrency"`
+}
+
+// UnprotectedPod is a pod mounting persistent volume claims on a node without a ready NodeAgent
+type UnprotectedPod struct {
+	// namespace of the pod
+	Namespace string `json:"namespace"`
+	// name of the pod
+	Name string `json:"name"`
+	// workload controlling the pod, as kind/name
+	// +optional
+	Workload string `json:"workload,omitempty"`
+	// node the pod runs on
+	Node string `json:"node"`
+	// persistentVolumeClaims mounted by the pod
+	PersistentVolumeClaims []string `json:"persistentVolumeClaims"`
+}
+
+// CredentialsRequestStatus describes a generated CredentialsRequest
+type CredentialsRequestStatus struct {
+	// name of the CredentialsRequest in the DataProtectionApplication namespace
+	Name string `json:"name"`
+	// provider the CredentialsRequest grants permissions on
+	Provider string `json:"provider"`
+	// secret the Cloud Credential Operator or ccoctl writes the credentials to
+	Secret string `json:"secret"`
+}
+
+// CredentialSource is where the Velero plugin of a location reads its credentials from
+// +kubebuilder:validation:Enum=DefaultFile;LocationCredential;MountedFile;CredentialsFile;ExternalCredential;STS;Environment
+type CredentialSource string
+
+const (
+	// CredentialSourceDefaultFile is the default credentials secret of the provider, mounted for every location of the provider
+	CredentialSourceDefaultFile CredentialSource = "DefaultFile"
+	// CredentialSourceLocationCredential is the credential secret of the location, read by Velero at runtime
+	CredentialSourceLocationCredential CredentialSource = "LocationCredential"
+	// CredentialSourceMountedFile is the credential secret of the location, mounted for this location only with mountCredential
+	CredentialSourceMountedFile CredentialSource = "MountedFile"
+	// CredentialSourceCredentialsFile is the file named by the credentialsFile config of the location
+	CredentialSourceCredentialsFile CredentialSource = "CredentialsFile"
+	// CredentialSourceExternalCredential is the file mounted from an external secret store
+	CredentialSourceExternalCredential CredentialSource = "ExternalCredential"
+	// CredentialSourceSTS is a secret holding short-lived token credentials, such as a role assumed with the
+	// Velero service account token
+	CredentialSourceSTS CredentialSource = "STS"
+	// CredentialSourceEnvironment means no credentials are configured, the plugin uses the ambient credentials of the pod
+	CredentialSourceEnvironment CredentialSource = "Environment"
+)
+
+// LocationCredentialStatus describes the credentials resolved for a backup or snapshot location
+type LocationCredentialStatus struct {
+	// kind of the location: BackupStorageLocation or VolumeSnapshotLocation
+	Kind string `json:"kind"`
+	// name of the location
+	Name string `json:"name"`
+	// provider of the location
+	// +optional
+	Provider string `json:"provider,omitempty"`
+	// source of the credentials used by the location
+	Source CredentialSource `json:"source"`
+	// secret holding the credentials, as name/key
+	// +optional
+	Secret string `json:"secret,omitempty"`
+	// file is the path of the credentials file in the Velero pod, when the credentials are mounted
+	// +optional
+	File string `json:"file,omitempty"`
+}
+
+// CloudIdentityStatus describes the secret generated for a cloud identity
+type CloudIdentityStatus struct {
+	// name of the identity and of the generated secret
+	Name string `json:"name"`
+	// provider of the identity: aws, gcp or azure
+	Provider string `json:"provider"`
+	// key of the generated secret holding the credentials
+	Key string `json:"key"`
 }
 
 //+kubebuilder:object:root=true
@@ -34,6 +34,11 @@ type DataProtectionTestSpec struct {
 	// +optional
 	BackupLocationSpec *velerov1.BackupStorageLocationSpec `json:"backupLocationSpec,omitempty"`
 
+	// backupLocationSelector selects multiple BSLs in the DPT namespace to test in parallel.
+	// Mutually exclusive with backupLocationName and backupLocationSpec.
+	// +optional
+	BackupLocationSelector *BackupLocationSelector `json:"backupLocationSelector,omitempty"`
+
 	// uploadSpeedTestConfig specifies parameters for an object storage upload speed test.
 	// +optional
 	UploadSpeedTestConfig *UploadSpeedTestConfig `json:"uploadSpeedTestConfig,omitempty"`
@@ -42,6 +47,11 @@ type DataProtectionTestSpec struct {
 	// +optional
 	CSIVolumeSnapshotTestConfigs []CSIVolumeSnapshotTestConfig `json:"csiVolumeSnapshotTestConfigs,omitempty"`
 
+	// volumeSnapshotLocationTestConfig tests provider-native snapshots (AWS EBS, GCE PD, Azure Disk)
+	// with the credential and config of a VolumeSnapshotLocation.
+	// +optional
+	VolumeSnapshotLocationTestConfig *VolumeSnapshotLocationTestConfig `json:"volumeSnapshotLocationTestConfig,omitempty"`
+
 	// forceRun will re-trigger the DPT even if it already completed
 	// +kubebuilder:default=false
 	// +optional
@@ -51,6 +61,44 @@ type DataProtectionTestSpec struct {
 	// +kubebuilder:default=false
 	// +optional
 	SkipTLSVerify bool `json:"skipTLSVerify,omitempty"`
+
+	// thresholds defines pass/fail criteria for the test results.
+	// The phase becomes Failed, with the violations listed in status.failureReasons, when any threshold is not met.
+	// +optional
+	Thresholds *TestThresholds `json:"thresholds,omitempty"`
+
+	// reportExport writes a versioned JSON report of each completed run to a ConfigMap or the BSL bucket.
+	// +optional
+	ReportExport *ReportExportConfig `json:"reportExport,omitempty"`
+}
+
+// ReportExportConfig configures where the JSON report of a DPT run is written.
+type ReportExportConfig struct {
+	// destination of the report. ConfigMap writes the latest report to the "report.json" key of the
+	// ConfigMap "dpt-report-<dpt name>" in the DPT namespace. Bucket writes every report to the tested
+	// BSL bucket under the "dpt-reports/" prefix; the BSL must use an objectStorage prefix so that the
+	// report directory stays outside of the Velero backup store.
+	// +kubebuilder:validation:Enum=ConfigMap;Bucket
+	// +kubebuilder:default=ConfigMap
+	// +optional
+	Destination string `json:"destination,omitempty"`
+}
+
+// BackupLocationSelector selects the BackupStorageLocations tested by a single DPT.
+type BackupLocationSelector struct {
+	// all selects every BSL in the DPT namespace.
+	// +optional
+	All bool `json:"all,omitempty"`
+
+	// labelSelector selects BSLs in the DPT namespace by label.
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// maxConcurrency is the maximum number of BSLs tested at the same time.
+	// +kubebuilder:default=3
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxConcurrency int `json:"maxConcurrency,omitempty"`
 }
 
 // UploadSpeedTestConfig contains configuration for testing object storage upload performance.
@@ -62,6 +110,36 @@ type UploadSpeedTestConfig struct {
 	// timeout defines the maximum duration for the upload test, e.g., "60s".
 	// +optional
 	Timeout metav1.Duration `json:"timeout,omitempty"`
+
+	// downloadTest additionally measures the download speed of an object of fileSize.
+	// Enabled automatically when thresholds.minDownloadSpeedMbps is set.
+	// +optional
+	DownloadTest bool `json:"downloadTest,omitempty"`
+}
+
+// TestThresholds contains the criteria a DPT run must meet to be Complete.
+type TestThresholds struct {
+	// minUploadSpeedMbps is the minimum acceptable upload speed. Requires uploadSpeedTestConfig.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinUploadSpeedMbps int64 `json:"minUploadSpeedMbps,omitempty"`
+
+	// minDownloadSpeedMbps is the minimum acceptable download speed. Requires uploadSpeedTestConfig.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinDownloadSpeedMbps int64 `json:"minDownloadSpeedMbps,omitempty"`
+
+	// maxSnapshotReadyDuration is the maximum time each CSI VolumeSnapshot may take to become ReadyToUse, e.g., "2m".
+	// +optional
+	MaxSnapshotReadyDuration *metav1.Duration `json:"maxSnapshotReadyDuration,omitempty"`
+
+	// requiredEncryptionAlgorithm is the bucket encryption algorithm that must be reported, e.g., "AES256" or "aws:kms".
+	// +optional
+	RequiredEncryptionAlgorithm string `json:"requiredEncryptionAlgorithm,omitempty"`
+
+	// requireVersioning requires bucket versioning to be Enabled.
+	// +optional
+	RequireVersioning bool `json:"requireVersioning,omitempty"`
 }
 
 // CSIVolumeSnapshotTestConfig contains config for performing a CSI VolumeSnapshot test.
@@ -79,6 +157,33 @@ type CSIVolumeSnapshotTestConfig struct {
 	VolumeSnapshotSource VolumeSnapshotSource `json:"volumeSnapshotSource,omitempty"`
 }
 
+// VolumeSnapshotLocationTestConfig contains config for performing a native cloud snapshot test.
+type VolumeSnapshotLocationTestConfig struct {
+	// volumeSnapshotLocationName is the name of the VolumeSnapshotLocation in the DPT namespace.
+	// +kubebuilder:validation:Required
+	VolumeSnapshotLocationName string `json:"volumeSnapshotLocationName"`
+
+	// volumeID of an existing volume to snapshot. If empty, a scratch disk is created and deleted after the test.
+	// On GCP this is the disk name, on Azure the disk name or resource ID.
+	// +optional
+	VolumeID string `json:"volumeID,omitempty"`
+
+	// availabilityZone of the scratch disk, e.g., "us-east-1a" or "us-central1-a".
+	// Required on AWS and GCP when volumeID is empty, and on GCP to locate volumeID.
+	// +optional
+	AvailabilityZone string `json:"availabilityZone,omitempty"`
+
+	// scratchDiskSizeGB is the size of the scratch disk.
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ScratchDiskSizeGB int64 `json:"scratchDiskSizeGB,omitempty"`
+
+	// timeout specifies how long to wait for the snapshot to complete, e.g., "10m"
+	// +optional
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
 // VolumeSnapshotSource points to the PVC that should be snapshotted.
 type VolumeSnapshotSource struct {
 	// persistentVolumeClaimName is the name of the PVC to snapshot.
@@ -104,10 +209,36 @@ type DataProtectionTestStatus struct {
 	// +optional
 	BucketMetadata *BucketMetadata `json:"bucketMetadata,omitempty"`
 
+	// endpointLatency is the round-trip time of the vendor detection request to the storage endpoint.
+	// +optional
+	EndpointLatency string `json:"endpointLatency,omitempty"`
+
+	// s3Capabilities reports the optional S3 features supported by the endpoint and bucket.
+	// Only populated for aws-compatible BSLs when an upload test is configured.
+	// +optional
+	S3Capabilities *S3Capabilities `json:"s3Capabilities,omitempty"`
+
+	// tokenExchange reports the exchange of a federated service account token for cloud credentials,
+	// such as GCP workload identity federation. Failures are reported here rather than as bucket errors.
+	// +optional
+	TokenExchange *TokenExchangeStatus `json:"tokenExchange,omitempty"`
+
 	// uploadTest contains results of the object storage upload test.
 	// +optional
 	UploadTest UploadTestStatus `json:"uploadTest,omitempty"`
 
+	// downloadTest contains results of the object storage download test.
+	// +optional
+	DownloadTest *DownloadTestStatus `json:"downloadTest,omitempty"`
+
+	// backupLocationResults contains the results for each BSL selected by backupLocationSelector.
+	// +optional
+	BackupLocationResults []BackupLocationTestResult `json:"backupLocationResults,omitempty"`
+
+	// backupLocationSummary is the pass/fail summary of the BSLs selected by backupLocationSelector.
+	// +optional
+	BackupLocationSummary string `json:"backupLocationSummary,omitempty"`
+
 	// snapshotTests contains results for each snapshot tested PVC.
 	// +optional
 	SnapshotTests []SnapshotTestStatus `json:"snapshotTests,omitempty"`
@@ -116,6 +247,10 @@ type DataProtectionTestStatus struct {
 	// +optional
 	SnapshotSummary string `json:"snapshotSummary,omitempty"`
 
+	// volumeSnapshotLocationTest contains the result of the native cloud snapshot test.
+	// +optional
+	VolumeSnapshotLocationTest *VolumeSnapshotLocationTestStatus `json:"volumeSnapshotLocationTest,omitempty"`
+
 	// phase indicates phase of the DataProtectionTest - Complete, Failed
 	// +optional
 	Phase string `json:"phase,omitempty"`
@@ -123,6 +258,44 @@ type DataProtectionTestStatus struct {
 	// errorMessage contains details of any DPT failure
 	// +optional
 	ErrorMessage string `json:"errorMessage,omitempty"`
+
+	// failureReasons lists the thresholds violated by the last run.
+	// +optional
+	FailureReasons []string `json:"failureReasons,omitempty"`
+
+	// report describes where the JSON report of the last run was exported.
+	// +optional
+	Report *ReportExportStatus `json:"report,omitempty"`
+}
+
+// ReportExportStatus reports the result of exporting the JSON report.
+type ReportExportStatus struct {
+	// schemaVersion of the exported report.
+	// +optional
+	SchemaVersion string `json:"schemaVersion,omitempty"`
+
+	// location of the exported report, either "ConfigMap/<name>" or "<bucket>/<key>".
+	// +optional
+	Location string `json:"location,omitempty"`
+
+	// errorMessage contains details of any failure exporting the report.
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// TokenExchangeStatus holds the result of exchanging a federated token for cloud credentials.
+type TokenExchangeStatus struct {
+	// credentialType is the type of the exchanged credentials (e.g., external_account).
+	// +optional
+	CredentialType string `json:"credentialType,omitempty"`
+
+	// success indicates if the token exchange succeeded.
+	// +optional
+	Success bool `json:"success,omitempty"`
+
+	// errorMessage contains details of any token exchange failure.
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
 }
 
 // UploadTestStatus holds the results of the upload test.
@@ -144,6 +317,67 @@ type UploadTestStatus struct {
 	ErrorMessage string `json:"errorMessage,omitempty"`
 }
 
+// DownloadTestStatus holds the results of the download test.
+type DownloadTestStatus struct {
+	// speedMbps is the calculated download speed.
+	// +optional
+	SpeedMbps int64 `json:"speedMbps,omitempty"`
+
+	// duration is the time taken to download the test file.
+	// +optional
+	Duration string `json:"duration,omitempty"`
+
+	// success indicates if the download succeeded.
+	// +optional
+	Success bool `json:"success,omitempty"`
+
+	// errorMessage contains details of any download failure.
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// BackupLocationTestResult holds the results for an individual BSL tested through backupLocationSelector.
+type BackupLocationTestResult struct {
+	// name of the tested BSL.
+	Name string `json:"name"`
+
+	// provider of the tested BSL.
+	// +optional
+	Provider string `json:"provider,omitempty"`
+
+	// status indicates whether the BSL tests passed ("Passed", "Failed").
+	// +optional
+	Status string `json:"status,omitempty"`
+
+	// s3Vendor indicates the detected s3 vendor name if applicable.
+	// +optional
+	S3Vendor string `json:"s3Vendor,omitempty"`
+
+	// tokenExchange reports the exchange of a federated service account token for the BSL credentials.
+	// +optional
+	TokenExchange *TokenExchangeStatus `json:"tokenExchange,omitempty"`
+
+	// uploadTest contains results of the object storage upload test.
+	// +optional
+	UploadTest *UploadTestStatus `json:"uploadTest,omitempty"`
+
+	// downloadTest contains results of the object storage download test.
+	// +optional
+	DownloadTest *DownloadTestStatus `json:"downloadTest,omitempty"`
+
+	// bucketMetadata reports the encryption and versioning status of the BSL bucket.
+	// +optional
+	BucketMetadata *BucketMetadata `json:"bucketMetadata,omitempty"`
+
+	// s3Capabilities reports the optional S3 features supported by the BSL endpoint.
+	// +optional
+	S3Capabilities *S3Capabilities `json:"s3Capabilities,omitempty"`
+
+	// errorMessage contains details of any failure testing the BSL.
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
 // SnapshotTestStatus holds the result for an individual PVC snapshot test.
 type SnapshotTestStatus struct {
 	// persistentVolumeClaimName of the tested PVC.
@@ -167,6 +401,71 @@ type SnapshotTestStatus struct {
 	ErrorMessage string `json:"errorMessage,omitempty"`
 }
 
+// VolumeSnapshotLocationTestStatus holds the result of a native cloud snapshot test.
+type VolumeSnapshotLocationTestStatus struct {
+	// name of the tested VolumeSnapshotLocation.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// provider of the tested VolumeSnapshotLocation.
+	// +optional
+	Provider string `json:"provider,omitempty"`
+
+	// region the test ran in (AWS region, GCP project, or Azure resource group).
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// status indicates the test result ("Passed", "Failed").
+	// +optional
+	Status string `json:"status,omitempty"`
+
+	// volumesFound is the number of volumes returned by the list operation (first page only).
+	// +optional
+	VolumesFound int `json:"volumesFound,omitempty"`
+
+	// volumeID of the snapshotted volume.
+	// +optional
+	VolumeID string `json:"volumeID,omitempty"`
+
+	// scratchVolume is true when the volume was created for the test.
+	// +optional
+	ScratchVolume bool `json:"scratchVolume,omitempty"`
+
+	// snapshotID of the test snapshot.
+	// +optional
+	SnapshotID string `json:"snapshotID,omitempty"`
+
+	// snapshotReadyDuration is the time from creating the snapshot until it was complete.
+	// +optional
+	SnapshotReadyDuration string `json:"snapshotReadyDuration,omitempty"`
+
+	// operations lists each cloud API operation with its permission result and timing.
+	// +optional
+	Operations []SnapshotOperationStatus `json:"operations,omitempty"`
+
+	// errorMessage contains details of any test failure.
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// SnapshotOperationStatus holds the result of a single cloud API operation.
+type SnapshotOperationStatus struct {
+	// operation name, e.g., "ListVolumes", "CreateSnapshot".
+	Operation string `json:"operation"`
+
+	// permission is "Allowed", "Denied", or "Error" when the operation failed for another reason.
+	// +optional
+	Permission string `json:"permission,omitempty"`
+
+	// duration of the operation.
+	// +optional
+	Duration string `json:"duration,omitempty"`
+
+	// errorMessage contains details of any failure.
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
 // BucketMetadata contains encryption and versioning info for the target bucket.
 type BucketMetadata struct {
 	// encryptionAlgorithm reports the encryption method (AES256, aws:kms, or "None").
@@ -182,6 +481,52 @@ type BucketMetadata struct {
 	ErrorMessage string `json:"errorMessage,omitempty"`
 }
 
+// S3Capabilities contains the results of probing an S3-compatible endpoint for optional features.
+type S3Capabilities struct {
+	// checksumAlgorithms lists the flexible checksum algorithms (CRC32, CRC32C, SHA1, SHA256) the endpoint stored and returned.
+	// +optional
+	ChecksumAlgorithms []string `json:"checksumAlgorithms,omitempty"`
+
+	// objectLock reports whether Object Lock is Enabled, Disabled, or Unsupported on the bucket.
+	// +optional
+	ObjectLock string `json:"objectLock,omitempty"`
+
+	// versioning reports whether bucket versioning is Enabled, Suspended, None, or Unsupported.
+	// +optional
+	Versioning string `json:"versioning,omitempty"`
+
+	// conditionalWrites indicates whether the endpoint rejects a PutObject with "If-None-Match: *" for an existing key.
+	// +optional
+	ConditionalWrites *bool `json:"conditionalWrites,omitempty"`
+
+	// multipartLimits reports the documented multipart upload limits for the detected vendor.
+	// +optional
+	MultipartLimits *MultipartLimits `json:"multipartLimits,omitempty"`
+
+	// recommendedConfig contains BSL config values that are safe to use with this endpoint, e.g. checksumAlgorithm.
+	// +optional
+	RecommendedConfig map[string]string `json:"recommendedConfig,omitempty"`
+
+	// errorMessage contains details of any failure while probing capabilities.
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// MultipartLimits describes multipart upload limits of an S3-compatible vendor.
+type MultipartLimits struct {
+	// maxParts is the maximum number of parts in a single multipart upload.
+	// +optional
+	MaxParts int64 `json:"maxParts,omitempty"`
+
+	// minPartSizeBytes is the minimum size of every part except the last one.
+	// +optional
+	MinPartSizeBytes int64 `json:"minPartSizeBytes,omitempty"`
+
+	// maxPartSizeBytes is the maximum size of a single part.
+	// +optional
+	MaxPartSizeBytes int64 `json:"maxPartSizeBytes,omitempty"`
+}
+
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=".status.phase",description="Current phase of the DPT"
 // +kubebuilder:printcolumn:name="LastTested",type=date,JSONPath=".status.lastTested",description="Last time the test was executed"
 // +kubebuilder:printcolumn:name="UploadSpeed(Mbps)",type=integer,JSONPath=".status.uploadTest.speedMbps",description="Upload speed to object storage"
@@ -0,0 +1,217 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConditionVerified is true when the last restore verification succeeded
+	ConditionVerified = "Verified"
+	// VerifiedReasonSucceeded is the reason of the Verified condition when the last verification succeeded
+	VerifiedReasonSucceeded = "VerificationSucceeded"
+	// VerifiedReasonFailed is the reason of the Verified condition when the last verification failed
+	VerifiedReasonFailed = "VerificationFailed"
+	// VerifiedReasonInvalidSpec is the reason of the Verified condition when the spec is invalid
+	VerifiedReasonInvalidSpec = "InvalidSpec"
+)
+
+// RestoreVerificationPhase is the step of the current verification run
+// +kubebuilder:validation:Enum=Restoring;WaitingForWorkloads;RunningChecks;CleaningUp
+type RestoreVerificationPhase string
+
+const (
+	// RestoreVerificationPhaseRestoring waits for the Velero Restore into the scratch namespaces
+	RestoreVerificationPhaseRestoring RestoreVerificationPhase = "Restoring"
+	// RestoreVerificationPhaseWaitingForWorkloads waits for the restored workloads to be ready
+	RestoreVerificationPhaseWaitingForWorkloads RestoreVerificationPhase = "WaitingForWorkloads"
+	// RestoreVerificationPhaseRunningChecks waits for the check pods to complete
+	RestoreVerificationPhaseRunningChecks RestoreVerificationPhase = "RunningChecks"
+	// RestoreVerificationPhaseCleaningUp waits for the deletion of the scratch namespaces
+	RestoreVerificationPhaseCleaningUp RestoreVerificationPhase = "CleaningUp"
+)
+
+// RestoreVerificationResult is the result of a verification run or of a check
+// +kubebuilder:validation:Enum=Succeeded;Failed
+type RestoreVerificationResult string
+
+const (
+	RestoreVerificationResultSucceeded RestoreVerificationResult = "Succeeded"
+	RestoreVerificationResultFailed    RestoreVerificationResult = "Failed"
+)
+
+// RestoreVerificationSpec defines the backups to verify and how
+type RestoreVerificationSpec struct {
+	// scheduleName is the Velero Schedule, in the namespace of the RestoreVerification, whose latest completed
+	// backup is verified
+	// +kubebuilder:validation:MinLength=1
+	ScheduleName string `json:"scheduleName"`
+	// schedule is the cron expression of the verifications, for instance "0 6 * * *". When unset, each new completed
+	// backup of the Schedule is verified.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+	// includedNamespaces are the namespaces of the backup restored. Defaults to the included namespaces of the backup,
+	// required when the backup includes namespaces by wildcard or includes all namespaces. They must be included in the
+	// backup.
+	// +optional
+	IncludedNamespaces []string `json:"includedNamespaces,omitempty"`
+	// readinessTimeout is how long the restored Deployments, StatefulSets and DaemonSets have to be ready once the
+	// restore completed
+	// +kubebuilder:default="10m"
+	// +optional
+	ReadinessTimeout metav1.Duration `json:"readinessTimeout,omitempty"`
+	// checkTimeout is how long the check pods have to complete
+	// +kubebuilder:default="10m"
+	// +optional
+	CheckTimeout metav1.Duration `json:"checkTimeout,omitempty"`
+	// checks are pods run in the scratch namespaces once the workloads are ready, a check succeeds when its pod
+	// succeeds
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Checks []RestoreVerificationCheck `json:"checks,omitempty"`
+	// keepOnFailure keeps the scratch namespaces of a failed verification for troubleshooting, until the next
+	// verification
+	// +optional
+	KeepOnFailure bool `json:"keepOnFailure,omitempty"`
+}
+
+// RestoreVerificationCheck is a pod checking the restored workloads, for instance querying a restored database
+type RestoreVerificationCheck struct {
+	// name of the check
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=40
+	Name string `json:"name"`
+	// namespace is the namespace of the backup whose scratch namespace runs the check. Defaults to the first
+	// restored namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// image of the check container
+	Image string `json:"image"`
+	// command of the check container
+	// +optional
+	Command []string `json:"command,omitempty"`
+	// args of the check container
+	// +optional
+	Args []string `json:"args,omitempty"`
+	// env of the check container, besides SOURCE_NAMESPACE and SCRATCH_NAMESPACE
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+}
+
+// RestoreVerificationStatus reports the current and last verification runs
+type RestoreVerificationStatus struct {
+	// conditions of the verification, Verified is true when the last verification succeeded
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// phase is the step of the current verification run, unset between runs
+	// +optional
+	Phase RestoreVerificationPhase `json:"phase,omitempty"`
+	// currentRun is the verification in progress
+	// +optional
+	CurrentRun *RestoreVerificationRun `json:"currentRun,omitempty"`
+	// lastRun is the last completed verification
+	// +optional
+	LastRun *RestoreVerificationRun `json:"lastRun,omitempty"`
+	// lastSuccessfulRunTime is when the last successful verification completed
+	// +optional
+	LastSuccessfulRunTime *metav1.Time `json:"lastSuccessfulRunTime,omitempty"`
+	// nextRunTime is when the next verification starts, unset when each new backup is verified
+	// +optional
+	NextRunTime *metav1.Time `json:"nextRunTime,omitempty"`
+}
+
+// RestoreVerificationRun is a verification of a backup
+type RestoreVerificationRun struct {
+	// backup is the verified backup
+	// +optional
+	Backup string `json:"backup,omitempty"`
+	// restore is the Velero Restore of the backup into the scratch namespaces
+	// +optional
+	Restore string `json:"restore,omitempty"`
+	// namespaceMapping maps the namespaces of the backup to their scratch namespaces
+	// +optional
+	NamespaceMapping map[string]string `json:"namespaceMapping,omitempty"`
+	// startTime is when the verification started
+	StartTime metav1.Time `json:"startTime"`
+	// restoreCompletionTime is when the restore completed
+	// +optional
+	RestoreCompletionTime *metav1.Time `json:"restoreCompletionTime,omitempty"`
+	// workloadsReadyTime is when the restored workloads were ready
+	// +optional
+	WorkloadsReadyTime *metav1.Time `json:"workloadsReadyTime,omitempty"`
+	// completionTime is when the result was recorded
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// result of the verification
+	// +optional
+	Result RestoreVerificationResult `json:"result,omitempty"`
+	// message explains the result
+	// +optional
+	Message string `json:"message,omitempty"`
+	// checks reports the check pods
+	// +optional
+	Checks []RestoreVerificationCheckStatus `json:"checks,omitempty"`
+}
+
+// RestoreVerificationCheckStatus is the result of a check pod
+type RestoreVerificationCheckStatus struct {
+	// name of the check
+	Name string `json:"name"`
+	// pod is the namespace and name of the check pod
+	Pod string `json:"pod"`
+	// result of the check, unset while the pod runs
+	// +optional
+	Result RestoreVerificationResult `json:"result,omitempty"`
+	// message is the termination message or reason of a failed check
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=".spec.scheduleName"
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="LastResult",type=string,JSONPath=".status.lastRun.result"
+// +kubebuilder:printcolumn:name="LastBackup",type=string,JSONPath=".status.lastRun.backup"
+// +kubebuilder:printcolumn:name="LastSuccess",type=date,JSONPath=".status.lastSuccessfulRunTime"
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=restoreverifications,shortName=rv
+
+// RestoreVerification periodically restores the latest backup of a Velero Schedule into scratch namespaces, waits
+// for the restored workloads, runs check pods, records the result and deletes the scratch namespaces
+type RestoreVerification struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RestoreVerificationSpec   `json:"spec,omitempty"`
+	Status RestoreVerificationStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// RestoreVerificationList contains a list of RestoreVerification
+type RestoreVerificationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RestoreVerification `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RestoreVerification{}, &RestoreVerificationList{})
+}
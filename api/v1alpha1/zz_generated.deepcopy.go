@@ -29,6 +29,41 @@ import (
 	timex "time"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSCloudIdentity) DeepCopyInto(out *AWSCloudIdentity) {
+	*out = *in
+	if in.RolesAnywhere != nil {
+		in, out := &in.RolesAnywhere, &out.RolesAnywhere
+		*out = new(AWSRolesAnywhere)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSCloudIdentity.
+func (in *AWSCloudIdentity) DeepCopy() *AWSCloudIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSCloudIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSRolesAnywhere) DeepCopyInto(out *AWSRolesAnywhere) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSRolesAnywhere.
+func (in *AWSRolesAnywhere) DeepCopy() *AWSRolesAnywhere {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSRolesAnywhere)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApplicationConfig) DeepCopyInto(out *ApplicationConfig) {
 	*out = *in
@@ -66,6 +101,152 @@ func (in *ApplicationConfig) DeepCopy() *ApplicationConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureCloudIdentity) DeepCopyInto(out *AzureCloudIdentity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureCloudIdentity.
+func (in *AzureCloudIdentity) DeepCopy() *AzureCloudIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureCloudIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupComplianceReport) DeepCopyInto(out *BackupComplianceReport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupComplianceReport.
+func (in *BackupComplianceReport) DeepCopy() *BackupComplianceReport {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupComplianceReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BackupComplianceReport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupComplianceReportList) DeepCopyInto(out *BackupComplianceReportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BackupComplianceReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupComplianceReportList.
+func (in *BackupComplianceReportList) DeepCopy() *BackupComplianceReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupComplianceReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BackupComplianceReportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupComplianceReportSpec) DeepCopyInto(out *BackupComplianceReportSpec) {
+	*out = *in
+	out.RPO = in.RPO
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExcludedNamespaces != nil {
+		in, out := &in.ExcludedNamespaces, &out.ExcludedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.EvaluationInterval = in.EvaluationInterval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupComplianceReportSpec.
+func (in *BackupComplianceReportSpec) DeepCopy() *BackupComplianceReportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupComplianceReportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupComplianceReportStatus) DeepCopyInto(out *BackupComplianceReportStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastEvaluationTime != nil {
+		in, out := &in.LastEvaluationTime, &out.LastEvaluationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.BreachedNamespaces != nil {
+		in, out := &in.BreachedNamespaces, &out.BreachedNamespaces
+		*out = make([]NamespaceComplianceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.UncoveredNamespaces != nil {
+		in, out := &in.UncoveredNamespaces, &out.UncoveredNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]NamespaceGroupComplianceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupComplianceReportStatus.
+func (in *BackupComplianceReportStatus) DeepCopy() *BackupComplianceReportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupComplianceReportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupLocation) DeepCopyInto(out *BackupLocation) {
 	*out = *in
@@ -79,6 +260,11 @@ func (in *BackupLocation) DeepCopyInto(out *BackupLocation) {
 		*out = new(CloudStorageLocation)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ExternalCredential != nil {
+		in, out := &in.ExternalCredential, &out.ExternalCredential
+		*out = new(ExternalCredential)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupLocation.
@@ -91,6 +277,66 @@ func (in *BackupLocation) DeepCopy() *BackupLocation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupLocationSelector) DeepCopyInto(out *BackupLocationSelector) {
+	*out = *in
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupLocationSelector.
+func (in *BackupLocationSelector) DeepCopy() *BackupLocationSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupLocationSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupLocationTestResult) DeepCopyInto(out *BackupLocationTestResult) {
+	*out = *in
+	if in.TokenExchange != nil {
+		in, out := &in.TokenExchange, &out.TokenExchange
+		*out = new(TokenExchangeStatus)
+		**out = **in
+	}
+	if in.UploadTest != nil {
+		in, out := &in.UploadTest, &out.UploadTest
+		*out = new(UploadTestStatus)
+		**out = **in
+	}
+	if in.DownloadTest != nil {
+		in, out := &in.DownloadTest, &out.DownloadTest
+		*out = new(DownloadTestStatus)
+		**out = **in
+	}
+	if in.BucketMetadata != nil {
+		in, out := &in.BucketMetadata, &out.BucketMetadata
+		*out = new(BucketMetadata)
+		**out = **in
+	}
+	if in.S3Capabilities != nil {
+		in, out := &in.S3Capabilities, &out.S3Capabilities
+		*out = new(S3Capabilities)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupLocationTestResult.
+func (in *BackupLocationTestResult) DeepCopy() *BackupLocationTestResult {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupLocationTestResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BucketMetadata) DeepCopyInto(out *BucketMetadata) {
 	*out = *in
@@ -123,6 +369,51 @@ func (in *CSIVolumeSnapshotTestConfig) DeepCopy() *CSIVolumeSnapshotTestConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudIdentity) DeepCopyInto(out *CloudIdentity) {
+	*out = *in
+	if in.AWS != nil {
+		in, out := &in.AWS, &out.AWS
+		*out = new(AWSCloudIdentity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GCP != nil {
+		in, out := &in.GCP, &out.GCP
+		*out = new(GCPCloudIdentity)
+		**out = **in
+	}
+	if in.Azure != nil {
+		in, out := &in.Azure, &out.Azure
+		*out = new(AzureCloudIdentity)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudIdentity.
+func (in *CloudIdentity) DeepCopy() *CloudIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudIdentityStatus) DeepCopyInto(out *CloudIdentityStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudIdentityStatus.
+func (in *CloudIdentityStatus) DeepCopy() *CloudIdentityStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudIdentityStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloudStorage) DeepCopyInto(out *CloudStorage) {
 	*out = *in
@@ -281,6 +572,61 @@ func (in *CloudStorageStatus) DeepCopy() *CloudStorageStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialHealthCheck) DeepCopyInto(out *CredentialHealthCheck) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ExpiryWarningPeriod != nil {
+		in, out := &in.ExpiryWarningPeriod, &out.ExpiryWarningPeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialHealthCheck.
+func (in *CredentialHealthCheck) DeepCopy() *CredentialHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsRequestStatus) DeepCopyInto(out *CredentialsRequestStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsRequestStatus.
+func (in *CredentialsRequestStatus) DeepCopy() *CredentialsRequestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsRequestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsRequests) DeepCopyInto(out *CredentialsRequests) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsRequests.
+func (in *CredentialsRequests) DeepCopy() *CredentialsRequests {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsRequests)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CustomPlugin) DeepCopyInto(out *CustomPlugin) {
 	*out = *in
@@ -464,6 +810,40 @@ func (in *DataProtectionApplicationSpec) DeepCopyInto(out *DataProtectionApplica
 		*out = new(NonAdmin)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.CredentialHealthCheck != nil {
+		in, out := &in.CredentialHealthCheck, &out.CredentialHealthCheck
+		*out = new(CredentialHealthCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CloudIdentities != nil {
+		in, out := &in.CloudIdentities, &out.CloudIdentities
+		*out = make([]CloudIdentity, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CredentialsRequests != nil {
+		in, out := &in.CredentialsRequests, &out.CredentialsRequests
+		*out = new(CredentialsRequests)
+		**out = **in
+	}
+	if in.ResourceRecommendations != nil {
+		in, out := &in.ResourceRecommendations, &out.ResourceRecommendations
+		*out = new(ResourceRecommendations)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(NetworkPolicyConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SchedulePolicies != nil {
+		in, out := &in.SchedulePolicies, &out.SchedulePolicies
+		*out = make([]SchedulePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataProtectionApplicationSpec.
@@ -486,10 +866,53 @@ func (in *DataProtectionApplicationStatus) DeepCopyInto(out *DataProtectionAppli
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataProtectionApplicationStatus.
-func (in *DataProtectionApplicationStatus) DeepCopy() *DataProtectionApplicationStatus {
+	if in.LastCredentialHealthCheck != nil {
+		in, out := &in.LastCredentialHealthCheck, &out.LastCredentialHealthCheck
+		*out = (*in).DeepCopy()
+	}
+	if in.CloudIdentities != nil {
+		in, out := &in.CloudIdentities, &out.CloudIdentities
+		*out = make([]CloudIdentityStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.LocationCredentials != nil {
+		in, out := &in.LocationCredentials, &out.LocationCredentials
+		*out = make([]LocationCredentialStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.CredentialsRequests != nil {
+		in, out := &in.CredentialsRequests, &out.CredentialsRequests
+		*out = make([]CredentialsRequestStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeAgent != nil {
+		in, out := &in.NodeAgent, &out.NodeAgent
+		*out = new(NodeAgentStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeAgentMaintenanceWindow != nil {
+		in, out := &in.NodeAgentMaintenanceWindow, &out.NodeAgentMaintenanceWindow
+		*out = new(NodeAgentMaintenanceWindowStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResourceRecommendations != nil {
+		in, out := &in.ResourceRecommendations, &out.ResourceRecommendations
+		*out = make([]ResourceRecommendationStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SchedulePolicies != nil {
+		in, out := &in.SchedulePolicies, &out.SchedulePolicies
+		*out = make([]SchedulePolicyStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataProtectionApplicationStatus.
+func (in *DataProtectionApplicationStatus) DeepCopy() *DataProtectionApplicationStatus {
 	if in == nil {
 		return nil
 	}
@@ -565,6 +988,11 @@ func (in *DataProtectionTestSpec) DeepCopyInto(out *DataProtectionTestSpec) {
 		*out = new(velerov1.BackupStorageLocationSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.BackupLocationSelector != nil {
+		in, out := &in.BackupLocationSelector, &out.BackupLocationSelector
+		*out = new(BackupLocationSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.UploadSpeedTestConfig != nil {
 		in, out := &in.UploadSpeedTestConfig, &out.UploadSpeedTestConfig
 		*out = new(UploadSpeedTestConfig)
@@ -575,6 +1003,21 @@ func (in *DataProtectionTestSpec) DeepCopyInto(out *DataProtectionTestSpec) {
 		*out = make([]CSIVolumeSnapshotTestConfig, len(*in))
 		copy(*out, *in)
 	}
+	if in.VolumeSnapshotLocationTestConfig != nil {
+		in, out := &in.VolumeSnapshotLocationTestConfig, &out.VolumeSnapshotLocationTestConfig
+		*out = new(VolumeSnapshotLocationTestConfig)
+		**out = **in
+	}
+	if in.Thresholds != nil {
+		in, out := &in.Thresholds, &out.Thresholds
+		*out = new(TestThresholds)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReportExport != nil {
+		in, out := &in.ReportExport, &out.ReportExport
+		*out = new(ReportExportConfig)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataProtectionTestSpec.
@@ -596,12 +1039,49 @@ func (in *DataProtectionTestStatus) DeepCopyInto(out *DataProtectionTestStatus)
 		*out = new(BucketMetadata)
 		**out = **in
 	}
+	if in.S3Capabilities != nil {
+		in, out := &in.S3Capabilities, &out.S3Capabilities
+		*out = new(S3Capabilities)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TokenExchange != nil {
+		in, out := &in.TokenExchange, &out.TokenExchange
+		*out = new(TokenExchangeStatus)
+		**out = **in
+	}
 	out.UploadTest = in.UploadTest
+	if in.DownloadTest != nil {
+		in, out := &in.DownloadTest, &out.DownloadTest
+		*out = new(DownloadTestStatus)
+		**out = **in
+	}
+	if in.BackupLocationResults != nil {
+		in, out := &in.BackupLocationResults, &out.BackupLocationResults
+		*out = make([]BackupLocationTestResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.SnapshotTests != nil {
 		in, out := &in.SnapshotTests, &out.SnapshotTests
 		*out = make([]SnapshotTestStatus, len(*in))
 		copy(*out, *in)
 	}
+	if in.VolumeSnapshotLocationTest != nil {
+		in, out := &in.VolumeSnapshotLocationTest, &out.VolumeSnapshotLocationTest
+		*out = new(VolumeSnapshotLocationTestStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FailureReasons != nil {
+		in, out := &in.FailureReasons, &out.FailureReasons
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Report != nil {
+		in, out := &in.Report, &out.Report
+		*out = new(ReportExportStatus)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataProtectionTestStatus.
@@ -614,6 +1094,21 @@ func (in *DataProtectionTestStatus) DeepCopy() *DataProtectionTestStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DownloadTestStatus) DeepCopyInto(out *DownloadTestStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DownloadTestStatus.
+func (in *DownloadTestStatus) DeepCopy() *DownloadTestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DownloadTestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnforceBackupStorageLocationSpec) DeepCopyInto(out *EnforceBackupStorageLocationSpec) {
 	*out = *in
@@ -652,6 +1147,21 @@ func (in *EnforceBackupStorageLocationSpec) DeepCopy() *EnforceBackupStorageLoca
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalCredential) DeepCopyInto(out *ExternalCredential) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalCredential.
+func (in *ExternalCredential) DeepCopy() *ExternalCredential {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalCredential)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Features) DeepCopyInto(out *Features) {
 	*out = *in
@@ -672,6 +1182,21 @@ func (in *Features) DeepCopy() *Features {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCPCloudIdentity) DeepCopyInto(out *GCPCloudIdentity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPCloudIdentity.
+func (in *GCPCloudIdentity) DeepCopy() *GCPCloudIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(GCPCloudIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GlobalFlags) DeepCopyInto(out *GlobalFlags) {
 	*out = *in
@@ -751,6 +1276,21 @@ func (in *LoadConcurrency) DeepCopy() *LoadConcurrency {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocationCredentialStatus) DeepCopyInto(out *LocationCredentialStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocationCredentialStatus.
+func (in *LocationCredentialStatus) DeepCopy() *LocationCredentialStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LocationCredentialStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LoggingFlags) DeepCopyInto(out *LoggingFlags) {
 	*out = *in
@@ -811,6 +1351,79 @@ func (in *LoggingFlags) DeepCopy() *LoggingFlags {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MultipartLimits) DeepCopyInto(out *MultipartLimits) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultipartLimits.
+func (in *MultipartLimits) DeepCopy() *MultipartLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(MultipartLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceComplianceStatus) DeepCopyInto(out *NamespaceComplianceStatus) {
+	*out = *in
+	if in.LastSuccessfulBackupTime != nil {
+		in, out := &in.LastSuccessfulBackupTime, &out.LastSuccessfulBackupTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceComplianceStatus.
+func (in *NamespaceComplianceStatus) DeepCopy() *NamespaceComplianceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceComplianceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceGroupComplianceStatus) DeepCopyInto(out *NamespaceGroupComplianceStatus) {
+	*out = *in
+	if in.LastSuccessfulBackupTime != nil {
+		in, out := &in.LastSuccessfulBackupTime, &out.LastSuccessfulBackupTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceGroupComplianceStatus.
+func (in *NamespaceGroupComplianceStatus) DeepCopy() *NamespaceGroupComplianceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceGroupComplianceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicyConfig) DeepCopyInto(out *NetworkPolicyConfig) {
+	*out = *in
+	if in.ProviderCIDRs != nil {
+		in, out := &in.ProviderCIDRs, &out.ProviderCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicyConfig.
+func (in *NetworkPolicyConfig) DeepCopy() *NetworkPolicyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeAgentCommonFields) DeepCopyInto(out *NodeAgentCommonFields) {
 	*out = *in
@@ -856,6 +1469,13 @@ func (in *NodeAgentConfig) DeepCopyInto(out *NodeAgentConfig) {
 		**out = **in
 	}
 	in.NodeAgentConfigMapSettings.DeepCopyInto(&out.NodeAgentConfigMapSettings)
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]NodeAgentMaintenanceWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.KopiaRepoOptions.DeepCopyInto(&out.KopiaRepoOptions)
 }
 
@@ -879,6 +1499,108 @@ func (in *NodeAgentConfigMapSettings) DeepCopy() *NodeAgentConfigMapSettings {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeAgentMaintenanceWindow) DeepCopyInto(out *NodeAgentMaintenanceWindow) {
+	*out = *in
+	out.Duration = in.Duration
+	if in.LoadConcurrency != nil {
+		in, out := &in.LoadConcurrency, &out.LoadConcurrency
+		*out = new(LoadConcurrency)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LoadAffinityConfig != nil {
+		in, out := &in.LoadAffinityConfig, &out.LoadAffinityConfig
+		*out = make([]*LoadAffinity, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(LoadAffinity)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeAgentMaintenanceWindow.
+func (in *NodeAgentMaintenanceWindow) DeepCopy() *NodeAgentMaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeAgentMaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeAgentMaintenanceWindowStatus) DeepCopyInto(out *NodeAgentMaintenanceWindowStatus) {
+	*out = *in
+	in.Start.DeepCopyInto(&out.Start)
+	in.End.DeepCopyInto(&out.End)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeAgentMaintenanceWindowStatus.
+func (in *NodeAgentMaintenanceWindowStatus) DeepCopy() *NodeAgentMaintenanceWindowStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeAgentMaintenanceWindowStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeAgentNodeStatus) DeepCopyInto(out *NodeAgentNodeStatus) {
+	*out = *in
+	if in.ConcurrencyRule != nil {
+		in, out := &in.ConcurrencyRule, &out.ConcurrencyRule
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeAgentNodeStatus.
+func (in *NodeAgentNodeStatus) DeepCopy() *NodeAgentNodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeAgentNodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeAgentStatus) DeepCopyInto(out *NodeAgentStatus) {
+	*out = *in
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]NodeAgentNodeStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.UnprotectedPods != nil {
+		in, out := &in.UnprotectedPods, &out.UnprotectedPods
+		*out = make([]UnprotectedPod, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastUnprotectedPodScan != nil {
+		in, out := &in.LastUnprotectedPodScan, &out.LastUnprotectedPodScan
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeAgentStatus.
+func (in *NodeAgentStatus) DeepCopy() *NodeAgentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeAgentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NonAdmin) DeepCopyInto(out *NonAdmin) {
 	*out = *in
@@ -917,6 +1639,11 @@ func (in *NonAdmin) DeepCopyInto(out *NonAdmin) {
 		*out = new(v1.Duration)
 		**out = **in
 	}
+	if in.PodConfig != nil {
+		in, out := &in.PodConfig, &out.PodConfig
+		*out = new(PodConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NonAdmin.
@@ -988,6 +1715,18 @@ func (in *PodConfig) DeepCopyInto(out *PodConfig) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]corev1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(corev1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodConfig.
@@ -1000,6 +1739,36 @@ func (in *PodConfig) DeepCopy() *PodConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReportExportConfig) DeepCopyInto(out *ReportExportConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportExportConfig.
+func (in *ReportExportConfig) DeepCopy() *ReportExportConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ReportExportConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReportExportStatus) DeepCopyInto(out *ReportExportStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportExportStatus.
+func (in *ReportExportStatus) DeepCopy() *ReportExportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReportExportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RepositoryMaintenanceConfig) DeepCopyInto(out *RepositoryMaintenanceConfig) {
 	*out = *in
@@ -1032,10 +1801,112 @@ func (in *RepositoryMaintenanceConfig) DeepCopy() *RepositoryMaintenanceConfig {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ResticConfig) DeepCopyInto(out *ResticConfig) {
+func (in *ResourceBounds) DeepCopyInto(out *ResourceBounds) {
 	*out = *in
-	in.NodeAgentCommonFields.DeepCopyInto(&out.NodeAgentCommonFields)
-}
+	if in.MinAllowed != nil {
+		in, out := &in.MinAllowed, &out.MinAllowed
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.MaxAllowed != nil {
+		in, out := &in.MaxAllowed, &out.MaxAllowed
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceBounds.
+func (in *ResourceBounds) DeepCopy() *ResourceBounds {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceBounds)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceRecommendationStatus) DeepCopyInto(out *ResourceRecommendationStatus) {
+	*out = *in
+	if in.Requests != nil {
+		in, out := &in.Requests, &out.Requests
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Peak != nil {
+		in, out := &in.Peak, &out.Peak
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.PreviousPeak != nil {
+		in, out := &in.PreviousPeak, &out.PreviousPeak
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	in.WindowStart.DeepCopyInto(&out.WindowStart)
+	if in.LastSample != nil {
+		in, out := &in.LastSample, &out.LastSample
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceRecommendationStatus.
+func (in *ResourceRecommendationStatus) DeepCopy() *ResourceRecommendationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceRecommendationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceRecommendations) DeepCopyInto(out *ResourceRecommendations) {
+	*out = *in
+	if in.Velero != nil {
+		in, out := &in.Velero, &out.Velero
+		*out = new(ResourceBounds)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeAgent != nil {
+		in, out := &in.NodeAgent, &out.NodeAgent
+		*out = new(ResourceBounds)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceRecommendations.
+func (in *ResourceRecommendations) DeepCopy() *ResourceRecommendations {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceRecommendations)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResticConfig) DeepCopyInto(out *ResticConfig) {
+	*out = *in
+	in.NodeAgentCommonFields.DeepCopyInto(&out.NodeAgentCommonFields)
+}
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResticConfig.
 func (in *ResticConfig) DeepCopy() *ResticConfig {
@@ -1047,6 +1918,221 @@ func (in *ResticConfig) DeepCopy() *ResticConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreVerification) DeepCopyInto(out *RestoreVerification) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreVerification.
+func (in *RestoreVerification) DeepCopy() *RestoreVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RestoreVerification) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreVerificationCheck) DeepCopyInto(out *RestoreVerificationCheck) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreVerificationCheck.
+func (in *RestoreVerificationCheck) DeepCopy() *RestoreVerificationCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreVerificationCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreVerificationCheckStatus) DeepCopyInto(out *RestoreVerificationCheckStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreVerificationCheckStatus.
+func (in *RestoreVerificationCheckStatus) DeepCopy() *RestoreVerificationCheckStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreVerificationCheckStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreVerificationList) DeepCopyInto(out *RestoreVerificationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RestoreVerification, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreVerificationList.
+func (in *RestoreVerificationList) DeepCopy() *RestoreVerificationList {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreVerificationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RestoreVerificationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreVerificationRun) DeepCopyInto(out *RestoreVerificationRun) {
+	*out = *in
+	if in.NamespaceMapping != nil {
+		in, out := &in.NamespaceMapping, &out.NamespaceMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.RestoreCompletionTime != nil {
+		in, out := &in.RestoreCompletionTime, &out.RestoreCompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.WorkloadsReadyTime != nil {
+		in, out := &in.WorkloadsReadyTime, &out.WorkloadsReadyTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]RestoreVerificationCheckStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreVerificationRun.
+func (in *RestoreVerificationRun) DeepCopy() *RestoreVerificationRun {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreVerificationRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreVerificationSpec) DeepCopyInto(out *RestoreVerificationSpec) {
+	*out = *in
+	if in.IncludedNamespaces != nil {
+		in, out := &in.IncludedNamespaces, &out.IncludedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.ReadinessTimeout = in.ReadinessTimeout
+	out.CheckTimeout = in.CheckTimeout
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]RestoreVerificationCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreVerificationSpec.
+func (in *RestoreVerificationSpec) DeepCopy() *RestoreVerificationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreVerificationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreVerificationStatus) DeepCopyInto(out *RestoreVerificationStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CurrentRun != nil {
+		in, out := &in.CurrentRun, &out.CurrentRun
+		*out = new(RestoreVerificationRun)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastRun != nil {
+		in, out := &in.LastRun, &out.LastRun
+		*out = new(RestoreVerificationRun)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastSuccessfulRunTime != nil {
+		in, out := &in.LastSuccessfulRunTime, &out.LastSuccessfulRunTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextRunTime != nil {
+		in, out := &in.NextRunTime, &out.NextRunTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreVerificationStatus.
+func (in *RestoreVerificationStatus) DeepCopy() *RestoreVerificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreVerificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RetainPolicy) DeepCopyInto(out *RetainPolicy) {
 	*out = *in
@@ -1078,6 +2164,148 @@ func (in *RuledConfigs) DeepCopy() *RuledConfigs {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3Capabilities) DeepCopyInto(out *S3Capabilities) {
+	*out = *in
+	if in.ChecksumAlgorithms != nil {
+		in, out := &in.ChecksumAlgorithms, &out.ChecksumAlgorithms
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConditionalWrites != nil {
+		in, out := &in.ConditionalWrites, &out.ConditionalWrites
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MultipartLimits != nil {
+		in, out := &in.MultipartLimits, &out.MultipartLimits
+		*out = new(MultipartLimits)
+		**out = **in
+	}
+	if in.RecommendedConfig != nil {
+		in, out := &in.RecommendedConfig, &out.RecommendedConfig
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3Capabilities.
+func (in *S3Capabilities) DeepCopy() *S3Capabilities {
+	if in == nil {
+		return nil
+	}
+	out := new(S3Capabilities)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchedulePolicy) DeepCopyInto(out *SchedulePolicy) {
+	*out = *in
+	in.NamespaceSelector.DeepCopyInto(&out.NamespaceSelector)
+	if in.SnapshotMoveData != nil {
+		in, out := &in.SnapshotMoveData, &out.SnapshotMoveData
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DefaultVolumesToFsBackup != nil {
+		in, out := &in.DefaultVolumesToFsBackup, &out.DefaultVolumesToFsBackup
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Tiers != nil {
+		in, out := &in.Tiers, &out.Tiers
+		*out = make([]ScheduleTier, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchedulePolicy.
+func (in *SchedulePolicy) DeepCopy() *SchedulePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(SchedulePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchedulePolicyStatus) DeepCopyInto(out *SchedulePolicyStatus) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tiers != nil {
+		in, out := &in.Tiers, &out.Tiers
+		*out = make([]ScheduleTierStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchedulePolicyStatus.
+func (in *SchedulePolicyStatus) DeepCopy() *SchedulePolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SchedulePolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduleTier) DeepCopyInto(out *ScheduleTier) {
+	*out = *in
+	out.TTL = in.TTL
+	if in.SnapshotMoveData != nil {
+		in, out := &in.SnapshotMoveData, &out.SnapshotMoveData
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DefaultVolumesToFsBackup != nil {
+		in, out := &in.DefaultVolumesToFsBackup, &out.DefaultVolumesToFsBackup
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleTier.
+func (in *ScheduleTier) DeepCopy() *ScheduleTier {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduleTier)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduleTierStatus) DeepCopyInto(out *ScheduleTierStatus) {
+	*out = *in
+	if in.LastSuccessfulBackupTime != nil {
+		in, out := &in.LastSuccessfulBackupTime, &out.LastSuccessfulBackupTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleTierStatus.
+func (in *ScheduleTierStatus) DeepCopy() *ScheduleTierStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduleTierStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServerFlags) DeepCopyInto(out *ServerFlags) {
 	*out = *in
@@ -1181,6 +2409,11 @@ func (in *SnapshotLocation) DeepCopyInto(out *SnapshotLocation) {
 		*out = new(velerov1.VolumeSnapshotLocationSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ExternalCredential != nil {
+		in, out := &in.ExternalCredential, &out.ExternalCredential
+		*out = new(ExternalCredential)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotLocation.
@@ -1193,6 +2426,21 @@ func (in *SnapshotLocation) DeepCopy() *SnapshotLocation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotOperationStatus) DeepCopyInto(out *SnapshotOperationStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotOperationStatus.
+func (in *SnapshotOperationStatus) DeepCopy() *SnapshotOperationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotOperationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SnapshotTestStatus) DeepCopyInto(out *SnapshotTestStatus) {
 	*out = *in
@@ -1228,6 +2476,61 @@ func (in *StorageType) DeepCopy() *StorageType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TestThresholds) DeepCopyInto(out *TestThresholds) {
+	*out = *in
+	if in.MaxSnapshotReadyDuration != nil {
+		in, out := &in.MaxSnapshotReadyDuration, &out.MaxSnapshotReadyDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestThresholds.
+func (in *TestThresholds) DeepCopy() *TestThresholds {
+	if in == nil {
+		return nil
+	}
+	out := new(TestThresholds)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenExchangeStatus) DeepCopyInto(out *TokenExchangeStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenExchangeStatus.
+func (in *TokenExchangeStatus) DeepCopy() *TokenExchangeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenExchangeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnprotectedPod) DeepCopyInto(out *UnprotectedPod) {
+	*out = *in
+	if in.PersistentVolumeClaims != nil {
+		in, out := &in.PersistentVolumeClaims, &out.PersistentVolumeClaims
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnprotectedPod.
+func (in *UnprotectedPod) DeepCopy() *UnprotectedPod {
+	if in == nil {
+		return nil
+	}
+	out := new(UnprotectedPod)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UploadSpeedTestConfig) DeepCopyInto(out *UploadSpeedTestConfig) {
 	*out = *in
@@ -1328,6 +2631,11 @@ func (in *VeleroConfig) DeepCopyInto(out *VeleroConfig) {
 			}
 		}
 	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(VeleroPodDisruptionBudget)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VeleroConfig.
@@ -1340,6 +2648,31 @@ func (in *VeleroConfig) DeepCopy() *VeleroConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VeleroPodDisruptionBudget) DeepCopyInto(out *VeleroPodDisruptionBudget) {
+	*out = *in
+	if in.Enable != nil {
+		in, out := &in.Enable, &out.Enable
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ProtectInProgressOperations != nil {
+		in, out := &in.ProtectInProgressOperations, &out.ProtectInProgressOperations
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VeleroPodDisruptionBudget.
+func (in *VeleroPodDisruptionBudget) DeepCopy() *VeleroPodDisruptionBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(VeleroPodDisruptionBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VeleroServerArgs) DeepCopyInto(out *VeleroServerArgs) {
 	*out = *in
@@ -1372,6 +2705,42 @@ func (in *VolumeOptions) DeepCopy() *VolumeOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotLocationTestConfig) DeepCopyInto(out *VolumeSnapshotLocationTestConfig) {
+	*out = *in
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotLocationTestConfig.
+func (in *VolumeSnapshotLocationTestConfig) DeepCopy() *VolumeSnapshotLocationTestConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotLocationTestConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotLocationTestStatus) DeepCopyInto(out *VolumeSnapshotLocationTestStatus) {
+	*out = *in
+	if in.Operations != nil {
+		in, out := &in.Operations, &out.Operations
+		*out = make([]SnapshotOperationStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotLocationTestStatus.
+func (in *VolumeSnapshotLocationTestStatus) DeepCopy() *VolumeSnapshotLocationTestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotLocationTestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeSnapshotSource) DeepCopyInto(out *VolumeSnapshotSource) {
 	*out = *in
@@ -31,6 +31,7 @@ import (
 	monitor "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -57,6 +58,7 @@ import (
 	//+kubebuilder:scaffold:imports
 	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
 	"github.com/openshift/oadp-operator/pkg/leaderelection"
+	"github.com/openshift/oadp-operator/pkg/tlsprofile"
 )
 
 var (
@@ -150,7 +152,23 @@ func main() {
 		c.NextProtos = []string{"http/1.1"}
 	}
 
-	tlsOpts := []func(*tls.Config){}
+	// the metrics and webhook servers follow the TLS security profile of the cluster APIServer configuration
+	tlsProfileSpec, err := getTLSProfileSpec(kubeconf)
+	if err != nil {
+		setupLog.Error(err, "unable to get the cluster TLS security profile")
+		os.Exit(1)
+	}
+	applyTLSProfile, unsupportedCiphers, err := tlsprofile.TLSConfigFunc(tlsProfileSpec)
+	if err != nil {
+		setupLog.Error(err, "invalid cluster TLS security profile")
+		os.Exit(1)
+	}
+	if len(unsupportedCiphers) > 0 {
+		setupLog.Info("ignoring the cipher suites of the cluster TLS security profile not supported by Go", "ciphers", unsupportedCiphers)
+	}
+	setupLog.Info("applying the cluster TLS security profile", "minTLSVersion", tlsProfileSpec.MinTLSVersion)
+
+	tlsOpts := []func(*tls.Config){applyTLSProfile}
 	if !enableHTTP2 {
 		tlsOpts = append(tlsOpts, disableHTTP2)
 	}
@@ -177,6 +195,10 @@ func main() {
 			DefaultNamespaces: map[string]cache.Config{
 				watchNamespace: {},
 			},
+			// nodes are cached for the NodeAgent status, without the fields it does not read
+			ByObject: map[client.Object]cache.ByObject{
+				&corev1.Node{}: {Transform: stripNode},
+			},
 		},
 	})
 	if err != nil {
@@ -226,6 +248,7 @@ func main() {
 
 	uncachedClientScheme := runtime.NewScheme()
 	utilruntime.Must(oadpv1alpha1.AddToScheme(uncachedClientScheme))
+	utilruntime.Must(corev1.AddToScheme(uncachedClientScheme))
 	utilruntime.Must(appsv1.AddToScheme(uncachedClientScheme))
 	utilruntime.Must(snapshotv1api.AddToScheme(uncachedClientScheme))
 	uncachedClient, err := client.New(kubeconf, client.Options{
@@ -264,6 +287,43 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "DataProtectionTest")
 		os.Exit(1)
 	}
+
+	if err = (&controller.BackupComplianceReportReconciler{
+		Client:            mgr.GetClient(),
+		Scheme:            mgr.GetScheme(),
+		EventRecorder:     mgr.GetEventRecorderFor("BackupComplianceReport-controller"),
+		ClusterWideClient: uncachedClient,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BackupComplianceReport")
+		os.Exit(1)
+	}
+
+	if err = (&controller.RestoreVerificationReconciler{
+		Client:            mgr.GetClient(),
+		Scheme:            mgr.GetScheme(),
+		EventRecorder:     mgr.GetEventRecorderFor("RestoreVerification-controller"),
+		ClusterWideClient: uncachedClient,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RestoreVerification")
+		os.Exit(1)
+	}
+	// restart the operator when the cluster TLS security profile changes
+	ctx, restart := context.WithCancel(ctrl.SetupSignalHandler())
+	defer restart()
+	apiServerExists, err := DoesCRDExist(configv1.GroupVersion.String(), "apiservers", kubeconf)
+	if err != nil {
+		setupLog.Error(err, "unable to discover the cluster APIServer configuration, the TLS security profile is not watched")
+	}
+	if apiServerExists {
+		if err = (&controller.TLSSecurityProfileWatcher{
+			Client:             mgr.GetClient(),
+			InitialProfileSpec: tlsProfileSpec,
+			OnProfileChange:    restart,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "TLSSecurityProfileWatcher")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -276,12 +336,24 @@ func main() {
 	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
 
+// getTLSProfileSpec returns the TLS security profile of the cluster APIServer configuration, read before the
+// manager and its cache are started
+func getTLSProfileSpec(kubeconf *rest.Config) (configv1.TLSProfileSpec, error) {
+	configScheme := runtime.NewScheme()
+	utilruntime.Must(configv1.AddToScheme(configScheme))
+	configClient, err := client.New(kubeconf, client.Options{Scheme: configScheme})
+	if err != nil {
+		return configv1.TLSProfileSpec{}, err
+	}
+	return tlsprofile.GetAPIServerProfileSpec(context.Background(), configClient)
+}
+
 // getWatchNamespace returns the Namespace the operator should be watching for changes
 func getWatchNamespace() (string, error) {
 	// WatchNamespaceEnvVar is the constant for env variable WATCH_NAMESPACE
@@ -348,3 +420,12 @@ func DoesCRDExist(CRDGroupVersion, CRDName string, kubeconf *rest.Config) (bool,
 	}
 	return discoveryResult, nil
 }
+
+// stripNode drops the managed fields and the container images of a node before it is cached
+func stripNode(object interface{}) (interface{}, error) {
+	if node, ok := object.(*corev1.Node); ok {
+		node.ManagedFields = nil
+		node.Status.Images = nil
+	}
+	return object, nil
+}
@@ -0,0 +1,402 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	"github.com/vmware-tanzu/velero/pkg/util/collections"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+const (
+	// defaultBackupComplianceRPO is the recovery point objective of the reports without spec.rpo
+	defaultBackupComplianceRPO = 24 * time.Hour
+	// defaultBackupComplianceEvaluationInterval is the evaluation interval of the reports without spec.evaluationInterval
+	defaultBackupComplianceEvaluationInterval = 5 * time.Minute
+	// maxReportedNamespaces limits the namespaces listed in status.breachedNamespaces and status.uncoveredNamespaces
+	maxReportedNamespaces = 50
+)
+
+var (
+	backupComplianceNamespaceLabels = []string{"report_namespace", "report", "namespace", "group"}
+
+	backupComplianceLastSuccessfulBackup = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "oadp_backup_compliance_namespace_last_successful_backup_timestamp_seconds",
+		Help: "Completion time of the last successful backup including the namespace, unset without successful backup",
+	}, backupComplianceNamespaceLabels)
+	backupComplianceRPOBreached = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "oadp_backup_compliance_namespace_rpo_breached",
+		Help: "1 when the namespace had no successful backup within the recovery point objective of the report",
+	}, backupComplianceNamespaceLabels)
+	backupComplianceCovered = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "oadp_backup_compliance_namespace_covered",
+		Help: "1 when an enabled Schedule includes the namespace",
+	}, backupComplianceNamespaceLabels)
+	backupComplianceNamespaces = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "oadp_backup_compliance_namespaces",
+		Help: "Number of namespaces tracked by the report, by state: total, breached or uncovered",
+	}, []string{"report_namespace", "report", "state"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(backupComplianceLastSuccessfulBackup, backupComplianceRPOBreached, backupComplianceCovered, backupComplianceNamespaces)
+}
+
+// BackupComplianceReportReconciler reconciles a BackupComplianceReport object
+type BackupComplianceReportReconciler struct {
+	client.Client
+	Scheme            *runtime.Scheme
+	EventRecorder     record.EventRecorder
+	ClusterWideClient client.Client
+	// Clock is the time source of the RPO evaluation, the real clock when nil
+	Clock clock.PassiveClock
+}
+
+//+kubebuilder:rbac:groups=oadp.openshift.io,resources=backupcompliancereports,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=oadp.openshift.io,resources=backupcompliancereports/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=oadp.openshift.io,resources=backupcompliancereports/finalizers,verbs=update
+
+func (r *BackupComplianceReportReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("backupcompliancereport", req.NamespacedName)
+
+	report := &oadpv1alpha1.BackupComplianceReport{}
+	if err := r.Get(ctx, req.NamespacedName, report); err != nil {
+		if apierrors.IsNotFound(err) {
+			deleteBackupComplianceMetrics(req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	namespaces, err := r.trackedNamespaces(ctx, report)
+	if err == nil {
+		err = r.evaluate(ctx, logger, report, namespaces)
+	}
+	if err != nil {
+		logger.Error(err, "Error evaluating the backup compliance report")
+		apimeta.SetStatusCondition(&report.Status.Conditions, metav1.Condition{
+			Type:    oadpv1alpha1.ConditionCompliant,
+			Status:  metav1.ConditionUnknown,
+			Reason:  oadpv1alpha1.CompliantReasonEvaluationFailed,
+			Message: err.Error(),
+		})
+	}
+	if statusErr := r.Status().Update(ctx, report); statusErr != nil && err == nil {
+		err = statusErr
+	}
+	return ctrl.Result{RequeueAfter: backupComplianceEvaluationInterval(report)}, err
+}
+
+// trackedNamespaces returns the namespaces selected by the report, without the excluded namespaces
+func (r *BackupComplianceReportReconciler) trackedNamespaces(ctx context.Context, report *oadpv1alpha1.BackupComplianceReport) ([]corev1.Namespace, error) {
+	selector := labels.Everything()
+	if report.Spec.NamespaceSelector != nil {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(report.Spec.NamespaceSelector); err != nil {
+			return nil, fmt.Errorf("spec.namespaceSelector is invalid: %w", err)
+		}
+	}
+	clusterClient := r.ClusterWideClient
+	if clusterClient == nil {
+		clusterClient = r.Client
+	}
+	namespaceList := &corev1.NamespaceList{}
+	if err := clusterClient.List(ctx, namespaceList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	excluded := collections.NewIncludesExcludes().Excludes(report.Spec.ExcludedNamespaces...)
+	namespaces := make([]corev1.Namespace, 0, len(namespaceList.Items))
+	for _, namespace := range namespaceList.Items {
+		// the scratch namespaces of restore verifications are not tracked
+		if namespace.DeletionTimestamp != nil || namespace.Labels[RestoreVerificationLabel] != "" || !excluded.ShouldInclude(namespace.Name) {
+			continue
+		}
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Slice(namespaces, func(i, j int) bool { return namespaces[i].Name < namespaces[j].Name })
+	return namespaces, nil
+}
+
+// evaluate sets the status and the metrics of the report from the Backups and Schedules of its namespace
+func (r *BackupComplianceReportReconciler) evaluate(ctx context.Context, logger logr.Logger, report *oadpv1alpha1.BackupComplianceReport, namespaces []corev1.Namespace) error {
+	backups := &velerov1.BackupList{}
+	if err := r.List(ctx, backups, client.InNamespace(report.Namespace)); err != nil {
+		return err
+	}
+	schedules := &velerov1.ScheduleList{}
+	if err := r.List(ctx, schedules, client.InNamespace(report.Namespace)); err != nil {
+		return err
+	}
+
+	now := r.now()
+	compliance := evaluateBackupCompliance(report, namespaces, backups.Items, schedules.Items, now)
+	wasCompliant := apimeta.IsStatusConditionTrue(report.Status.Conditions, oadpv1alpha1.ConditionCompliant)
+	report.Status = backupComplianceStatus(report, compliance, now)
+	setBackupComplianceMetrics(report, compliance)
+
+	if wasCompliant && report.Status.BreachedNamespaceCount > 0 {
+		r.EventRecorder.Event(report, corev1.EventTypeWarning, oadpv1alpha1.CompliantReasonRPOBreached,
+			fmt.Sprintf("%d namespaces had no successful backup within the RPO of %s", report.Status.BreachedNamespaceCount, backupComplianceRPO(report)))
+	}
+	logger.V(1).Info("Evaluated backup compliance", "namespaces", report.Status.NamespaceCount,
+		"breached", report.Status.BreachedNamespaceCount, "uncovered", report.Status.UncoveredNamespaceCount)
+	return nil
+}
+
+// namespaceCompliance is the compliance of a tracked namespace
+type namespaceCompliance struct {
+	oadpv1alpha1.NamespaceComplianceStatus
+	breached bool
+}
+
+// evaluateBackupCompliance returns the compliance of the namespaces at now. A namespace is covered by the enabled
+// Schedules including it, and its last successful backup is the last completed backup including it.
+func evaluateBackupCompliance(report *oadpv1alpha1.BackupComplianceReport, namespaces []corev1.Namespace, backups []velerov1.Backup, schedules []velerov1.Schedule, now time.Time) []namespaceCompliance {
+	var completed []velerov1.Backup
+	for _, backup := range backups {
+		if backup.Status.Phase == velerov1.BackupPhaseCompleted && backup.Status.CompletionTimestamp != nil {
+			completed = append(completed, backup)
+		}
+	}
+	var enabled []velerov1.Schedule
+	for _, schedule := range schedules {
+		if !schedule.Spec.Paused && schedule.Status.Phase != velerov1.SchedulePhaseFailedValidation {
+			enabled = append(enabled, schedule)
+		}
+	}
+
+	rpo := backupComplianceRPO(report)
+	compliance := make([]namespaceCompliance, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		status := namespaceCompliance{NamespaceComplianceStatus: oadpv1alpha1.NamespaceComplianceStatus{Namespace: namespace.Name}}
+		if report.Spec.GroupByLabel != "" {
+			status.Group = namespace.Labels[report.Spec.GroupByLabel]
+		}
+		for _, schedule := range enabled {
+			if backupIncludesNamespace(schedule.Spec.Template, namespace.Name) {
+				status.Covered = true
+				break
+			}
+		}
+		for i := range completed {
+			backup := &completed[i]
+			if !backupIncludesNamespace(backup.Spec, namespace.Name) {
+				continue
+			}
+			if status.LastSuccessfulBackupTime == nil || backup.Status.CompletionTimestamp.After(status.LastSuccessfulBackupTime.Time) {
+				status.LastSuccessfulBackup = backup.Name
+				status.LastSuccessfulBackupTime = backup.Status.CompletionTimestamp.DeepCopy()
+			}
+		}
+		status.breached = status.LastSuccessfulBackupTime == nil || now.Sub(status.LastSuccessfulBackupTime.Time) > rpo
+		compliance = append(compliance, status)
+	}
+	return compliance
+}
+
+// backupIncludesNamespace returns whether a backup includes a namespace, with the wildcards of Velero
+func backupIncludesNamespace(spec velerov1.BackupSpec, namespace string) bool {
+	return collections.NewIncludesExcludes().Includes(spec.IncludedNamespaces...).Excludes(spec.ExcludedNamespaces...).ShouldInclude(namespace)
+}
+
+// backupComplianceStatus returns the status of the report from the compliance of its namespaces
+func backupComplianceStatus(report *oadpv1alpha1.BackupComplianceReport, compliance []namespaceCompliance, now time.Time) oadpv1alpha1.BackupComplianceReportStatus {
+	status := oadpv1alpha1.BackupComplianceReportStatus{
+		Conditions:         report.Status.Conditions,
+		LastEvaluationTime: &metav1.Time{Time: now},
+		NamespaceCount:     len(compliance),
+	}
+	groups := map[string]*oadpv1alpha1.NamespaceGroupComplianceStatus{}
+	var groupNames []string
+	var breached []oadpv1alpha1.NamespaceComplianceStatus
+	for _, namespace := range compliance {
+		if report.Spec.GroupByLabel != "" {
+			group, found := groups[namespace.Group]
+			if !found {
+				group = &oadpv1alpha1.NamespaceGroupComplianceStatus{Name: namespace.Group, LastSuccessfulBackupTime: namespace.LastSuccessfulBackupTime}
+				groups[namespace.Group] = group
+				groupNames = append(groupNames, namespace.Group)
+			}
+			group.NamespaceCount++
+			if namespace.breached {
+				group.BreachedNamespaceCount++
+			}
+			if !namespace.Covered {
+				group.UncoveredNamespaceCount++
+			}
+			if namespace.LastSuccessfulBackupTime == nil ||
+				group.LastSuccessfulBackupTime != nil && namespace.LastSuccessfulBackupTime.Before(group.LastSuccessfulBackupTime) {
+				group.LastSuccessfulBackupTime = namespace.LastSuccessfulBackupTime
+			}
+		}
+		if namespace.breached {
+			breached = append(breached, namespace.NamespaceComplianceStatus)
+		}
+		if !namespace.Covered {
+			status.UncoveredNamespaceCount++
+			if len(status.UncoveredNamespaces) < maxReportedNamespaces {
+				status.UncoveredNamespaces = append(status.UncoveredNamespaces, namespace.Namespace)
+			}
+		}
+	}
+
+	// the namespaces never backed up first, then the oldest backups
+	sort.SliceStable(breached, func(i, j int) bool {
+		if breached[j].LastSuccessfulBackupTime == nil {
+			return false
+		}
+		return breached[i].LastSuccessfulBackupTime == nil || breached[i].LastSuccessfulBackupTime.Before(breached[j].LastSuccessfulBackupTime)
+	})
+	status.BreachedNamespaceCount = len(breached)
+	if len(breached) > maxReportedNamespaces {
+		breached = breached[:maxReportedNamespaces]
+	}
+	status.BreachedNamespaces = breached
+
+	sort.Strings(groupNames)
+	for _, name := range groupNames {
+		status.Groups = append(status.Groups, *groups[name])
+	}
+
+	condition := metav1.Condition{
+		Type:    oadpv1alpha1.ConditionCompliant,
+		Status:  metav1.ConditionTrue,
+		Reason:  oadpv1alpha1.CompliantReasonRPOMet,
+		Message: fmt.Sprintf("all %d namespaces had a successful backup within %s", status.NamespaceCount, backupComplianceRPO(report)),
+	}
+	if status.BreachedNamespaceCount > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = oadpv1alpha1.CompliantReasonRPOBreached
+		condition.Message = fmt.Sprintf("%d of %d namespaces had no successful backup within %s", status.BreachedNamespaceCount, status.NamespaceCount, backupComplianceRPO(report))
+	}
+	apimeta.SetStatusCondition(&status.Conditions, condition)
+	return status
+}
+
+// setBackupComplianceMetrics replaces the metrics of the report with the compliance of its namespaces
+func setBackupComplianceMetrics(report *oadpv1alpha1.BackupComplianceReport, compliance []namespaceCompliance) {
+	key := types.NamespacedName{Namespace: report.Namespace, Name: report.Name}
+	// the series of namespaces no longer tracked are removed
+	deleteBackupComplianceMetrics(key)
+	breached, uncovered := 0, 0
+	for _, namespace := range compliance {
+		labelValues := []string{report.Namespace, report.Name, namespace.Namespace, namespace.Group}
+		if namespace.LastSuccessfulBackupTime != nil {
+			backupComplianceLastSuccessfulBackup.WithLabelValues(labelValues...).Set(float64(namespace.LastSuccessfulBackupTime.Unix()))
+		}
+		backupComplianceRPOBreached.WithLabelValues(labelValues...).Set(boolToFloat64(namespace.breached))
+		backupComplianceCovered.WithLabelValues(labelValues...).Set(boolToFloat64(namespace.Covered))
+		if namespace.breached {
+			breached++
+		}
+		if !namespace.Covered {
+			uncovered++
+		}
+	}
+	backupComplianceNamespaces.WithLabelValues(report.Namespace, report.Name, "total").Set(float64(len(compliance)))
+	backupComplianceNamespaces.WithLabelValues(report.Namespace, report.Name, "breached").Set(float64(breached))
+	backupComplianceNamespaces.WithLabelValues(report.Namespace, report.Name, "uncovered").Set(float64(uncovered))
+}
+
+// deleteBackupComplianceMetrics deletes the metrics of a report
+func deleteBackupComplianceMetrics(key types.NamespacedName) {
+	reportLabels := prometheus.Labels{"report_namespace": key.Namespace, "report": key.Name}
+	for _, gauge := range []*prometheus.GaugeVec{backupComplianceLastSuccessfulBackup, backupComplianceRPOBreached, backupComplianceCovered, backupComplianceNamespaces} {
+		gauge.DeletePartialMatch(reportLabels)
+	}
+}
+
+func boolToFloat64(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// backupComplianceRPO returns the recovery point objective of the report
+func backupComplianceRPO(report *oadpv1alpha1.BackupComplianceReport) time.Duration {
+	if report.Spec.RPO.Duration <= 0 {
+		return defaultBackupComplianceRPO
+	}
+	return report.Spec.RPO.Duration
+}
+
+// backupComplianceEvaluationInterval returns the evaluation interval of the report
+func backupComplianceEvaluationInterval(report *oadpv1alpha1.BackupComplianceReport) time.Duration {
+	if report.Spec.EvaluationInterval.Duration <= 0 {
+		return defaultBackupComplianceEvaluationInterval
+	}
+	return report.Spec.EvaluationInterval.Duration
+}
+
+// now returns the current time of the reconciler clock
+func (r *BackupComplianceReportReconciler) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
+	return r.Clock.Now()
+}
+
+// backupComplianceReportRequests returns the reports of the namespace of a Velero Backup or Schedule
+func (r *BackupComplianceReportReconciler) backupComplianceReportRequests(ctx context.Context, object client.Object) []reconcile.Request {
+	reports := &oadpv1alpha1.BackupComplianceReportList{}
+	if err := r.List(ctx, reports, client.InNamespace(object.GetNamespace())); err != nil {
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(reports.Items))
+	for _, report := range reports.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: report.Namespace, Name: report.Name}})
+	}
+	return requests
+}
+
+// backupCompliancePredicate passes the Backup events changing its phase and the Schedule events changing its spec
+// or phase, the other status updates do not change the compliance
+func backupCompliancePredicate() predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			switch oldObject := e.ObjectOld.(type) {
+			case *velerov1.Backup:
+				newObject, ok := e.ObjectNew.(*velerov1.Backup)
+				return !ok || oldObject.Status.Phase != newObject.Status.Phase
+			case *velerov1.Schedule:
+				newObject, ok := e.ObjectNew.(*velerov1.Schedule)
+				return !ok || oldObject.Generation != newObject.Generation || oldObject.Status.Phase != newObject.Status.Phase
+			}
+			return true
+		},
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *BackupComplianceReportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&oadpv1alpha1.BackupComplianceReport{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&velerov1.Backup{}, handler.EnqueueRequestsFromMapFunc(r.backupComplianceReportRequests), builder.WithPredicates(backupCompliancePredicate())).
+		Watches(&velerov1.Schedule{}, handler.EnqueueRequestsFromMapFunc(r.backupComplianceReportRequests), builder.WithPredicates(backupCompliancePredicate())).
+		Complete(r)
+}
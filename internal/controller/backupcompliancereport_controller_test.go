@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestBackupIncludesNamespace(t *testing.T) {
+	require.True(t, backupIncludesNamespace(velerov1.BackupSpec{}, "app"), "no included namespaces is the whole cluster")
+	require.True(t, backupIncludesNamespace(velerov1.BackupSpec{IncludedNamespaces: []string{"*"}}, "app"))
+	require.True(t, backupIncludesNamespace(velerov1.BackupSpec{IncludedNamespaces: []string{"team-*"}}, "team-a"))
+	require.False(t, backupIncludesNamespace(velerov1.BackupSpec{IncludedNamespaces: []string{"team-*"}}, "app"))
+	require.False(t, backupIncludesNamespace(velerov1.BackupSpec{ExcludedNamespaces: []string{"app"}}, "app"))
+}
+
+func TestBackupComplianceReportReconciler_Reconcile(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	report := &oadpv1alpha1.BackupComplianceReport{
+		ObjectMeta: metav1.ObjectMeta{Name: "compliance", Namespace: "openshift-adp"},
+		Spec: oadpv1alpha1.BackupComplianceReportSpec{
+			RPO:                metav1.Duration{Duration: 24 * time.Hour},
+			ExcludedNamespaces: []string{"openshift*"},
+			GroupByLabel:       "team",
+		},
+	}
+	namespace := func(name, team string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"team": team}}}
+	}
+	backup := func(name string, phase velerov1.BackupPhase, completed time.Time, namespaces ...string) *velerov1.Backup {
+		return &velerov1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "openshift-adp"},
+			Spec:       velerov1.BackupSpec{IncludedNamespaces: namespaces},
+			Status:     velerov1.BackupStatus{Phase: phase, CompletionTimestamp: &metav1.Time{Time: completed}},
+		}
+	}
+	schedule := func(name string, paused bool, namespaces ...string) *velerov1.Schedule {
+		return &velerov1.Schedule{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "openshift-adp"},
+			Spec:       velerov1.ScheduleSpec{Paused: paused, Template: velerov1.BackupSpec{IncludedNamespaces: namespaces}},
+		}
+	}
+	scheme, err := getSchemeForFakeClient()
+	require.NoError(t, err)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(report).WithObjects(report,
+		namespace("payments", "finance"), namespace("ledger", "finance"), namespace("shop", "retail"),
+		namespace("scratch", ""), namespace("openshift-adp", ""),
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "verify-payments", Labels: map[string]string{RestoreVerificationLabel: "verify"}}},
+		backup("daily-1", velerov1.BackupPhaseCompleted, now.Add(-2*time.Hour), "payments", "ledger"),
+		backup("daily-0", velerov1.BackupPhaseCompleted, now.Add(-26*time.Hour), "payments", "ledger", "shop"),
+		backup("shop-1", velerov1.BackupPhasePartiallyFailed, now.Add(-time.Hour), "shop"),
+		schedule("daily", false, "payments", "ledger"),
+		schedule("shop", true, "shop"),
+	).Build()
+	eventRecorder := record.NewFakeRecorder(5)
+	r := &BackupComplianceReportReconciler{
+		Client:        fakeClient,
+		Scheme:        fakeClient.Scheme(),
+		EventRecorder: eventRecorder,
+		Clock:         clocktesting.NewFakePassiveClock(now),
+	}
+	key := types.NamespacedName{Namespace: "openshift-adp", Name: "compliance"}
+
+	result, err := r.Reconcile(newContextForTest(), ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	require.Equal(t, defaultBackupComplianceEvaluationInterval, result.RequeueAfter)
+
+	require.NoError(t, fakeClient.Get(newContextForTest(), key, report))
+	status := report.Status
+	require.Equal(t, 4, status.NamespaceCount, "excluded and restore verification namespaces are not tracked")
+	require.Equal(t, 2, status.BreachedNamespaceCount)
+	require.Equal(t, 2, status.UncoveredNamespaceCount)
+	require.Equal(t, []string{"scratch", "shop"}, status.UncoveredNamespaces, "paused Schedules do not cover their namespaces")
+	require.Len(t, status.BreachedNamespaces, 2)
+	require.Equal(t, "scratch", status.BreachedNamespaces[0].Namespace, "namespaces never backed up first")
+	require.Nil(t, status.BreachedNamespaces[0].LastSuccessfulBackupTime)
+	require.Equal(t, "shop", status.BreachedNamespaces[1].Namespace)
+	require.Equal(t, "retail", status.BreachedNamespaces[1].Group)
+	require.Equal(t, "daily-0", status.BreachedNamespaces[1].LastSuccessfulBackup, "partially failed backups are not successful")
+
+	require.Len(t, status.Groups, 3)
+	require.Equal(t, "", status.Groups[0].Name)
+	require.Equal(t, "finance", status.Groups[1].Name)
+	require.Equal(t, 2, status.Groups[1].NamespaceCount)
+	require.Zero(t, status.Groups[1].BreachedNamespaceCount)
+	require.True(t, status.Groups[1].LastSuccessfulBackupTime.Time.Equal(now.Add(-2*time.Hour)))
+	require.Equal(t, oadpv1alpha1.NamespaceGroupComplianceStatus{Name: "retail", NamespaceCount: 1, BreachedNamespaceCount: 1, UncoveredNamespaceCount: 1,
+		LastSuccessfulBackupTime: status.Groups[2].LastSuccessfulBackupTime}, status.Groups[2])
+
+	condition := apimeta.FindStatusCondition(status.Conditions, oadpv1alpha1.ConditionCompliant)
+	require.NotNil(t, condition)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Equal(t, oadpv1alpha1.CompliantReasonRPOBreached, condition.Reason)
+
+	require.Equal(t, float64(1), testutil.ToFloat64(backupComplianceRPOBreached.WithLabelValues("openshift-adp", "compliance", "shop", "retail")))
+	require.Equal(t, float64(0), testutil.ToFloat64(backupComplianceRPOBreached.WithLabelValues("openshift-adp", "compliance", "payments", "finance")))
+	require.Equal(t, float64(now.Add(-2*time.Hour).Unix()),
+		testutil.ToFloat64(backupComplianceLastSuccessfulBackup.WithLabelValues("openshift-adp", "compliance", "ledger", "finance")))
+	require.Equal(t, float64(0), testutil.ToFloat64(backupComplianceCovered.WithLabelValues("openshift-adp", "compliance", "scratch", "")))
+	require.Equal(t, float64(2), testutil.ToFloat64(backupComplianceNamespaces.WithLabelValues("openshift-adp", "compliance", "uncovered")))
+
+	// a new backup of the breached namespaces restores the compliance
+	require.NoError(t, fakeClient.Create(newContextForTest(), backup("all-1", velerov1.BackupPhaseCompleted, now.Add(-time.Hour))))
+	_, err = r.Reconcile(newContextForTest(), ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Get(newContextForTest(), key, report))
+	require.True(t, apimeta.IsStatusConditionTrue(report.Status.Conditions, oadpv1alpha1.ConditionCompliant))
+	require.Empty(t, report.Status.BreachedNamespaces)
+	require.Equal(t, 2, report.Status.UncoveredNamespaceCount, "a backup does not cover a namespace")
+
+	// the RPO elapses, the breach is reported as an event
+	r.Clock = clocktesting.NewFakePassiveClock(now.Add(24 * time.Hour))
+	_, err = r.Reconcile(newContextForTest(), ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	require.Len(t, eventRecorder.Events, 1)
+	require.Contains(t, <-eventRecorder.Events, "4 namespaces had no successful backup within the RPO of 24h0m0s")
+
+	// the metrics of a deleted report are removed
+	require.NoError(t, fakeClient.Delete(newContextForTest(), report))
+	_, err = r.Reconcile(newContextForTest(), ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	require.Zero(t, testutil.CollectAndCount(backupComplianceRPOBreached))
+	require.Zero(t, testutil.CollectAndCount(backupComplianceNamespaces))
+}
+
+func TestBackupCompliancePredicate(t *testing.T) {
+	inProgress := &velerov1.Backup{Status: velerov1.BackupStatus{Phase: velerov1.BackupPhaseInProgress, Progress: &velerov1.BackupProgress{ItemsBackedUp: 1}}}
+	progressed := inProgress.DeepCopy()
+	progressed.Status.Progress.ItemsBackedUp = 2
+	completed := inProgress.DeepCopy()
+	completed.Status.Phase = velerov1.BackupPhaseCompleted
+
+	predicate := backupCompliancePredicate()
+	require.False(t, predicate.Update(event.UpdateEvent{ObjectOld: inProgress, ObjectNew: progressed}))
+	require.True(t, predicate.Update(event.UpdateEvent{ObjectOld: inProgress, ObjectNew: completed}))
+
+	schedule := &velerov1.Schedule{ObjectMeta: metav1.ObjectMeta{Generation: 1}}
+	lastBackup := schedule.DeepCopy()
+	lastBackup.Status.LastBackup = &metav1.Time{Time: time.Now()}
+	paused := schedule.DeepCopy()
+	paused.Generation = 2
+	require.False(t, predicate.Update(event.UpdateEvent{ObjectOld: schedule, ObjectNew: lastBackup}))
+	require.True(t, predicate.Update(event.UpdateEvent{ObjectOld: schedule, ObjectNew: paused}))
+}
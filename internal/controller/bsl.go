@@ -22,6 +22,7 @@ import (
 	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
 	"github.com/openshift/oadp-operator/pkg/common"
 	"github.com/openshift/oadp-operator/pkg/credentials"
+	"github.com/openshift/oadp-operator/pkg/credentials/azurecreds"
 	"github.com/openshift/oadp-operator/pkg/storage/aws"
 )
 
@@ -102,11 +103,31 @@ func (r *DataProtectionApplicationReconciler) ValidateBackupStorageLocations() (
 		namesSeen[bslName] = true
 	}
 
-	for _, bslSpec := range dpa.Spec.BackupLocations {
+	for i, bslSpec := range dpa.Spec.BackupLocations {
 		if err := r.ensureBackupLocationHasVeleroOrCloudStorage(&bslSpec); err != nil {
 			return false, err
 		}
 
+		if bslSpec.ExternalCredential != nil {
+			bslYAMLPath := fmt.Sprintf("spec.backupLocations[%v]", i)
+			if bslSpec.Velero == nil {
+				return false, fmt.Errorf("%s.externalCredential is only supported with velero backup locations", bslYAMLPath)
+			}
+			if err := r.validateExternalCredential(bslYAMLPath, bslSpec.ExternalCredential, bslSpec.Velero.Credential, bslSpec.Velero.Config); err != nil {
+				return false, err
+			}
+		}
+
+		if bslSpec.MountCredential {
+			bslYAMLPath := fmt.Sprintf("spec.backupLocations[%v]", i)
+			if bslSpec.Velero == nil {
+				return false, fmt.Errorf("%s.mountCredential is only supported with velero backup locations", bslYAMLPath)
+			}
+			if err := validateMountCredential(bslYAMLPath, bslSpec.Velero.Credential, bslSpec.ExternalCredential, bslSpec.Velero.Config); err != nil {
+				return false, err
+			}
+		}
+
 		if err := r.ensurePrefixWhenBackupImages(&bslSpec); err != nil {
 			return false, err
 		}
@@ -128,17 +149,17 @@ func (r *DataProtectionApplicationReconciler) ValidateBackupStorageLocations() (
 			// TODO: cases might need some updates for IBM/Minio/noobaa
 			switch provider {
 			case AWSProvider, "velero.io/aws":
-				err := r.validateAWSBackupStorageLocation(*bslSpec.Velero)
+				err := r.validateAWSBackupStorageLocation(*bslSpec.Velero, bslSpec.ExternalCredential)
 				if err != nil {
 					return false, err
 				}
 			case AzureProvider, "velero.io/azure":
-				err := r.validateAzureBackupStorageLocation(*bslSpec.Velero)
+				err := r.validateAzureBackupStorageLocation(*bslSpec.Velero, bslSpec.ExternalCredential)
 				if err != nil {
 					return false, err
 				}
 			case GCPProvider, "velero.io/gcp":
-				err := r.validateGCPBackupStorageLocation(*bslSpec.Velero)
+				err := r.validateGCPBackupStorageLocation(*bslSpec.Velero, bslSpec.ExternalCredential)
 				if err != nil {
 					return false, err
 				}
@@ -207,7 +228,7 @@ func (r *DataProtectionApplicationReconciler) ReconcileBackupStorageLocations(lo
 			secretName, _, _ = r.getSecretNameAndKeyFromCloudStorage(bslSpec.CloudStorage)
 		}
 
-		if bslSpec.Velero != nil {
+		if bslSpec.Velero != nil && bslSpec.ExternalCredential == nil {
 			secretName, _, _ = r.getSecretNameAndKey(bslSpec.Velero.Config, bslSpec.Velero.Credential, oadpv1alpha1.DefaultPlugin(bslSpec.Velero.Provider))
 		}
 		err = r.UpdateCredentialsSecretLabels(secretName, dpa.Name)
@@ -233,6 +254,16 @@ func (r *DataProtectionApplicationReconciler) ReconcileBackupStorageLocations(lo
 				if bsl.ResourceVersion != "" {
 					bsl.Spec.Default = existingDefault
 				}
+				// Use the credentials mounted from the external secret store
+				if bslSpec.ExternalCredential != nil {
+					bsl.Spec.Config = externalCredentialConfig(bsl.Spec.Config, bslSpec.ExternalCredential)
+					bsl.Spec.Credential = nil
+				}
+				// Use the credential secret key mounted for this location only
+				if bslSpec.MountCredential && bslSpec.Velero.Credential != nil {
+					bsl.Spec.Config = mountedCredentialConfig(bsl.Spec.Config, bslSpec.Velero.Credential)
+					bsl.Spec.Credential = nil
+				}
 				return nil
 			}
 			if bslSpec.CloudStorage != nil {
@@ -500,9 +531,9 @@ func (r *DataProtectionApplicationReconciler) populateBSLFromCloudStorage(bslSpe
 	return nil
 }
 
-func (r *DataProtectionApplicationReconciler) validateAWSBackupStorageLocation(bslSpec velerov1.BackupStorageLocationSpec) error {
+func (r *DataProtectionApplicationReconciler) validateAWSBackupStorageLocation(bslSpec velerov1.BackupStorageLocationSpec, externalCredential *oadpv1alpha1.ExternalCredential) error {
 	// validate provider plugin and secret
-	err := r.validateProviderPluginAndSecret(bslSpec)
+	err := r.validateProviderPluginAndSecret(bslSpec, externalCredential)
 	if err != nil {
 		return err
 	}
@@ -534,9 +565,9 @@ func (r *DataProtectionApplicationReconciler) validateAWSBackupStorageLocation(b
 	return nil
 }
 
-func (r *DataProtectionApplicationReconciler) validateAzureBackupStorageLocation(bslSpec velerov1.BackupStorageLocationSpec) error {
+func (r *DataProtectionApplicationReconciler) validateAzureBackupStorageLocation(bslSpec velerov1.BackupStorageLocationSpec, externalCredential *oadpv1alpha1.ExternalCredential) error {
 	// validate provider plugin and secret
-	err := r.validateProviderPluginAndSecret(bslSpec)
+	err := r.validateProviderPluginAndSecret(bslSpec, externalCredential)
 	if err != nil {
 		return err
 	}
@@ -562,12 +593,34 @@ func (r *DataProtectionApplicationReconciler) validateAzureBackupStorageLocation
 		return fmt.Errorf("prefix for Azure backupstoragelocation object storage cannot be empty. it is required for backing up images")
 	}
 
+	if externalCredential == nil && !r.dpa.Spec.Configuration.Velero.HasFeatureFlag("no-secret") {
+		return r.validateAzureCredentials(bslSpec)
+	}
+
+	return nil
+}
+
+// validateAzureCredentials checks that the secret of the Azure backupstoragelocation holds complete
+// credentials for the authentication method the Velero Azure plugin resolves from it
+func (r *DataProtectionApplicationReconciler) validateAzureCredentials(bslSpec velerov1.BackupStorageLocationSpec) error {
+	secretName, secretKey, _ := r.getSecretNameAndKey(bslSpec.Config, bslSpec.Credential, oadpv1alpha1.DefaultPluginMicrosoftAzure)
+	secret, err := r.getProviderSecret(secretName)
+	if err != nil {
+		return err
+	}
+	creds := azurecreds.ForLocation(secret.Data, secretKey, bslSpec.Config)
+	if err := creds.Validate(); err != nil {
+		return fmt.Errorf("invalid credentials in secret %s for Azure backupstoragelocation: %w", secretName, err)
+	}
+	if envVar := bslSpec.Config[azurecreds.StorageAccountKeyEnvVarConfigKey]; envVar != "" && creds.StorageAccountKey == "" {
+		return fmt.Errorf("storageAccountKeyEnvVar %s of Azure backupstoragelocation is not set in secret %s", envVar, secretName)
+	}
 	return nil
 }
 
-func (r *DataProtectionApplicationReconciler) validateGCPBackupStorageLocation(bslSpec velerov1.BackupStorageLocationSpec) error {
+func (r *DataProtectionApplicationReconciler) validateGCPBackupStorageLocation(bslSpec velerov1.BackupStorageLocationSpec, externalCredential *oadpv1alpha1.ExternalCredential) error {
 	// validate provider plugin and secret
-	err := r.validateProviderPluginAndSecret(bslSpec)
+	err := r.validateProviderPluginAndSecret(bslSpec, externalCredential)
 	if err != nil {
 		return err
 	}
@@ -596,7 +649,7 @@ func pluginExistsInVeleroCR(configuredPlugins []oadpv1alpha1.DefaultPlugin, expe
 	return false
 }
 
-func (r *DataProtectionApplicationReconciler) validateProviderPluginAndSecret(bslSpec velerov1.BackupStorageLocationSpec) error {
+func (r *DataProtectionApplicationReconciler) validateProviderPluginAndSecret(bslSpec velerov1.BackupStorageLocationSpec, externalCredential *oadpv1alpha1.ExternalCredential) error {
 	if r.dpa.Spec.Configuration.Velero.HasFeatureFlag("no-secret") {
 		return nil
 	}
@@ -605,6 +658,10 @@ func (r *DataProtectionApplicationReconciler) validateProviderPluginAndSecret(bs
 		r.Log.Info(fmt.Sprintf("%s backupstoragelocation is configured but velero plugin for %s is not present", bslSpec.Provider, bslSpec.Provider))
 		//TODO: set warning condition on Velero CR
 	}
+	// external credentials are validated without reading them
+	if externalCredential != nil {
+		return nil
+	}
 	secretName, _, _ := r.getSecretNameAndKey(bslSpec.Config, bslSpec.Credential, oadpv1alpha1.DefaultPlugin(bslSpec.Provider))
 
 	_, err := r.getProviderSecret(secretName)
@@ -641,8 +698,8 @@ func (r *DataProtectionApplicationReconciler) ensurePrefixWhenBackupImages(bsl *
 }
 
 func (r *DataProtectionApplicationReconciler) ensureSecretDataExists(bsl *oadpv1alpha1.BackupLocation) error {
-	// Don't check if the Velero feature flag 'no-secret' is set
-	if r.dpa.Spec.Configuration.Velero.HasFeatureFlag("no-secret") {
+	// Don't check if the Velero feature flag 'no-secret' is set or the credentials come from an external secret store
+	if r.dpa.Spec.Configuration.Velero.HasFeatureFlag("no-secret") || bsl.ExternalCredential != nil {
 		return nil
 	}
 
@@ -729,6 +786,11 @@ func (r *DataProtectionApplicationReconciler) patchSecretsForBSL(bsl *velerov1.B
 	var provider, secretName string
 	var bslConfig map[string]string
 
+	// credentials of an external secret store are not patched
+	if bslSpec.ExternalCredential != nil {
+		return nil
+	}
+
 	if bslSpec.Velero != nil {
 		provider = string(bslSpec.Velero.Provider)
 		secretName, _, _ = r.getSecretNameAndKey(bslSpec.Velero.Config, bslSpec.Velero.Credential, oadpv1alpha1.DefaultPlugin(bslSpec.Velero.Provider))
@@ -905,7 +967,8 @@ func (r *DataProtectionApplicationReconciler) patchAzureSecretWithResourceGroup(
 	return nil
 }
 
-// processCACertForBSLs creates a ConfigMap containing CA certificates from BackupStorageLocations
+// processCACertForBSLs creates a ConfigMap containing CA certificates from BackupStorageLocations of every provider,
+// merged with the cluster trusted CA bundle
 // Returns the ConfigMap name if certificates were found, empty string otherwise
 func (r *DataProtectionApplicationReconciler) processCACertForBSLs() (string, error) {
 	dpa := r.dpa
@@ -913,7 +976,7 @@ func (r *DataProtectionApplicationReconciler) processCACertForBSLs() (string, er
 	collectedCerts := make(map[string]bool)    // Track unique certificates to avoid duplicates
 	processedBSLNames := make(map[string]bool) // Track which BSLs have been processed from DPA spec
 
-	// First, collect all unique CA certificates from AWS BSLs defined in the DPA spec
+	// First, collect all unique CA certificates from the BSLs defined in the DPA spec, of every provider
 	for i, bslSpec := range dpa.Spec.BackupLocations {
 		var caCert []byte
 		var provider string
@@ -947,11 +1010,6 @@ func (r *DataProtectionApplicationReconciler) processCACertForBSLs() (string, er
 			}
 		}
 
-		// Only process CA certificates from AWS providers
-		if !strings.Contains(strings.ToLower(provider), "aws") {
-			continue
-		}
-
 		// Append certificate if found and not already collected
 		if len(caCert) > 0 {
 			certStr := string(caCert)
@@ -976,7 +1034,7 @@ func (r *DataProtectionApplicationReconciler) processCACertForBSLs() (string, er
 					caCertData = append(caCertData, '\n')
 				}
 				if debugMode {
-					r.Log.Info("Added CA certificate from DPA AWS BSL", "bsl", bslName, "provider", provider)
+					r.Log.Info("Added CA certificate from DPA BSL", "bsl", bslName, "provider", provider)
 				}
 			}
 		}
@@ -995,11 +1053,6 @@ func (r *DataProtectionApplicationReconciler) processCACertForBSLs() (string, er
 				continue
 			}
 
-			// Only process BSLs with AWS provider
-			if !strings.Contains(strings.ToLower(bsl.Spec.Provider), "aws") {
-				continue
-			}
-
 			// Check for CA certificate in this BSL
 			if bsl.Spec.ObjectStorage != nil && bsl.Spec.ObjectStorage.CACert != nil {
 				caCert := bsl.Spec.ObjectStorage.CACert
@@ -1026,7 +1079,7 @@ func (r *DataProtectionApplicationReconciler) processCACertForBSLs() (string, er
 							caCertData = append(caCertData, '\n')
 						}
 						if debugMode {
-							r.Log.Info("Added CA certificate from additional AWS BSL", "bsl", bsl.Name, "provider", bsl.Spec.Provider)
+							r.Log.Info("Added CA certificate from additional BSL", "bsl", bsl.Name, "provider", bsl.Spec.Provider)
 						}
 					}
 				}
@@ -1034,10 +1087,13 @@ func (r *DataProtectionApplicationReconciler) processCACertForBSLs() (string, er
 		}
 	}
 
-	// Include system default CA certificates if available, but only if we have custom CAs
-	if len(caCertData) > 0 {
-		systemCACerts := r.getSystemCACertificates()
-		if len(systemCACerts) > 0 {
+	// Include the cluster trusted CA bundle, which holds the system CAs and the custom CA of the cluster proxy.
+	// Without an injected bundle, include the system default CA certificates if available, but only if we have custom CAs
+	if len(caCertData) > 0 || r.clusterProxy.hasTrustedCABundle() {
+		if r.clusterProxy != nil && len(r.clusterProxy.trustedCABundle) > 0 {
+			caCertData = append(caCertData, []byte("# Cluster trusted CA certificates\n")...)
+			caCertData = append(caCertData, r.clusterProxy.trustedCABundle...)
+		} else if systemCACerts := r.getSystemCACertificates(); len(systemCACerts) > 0 {
 			// Add a separator comment
 			caCertData = append(caCertData, []byte("# System default CA certificates\n")...)
 			caCertData = append(caCertData, systemCACerts...)
@@ -1102,6 +1158,10 @@ func (r *DataProtectionApplicationReconciler) processCACertForBSLs() (string, er
 // getSystemCACertificates retrieves system default CA certificates from the container filesystem.
 // It checks common locations for CA certificate bundles and returns the content if found.
 func (r *DataProtectionApplicationReconciler) getSystemCACertificates() []byte {
+	if r.systemCACertificates != nil {
+		return r.systemCACertificates()
+	}
+
 	// Common locations for CA certificate bundles in container images
 	caPaths := []string{
 		"/etc/ssl/certs/ca-certificates.crt",                // Debian/Ubuntu
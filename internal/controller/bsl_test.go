@@ -5485,11 +5485,10 @@ HREEQTBM----END CERTIFICATE-----`
 				// Verify content based on test case
 				bundleContent := configMap.Data[caBundleFileName]
 				if strings.Contains(tt.name, "Multiple BSLs with different CA certificates") {
-					// Verify only AWS certificates are concatenated (Azure is filtered out)
+					// Verify the certificates of every provider are concatenated
 					assert.Contains(t, bundleContent, "First CA Certificate")
 					assert.Contains(t, bundleContent, "Second CA Certificate")
-					// Azure certificate should NOT be included (provider filtering)
-					assert.NotContains(t, bundleContent, "Third CA Certificate")
+					assert.Contains(t, bundleContent, "Third CA Certificate")
 				} else if strings.Contains(tt.name, "Multiple BSLs with duplicate CA certificates") {
 					// Verify duplicate is only included once
 					assert.Equal(t, 1, strings.Count(bundleContent, testCACertPEM))
@@ -5805,3 +5804,55 @@ ddummyenddummyenddummyenddummyend
 		})
 	}
 }
+
+func TestDPAReconciler_validateAzureCredentials(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          map[string][]byte
+		config        map[string]string
+		wantErrorText string
+	}{
+		{
+			name:   "storage account key",
+			data:   map[string][]byte{"cloud": []byte("AZURE_STORAGE_ACCOUNT_ACCESS_KEY=key\nAZURE_CLOUD_NAME=AzurePublicCloud")},
+			config: map[string]string{"storageAccountKeyEnvVar": "AZURE_STORAGE_ACCOUNT_ACCESS_KEY"},
+		},
+		{
+			name: "service principal",
+			data: map[string][]byte{"cloud": []byte("AZURE_TENANT_ID=tenant\nAZURE_CLIENT_ID=client\nAZURE_CLIENT_SECRET=secret")},
+		},
+		{
+			name:          "storage account key variable missing",
+			data:          map[string][]byte{"cloud": []byte("AZURE_TENANT_ID=tenant\nAZURE_CLIENT_ID=client\nAZURE_CLIENT_SECRET=secret")},
+			config:        map[string]string{"storageAccountKeyEnvVar": "AZURE_STORAGE_ACCOUNT_ACCESS_KEY"},
+			wantErrorText: "storageAccountKeyEnvVar AZURE_STORAGE_ACCOUNT_ACCESS_KEY of Azure backupstoragelocation is not set in secret cloud-credentials-azure",
+		},
+		{
+			name:          "client secret without tenant",
+			data:          map[string][]byte{"cloud": []byte("AZURE_CLIENT_ID=client\nAZURE_CLIENT_SECRET=secret")},
+			wantErrorText: "invalid credentials in secret cloud-credentials-azure for Azure backupstoragelocation: service principal credentials require AZURE_TENANT_ID and AZURE_CLIENT_ID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials-azure", Namespace: "test-ns"},
+				Data:       tt.data,
+			}
+			r := &DataProtectionApplicationReconciler{
+				Client:         getFakeClientFromObjectsForTest(t, secret),
+				Log:            logr.Discard(),
+				Context:        newContextForTest(),
+				NamespacedName: types.NamespacedName{Namespace: "test-ns", Name: "test-dpa"},
+				dpa:            &oadpv1alpha1.DataProtectionApplication{},
+			}
+			err := r.validateAzureCredentials(velerov1.BackupStorageLocationSpec{Provider: "azure", Config: tt.config})
+			if tt.wantErrorText != "" {
+				assert.EqualError(t, err, tt.wantErrorText)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
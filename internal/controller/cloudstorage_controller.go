@@ -73,6 +73,10 @@ func (b CloudStorageReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	bucket := oadpv1alpha1.CloudStorage{}
 
 	if err := b.Client.Get(ctx, req.NamespacedName, &bucket); err != nil {
+		if errors.IsNotFound(err) {
+			// drop the cached credentials of the deleted bucket
+			bucketpkg.EvictCredentials(req.NamespacedName)
+		}
 		logger.Error(err, "unable to fetch bucket CR")
 		return result, nil
 	}
@@ -109,7 +113,7 @@ func (b CloudStorageReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			return ctrl.Result{Requeue: true}, nil
 		}
 		if shouldDelete && bucket.DeletionTimestamp != nil {
-			deleted, err := clnt.Delete()
+			deleted, err := clnt.Delete(ctx)
 			if err != nil {
 				logger.Error(err, "unable to delete bucket")
 				b.EventRecorder.Event(&bucket, corev1.EventTypeWarning, "UnableToDeleteBucket", fmt.Sprintf("unable to delete bucket: %v", bucket.Spec.Name))
@@ -158,9 +162,9 @@ func (b CloudStorageReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		logger.V(1).Info(fmt.Sprintf("Following standardized STS workflow, secret %s is available", secretName))
 	}
 	// Now continue with bucket creation as secret exists and we are good to go !!!
-	if ok, err = clnt.Exists(); !ok && err == nil {
+	if ok, err = clnt.Exists(ctx); !ok && err == nil {
 		// Handle Creation if bucket does not exist
-		created, err := clnt.Create()
+		created, err := clnt.Create(ctx)
 		if !created || err != nil {
 			logger.Info("unable to create object bucket", "error", err)
 			b.EventRecorder.Event(&bucket, corev1.EventTypeWarning, "BucketNotCreated", fmt.Sprintf("unable to create bucket: %v", err))
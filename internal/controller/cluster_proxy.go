@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	"golang.org/x/net/http/httpproxy"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+const (
+	// trustedCABundleConfigMapName is the ConfigMap the cluster network operator injects the cluster trusted CA bundle into
+	trustedCABundleConfigMapName = "oadp-trusted-ca-bundle"
+	// injectTrustedCABundleLabel requests the injection of the cluster trusted CA bundle into a ConfigMap
+	injectTrustedCABundleLabel = "config.openshift.io/inject-trusted-cabundle"
+	// trustedCABundleKey is the ConfigMap key of the injected trusted CA bundle
+	trustedCABundleKey = "ca-bundle.crt"
+	// sslCertFileEnvVar overrides the system certificate pool of the Go and OpenSSL clients
+	sslCertFileEnvVar = "SSL_CERT_FILE"
+)
+
+// clusterProxyConfig is the cluster-wide proxy configuration of config.openshift.io/v1 Proxy cluster
+type clusterProxyConfig struct {
+	httpProxy  string
+	httpsProxy string
+	noProxy    string
+	// customTrustedCA is true when the Proxy references a user provided trusted CA ConfigMap
+	customTrustedCA bool
+	// trustedCABundle is the cluster trusted CA bundle injected into the trusted CA ConfigMap, system CAs included
+	trustedCABundle []byte
+}
+
+// envVars returns the proxy environment variables, in upper and lower case like proxy.ReadProxyVarsFromEnv
+func (c *clusterProxyConfig) envVars() []corev1.EnvVar {
+	if c == nil {
+		return nil
+	}
+	var envVars []corev1.EnvVar
+	for _, envVar := range []corev1.EnvVar{
+		{Name: common.HTTPProxyEnvVar, Value: c.httpProxy},
+		{Name: common.HTTPSProxyEnvVar, Value: c.httpsProxy},
+		{Name: common.NoProxyEnvVar, Value: c.noProxy},
+	} {
+		if envVar.Value == "" {
+			continue
+		}
+		envVars = append(envVars, envVar, corev1.EnvVar{Name: strings.ToLower(envVar.Name), Value: envVar.Value})
+	}
+	return envVars
+}
+
+// hasTrustedCABundle returns whether the cluster trusts a custom CA and its bundle was injected
+func (c *clusterProxyConfig) hasTrustedCABundle() bool {
+	return c != nil && c.customTrustedCA && len(c.trustedCABundle) > 0
+}
+
+// proxyFunc returns the proxy of the requests of the operator, the proxy of the operator environment when the
+// cluster has no proxy
+func (c *clusterProxyConfig) proxyFunc() func(*http.Request) (*url.URL, error) {
+	if c == nil || c.httpProxy == "" && c.httpsProxy == "" {
+		return http.ProxyFromEnvironment
+	}
+	proxyURL := (&httpproxy.Config{HTTPProxy: c.httpProxy, HTTPSProxy: c.httpsProxy, NoProxy: c.noProxy}).ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyURL(req.URL)
+	}
+}
+
+// getClusterProxy returns the cluster Proxy, nil when the cluster has none
+func getClusterProxy(ctx context.Context, c client.Client) (*configv1.Proxy, error) {
+	clusterProxy := &configv1.Proxy{}
+	if err := c.Get(ctx, types.NamespacedName{Name: Cluster}, clusterProxy); err != nil {
+		if k8serror.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return clusterProxy, nil
+}
+
+// getClusterProxyConfig returns the effective proxy configuration of the cluster Proxy and the trusted CA bundle
+// injected in the namespace, nil when the cluster has no Proxy
+func getClusterProxyConfig(ctx context.Context, c client.Client, namespace string) (*clusterProxyConfig, error) {
+	clusterProxy, err := getClusterProxy(ctx, c)
+	if err != nil || clusterProxy == nil {
+		return nil, err
+	}
+	// the status holds the effective configuration, noProxy includes the cluster networks
+	config := &clusterProxyConfig{
+		httpProxy:       clusterProxy.Status.HTTPProxy,
+		httpsProxy:      clusterProxy.Status.HTTPSProxy,
+		noProxy:         clusterProxy.Status.NoProxy,
+		customTrustedCA: clusterProxy.Spec.TrustedCA.Name != "",
+	}
+	if config.httpProxy == "" && config.httpsProxy == "" {
+		config.httpProxy = clusterProxy.Spec.HTTPProxy
+		config.httpsProxy = clusterProxy.Spec.HTTPSProxy
+		config.noProxy = clusterProxy.Spec.NoProxy
+	}
+
+	trustedCA := &corev1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: trustedCABundleConfigMapName}, trustedCA); err != nil {
+		if k8serror.IsNotFound(err) {
+			return config, nil
+		}
+		return nil, err
+	}
+	config.trustedCABundle = []byte(trustedCA.Data[trustedCABundleKey])
+	return config, nil
+}
+
+// ReconcileClusterProxy reads the cluster-wide proxy configuration propagated to the Velero, NodeAgent and
+// repository maintenance pods, and requests the injection of the cluster trusted CA bundle merged into the CA
+// bundle of the backup storage locations
+func (r *DataProtectionApplicationReconciler) ReconcileClusterProxy(log logr.Logger) (bool, error) {
+	r.clusterProxy = nil
+	clusterProxy, err := getClusterProxy(r.Context, r.Client)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the cluster proxy: %w", err)
+	}
+	if clusterProxy == nil {
+		return true, nil
+	}
+
+	dpa := r.dpa
+	trustedCA := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      trustedCABundleConfigMapName,
+			Namespace: dpa.Namespace,
+		},
+	}
+	// the data is owned by the cluster network operator, only the labels requesting the injection are set
+	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, trustedCA, func() error {
+		if err := controllerutil.SetControllerReference(dpa, trustedCA, r.Scheme); err != nil {
+			return err
+		}
+		if trustedCA.Labels == nil {
+			trustedCA.Labels = map[string]string{}
+		}
+		trustedCA.Labels["app.kubernetes.io/name"] = common.Velero
+		trustedCA.Labels["app.kubernetes.io/managed-by"] = common.OADPOperator
+		trustedCA.Labels["app.kubernetes.io/component"] = "ca-bundle"
+		trustedCA.Labels[oadpv1alpha1.OadpOperatorLabel] = "True"
+		trustedCA.Labels[injectTrustedCABundleLabel] = "true"
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to request the cluster trusted CA bundle: %w", err)
+	}
+	if op == controllerutil.OperationResultCreated || op == controllerutil.OperationResultUpdated {
+		r.EventRecorder.Event(trustedCA, corev1.EventTypeNormal, "TrustedCABundleConfigMapReconciled",
+			fmt.Sprintf("performed %s on trusted CA bundle ConfigMap %s/%s", op, trustedCA.Namespace, trustedCA.Name))
+	}
+
+	if r.clusterProxy, err = getClusterProxyConfig(r.Context, r.Client, dpa.Namespace); err != nil {
+		return false, fmt.Errorf("failed to get the cluster proxy configuration: %w", err)
+	}
+	// the proxy URLs may hold credentials, they are not logged
+	log.V(1).Info("Cluster proxy configuration", "proxy", len(r.clusterProxy.envVars()) > 0,
+		"customTrustedCA", r.clusterProxy.customTrustedCA, "trustedCABundleInjected", len(r.clusterProxy.trustedCABundle) > 0)
+	return true, nil
+}
+
+// isTrustedCABundleConfigMap returns whether the object is a ConfigMap the cluster trusted CA bundle is injected into
+func isTrustedCABundleConfigMap(object client.Object) bool {
+	_, isConfigMap := object.(*corev1.ConfigMap)
+	return isConfigMap && object.GetLabels()[injectTrustedCABundleLabel] == "true"
+}
+
+// clusterProxyChanged returns whether the configuration or the effective configuration of the Proxy changed
+func clusterProxyChanged(oldProxy, newProxy *configv1.Proxy) bool {
+	return !equality.Semantic.DeepEqual(oldProxy.Spec, newProxy.Spec) || !equality.Semantic.DeepEqual(oldProxy.Status, newProxy.Status)
+}
+
+// clusterProxyDPARequests maps the cluster Proxy to every DPA, which propagate its configuration to their pods
+func (r *DataProtectionApplicationReconciler) clusterProxyDPARequests(ctx context.Context, object client.Object) []reconcile.Request {
+	if object.GetName() != Cluster {
+		return nil
+	}
+	dpaList := &oadpv1alpha1.DataProtectionApplicationList{}
+	if err := r.List(ctx, dpaList); err != nil {
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(dpaList.Items))
+	for i := range dpaList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&dpaList.Items[i])})
+	}
+	return requests
+}
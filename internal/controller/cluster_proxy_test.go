@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func TestClusterProxyConfig(t *testing.T) {
+	var noProxy *clusterProxyConfig
+	require.Nil(t, noProxy.envVars())
+	require.False(t, noProxy.hasTrustedCABundle())
+
+	config := &clusterProxyConfig{httpsProxy: "http://proxy.example.com:3128", noProxy: ".cluster.local,.svc,10.0.0.0/16"}
+	require.Equal(t, []corev1.EnvVar{
+		{Name: common.HTTPSProxyEnvVar, Value: "http://proxy.example.com:3128"},
+		{Name: "https_proxy", Value: "http://proxy.example.com:3128"},
+		{Name: common.NoProxyEnvVar, Value: ".cluster.local,.svc,10.0.0.0/16"},
+		{Name: "no_proxy", Value: ".cluster.local,.svc,10.0.0.0/16"},
+	}, config.envVars())
+
+	proxyFunc := config.proxyFunc()
+	request, err := http.NewRequest(http.MethodHead, "https://s3.us-east-1.amazonaws.com", nil)
+	require.NoError(t, err)
+	proxyURL, err := proxyFunc(request)
+	require.NoError(t, err)
+	require.Equal(t, "proxy.example.com:3128", proxyURL.Host)
+	request, err = http.NewRequest(http.MethodHead, "https://minio.minio.svc", nil)
+	require.NoError(t, err)
+	proxyURL, err = proxyFunc(request)
+	require.NoError(t, err)
+	require.Nil(t, proxyURL, "noProxy is honored")
+
+	config.trustedCABundle = []byte("bundle")
+	require.False(t, config.hasTrustedCABundle(), "the cluster has no custom trusted CA")
+	config.customTrustedCA = true
+	require.True(t, config.hasTrustedCABundle())
+}
+
+func TestDPAReconciler_ReconcileClusterProxy(t *testing.T) {
+	bslCA := generateTestCACert("azure-storage-ca")
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns", UID: "test-dpa-uid"},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{Velero: &oadpv1alpha1.VeleroConfig{}},
+			BackupLocations: []oadpv1alpha1.BackupLocation{{
+				Velero: &velerov1.BackupStorageLocationSpec{
+					Provider: AzureProvider,
+					StorageType: velerov1.StorageType{
+						ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "test-bucket", CACert: bslCA},
+					},
+				},
+			}},
+		},
+	}
+	clusterProxy := &configv1.Proxy{
+		ObjectMeta: metav1.ObjectMeta{Name: Cluster},
+		Spec: configv1.ProxySpec{
+			HTTPProxy:  "http://proxy.example.com:3128",
+			HTTPSProxy: "http://proxy.example.com:3128",
+			TrustedCA:  configv1.ConfigMapNameReference{Name: "user-ca-bundle"},
+		},
+		Status: configv1.ProxyStatus{
+			HTTPProxy:  "http://proxy.example.com:3128",
+			HTTPSProxy: "http://proxy.example.com:3128",
+			NoProxy:    ".cluster.local,.svc,localhost",
+		},
+	}
+	fakeClient := getFakeClientFromObjectsForTest(t, dpa, clusterProxy)
+	r := &DataProtectionApplicationReconciler{
+		Client:         fakeClient,
+		Scheme:         fakeClient.Scheme(),
+		dpa:            dpa,
+		Log:            logr.Discard(),
+		Context:        newContextForTest(),
+		NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+		EventRecorder:  newEventRecorder(),
+	}
+
+	ok, err := r.ReconcileClusterProxy(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	trustedCA := &corev1.ConfigMap{}
+	require.NoError(t, fakeClient.Get(r.Context, client.ObjectKey{Namespace: dpa.Namespace, Name: trustedCABundleConfigMapName}, trustedCA))
+	require.Equal(t, "true", trustedCA.Labels[injectTrustedCABundleLabel])
+	require.True(t, metav1.IsControlledBy(trustedCA, dpa))
+	require.Equal(t, ".cluster.local,.svc,localhost", r.clusterProxy.noProxy, "the status is the effective configuration")
+	require.False(t, r.clusterProxy.hasTrustedCABundle(), "the bundle is not injected yet")
+
+	// the cluster network operator injects the bundle
+	clusterCA := generateTestCACert("cluster-proxy-ca")
+	trustedCA.Data = map[string]string{trustedCABundleKey: string(clusterCA)}
+	require.NoError(t, fakeClient.Update(r.Context, trustedCA))
+	scheme, err := getSchemeForFakeClient()
+	require.NoError(t, err)
+	require.False(t, veleroPredicate(scheme).Update(event.UpdateEvent{ObjectOld: trustedCA.DeepCopy(), ObjectNew: trustedCA}),
+		"an unchanged resource version is filtered")
+	injected := trustedCA.DeepCopy()
+	injected.ResourceVersion = "changed"
+	require.True(t, veleroPredicate(scheme).Update(event.UpdateEvent{ObjectOld: trustedCA, ObjectNew: injected}))
+
+	ok, err = r.ReconcileClusterProxy(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, r.clusterProxy.hasTrustedCABundle())
+
+	// the bundle of the BSL CA certificates includes the cluster trusted CA bundle, mounted with SSL_CERT_FILE
+	deployment := &appsv1.Deployment{}
+	container := &corev1.Container{}
+	require.NoError(t, r.processCACertificatesForVelero(deployment, container))
+	bundle := &corev1.ConfigMap{}
+	require.NoError(t, fakeClient.Get(r.Context, client.ObjectKey{Namespace: dpa.Namespace, Name: caBundleConfigMapName}, bundle))
+	require.Contains(t, bundle.Data[caBundleFileName], string(bslCA))
+	require.Contains(t, bundle.Data[caBundleFileName], string(clusterCA))
+	require.Contains(t, container.Env, corev1.EnvVar{Name: sslCertFileEnvVar, Value: caCertMountPath + "/" + caBundleFileName})
+
+	// the DataProtectionTest clients trust the cluster CA
+	tlsConfig, err := buildTLSConfig(&oadpv1alpha1.DataProtectionTest{}, nil, r.clusterProxy, nil, r.Log)
+	require.NoError(t, err)
+	require.NotNil(t, tlsConfig.RootCAs)
+
+	// without the Proxy, the configuration is cleared
+	require.NoError(t, fakeClient.Delete(r.Context, clusterProxy))
+	ok, err = r.ReconcileClusterProxy(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Nil(t, r.clusterProxy)
+}
+
+func TestDPAReconciler_clusterProxyDPARequests(t *testing.T) {
+	first := &oadpv1alpha1.DataProtectionApplication{ObjectMeta: metav1.ObjectMeta{Name: "first", Namespace: "ns-a"}}
+	second := &oadpv1alpha1.DataProtectionApplication{ObjectMeta: metav1.ObjectMeta{Name: "second", Namespace: "ns-b"}}
+	fakeClient := getFakeClientFromObjectsForTest(t, first, second)
+	r := &DataProtectionApplicationReconciler{Client: fakeClient}
+
+	requests := r.clusterProxyDPARequests(newContextForTest(), &configv1.Proxy{ObjectMeta: metav1.ObjectMeta{Name: Cluster}})
+	require.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "ns-a", Name: "first"}},
+		{NamespacedName: types.NamespacedName{Namespace: "ns-b", Name: "second"}},
+	}, requests)
+	require.Empty(t, r.clusterProxyDPARequests(newContextForTest(), &configv1.Proxy{ObjectMeta: metav1.ObjectMeta{Name: "other"}}))
+
+	predicate := veleroPredicate(fakeClient.Scheme())
+	require.True(t, predicate.Create(event.CreateEvent{Object: &configv1.Proxy{ObjectMeta: metav1.ObjectMeta{Name: Cluster}}}))
+	require.True(t, predicate.Delete(event.DeleteEvent{Object: &configv1.Proxy{ObjectMeta: metav1.ObjectMeta{Name: Cluster}}}))
+}
@@ -0,0 +1,436 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/cloudprovider"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+const (
+	defaultCredentialHealthCheckInterval = time.Hour
+	defaultCredentialExpiryWarningPeriod = 30 * 24 * time.Hour
+	credentialHealthCheckTimeout         = 30 * time.Second
+	serviceAccountTokenExpirationSeconds = 600
+	// credentialHealthCheckBudget bounds the duration of all the checks of a DPA
+	credentialHealthCheckBudget = 2 * time.Minute
+	// credentialHealthCheckPollInterval is how often a DPA is reconciled while its checks are running
+	credentialHealthCheckPollInterval = 5 * time.Second
+	// maxConcurrentCredentialChecks limits the secret keys checked at the same time
+	maxConcurrentCredentialChecks = 4
+)
+
+// credentialCheckTarget is a secret key used by one or more BSLs or VSLs.
+type credentialCheckTarget struct {
+	secretName string
+	secretKey  string
+	provider   string
+	opts       cloudprovider.CredentialCheckOptions
+	// data is the content of the secret key, read before the check starts
+	data []byte
+	// readErr is why the secret key could not be read
+	readErr string
+}
+
+// credentialHealthResult is the outcome of the checks of a DPA, the condition of each secret without its type
+type credentialHealthResult struct {
+	conditions map[string]metav1.Condition
+	checkedAt  metav1.Time
+}
+
+// credentialHealthChecks runs the credential health checks of the DPAs in the background, so that slow cloud APIs
+// do not hold the DPA reconcile. The reconcile following the end of the checks reports their result.
+type credentialHealthChecks struct {
+	mu      sync.Mutex
+	running map[types.NamespacedName]bool
+	results map[types.NamespacedName]credentialHealthResult
+	// wg tracks the running checks, for tests
+	wg sync.WaitGroup
+}
+
+// start runs check in the background unless a check of the DPA is already running
+func (c *credentialHealthChecks) start(key types.NamespacedName, check func() credentialHealthResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running[key] {
+		return
+	}
+	if c.running == nil {
+		c.running = map[types.NamespacedName]bool{}
+		c.results = map[types.NamespacedName]credentialHealthResult{}
+	}
+	c.running[key] = true
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		result := check()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.running, key)
+		c.results[key] = result
+	}()
+}
+
+// isRunning returns whether a check of the DPA is running
+func (c *credentialHealthChecks) isRunning(key types.NamespacedName) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.running[key]
+}
+
+// take returns and forgets the result of the last completed check of the DPA
+func (c *credentialHealthChecks) take(key types.NamespacedName) (credentialHealthResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, found := c.results[key]
+	delete(c.results, key)
+	return result, found
+}
+
+// ReconcileCredentialHealth authenticates with each BSL and VSL secret once per interval and reports
+// the result in a CredentialsHealthy-<secret name> condition. The checks run in the background within
+// credentialHealthCheckBudget, and authentication failures do not fail the reconcile.
+func (r *DataProtectionApplicationReconciler) ReconcileCredentialHealth(log logr.Logger) (bool, error) {
+	key := client.ObjectKeyFromObject(r.dpa)
+	if r.credentialChecks == nil {
+		r.credentialChecks = &credentialHealthChecks{}
+	}
+	if !r.credentialHealthCheckEnabled() {
+		r.credentialChecks.take(key)
+		r.removeCredentialHealthConditions(nil)
+		r.dpa.Status.LastCredentialHealthCheck = nil
+		return true, nil
+	}
+
+	targets := r.credentialCheckTargets(log)
+	secretNames := map[string]bool{}
+	for _, target := range targets {
+		secretNames[target.secretName] = true
+	}
+	r.removeCredentialHealthConditions(secretNames)
+
+	if result, done := r.credentialChecks.take(key); done {
+		r.setCredentialHealthConditions(result, secretNames)
+	}
+	if r.credentialChecks.isRunning(key) || !r.credentialHealthCheckDue(secretNames) {
+		return true, nil
+	}
+
+	// the secrets are read now, the background checks do not use the reconciler state
+	for i := range targets {
+		secret, err := r.getProviderSecret(targets[i].secretName)
+		if err != nil {
+			targets[i].readErr = fmt.Sprintf("failed to get secret: %v", err)
+			continue
+		}
+		targets[i].data = secret.Data[targets[i].secretKey]
+		targets[i].opts.SecretLabels = secret.Labels
+	}
+	prober := r.credentialProber
+	if prober == nil {
+		prober = &cloudprovider.SDKCredentialProber{}
+	}
+	tokenSource := r.serviceAccountToken
+	if tokenSource == nil {
+		c, namespace := r.Client, r.dpa.Namespace
+		tokenSource = func(ctx context.Context, audience string) (string, error) {
+			return requestVeleroServiceAccountToken(ctx, c, namespace, audience)
+		}
+	}
+	expiryWarningPeriod := r.credentialExpiryWarningPeriod()
+	r.credentialChecks.start(key, func() credentialHealthResult {
+		return checkCredentialTargets(log, prober, tokenSource, targets, expiryWarningPeriod)
+	})
+	return true, nil
+}
+
+// checkCredentialTargets authenticates with the secret keys, a few at a time, and returns the condition of each
+// secret reporting the worst result of its keys
+func checkCredentialTargets(log logr.Logger, prober cloudprovider.CredentialProber, tokenSource func(context.Context, string) (string, error), targets []credentialCheckTarget, expiryWarningPeriod time.Duration) credentialHealthResult {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialHealthCheckBudget)
+	defer cancel()
+
+	type checkResult struct{ message, reason string }
+	checkResults := make([]checkResult, len(targets))
+	semaphore := make(chan struct{}, maxConcurrentCredentialChecks)
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			message, reason := checkCredentialTarget(ctx, log, prober, tokenSource, targets[i], expiryWarningPeriod)
+			checkResults[i] = checkResult{message: message, reason: reason}
+		}()
+	}
+	wg.Wait()
+
+	messages := map[string][]string{}
+	conditions := map[string]metav1.Condition{}
+	for i, target := range targets {
+		condition := conditions[target.secretName]
+		messages[target.secretName] = append(messages[target.secretName], checkResults[i].message)
+		if credentialReasonSeverity(checkResults[i].reason) >= credentialReasonSeverity(condition.Reason) {
+			condition.Reason = checkResults[i].reason
+		}
+		conditions[target.secretName] = condition
+	}
+	for secretName, condition := range conditions {
+		condition.Message = strings.Join(messages[secretName], "; ")
+		switch condition.Reason {
+		case oadpv1alpha1.CredentialsReasonAuthenticationFailed:
+			condition.Status = metav1.ConditionFalse
+		case oadpv1alpha1.CredentialsReasonNotChecked:
+			condition.Status = metav1.ConditionUnknown
+		default:
+			condition.Status = metav1.ConditionTrue
+		}
+		conditions[secretName] = condition
+	}
+	return credentialHealthResult{conditions: conditions, checkedAt: metav1.Now()}
+}
+
+// setCredentialHealthConditions reports the result of the checks of the secrets still used by the DPA. Events are
+// only emitted when the reason of a condition changes.
+func (r *DataProtectionApplicationReconciler) setCredentialHealthConditions(result credentialHealthResult, secretNames map[string]bool) {
+	for _, secretName := range sets.List(sets.KeySet(result.conditions)) {
+		if !secretNames[secretName] {
+			continue
+		}
+		condition := result.conditions[secretName]
+		condition.Type = oadpv1alpha1.ConditionCredentialsHealthyPrefix + secretName
+		previousReason := ""
+		if previous := apimeta.FindStatusCondition(r.dpa.Status.Conditions, condition.Type); previous != nil {
+			previousReason = previous.Reason
+		}
+		apimeta.SetStatusCondition(&r.dpa.Status.Conditions, condition)
+		if previousReason == condition.Reason {
+			continue
+		}
+		switch {
+		case condition.Reason == oadpv1alpha1.CredentialsReasonAuthenticationFailed || condition.Reason == oadpv1alpha1.CredentialsReasonExpiringSoon:
+			r.EventRecorder.Event(r.dpa, corev1.EventTypeWarning, "Credentials"+condition.Reason,
+				fmt.Sprintf("secret %s: %s", secretName, condition.Message))
+		case previousReason != "" && condition.Reason == oadpv1alpha1.CredentialsReasonAuthenticated:
+			r.EventRecorder.Event(r.dpa, corev1.EventTypeNormal, "Credentials"+condition.Reason,
+				fmt.Sprintf("secret %s: %s", secretName, condition.Message))
+		}
+	}
+	checkedAt := result.checkedAt
+	r.dpa.Status.LastCredentialHealthCheck = &checkedAt
+}
+
+// checkCredentialTarget authenticates with one secret key and returns the result message and condition reason.
+func checkCredentialTarget(ctx context.Context, log logr.Logger, prober cloudprovider.CredentialProber, tokenSource func(context.Context, string) (string, error), target credentialCheckTarget, expiryWarningPeriod time.Duration) (string, string) {
+	prefix := fmt.Sprintf("key %s (%s)", target.secretKey, target.provider)
+
+	if target.readErr != "" {
+		return fmt.Sprintf("%s: %s", prefix, target.readErr), oadpv1alpha1.CredentialsReasonAuthenticationFailed
+	}
+	if len(target.data) == 0 {
+		return fmt.Sprintf("%s: key not found in secret", prefix), oadpv1alpha1.CredentialsReasonAuthenticationFailed
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, credentialHealthCheckTimeout)
+	defer cancel()
+	opts := target.opts
+	opts.ServiceAccountToken = tokenSource
+	result := cloudprovider.CheckCredentials(ctx, prober, target.provider, target.data, opts)
+
+	switch {
+	case result.Skipped:
+		return fmt.Sprintf("%s: %v", prefix, result.Err), oadpv1alpha1.CredentialsReasonNotChecked
+	case result.Err != nil:
+		log.Info("credential health check failed", "secret", target.secretName, "key", target.secretKey, "error", result.Err.Error())
+		return fmt.Sprintf("%s: %v", prefix, result.Err), oadpv1alpha1.CredentialsReasonAuthenticationFailed
+	}
+
+	message := fmt.Sprintf("%s: authenticated as %s", prefix, result.Identity)
+	if result.ExpiresAt != nil {
+		message += fmt.Sprintf(", expires at %s", result.ExpiresAt.UTC().Format(time.RFC3339))
+		if time.Until(*result.ExpiresAt) < expiryWarningPeriod {
+			return message, oadpv1alpha1.CredentialsReasonExpiringSoon
+		}
+	}
+	return message, oadpv1alpha1.CredentialsReasonAuthenticated
+}
+
+// credentialCheckTargets returns the secret keys of the DPA BSLs and VSLs, without duplicates.
+func (r *DataProtectionApplicationReconciler) credentialCheckTargets(log logr.Logger) []credentialCheckTarget {
+	if r.dpa.Spec.Configuration != nil && r.dpa.Spec.Configuration.Velero != nil &&
+		r.dpa.Spec.Configuration.Velero.HasFeatureFlag("no-secret") {
+		return nil
+	}
+
+	var targets []credentialCheckTarget
+	seen := map[string]bool{}
+	add := func(secretName, secretKey, provider string, config map[string]string) {
+		if secretName == "" || secretKey == "" {
+			return
+		}
+		target := credentialCheckTarget{
+			secretName: secretName,
+			secretKey:  secretKey,
+			provider:   provider,
+			opts: cloudprovider.CredentialCheckOptions{
+				Profile: config[Profile],
+				Region:  config[Region],
+			},
+		}
+		key := strings.Join([]string{secretName, secretKey, provider, target.opts.Profile, target.opts.Region}, "/")
+		if !seen[key] {
+			seen[key] = true
+			targets = append(targets, target)
+		}
+	}
+
+	for _, bsl := range r.dpa.Spec.BackupLocations {
+		switch {
+		case bsl.ExternalCredential != nil:
+			// external credentials are only readable by the Secrets Store CSI driver
+			continue
+		case bsl.Velero != nil:
+			secretName, secretKey, _ := r.getSecretNameAndKey(bsl.Velero.Config, bsl.Velero.Credential, oadpv1alpha1.DefaultPlugin(bsl.Velero.Provider))
+			add(secretName, secretKey, bsl.Velero.Provider, bsl.Velero.Config)
+		case bsl.CloudStorage != nil:
+			bucket := &oadpv1alpha1.CloudStorage{}
+			if err := r.Get(r.Context, client.ObjectKey{Namespace: r.dpa.Namespace, Name: bsl.CloudStorage.CloudStorageRef.Name}, bucket); err != nil {
+				log.Info("skipping credential health check for CloudStorage", "name", bsl.CloudStorage.CloudStorageRef.Name, "error", err.Error())
+				continue
+			}
+			secretName, secretKey := bucket.Spec.CreationSecret.Name, bucket.Spec.CreationSecret.Key
+			if bsl.CloudStorage.Credential != nil {
+				secretName, secretKey = bsl.CloudStorage.Credential.Name, bsl.CloudStorage.Credential.Key
+			}
+			config := map[string]string{Region: bucket.Spec.Region}
+			if profile, exists := bsl.CloudStorage.Config[Profile]; exists {
+				config[Profile] = profile
+			}
+			add(secretName, secretKey, string(bucket.Spec.Provider), config)
+		}
+	}
+	for _, vsl := range r.dpa.Spec.SnapshotLocations {
+		if vsl.Velero == nil || vsl.ExternalCredential != nil {
+			continue
+		}
+		secretName, secretKey, _ := r.getSecretNameAndKey(vsl.Velero.Config, vsl.Velero.Credential, oadpv1alpha1.DefaultPlugin(vsl.Velero.Provider))
+		add(secretName, secretKey, vsl.Velero.Provider, vsl.Velero.Config)
+	}
+	return targets
+}
+
+// credentialHealthCheckDue returns true when the interval elapsed or a secret has not been checked yet.
+func (r *DataProtectionApplicationReconciler) credentialHealthCheckDue(secretNames map[string]bool) bool {
+	last := r.dpa.Status.LastCredentialHealthCheck
+	if last == nil || time.Since(last.Time) >= r.credentialHealthCheckInterval() {
+		return true
+	}
+	for secretName := range secretNames {
+		if apimeta.FindStatusCondition(r.dpa.Status.Conditions, oadpv1alpha1.ConditionCredentialsHealthyPrefix+secretName) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// credentialHealthRequeueAfter returns when the running checks are polled or the next credential health check is
+// due, or 0 when it is disabled.
+func (r *DataProtectionApplicationReconciler) credentialHealthRequeueAfter() time.Duration {
+	if !r.credentialHealthCheckEnabled() {
+		return 0
+	}
+	if r.credentialChecks != nil && r.credentialChecks.isRunning(client.ObjectKeyFromObject(r.dpa)) {
+		return credentialHealthCheckPollInterval
+	}
+	if r.dpa.Status.LastCredentialHealthCheck == nil {
+		return 0
+	}
+	remaining := r.credentialHealthCheckInterval() - time.Since(r.dpa.Status.LastCredentialHealthCheck.Time)
+	if remaining < time.Second {
+		return time.Second
+	}
+	return remaining
+}
+
+// removeCredentialHealthConditions removes the credential conditions of secrets not in keep.
+func (r *DataProtectionApplicationReconciler) removeCredentialHealthConditions(keep map[string]bool) {
+	var stale []string
+	for _, condition := range r.dpa.Status.Conditions {
+		secretName, found := strings.CutPrefix(condition.Type, oadpv1alpha1.ConditionCredentialsHealthyPrefix)
+		if found && !keep[secretName] {
+			stale = append(stale, condition.Type)
+		}
+	}
+	sort.Strings(stale)
+	for _, conditionType := range stale {
+		apimeta.RemoveStatusCondition(&r.dpa.Status.Conditions, conditionType)
+	}
+}
+
+// requestVeleroServiceAccountToken requests a short-lived token of the Velero service account in namespace for audience.
+func requestVeleroServiceAccountToken(ctx context.Context, c client.Client, namespace, audience string) (string, error) {
+	serviceAccount := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: common.Velero, Namespace: namespace},
+	}
+	tokenRequest := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			Audiences:         []string{audience},
+			ExpirationSeconds: ptr.To(int64(serviceAccountTokenExpirationSeconds)),
+		},
+	}
+	if err := c.SubResource("token").Create(ctx, serviceAccount, tokenRequest); err != nil {
+		return "", err
+	}
+	return tokenRequest.Status.Token, nil
+}
+
+func (r *DataProtectionApplicationReconciler) credentialHealthCheckEnabled() bool {
+	return r.dpa.Spec.CredentialHealthCheck != nil && r.dpa.Spec.CredentialHealthCheck.Enable
+}
+
+func (r *DataProtectionApplicationReconciler) credentialHealthCheckInterval() time.Duration {
+	if check := r.dpa.Spec.CredentialHealthCheck; check != nil && check.Interval != nil && check.Interval.Duration > 0 {
+		return check.Interval.Duration
+	}
+	return defaultCredentialHealthCheckInterval
+}
+
+func (r *DataProtectionApplicationReconciler) credentialExpiryWarningPeriod() time.Duration {
+	if check := r.dpa.Spec.CredentialHealthCheck; check != nil && check.ExpiryWarningPeriod != nil {
+		return check.ExpiryWarningPeriod.Duration
+	}
+	return defaultCredentialExpiryWarningPeriod
+}
+
+// credentialReasonSeverity orders the reasons so the condition of a secret used by several locations reports the worst result.
+func credentialReasonSeverity(reason string) int {
+	switch reason {
+	case oadpv1alpha1.CredentialsReasonAuthenticationFailed:
+		return 4
+	case oadpv1alpha1.CredentialsReasonExpiringSoon:
+		return 3
+	case oadpv1alpha1.CredentialsReasonNotChecked:
+		return 2
+	case oadpv1alpha1.CredentialsReasonAuthenticated:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/cloudprovider"
+)
+
+// mockCredentialProber rejects the AWS keys listed in rejected and reports expiresAt for Azure client secrets.
+type mockCredentialProber struct {
+	rejected  map[string]bool
+	expiresAt *time.Time
+	calls     atomic.Int32
+}
+
+func (m *mockCredentialProber) AWSCallerIdentity(ctx context.Context, creds cloudprovider.AWSStaticCredentials, region string) (string, error) {
+	m.calls.Add(1)
+	if m.rejected[creds.AccessKeyID] {
+		return "", errors.New("InvalidClientTokenId")
+	}
+	return "arn:aws:iam::123456789012:user/" + creds.AccessKeyID, nil
+}
+func (m *mockCredentialProber) AWSAssumeRoleWithWebIdentity(ctx context.Context, roleARN, token, region string) (string, error) {
+	m.calls.Add(1)
+	return roleARN, nil
+}
+func (m *mockCredentialProber) AzureToken(ctx context.Context, creds cloudprovider.AzureCredentials, federatedToken string) error {
+	m.calls.Add(1)
+	return nil
+}
+func (m *mockCredentialProber) AzureClientSecretExpiry(ctx context.Context, creds cloudprovider.AzureCredentials) (*time.Time, error) {
+	return m.expiresAt, nil
+}
+func (m *mockCredentialProber) GCPToken(ctx context.Context, credentialsJSON []byte, subjectToken string) error {
+	m.calls.Add(1)
+	return nil
+}
+func (m *mockCredentialProber) GCPKeyExpiry(ctx context.Context, credentialsJSON []byte) (*time.Time, error) {
+	return nil, nil
+}
+
+func TestDPAReconciler_ReconcileCredentialHealth(t *testing.T) {
+	awsSecret := func(name, accessKey string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns"},
+			Data: map[string][]byte{
+				"cloud": []byte("[default]\naws_access_key_id=" + accessKey + "\naws_secret_access_key=secret\n"),
+			},
+		}
+	}
+	azureSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials-azure", Namespace: "test-ns"},
+		Data: map[string][]byte{
+			"cloud": []byte("AZURE_TENANT_ID=tenant\nAZURE_CLIENT_ID=client\nAZURE_CLIENT_SECRET=secret\n"),
+		},
+	}
+	soon := time.Now().Add(24 * time.Hour)
+
+	newDPA := func() *oadpv1alpha1.DataProtectionApplication {
+		return &oadpv1alpha1.DataProtectionApplication{
+			ObjectMeta: metav1.ObjectMeta{Name: "dpa", Namespace: "test-ns"},
+			Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+				Configuration: &oadpv1alpha1.ApplicationConfig{
+					Velero: &oadpv1alpha1.VeleroConfig{},
+				},
+				BackupLocations: []oadpv1alpha1.BackupLocation{
+					{Velero: &velerov1.BackupStorageLocationSpec{Provider: "aws", Config: map[string]string{Region: "us-east-1"}}},
+					{Velero: &velerov1.BackupStorageLocationSpec{
+						Provider:   "aws",
+						Config:     map[string]string{Region: "us-east-1"},
+						Credential: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "rejected-credentials"}, Key: "cloud"},
+					}},
+				},
+				SnapshotLocations: []oadpv1alpha1.SnapshotLocation{
+					{Velero: &velerov1.VolumeSnapshotLocationSpec{Provider: "aws", Config: map[string]string{Region: "us-east-1"}}},
+					{Velero: &velerov1.VolumeSnapshotLocationSpec{Provider: "azure"}},
+				},
+				CredentialHealthCheck: &oadpv1alpha1.CredentialHealthCheck{Enable: true},
+			},
+			Status: oadpv1alpha1.DataProtectionApplicationStatus{
+				Conditions: []metav1.Condition{
+					{Type: oadpv1alpha1.ConditionCredentialsHealthyPrefix + "removed-credentials", Status: metav1.ConditionTrue, Reason: oadpv1alpha1.CredentialsReasonAuthenticated},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		mutate          func(dpa *oadpv1alpha1.DataProtectionApplication)
+		expectCalls     int32
+		expectReasons   map[string]string
+		expectRemoved   []string
+		expectLastCheck bool
+	}{
+		{
+			name:        "one condition per secret",
+			expectCalls: 3,
+			expectReasons: map[string]string{
+				"cloud-credentials":       oadpv1alpha1.CredentialsReasonAuthenticated,
+				"rejected-credentials":    oadpv1alpha1.CredentialsReasonAuthenticationFailed,
+				"cloud-credentials-azure": oadpv1alpha1.CredentialsReasonExpiringSoon,
+			},
+			expectRemoved:   []string{"removed-credentials"},
+			expectLastCheck: true,
+		},
+		{
+			name: "not due within the interval",
+			mutate: func(dpa *oadpv1alpha1.DataProtectionApplication) {
+				dpa.Spec.BackupLocations = dpa.Spec.BackupLocations[:1]
+				dpa.Spec.SnapshotLocations = nil
+				dpa.Status.LastCredentialHealthCheck = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+				dpa.Status.Conditions = append(dpa.Status.Conditions, metav1.Condition{
+					Type: oadpv1alpha1.ConditionCredentialsHealthyPrefix + "cloud-credentials", Status: metav1.ConditionTrue, Reason: oadpv1alpha1.CredentialsReasonAuthenticated,
+				})
+			},
+			expectCalls:     0,
+			expectReasons:   map[string]string{"cloud-credentials": oadpv1alpha1.CredentialsReasonAuthenticated},
+			expectRemoved:   []string{"removed-credentials"},
+			expectLastCheck: true,
+		},
+		{
+			name: "due after the interval",
+			mutate: func(dpa *oadpv1alpha1.DataProtectionApplication) {
+				dpa.Spec.BackupLocations = dpa.Spec.BackupLocations[:1]
+				dpa.Spec.SnapshotLocations = nil
+				dpa.Spec.CredentialHealthCheck.Interval = &metav1.Duration{Duration: 10 * time.Minute}
+				dpa.Status.LastCredentialHealthCheck = &metav1.Time{Time: time.Now().Add(-time.Hour)}
+			},
+			expectCalls:     1,
+			expectReasons:   map[string]string{"cloud-credentials": oadpv1alpha1.CredentialsReasonAuthenticated},
+			expectLastCheck: true,
+		},
+		{
+			name: "disabled removes the conditions",
+			mutate: func(dpa *oadpv1alpha1.DataProtectionApplication) {
+				dpa.Spec.CredentialHealthCheck = nil
+				dpa.Status.LastCredentialHealthCheck = &metav1.Time{Time: time.Now()}
+			},
+			expectRemoved: []string{"removed-credentials"},
+		},
+		{
+			name: "no-secret feature flag skips all secrets",
+			mutate: func(dpa *oadpv1alpha1.DataProtectionApplication) {
+				dpa.Spec.Configuration.Velero.FeatureFlags = []string{"no-secret"}
+			},
+			expectRemoved:   []string{"removed-credentials"},
+			expectLastCheck: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := newDPA()
+			if tt.mutate != nil {
+				tt.mutate(dpa)
+			}
+			fakeClient := getFakeClientFromObjectsForTest(t, dpa, awsSecret("cloud-credentials", "AKIAVALID"), awsSecret("rejected-credentials", "AKIAREJECTED"), azureSecret)
+			prober := &mockCredentialProber{rejected: map[string]bool{"AKIAREJECTED": true}, expiresAt: &soon}
+			r := &DataProtectionApplicationReconciler{
+				Client:           fakeClient,
+				Scheme:           fakeClient.Scheme(),
+				Log:              logr.Discard(),
+				Context:          newContextForTest(),
+				NamespacedName:   types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+				EventRecorder:    record.NewFakeRecorder(10),
+				dpa:              dpa,
+				credentialProber: prober,
+				serviceAccountToken: func(ctx context.Context, audience string) (string, error) {
+					return "token", nil
+				},
+			}
+
+			ok, err := r.ReconcileCredentialHealth(r.Log)
+			require.NoError(t, err)
+			require.True(t, ok)
+			// the checks run in the background, the next reconcile reports their result
+			r.credentialChecks.wg.Wait()
+			ok, err = r.ReconcileCredentialHealth(r.Log)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, tt.expectCalls, prober.calls.Load())
+			require.Equal(t, tt.expectLastCheck, dpa.Status.LastCredentialHealthCheck != nil)
+
+			for secretName, reason := range tt.expectReasons {
+				condition := apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionCredentialsHealthyPrefix+secretName)
+				require.NotNil(t, condition, secretName)
+				require.Equal(t, reason, condition.Reason, condition.Message)
+			}
+			for _, secretName := range tt.expectRemoved {
+				require.Nil(t, apimeta.FindStatusCondition(dpa.Status.Conditions, oadpv1alpha1.ConditionCredentialsHealthyPrefix+secretName))
+			}
+			if tt.expectLastCheck {
+				require.Positive(t, r.credentialHealthRequeueAfter())
+			} else {
+				require.Zero(t, r.credentialHealthRequeueAfter())
+			}
+		})
+	}
+}
+
+func TestDPAReconciler_ReconcileCredentialHealth_EventsOnTransitions(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "dpa", Namespace: "test-ns"},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{Velero: &oadpv1alpha1.VeleroConfig{}},
+			BackupLocations: []oadpv1alpha1.BackupLocation{
+				{Velero: &velerov1.BackupStorageLocationSpec{Provider: "aws", Config: map[string]string{Region: "us-east-1"}}},
+			},
+			CredentialHealthCheck: &oadpv1alpha1.CredentialHealthCheck{Enable: true},
+		},
+	}
+	fakeClient := getFakeClientFromObjectsForTest(t, dpa, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials", Namespace: "test-ns"},
+		Data:       map[string][]byte{"cloud": []byte("[default]\naws_access_key_id=AKIAREJECTED\naws_secret_access_key=secret\n")},
+	})
+	prober := &mockCredentialProber{rejected: map[string]bool{"AKIAREJECTED": true}}
+	eventRecorder := record.NewFakeRecorder(10)
+	r := &DataProtectionApplicationReconciler{
+		Client:           fakeClient,
+		Scheme:           fakeClient.Scheme(),
+		Log:              logr.Discard(),
+		Context:          newContextForTest(),
+		NamespacedName:   types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+		EventRecorder:    eventRecorder,
+		dpa:              dpa,
+		credentialProber: prober,
+	}
+	check := func() {
+		dpa.Status.LastCredentialHealthCheck = nil
+		_, err := r.ReconcileCredentialHealth(r.Log)
+		require.NoError(t, err)
+		require.Equal(t, credentialHealthCheckPollInterval, r.credentialHealthRequeueAfter())
+		r.credentialChecks.wg.Wait()
+		_, err = r.ReconcileCredentialHealth(r.Log)
+		require.NoError(t, err)
+	}
+
+	check()
+	require.Len(t, eventRecorder.Events, 1)
+	require.Contains(t, <-eventRecorder.Events, "CredentialsAuthenticationFailed")
+
+	check()
+	require.Empty(t, eventRecorder.Events, "a failure reported again emits no event")
+
+	prober.rejected = nil
+	check()
+	require.Len(t, eventRecorder.Events, 1)
+	require.Contains(t, <-eventRecorder.Events, "Normal CredentialsAuthenticated")
+	require.Equal(t, int32(3), prober.calls.Load())
+}
@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// CredentialsHashAnnotation is set on the Velero and NodeAgent pod templates to the hash of the
+// secrets and ConfigMaps (CA bundles) they mount, so rotating a credential rolls out the pods.
+const CredentialsHashAnnotation = "oadp.openshift.io/credentials-hash"
+
+const (
+	secretRefKind    = "Secret"
+	configMapRefKind = "ConfigMap"
+)
+
+// setCredentialsHashAnnotation hashes the content of every secret and ConfigMap referenced by the volumes
+// and environment of the pod template and stores it in the CredentialsHashAnnotation.
+// References to objects that do not exist are ignored, the annotation is removed when none exists.
+func (r *DataProtectionApplicationReconciler) setCredentialsHashAnnotation(template *corev1.PodTemplateSpec, namespace string) error {
+	hash, err := r.credentialsHash(&template.Spec, namespace)
+	if err != nil {
+		return err
+	}
+	if hash == "" {
+		delete(template.Annotations, CredentialsHashAnnotation)
+		return nil
+	}
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[CredentialsHashAnnotation] = hash
+	return nil
+}
+
+func (r *DataProtectionApplicationReconciler) credentialsHash(podSpec *corev1.PodSpec, namespace string) (string, error) {
+	refs := podSpecObjectRefs(podSpec)
+	if len(refs) == 0 {
+		return "", nil
+	}
+
+	hasher := sha256.New()
+	hashed := false
+	for _, ref := range refs {
+		var data map[string][]byte
+		switch ref.kind {
+		case secretRefKind:
+			secret := corev1.Secret{}
+			if err := r.Get(r.Context, types.NamespacedName{Namespace: namespace, Name: ref.name}, &secret); err != nil {
+				if errors.IsNotFound(err) {
+					continue
+				}
+				return "", fmt.Errorf("failed to get secret %s for credentials hash: %w", ref.name, err)
+			}
+			data = secret.Data
+		case configMapRefKind:
+			configMap := corev1.ConfigMap{}
+			if err := r.Get(r.Context, types.NamespacedName{Namespace: namespace, Name: ref.name}, &configMap); err != nil {
+				if errors.IsNotFound(err) {
+					continue
+				}
+				return "", fmt.Errorf("failed to get ConfigMap %s for credentials hash: %w", ref.name, err)
+			}
+			data = make(map[string][]byte, len(configMap.Data)+len(configMap.BinaryData))
+			for key, value := range configMap.Data {
+				data[key] = []byte(value)
+			}
+			for key, value := range configMap.BinaryData {
+				data[key] = value
+			}
+		}
+
+		hashed = true
+		fmt.Fprintf(hasher, "%s/%s\x00", ref.kind, ref.name)
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(hasher, "%s\x00", key)
+			hasher.Write(data[key])
+			hasher.Write([]byte{0})
+		}
+	}
+	if !hashed {
+		return "", nil
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+type podObjectRef struct {
+	kind string
+	name string
+}
+
+// podSpecObjectRefs returns the secrets and ConfigMaps referenced by the volumes, env and envFrom
+// of the pod spec, sorted and without duplicates.
+func podSpecObjectRefs(podSpec *corev1.PodSpec) []podObjectRef {
+	seen := map[podObjectRef]bool{}
+	add := func(kind, name string) {
+		if name != "" {
+			seen[podObjectRef{kind: kind, name: name}] = true
+		}
+	}
+
+	for _, volume := range podSpec.Volumes {
+		if volume.Secret != nil {
+			add(secretRefKind, volume.Secret.SecretName)
+		}
+		if volume.ConfigMap != nil {
+			add(configMapRefKind, volume.ConfigMap.Name)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil {
+					add(secretRefKind, source.Secret.Name)
+				}
+				if source.ConfigMap != nil {
+					add(configMapRefKind, source.ConfigMap.Name)
+				}
+			}
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, podSpec.InitContainers...), podSpec.Containers...)
+	for _, container := range containers {
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.ValueFrom.SecretKeyRef != nil {
+				add(secretRefKind, env.ValueFrom.SecretKeyRef.Name)
+			}
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				add(configMapRefKind, env.ValueFrom.ConfigMapKeyRef.Name)
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.SecretRef != nil {
+				add(secretRefKind, envFrom.SecretRef.Name)
+			}
+			if envFrom.ConfigMapRef != nil {
+				add(configMapRefKind, envFrom.ConfigMapRef.Name)
+			}
+		}
+	}
+
+	refs := make([]podObjectRef, 0, len(seen))
+	for ref := range seen {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].kind != refs[j].kind {
+			return refs[i].kind < refs[j].kind
+		}
+		return refs[i].name < refs[j].name
+	})
+	return refs
+}
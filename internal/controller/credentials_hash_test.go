@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestDPAReconciler_setCredentialsHashAnnotation(t *testing.T) {
+	podSpec := corev1.PodSpec{
+		Volumes: []corev1.Volume{
+			{Name: "cloud-credentials", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "cloud-credentials"}}},
+			{Name: "ca", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "velero-ca-bundle"}}}},
+			{Name: "missing", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "missing", Optional: ptr.To(true)}}},
+		},
+		Containers: []corev1.Container{{
+			Name: "velero",
+			Env: []corev1.EnvVar{{
+				Name: "AZURE_CLIENT_ID",
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "azure-workload-identity-env"},
+					Key:                  "AZURE_CLIENT_ID",
+				}},
+			}},
+		}},
+	}
+	secret := func(name, value string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns"},
+			Data:       map[string][]byte{"cloud": []byte(value)},
+		}
+	}
+	caBundle := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "velero-ca-bundle", Namespace: "test-ns"},
+		Data:       map[string]string{"ca-bundle.pem": "-----BEGIN CERTIFICATE-----"},
+	}
+
+	hashWith := func(t *testing.T, spec corev1.PodSpec, objects ...client.Object) (string, bool) {
+		fakeClient := getFakeClientFromObjectsForTest(t, objects...)
+		r := &DataProtectionApplicationReconciler{Client: fakeClient, Log: logr.Discard(), Context: newContextForTest()}
+		template := &corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{CredentialsHashAnnotation: "stale"}},
+			Spec:       spec,
+		}
+		require.NoError(t, r.setCredentialsHashAnnotation(template, "test-ns"))
+		hash, found := template.Annotations[CredentialsHashAnnotation]
+		return hash, found
+	}
+
+	original, found := hashWith(t, podSpec, secret("cloud-credentials", "v1"), secret("azure-workload-identity-env", "id"), caBundle)
+	require.True(t, found)
+	require.Len(t, original, 64)
+
+	again, _ := hashWith(t, podSpec, caBundle, secret("azure-workload-identity-env", "id"), secret("cloud-credentials", "v1"))
+	require.Equal(t, original, again, "hash must not depend on object order")
+
+	rotated, _ := hashWith(t, podSpec, secret("cloud-credentials", "v2"), secret("azure-workload-identity-env", "id"), caBundle)
+	require.NotEqual(t, original, rotated, "rotating a mounted secret must change the hash")
+
+	caBundle.Data["ca-bundle.pem"] = "-----BEGIN CERTIFICATE-----\nnew"
+	renewed, _ := hashWith(t, podSpec, secret("cloud-credentials", "v1"), secret("azure-workload-identity-env", "id"), caBundle)
+	require.NotEqual(t, original, renewed, "updating the CA bundle must change the hash")
+
+	_, found = hashWith(t, podSpec)
+	require.False(t, found, "annotation must be removed when no referenced object exists")
+
+	_, found = hashWith(t, corev1.PodSpec{})
+	require.False(t, found)
+}
+
+func TestPodSpecObjectRefs(t *testing.T) {
+	podSpec := &corev1.PodSpec{
+		Volumes: []corev1.Volume{
+			{Name: "b", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "b-secret"}}},
+			{Name: "token", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+				{Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "a-secret"}}},
+				{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "ca"}}},
+				{ServiceAccountToken: &corev1.ServiceAccountTokenProjection{Path: "token"}},
+			}}}},
+			{Name: "scratch", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		},
+		InitContainers: []corev1.Container{{
+			EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "b-secret"}}}},
+		}},
+		Containers: []corev1.Container{{
+			Env: []corev1.EnvVar{
+				{Name: "PLAIN", Value: "value"},
+				{Name: "FROM_CM", ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "config"}, Key: "k"}}},
+			},
+		}},
+	}
+
+	require.Equal(t, []podObjectRef{
+		{kind: configMapRefKind, name: "ca"},
+		{kind: configMapRefKind, name: "config"},
+		{kind: secretRefKind, name: "a-secret"},
+		{kind: secretRefKind, name: "b-secret"},
+	}, podSpecObjectRefs(podSpec))
+}
@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/credentials"
+)
+
+// CredentialsRequestLabel is set on the CredentialsRequests generated for spec.credentialsRequests, its value is the provider
+const CredentialsRequestLabel = "oadp.openshift.io/credentials-request"
+
+// credentialsRequestGVK is the Cloud Credential Operator CredentialsRequest kind
+var credentialsRequestGVK = schema.GroupVersionKind{
+	Group:   "cloudcredential.openshift.io",
+	Version: "v1",
+	Kind:    credentials.CredentialsRequestKind,
+}
+
+// credentialsRequestProviders maps the providers with a CredentialsRequest provider spec to the plugin of their default secret
+var credentialsRequestProviders = map[string]oadpv1alpha1.DefaultPlugin{
+	AWSProvider:   oadpv1alpha1.DefaultPluginAWS,
+	AzureProvider: oadpv1alpha1.DefaultPluginMicrosoftAzure,
+	GCPProvider:   oadpv1alpha1.DefaultPluginGCP,
+}
+
+// credentialsRequestName returns the name of the CredentialsRequest of a provider
+func credentialsRequestName(dpa *oadpv1alpha1.DataProtectionApplication, provider string) string {
+	return dpa.Name + "-" + provider
+}
+
+// cloudPermissions derives the permissions each provider needs from the backup and snapshot locations of the DPA.
+// Backup locations on S3-compatible storage (s3Url config) are not AWS accounts and are skipped.
+func (r *DataProtectionApplicationReconciler) cloudPermissions() (map[string]*credentials.CloudPermissions, error) {
+	dpa := r.dpa
+	permissions := map[string]*credentials.CloudPermissions{}
+	forProvider := func(provider string) *credentials.CloudPermissions {
+		provider = strings.TrimPrefix(provider, "velero.io/")
+		if _, found := credentialsRequestProviders[provider]; !found {
+			return nil
+		}
+		if permissions[provider] == nil {
+			permissions[provider] = &credentials.CloudPermissions{}
+		}
+		return permissions[provider]
+	}
+
+	for _, bsl := range dpa.Spec.BackupLocations {
+		if bsl.Velero != nil {
+			if bsl.Velero.Config[S3URL] != "" && !strings.Contains(bsl.Velero.Config[S3URL], "amazonaws.com") {
+				continue
+			}
+			if p := forProvider(bsl.Velero.Provider); p != nil {
+				p.ObjectStorage = true
+				bucket := ""
+				if bsl.Velero.ObjectStorage != nil {
+					bucket = bsl.Velero.ObjectStorage.Bucket
+				}
+				p.Buckets = append(p.Buckets, bucket)
+			}
+		}
+		if bsl.CloudStorage != nil && bsl.CloudStorage.CloudStorageRef.Name != "" {
+			cloudStorage := &oadpv1alpha1.CloudStorage{}
+			if err := r.Get(r.Context, client.ObjectKey{Namespace: dpa.Namespace, Name: bsl.CloudStorage.CloudStorageRef.Name}, cloudStorage); err != nil {
+				return nil, fmt.Errorf("unable to get CloudStorage %s for its CredentialsRequest: %w", bsl.CloudStorage.CloudStorageRef.Name, err)
+			}
+			if p := forProvider(string(cloudStorage.Spec.Provider)); p != nil {
+				p.ObjectStorage = true
+				p.CreateBuckets = true
+				p.Buckets = append(p.Buckets, cloudStorage.Spec.Name)
+				if shouldDelete, err := strconv.ParseBool(cloudStorage.Annotations[oadpCloudStorageDeleteAnnotation]); err == nil && shouldDelete {
+					p.DeleteBuckets = true
+				}
+			}
+		}
+	}
+	for _, vsl := range dpa.Spec.SnapshotLocations {
+		if vsl.Velero != nil {
+			if p := forProvider(vsl.Velero.Provider); p != nil {
+				p.Snapshots = true
+			}
+		}
+	}
+	return permissions, nil
+}
+
+// credentialsRequestProviderSpec returns the provider spec granting the permissions of a provider
+func credentialsRequestProviderSpec(provider string, permissions credentials.CloudPermissions) map[string]interface{} {
+	switch provider {
+	case AWSProvider:
+		return credentials.AWSProviderSpec(permissions)
+	case GCPProvider:
+		return credentials.GCPProviderSpec(permissions)
+	default:
+		return credentials.AzureProviderSpec(permissions)
+	}
+}
+
+// ReconcileCredentialsRequests generates a CredentialsRequest for each cloud provider of the backup and snapshot
+// locations when spec.credentialsRequests is enabled, so the Cloud Credential Operator, or ccoctl in manual mode,
+// creates the provider secret with the least-privilege permissions derived from the DPA.
+// CredentialsRequests of providers no longer used, or all of them once disabled, are deleted.
+func (r *DataProtectionApplicationReconciler) ReconcileCredentialsRequests(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	enabled := dpa.Spec.CredentialsRequests != nil && dpa.Spec.CredentialsRequests.Enable
+	if !enabled && len(dpa.Status.CredentialsRequests) == 0 {
+		return true, nil
+	}
+
+	desired := map[string]bool{}
+	var statuses []oadpv1alpha1.CredentialsRequestStatus
+	if enabled {
+		permissions, err := r.cloudPermissions()
+		if err != nil {
+			return false, err
+		}
+		providers := make([]string, 0, len(permissions))
+		for provider := range permissions {
+			providers = append(providers, provider)
+		}
+		sort.Strings(providers)
+
+		for _, provider := range providers {
+			name := credentialsRequestName(dpa, provider)
+			secretName := credentials.PluginSpecificFields[credentialsRequestProviders[provider]].SecretName
+			desired[name] = true
+
+			credentialsRequest := &unstructured.Unstructured{}
+			credentialsRequest.SetGroupVersionKind(credentialsRequestGVK)
+			credentialsRequest.SetName(name)
+			credentialsRequest.SetNamespace(dpa.Namespace)
+			op, err := controllerutil.CreateOrUpdate(r.Context, r.Client, credentialsRequest, func() error {
+				labels := getDpaAppLabels(dpa)
+				labels[CredentialsRequestLabel] = provider
+				credentialsRequest.SetLabels(labels)
+				spec := credentials.CredentialsRequestSpec(credentialsRequestProviderSpec(provider, *permissions[provider]), secretName, dpa.Namespace)
+				if err := unstructured.SetNestedMap(credentialsRequest.Object, spec, "spec"); err != nil {
+					return err
+				}
+				return controllerutil.SetControllerReference(dpa, credentialsRequest, r.Scheme)
+			})
+			if apimeta.IsNoMatchError(err) {
+				return false, fmt.Errorf("spec.credentialsRequests requires the Cloud Credential Operator, CredentialsRequest is not available in the cluster")
+			}
+			if err != nil {
+				log.Error(err, "Error reconciling CredentialsRequest", "provider", provider)
+				return false, err
+			}
+			if op == controllerutil.OperationResultCreated || op == controllerutil.OperationResultUpdated {
+				r.EventRecorder.Event(dpa,
+					corev1.EventTypeNormal,
+					"CredentialsRequestReconciled",
+					fmt.Sprintf("performed %s on %s CredentialsRequest %s/%s", op, provider, dpa.Namespace, name),
+				)
+			}
+			statuses = append(statuses, oadpv1alpha1.CredentialsRequestStatus{Name: name, Provider: provider, Secret: secretName})
+		}
+	}
+
+	existing := &unstructured.UnstructuredList{}
+	existing.SetGroupVersionKind(credentialsRequestGVK.GroupVersion().WithKind(credentials.CredentialsRequestKind + "List"))
+	err := r.List(r.Context, existing, client.InNamespace(dpa.Namespace), client.HasLabels{CredentialsRequestLabel})
+	if apimeta.IsNoMatchError(err) && !enabled {
+		dpa.Status.CredentialsRequests = nil
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for i := range existing.Items {
+		credentialsRequest := &existing.Items[i]
+		if desired[credentialsRequest.GetName()] || !metav1.IsControlledBy(credentialsRequest, dpa) {
+			continue
+		}
+		if err := r.Delete(r.Context, credentialsRequest); err != nil && !k8serror.IsNotFound(err) {
+			log.Error(err, "Error deleting CredentialsRequest", "name", credentialsRequest.GetName())
+			return false, err
+		}
+		r.EventRecorder.Event(dpa,
+			corev1.EventTypeNormal,
+			"CredentialsRequestDeleted",
+			fmt.Sprintf("deleted CredentialsRequest %s/%s", credentialsRequest.GetNamespace(), credentialsRequest.GetName()),
+		)
+	}
+
+	dpa.Status.CredentialsRequests = statuses
+	return true, nil
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestDPAReconciler_ReconcileCredentialsRequests(t *testing.T) {
+	newDPA := func(enable bool) *oadpv1alpha1.DataProtectionApplication {
+		return &oadpv1alpha1.DataProtectionApplication{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns", UID: "test-uid"},
+			Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+				CredentialsRequests: &oadpv1alpha1.CredentialsRequests{Enable: enable},
+				BackupLocations: []oadpv1alpha1.BackupLocation{
+					{Velero: &velerov1.BackupStorageLocationSpec{
+						Provider:    AWSProvider,
+						StorageType: velerov1.StorageType{ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "backups"}},
+					}},
+					{Velero: &velerov1.BackupStorageLocationSpec{
+						Provider:    AWSProvider,
+						StorageType: velerov1.StorageType{ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "minio"}},
+						Config:      map[string]string{S3URL: "https://minio.example.com"},
+					}},
+					{CloudStorage: &oadpv1alpha1.CloudStorageLocation{CloudStorageRef: corev1.LocalObjectReference{Name: "gcs"}}},
+				},
+				SnapshotLocations: []oadpv1alpha1.SnapshotLocation{
+					{Velero: &velerov1.VolumeSnapshotLocationSpec{Provider: "velero.io/aws"}},
+				},
+			},
+		}
+	}
+	cloudStorage := &oadpv1alpha1.CloudStorage{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "gcs", Namespace: "test-ns",
+			Annotations: map[string]string{oadpCloudStorageDeleteAnnotation: "true"},
+		},
+		Spec: oadpv1alpha1.CloudStorageSpec{Name: "gcs-bucket", Provider: oadpv1alpha1.GCPBucketProvider},
+	}
+	ownedRequest := func(name, provider string) *unstructured.Unstructured {
+		credentialsRequest := &unstructured.Unstructured{}
+		credentialsRequest.SetGroupVersionKind(credentialsRequestGVK)
+		credentialsRequest.SetName(name)
+		credentialsRequest.SetNamespace("test-ns")
+		credentialsRequest.SetLabels(map[string]string{CredentialsRequestLabel: provider})
+		credentialsRequest.SetOwnerReferences([]metav1.OwnerReference{{
+			APIVersion: oadpv1alpha1.GroupVersion.String(), Kind: "DataProtectionApplication", Name: "test-dpa", UID: "test-uid", Controller: ptr.To(true),
+		}})
+		return credentialsRequest
+	}
+
+	t.Run("one CredentialsRequest per provider with derived permissions", func(t *testing.T) {
+		dpa := newDPA(true)
+		fakeClient := getFakeClientFromObjectsForTest(t, dpa, cloudStorage, ownedRequest("test-dpa-azure", AzureProvider))
+		r := &DataProtectionApplicationReconciler{
+			Client:        fakeClient,
+			Scheme:        fakeClient.Scheme(),
+			dpa:           dpa,
+			Log:           logr.Discard(),
+			Context:       newContextForTest(),
+			EventRecorder: newEventRecorder(),
+		}
+
+		ok, err := r.ReconcileCredentialsRequests(r.Log)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []oadpv1alpha1.CredentialsRequestStatus{
+			{Name: "test-dpa-aws", Provider: AWSProvider, Secret: "cloud-credentials"},
+			{Name: "test-dpa-gcp", Provider: GCPProvider, Secret: "cloud-credentials-gcp"},
+		}, dpa.Status.CredentialsRequests)
+
+		aws := &unstructured.Unstructured{}
+		aws.SetGroupVersionKind(credentialsRequestGVK)
+		require.NoError(t, fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: "test-dpa-aws"}, aws))
+		require.True(t, metav1.IsControlledBy(aws, dpa))
+		statements, _, _ := unstructured.NestedSlice(aws.Object, "spec", "providerSpec", "statementEntries")
+		var resources []string
+		for _, statement := range statements {
+			resources = append(resources, statement.(map[string]interface{})["resource"].(string))
+		}
+		require.Equal(t, []string{"arn:aws:s3:::backups/*", "arn:aws:s3:::backups", "*"}, resources, "the S3-compatible location is not an AWS bucket")
+		secretNamespace, _, _ := unstructured.NestedString(aws.Object, "spec", "secretRef", "namespace")
+		require.Equal(t, "test-ns", secretNamespace)
+
+		gcp := &unstructured.Unstructured{}
+		gcp.SetGroupVersionKind(credentialsRequestGVK)
+		require.NoError(t, fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: "test-dpa-gcp"}, gcp))
+		permissions, _, _ := unstructured.NestedStringSlice(gcp.Object, "spec", "providerSpec", "permissions")
+		require.Contains(t, permissions, "storage.buckets.create")
+		require.Contains(t, permissions, "storage.buckets.delete")
+		require.NotContains(t, permissions, "compute.snapshots.create")
+
+		azure := &unstructured.Unstructured{}
+		azure.SetGroupVersionKind(credentialsRequestGVK)
+		err = fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: "test-dpa-azure"}, azure)
+		require.True(t, k8serror.IsNotFound(err), "CredentialsRequest of a provider no longer used is deleted")
+	})
+
+	t.Run("disabled deletes generated CredentialsRequests", func(t *testing.T) {
+		dpa := newDPA(false)
+		dpa.Status.CredentialsRequests = []oadpv1alpha1.CredentialsRequestStatus{{Name: "test-dpa-aws", Provider: AWSProvider, Secret: "cloud-credentials"}}
+		fakeClient := getFakeClientFromObjectsForTest(t, dpa, ownedRequest("test-dpa-aws", AWSProvider))
+		r := &DataProtectionApplicationReconciler{
+			Client:        fakeClient,
+			Scheme:        fakeClient.Scheme(),
+			dpa:           dpa,
+			Log:           logr.Discard(),
+			Context:       newContextForTest(),
+			EventRecorder: newEventRecorder(),
+		}
+
+		ok, err := r.ReconcileCredentialsRequests(r.Log)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Empty(t, dpa.Status.CredentialsRequests)
+		err = fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: "test-dpa-aws"}, ownedRequest("test-dpa-aws", AWSProvider))
+		require.True(t, k8serror.IsNotFound(err))
+	})
+
+	t.Run("missing CloudStorage", func(t *testing.T) {
+		dpa := newDPA(true)
+		fakeClient := getFakeClientFromObjectsForTest(t, dpa)
+		r := &DataProtectionApplicationReconciler{
+			Client:        fakeClient,
+			Scheme:        fakeClient.Scheme(),
+			dpa:           dpa,
+			Log:           logr.Discard(),
+			Context:       newContextForTest(),
+			EventRecorder: newEventRecorder(),
+		}
+		ok, err := r.ReconcileCredentialsRequests(r.Log)
+		require.ErrorContains(t, err, "unable to get CloudStorage gcs for its CredentialsRequest")
+		require.False(t, ok)
+	})
+}
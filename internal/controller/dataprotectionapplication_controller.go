@@ -19,27 +19,34 @@ package controller
 import (
 	"context"
 	"os"
+	"time"
 
 	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
 	routev1 "github.com/openshift/api/route/v1"
 	security "github.com/openshift/api/security/v1"
 	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
 	oadpclient "github.com/openshift/oadp-operator/pkg/client"
+	"github.com/openshift/oadp-operator/pkg/cloudprovider"
 )
 
 // DataProtectionApplicationReconciler reconciles a DataProtectionApplication object
@@ -52,6 +59,18 @@ type DataProtectionApplicationReconciler struct {
 	EventRecorder     record.EventRecorder
 	dpa               *oadpv1alpha1.DataProtectionApplication
 	ClusterWideClient client.Client
+	// clusterProxy is the cluster-wide proxy configuration read by ReconcileClusterProxy, nil when the cluster has no proxy
+	clusterProxy *clusterProxyConfig
+	// Clock is the time source of the node-agent maintenance windows, the real clock when nil
+	Clock clock.PassiveClock
+
+	// credentialProber and serviceAccountToken override the cloud and token calls of the credential health check in tests
+	credentialProber    cloudprovider.CredentialProber
+	serviceAccountToken func(ctx context.Context, audience string) (string, error)
+	// credentialChecks runs the credential health checks in the background, created by the first check
+	credentialChecks *credentialHealthChecks
+	// systemCACertificates overrides the system CA certificates read from the container filesystem, used by tests
+	systemCACertificates func() []byte
 }
 
 var debugMode = os.Getenv("DEBUG") == "true"
@@ -61,7 +80,8 @@ var debugMode = os.Getenv("DEBUG") == "true"
 //+kubebuilder:rbac:groups=oadp.openshift.io,resources=dataprotectionapplications/finalizers,verbs=update
 
 //+kubebuilder:rbac:groups=config.openshift.io,resources=infrastructures,verbs=get;list;watch
-//+kubebuilder:rbac:groups=cloudcredential.openshift.io,resources=credentialsrequests,verbs=get;create;update
+//+kubebuilder:rbac:groups=config.openshift.io,resources=proxies,verbs=get;list;watch
+//+kubebuilder:rbac:groups=cloudcredential.openshift.io,resources=credentialsrequests,verbs=get;list;create;update;delete
 //+kubebuilder:rbac:groups=oadp.openshift.io,resources=*,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=corev1;coordination.k8s.io,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=velero.io,resources=*,verbs=get;list;watch;create;update;patch;delete
@@ -69,9 +89,15 @@ var debugMode = os.Getenv("DEBUG") == "true"
 //+kubebuilder:rbac:groups=security.openshift.io,resources=securitycontextconstraints,verbs=use,resourceNames=privileged
 //+kubebuilder:rbac:groups="",resources=secrets;configmaps;pods;services;serviceaccounts;endpoints;persistentvolumeclaims;events,verbs=get;list;watch;create;update;patch;delete;deletecollection
 //+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=serviceaccounts/token,verbs=create
 //+kubebuilder:rbac:groups=apps,resources=deployments;daemonsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=metrics.k8s.io,resources=pods,verbs=get;list
+//+kubebuilder:rbac:groups=secrets-store.csi.x-k8s.io,resources=secretproviderclasses,verbs=get;list;watch
 
 // Reconcile is part of the main Kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -93,6 +119,7 @@ func (r *DataProtectionApplicationReconciler) Reconcile(ctx context.Context, req
 	oadpclient.SetClient(r.Client)
 
 	_, err := ReconcileBatch(r.Log,
+		r.ReconcileCloudIdentities,
 		r.ValidateDataProtectionCR,
 		r.ReconcileFsRestoreHelperConfig,
 		r.ReconcileBackupStorageLocations,
@@ -103,14 +130,23 @@ func (r *DataProtectionApplicationReconciler) Reconcile(ctx context.Context, req
 		r.ReconcileRegistryRouteConfigs,
 		r.LabelVSLSecrets,
 		r.ReconcileVolumeSnapshotLocations,
+		r.ReconcileSchedulePolicies,
+		r.ReconcileLocationCredentialStatus,
+		r.ReconcileCredentialsRequests,
+		r.ReconcileResourceRecommendations,
 		r.ReconcileAzureWorkloadIdentitySecret,
+		r.ReconcileClusterProxy,
 		r.ReconcileVeleroDeployment,
+		r.ReconcileVeleroPodDisruptionBudget,
 		r.ReconcileNodeAgentConfigMap,
 		r.ReconcileBackupRepositoryConfigMap,
 		r.ReconcileRepositoryMaintenanceConfigMap,
 		r.ReconcileNodeAgentDaemonset,
+		r.ReconcileNodeAgentStatus,
 		r.ReconcileVeleroMetricsSVC,
 		r.ReconcileNonAdminController,
+		r.ReconcileNetworkPolicies,
+		r.ReconcileCredentialHealth,
 	)
 
 	if err != nil {
@@ -138,7 +174,26 @@ func (r *DataProtectionApplicationReconciler) Reconcile(ctx context.Context, req
 		err = statusErr
 	}
 
-	return ctrl.Result{}, err
+	return ctrl.Result{RequeueAfter: minRequeueAfter(r.credentialHealthRequeueAfter(), r.nodeAgentMaintenanceWindowRequeueAfter(), r.resourceRecommendationRequeueAfter(), r.schedulePolicyRequeueAfter())}, err
+}
+
+// minRequeueAfter returns the shortest of the requeue delays, ignoring zero delays
+func minRequeueAfter(delays ...time.Duration) time.Duration {
+	var shortest time.Duration
+	for _, delay := range delays {
+		if delay > 0 && (shortest == 0 || delay < shortest) {
+			shortest = delay
+		}
+	}
+	return shortest
+}
+
+// now returns the current time of the reconciler clock
+func (r *DataProtectionApplicationReconciler) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
+	return r.Clock.Now()
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -148,12 +203,19 @@ func (r *DataProtectionApplicationReconciler) SetupWithManager(mgr ctrl.Manager)
 		Owns(&appsv1.Deployment{}).
 		Owns(&velerov1.BackupStorageLocation{}).
 		Owns(&velerov1.VolumeSnapshotLocation{}).
+		Owns(&velerov1.Schedule{}).
 		Owns(&appsv1.DaemonSet{}).
 		Owns(&security.SecurityContextConstraints{}).
 		Owns(&corev1.Service{}).
 		Owns(&routev1.Route{}).
 		Owns(&corev1.ConfigMap{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
+		Owns(&networkingv1.NetworkPolicy{}).
 		Watches(&corev1.Secret{}, &labelHandler{}).
+		Watches(&velerov1.Backup{}, handler.EnqueueRequestsFromMapFunc(r.veleroOperationDPARequests)).
+		Watches(&velerov1.Restore{}, handler.EnqueueRequestsFromMapFunc(r.veleroOperationDPARequests)).
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(r.nodeAgentDPARequests)).
+		Watches(&configv1.Proxy{}, handler.EnqueueRequestsFromMapFunc(r.clusterProxyDPARequests)).
 		WithEventFilter(veleroPredicate(r.Scheme)).
 		Complete(r)
 }
@@ -18,8 +18,11 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -28,7 +31,9 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/hashicorp/go-multierror"
 	snapshotv1api "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	configv1 "github.com/openshift/api/config/v1"
 	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -41,6 +46,9 @@ import (
 
 	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
 	"github.com/openshift/oadp-operator/pkg/cloudprovider"
+	"github.com/openshift/oadp-operator/pkg/credentials/azurecreds"
+	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
+	"github.com/openshift/oadp-operator/pkg/tlsprofile"
 	"github.com/openshift/oadp-operator/pkg/utils"
 )
 
@@ -54,8 +62,34 @@ type DataProtectionTestReconciler struct {
 	NamespacedName    types.NamespacedName
 	dpt               *oadpv1alpha1.DataProtectionTest
 	ClusterWideClient client.Client
+	// clusterProxy is the cluster-wide proxy configuration of the HTTP clients, nil when the cluster has no proxy
+	clusterProxy *clusterProxyConfig
+	// tlsProfile is the cluster TLS security profile of the HTTP clients, nil when it can not be read
+	tlsProfile *configv1.TLSProfileSpec
+
+	// cloudProviderFactory overrides initializeProvider, used by tests
+	cloudProviderFactory func(ctx context.Context, backupLocationSpec *velerov1.BackupStorageLocationSpec) (cloudprovider.CloudProvider, error)
+	// serviceAccountTokenSource overrides the TokenRequest of the Velero service account token, used by tests
+	serviceAccountTokenSource func(ctx context.Context, audience string) (string, error)
+	// awsCredentialsEndpoint overrides the IAM Roles Anywhere and EKS Pod Identity agent endpoints, used by tests
+	awsCredentialsEndpoint string
+	// snapshotAPIFactory overrides initializeSnapshotAPI, used by tests
+	snapshotAPIFactory func(ctx context.Context, vsl *velerov1.VolumeSnapshotLocation, cfg *oadpv1alpha1.VolumeSnapshotLocationTestConfig) (cloudprovider.SnapshotAPI, error)
 }
 
+const (
+	// awsCredentialProcessKey is the shared config setting of IAM Roles Anywhere profiles
+	awsCredentialProcessKey = "credential_process"
+	// awsCredentialSourceKey is the shared config setting of EKS Pod Identity profiles
+	awsCredentialSourceKey = "credential_source"
+	// awsRoleARNKey and awsWebIdentityTokenFileKey are the shared config settings of STS profiles
+	awsRoleARNKey              = "role_arn"
+	awsWebIdentityTokenFileKey = "web_identity_token_file"
+)
+
+// defaultBackupLocationTestConcurrency is the number of BSLs tested at once when maxConcurrency is unset
+const defaultBackupLocationTestConcurrency = 3
+
 // +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;create;watch;delete;update
 // +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshotcontents,verbs=get;list;watch;delete;update
 // +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshotclasses,verbs=get;list;watch;delete;update
@@ -127,64 +161,51 @@ func (r *DataProtectionTestReconciler) Reconcile(ctx context.Context, req ctrl.R
 		return ctrl.Result{}, nil
 	}
 
-	// Resolve the backup location from spec or by fetching BSL
-	resolvedBackupLocationSpec, err := r.resolveBackupLocation(r.Context, r.dpt)
+	// Reach the storage through the cluster proxy, trusting the custom CA of the cluster
+	clusterProxy, err := getClusterProxyConfig(ctx, r.Client, r.NamespacedName.Namespace)
 	if err != nil {
-		logger.Error(err, "failed to resolve BackupLocation")
-		r.updateDPTErrorStatus(ctx, fmt.Sprintf("failed to resolve BackupLocation: %v", err))
-		return ctrl.Result{}, err
+		logger.Error(err, "failed to get the cluster proxy configuration; using the operator environment")
 	}
+	r.clusterProxy = clusterProxy
 
-	if resolvedBackupLocationSpec == nil {
-		msg := "BackupLocation is nil after resolution"
-		logger.Info(msg)
-		r.updateDPTErrorStatus(ctx, msg)
-		return ctrl.Result{}, fmt.Errorf("resolved BackupLocationSpec is nil")
+	// Follow the minimum TLS version and cipher suites of the cluster TLS security profile
+	r.tlsProfile = nil
+	if tlsProfile, err := tlsprofile.GetAPIServerProfileSpec(ctx, r.Client); err != nil {
+		logger.Error(err, "failed to get the cluster TLS security profile; using the Go defaults")
+	} else {
+		r.tlsProfile = &tlsProfile
 	}
 
-	// Determine S3-compatible vendor (if applicable)
-	if strings.EqualFold(resolvedBackupLocationSpec.Provider, AWSProvider) {
-		if err := r.determineVendor(ctx, r.dpt, resolvedBackupLocationSpec); err != nil {
-			logger.Error(err, "S3 vendor detection failed")
+	var resolvedBackupLocationSpec *velerov1.BackupStorageLocationSpec
+	if r.dpt.Spec.BackupLocationSelector != nil {
+		// Test every selected BSL in parallel
+		if err := r.runBackupLocationTests(ctx, r.dpt); err != nil {
+			logger.Error(err, "failed to run BackupLocation tests")
+			r.updateDPTErrorStatus(ctx, fmt.Sprintf("failed to run BackupLocation tests: %v", err))
+			return ctrl.Result{}, err
 		}
-	}
-
-	// Handle Upload Speed Test + Bucket Metadata (if UploadSpeedTestConfig is provided)
-	if cfg := r.dpt.Spec.UploadSpeedTestConfig; cfg != nil {
-		logger.Info("Initializing cloud provider for upload test...")
-
-		cp, err := r.initializeProvider(ctx, resolvedBackupLocationSpec)
+	} else {
+		// Resolve the backup location from spec or by fetching BSL
+		var err error
+		resolvedBackupLocationSpec, err = r.resolveBackupLocation(r.Context, r.dpt)
 		if err != nil {
-			logger.Error(err, "failed to initialize cloud provider")
-			r.updateDPTErrorStatus(ctx, fmt.Sprintf("cloud provider init failed: %v", err))
+			logger.Error(err, "failed to resolve BackupLocation")
+			r.updateDPTErrorStatus(ctx, fmt.Sprintf("failed to resolve BackupLocation: %v", err))
 			return ctrl.Result{}, err
 		}
 
-		// Upload speed test
-		logger.Info("Executing upload test...")
-		if err := r.runUploadTest(ctx, r.dpt, resolvedBackupLocationSpec, cp); err != nil {
-			logger.Error(err, "upload test failed")
-			// handled in UploadTestStatus.ErrorMessage
+		if resolvedBackupLocationSpec == nil {
+			msg := "BackupLocation is nil after resolution"
+			logger.Info(msg)
+			r.updateDPTErrorStatus(ctx, msg)
+			return ctrl.Result{}, fmt.Errorf("resolved BackupLocationSpec is nil")
 		}
 
-		// Bucket metadata
-		// We can only fetch metadata if we are not using a storage account key
-		if azureProvider, ok := cp.(*cloudprovider.AzureProvider); !ok || !azureProvider.IsStorageAccountKeyAuth() {
-			logger.Info("Fetching Bucket metadata...")
-			meta, err := cp.GetBucketMetadata(ctx, resolvedBackupLocationSpec.ObjectStorage.Bucket, r.Log)
-			if err != nil {
-				logger.Error(err, "bucket metadata collection failed")
-				r.dpt.Status.BucketMetadata = &oadpv1alpha1.BucketMetadata{
-					ErrorMessage: err.Error(),
-				}
-			} else {
-				r.dpt.Status.BucketMetadata = meta
-			}
-		} else {
-			logger.Info("Skipping bucket metadata collection because storage account key authentication is used")
+		if err := r.testBackupLocation(ctx, r.dpt, resolvedBackupLocationSpec); err != nil {
+			logger.Error(err, "BackupLocation test failed")
+			r.updateDPTErrorStatus(ctx, err.Error())
+			return ctrl.Result{}, err
 		}
-	} else {
-		logger.Info("Skipping upload test because no spec.uploadSpeed config found")
 	}
 
 	//Run Snapshot Test(s)
@@ -198,13 +219,33 @@ func (r *DataProtectionTestReconciler) Reconcile(ctx context.Context, req ctrl.R
 		logger.Info("Skipping snapshot test because no spec.csiVolumeSnapshotTestConfigs found")
 	}
 
-	// Final status update: mark as Complete
+	// Run native cloud snapshot test (if VolumeSnapshotLocationTestConfig is provided)
+	if r.dpt.Spec.VolumeSnapshotLocationTestConfig != nil {
+		logger.Info("Running VolumeSnapshotLocation test", "vsl", r.dpt.Spec.VolumeSnapshotLocationTestConfig.VolumeSnapshotLocationName)
+		r.runVolumeSnapshotLocationTest(ctx, r.dpt)
+	}
+
+	// Evaluate pass/fail thresholds (if Thresholds is provided)
+	if r.dpt.Spec.Thresholds != nil {
+		r.dpt.Status.FailureReasons = evaluateThresholds(r.dpt)
+		if len(r.dpt.Status.FailureReasons) > 0 {
+			logger.Info("DPT thresholds violated", "reasons", r.dpt.Status.FailureReasons)
+		}
+	}
+
+	// Export the JSON report (if ReportExport is provided)
+	if r.dpt.Spec.ReportExport != nil {
+		logger.Info("Exporting DPT report", "destination", r.dpt.Spec.ReportExport.Destination)
+		r.dpt.Status.Report = r.exportReport(ctx, r.dpt, resolvedBackupLocationSpec)
+	}
+
+	// Final status update: mark as Complete, or Failed if thresholds were violated
 	if err := r.updateDPTStatusToComplete(ctx); err != nil {
-		logger.Error(err, "failed to update DPT status to Complete")
+		logger.Error(err, "failed to update DPT final status")
 		return ctrl.Result{}, err
 	}
 
-	logger.Info("Reconciliation completed successfully", "finalPhase", "Complete")
+	logger.Info("Reconciliation completed successfully", "finalPhase", finalPhase(r.dpt))
 	return ctrl.Result{}, nil
 
 }
@@ -216,8 +257,217 @@ func (r *DataProtectionTestReconciler) SetupWithManager(mgr ctrl.Manager) error
 		Complete(r)
 }
 
+// testBackupLocation runs vendor detection, the upload test and bucket metadata/capability collection
+// against a single backup location, recording the results in the given DPT status.
+// Only a missing objectStorage config or a cloud provider initialization failure is returned, with the message of
+// the status; test failures are reported in status.
+func (r *DataProtectionTestReconciler) testBackupLocation(ctx context.Context, dpt *oadpv1alpha1.DataProtectionTest, backupLocationSpec *velerov1.BackupStorageLocationSpec) error {
+	// Determine S3-compatible vendor (if applicable)
+	if strings.EqualFold(backupLocationSpec.Provider, AWSProvider) {
+		if err := r.determineVendor(ctx, dpt, backupLocationSpec); err != nil {
+			r.Log.Error(err, "S3 vendor detection failed")
+		}
+	}
+
+	// Handle Upload Speed Test + Bucket Metadata (if UploadSpeedTestConfig is provided)
+	if dpt.Spec.UploadSpeedTestConfig == nil {
+		r.Log.Info("Skipping upload test because no spec.uploadSpeed config found")
+		return nil
+	}
+
+	if backupLocationSpec.ObjectStorage == nil {
+		return fmt.Errorf("objectStorage config is missing in backupLocationSpec")
+	}
+
+	r.Log.Info("Initializing cloud provider for upload test...")
+	newProvider := r.initializeProvider
+	if r.cloudProviderFactory != nil {
+		newProvider = r.cloudProviderFactory
+	}
+	cp, err := newProvider(ctx, backupLocationSpec)
+	var exchangeErr *cloudprovider.TokenExchangeError
+	if errors.As(err, &exchangeErr) {
+		dpt.Status.TokenExchange = &oadpv1alpha1.TokenExchangeStatus{
+			CredentialType: exchangeErr.CredentialType,
+			ErrorMessage:   exchangeErr.Err.Error(),
+		}
+	}
+	if err != nil {
+		return providerInitError(err)
+	}
+	if exchanger, ok := cp.(cloudprovider.TokenExchanger); ok && exchanger.ExchangedCredentialType() != "" {
+		dpt.Status.TokenExchange = &oadpv1alpha1.TokenExchangeStatus{
+			CredentialType: exchanger.ExchangedCredentialType(),
+			Success:        true,
+		}
+	}
+
+	// Upload speed test
+	r.Log.Info("Executing upload test...")
+	if err := r.runUploadTest(ctx, dpt, backupLocationSpec, cp); err != nil {
+		r.Log.Error(err, "upload test failed")
+		// handled in UploadTestStatus.ErrorMessage
+	}
+
+	// Download speed test (if requested directly or by a download threshold)
+	if downloadTestEnabled(dpt) {
+		if tester, ok := cp.(cloudprovider.DownloadTester); ok {
+			r.Log.Info("Executing download test...")
+			r.runDownloadTest(ctx, dpt, backupLocationSpec, tester)
+		} else {
+			dpt.Status.DownloadTest = &oadpv1alpha1.DownloadTestStatus{
+				ErrorMessage: fmt.Sprintf("provider %s does not support the download test", backupLocationSpec.Provider),
+			}
+		}
+	}
+
+	// Bucket metadata
+	// Metadata is read through Azure Resource Manager, which needs a Microsoft Entra identity
+	if azureProvider, ok := cp.(*cloudprovider.AzureProvider); !ok || !azureProvider.IsStorageAccountKeyOnlyAuth() {
+		r.Log.Info("Fetching Bucket metadata...")
+		meta, err := cp.GetBucketMetadata(ctx, backupLocationSpec.ObjectStorage.Bucket, r.Log)
+		if err != nil {
+			r.Log.Error(err, "bucket metadata collection failed")
+			dpt.Status.BucketMetadata = &oadpv1alpha1.BucketMetadata{
+				ErrorMessage: err.Error(),
+			}
+		} else {
+			dpt.Status.BucketMetadata = meta
+		}
+	} else {
+		r.Log.Info("Skipping bucket metadata collection because only a storage account key is available")
+	}
+
+	// S3 capabilities
+	if prober, ok := cp.(cloudprovider.CapabilityProber); ok {
+		r.Log.Info("Probing S3 capabilities...")
+		capabilities, err := prober.ProbeCapabilities(ctx, backupLocationSpec.ObjectStorage.Bucket, dpt.Status.S3Vendor, r.Log)
+		if err != nil {
+			r.Log.Error(err, "S3 capability probe failed")
+			// handled in S3Capabilities.ErrorMessage
+		}
+		dpt.Status.S3Capabilities = capabilities
+	}
+
+	return nil
+}
+
+// runBackupLocationTests lists the BSLs matching spec.backupLocationSelector and tests them in parallel,
+// bounded by maxConcurrency. Per-location results and a pass/fail summary are added to the DPT status.
+func (r *DataProtectionTestReconciler) runBackupLocationTests(ctx context.Context, dpt *oadpv1alpha1.DataProtectionTest) error {
+	selector := dpt.Spec.BackupLocationSelector
+
+	if dpt.Spec.BackupLocationName != "" || dpt.Spec.BackupLocationSpec != nil {
+		return fmt.Errorf("backupLocationSelector cannot be set together with backupLocationName or backupLocationSpec")
+	}
+
+	if selector.All == (selector.LabelSelector != nil) {
+		return fmt.Errorf("exactly one of backupLocationSelector.all or backupLocationSelector.labelSelector must be set")
+	}
+
+	listOpts := []client.ListOption{client.InNamespace(dpt.Namespace)}
+	if selector.LabelSelector != nil {
+		labelSelector, err := metav1.LabelSelectorAsSelector(selector.LabelSelector)
+		if err != nil {
+			return fmt.Errorf("invalid backupLocationSelector.labelSelector: %w", err)
+		}
+		listOpts = append(listOpts, client.MatchingLabelsSelector{Selector: labelSelector})
+	}
+
+	bslList := &velerov1.BackupStorageLocationList{}
+	if err := r.List(ctx, bslList, listOpts...); err != nil {
+		return fmt.Errorf("failed to list BackupStorageLocations: %w", err)
+	}
+
+	if len(bslList.Items) == 0 {
+		return fmt.Errorf("no BackupStorageLocations matched backupLocationSelector")
+	}
+
+	sort.Slice(bslList.Items, func(i, j int) bool {
+		return bslList.Items[i].Name < bslList.Items[j].Name
+	})
+
+	maxConcurrency := selector.MaxConcurrency
+	if maxConcurrency < 1 {
+		maxConcurrency = defaultBackupLocationTestConcurrency
+	}
+
+	r.Log.Info("Starting BackupLocation tests", "count", len(bslList.Items), "maxConcurrency", maxConcurrency)
+
+	results := make([]oadpv1alpha1.BackupLocationTestResult, len(bslList.Items))
+	semaphore := make(chan struct{}, maxConcurrency)
+	var wg sync.WaitGroup
+
+	for i := range bslList.Items {
+		wg.Add(1)
+
+		// launch a goroutine for each BSL, waiting for a free slot
+		go func(i int, bsl *velerov1.BackupStorageLocation) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			// each location records its results on its own copy of the DPT
+			locationDPT := &oadpv1alpha1.DataProtectionTest{
+				ObjectMeta: dpt.ObjectMeta,
+				Spec:       dpt.Spec,
+			}
+
+			result := oadpv1alpha1.BackupLocationTestResult{
+				Name:     bsl.Name,
+				Provider: bsl.Spec.Provider,
+				Status:   "Passed",
+			}
+
+			if err := r.testBackupLocation(ctx, locationDPT, &bsl.Spec); err != nil {
+				r.Log.Error(err, "BackupLocation test failed", "bsl", bsl.Name)
+				result.Status = "Failed"
+				result.ErrorMessage = err.Error()
+			} else if dpt.Spec.UploadSpeedTestConfig != nil {
+				uploadTest := locationDPT.Status.UploadTest
+				result.UploadTest = &uploadTest
+				if !uploadTest.Success {
+					result.Status = "Failed"
+					result.ErrorMessage = uploadTest.ErrorMessage
+				}
+			}
+
+			result.TokenExchange = locationDPT.Status.TokenExchange
+			result.S3Vendor = locationDPT.Status.S3Vendor
+			result.DownloadTest = locationDPT.Status.DownloadTest
+			result.BucketMetadata = locationDPT.Status.BucketMetadata
+			result.S3Capabilities = locationDPT.Status.S3Capabilities
+
+			if dpt.Spec.Thresholds != nil && result.Status == "Passed" {
+				if violations := backupLocationViolations(dpt.Spec.Thresholds, dpt.Spec.UploadSpeedTestConfig != nil, result.UploadTest, result.DownloadTest, result.BucketMetadata); len(violations) > 0 {
+					result.Status = "Failed"
+					result.ErrorMessage = strings.Join(violations, "; ")
+				}
+			}
+			results[i] = result
+		}(i, &bslList.Items[i])
+	}
+
+	wg.Wait()
+
+	dpt.Status.BackupLocationResults = results
+
+	// Summarize results
+	passed := 0
+	for _, result := range results {
+		if result.Status == "Passed" {
+			passed++
+		}
+	}
+	dpt.Status.BackupLocationSummary = fmt.Sprintf("%d/%d passed", passed, len(results))
+
+	r.Log.Info("All BackupLocation tests completed", "summary", dpt.Status.BackupLocationSummary)
+	return nil
+}
+
 // determineVendor sends a HEAD request to the provided s3Url in the BackupLocationSpec config,
-// extracts the Server header and known fallback headers to set the detected vendor (e.g., AWS, MinIO, Ceph) in the DPT status.
+// and matches the endpoint and response headers against the registered vendor fingerprints
+// to set the detected vendor (e.g., AWS, MinIO, Ceph, NooBaa) in the DPT status.
 // Only applicable for aws-compatible BSLs.
 func (r *DataProtectionTestReconciler) determineVendor(ctx context.Context, dpt *oadpv1alpha1.DataProtectionTest, backupLocationSpec *velerov1.BackupStorageLocationSpec) error {
 	s3Url := backupLocationSpec.Config["s3Url"]
@@ -242,36 +492,24 @@ func (r *DataProtectionTestReconciler) determineVendor(ctx context.Context, dpt
 	}
 
 	// Build HTTP client with TLS configuration
-	httpClient, err := buildHTTPClientWithTLS(dpt, backupLocationSpec, r.Log)
+	httpClient, err := buildHTTPClientWithTLS(dpt, backupLocationSpec, r.clusterProxy, r.tlsProfile, r.Log)
 	if err != nil {
 		return fmt.Errorf("failed to build HTTP client with TLS: %w", err)
 	}
 
+	start := time.Now()
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("HEAD request to %s failed: %w", s3Url, err)
 	}
 	defer resp.Body.Close()
+	dpt.Status.EndpointLatency = time.Since(start).Truncate(time.Millisecond).String()
 
-	server := strings.ToLower(resp.Header.Get("Server"))
-	xAmzReqID := resp.Header.Get("x-amz-request-id")
-	minioRegion := resp.Header.Get("x-minio-region")
-	rgwReqID := resp.Header.Get("x-rgw-request-id")
-
-	switch {
-	case strings.Contains(server, "amazon") || (xAmzReqID != "" && minioRegion == ""):
-		dpt.Status.S3Vendor = "AWS"
-	case strings.Contains(server, "minio") || minioRegion != "":
-		dpt.Status.S3Vendor = "MinIO"
-	case strings.Contains(server, "ceph") || rgwReqID != "":
-		dpt.Status.S3Vendor = "Ceph"
-	default:
-		if server != "" {
-			dpt.Status.S3Vendor = server
-		} else {
-			dpt.Status.S3Vendor = "Unknown"
-		}
+	endpoint, err := url.Parse(s3Url)
+	if err != nil {
+		return fmt.Errorf("failed to parse s3Url %s: %w", s3Url, err)
 	}
+	dpt.Status.S3Vendor = cloudprovider.DetectS3Vendor(endpoint, resp.Header)
 
 	r.Log.Info("Detected S3 vendor", "vendor", dpt.Status.S3Vendor)
 	return nil
@@ -326,12 +564,6 @@ func (r *DataProtectionTestReconciler) initializeAWSProvider(ctx context.Context
 		}
 	}
 
-	r.Log.Info("Parsing AWS credentials", "profile", AWSProfile)
-	accessKey, secretKey, err := utils.ParseAWSSecret(secret, backupLocationSpec.Credential.Key, AWSProfile)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse AWS secret: %w", err)
-	}
-
 	// Get region and S3 URL from configuration
 	cfg := backupLocationSpec.Config
 	if cfg == nil {
@@ -346,6 +578,11 @@ func (r *DataProtectionTestReconciler) initializeAWSProvider(ctx context.Context
 		region = "us-east-1"
 	}
 
+	creds, credentialType, err := r.awsCredentials(ctx, secret, backupLocationSpec.Credential.Key, AWSProfile, region)
+	if err != nil {
+		return nil, err
+	}
+
 	// Ignore s3Url if it's aws-native
 	if s3Url != "" && strings.Contains(s3Url, "amazonaws.com") {
 		r.Log.Info("Detected AWS-native endpoint; ignoring s3Url")
@@ -353,16 +590,16 @@ func (r *DataProtectionTestReconciler) initializeAWSProvider(ctx context.Context
 	}
 
 	// Create AWS session with TLS configuration
-	sess, err := buildAWSSessionWithTLS(r.dpt, backupLocationSpec, region, s3Url, r.Log)
+	sess, err := buildAWSSessionWithTLS(r.dpt, backupLocationSpec, r.clusterProxy, r.tlsProfile, region, s3Url, r.Log)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create AWS session with TLS: %w", err)
 	}
 
 	// Set credentials on the session
-	sess.Config.Credentials = credentials.NewStaticCredentials(accessKey, secretKey, "")
+	sess.Config.Credentials = creds
 
 	// Initialize the AWS provider with the TLS-configured session
-	awsProvider := cloudprovider.NewAWSProviderWithSession(sess)
+	awsProvider := cloudprovider.NewAWSProviderWithExchangedCredentials(sess, credentialType)
 	if awsProvider == nil {
 		return nil, fmt.Errorf("failed to create AWS provider")
 	}
@@ -371,7 +608,8 @@ func (r *DataProtectionTestReconciler) initializeAWSProvider(ctx context.Context
 	return awsProvider, nil
 }
 
-// initializeGCPProvider initializes a GCP CloudProvider using service account credentials
+// initializeGCPProvider initializes a GCP CloudProvider using service account key or
+// workload identity federation (external_account) credentials
 func (r *DataProtectionTestReconciler) initializeGCPProvider(ctx context.Context, backupLocationSpec *velerov1.BackupStorageLocationSpec) (cloudprovider.CloudProvider, error) {
 	r.Log.Info("Initializing GCP provider")
 
@@ -403,6 +641,24 @@ func (r *DataProtectionTestReconciler) initializeGCPProvider(ctx context.Context
 
 	bucket := backupLocationSpec.ObjectStorage.Bucket
 
+	credentialType, err := cloudprovider.GCPCredentialType(credentialsJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	if credentialType == cloudprovider.GCPExternalAccountCredentialType {
+		subjectToken, err := r.gcpSubjectToken(ctx, credentialsJSON)
+		if err != nil {
+			return nil, err
+		}
+		gcpProvider, err := cloudprovider.NewGCPProviderWithExternalAccount(ctx, bucket, credentialsJSON, subjectToken)
+		if err != nil {
+			return nil, err
+		}
+		r.Log.Info("Successfully initialized GCP provider with workload identity federation", "bucket", bucket)
+		return gcpProvider, nil
+	}
+
 	// Initialize the GCP provider
 	gcpProvider, err := cloudprovider.NewGCPProvider(ctx, bucket, credentialsJSON)
 	if err != nil {
@@ -413,6 +669,152 @@ func (r *DataProtectionTestReconciler) initializeGCPProvider(ctx context.Context
 	return gcpProvider, nil
 }
 
+// awsCredentials resolves the credentials of the profile of the AWS secret the way Velero does: IAM Roles Anywhere for
+// a credential_process, EKS Pod Identity for the EcsContainer credential_source, STS AssumeRoleWithWebIdentity for a
+// role_arn with a web_identity_token_file, and static keys otherwise. It also returns the credential type of the
+// exchanged credentials, empty for static keys.
+func (r *DataProtectionTestReconciler) awsCredentials(ctx context.Context, secret corev1.Secret, secretKey, profile, region string) (*credentials.Credentials, string, error) {
+	r.Log.Info("Parsing AWS credentials", "profile", profile)
+	settings, err := utils.ParseAWSProfileSettings(secret, secretKey, profile)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse AWS secret: %w", err)
+	}
+	switch {
+	case settings[awsCredentialProcessKey] != "":
+		value, err := r.awsRolesAnywhereCredentials(ctx, settings[awsCredentialProcessKey])
+		if err != nil {
+			return nil, "", err
+		}
+		return credentials.NewStaticCredentialsFromCreds(value), cloudprovider.AWSRolesAnywhereCredentialType, nil
+	case strings.EqualFold(settings[awsCredentialSourceKey], stsflow.PodIdentityContainerCredentialsSource):
+		value, err := r.awsPodIdentityCredentials(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		return credentials.NewStaticCredentialsFromCreds(value), cloudprovider.AWSPodIdentityCredentialType, nil
+	case settings[awsRoleARNKey] != "" && settings[awsWebIdentityTokenFileKey] != "":
+		value, err := r.awsWebIdentityCredentials(ctx, settings[awsRoleARNKey], region)
+		if err != nil {
+			return nil, "", err
+		}
+		return credentials.NewStaticCredentialsFromCreds(value), cloudprovider.AWSWebIdentityCredentialType, nil
+	default:
+		accessKey, secretKey, err := utils.ParseAWSSecret(secret, secretKey, profile)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse AWS secret: %w", err)
+		}
+		return credentials.NewStaticCredentials(accessKey, secretKey, ""), "", nil
+	}
+}
+
+// awsWebIdentityCredentials assumes the role_arn of an STS secret with a Velero service account token for the
+// openshift audience. The web_identity_token_file of the secret is never read by the operator.
+func (r *DataProtectionTestReconciler) awsWebIdentityCredentials(ctx context.Context, roleARN, region string) (credentials.Value, error) {
+	token, err := r.veleroServiceAccountToken(ctx, cloudprovider.ServiceAccountTokenAudience)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	return cloudprovider.WebIdentityCredentials(ctx, r.awsCredentialsEndpoint, region, roleARN, token)
+}
+
+// gcpSubjectToken returns the service account token exchanged for GCP workload identity federation credentials,
+// a token of the Velero service account requested for the audience of the workload identity pool provider.
+// The credential source file of the secret is never read, and a failed token request is returned as a
+// *cloudprovider.TokenExchangeError.
+func (r *DataProtectionTestReconciler) gcpSubjectToken(ctx context.Context, credentialsJSON []byte) (string, error) {
+	account, err := cloudprovider.ParseGCPExternalAccount(credentialsJSON)
+	if err != nil {
+		return "", err
+	}
+	token, err := r.veleroServiceAccountToken(ctx, account.Audience)
+	if err != nil {
+		return "", &cloudprovider.TokenExchangeError{CredentialType: cloudprovider.GCPExternalAccountCredentialType, Err: err}
+	}
+	return token, nil
+}
+
+// veleroServiceAccountToken requests a token of the Velero service account for audience.
+func (r *DataProtectionTestReconciler) veleroServiceAccountToken(ctx context.Context, audience string) (string, error) {
+	tokenSource := r.serviceAccountTokenSource
+	if tokenSource == nil {
+		tokenSource = func(ctx context.Context, audience string) (string, error) {
+			return requestVeleroServiceAccountToken(ctx, r.Client, r.NamespacedName.Namespace, audience)
+		}
+	}
+	token, err := tokenSource(ctx, audience)
+	if err != nil {
+		return "", fmt.Errorf("failed to request service account token: %w", err)
+	}
+	return token, nil
+}
+
+// awsRolesAnywhereCredentials creates an IAM Roles Anywhere session with the certificate of the credential_process.
+// The certificate and private key are read from the cloud identity certificate secret mounted in the Velero pod.
+func (r *DataProtectionTestReconciler) awsRolesAnywhereCredentials(ctx context.Context, credentialProcess string) (credentials.Value, error) {
+	process, err := cloudprovider.ParseAWSRolesAnywhereProcess(credentialProcess)
+	if err != nil {
+		return credentials.Value{}, fmt.Errorf("failed to parse AWS secret: %w", err)
+	}
+	certificate, err := r.rolesAnywhereCertificateFile(ctx, process.Certificate)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	privateKey, err := r.rolesAnywhereCertificateFile(ctx, process.PrivateKey)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	endpoint, region, err := process.RolesAnywhereEndpoint()
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	if r.awsCredentialsEndpoint != "" {
+		endpoint = r.awsCredentialsEndpoint
+	}
+	return cloudprovider.RolesAnywhereCredentials(ctx, nil, endpoint, region, process, certificate, privateKey)
+}
+
+// rolesAnywhereCertificateFile reads a file of the credential_process from the secret mounted at that path
+// under stsflow.RolesAnywhereMountPath, since the operator pod does not mount the certificate secrets.
+func (r *DataProtectionTestReconciler) rolesAnywhereCertificateFile(ctx context.Context, file string) ([]byte, error) {
+	secretName, key, found := strings.Cut(strings.TrimPrefix(file, stsflow.RolesAnywhereMountPath+"/"), "/")
+	if !strings.HasPrefix(file, stsflow.RolesAnywhereMountPath+"/") || !found {
+		return nil, fmt.Errorf("certificate file %s is not mounted from a cloud identity certificate secret", file)
+	}
+	secret, err := utils.GetProviderSecret(secretName, r.NamespacedName.Namespace, r.Client, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get IAM Roles Anywhere certificate secret: %w", err)
+	}
+	data, found := secret.Data[key]
+	if !found {
+		return nil, fmt.Errorf("key %s not found in secret %s", key, secretName)
+	}
+	return data, nil
+}
+
+// awsPodIdentityCredentials requests the credentials of the EKS Pod Identity association of the velero service account
+// from the Pod Identity agent, with a velero service account token for the agent audience.
+func (r *DataProtectionTestReconciler) awsPodIdentityCredentials(ctx context.Context) (credentials.Value, error) {
+	token, err := r.veleroServiceAccountToken(ctx, stsflow.PodIdentityTokenAudience)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	endpoint := stsflow.PodIdentityCredentialsFullURI
+	if r.awsCredentialsEndpoint != "" {
+		endpoint = r.awsCredentialsEndpoint
+	}
+	return cloudprovider.PodIdentityCredentials(ctx, nil, endpoint, token)
+}
+
+// providerInitError wraps a cloud provider initialization failure, keeping token exchange failures distinct from
+// bucket and configuration errors
+func providerInitError(err error) error {
+	var exchangeErr *cloudprovider.TokenExchangeError
+	if errors.As(err, &exchangeErr) {
+		return exchangeErr
+	}
+	return fmt.Errorf("cloud provider init failed: %w", err)
+}
+
 // initializeAzureProvider initializes an Azure CloudProvider using credentials and configuration
 func (r *DataProtectionTestReconciler) initializeAzureProvider(ctx context.Context, backupLocationSpec *velerov1.BackupStorageLocationSpec) (cloudprovider.CloudProvider, error) {
 	r.Log.Info("Initializing Azure provider")
@@ -428,7 +830,12 @@ func (r *DataProtectionTestReconciler) initializeAzureProvider(ctx context.Conte
 		return nil, fmt.Errorf("failed to get Azure secret: %w", err)
 	}
 
-	creds := cloudprovider.ParseAzureCredentials(secret.Data)
+	// authenticate as Velero does for the BSL
+	creds := azurecreds.ForLocation(secret.Data, backupLocationSpec.Credential.Key, backupLocationSpec.Config)
+	if err := creds.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid Azure credentials in secret %s: %w", backupLocationSpec.Credential.Name, err)
+	}
+	r.Log.Info("Resolved Azure credentials", "method", creds.Method())
 
 	// Initialize the Azure provider
 	azureProvider, err := cloudprovider.NewAzureProvider(creds)
@@ -479,6 +886,29 @@ func (r *DataProtectionTestReconciler) runUploadTest(ctx context.Context, dpt *o
 	return nil
 }
 
+// runDownloadTest performs a download speed test using a provider that implements DownloadTester.
+// The results are written into the DataProtectionTest's DownloadTestStatus field.
+func (r *DataProtectionTestReconciler) runDownloadTest(ctx context.Context, dpt *oadpv1alpha1.DataProtectionTest, backupLocationSpec *velerov1.BackupStorageLocationSpec, tester cloudprovider.DownloadTester) {
+	cfg := dpt.Spec.UploadSpeedTestConfig
+	bucket := backupLocationSpec.ObjectStorage.Bucket
+	r.Log.Info("Starting download test", "bucket", bucket, "fileSize", cfg.FileSize, "timeout", cfg.Timeout)
+	speed, duration, err := tester.DownloadTest(ctx, *cfg, bucket, r.Log)
+
+	dpt.Status.DownloadTest = &oadpv1alpha1.DownloadTestStatus{
+		Duration: duration.Truncate(time.Millisecond).String(),
+		Success:  err == nil,
+	}
+
+	if err != nil {
+		r.Log.Error(err, "Download test failed")
+		dpt.Status.DownloadTest.ErrorMessage = err.Error()
+		return
+	}
+
+	dpt.Status.DownloadTest.SpeedMbps = speed
+	r.Log.Info("Download test succeeded", "speedMbps", speed, "duration", duration.Truncate(time.Millisecond).String())
+}
+
 // resolveBackupLocation resolves the effective BackupStorageLocationSpec to use,
 // either inline from the DPT CR or by fetching a named BSL from the cluster.
 func (r *DataProtectionTestReconciler) resolveBackupLocation(
@@ -688,6 +1118,9 @@ func (r *DataProtectionTestReconciler) updateDPTErrorStatus(ctx context.Context,
 		}
 		latest.Status.Phase = "Failed"
 		latest.Status.ErrorMessage = msg
+		if r.dpt != nil {
+			latest.Status.TokenExchange = r.dpt.Status.TokenExchange
+		}
 		return r.Status().Update(ctx, latest)
 	})
 
@@ -703,13 +1136,25 @@ func (r *DataProtectionTestReconciler) updateDPTStatusToComplete(ctx context.Con
 			return err
 		}
 
-		latest.Status.Phase = "Complete"
+		latest.Status.Phase = finalPhase(r.dpt)
 		latest.Status.ErrorMessage = ""
+		if len(r.dpt.Status.FailureReasons) > 0 {
+			latest.Status.ErrorMessage = thresholdErrorMessage(r.dpt.Status.FailureReasons)
+		}
+		latest.Status.FailureReasons = r.dpt.Status.FailureReasons
+		latest.Status.TokenExchange = r.dpt.Status.TokenExchange
 		latest.Status.UploadTest = r.dpt.Status.UploadTest
+		latest.Status.DownloadTest = r.dpt.Status.DownloadTest
 		latest.Status.SnapshotTests = r.dpt.Status.SnapshotTests
 		latest.Status.SnapshotSummary = r.dpt.Status.SnapshotSummary
+		latest.Status.VolumeSnapshotLocationTest = r.dpt.Status.VolumeSnapshotLocationTest
 		latest.Status.BucketMetadata = r.dpt.Status.BucketMetadata
+		latest.Status.S3Capabilities = r.dpt.Status.S3Capabilities
 		latest.Status.S3Vendor = r.dpt.Status.S3Vendor
+		latest.Status.BackupLocationResults = r.dpt.Status.BackupLocationResults
+		latest.Status.BackupLocationSummary = r.dpt.Status.BackupLocationSummary
+		latest.Status.EndpointLatency = r.dpt.Status.EndpointLatency
+		latest.Status.Report = r.dpt.Status.Report
 
 		return r.Status().Update(ctx, latest)
 	})
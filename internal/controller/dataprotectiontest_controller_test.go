@@ -18,21 +18,29 @@ package controller
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/go-logr/logr"
 	snapshotv1api "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	configv1 "github.com/openshift/api/config/v1"
 	"github.com/stretchr/testify/require"
 	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	"golang.org/x/oauth2"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
@@ -101,6 +109,19 @@ func TestDetermineVendor(t *testing.T) {
 			},
 			expectedVendor: "Ceph",
 		},
+		{
+			name:         "Detect NooBaa despite x-amz-request-id",
+			serverHeader: "NooBaa",
+			extraHeaders: map[string]string{
+				"x-amz-request-id": "some-request-id",
+			},
+			expectedVendor: "NooBaa",
+		},
+		{
+			name:           "Detect StorageGRID via Server header",
+			serverHeader:   "StorageGRID/11.8.0",
+			expectedVendor: "StorageGRID",
+		},
 		{
 			name:           "Unknown vendor fallback",
 			serverHeader:   "SomethingElse",
@@ -239,6 +260,103 @@ func TestResolveBackupLocation(t *testing.T) {
 	}
 }
 
+func TestInitializeAWSProvider_PodIdentity(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, oadpv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	ctx := context.Background()
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "requested-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "no pod identity association for the service account")
+			return
+		}
+		fmt.Fprint(w, `{"AccessKeyId":"ASIA","SecretAccessKey":"secret","Token":"session"}`)
+	}))
+	defer agent.Close()
+
+	tests := []struct {
+		name              string
+		token             string
+		expectExchangeErr bool
+	}{
+		{
+			name:  "credentials served by the agent",
+			token: "requested-token",
+		},
+		{
+			name:              "service account without association",
+			token:             "other-token",
+			expectExchangeErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod-identity", Namespace: "openshift-adp"},
+				Data:       map[string][]byte{"credentials": []byte("[default]\ncredential_source = EcsContainer")},
+			}
+			var audience string
+			reconciler := &DataProtectionTestReconciler{
+				Client:         fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+				Log:            logr.Discard(),
+				Context:        ctx,
+				NamespacedName: types.NamespacedName{Name: "dummy", Namespace: "openshift-adp"},
+				dpt:            &oadpv1alpha1.DataProtectionTest{},
+				serviceAccountTokenSource: func(ctx context.Context, a string) (string, error) {
+					audience = a
+					return tt.token, nil
+				},
+				awsCredentialsEndpoint: agent.URL,
+			}
+
+			cp, err := reconciler.initializeAWSProvider(ctx, &velerov1.BackupStorageLocationSpec{
+				Provider: "aws",
+				Config:   map[string]string{"region": "us-east-1"},
+				Credential: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "pod-identity"},
+					Key:                  "credentials",
+				},
+			})
+			require.Equal(t, "pods.eks.amazonaws.com", audience)
+			if tt.expectExchangeErr {
+				var exchangeErr *cloudprovider.TokenExchangeError
+				require.ErrorAs(t, err, &exchangeErr)
+				require.Equal(t, cloudprovider.AWSPodIdentityCredentialType, exchangeErr.CredentialType)
+				return
+			}
+			require.NoError(t, err)
+			exchanger, ok := cp.(cloudprovider.TokenExchanger)
+			require.True(t, ok)
+			require.Equal(t, cloudprovider.AWSPodIdentityCredentialType, exchanger.ExchangedCredentialType())
+		})
+	}
+}
+
+func TestRolesAnywhereCertificateFile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "velero-cert", Namespace: "openshift-adp"},
+		Data:       map[string][]byte{"tls.crt": []byte("certificate")},
+	}
+	reconciler := &DataProtectionTestReconciler{
+		Client:         fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+		NamespacedName: types.NamespacedName{Name: "dummy", Namespace: "openshift-adp"},
+	}
+
+	data, err := reconciler.rolesAnywhereCertificateFile(context.Background(), "/credentials-rolesanywhere/velero-cert/tls.crt")
+	require.NoError(t, err)
+	require.Equal(t, "certificate", string(data))
+
+	_, err = reconciler.rolesAnywhereCertificateFile(context.Background(), "/credentials-rolesanywhere/velero-cert/tls.key")
+	require.ErrorContains(t, err, "key tls.key not found in secret velero-cert")
+
+	_, err = reconciler.rolesAnywhereCertificateFile(context.Background(), "/etc/pki/velero.crt")
+	require.ErrorContains(t, err, "is not mounted from a cloud identity certificate secret")
+}
+
 func TestInitializeProvider(t *testing.T) {
 	scheme := runtime.NewScheme()
 	require.NoError(t, oadpv1alpha1.AddToScheme(scheme))
@@ -614,7 +732,7 @@ func TestBuildTLSConfig(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			logger := logr.Discard()
 
-			tlsConfig, err := buildTLSConfig(tt.dpt, tt.bsl, logger)
+			tlsConfig, err := buildTLSConfig(tt.dpt, tt.bsl, nil, nil, logger)
 
 			if tt.expectError {
 				require.Error(t, err)
@@ -635,6 +753,23 @@ func TestBuildTLSConfig(t *testing.T) {
 	}
 }
 
+func TestBuildTLSConfig_TLSProfile(t *testing.T) {
+	dpt := &oadpv1alpha1.DataProtectionTest{Spec: oadpv1alpha1.DataProtectionTestSpec{SkipTLSVerify: true}}
+
+	tlsConfig, err := buildTLSConfig(dpt, nil, nil, configv1.TLSProfiles[configv1.TLSProfileModernType], logr.Discard())
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MinVersion)
+	require.True(t, tlsConfig.InsecureSkipVerify, "the profile applies with skipTLSVerify")
+
+	tlsConfig, err = buildTLSConfig(dpt, nil, nil, configv1.TLSProfiles[configv1.TLSProfileIntermediateType], logr.Discard())
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+	require.Contains(t, tlsConfig.CipherSuites, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256)
+
+	_, err = buildTLSConfig(dpt, nil, nil, &configv1.TLSProfileSpec{MinTLSVersion: "VersionTLS14"}, logr.Discard())
+	require.Error(t, err)
+}
+
 func TestBuildHTTPClientWithTLS(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -674,7 +809,7 @@ func TestBuildHTTPClientWithTLS(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			logger := logr.Discard()
 
-			client, err := buildHTTPClientWithTLS(tt.dpt, tt.bsl, logger)
+			client, err := buildHTTPClientWithTLS(tt.dpt, tt.bsl, nil, nil, logger)
 
 			if tt.expectError {
 				require.Error(t, err)
@@ -745,7 +880,7 @@ func TestBuildAWSSessionWithTLS(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			logger := logr.Discard()
 
-			session, err := buildAWSSessionWithTLS(tt.dpt, tt.bsl, tt.region, tt.endpoint, logger)
+			session, err := buildAWSSessionWithTLS(tt.dpt, tt.bsl, nil, nil, tt.region, tt.endpoint, logger)
 
 			if tt.expectError {
 				require.Error(t, err)
@@ -852,6 +987,216 @@ func TestInitializeGCPProvider(t *testing.T) {
 	}
 }
 
+// gcpEndpointTransport sends the requests to the GCP endpoints to a test server
+type gcpEndpointTransport struct {
+	server *url.URL
+}
+
+func (t gcpEndpointTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.server.Scheme
+	req.URL.Host = t.server.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestInitializeGCPProvider_ExternalAccount(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, oadpv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	// a file the secret must not be able to make the operator read, such as its own service account token
+	operatorTokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(operatorTokenFile, []byte("operator-token\n"), 0600))
+
+	const (
+		audience = "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/pool/providers/provider"
+		tokenURL = "https://sts.googleapis.com/v1/token"
+	)
+
+	tests := []struct {
+		name               string
+		audience           string
+		tokenURL           string
+		credentialFile     string
+		stsStatus          int
+		tokenSourceErr     error
+		expectSubjectToken string
+		expectExchangeErr  bool
+		expectRefused      bool
+	}{
+		{
+			name:               "token requested for the Velero service account",
+			stsStatus:          http.StatusOK,
+			expectSubjectToken: "requested-token",
+		},
+		{
+			name:               "hostile credential source file is not read",
+			credentialFile:     operatorTokenFile,
+			stsStatus:          http.StatusOK,
+			expectSubjectToken: "requested-token",
+		},
+		{
+			name:          "hostile token_url is refused",
+			tokenURL:      "https://attacker.example.com/v1/token",
+			stsStatus:     http.StatusOK,
+			expectRefused: true,
+		},
+		{
+			name:          "audience that is not a workload identity pool is refused",
+			audience:      "https://kubernetes.default.svc",
+			stsStatus:     http.StatusOK,
+			expectRefused: true,
+		},
+		{
+			name:               "token exchange rejected",
+			stsStatus:          http.StatusBadRequest,
+			expectSubjectToken: "requested-token",
+			expectExchangeErr:  true,
+		},
+		{
+			name:              "service account token request failed",
+			tokenSourceErr:    fmt.Errorf("forbidden"),
+			expectExchangeErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subjectToken atomic.Value
+			sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, r.ParseForm())
+				subjectToken.Store(r.PostForm.Get("subject_token"))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.stsStatus)
+				if tt.stsStatus != http.StatusOK {
+					fmt.Fprint(w, `{"error":"invalid_grant","error_description":"audience mismatch"}`)
+					return
+				}
+				fmt.Fprint(w, `{"access_token":"access","issued_token_type":"urn:ietf:params:oauth:token-type:access_token","token_type":"Bearer","expires_in":3600}`)
+			}))
+			defer sts.Close()
+			stsURL, err := url.Parse(sts.URL)
+			require.NoError(t, err)
+			ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: gcpEndpointTransport{server: stsURL}})
+
+			accountAudience, accountTokenURL := audience, tokenURL
+			if tt.audience != "" {
+				accountAudience = tt.audience
+			}
+			if tt.tokenURL != "" {
+				accountTokenURL = tt.tokenURL
+			}
+			credentialsJSON := fmt.Sprintf(`{
+				"type": "external_account",
+				"audience": %q,
+				"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+				"token_url": %q,
+				"credential_source": {"file": %q, "format": {"type": "text"}}
+			}`, accountAudience, accountTokenURL, tt.credentialFile)
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "gcp-secret", Namespace: "openshift-adp"},
+				Data:       map[string][]byte{"cloud": []byte(credentialsJSON)},
+			}
+			var requestedAudience string
+			reconciler := &DataProtectionTestReconciler{
+				Client:         fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+				Log:            logr.Discard(),
+				Context:        ctx,
+				NamespacedName: types.NamespacedName{Name: "dummy", Namespace: "openshift-adp"},
+				dpt:            &oadpv1alpha1.DataProtectionTest{},
+				serviceAccountTokenSource: func(ctx context.Context, a string) (string, error) {
+					requestedAudience = a
+					return "requested-token", tt.tokenSourceErr
+				},
+			}
+
+			cp, err := reconciler.initializeGCPProvider(ctx, &velerov1.BackupStorageLocationSpec{
+				Provider: "gcp",
+				StorageType: velerov1.StorageType{
+					ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "bucket"},
+				},
+				Credential: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "gcp-secret"},
+					Key:                  "cloud",
+				},
+			})
+
+			if tt.expectRefused {
+				require.Error(t, err)
+				require.Empty(t, requestedAudience, "no service account token may be requested for a refused configuration")
+				require.Nil(t, subjectToken.Load(), "a refused configuration must not reach the security token service")
+				return
+			}
+			if tt.expectSubjectToken != "" {
+				require.Equal(t, tt.expectSubjectToken, subjectToken.Load())
+			}
+			require.Equal(t, audience, requestedAudience)
+			if tt.expectExchangeErr {
+				var exchangeErr *cloudprovider.TokenExchangeError
+				require.ErrorAs(t, err, &exchangeErr)
+				require.Equal(t, cloudprovider.GCPExternalAccountCredentialType, exchangeErr.CredentialType)
+				return
+			}
+			require.NoError(t, err)
+			exchanger, ok := cp.(cloudprovider.TokenExchanger)
+			require.True(t, ok)
+			require.Equal(t, cloudprovider.GCPExternalAccountCredentialType, exchanger.ExchangedCredentialType())
+		})
+	}
+}
+
+func TestTestBackupLocation_TokenExchange(t *testing.T) {
+	bslSpec := &velerov1.BackupStorageLocationSpec{
+		Provider: "gcp",
+		StorageType: velerov1.StorageType{
+			ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "bucket"},
+		},
+	}
+	spec := oadpv1alpha1.DataProtectionTestSpec{
+		UploadSpeedTestConfig: &oadpv1alpha1.UploadSpeedTestConfig{FileSize: "1MB"},
+	}
+
+	t.Run("failed exchange is reported separately from bucket errors", func(t *testing.T) {
+		reconciler := &DataProtectionTestReconciler{
+			Log: logr.Discard(),
+			cloudProviderFactory: func(ctx context.Context, _ *velerov1.BackupStorageLocationSpec) (cloudprovider.CloudProvider, error) {
+				return nil, &cloudprovider.TokenExchangeError{
+					CredentialType: cloudprovider.GCPExternalAccountCredentialType,
+					Err:            fmt.Errorf("invalid_grant"),
+				}
+			},
+		}
+		dpt := &oadpv1alpha1.DataProtectionTest{Spec: spec}
+
+		err := reconciler.testBackupLocation(context.Background(), dpt, bslSpec)
+		require.Error(t, err)
+		require.Equal(t, &oadpv1alpha1.TokenExchangeStatus{
+			CredentialType: cloudprovider.GCPExternalAccountCredentialType,
+			ErrorMessage:   "invalid_grant",
+		}, dpt.Status.TokenExchange)
+		require.EqualError(t, err, "external_account token exchange failed: invalid_grant")
+		require.Empty(t, dpt.Status.UploadTest.ErrorMessage)
+	})
+
+	t.Run("non-exchange errors keep the provider init message", func(t *testing.T) {
+		reconciler := &DataProtectionTestReconciler{
+			Log: logr.Discard(),
+			cloudProviderFactory: func(ctx context.Context, _ *velerov1.BackupStorageLocationSpec) (cloudprovider.CloudProvider, error) {
+				return nil, fmt.Errorf("boom")
+			},
+		}
+		err := reconciler.testBackupLocation(context.Background(), &oadpv1alpha1.DataProtectionTest{Spec: spec}, bslSpec)
+		require.EqualError(t, err, "cloud provider init failed: boom")
+	})
+
+	t.Run("missing objectStorage is not reported as a provider init failure", func(t *testing.T) {
+		reconciler := &DataProtectionTestReconciler{Log: logr.Discard()}
+		err := reconciler.testBackupLocation(context.Background(), &oadpv1alpha1.DataProtectionTest{Spec: spec}, &velerov1.BackupStorageLocationSpec{Provider: "gcp"})
+		require.EqualError(t, err, "objectStorage config is missing in backupLocationSpec")
+	})
+}
+
 func TestUpdateDPTErrorStatus(t *testing.T) {
 	scheme := runtime.NewScheme()
 	require.NoError(t, oadpv1alpha1.AddToScheme(scheme))
@@ -1184,3 +1529,170 @@ func TestRunSnapshotTests(t *testing.T) {
 		})
 	}
 }
+
+func TestRunBackupLocationTests(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, oadpv1alpha1.AddToScheme(scheme))
+	require.NoError(t, velerov1.AddToScheme(scheme))
+
+	newBSL := func(name, provider string, labels map[string]string) *velerov1.BackupStorageLocation {
+		return &velerov1.BackupStorageLocation{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "openshift-adp",
+				Labels:    labels,
+			},
+			Spec: velerov1.BackupStorageLocationSpec{
+				Provider: provider,
+				StorageType: velerov1.StorageType{
+					ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: name + "-bucket"},
+				},
+			},
+		}
+	}
+	bsls := []client.Object{
+		newBSL("bsl-a", "gcp", map[string]string{"tier": "gold"}),
+		newBSL("bsl-b", "gcp", map[string]string{"tier": "gold"}),
+		newBSL("bsl-c", "gcp", map[string]string{"tier": "silver"}),
+		newBSL("bsl-broken", "gcp", map[string]string{"tier": "bronze"}),
+		newBSL("bsl-slow", "gcp", map[string]string{"tier": "bronze"}),
+	}
+
+	tests := []struct {
+		name            string
+		spec            oadpv1alpha1.DataProtectionTestSpec
+		expectErr       bool
+		expectResults   map[string]string
+		expectSummary   string
+		expectMaxActive int32
+	}{
+		{
+			name: "all locations with bounded concurrency",
+			spec: oadpv1alpha1.DataProtectionTestSpec{
+				BackupLocationSelector: &oadpv1alpha1.BackupLocationSelector{All: true, MaxConcurrency: 2},
+				UploadSpeedTestConfig:  &oadpv1alpha1.UploadSpeedTestConfig{FileSize: "1MB"},
+			},
+			expectResults: map[string]string{
+				"bsl-a": "Passed", "bsl-b": "Passed", "bsl-c": "Passed", "bsl-broken": "Failed", "bsl-slow": "Failed",
+			},
+			expectSummary:   "3/5 passed",
+			expectMaxActive: 2,
+		},
+		{
+			name: "locations selected by label",
+			spec: oadpv1alpha1.DataProtectionTestSpec{
+				BackupLocationSelector: &oadpv1alpha1.BackupLocationSelector{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}},
+				},
+				UploadSpeedTestConfig: &oadpv1alpha1.UploadSpeedTestConfig{FileSize: "1MB"},
+			},
+			expectResults:   map[string]string{"bsl-a": "Passed", "bsl-b": "Passed"},
+			expectSummary:   "2/2 passed",
+			expectMaxActive: 2,
+		},
+		{
+			name: "locations below the upload threshold fail",
+			spec: oadpv1alpha1.DataProtectionTestSpec{
+				BackupLocationSelector: &oadpv1alpha1.BackupLocationSelector{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}},
+				},
+				UploadSpeedTestConfig: &oadpv1alpha1.UploadSpeedTestConfig{FileSize: "1MB"},
+				Thresholds:            &oadpv1alpha1.TestThresholds{MinUploadSpeedMbps: 200, RequireVersioning: true},
+			},
+			expectResults:   map[string]string{"bsl-a": "Failed", "bsl-b": "Failed"},
+			expectSummary:   "0/2 passed",
+			expectMaxActive: 2,
+		},
+		{
+			name: "no upload config only records locations",
+			spec: oadpv1alpha1.DataProtectionTestSpec{
+				BackupLocationSelector: &oadpv1alpha1.BackupLocationSelector{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "bronze"}},
+				},
+			},
+			expectResults: map[string]string{"bsl-broken": "Passed", "bsl-slow": "Passed"},
+			expectSummary: "2/2 passed",
+		},
+		{
+			name: "selector combined with backupLocationName",
+			spec: oadpv1alpha1.DataProtectionTestSpec{
+				BackupLocationName:     "bsl-a",
+				BackupLocationSelector: &oadpv1alpha1.BackupLocationSelector{All: true},
+			},
+			expectErr: true,
+		},
+		{
+			name: "neither all nor labelSelector",
+			spec: oadpv1alpha1.DataProtectionTestSpec{
+				BackupLocationSelector: &oadpv1alpha1.BackupLocationSelector{},
+			},
+			expectErr: true,
+		},
+		{
+			name: "no matching locations",
+			spec: oadpv1alpha1.DataProtectionTestSpec{
+				BackupLocationSelector: &oadpv1alpha1.BackupLocationSelector{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "platinum"}},
+				},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(bsls...).Build()
+
+			var active, maxActive int32
+			r := &DataProtectionTestReconciler{
+				Client: k8sClient,
+				Log:    logr.Discard(),
+				cloudProviderFactory: func(ctx context.Context, spec *velerov1.BackupStorageLocationSpec) (cloudprovider.CloudProvider, error) {
+					current := atomic.AddInt32(&active, 1)
+					defer atomic.AddInt32(&active, -1)
+					for {
+						observed := atomic.LoadInt32(&maxActive)
+						if current <= observed || atomic.CompareAndSwapInt32(&maxActive, observed, current) {
+							break
+						}
+					}
+					time.Sleep(20 * time.Millisecond)
+
+					switch spec.ObjectStorage.Bucket {
+					case "bsl-broken-bucket":
+						return nil, fmt.Errorf("invalid credentials")
+					case "bsl-slow-bucket":
+						return &mockProvider{err: fmt.Errorf("upload timed out")}, nil
+					}
+					return &mockProvider{speed: 100, duration: time.Second, metadata: &oadpv1alpha1.BucketMetadata{VersioningStatus: "Enabled"}}, nil
+				},
+			}
+
+			dpt := &oadpv1alpha1.DataProtectionTest{
+				ObjectMeta: metav1.ObjectMeta{Name: "dpt", Namespace: "openshift-adp"},
+				Spec:       tt.spec,
+			}
+
+			err := r.runBackupLocationTests(context.Background(), dpt)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			results := map[string]string{}
+			for _, result := range dpt.Status.BackupLocationResults {
+				results[result.Name] = result.Status
+				if result.Status == "Failed" {
+					require.NotEmpty(t, result.ErrorMessage)
+				} else if tt.spec.UploadSpeedTestConfig != nil {
+					require.Equal(t, int64(100), result.UploadTest.SpeedMbps)
+					require.Equal(t, "Enabled", result.BucketMetadata.VersioningStatus)
+				}
+			}
+			require.Equal(t, tt.expectResults, results)
+			require.Equal(t, tt.expectSummary, dpt.Status.BackupLocationSummary)
+			require.LessOrEqual(t, maxActive, tt.expectMaxActive)
+		})
+	}
+}
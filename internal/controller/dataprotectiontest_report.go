@@ -0,0 +1,190 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/cloudprovider"
+	"github.com/openshift/oadp-operator/pkg/common"
+	"github.com/openshift/oadp-operator/pkg/dptreport"
+)
+
+const (
+	// ReportDestinationConfigMap writes the report to a ConfigMap in the DPT namespace
+	ReportDestinationConfigMap = "ConfigMap"
+	// ReportDestinationBucket writes the report to the BSL bucket
+	ReportDestinationBucket = "Bucket"
+
+	dptReportConfigMapPrefix = "dpt-report-"
+	dptReportConfigMapKey    = "report.json"
+	dptReportBucketPrefix    = "dpt-reports"
+)
+
+// +kubebuilder:rbac:groups=config.openshift.io,resources=clusterversions,verbs=get;list;watch
+
+// exportReport builds the JSON report of the current run and writes it to the destination
+// configured in spec.reportExport. Failures are returned in the status instead of failing the DPT.
+func (r *DataProtectionTestReconciler) exportReport(ctx context.Context, dpt *oadpv1alpha1.DataProtectionTest, backupLocationSpec *velerov1.BackupStorageLocationSpec) *oadpv1alpha1.ReportExportStatus {
+	// the report describes the run as it is recorded on completion
+	reportDPT := dpt.DeepCopy()
+	reportDPT.Status.Phase = finalPhase(dpt)
+	reportDPT.Status.ErrorMessage = ""
+	if len(dpt.Status.FailureReasons) > 0 {
+		reportDPT.Status.ErrorMessage = thresholdErrorMessage(dpt.Status.FailureReasons)
+	}
+
+	report := dptreport.Build(reportDPT, backupLocationSpec, r.clusterIdentity(ctx), time.Now())
+	status := &oadpv1alpha1.ReportExportStatus{
+		SchemaVersion: dptreport.SchemaVersion,
+	}
+
+	data, err := report.Marshal()
+	if err != nil {
+		status.ErrorMessage = fmt.Sprintf("failed to encode report: %v", err)
+		return status
+	}
+
+	var location string
+	switch dpt.Spec.ReportExport.Destination {
+	case ReportDestinationBucket:
+		location, err = r.writeReportToBucket(ctx, dpt, backupLocationSpec, report.GeneratedAt, data)
+	default:
+		location, err = r.writeReportToConfigMap(ctx, dpt, data)
+	}
+
+	if err != nil {
+		r.Log.Error(err, "failed to export DPT report", "destination", dpt.Spec.ReportExport.Destination)
+		status.ErrorMessage = err.Error()
+		return status
+	}
+
+	r.Log.Info("Exported DPT report", "location", location)
+	status.Location = location
+	return status
+}
+
+// writeReportToConfigMap stores the report in the "dpt-report-<dpt name>" ConfigMap owned by the DPT.
+func (r *DataProtectionTestReconciler) writeReportToConfigMap(ctx context.Context, dpt *oadpv1alpha1.DataProtectionTest, data []byte) (string, error) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dptReportConfigMapPrefix + dpt.Name,
+			Namespace: dpt.Namespace,
+		},
+	}
+
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, configMap, func() error {
+		if configMap.Labels == nil {
+			configMap.Labels = map[string]string{}
+		}
+		configMap.Labels[oadpv1alpha1.OadpOperatorLabel] = "True"
+		configMap.Labels["app.kubernetes.io/managed-by"] = common.OADPOperator
+		configMap.Labels["app.kubernetes.io/component"] = "dpt-report"
+		configMap.Data = map[string]string{
+			dptReportConfigMapKey: string(data),
+		}
+		return controllerutil.SetControllerReference(dpt, configMap, r.Scheme)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to write report ConfigMap %s: %w", configMap.Name, err)
+	}
+
+	if op != controllerutil.OperationResultNone && r.EventRecorder != nil {
+		r.EventRecorder.Event(dpt, corev1.EventTypeNormal, "DPTReportExported",
+			fmt.Sprintf("wrote report to ConfigMap %s", configMap.Name))
+	}
+
+	return "ConfigMap/" + configMap.Name, nil
+}
+
+// writeReportToBucket stores the report in the tested BSL bucket under
+// dpt-reports/<namespace>/<name>/<timestamp>.json.
+// The BSL must use a prefix because Velero marks a backup store unavailable
+// when its root contains unknown directories.
+func (r *DataProtectionTestReconciler) writeReportToBucket(ctx context.Context, dpt *oadpv1alpha1.DataProtectionTest, backupLocationSpec *velerov1.BackupStorageLocationSpec, generatedAt time.Time, data []byte) (string, error) {
+	if dpt.Spec.BackupLocationSelector != nil {
+		return "", fmt.Errorf("reportExport destination %s is not supported with backupLocationSelector", ReportDestinationBucket)
+	}
+
+	if backupLocationSpec == nil || backupLocationSpec.ObjectStorage == nil || backupLocationSpec.ObjectStorage.Bucket == "" {
+		return "", fmt.Errorf("reportExport destination %s requires a BSL bucket", ReportDestinationBucket)
+	}
+
+	if backupLocationSpec.ObjectStorage.Prefix == "" {
+		return "", fmt.Errorf("reportExport destination %s requires the BSL to set objectStorage.prefix, otherwise %s/ would be written inside the Velero backup store", ReportDestinationBucket, dptReportBucketPrefix)
+	}
+
+	newProvider := r.initializeProvider
+	if r.cloudProviderFactory != nil {
+		newProvider = r.cloudProviderFactory
+	}
+	cp, err := newProvider(ctx, backupLocationSpec)
+	if err != nil {
+		return "", fmt.Errorf("cloud provider init failed: %w", err)
+	}
+
+	writer, ok := cp.(cloudprovider.ObjectWriter)
+	if !ok {
+		return "", fmt.Errorf("provider %s does not support writing reports to the bucket", backupLocationSpec.Provider)
+	}
+
+	bucket := backupLocationSpec.ObjectStorage.Bucket
+	key := path.Join(dptReportBucketPrefix, dpt.Namespace, dpt.Name, generatedAt.UTC().Format("20060102T150405Z")+".json")
+	if err := writer.PutObject(ctx, bucket, key, data); err != nil {
+		return "", err
+	}
+
+	return bucket + "/" + key, nil
+}
+
+// clusterIdentity returns the OpenShift cluster ID, falling back to the kube-system namespace UID.
+func (r *DataProtectionTestReconciler) clusterIdentity(ctx context.Context) dptreport.ClusterIdentity {
+	c := r.ClusterWideClient
+	if c == nil {
+		c = r.Client
+	}
+
+	clusterVersion := &configv1.ClusterVersion{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "version"}, clusterVersion); err == nil && clusterVersion.Spec.ClusterID != "" {
+		return dptreport.ClusterIdentity{
+			ID:     string(clusterVersion.Spec.ClusterID),
+			Source: "ClusterVersion",
+		}
+	}
+
+	namespace := &corev1.Namespace{}
+	if err := c.Get(ctx, client.ObjectKey{Name: "kube-system"}, namespace); err != nil {
+		r.Log.Error(err, "failed to determine cluster identity for DPT report")
+		return dptreport.ClusterIdentity{}
+	}
+	return dptreport.ClusterIdentity{
+		ID:     string(namespace.UID),
+		Source: "Namespace/kube-system",
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/cloudprovider"
+	"github.com/openshift/oadp-operator/pkg/dptreport"
+)
+
+type mockObjectWriter struct {
+	mockProvider
+	objects map[string][]byte
+}
+
+func (m *mockObjectWriter) PutObject(ctx context.Context, bucket, key string, data []byte) error {
+	m.objects[bucket+"/"+key] = data
+	return nil
+}
+
+func TestExportReport(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, oadpv1alpha1.AddToScheme(scheme))
+	require.NoError(t, velerov1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	kubeSystem := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: "kube-system-uid"}}
+
+	bslSpec := func(prefix string) *velerov1.BackupStorageLocationSpec {
+		return &velerov1.BackupStorageLocationSpec{
+			Provider: "aws",
+			StorageType: velerov1.StorageType{
+				ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "bucket", Prefix: prefix},
+			},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		destination    string
+		selector       *oadpv1alpha1.BackupLocationSelector
+		bslSpec        *velerov1.BackupStorageLocationSpec
+		expectLocation string
+		expectError    string
+	}{
+		{
+			name:           "ConfigMap destination",
+			destination:    ReportDestinationConfigMap,
+			bslSpec:        bslSpec(""),
+			expectLocation: "ConfigMap/dpt-report-dpt",
+		},
+		{
+			name:           "default destination is ConfigMap",
+			destination:    "",
+			bslSpec:        bslSpec(""),
+			expectLocation: "ConfigMap/dpt-report-dpt",
+		},
+		{
+			name:           "Bucket destination with BSL prefix",
+			destination:    ReportDestinationBucket,
+			bslSpec:        bslSpec("velero"),
+			expectLocation: "bucket/dpt-reports/openshift-adp/dpt/",
+		},
+		{
+			name:        "Bucket destination without BSL prefix",
+			destination: ReportDestinationBucket,
+			bslSpec:     bslSpec(""),
+			expectError: "objectStorage.prefix",
+		},
+		{
+			name:        "Bucket destination with backupLocationSelector",
+			destination: ReportDestinationBucket,
+			selector:    &oadpv1alpha1.BackupLocationSelector{All: true},
+			expectError: "backupLocationSelector",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpt := &oadpv1alpha1.DataProtectionTest{
+				ObjectMeta: metav1.ObjectMeta{Name: "dpt", Namespace: "openshift-adp", UID: "dpt-uid"},
+				Spec: oadpv1alpha1.DataProtectionTestSpec{
+					BackupLocationSelector: tt.selector,
+					ReportExport:           &oadpv1alpha1.ReportExportConfig{Destination: tt.destination},
+				},
+				Status: oadpv1alpha1.DataProtectionTestStatus{
+					Phase:    "InProgress",
+					S3Vendor: "AWS",
+				},
+			}
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(dpt, kubeSystem).Build()
+
+			writer := &mockObjectWriter{objects: map[string][]byte{}}
+			r := &DataProtectionTestReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				Log:    logr.Discard(),
+				cloudProviderFactory: func(ctx context.Context, spec *velerov1.BackupStorageLocationSpec) (cloudprovider.CloudProvider, error) {
+					return writer, nil
+				},
+			}
+
+			status := r.exportReport(context.Background(), dpt, tt.bslSpec)
+			require.Equal(t, dptreport.SchemaVersion, status.SchemaVersion)
+
+			if tt.expectError != "" {
+				require.Contains(t, status.ErrorMessage, tt.expectError)
+				require.Empty(t, status.Location)
+				return
+			}
+			require.Empty(t, status.ErrorMessage)
+			require.True(t, strings.HasPrefix(status.Location, tt.expectLocation), status.Location)
+
+			var data []byte
+			if strings.HasPrefix(status.Location, "ConfigMap/") {
+				configMap := &corev1.ConfigMap{}
+				require.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: "dpt-report-dpt", Namespace: "openshift-adp"}, configMap))
+				require.Len(t, configMap.OwnerReferences, 1)
+				require.Equal(t, "dpt", configMap.OwnerReferences[0].Name)
+				data = []byte(configMap.Data["report.json"])
+			} else {
+				require.True(t, strings.HasSuffix(status.Location, ".json"))
+				data = writer.objects[status.Location]
+			}
+
+			report := &dptreport.Report{}
+			require.NoError(t, json.Unmarshal(data, report))
+			require.Equal(t, dptreport.SchemaVersion, report.SchemaVersion)
+			require.Equal(t, "Complete", report.Results.Phase)
+			require.Equal(t, "AWS", report.Results.S3Vendor)
+			require.Equal(t, "kube-system-uid", report.Cluster.ID)
+		})
+	}
+}
+
+func TestClusterIdentity(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, configv1.AddToScheme(scheme))
+
+	tests := []struct {
+		name         string
+		objects      []client.Object
+		expectID     string
+		expectSource string
+	}{
+		{
+			name: "OpenShift ClusterVersion",
+			objects: []client.Object{
+				&configv1.ClusterVersion{ObjectMeta: metav1.ObjectMeta{Name: "version"}, Spec: configv1.ClusterVersionSpec{ClusterID: "ocp-cluster-id"}},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: "kube-system-uid"}},
+			},
+			expectID:     "ocp-cluster-id",
+			expectSource: "ClusterVersion",
+		},
+		{
+			name: "kube-system namespace fallback",
+			objects: []client.Object{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: "kube-system-uid"}},
+			},
+			expectID:     "kube-system-uid",
+			expectSource: "Namespace/kube-system",
+		},
+		{
+			name: "no identity available",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &DataProtectionTestReconciler{
+				ClusterWideClient: fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.objects...).Build(),
+				Log:               logr.Discard(),
+			}
+			identity := r.clusterIdentity(context.Background())
+			require.Equal(t, tt.expectID, identity.ID, fmt.Sprintf("%+v", identity))
+			require.Equal(t, tt.expectSource, identity.Source)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+// evaluateThresholds returns a reason for every spec.thresholds criterion the DPT results violate.
+// An empty result means the run passed, or that no thresholds are configured.
+func evaluateThresholds(dpt *oadpv1alpha1.DataProtectionTest) []string {
+	thresholds := dpt.Spec.Thresholds
+	if thresholds == nil {
+		return nil
+	}
+
+	var reasons []string
+	uploadConfigured := dpt.Spec.UploadSpeedTestConfig != nil
+
+	if dpt.Spec.BackupLocationSelector != nil {
+		for _, result := range dpt.Status.BackupLocationResults {
+			for _, reason := range backupLocationViolations(thresholds, uploadConfigured, result.UploadTest, result.DownloadTest, result.BucketMetadata) {
+				reasons = append(reasons, fmt.Sprintf("%s: %s", result.Name, reason))
+			}
+		}
+	} else {
+		uploadTest := dpt.Status.UploadTest
+		reasons = append(reasons, backupLocationViolations(thresholds, uploadConfigured, &uploadTest, dpt.Status.DownloadTest, dpt.Status.BucketMetadata)...)
+	}
+
+	if maxReady := thresholds.MaxSnapshotReadyDuration; maxReady != nil {
+		if len(dpt.Spec.CSIVolumeSnapshotTestConfigs) == 0 {
+			reasons = append(reasons, "maxSnapshotReadyDuration requires csiVolumeSnapshotTestConfigs")
+		}
+		for _, snapshot := range dpt.Status.SnapshotTests {
+			pvc := snapshot.PersistentVolumeClaimNamespace + "/" + snapshot.PersistentVolumeClaimName
+			if snapshot.Status != "Ready" {
+				reasons = append(reasons, fmt.Sprintf("snapshot of PVC %s did not become ready: %s", pvc, snapshot.ErrorMessage))
+				continue
+			}
+			readyDuration, err := time.ParseDuration(snapshot.ReadyDuration)
+			if err != nil {
+				reasons = append(reasons, fmt.Sprintf("snapshot of PVC %s has invalid ready duration %q", pvc, snapshot.ReadyDuration))
+				continue
+			}
+			if readyDuration > maxReady.Duration {
+				reasons = append(reasons, fmt.Sprintf("snapshot of PVC %s took %s to become ready, exceeding maximum %s", pvc, snapshot.ReadyDuration, maxReady.Duration))
+			}
+		}
+	}
+
+	return reasons
+}
+
+// backupLocationViolations checks the object storage thresholds against the results of a single location.
+func backupLocationViolations(
+	thresholds *oadpv1alpha1.TestThresholds,
+	uploadConfigured bool,
+	uploadTest *oadpv1alpha1.UploadTestStatus,
+	downloadTest *oadpv1alpha1.DownloadTestStatus,
+	bucketMetadata *oadpv1alpha1.BucketMetadata,
+) []string {
+	var reasons []string
+
+	if thresholds.MinUploadSpeedMbps > 0 {
+		switch {
+		case !uploadConfigured:
+			reasons = append(reasons, "minUploadSpeedMbps requires uploadSpeedTestConfig")
+		case uploadTest == nil || !uploadTest.Success:
+			reasons = append(reasons, "upload test did not succeed")
+		case uploadTest.SpeedMbps < thresholds.MinUploadSpeedMbps:
+			reasons = append(reasons, fmt.Sprintf("upload speed %d Mbps is below minimum %d Mbps", uploadTest.SpeedMbps, thresholds.MinUploadSpeedMbps))
+		}
+	}
+
+	if thresholds.MinDownloadSpeedMbps > 0 {
+		switch {
+		case !uploadConfigured:
+			reasons = append(reasons, "minDownloadSpeedMbps requires uploadSpeedTestConfig")
+		case downloadTest == nil || !downloadTest.Success:
+			reasons = append(reasons, "download test did not succeed")
+		case downloadTest.SpeedMbps < thresholds.MinDownloadSpeedMbps:
+			reasons = append(reasons, fmt.Sprintf("download speed %d Mbps is below minimum %d Mbps", downloadTest.SpeedMbps, thresholds.MinDownloadSpeedMbps))
+		}
+	}
+
+	if thresholds.RequiredEncryptionAlgorithm != "" || thresholds.RequireVersioning {
+		if bucketMetadata == nil || bucketMetadata.ErrorMessage != "" {
+			reasons = append(reasons, "bucket metadata could not be determined")
+			return reasons
+		}
+	}
+
+	if required := thresholds.RequiredEncryptionAlgorithm; required != "" && !strings.EqualFold(bucketMetadata.EncryptionAlgorithm, required) {
+		reasons = append(reasons, fmt.Sprintf("bucket encryption %q does not match required %q", bucketMetadata.EncryptionAlgorithm, required))
+	}
+
+	if thresholds.RequireVersioning && bucketMetadata.VersioningStatus != "Enabled" {
+		reasons = append(reasons, fmt.Sprintf("bucket versioning is %q, Enabled is required", bucketMetadata.VersioningStatus))
+	}
+
+	return reasons
+}
+
+// downloadTestEnabled reports whether the download test should run for the DPT.
+func downloadTestEnabled(dpt *oadpv1alpha1.DataProtectionTest) bool {
+	if dpt.Spec.UploadSpeedTestConfig == nil {
+		return false
+	}
+	return dpt.Spec.UploadSpeedTestConfig.DownloadTest ||
+		(dpt.Spec.Thresholds != nil && dpt.Spec.Thresholds.MinDownloadSpeedMbps > 0)
+}
+
+// thresholdErrorMessage summarizes the threshold violations for status.errorMessage.
+func thresholdErrorMessage(reasons []string) string {
+	return fmt.Sprintf("%d threshold(s) violated: %s", len(reasons), strings.Join(reasons, "; "))
+}
+
+// finalPhase returns the phase a finished DPT run is recorded with.
+func finalPhase(dpt *oadpv1alpha1.DataProtectionTest) string {
+	if len(dpt.Status.FailureReasons) > 0 {
+		return "Failed"
+	}
+	return "Complete"
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestEvaluateThresholds(t *testing.T) {
+	uploadConfig := &oadpv1alpha1.UploadSpeedTestConfig{FileSize: "10MB"}
+	snapshotConfigs := []oadpv1alpha1.CSIVolumeSnapshotTestConfig{
+		{VolumeSnapshotSource: oadpv1alpha1.VolumeSnapshotSource{PersistentVolumeClaimName: "mysql", PersistentVolumeClaimNamespace: "app"}},
+	}
+
+	tests := []struct {
+		name          string
+		spec          oadpv1alpha1.DataProtectionTestSpec
+		status        oadpv1alpha1.DataProtectionTestStatus
+		expectReasons []string
+	}{
+		{
+			name: "no thresholds",
+			spec: oadpv1alpha1.DataProtectionTestSpec{UploadSpeedTestConfig: uploadConfig},
+			status: oadpv1alpha1.DataProtectionTestStatus{
+				UploadTest: oadpv1alpha1.UploadTestStatus{Success: false},
+			},
+		},
+		{
+			name: "all thresholds met",
+			spec: oadpv1alpha1.DataProtectionTestSpec{
+				UploadSpeedTestConfig:        uploadConfig,
+				CSIVolumeSnapshotTestConfigs: snapshotConfigs,
+				Thresholds: &oadpv1alpha1.TestThresholds{
+					MinUploadSpeedMbps:          100,
+					MinDownloadSpeedMbps:        200,
+					MaxSnapshotReadyDuration:    &metav1.Duration{Duration: time.Minute},
+					RequiredEncryptionAlgorithm: "aws:kms",
+					RequireVersioning:           true,
+				},
+			},
+			status: oadpv1alpha1.DataProtectionTestStatus{
+				UploadTest:     oadpv1alpha1.UploadTestStatus{SpeedMbps: 150, Success: true},
+				DownloadTest:   &oadpv1alpha1.DownloadTestStatus{SpeedMbps: 300, Success: true},
+				BucketMetadata: &oadpv1alpha1.BucketMetadata{EncryptionAlgorithm: "AWS:KMS", VersioningStatus: "Enabled"},
+				SnapshotTests: []oadpv1alpha1.SnapshotTestStatus{
+					{PersistentVolumeClaimName: "mysql", PersistentVolumeClaimNamespace: "app", Status: "Ready", ReadyDuration: "30s"},
+				},
+			},
+		},
+		{
+			name: "speeds below minimum",
+			spec: oadpv1alpha1.DataProtectionTestSpec{
+				UploadSpeedTestConfig: uploadConfig,
+				Thresholds:            &oadpv1alpha1.TestThresholds{MinUploadSpeedMbps: 100, MinDownloadSpeedMbps: 200},
+			},
+			status: oadpv1alpha1.DataProtectionTestStatus{
+				UploadTest:   oadpv1alpha1.UploadTestStatus{SpeedMbps: 50, Success: true},
+				DownloadTest: &oadpv1alpha1.DownloadTestStatus{SpeedMbps: 150, Success: true},
+			},
+			expectReasons: []string{
+				"upload speed 50 Mbps is below minimum 100 Mbps",
+				"download speed 150 Mbps is below minimum 200 Mbps",
+			},
+		},
+		{
+			name: "failed and missing transfer tests",
+			spec: oadpv1alpha1.DataProtectionTestSpec{
+				UploadSpeedTestConfig: uploadConfig,
+				Thresholds:            &oadpv1alpha1.TestThresholds{MinUploadSpeedMbps: 100, MinDownloadSpeedMbps: 200},
+			},
+			status: oadpv1alpha1.DataProtectionTestStatus{
+				UploadTest: oadpv1alpha1.UploadTestStatus{Success: false, ErrorMessage: "timeout"},
+			},
+			expectReasons: []string{"upload test did not succeed", "download test did not succeed"},
+		},
+		{
+			name: "speed thresholds without upload config",
+			spec: oadpv1alpha1.DataProtectionTestSpec{
+				Thresholds: &oadpv1alpha1.TestThresholds{MinUploadSpeedMbps: 100, MinDownloadSpeedMbps: 200},
+			},
+			expectReasons: []string{
+				"minUploadSpeedMbps requires uploadSpeedTestConfig",
+				"minDownloadSpeedMbps requires uploadSpeedTestConfig",
+			},
+		},
+		{
+			name: "encryption and versioning mismatch",
+			spec: oadpv1alpha1.DataProtectionTestSpec{
+				UploadSpeedTestConfig: uploadConfig,
+				Thresholds:            &oadpv1alpha1.TestThresholds{RequiredEncryptionAlgorithm: "aws:kms", RequireVersioning: true},
+			},
+			status: oadpv1alpha1.DataProtectionTestStatus{
+				BucketMetadata: &oadpv1alpha1.BucketMetadata{EncryptionAlgorithm: "AES256", VersioningStatus: "Suspended"},
+			},
+			expectReasons: []string{
+				`bucket encryption "AES256" does not match required "aws:kms"`,
+				`bucket versioning is "Suspended", Enabled is required`,
+			},
+		},
+		{
+			name: "bucket metadata unavailable",
+			spec: oadpv1alpha1.DataProtectionTestSpec{
+				UploadSpeedTestConfig: uploadConfig,
+				Thresholds:            &oadpv1alpha1.TestThresholds{RequireVersioning: true},
+			},
+			status: oadpv1alpha1.DataProtectionTestStatus{
+				BucketMetadata: &oadpv1alpha1.BucketMetadata{ErrorMessage: "access denied"},
+			},
+			expectReasons: []string{"bucket metadata could not be determined"},
+		},
+		{
+			name: "slow and failed snapshots",
+			spec: oadpv1alpha1.DataProtectionTestSpec{
+				CSIVolumeSnapshotTestConfigs: snapshotConfigs,
+				Thresholds:                   &oadpv1alpha1.TestThresholds{MaxSnapshotReadyDuration: &metav1.Duration{Duration: time.Minute}},
+			},
+			status: oadpv1alpha1.DataProtectionTestStatus{
+				SnapshotTests: []oadpv1alpha1.SnapshotTestStatus{
+					{PersistentVolumeClaimName: "mysql", PersistentVolumeClaimNamespace: "app", Status: "Ready", ReadyDuration: "1m30s"},
+					{PersistentVolumeClaimName: "mongo", PersistentVolumeClaimNamespace: "app", Status: "Failed", ErrorMessage: "timeout"},
+				},
+			},
+			expectReasons: []string{
+				"snapshot of PVC app/mysql took 1m30s to become ready, exceeding maximum 1m0s",
+				"snapshot of PVC app/mongo did not become ready: timeout",
+			},
+		},
+		{
+			name: "snapshot threshold without snapshot tests",
+			spec: oadpv1alpha1.DataProtectionTestSpec{
+				Thresholds: &oadpv1alpha1.TestThresholds{MaxSnapshotReadyDuration: &metav1.Duration{Duration: time.Minute}},
+			},
+			expectReasons: []string{"maxSnapshotReadyDuration requires csiVolumeSnapshotTestConfigs"},
+		},
+		{
+			name: "backup location selector prefixes reasons with the BSL name",
+			spec: oadpv1alpha1.DataProtectionTestSpec{
+				BackupLocationSelector: &oadpv1alpha1.BackupLocationSelector{All: true},
+				UploadSpeedTestConfig:  uploadConfig,
+				Thresholds:             &oadpv1alpha1.TestThresholds{MinUploadSpeedMbps: 100},
+			},
+			status: oadpv1alpha1.DataProtectionTestStatus{
+				BackupLocationResults: []oadpv1alpha1.BackupLocationTestResult{
+					{Name: "fast", UploadTest: &oadpv1alpha1.UploadTestStatus{SpeedMbps: 500, Success: true}},
+					{Name: "slow", UploadTest: &oadpv1alpha1.UploadTestStatus{SpeedMbps: 10, Success: true}},
+					{Name: "broken"},
+				},
+			},
+			expectReasons: []string{
+				"slow: upload speed 10 Mbps is below minimum 100 Mbps",
+				"broken: upload test did not succeed",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpt := &oadpv1alpha1.DataProtectionTest{Spec: tt.spec, Status: tt.status}
+			reasons := evaluateThresholds(dpt)
+			require.Equal(t, tt.expectReasons, reasons)
+
+			dpt.Status.FailureReasons = reasons
+			if len(tt.expectReasons) > 0 {
+				require.Equal(t, "Failed", finalPhase(dpt))
+			} else {
+				require.Equal(t, "Complete", finalPhase(dpt))
+			}
+		})
+	}
+}
+
+func TestDownloadTestEnabled(t *testing.T) {
+	require.False(t, downloadTestEnabled(&oadpv1alpha1.DataProtectionTest{
+		Spec: oadpv1alpha1.DataProtectionTestSpec{Thresholds: &oadpv1alpha1.TestThresholds{MinDownloadSpeedMbps: 10}},
+	}))
+	require.False(t, downloadTestEnabled(&oadpv1alpha1.DataProtectionTest{
+		Spec: oadpv1alpha1.DataProtectionTestSpec{UploadSpeedTestConfig: &oadpv1alpha1.UploadSpeedTestConfig{}},
+	}))
+	require.True(t, downloadTestEnabled(&oadpv1alpha1.DataProtectionTest{
+		Spec: oadpv1alpha1.DataProtectionTestSpec{UploadSpeedTestConfig: &oadpv1alpha1.UploadSpeedTestConfig{DownloadTest: true}},
+	}))
+	require.True(t, downloadTestEnabled(&oadpv1alpha1.DataProtectionTest{
+		Spec: oadpv1alpha1.DataProtectionTestSpec{
+			UploadSpeedTestConfig: &oadpv1alpha1.UploadSpeedTestConfig{},
+			Thresholds:            &oadpv1alpha1.TestThresholds{MinDownloadSpeedMbps: 10},
+		},
+	}))
+}
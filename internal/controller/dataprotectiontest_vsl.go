@@ -0,0 +1,197 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/cloudprovider"
+	oadpcreds "github.com/openshift/oadp-operator/pkg/credentials"
+	"github.com/openshift/oadp-operator/pkg/credentials/azurecreds"
+	"github.com/openshift/oadp-operator/pkg/utils"
+)
+
+const (
+	// VolumeSnapshotLocation config keys used by the native snapshot test
+	vslVolumeProject    = "volumeProject"
+	vslProject          = "project"
+	vslSnapshotLocation = "snapshotLocation"
+	vslIncremental      = "incremental"
+)
+
+// runVolumeSnapshotLocationTest runs the native cloud snapshot test against the VSL named in
+// spec.volumeSnapshotLocationTestConfig and records the result in the DPT status.
+func (r *DataProtectionTestReconciler) runVolumeSnapshotLocationTest(ctx context.Context, dpt *oadpv1alpha1.DataProtectionTest) {
+	cfg := dpt.Spec.VolumeSnapshotLocationTestConfig
+
+	vsl := &velerov1.VolumeSnapshotLocation{}
+	if err := r.Get(ctx, types.NamespacedName{Name: cfg.VolumeSnapshotLocationName, Namespace: dpt.Namespace}, vsl); err != nil {
+		r.Log.Error(err, "failed to get VolumeSnapshotLocation", "name", cfg.VolumeSnapshotLocationName)
+		dpt.Status.VolumeSnapshotLocationTest = &oadpv1alpha1.VolumeSnapshotLocationTestStatus{
+			Name:         cfg.VolumeSnapshotLocationName,
+			Status:       "Failed",
+			ErrorMessage: fmt.Sprintf("failed to get VolumeSnapshotLocation: %v", err),
+		}
+		return
+	}
+
+	newSnapshotAPI := r.initializeSnapshotAPI
+	if r.snapshotAPIFactory != nil {
+		newSnapshotAPI = r.snapshotAPIFactory
+	}
+	api, err := newSnapshotAPI(ctx, vsl, cfg)
+	if err != nil {
+		r.Log.Error(err, "failed to initialize snapshot API", "vsl", vsl.Name)
+		dpt.Status.VolumeSnapshotLocationTest = &oadpv1alpha1.VolumeSnapshotLocationTestStatus{
+			Name:         vsl.Name,
+			Provider:     vsl.Spec.Provider,
+			Status:       "Failed",
+			ErrorMessage: fmt.Sprintf("cloud provider init failed: %v", err),
+		}
+		return
+	}
+
+	r.Log.Info("Starting native snapshot test", "vsl", vsl.Name, "provider", vsl.Spec.Provider, "region", api.Region(), "volumeID", cfg.VolumeID)
+	status := cloudprovider.RunNativeSnapshotTest(ctx, api, cloudprovider.NativeSnapshotTestOptions{
+		Name:              fmt.Sprintf("oadp-dpt-%d", time.Now().Unix()),
+		VolumeID:          cfg.VolumeID,
+		AvailabilityZone:  cfg.AvailabilityZone,
+		ScratchDiskSizeGB: cfg.ScratchDiskSizeGB,
+		Timeout:           cfg.Timeout.Duration,
+	}, r.Log)
+	status.Name = vsl.Name
+	status.Provider = vsl.Spec.Provider
+	dpt.Status.VolumeSnapshotLocationTest = status
+
+	r.Log.Info("Native snapshot test completed", "vsl", vsl.Name, "status", status.Status, "snapshotReadyDuration", status.SnapshotReadyDuration)
+}
+
+// initializeSnapshotAPI creates the provider snapshot API from the VSL credential and config.
+// Without spec.credential, the default cloud credentials secret of the provider is used, as Velero does.
+func (r *DataProtectionTestReconciler) initializeSnapshotAPI(ctx context.Context, vsl *velerov1.VolumeSnapshotLocation, cfg *oadpv1alpha1.VolumeSnapshotLocationTestConfig) (cloudprovider.SnapshotAPI, error) {
+	provider := strings.ToLower(vsl.Spec.Provider)
+	pluginFields, ok := oadpcreds.PluginSpecificFields[oadpv1alpha1.DefaultPlugin(provider)]
+	if !ok || !pluginFields.IsCloudProvider {
+		return nil, fmt.Errorf("unsupported VolumeSnapshotLocation provider: %s", vsl.Spec.Provider)
+	}
+
+	secretName, secretKey := pluginFields.SecretName, pluginFields.PluginSecretKey
+	if vsl.Spec.Credential != nil {
+		if vsl.Spec.Credential.Name != "" {
+			secretName = vsl.Spec.Credential.Name
+		}
+		if vsl.Spec.Credential.Key != "" {
+			secretKey = vsl.Spec.Credential.Key
+		}
+	}
+
+	r.Log.Info("Fetching VolumeSnapshotLocation secret", "secretName", secretName, "namespace", vsl.Namespace)
+	secret, err := utils.GetProviderSecret(secretName, vsl.Namespace, r.Client, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s secret: %w", provider, err)
+	}
+
+	config := vsl.Spec.Config
+	switch provider {
+	case AWSProvider:
+		return r.initializeAWSSnapshotAPI(ctx, secret, secretKey, config)
+	case GCPProvider:
+		credentialsJSON, exists := secret.Data[secretKey]
+		if !exists || len(credentialsJSON) == 0 {
+			return nil, fmt.Errorf("credential key %s not found in secret %s", secretKey, secretName)
+		}
+		project := gcpSnapshotProject(config, credentialsJSON)
+		if project == "" {
+			return nil, fmt.Errorf("GCP project could not be determined from VolumeSnapshotLocation config or credentials")
+		}
+		credentialType, err := cloudprovider.GCPCredentialType(credentialsJSON)
+		if err != nil {
+			return nil, err
+		}
+		if credentialType == cloudprovider.GCPExternalAccountCredentialType {
+			subjectToken, err := r.gcpSubjectToken(ctx, credentialsJSON)
+			if err != nil {
+				return nil, err
+			}
+			return cloudprovider.NewGCPSnapshotAPIWithExternalAccount(ctx, credentialsJSON, subjectToken, project, cfg.AvailabilityZone, config[vslSnapshotLocation])
+		}
+		return cloudprovider.NewGCPSnapshotAPI(ctx, credentialsJSON, project, cfg.AvailabilityZone, config[vslSnapshotLocation])
+	case AzureProvider:
+		// the VSL config takes precedence over the secret for the subscription and resource group
+		creds := azurecreds.ForLocation(secret.Data, secretKey, config)
+		if err := creds.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid Azure credentials in secret %s: %w", secretName, err)
+		}
+		return cloudprovider.NewAzureSnapshotAPI(creds, creds.SubscriptionID, creds.ResourceGroupName, config[vslIncremental] == "true")
+	default:
+		return nil, fmt.Errorf("unsupported VolumeSnapshotLocation provider: %s", vsl.Spec.Provider)
+	}
+}
+
+// initializeAWSSnapshotAPI creates the EC2 snapshot API with the credentials of the VSL profile, resolved like the
+// credentials of the BSL test
+func (r *DataProtectionTestReconciler) initializeAWSSnapshotAPI(ctx context.Context, secret corev1.Secret, secretKey string, config map[string]string) (cloudprovider.SnapshotAPI, error) {
+	region := config[Region]
+	if region == "" {
+		return nil, fmt.Errorf("region is required in VolumeSnapshotLocation config")
+	}
+
+	profile := "default"
+	if value, exists := config[Profile]; exists {
+		profile = value
+	}
+
+	creds, _, err := r.awsCredentials(ctx, secret, secretKey, profile, region)
+	if err != nil {
+		return nil, err
+	}
+
+	// reach EC2 through the cluster proxy with the cluster TLS profile, like the BSL test
+	sess, err := buildAWSSessionWithTLS(r.dpt, nil, r.clusterProxy, r.tlsProfile, region, "", r.Log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session with TLS: %w", err)
+	}
+	sess.Config.Credentials = creds
+	return cloudprovider.NewAWSSnapshotAPI(sess), nil
+}
+
+// gcpSnapshotProject returns the project of the disks: volumeProject, then project from the VSL config,
+// then the project_id of the service account key.
+func gcpSnapshotProject(config map[string]string, credentialsJSON []byte) string {
+	if project := config[vslVolumeProject]; project != "" {
+		return project
+	}
+	if project := config[vslProject]; project != "" {
+		return project
+	}
+	var key struct {
+		ProjectID string `json:"project_id"`
+	}
+	if err := json.Unmarshal(credentialsJSON, &key); err != nil {
+		return ""
+	}
+	return key.ProjectID
+}
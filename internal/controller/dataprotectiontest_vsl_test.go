@@ -0,0 +1,437 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	"golang.org/x/oauth2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/cloudprovider"
+)
+
+// mockSnapshotAPI succeeds on every operation except CreateSnapshot when snapshotErr is set.
+type mockSnapshotAPI struct {
+	snapshotErr error
+}
+
+func (m *mockSnapshotAPI) Region() string { return "us-east-1" }
+func (m *mockSnapshotAPI) ListVolumes(ctx context.Context) (int, error) {
+	return 2, nil
+}
+func (m *mockSnapshotAPI) DescribeVolume(ctx context.Context, volumeID string) error { return nil }
+func (m *mockSnapshotAPI) CreateVolume(ctx context.Context, name, zone string, sizeGB int64) (string, error) {
+	return "vol-scratch", nil
+}
+func (m *mockSnapshotAPI) DeleteVolume(ctx context.Context, volumeID string) error { return nil }
+func (m *mockSnapshotAPI) CreateSnapshot(ctx context.Context, name, volumeID string) (string, error) {
+	return "snap-1", m.snapshotErr
+}
+func (m *mockSnapshotAPI) WaitForSnapshot(ctx context.Context, snapshotID string) error { return nil }
+func (m *mockSnapshotAPI) DeleteSnapshot(ctx context.Context, snapshotID string) error  { return nil }
+func (m *mockSnapshotAPI) IsPermissionDenied(err error) bool                            { return err != nil }
+
+func TestRunVolumeSnapshotLocationTest(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, oadpv1alpha1.AddToScheme(scheme))
+	require.NoError(t, velerov1.AddToScheme(scheme))
+
+	vsl := &velerov1.VolumeSnapshotLocation{
+		ObjectMeta: metav1.ObjectMeta{Name: "default-vsl", Namespace: "openshift-adp"},
+		Spec: velerov1.VolumeSnapshotLocationSpec{
+			Provider: "aws",
+			Config:   map[string]string{"region": "us-east-1"},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		vslName        string
+		volumeID       string
+		api            cloudprovider.SnapshotAPI
+		initErr        error
+		expectStatus   string
+		expectError    string
+		expectScratch  bool
+		expectDenied   string
+		expectProvider string
+	}{
+		{
+			name:           "scratch volume passes",
+			vslName:        "default-vsl",
+			api:            &mockSnapshotAPI{},
+			expectStatus:   "Passed",
+			expectScratch:  true,
+			expectProvider: "aws",
+		},
+		{
+			name:           "denied snapshot is reported",
+			vslName:        "default-vsl",
+			volumeID:       "vol-123",
+			api:            &mockSnapshotAPI{snapshotErr: fmt.Errorf("UnauthorizedOperation")},
+			expectStatus:   "Failed",
+			expectError:    "CreateSnapshot failed",
+			expectDenied:   "CreateSnapshot",
+			expectProvider: "aws",
+		},
+		{
+			name:           "provider init failure",
+			vslName:        "default-vsl",
+			initErr:        fmt.Errorf("invalid credentials"),
+			expectStatus:   "Failed",
+			expectError:    "cloud provider init failed",
+			expectProvider: "aws",
+		},
+		{
+			name:         "missing VolumeSnapshotLocation",
+			vslName:      "missing",
+			expectStatus: "Failed",
+			expectError:  "failed to get VolumeSnapshotLocation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &DataProtectionTestReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(vsl).Build(),
+				Log:    logr.Discard(),
+				snapshotAPIFactory: func(ctx context.Context, vsl *velerov1.VolumeSnapshotLocation, cfg *oadpv1alpha1.VolumeSnapshotLocationTestConfig) (cloudprovider.SnapshotAPI, error) {
+					return tt.api, tt.initErr
+				},
+			}
+			dpt := &oadpv1alpha1.DataProtectionTest{
+				ObjectMeta: metav1.ObjectMeta{Name: "dpt", Namespace: "openshift-adp"},
+				Spec: oadpv1alpha1.DataProtectionTestSpec{
+					VolumeSnapshotLocationTestConfig: &oadpv1alpha1.VolumeSnapshotLocationTestConfig{
+						VolumeSnapshotLocationName: tt.vslName,
+						VolumeID:                   tt.volumeID,
+						AvailabilityZone:           "us-east-1a",
+					},
+				},
+			}
+
+			r.runVolumeSnapshotLocationTest(context.Background(), dpt)
+
+			status := dpt.Status.VolumeSnapshotLocationTest
+			require.NotNil(t, status)
+			require.Equal(t, tt.vslName, status.Name)
+			require.Equal(t, tt.expectProvider, status.Provider)
+			require.Equal(t, tt.expectStatus, status.Status)
+			require.Equal(t, tt.expectScratch, status.ScratchVolume)
+			if tt.expectError != "" {
+				require.Contains(t, status.ErrorMessage, tt.expectError)
+			} else {
+				require.Empty(t, status.ErrorMessage)
+				require.Equal(t, 2, status.VolumesFound)
+				require.NotEmpty(t, status.SnapshotReadyDuration)
+			}
+			for _, operation := range status.Operations {
+				if operation.Operation == tt.expectDenied {
+					require.Equal(t, cloudprovider.PermissionDenied, operation.Permission)
+				}
+			}
+		})
+	}
+}
+
+func TestInitializeSnapshotAPI(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	awsSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials", Namespace: "openshift-adp"},
+		Data: map[string][]byte{
+			"cloud": []byte("[default]\naws_access_key_id=AKIA\naws_secret_access_key=secret\n"),
+		},
+	}
+	customSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vsl-credentials", Namespace: "openshift-adp"},
+		Data: map[string][]byte{
+			"snapshots": []byte("[default]\naws_access_key_id=AKIA\naws_secret_access_key=secret\n"),
+		},
+	}
+
+	tests := []struct {
+		name         string
+		vsl          velerov1.VolumeSnapshotLocationSpec
+		objects      []client.Object
+		expectRegion string
+		expectError  string
+	}{
+		{
+			name:         "AWS default credentials",
+			vsl:          velerov1.VolumeSnapshotLocationSpec{Provider: "aws", Config: map[string]string{"region": "eu-west-1"}},
+			objects:      []client.Object{awsSecret},
+			expectRegion: "eu-west-1",
+		},
+		{
+			name: "AWS VSL credential",
+			vsl: velerov1.VolumeSnapshotLocationSpec{
+				Provider:   "aws",
+				Config:     map[string]string{"region": "us-west-2"},
+				Credential: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "vsl-credentials"}, Key: "snapshots"},
+			},
+			objects:      []client.Object{customSecret},
+			expectRegion: "us-west-2",
+		},
+		{
+			name:        "AWS without region",
+			vsl:         velerov1.VolumeSnapshotLocationSpec{Provider: "aws"},
+			objects:     []client.Object{awsSecret},
+			expectError: "region is required",
+		},
+		{
+			name:        "missing secret",
+			vsl:         velerov1.VolumeSnapshotLocationSpec{Provider: "gcp"},
+			expectError: "failed to get gcp secret",
+		},
+		{
+			name:        "unsupported provider",
+			vsl:         velerov1.VolumeSnapshotLocationSpec{Provider: "openshift"},
+			expectError: "unsupported VolumeSnapshotLocation provider",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &DataProtectionTestReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.objects...).Build(),
+				Log:    logr.Discard(),
+				dpt:    &oadpv1alpha1.DataProtectionTest{},
+			}
+			vsl := &velerov1.VolumeSnapshotLocation{
+				ObjectMeta: metav1.ObjectMeta{Name: "vsl", Namespace: "openshift-adp"},
+				Spec:       tt.vsl,
+			}
+
+			api, err := r.initializeSnapshotAPI(context.Background(), vsl, &oadpv1alpha1.VolumeSnapshotLocationTestConfig{})
+			if tt.expectError != "" {
+				require.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectRegion, api.Region())
+		})
+	}
+}
+
+func TestGCPSnapshotProject(t *testing.T) {
+	key := []byte(`{"type":"service_account","project_id":"key-project"}`)
+
+	require.Equal(t, "disks", gcpSnapshotProject(map[string]string{"volumeProject": "disks", "project": "snapshots"}, key))
+	require.Equal(t, "snapshots", gcpSnapshotProject(map[string]string{"project": "snapshots"}, key))
+	require.Equal(t, "key-project", gcpSnapshotProject(nil, key))
+	require.Empty(t, gcpSnapshotProject(nil, []byte("not json")))
+}
+
+func TestInitializeSnapshotAPI_WebIdentity(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	var roleARN string
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if r.Form.Get("WebIdentityToken") != "requested-token" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<ErrorResponse><Error><Type>Sender</Type><Code>InvalidIdentityToken</Code><Message>invalid token</Message></Error></ErrorResponse>`)
+			return
+		}
+		roleARN = r.Form.Get("RoleArn")
+		fmt.Fprint(w, `<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>`+
+			`<AccessKeyId>ASIA</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>session</SessionToken>`+
+			`<Expiration>2100-01-01T00:00:00Z</Expiration></Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`)
+	}))
+	defer sts.Close()
+
+	// a file the secret must not be able to make the operator read, such as its own service account token
+	operatorTokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(operatorTokenFile, []byte("operator-token"), 0600))
+
+	tests := []struct {
+		name              string
+		token             string
+		expectExchangeErr bool
+	}{
+		{
+			name:  "role assumed with the service account token",
+			token: "requested-token",
+		},
+		{
+			name:              "token rejected by STS",
+			token:             "other-token",
+			expectExchangeErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials", Namespace: "openshift-adp"},
+				Data: map[string][]byte{"cloud": []byte("[snapshots]\nrole_arn = arn:aws:iam::123456789012:role/velero\n" +
+					"web_identity_token_file = " + operatorTokenFile + "\n")},
+			}
+			var audience string
+			r := &DataProtectionTestReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+				Log:    logr.Discard(),
+				dpt:    &oadpv1alpha1.DataProtectionTest{},
+				serviceAccountTokenSource: func(ctx context.Context, a string) (string, error) {
+					audience = a
+					return tt.token, nil
+				},
+				awsCredentialsEndpoint: sts.URL,
+			}
+			vsl := &velerov1.VolumeSnapshotLocation{
+				ObjectMeta: metav1.ObjectMeta{Name: "vsl", Namespace: "openshift-adp"},
+				Spec:       velerov1.VolumeSnapshotLocationSpec{Provider: "aws", Config: map[string]string{"region": "us-east-2", "profile": "snapshots"}},
+			}
+
+			api, err := r.initializeSnapshotAPI(context.Background(), vsl, &oadpv1alpha1.VolumeSnapshotLocationTestConfig{})
+			require.Equal(t, cloudprovider.ServiceAccountTokenAudience, audience)
+			if tt.expectExchangeErr {
+				var exchangeErr *cloudprovider.TokenExchangeError
+				require.ErrorAs(t, err, &exchangeErr)
+				require.Equal(t, cloudprovider.AWSWebIdentityCredentialType, exchangeErr.CredentialType)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "us-east-2", api.Region())
+			require.Equal(t, "arn:aws:iam::123456789012:role/velero", roleARN)
+		})
+	}
+}
+
+func TestInitializeSnapshotAPI_ClusterProxy(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	var proxiedHost atomic.Value
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodConnect {
+			proxiedHost.Store(r.Host)
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer proxy.Close()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials", Namespace: "openshift-adp"},
+		Data:       map[string][]byte{"cloud": []byte("[default]\naws_access_key_id=AKIA\naws_secret_access_key=secret\n")},
+	}
+	r := &DataProtectionTestReconciler{
+		Client:       fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+		Log:          logr.Discard(),
+		dpt:          &oadpv1alpha1.DataProtectionTest{},
+		clusterProxy: &clusterProxyConfig{httpsProxy: proxy.URL},
+	}
+	vsl := &velerov1.VolumeSnapshotLocation{
+		ObjectMeta: metav1.ObjectMeta{Name: "vsl", Namespace: "openshift-adp"},
+		Spec:       velerov1.VolumeSnapshotLocationSpec{Provider: "aws", Config: map[string]string{"region": "eu-west-1"}},
+	}
+
+	api, err := r.initializeSnapshotAPI(context.Background(), vsl, &oadpv1alpha1.VolumeSnapshotLocationTestConfig{})
+	require.NoError(t, err)
+	_, err = api.ListVolumes(context.Background())
+	require.Error(t, err)
+	require.Equal(t, "ec2.eu-west-1.amazonaws.com:443", proxiedHost.Load(), "EC2 is reached through the cluster proxy")
+}
+
+func TestInitializeSnapshotAPI_GCPExternalAccount(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	var subjectToken atomic.Value
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		subjectToken.Store(r.PostForm.Get("subject_token"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"access","issued_token_type":"urn:ietf:params:oauth:token-type:access_token","token_type":"Bearer","expires_in":3600}`)
+	}))
+	defer sts.Close()
+	stsURL, err := url.Parse(sts.URL)
+	require.NoError(t, err)
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: gcpEndpointTransport{server: stsURL}})
+
+	tests := []struct {
+		name        string
+		tokenURL    string
+		expectError string
+	}{
+		{
+			name:     "token of the Velero service account exchanged",
+			tokenURL: "https://sts.googleapis.com/v1/token",
+		},
+		{
+			name:        "hostile token_url is refused",
+			tokenURL:    "https://attacker.example.com/v1/token",
+			expectError: "is not the GCP security token service",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subjectToken = atomic.Value{}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials-gcp", Namespace: "openshift-adp"},
+				Data: map[string][]byte{"cloud": []byte(fmt.Sprintf(`{
+					"type": "external_account",
+					"audience": "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/pool/providers/provider",
+					"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+					"token_url": %q,
+					"credential_source": {"file": "/var/run/secrets/openshift/serviceaccount/token"}
+				}`, tt.tokenURL))},
+			}
+			r := &DataProtectionTestReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+				Log:    logr.Discard(),
+				dpt:    &oadpv1alpha1.DataProtectionTest{},
+				serviceAccountTokenSource: func(ctx context.Context, audience string) (string, error) {
+					return "requested-token", nil
+				},
+			}
+			vsl := &velerov1.VolumeSnapshotLocation{
+				ObjectMeta: metav1.ObjectMeta{Name: "vsl", Namespace: "openshift-adp"},
+				Spec:       velerov1.VolumeSnapshotLocationSpec{Provider: "gcp", Config: map[string]string{"project": "disks"}},
+			}
+
+			api, err := r.initializeSnapshotAPI(ctx, vsl, &oadpv1alpha1.VolumeSnapshotLocationTestConfig{})
+			if tt.expectError != "" {
+				require.ErrorContains(t, err, tt.expectError)
+				require.Nil(t, subjectToken.Load())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "disks", api.Region())
+			require.Equal(t, "requested-token", subjectToken.Load())
+		})
+	}
+}
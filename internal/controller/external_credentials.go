@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/credentials"
+)
+
+// secretProviderClassGVK is the Secrets Store CSI driver SecretProviderClass kind
+var secretProviderClassGVK = schema.GroupVersionKind{
+	Group:   "secrets-store.csi.x-k8s.io",
+	Version: "v1",
+	Kind:    "SecretProviderClass",
+}
+
+// externalCredentialConfig returns a copy of the location config using the mounted external credential as credentialsFile
+func externalCredentialConfig(config map[string]string, externalCredential *oadpv1alpha1.ExternalCredential) map[string]string {
+	updated := make(map[string]string, len(config)+1)
+	for key, value := range config {
+		updated[key] = value
+	}
+	updated[CredentialsFileKey] = credentials.ExternalCredentialFile(externalCredential)
+	return updated
+}
+
+// validateExternalCredential checks that an external credential is the only credential of the location and
+// that its SecretProviderClass exists. The credentials themselves are only read by the Secrets Store CSI driver.
+func (r *DataProtectionApplicationReconciler) validateExternalCredential(locationYAMLPath string, externalCredential *oadpv1alpha1.ExternalCredential, credential *corev1.SecretKeySelector, config map[string]string) error {
+	if externalCredential.SecretProviderClass == "" || externalCredential.Key == "" {
+		return fmt.Errorf("%s.externalCredential secretProviderClass and key cannot be empty", locationYAMLPath)
+	}
+	if credential != nil {
+		return fmt.Errorf("%s.externalCredential cannot be set together with velero.credential", locationYAMLPath)
+	}
+	if _, found := config[CredentialsFileKey]; found {
+		return fmt.Errorf("%s.externalCredential cannot be set together with the %s config", locationYAMLPath, CredentialsFileKey)
+	}
+	if r.dpa.BackupImages() {
+		return fmt.Errorf("%s.externalCredential requires backupImages to be false, image backup reads the credentials from a Secret", locationYAMLPath)
+	}
+
+	secretProviderClass := &unstructured.Unstructured{}
+	secretProviderClass.SetGroupVersionKind(secretProviderClassGVK)
+	err := r.Get(r.Context, types.NamespacedName{Namespace: r.dpa.Namespace, Name: externalCredential.SecretProviderClass}, secretProviderClass)
+	switch {
+	case err == nil:
+		return nil
+	case apimeta.IsNoMatchError(err):
+		return fmt.Errorf("%s.externalCredential requires the Secrets Store CSI driver, SecretProviderClass is not available in the cluster", locationYAMLPath)
+	case k8serror.IsNotFound(err):
+		return fmt.Errorf("%s.externalCredential SecretProviderClass %s/%s not found", locationYAMLPath, r.dpa.Namespace, externalCredential.SecretProviderClass)
+	default:
+		return err
+	}
+}
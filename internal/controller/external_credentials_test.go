@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func newSecretProviderClass(name string) *unstructured.Unstructured {
+	secretProviderClass := &unstructured.Unstructured{}
+	secretProviderClass.SetGroupVersionKind(secretProviderClassGVK)
+	secretProviderClass.SetName(name)
+	secretProviderClass.SetNamespace("test-ns")
+	return secretProviderClass
+}
+
+func TestDPAReconciler_validateExternalCredential(t *testing.T) {
+	externalCredential := &oadpv1alpha1.ExternalCredential{SecretProviderClass: "vault-aws", Key: "cloud"}
+
+	tests := []struct {
+		name          string
+		credential    *corev1.SecretKeySelector
+		config        map[string]string
+		backupImages  bool
+		objects       []client.Object
+		wantErrorText string
+	}{
+		{
+			name:    "SecretProviderClass exists",
+			objects: []client.Object{newSecretProviderClass("vault-aws")},
+		},
+		{
+			name:          "SecretProviderClass not found",
+			wantErrorText: "spec.backupLocations[0].externalCredential SecretProviderClass test-ns/vault-aws not found",
+		},
+		{
+			name:          "set together with a credential",
+			credential:    &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "cloud-credentials"}, Key: "cloud"},
+			objects:       []client.Object{newSecretProviderClass("vault-aws")},
+			wantErrorText: "cannot be set together with velero.credential",
+		},
+		{
+			name:          "set together with credentialsFile",
+			config:        map[string]string{CredentialsFileKey: "cloud-credentials/cloud"},
+			objects:       []client.Object{newSecretProviderClass("vault-aws")},
+			wantErrorText: "cannot be set together with the credentialsFile config",
+		},
+		{
+			name:          "image backup enabled",
+			backupImages:  true,
+			objects:       []client.Object{newSecretProviderClass("vault-aws")},
+			wantErrorText: "requires backupImages to be false",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dpa := &oadpv1alpha1.DataProtectionApplication{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns"},
+				Spec:       oadpv1alpha1.DataProtectionApplicationSpec{BackupImages: ptr.To(tt.backupImages)},
+			}
+			fakeClient := getFakeClientFromObjectsForTest(t, append(tt.objects, dpa)...)
+			r := &DataProtectionApplicationReconciler{Client: fakeClient, Log: logr.Discard(), Context: newContextForTest(), dpa: dpa}
+
+			err := r.validateExternalCredential("spec.backupLocations[0]", externalCredential, tt.credential, tt.config)
+			if tt.wantErrorText != "" {
+				require.ErrorContains(t, err, tt.wantErrorText)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestDPAReconciler_ExternalCredentialLocations(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns"},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{DefaultPlugins: []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginAWS}},
+			},
+			BackupImages: ptr.To(false),
+			BackupLocations: []oadpv1alpha1.BackupLocation{{
+				Velero: &velerov1.BackupStorageLocationSpec{
+					Provider: AWSProvider,
+					Default:  true,
+					Config:   map[string]string{Region: "us-east-1"},
+					StorageType: velerov1.StorageType{
+						ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "bucket", Prefix: "velero"},
+					},
+				},
+				ExternalCredential: &oadpv1alpha1.ExternalCredential{SecretProviderClass: "vault-aws", Key: "s3"},
+			}},
+			SnapshotLocations: []oadpv1alpha1.SnapshotLocation{{
+				Velero: &velerov1.VolumeSnapshotLocationSpec{
+					Provider: AWSProvider,
+					Config:   map[string]string{Region: "us-east-1"},
+				},
+				ExternalCredential: &oadpv1alpha1.ExternalCredential{SecretProviderClass: "vault-aws", Key: "ebs"},
+			}},
+		},
+	}
+	fakeClient := getFakeClientFromObjectsForTest(t, dpa, newSecretProviderClass("vault-aws"))
+	r := &DataProtectionApplicationReconciler{
+		Client:         fakeClient,
+		Scheme:         fakeClient.Scheme(),
+		Log:            logr.Discard(),
+		Context:        newContextForTest(),
+		NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+		EventRecorder:  newEventRecorder(),
+		dpa:            dpa,
+	}
+
+	ok, err := r.ValidateBackupStorageLocations()
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = r.ValidateVolumeSnapshotLocations()
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = r.ValidateVeleroPlugins()
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = r.ReconcileBackupStorageLocations(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = r.ReconcileVolumeSnapshotLocations(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	bsl := &velerov1.BackupStorageLocation{}
+	require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Namespace: "test-ns", Name: "test-dpa-1"}, bsl))
+	require.Nil(t, bsl.Spec.Credential)
+	require.Equal(t, "/credentials-external/vault-aws/s3", bsl.Spec.Config[CredentialsFileKey])
+
+	vsl := &velerov1.VolumeSnapshotLocation{}
+	require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Namespace: "test-ns", Name: "test-dpa-1"}, vsl))
+	require.Nil(t, vsl.Spec.Credential)
+	require.Equal(t, "/credentials-external/vault-aws/ebs", vsl.Spec.Config[CredentialsFileKey])
+
+	require.NotContains(t, dpa.Spec.BackupLocations[0].Velero.Config, CredentialsFileKey, "the DPA spec must not be modified")
+	require.NotContains(t, dpa.Spec.SnapshotLocations[0].Velero.Config, CredentialsFileKey, "the DPA spec must not be modified")
+	require.Empty(t, r.credentialCheckTargets(r.Log))
+}
@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/credentials"
+	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
+)
+
+const (
+	backupStorageLocationKind  = "BackupStorageLocation"
+	volumeSnapshotLocationKind = "VolumeSnapshotLocation"
+)
+
+// mountedCredentialConfig returns a copy of the location config using the mounted credential secret key as credentialsFile
+func mountedCredentialConfig(config map[string]string, credential *corev1.SecretKeySelector) map[string]string {
+	updated := make(map[string]string, len(config)+1)
+	for key, value := range config {
+		updated[key] = value
+	}
+	updated[CredentialsFileKey] = credentials.LocationCredentialFile(credential)
+	return updated
+}
+
+// validateMountCredential checks that a location using mountCredential names the credential secret key to mount
+// and no other credentials
+func validateMountCredential(locationYAMLPath string, credential *corev1.SecretKeySelector, externalCredential *oadpv1alpha1.ExternalCredential, config map[string]string) error {
+	if credential == nil || credential.Name == "" || credential.Key == "" {
+		return fmt.Errorf("%s.mountCredential requires velero.credential name and key", locationYAMLPath)
+	}
+	if externalCredential != nil {
+		return fmt.Errorf("%s.mountCredential cannot be set together with externalCredential", locationYAMLPath)
+	}
+	if _, found := config[CredentialsFileKey]; found {
+		return fmt.Errorf("%s.mountCredential cannot be set together with the %s config", locationYAMLPath, CredentialsFileKey)
+	}
+	return nil
+}
+
+// ReconcileLocationCredentialStatus reports in status the credentials each backup and snapshot location resolves to
+func (r *DataProtectionApplicationReconciler) ReconcileLocationCredentialStatus(log logr.Logger) (bool, error) {
+	providerNeedsDefaultCreds, err := r.noDefaultCredentials()
+	if err != nil {
+		return false, err
+	}
+
+	var statuses []oadpv1alpha1.LocationCredentialStatus
+	for i, bsl := range r.dpa.Spec.BackupLocations {
+		status := oadpv1alpha1.LocationCredentialStatus{
+			Kind: backupStorageLocationKind,
+			Name: r.getBSLName(&bsl, i),
+		}
+		switch {
+		case bsl.Velero != nil:
+			status.Provider = bsl.Velero.Provider
+			r.resolveLocationCredential(&status, bsl.Velero.Credential, bsl.Velero.Config, bsl.ExternalCredential, bsl.MountCredential, providerNeedsDefaultCreds)
+		case bsl.CloudStorage != nil:
+			// CloudStorage locations always use their own credential, read by Velero at runtime
+			secretName, secretKey, err := r.getSecretNameAndKeyFromCloudStorage(bsl.CloudStorage)
+			if err != nil {
+				return false, err
+			}
+			status.Source = oadpv1alpha1.CredentialSourceLocationCredential
+			status.Secret = secretName + "/" + secretKey
+			if r.isSTSSecret(secretName) {
+				status.Source = oadpv1alpha1.CredentialSourceSTS
+			}
+		default:
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+	for i, vsl := range r.dpa.Spec.SnapshotLocations {
+		if vsl.Velero == nil {
+			continue
+		}
+		status := oadpv1alpha1.LocationCredentialStatus{
+			Kind:     volumeSnapshotLocationKind,
+			Name:     fmt.Sprintf("%s-%d", r.NamespacedName.Name, i+1),
+			Provider: vsl.Velero.Provider,
+		}
+		if vsl.Name != "" {
+			status.Name = vsl.Name
+		}
+		r.resolveLocationCredential(&status, vsl.Velero.Credential, vsl.Velero.Config, vsl.ExternalCredential, vsl.MountCredential, providerNeedsDefaultCreds)
+		statuses = append(statuses, status)
+	}
+
+	r.dpa.Status.LocationCredentials = statuses
+	return true, nil
+}
+
+// resolveLocationCredential sets the source, secret and file of the credentials used by a velero location,
+// in the order the Velero plugins read them
+func (r *DataProtectionApplicationReconciler) resolveLocationCredential(status *oadpv1alpha1.LocationCredentialStatus, credential *corev1.SecretKeySelector, config map[string]string, externalCredential *oadpv1alpha1.ExternalCredential, mountCredential bool, providerNeedsDefaultCreds map[string]bool) {
+	var secretName string
+	switch {
+	case externalCredential != nil:
+		status.Source = oadpv1alpha1.CredentialSourceExternalCredential
+		status.File = credentials.ExternalCredentialFile(externalCredential)
+		return
+	case mountCredential && credential != nil:
+		status.Source = oadpv1alpha1.CredentialSourceMountedFile
+		status.Secret = credential.Name + "/" + credential.Key
+		status.File = credentials.LocationCredentialFile(credential)
+		secretName = credential.Name
+	case config[CredentialsFileKey] != "":
+		status.Source = oadpv1alpha1.CredentialSourceCredentialsFile
+		status.File = config[CredentialsFileKey]
+		if name, key, err := credentials.GetSecretNameKeyFromCredentialsFileConfigString(config[CredentialsFileKey]); err == nil {
+			status.Secret = name + "/" + key
+		}
+		return
+	case credential != nil:
+		status.Source = oadpv1alpha1.CredentialSourceLocationCredential
+		status.Secret = credential.Name + "/" + credential.Key
+		secretName = credential.Name
+	default:
+		provider := strings.TrimPrefix(status.Provider, veleroIOPrefix)
+		fields, ok := credentials.PluginSpecificFields[oadpv1alpha1.DefaultPlugin(provider)]
+		if !ok || !fields.IsCloudProvider || !r.defaultCredentialsMounted(provider, providerNeedsDefaultCreds) {
+			status.Source = oadpv1alpha1.CredentialSourceEnvironment
+			return
+		}
+		status.Source = oadpv1alpha1.CredentialSourceDefaultFile
+		status.Secret = fields.SecretName + "/" + fields.PluginSecretKey
+		status.File = fields.MountPath + "/" + credentials.CloudFieldPath
+		secretName = fields.SecretName
+	}
+
+	if r.isSTSSecret(secretName) {
+		status.Source = oadpv1alpha1.CredentialSourceSTS
+	}
+}
+
+// defaultCredentialsMounted returns true if the default credentials secret of the provider is mounted in the
+// Velero pod, following appendPluginSpecificSpecs
+func (r *DataProtectionApplicationReconciler) defaultCredentialsMounted(provider string, providerNeedsDefaultCreds map[string]bool) bool {
+	if !providerNeedsDefaultCreds[provider] {
+		return false
+	}
+	return !r.dpa.Spec.Configuration.Velero.NoDefaultBackupLocation ||
+		r.dpa.Spec.UnsupportedOverrides[oadpv1alpha1.OperatorTypeKey] == oadpv1alpha1.OperatorTypeMTC
+}
+
+// isSTSSecret returns true if the secret holds short-lived credentials generated for the STS flow
+func (r *DataProtectionApplicationReconciler) isSTSSecret(secretName string) bool {
+	if secretName == "" {
+		return false
+	}
+	secret := &corev1.Secret{}
+	if err := r.Get(r.Context, types.NamespacedName{Namespace: r.dpa.Namespace, Name: secretName}, secret); err != nil {
+		return false
+	}
+	return secret.Labels[stsflow.STSSecretLabelKey] == stsflow.STSSecretLabelValue
+}
@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
+)
+
+func TestValidateMountCredential(t *testing.T) {
+	credential := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "team-a"}, Key: "cloud"}
+
+	tests := []struct {
+		name               string
+		credential         *corev1.SecretKeySelector
+		externalCredential *oadpv1alpha1.ExternalCredential
+		config             map[string]string
+		wantErrorText      string
+	}{
+		{
+			name:       "credential set",
+			credential: credential,
+		},
+		{
+			name:          "no credential",
+			wantErrorText: "spec.backupLocations[0].mountCredential requires velero.credential name and key",
+		},
+		{
+			name:          "credential without key",
+			credential:    &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "team-a"}},
+			wantErrorText: "requires velero.credential name and key",
+		},
+		{
+			name:               "set together with an external credential",
+			credential:         credential,
+			externalCredential: &oadpv1alpha1.ExternalCredential{SecretProviderClass: "vault-aws", Key: "cloud"},
+			wantErrorText:      "cannot be set together with externalCredential",
+		},
+		{
+			name:          "set together with credentialsFile",
+			credential:    credential,
+			config:        map[string]string{CredentialsFileKey: "team-a/cloud"},
+			wantErrorText: "cannot be set together with the credentialsFile config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMountCredential("spec.backupLocations[0]", tt.credential, tt.externalCredential, tt.config)
+			if tt.wantErrorText != "" {
+				require.ErrorContains(t, err, tt.wantErrorText)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestDPAReconciler_LocationCredentials(t *testing.T) {
+	awsCredentials := []byte("[default]\naws_access_key_id=id\naws_secret_access_key=key\n")
+	newSecret := func(name string, labels map[string]string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns", Labels: labels},
+			Data:       map[string][]byte{"cloud": awsCredentials},
+		}
+	}
+	newBSL := func(name string, credential *corev1.SecretKeySelector, isDefault bool) *velerov1.BackupStorageLocationSpec {
+		return &velerov1.BackupStorageLocationSpec{
+			Provider:   AWSProvider,
+			Default:    isDefault,
+			Config:     map[string]string{Region: "us-east-1"},
+			Credential: credential,
+			StorageType: velerov1.StorageType{
+				ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: name, Prefix: "velero"},
+			},
+		}
+	}
+	selector := func(name string) *corev1.SecretKeySelector {
+		return &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: "cloud"}
+	}
+
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns"},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{DefaultPlugins: []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginAWS}},
+			},
+			BackupImages: ptr.To(false),
+			BackupLocations: []oadpv1alpha1.BackupLocation{
+				{Name: "default", Velero: newBSL("default", nil, true)},
+				{Name: "team-a", Velero: newBSL("team-a", selector("team-a"), false), MountCredential: true},
+				{Name: "team-b", Velero: newBSL("team-b", selector("team-b"), false)},
+				{Name: "team-sts", Velero: newBSL("team-sts", selector("team-sts"), false)},
+			},
+			SnapshotLocations: []oadpv1alpha1.SnapshotLocation{{
+				Velero: &velerov1.VolumeSnapshotLocationSpec{
+					Provider:   AWSProvider,
+					Config:     map[string]string{Region: "us-east-1"},
+					Credential: selector("team-a"),
+				},
+				MountCredential: true,
+			}},
+		},
+	}
+	fakeClient := getFakeClientFromObjectsForTest(t, dpa,
+		newSecret("cloud-credentials", nil),
+		newSecret("team-a", nil),
+		newSecret("team-b", nil),
+		newSecret("team-sts", map[string]string{stsflow.STSSecretLabelKey: stsflow.STSSecretLabelValue}),
+	)
+	r := &DataProtectionApplicationReconciler{
+		Client:         fakeClient,
+		Scheme:         fakeClient.Scheme(),
+		Log:            logr.Discard(),
+		Context:        newContextForTest(),
+		NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+		EventRecorder:  newEventRecorder(),
+		dpa:            dpa,
+	}
+
+	ok, err := r.ValidateBackupStorageLocations()
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = r.ValidateVolumeSnapshotLocations()
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = r.ReconcileBackupStorageLocations(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = r.ReconcileVolumeSnapshotLocations(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	bsl := &velerov1.BackupStorageLocation{}
+	require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Namespace: "test-ns", Name: "team-a"}, bsl))
+	require.Nil(t, bsl.Spec.Credential)
+	require.Equal(t, "/credentials-location/team-a/cloud", bsl.Spec.Config[CredentialsFileKey])
+
+	require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Namespace: "test-ns", Name: "team-b"}, bsl))
+	require.Equal(t, selector("team-b"), bsl.Spec.Credential)
+	require.NotContains(t, bsl.Spec.Config, CredentialsFileKey)
+
+	vsl := &velerov1.VolumeSnapshotLocation{}
+	require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Namespace: "test-ns", Name: "test-dpa-1"}, vsl))
+	require.Nil(t, vsl.Spec.Credential)
+	require.Equal(t, "/credentials-location/team-a/cloud", vsl.Spec.Config[CredentialsFileKey])
+	require.NotContains(t, dpa.Spec.BackupLocations[1].Velero.Config, CredentialsFileKey, "the DPA spec must not be modified")
+
+	ok, err = r.ReconcileLocationCredentialStatus(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []oadpv1alpha1.LocationCredentialStatus{
+		{Kind: "BackupStorageLocation", Name: "default", Provider: "aws", Source: oadpv1alpha1.CredentialSourceDefaultFile, Secret: "cloud-credentials/cloud", File: "/credentials/cloud"},
+		{Kind: "BackupStorageLocation", Name: "team-a", Provider: "aws", Source: oadpv1alpha1.CredentialSourceMountedFile, Secret: "team-a/cloud", File: "/credentials-location/team-a/cloud"},
+		{Kind: "BackupStorageLocation", Name: "team-b", Provider: "aws", Source: oadpv1alpha1.CredentialSourceLocationCredential, Secret: "team-b/cloud"},
+		{Kind: "BackupStorageLocation", Name: "team-sts", Provider: "aws", Source: oadpv1alpha1.CredentialSourceSTS, Secret: "team-sts/cloud"},
+		{Kind: "VolumeSnapshotLocation", Name: "test-dpa-1", Provider: "aws", Source: oadpv1alpha1.CredentialSourceMountedFile, Secret: "team-a/cloud", File: "/credentials-location/team-a/cloud"},
+	}, dpa.Status.LocationCredentials)
+}
+
+func TestDPAReconciler_LocationCredentialsEnvironment(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns"},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{
+					DefaultPlugins:          []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginAWS},
+					NoDefaultBackupLocation: true,
+				},
+			},
+			SnapshotLocations: []oadpv1alpha1.SnapshotLocation{{
+				Name:   "ebs",
+				Velero: &velerov1.VolumeSnapshotLocationSpec{Provider: AWSProvider},
+			}},
+		},
+	}
+	r := &DataProtectionApplicationReconciler{
+		Client:         getFakeClientFromObjectsForTest(t, dpa),
+		Log:            logr.Discard(),
+		Context:        newContextForTest(),
+		NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+		dpa:            dpa,
+	}
+
+	ok, err := r.ReconcileLocationCredentialStatus(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []oadpv1alpha1.LocationCredentialStatus{
+		{Kind: "VolumeSnapshotLocation", Name: "ebs", Provider: "aws", Source: oadpv1alpha1.CredentialSourceEnvironment},
+	}, dpa.Status.LocationCredentials)
+}
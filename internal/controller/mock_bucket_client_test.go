@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"fmt"
 
 	bucketpkg "github.com/openshift/oadp-operator/pkg/bucket"
@@ -31,17 +32,17 @@ type mockBucketClient struct {
 // Ensure mockBucketClient implements bucketpkg.Client
 var _ bucketpkg.Client = &mockBucketClient{}
 
-func (m *mockBucketClient) Exists() (bool, error) {
+func (m *mockBucketClient) Exists(_ context.Context) (bool, error) {
 	m.existsCalled++
 	return m.existsResult, m.existsError
 }
 
-func (m *mockBucketClient) Create() (bool, error) {
+func (m *mockBucketClient) Create(_ context.Context) (bool, error) {
 	m.createCalled++
 	return m.createResult, m.createError
 }
 
-func (m *mockBucketClient) Delete() (bool, error) {
+func (m *mockBucketClient) Delete(_ context.Context) (bool, error) {
 	m.deleteCalled++
 	return m.deleteResult, m.deleteError
 }
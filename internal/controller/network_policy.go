@@ -0,0 +1,467 @@
+package controller
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+const (
+	networkPolicyPrefix = "oadp-"
+	// defaultMonitoringNamespace is the namespace of the cluster monitoring Prometheus scraping the metrics
+	defaultMonitoringNamespace = "openshift-monitoring"
+	// clusterDNSNamespace is the namespace of the cluster DNS pods, listening on port 5353 behind the port 53 service
+	clusterDNSNamespace = "openshift-dns"
+	// kubeDNSNamespace is the namespace of the kube-dns pods of clusters without the OpenShift DNS operator
+	kubeDNSNamespace = "kube-system"
+	// providerEndpointPort is the HTTPS port of the cloud provider endpoints
+	providerEndpointPort = 443
+	// defaultKubeAPIServerPort is the kube-apiserver port used when its endpoints can not be read
+	defaultKubeAPIServerPort = 6443
+	// metricsPortName is the name of the container ports scraped by the monitoring namespace
+	metricsPortName = "metrics"
+)
+
+// kubeDNSPodLabels selects the kube-dns pods in kubeDNSNamespace
+var kubeDNSPodLabels = map[string]string{"k8s-app": "kube-dns"}
+
+// networkPolicyComponent is an OADP workload the operator reconciles a NetworkPolicy for
+type networkPolicyComponent struct {
+	name    string
+	enabled bool
+	// pods selects the pods of the component
+	pods map[string]string
+	// workload is the Deployment or DaemonSet whose pod template defines the ports and proxies of the component
+	workload client.Object
+	// locations is whether the component reaches the backup and snapshot locations
+	locations bool
+}
+
+// networkPolicyComponents returns the components the operator reconciles NetworkPolicies for
+func (r *DataProtectionApplicationReconciler) networkPolicyComponents() []networkPolicyComponent {
+	namespace := r.NamespacedName.Namespace
+	return []networkPolicyComponent{
+		{
+			name:      common.Velero,
+			enabled:   true,
+			pods:      veleroLabelSelector.MatchLabels,
+			workload:  &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: common.Velero, Namespace: namespace}},
+			locations: true,
+		},
+		{
+			name:      common.NodeAgent,
+			enabled:   isNodeAgentEnabled(r.dpa),
+			pods:      nodeAgentMatchLabels,
+			workload:  &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: common.NodeAgent, Namespace: namespace}},
+			locations: true,
+		},
+		{
+			name:     nonAdminObjectName,
+			enabled:  r.checkNonAdminEnabled(),
+			pods:     controlPlaneLabel,
+			workload: &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: nonAdminObjectName, Namespace: namespace}},
+		},
+	}
+}
+
+// networkPoliciesEnabled returns whether spec.networkPolicy is enabled
+func networkPoliciesEnabled(dpa *oadpv1alpha1.DataProtectionApplication) bool {
+	return dpa.Spec.NetworkPolicy != nil && dpa.Spec.NetworkPolicy.Enable
+}
+
+// networkPolicyEndpoints are the egress destinations of a NetworkPolicy, TCP ports by CIDR. The empty CIDR is any
+// destination, for hostnames NetworkPolicies can not select.
+type networkPolicyEndpoints map[string]map[int32]bool
+
+func (e networkPolicyEndpoints) add(cidr string, port int32) {
+	if e[cidr] == nil {
+		e[cidr] = map[int32]bool{}
+	}
+	e[cidr][port] = true
+}
+
+// addHost adds the host and port, selecting the host by CIDR when it is an IP
+func (e networkPolicyEndpoints) addHost(host string, port int32) {
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		e.add("", port)
+	case ip.To4() != nil:
+		e.add(ip.String()+"/32", port)
+	default:
+		e.add(ip.String()+"/128", port)
+	}
+}
+
+// addURL adds the host and port of an URL, the port defaulting to the one of the scheme. URLs without scheme are
+// http, as in proxy environment variables.
+func (e networkPolicyEndpoints) addURL(rawURL string) error {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if parsed.Hostname() == "" {
+		return fmt.Errorf("no host in URL %q", rawURL)
+	}
+	port := int64(443)
+	if parsed.Scheme == "http" {
+		port = 80
+	}
+	if parsed.Port() != "" {
+		if port, err = strconv.ParseInt(parsed.Port(), 10, 32); err != nil {
+			return err
+		}
+	}
+	e.addHost(parsed.Hostname(), int32(port))
+	return nil
+}
+
+// egressRules returns one rule per CIDR, sorted, the rule without destination first
+func (e networkPolicyEndpoints) egressRules() []networkingv1.NetworkPolicyEgressRule {
+	cidrs := make([]string, 0, len(e))
+	for cidr := range e {
+		cidrs = append(cidrs, cidr)
+	}
+	slices.Sort(cidrs)
+	rules := make([]networkingv1.NetworkPolicyEgressRule, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		rule := networkingv1.NetworkPolicyEgressRule{Ports: tcpNetworkPolicyPorts(e[cidr])}
+		if cidr != "" {
+			rule.To = []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: cidr}}}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// tcpNetworkPolicyPorts returns the sorted TCP NetworkPolicy ports of the port numbers
+func tcpNetworkPolicyPorts(ports map[int32]bool) []networkingv1.NetworkPolicyPort {
+	numbers := make([]int32, 0, len(ports))
+	for port := range ports {
+		numbers = append(numbers, port)
+	}
+	slices.Sort(numbers)
+	policyPorts := make([]networkingv1.NetworkPolicyPort, 0, len(numbers))
+	for _, number := range numbers {
+		policyPorts = append(policyPorts, networkingv1.NetworkPolicyPort{
+			Protocol: ptr.To(corev1.ProtocolTCP),
+			Port:     ptr.To(intstr.FromInt32(number)),
+		})
+	}
+	return policyPorts
+}
+
+// clusterDNSEgressRule allows DNS queries to the cluster DNS pods
+func clusterDNSEgressRule(dns networkingv1.NetworkPolicyPeer) networkingv1.NetworkPolicyEgressRule {
+	rule := networkingv1.NetworkPolicyEgressRule{To: []networkingv1.NetworkPolicyPeer{dns}}
+	for _, port := range []int32{53, 5353} {
+		for _, protocol := range []corev1.Protocol{corev1.ProtocolUDP, corev1.ProtocolTCP} {
+			rule.Ports = append(rule.Ports, networkingv1.NetworkPolicyPort{Protocol: ptr.To(protocol), Port: ptr.To(intstr.FromInt32(port))})
+		}
+	}
+	return rule
+}
+
+// namespaceNetworkPolicyPeer selects the pods of the namespace matching the labels, all of them when labels is nil
+func namespaceNetworkPolicyPeer(namespace string, labels map[string]string) networkingv1.NetworkPolicyPeer {
+	peer := networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{corev1.LabelMetadataName: namespace}},
+	}
+	if labels != nil {
+		peer.PodSelector = &metav1.LabelSelector{MatchLabels: labels}
+	}
+	return peer
+}
+
+// clusterDNSPeer returns the cluster DNS pods: the pods of spec.networkPolicy.dnsNamespace, else of openshift-dns,
+// else the kube-dns pods of kube-system when openshift-dns does not exist
+func (r *DataProtectionApplicationReconciler) clusterDNSPeer() (networkingv1.NetworkPolicyPeer, error) {
+	if namespace := r.dpa.Spec.NetworkPolicy.DNSNamespace; namespace != "" {
+		return namespaceNetworkPolicyPeer(namespace, nil), nil
+	}
+	clusterClient := r.ClusterWideClient
+	if clusterClient == nil {
+		clusterClient = r.Client
+	}
+	if err := clusterClient.Get(r.Context, types.NamespacedName{Name: clusterDNSNamespace}, &corev1.Namespace{}); err != nil {
+		if k8serror.IsNotFound(err) {
+			return namespaceNetworkPolicyPeer(kubeDNSNamespace, kubeDNSPodLabels), nil
+		}
+		return networkingv1.NetworkPolicyPeer{}, err
+	}
+	return namespaceNetworkPolicyPeer(clusterDNSNamespace, nil), nil
+}
+
+// addKubeAPIServerEndpoints adds the kube-apiserver endpoints. Pods reach it through the kubernetes Service, whose
+// address most network plugins translate to the endpoints before applying NetworkPolicies.
+func (r *DataProtectionApplicationReconciler) addKubeAPIServerEndpoints(log logr.Logger, endpoints networkPolicyEndpoints) {
+	clusterClient := r.ClusterWideClient
+	if clusterClient == nil {
+		clusterClient = r.Client
+	}
+	apiServer := &corev1.Endpoints{}
+	if err := clusterClient.Get(r.Context, types.NamespacedName{Namespace: metav1.NamespaceDefault, Name: "kubernetes"}, apiServer); err != nil {
+		log.Info("kube-apiserver endpoints not readable, allowing egress to its default port", "error", err.Error())
+		endpoints.add("", defaultKubeAPIServerPort)
+		return
+	}
+	found := false
+	for _, subset := range apiServer.Subsets {
+		for _, address := range subset.Addresses {
+			for _, port := range subset.Ports {
+				endpoints.addHost(address.IP, port.Port)
+				found = true
+			}
+		}
+	}
+	if !found {
+		endpoints.add("", defaultKubeAPIServerPort)
+	}
+}
+
+// addLocationEndpoints adds the endpoints of the backup and snapshot locations of the namespace: the s3Url of the
+// location, else the HTTPS endpoints of the provider
+func (r *DataProtectionApplicationReconciler) addLocationEndpoints(log logr.Logger, endpoints networkPolicyEndpoints) error {
+	bsls := &velerov1.BackupStorageLocationList{}
+	if err := r.List(r.Context, bsls, client.InNamespace(r.NamespacedName.Namespace)); err != nil {
+		return err
+	}
+	vsls := &velerov1.VolumeSnapshotLocationList{}
+	if err := r.List(r.Context, vsls, client.InNamespace(r.NamespacedName.Namespace)); err != nil {
+		return err
+	}
+	providers := len(vsls.Items) > 0
+	for _, bsl := range bsls.Items {
+		s3URL := bsl.Spec.Config[S3URL]
+		if s3URL == "" {
+			providers = true
+			continue
+		}
+		if err := endpoints.addURL(s3URL); err != nil {
+			log.Info("ignoring invalid s3Url of backup storage location", "location", bsl.Name, "error", err.Error())
+			providers = true
+		}
+	}
+	if providers {
+		r.addProviderEndpoints(log, endpoints)
+	}
+	return nil
+}
+
+// addProviderEndpoints adds the HTTPS endpoints of the cloud providers: the spec.networkPolicy.providerCIDRs, else the
+// HTTPS proxy of the cluster, else any destination on port 443 since the provider hostnames can not be selected
+func (r *DataProtectionApplicationReconciler) addProviderEndpoints(log logr.Logger, endpoints networkPolicyEndpoints) {
+	if cidrs := r.dpa.Spec.NetworkPolicy.ProviderCIDRs; len(cidrs) > 0 {
+		for _, cidr := range cidrs {
+			if _, network, err := net.ParseCIDR(cidr); err == nil {
+				endpoints.add(network.String(), providerEndpointPort)
+			}
+		}
+		return
+	}
+	if r.clusterProxy != nil && r.clusterProxy.httpsProxy != "" {
+		err := endpoints.addURL(r.clusterProxy.httpsProxy)
+		if err == nil {
+			return
+		}
+		log.Info("ignoring invalid HTTPS proxy of the cluster", "error", err.Error())
+	}
+	log.V(1).Info("no providerCIDRs nor cluster proxy, allowing egress to any destination on the provider endpoint port")
+	endpoints.add("", providerEndpointPort)
+}
+
+// validateNetworkPolicyConfig validates the CIDRs of spec.networkPolicy.providerCIDRs
+func validateNetworkPolicyConfig(config *oadpv1alpha1.NetworkPolicyConfig) error {
+	if config == nil {
+		return nil
+	}
+	for _, cidr := range config.ProviderCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("spec.networkPolicy.providerCIDRs %q is not a valid CIDR: %w", cidr, err)
+		}
+	}
+	return nil
+}
+
+// podTemplateProxies returns the proxy URLs of the containers of the pod template
+func podTemplateProxies(template *corev1.PodTemplateSpec) []string {
+	var proxies []string
+	for _, container := range template.Spec.Containers {
+		for _, env := range container.Env {
+			switch env.Name {
+			case common.HTTPProxyEnvVar, common.HTTPSProxyEnvVar, strings.ToLower(common.HTTPProxyEnvVar), strings.ToLower(common.HTTPSProxyEnvVar):
+				if env.Value != "" && !slices.Contains(proxies, env.Value) {
+					proxies = append(proxies, env.Value)
+				}
+			}
+		}
+	}
+	return proxies
+}
+
+// metricsIngressRules allows the monitoring namespace to scrape the metrics ports of the pod template
+func metricsIngressRules(template *corev1.PodTemplateSpec, monitoringNamespace string) []networkingv1.NetworkPolicyIngressRule {
+	ports := map[int32]bool{}
+	for _, container := range template.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Name == metricsPortName {
+				ports[port.ContainerPort] = true
+			}
+		}
+	}
+	if len(ports) == 0 {
+		return nil
+	}
+	return []networkingv1.NetworkPolicyIngressRule{{
+		From: []networkingv1.NetworkPolicyPeer{{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{corev1.LabelMetadataName: monitoringNamespace}},
+		}},
+		Ports: tcpNetworkPolicyPorts(ports),
+	}}
+}
+
+// workloadPodTemplate returns the pod template of the Deployment or DaemonSet, empty when it does not exist yet
+func (r *DataProtectionApplicationReconciler) workloadPodTemplate(workload client.Object) (*corev1.PodTemplateSpec, error) {
+	if err := r.Get(r.Context, client.ObjectKeyFromObject(workload), workload); err != nil {
+		if k8serror.IsNotFound(err) {
+			return &corev1.PodTemplateSpec{}, nil
+		}
+		return nil, err
+	}
+	switch typed := workload.(type) {
+	case *appsv1.Deployment:
+		return &typed.Spec.Template, nil
+	case *appsv1.DaemonSet:
+		return &typed.Spec.Template, nil
+	}
+	return &corev1.PodTemplateSpec{}, nil
+}
+
+// ReconcileNetworkPolicies reconciles the NetworkPolicies of the Velero, NodeAgent and non admin controller pods when
+// spec.networkPolicy is enabled, and deletes them otherwise. The ingress ports and proxies are read from the
+// reconciled workloads, so the policies follow their changes.
+func (r *DataProtectionApplicationReconciler) ReconcileNetworkPolicies(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	enabled := networkPoliciesEnabled(dpa)
+	monitoringNamespace := defaultMonitoringNamespace
+	if enabled && dpa.Spec.NetworkPolicy.MonitoringNamespace != "" {
+		monitoringNamespace = dpa.Spec.NetworkPolicy.MonitoringNamespace
+	}
+
+	var (
+		locations networkPolicyEndpoints
+		dns       *networkingv1.NetworkPolicyPeer
+	)
+	for _, component := range r.networkPolicyComponents() {
+		policy := &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      networkPolicyPrefix + component.name,
+				Namespace: r.NamespacedName.Namespace,
+			},
+		}
+		if !enabled || !component.enabled {
+			if err := r.deleteNetworkPolicy(policy); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		template, err := r.workloadPodTemplate(component.workload)
+		if err != nil {
+			return false, err
+		}
+		if dns == nil {
+			peer, err := r.clusterDNSPeer()
+			if err != nil {
+				return false, err
+			}
+			dns = &peer
+		}
+		endpoints := networkPolicyEndpoints{}
+		r.addKubeAPIServerEndpoints(log, endpoints)
+		if component.locations {
+			if locations == nil {
+				locations = networkPolicyEndpoints{}
+				if err := r.addLocationEndpoints(log, locations); err != nil {
+					return false, err
+				}
+			}
+			for cidr, ports := range locations {
+				for port := range ports {
+					endpoints.add(cidr, port)
+				}
+			}
+		}
+		for _, proxy := range podTemplateProxies(template) {
+			if err := endpoints.addURL(proxy); err != nil {
+				log.Info("ignoring invalid proxy URL", "component", component.name, "error", err.Error())
+			}
+		}
+
+		op, err := controllerutil.CreateOrPatch(r.Context, r.Client, policy, func() error {
+			if err := controllerutil.SetControllerReference(dpa, policy, r.Scheme); err != nil {
+				return err
+			}
+			policy.Labels = getDpaAppLabels(dpa)
+			policy.Spec = networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: component.pods},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+				Ingress:     metricsIngressRules(template, monitoringNamespace),
+				Egress:      append([]networkingv1.NetworkPolicyEgressRule{clusterDNSEgressRule(*dns)}, endpoints.egressRules()...),
+			}
+			return nil
+		})
+		if err != nil {
+			return false, err
+		}
+		if op == controllerutil.OperationResultCreated || op == controllerutil.OperationResultUpdated {
+			r.EventRecorder.Event(policy,
+				corev1.EventTypeNormal,
+				"NetworkPolicyReconciled",
+				fmt.Sprintf("performed %s on NetworkPolicy %s/%s", op, policy.Namespace, policy.Name),
+			)
+		}
+	}
+	return true, nil
+}
+
+// deleteNetworkPolicy deletes the NetworkPolicy when the DPA owns it
+func (r *DataProtectionApplicationReconciler) deleteNetworkPolicy(policy *networkingv1.NetworkPolicy) error {
+	if err := r.Get(r.Context, client.ObjectKeyFromObject(policy), policy); err != nil {
+		if k8serror.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !metav1.IsControlledBy(policy, r.dpa) {
+		return nil
+	}
+	if err := r.Delete(r.Context, policy); err != nil && !k8serror.IsNotFound(err) {
+		return err
+	}
+	r.EventRecorder.Event(policy, corev1.EventTypeNormal, "DeletedNetworkPolicy",
+		fmt.Sprintf("NetworkPolicy %s/%s deleted", policy.Namespace, policy.Name))
+	return nil
+}
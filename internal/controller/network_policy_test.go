@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func tcpEgressRule(cidr string, ports ...int32) networkingv1.NetworkPolicyEgressRule {
+	portSet := map[int32]bool{}
+	for _, port := range ports {
+		portSet[port] = true
+	}
+	rule := networkingv1.NetworkPolicyEgressRule{Ports: tcpNetworkPolicyPorts(portSet)}
+	if cidr != "" {
+		rule.To = []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: cidr}}}
+	}
+	return rule
+}
+
+func TestNetworkPolicyEndpoints(t *testing.T) {
+	endpoints := networkPolicyEndpoints{}
+	require.NoError(t, endpoints.addURL("https://s3.us-east-1.amazonaws.com"))
+	require.NoError(t, endpoints.addURL("http://10.0.0.5:9000"))
+	require.NoError(t, endpoints.addURL("proxy.corp.example.com:3128"))
+	require.NoError(t, endpoints.addURL("http://[fd00::1]:8080"))
+	require.NoError(t, endpoints.addURL("http://minio.minio.svc"))
+	require.Error(t, endpoints.addURL("http://:8080"))
+	require.Error(t, endpoints.addURL("http://minio:port"))
+
+	require.Equal(t, []networkingv1.NetworkPolicyEgressRule{
+		tcpEgressRule("", 80, 443, 3128),
+		tcpEgressRule("10.0.0.5/32", 9000),
+		tcpEgressRule("fd00::1/128", 8080),
+	}, endpoints.egressRules())
+}
+
+func TestDPAReconciler_ReconcileNetworkPolicies(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns", UID: "test-dpa-uid"},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{},
+				NodeAgent: &oadpv1alpha1.NodeAgentConfig{
+					NodeAgentCommonFields: oadpv1alpha1.NodeAgentCommonFields{Enable: ptr.To(true)},
+				},
+			},
+			NetworkPolicy: &oadpv1alpha1.NetworkPolicyConfig{Enable: true},
+		},
+	}
+	veleroDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: common.Velero, Namespace: "test-ns"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:  common.Velero,
+			Ports: []corev1.ContainerPort{{Name: metricsPortName, ContainerPort: 8085}},
+			Env:   []corev1.EnvVar{{Name: common.HTTPSProxyEnvVar, Value: "http://10.1.1.1:3128"}},
+		}}}}},
+	}
+	apiServer := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubernetes", Namespace: metav1.NamespaceDefault},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: "192.168.0.10"}, {IP: "192.168.0.11"}},
+			Ports:     []corev1.EndpointPort{{Name: "https", Port: 6443}},
+		}},
+	}
+	bsl := &velerov1.BackupStorageLocation{
+		ObjectMeta: metav1.ObjectMeta{Name: "minio", Namespace: "test-ns"},
+		Spec: velerov1.BackupStorageLocationSpec{
+			Provider: "aws",
+			Config:   map[string]string{S3URL: "http://10.0.0.5:9000"},
+		},
+	}
+	dnsNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: clusterDNSNamespace}}
+	fakeClient := getFakeClientFromObjectsForTest(t, dpa, veleroDeployment, apiServer, bsl, dnsNamespace)
+	r := &DataProtectionApplicationReconciler{
+		Client:         fakeClient,
+		Scheme:         fakeClient.Scheme(),
+		dpa:            dpa,
+		Log:            logr.Discard(),
+		Context:        newContextForTest(),
+		NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+		EventRecorder:  newEventRecorder(),
+	}
+	getPolicy := func(component string) (*networkingv1.NetworkPolicy, error) {
+		policy := &networkingv1.NetworkPolicy{}
+		err := fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: networkPolicyPrefix + component}, policy)
+		return policy, err
+	}
+
+	ok, err := r.ReconcileNetworkPolicies(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	policy, err := getPolicy(common.Velero)
+	require.NoError(t, err)
+	require.True(t, metav1.IsControlledBy(policy, dpa))
+	require.Equal(t, veleroLabelSelector.MatchLabels, policy.Spec.PodSelector.MatchLabels)
+	require.Equal(t, []networkingv1.NetworkPolicyIngressRule{{
+		From: []networkingv1.NetworkPolicyPeer{{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{corev1.LabelMetadataName: defaultMonitoringNamespace}},
+		}},
+		Ports: []networkingv1.NetworkPolicyPort{{Protocol: ptr.To(corev1.ProtocolTCP), Port: ptr.To(intstr.FromInt32(8085))}},
+	}}, policy.Spec.Ingress)
+	require.Equal(t, []networkingv1.NetworkPolicyEgressRule{
+		clusterDNSEgressRule(namespaceNetworkPolicyPeer(clusterDNSNamespace, nil)),
+		tcpEgressRule("10.0.0.5/32", 9000),
+		tcpEgressRule("10.1.1.1/32", 3128),
+		tcpEgressRule("192.168.0.10/32", 6443),
+		tcpEgressRule("192.168.0.11/32", 6443),
+	}, policy.Spec.Egress)
+
+	policy, err = getPolicy(common.NodeAgent)
+	require.NoError(t, err)
+	require.Equal(t, nodeAgentMatchLabels, policy.Spec.PodSelector.MatchLabels)
+	require.Nil(t, policy.Spec.Ingress, "no metrics port until the daemonset exists")
+	require.Len(t, policy.Spec.Egress, 4, "no proxy")
+	_, err = getPolicy(nonAdminObjectName)
+	require.True(t, k8serror.IsNotFound(err), "non admin is disabled")
+
+	// the policy follows the metrics port of the deployment
+	veleroDeployment.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort = 9090
+	require.NoError(t, fakeClient.Update(r.Context, veleroDeployment))
+	dpa.Spec.NetworkPolicy.MonitoringNamespace = "user-monitoring"
+	ok, err = r.ReconcileNetworkPolicies(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	policy, err = getPolicy(common.Velero)
+	require.NoError(t, err)
+	require.Equal(t, ptr.To(intstr.FromInt32(9090)), policy.Spec.Ingress[0].Ports[0].Port)
+	require.Equal(t, "user-monitoring", policy.Spec.Ingress[0].From[0].NamespaceSelector.MatchLabels[corev1.LabelMetadataName])
+
+	dpa.Spec.Configuration.NodeAgent.Enable = ptr.To(false)
+	ok, err = r.ReconcileNetworkPolicies(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, err = getPolicy(common.NodeAgent)
+	require.True(t, k8serror.IsNotFound(err))
+
+	dpa.Spec.NetworkPolicy.Enable = false
+	ok, err = r.ReconcileNetworkPolicies(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, err = getPolicy(common.Velero)
+	require.True(t, k8serror.IsNotFound(err))
+}
+
+func TestDPAReconciler_ReconcileNetworkPolicies_ProviderEndpoints(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       oadpv1alpha1.NetworkPolicyConfig
+		clusterProxy *clusterProxyConfig
+		objects      []client.Object
+		wantDNS      networkingv1.NetworkPolicyPeer
+		wantEgress   []networkingv1.NetworkPolicyEgressRule
+	}{
+		{
+			name:       "any destination on 443 without provider CIDRs nor cluster proxy",
+			objects:    []client.Object{&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: clusterDNSNamespace}}},
+			wantDNS:    namespaceNetworkPolicyPeer(clusterDNSNamespace, nil),
+			wantEgress: []networkingv1.NetworkPolicyEgressRule{tcpEgressRule("", 443, 6443)},
+		},
+		{
+			name:    "provider CIDRs",
+			config:  oadpv1alpha1.NetworkPolicyConfig{ProviderCIDRs: []string{"52.216.0.0/15", "3.5.0.1/16"}},
+			wantDNS: namespaceNetworkPolicyPeer(kubeDNSNamespace, kubeDNSPodLabels),
+			wantEgress: []networkingv1.NetworkPolicyEgressRule{
+				tcpEgressRule("", 6443),
+				tcpEgressRule("3.5.0.0/16", 443),
+				tcpEgressRule("52.216.0.0/15", 443),
+			},
+		},
+		{
+			name:         "cluster proxy",
+			config:       oadpv1alpha1.NetworkPolicyConfig{DNSNamespace: "dns"},
+			clusterProxy: &clusterProxyConfig{httpProxy: "http://10.1.1.1:3128", httpsProxy: "http://10.1.1.1:3128"},
+			wantDNS:      namespaceNetworkPolicyPeer("dns", nil),
+			wantEgress: []networkingv1.NetworkPolicyEgressRule{
+				tcpEgressRule("", 6443),
+				tcpEgressRule("10.1.1.1/32", 3128),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.Enable = true
+			dpa := &oadpv1alpha1.DataProtectionApplication{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns", UID: "test-dpa-uid"},
+				Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+					Configuration: &oadpv1alpha1.ApplicationConfig{Velero: &oadpv1alpha1.VeleroConfig{}},
+					NetworkPolicy: &config,
+				},
+			}
+			bsl := &velerov1.BackupStorageLocation{
+				ObjectMeta: metav1.ObjectMeta{Name: "aws", Namespace: "test-ns"},
+				Spec:       velerov1.BackupStorageLocationSpec{Provider: "aws"},
+			}
+			fakeClient := getFakeClientFromObjectsForTest(t, append(tt.objects, dpa, bsl)...)
+			r := &DataProtectionApplicationReconciler{
+				Client:         fakeClient,
+				Scheme:         fakeClient.Scheme(),
+				dpa:            dpa,
+				Log:            logr.Discard(),
+				Context:        newContextForTest(),
+				NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+				EventRecorder:  newEventRecorder(),
+				clusterProxy:   tt.clusterProxy,
+			}
+
+			ok, err := r.ReconcileNetworkPolicies(r.Log)
+			require.NoError(t, err)
+			require.True(t, ok)
+			policy := &networkingv1.NetworkPolicy{}
+			require.NoError(t, fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: networkPolicyPrefix + common.Velero}, policy))
+			require.Equal(t, append([]networkingv1.NetworkPolicyEgressRule{clusterDNSEgressRule(tt.wantDNS)}, tt.wantEgress...), policy.Spec.Egress)
+		})
+	}
+}
+
+func TestValidateNetworkPolicyConfig(t *testing.T) {
+	require.NoError(t, validateNetworkPolicyConfig(nil))
+	require.NoError(t, validateNetworkPolicyConfig(&oadpv1alpha1.NetworkPolicyConfig{ProviderCIDRs: []string{"10.0.0.0/8", "fd00::/8"}}))
+	require.ErrorContains(t, validateNetworkPolicyConfig(&oadpv1alpha1.NetworkPolicyConfig{ProviderCIDRs: []string{"10.0.0.1"}}), "not a valid CIDR")
+}
@@ -2,6 +2,8 @@ package controller
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -11,7 +13,6 @@ import (
 	configv1 "github.com/openshift/api/config/v1"
 	"github.com/operator-framework/operator-lib/proxy"
 	"github.com/vmware-tanzu/velero/pkg/install"
-	"github.com/vmware-tanzu/velero/pkg/util/kube"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -41,6 +42,10 @@ const (
 	FSPVHostPathEnvVar      = "FS_PV_HOSTPATH"
 	PluginsHostPathEnvVar   = "PLUGINS_HOSTPATH"
 	NodeAgentCMVersionLabel = "openshift.io/node-agent-cm-version"
+	// NodeAgentConfigHashAnnotation is set on the NodeAgent pod template to the hash of the node-agent ConfigMap
+	// content. The node-agent reads the ConfigMap only at startup, so a change, such as a maintenance window starting
+	// or ending, rolls out the pods.
+	NodeAgentConfigHashAnnotation = "oadp.openshift.io/node-agent-config-hash"
 )
 
 var (
@@ -132,18 +137,9 @@ func (r *DataProtectionApplicationReconciler) updateNodeAgentCM(cm *corev1.Confi
 		return fmt.Errorf("failed to set controller reference: %w", err)
 	}
 
-	// determine PrivilegedFsBackup from DisableFsBackup setting
-	privilegedFsBackup := r.dpa.Spec.Configuration.Velero.DisableFsBackup == nil ||
-		!*r.dpa.Spec.Configuration.Velero.DisableFsBackup
-
-	configWithPrivileged := nodeAgentConfigMapWithPrivileged{
-		NodeAgentConfigMapSettings: r.dpa.Spec.Configuration.NodeAgent.NodeAgentConfigMapSettings,
-		PrivilegedFsBackup:         privilegedFsBackup,
-	}
-	// Convert NodeAgentConfigMapSettings to a generic map
-	configNodeAgentJSON, err := json.Marshal(configWithPrivileged)
+	configNodeAgentJSON, err := r.nodeAgentConfig()
 	if err != nil {
-		return fmt.Errorf("failed to serialize node agent config: %w", err)
+		return err
 	}
 
 	cm.Name = common.NodeAgentConfigMapPrefix + r.dpa.Name
@@ -163,6 +159,41 @@ func (r *DataProtectionApplicationReconciler) updateNodeAgentCM(cm *corev1.Confi
 	return nil
 }
 
+// nodeAgentConfig returns the node-agent-config content of the NodeAgent ConfigMap
+func (r *DataProtectionApplicationReconciler) nodeAgentConfig() ([]byte, error) {
+	// determine PrivilegedFsBackup from DisableFsBackup setting
+	privilegedFsBackup := r.dpa.Spec.Configuration.Velero.DisableFsBackup == nil ||
+		!*r.dpa.Spec.Configuration.Velero.DisableFsBackup
+
+	// the loadConcurrency and loadAffinity of an active maintenance window replace the DPA settings
+	settings, _ := r.nodeAgentConfigMapSettings(r.now())
+	configWithPrivileged := nodeAgentConfigMapWithPrivileged{
+		NodeAgentConfigMapSettings: settings,
+		PrivilegedFsBackup:         privilegedFsBackup,
+	}
+	// Convert NodeAgentConfigMapSettings to a generic map
+	configNodeAgentJSON, err := json.Marshal(configWithPrivileged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize node agent config: %w", err)
+	}
+	return configNodeAgentJSON, nil
+}
+
+// nodeAgentConfigHash returns the hash of the NodeAgent ConfigMap content, empty when the ConfigMap is not required.
+// The hash is computed from the DPA rather than read from the ConfigMap, which the cache may not have updated yet.
+func (r *DataProtectionApplicationReconciler) nodeAgentConfigHash() (string, error) {
+	settings, _ := r.nodeAgentConfigMapSettings(r.now())
+	if !isNodeAgentCMRequired(settings, r.dpa.Spec.Configuration.Velero.DisableFsBackup) {
+		return "", nil
+	}
+	configNodeAgentJSON, err := r.nodeAgentConfig()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(configNodeAgentJSON)
+	return hex.EncodeToString(sum[:]), nil
+}
+
 // ReconcileNodeAgentConfigMap handles creation, update, and deletion of the NodeAgent ConfigMap.
 func (r *DataProtectionApplicationReconciler) ReconcileNodeAgentConfigMap(log logr.Logger) (bool, error) {
 	dpa := r.dpa
@@ -174,7 +205,14 @@ func (r *DataProtectionApplicationReconciler) ReconcileNodeAgentConfigMap(log lo
 		},
 	}
 
-	if !isNodeAgentEnabled(dpa) || !isNodeAgentCMRequired(dpa.Spec.Configuration.NodeAgent.NodeAgentConfigMapSettings, dpa.Spec.Configuration.Velero.DisableFsBackup) {
+	var settings oadpv1alpha1.NodeAgentConfigMapSettings
+	var window *oadpv1alpha1.NodeAgentMaintenanceWindowStatus
+	if isNodeAgentEnabled(dpa) {
+		settings, window = r.nodeAgentConfigMapSettings(r.now())
+	}
+	r.setNodeAgentMaintenanceWindowStatus(window)
+
+	if !isNodeAgentEnabled(dpa) || !isNodeAgentCMRequired(settings, dpa.Spec.Configuration.Velero.DisableFsBackup) {
 		err := r.Get(r.Context, cmName, &configMap)
 		if err != nil && !errors.IsNotFound(err) {
 			return false, err
@@ -267,17 +305,7 @@ func (r *DataProtectionApplicationReconciler) ReconcileNodeAgentDaemonset(log lo
 		if err := controllerutil.SetControllerReference(dpa, ds, r.Scheme); err != nil {
 			return err
 		}
-		if dpa.Spec.Configuration.NodeAgent.NodeAgentConfigMapSettings.LoadAffinityConfig != nil {
-			veleroAffinityStruct := make([]*kube.LoadAffinity, len(dpa.Spec.Configuration.NodeAgent.NodeAgentConfigMapSettings.LoadAffinityConfig))
-
-			for i, aff := range dpa.Spec.Configuration.NodeAgent.NodeAgentConfigMapSettings.LoadAffinityConfig {
-				veleroAffinityStruct[i] = &kube.LoadAffinity{
-					NodeSelector: aff.NodeSelector,
-				}
-			}
-			affinity := kube.ToSystemAffinity(veleroAffinityStruct)
-			ds.Spec.Template.Spec.Affinity = affinity
-		}
+		applyLoadAffinity(&ds.Spec.Template.Spec, dpa.Spec.Configuration.NodeAgent.NodeAgentConfigMapSettings.LoadAffinityConfig)
 		return nil
 	})
 
@@ -370,7 +398,25 @@ func (r *DataProtectionApplicationReconciler) buildNodeAgentDaemonset(ds *appsv1
 	ds.Spec = installDs.Spec
 	ds.Name = dsName
 
-	return r.customizeNodeAgentDaemonset(ds)
+	if ds, err = r.customizeNodeAgentDaemonset(ds); err != nil {
+		return nil, err
+	}
+	// roll out the NodeAgent pods when the node-agent ConfigMap they use changes, they read it only at startup
+	configHash := ""
+	if configMapName != "" {
+		if configHash, err = r.nodeAgentConfigHash(); err != nil {
+			return nil, err
+		}
+	}
+	if configHash == "" {
+		delete(ds.Spec.Template.Annotations, NodeAgentConfigHashAnnotation)
+	} else {
+		if ds.Spec.Template.Annotations == nil {
+			ds.Spec.Template.Annotations = map[string]string{}
+		}
+		ds.Spec.Template.Annotations[NodeAgentConfigHashAnnotation] = configHash
+	}
+	return ds, nil
 }
 
 func (r *DataProtectionApplicationReconciler) customizeNodeAgentDaemonset(ds *appsv1.DaemonSet) (*appsv1.DaemonSet, error) {
@@ -483,6 +529,7 @@ func (r *DataProtectionApplicationReconciler) customizeNodeAgentDaemonset(ds *ap
 	ds.Spec.Template.Spec.Volumes = updatedVolumes
 
 	// Update with any pod config values
+	applyPodSchedulingConfig(&ds.Spec.Template.Spec, dpa.Spec.Configuration.NodeAgent.PodConfig)
 	if dpa.Spec.Configuration.NodeAgent.PodConfig != nil {
 		ds.Spec.Template.Spec.Tolerations = dpa.Spec.Configuration.NodeAgent.PodConfig.Tolerations
 		if len(dpa.Spec.Configuration.NodeAgent.PodConfig.NodeSelector) != 0 {
@@ -571,12 +618,32 @@ func (r *DataProtectionApplicationReconciler) customizeNodeAgentDaemonset(ds *ap
 				nodeAgentContainer.Env = common.AppendUniqueEnvVars(nodeAgentContainer.Env, dpa.Spec.Configuration.NodeAgent.PodConfig.Env)
 			}
 
-			// append env vars to the nodeAgent container
-			nodeAgentContainer.Env = common.AppendUniqueEnvVars(nodeAgentContainer.Env, proxy.ReadProxyVarsFromEnv())
+			// append proxy env vars to the nodeAgent container, from the operator environment then from the cluster proxy
+			nodeAgentContainer.Env = common.AppendUniqueEnvVars(nodeAgentContainer.Env, proxy.ReadProxyVarsFromEnv(), r.clusterProxy.envVars())
+
+			// trust the custom CA of the cluster, merged by the Velero deployment into its CA bundle ConfigMap
+			if r.clusterProxy.hasTrustedCABundle() {
+				ds.Spec.Template.Spec.Volumes = append(ds.Spec.Template.Spec.Volumes, corev1.Volume{
+					Name: caCertVolumeName,
+					VolumeSource: corev1.VolumeSource{
+						ConfigMap: &corev1.ConfigMapVolumeSource{
+							LocalObjectReference: corev1.LocalObjectReference{Name: caBundleConfigMapName},
+						},
+					},
+				})
+				nodeAgentContainer.VolumeMounts = append(nodeAgentContainer.VolumeMounts, corev1.VolumeMount{
+					Name:      caCertVolumeName,
+					MountPath: caCertMountPath,
+					ReadOnly:  true,
+				})
+				nodeAgentContainer.Env = common.AppendUniqueEnvVars(nodeAgentContainer.Env, []corev1.EnvVar{{
+					Name:  sslCertFileEnvVar,
+					Value: caCertMountPath + "/" + caBundleFileName,
+				}})
+			}
 
 			// Add Azure workload identity environment variables if configured
-			azureClientID := os.Getenv(stsflow.ClientIDEnvKey)
-			if azureClientID != "" && os.Getenv(stsflow.TenantIDEnvKey) != "" && os.Getenv(stsflow.SubscriptionIDEnvKey) != "" {
+			if _, _, found := azureWorkloadIdentity(dpa); found {
 				// Use envFrom to reference the secret containing Azure workload identity env vars
 				if nodeAgentContainer.EnvFrom == nil {
 					nodeAgentContainer.EnvFrom = []corev1.EnvFromSource{}
@@ -641,6 +708,11 @@ func (r *DataProtectionApplicationReconciler) customizeNodeAgentDaemonset(ds *ap
 
 	credentials.AppendCloudProviderVolumes(dpa, ds, providerNeedsDefaultCreds)
 
+	// roll out the NodeAgent pods when a mounted credential or CA bundle changes
+	if err := r.setCredentialsHashAnnotation(&ds.Spec.Template, ds.Namespace); err != nil {
+		return nil, err
+	}
+
 	setPodTemplateSpecDefaults(&ds.Spec.Template)
 	if ds.Spec.UpdateStrategy.Type == appsv1.RollingUpdateDaemonSetStrategyType {
 		ds.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateDaemonSet{
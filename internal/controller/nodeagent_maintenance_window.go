@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+// maintenanceWindowOccurrence returns the start of the occurrence of the window containing now, or the zero time
+// with the start of the next occurrence when the window is not active. next is zero when the schedule never
+// starts again.
+func maintenanceWindowOccurrence(window oadpv1alpha1.NodeAgentMaintenanceWindow, now time.Time) (start time.Time, next time.Time, err error) {
+	location := time.UTC
+	if window.TimeZone != "" {
+		if location, err = time.LoadLocation(window.TimeZone); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid timeZone %q: %w", window.TimeZone, err)
+		}
+	}
+	// the standard 5-field cron parser of Velero schedules
+	schedule, err := cron.ParseStandard(window.Schedule)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid schedule %q: %w", window.Schedule, err)
+	}
+	if _, ok := schedule.(cron.ConstantDelaySchedule); ok {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid schedule %q: @every intervals have no fixed start", window.Schedule)
+	}
+	if window.Duration.Duration <= 0 {
+		return time.Time{}, time.Time{}, fmt.Errorf("duration must be positive")
+	}
+
+	occurrence := schedule.Next(now.In(location).Add(-window.Duration.Duration))
+	if occurrence.IsZero() || occurrence.After(now) {
+		return time.Time{}, occurrence, nil
+	}
+	// the latest start before now, for windows longer than the schedule period
+	for {
+		following := schedule.Next(occurrence)
+		if following.IsZero() || following.After(now) {
+			return occurrence, following, nil
+		}
+		occurrence = following
+	}
+}
+
+// validateNodeAgentMaintenanceWindows checks the names, schedules, time zones and durations of the maintenance windows
+func validateNodeAgentMaintenanceWindows(windows []oadpv1alpha1.NodeAgentMaintenanceWindow) error {
+	names := map[string]bool{}
+	for _, window := range windows {
+		if names[window.Name] {
+			return fmt.Errorf("spec.configuration.nodeAgent.maintenanceWindows name %q is not unique", window.Name)
+		}
+		names[window.Name] = true
+		if _, _, err := maintenanceWindowOccurrence(window, time.Now()); err != nil {
+			return fmt.Errorf("spec.configuration.nodeAgent.maintenanceWindows %q: %w", window.Name, err)
+		}
+	}
+	return nil
+}
+
+// activeNodeAgentMaintenanceWindow returns the first maintenance window active at now with the status describing
+// its occurrence, nil when no window is active
+func activeNodeAgentMaintenanceWindow(windows []oadpv1alpha1.NodeAgentMaintenanceWindow, now time.Time) (*oadpv1alpha1.NodeAgentMaintenanceWindow, *oadpv1alpha1.NodeAgentMaintenanceWindowStatus) {
+	for i := range windows {
+		start, _, err := maintenanceWindowOccurrence(windows[i], now)
+		if err != nil || start.IsZero() {
+			continue
+		}
+		return &windows[i], &oadpv1alpha1.NodeAgentMaintenanceWindowStatus{
+			Name:  windows[i].Name,
+			Start: metav1.NewTime(start),
+			End:   metav1.NewTime(start.Add(windows[i].Duration.Duration)),
+		}
+	}
+	return nil, nil
+}
+
+// nodeAgentMaintenanceWindows returns the maintenance windows of the NodeAgent, none when the NodeAgent is disabled
+func nodeAgentMaintenanceWindows(dpa *oadpv1alpha1.DataProtectionApplication) []oadpv1alpha1.NodeAgentMaintenanceWindow {
+	if dpa.Spec.Configuration == nil || !isNodeAgentEnabled(dpa) {
+		return nil
+	}
+	return dpa.Spec.Configuration.NodeAgent.MaintenanceWindows
+}
+
+// nodeAgentConfigMapSettings returns the node-agent ConfigMap settings at now: the DPA settings with the
+// loadConcurrency and loadAffinity of the active maintenance window, and the status of that window
+func (r *DataProtectionApplicationReconciler) nodeAgentConfigMapSettings(now time.Time) (oadpv1alpha1.NodeAgentConfigMapSettings, *oadpv1alpha1.NodeAgentMaintenanceWindowStatus) {
+	settings := r.dpa.Spec.Configuration.NodeAgent.NodeAgentConfigMapSettings
+	window, status := activeNodeAgentMaintenanceWindow(nodeAgentMaintenanceWindows(r.dpa), now)
+	if window == nil {
+		return settings, nil
+	}
+	if window.LoadConcurrency != nil {
+		settings.LoadConcurrency = window.LoadConcurrency
+	}
+	if window.LoadAffinityConfig != nil {
+		settings.LoadAffinityConfig = window.LoadAffinityConfig
+	}
+	return settings, status
+}
+
+// nodeAgentMaintenanceWindowRequeueAfter returns the time until the next maintenance window starts or the active
+// one ends, so the node-agent ConfigMap is rewritten on time. Zero when there are no windows.
+func (r *DataProtectionApplicationReconciler) nodeAgentMaintenanceWindowRequeueAfter() time.Duration {
+	if r.dpa == nil {
+		return 0
+	}
+	now := r.now()
+	var transitions []time.Duration
+	for _, window := range nodeAgentMaintenanceWindows(r.dpa) {
+		start, next, err := maintenanceWindowOccurrence(window, now)
+		switch {
+		case err != nil:
+			continue
+		case !start.IsZero():
+			transitions = append(transitions, start.Add(window.Duration.Duration).Sub(now))
+		case !next.IsZero():
+			transitions = append(transitions, next.Sub(now))
+		}
+	}
+	requeueAfter := minRequeueAfter(transitions...)
+	if len(transitions) > 0 && requeueAfter < time.Second {
+		return time.Second
+	}
+	return requeueAfter
+}
+
+// setNodeAgentMaintenanceWindowStatus reports the active maintenance window in status, with an event when a
+// window starts or ends
+func (r *DataProtectionApplicationReconciler) setNodeAgentMaintenanceWindowStatus(window *oadpv1alpha1.NodeAgentMaintenanceWindowStatus) {
+	dpa := r.dpa
+	previous := dpa.Status.NodeAgentMaintenanceWindow
+	if previous != nil && (window == nil || previous.Name != window.Name) {
+		r.EventRecorder.Event(dpa, corev1.EventTypeNormal, "NodeAgentMaintenanceWindowEnded",
+			fmt.Sprintf("node-agent maintenance window %s ended", previous.Name))
+	}
+	if window != nil && (previous == nil || previous.Name != window.Name) {
+		r.EventRecorder.Event(dpa, corev1.EventTypeNormal, "NodeAgentMaintenanceWindowStarted",
+			fmt.Sprintf("node-agent maintenance window %s started, active until %s", window.Name, window.End.UTC().Format(time.RFC3339)))
+	}
+	dpa.Status.NodeAgentMaintenanceWindow = window
+}
@@ -0,0 +1,266 @@
+package controller
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func TestMaintenanceWindowOccurrence(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.Parse(time.RFC3339, value)
+		require.NoError(t, err)
+		return parsed
+	}
+	weeknights := oadpv1alpha1.NodeAgentMaintenanceWindow{Name: "weeknights", Schedule: "0 22 * * 1-5", Duration: metav1.Duration{Duration: 8 * time.Hour}}
+	tests := []struct {
+		name      string
+		window    oadpv1alpha1.NodeAgentMaintenanceWindow
+		now       time.Time
+		wantStart time.Time
+		wantNext  time.Time
+		wantErr   string
+	}{
+		{
+			name:      "active on a weeknight",
+			window:    weeknights,
+			now:       at("2025-01-06T23:00:00Z"),
+			wantStart: at("2025-01-06T22:00:00Z"),
+			wantNext:  at("2025-01-07T22:00:00Z"),
+		},
+		{
+			name:      "friday window runs into saturday",
+			window:    weeknights,
+			now:       at("2025-01-11T03:00:00Z"),
+			wantStart: at("2025-01-10T22:00:00Z"),
+			wantNext:  at("2025-01-13T22:00:00Z"),
+		},
+		{
+			name:     "inactive on the weekend",
+			window:   weeknights,
+			now:      at("2025-01-11T12:00:00Z"),
+			wantNext: at("2025-01-13T22:00:00Z"),
+		},
+		{
+			name:     "ended exactly now",
+			window:   weeknights,
+			now:      at("2025-01-07T06:00:00Z"),
+			wantNext: at("2025-01-07T22:00:00Z"),
+		},
+		{
+			name:      "schedule in a time zone",
+			window:    oadpv1alpha1.NodeAgentMaintenanceWindow{Name: "paris", Schedule: "0 1 * * *", TimeZone: "Europe/Paris", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+			now:       at("2025-01-06T00:30:00Z"),
+			wantStart: at("2025-01-06T00:00:00Z"),
+			wantNext:  at("2025-01-07T00:00:00Z"),
+		},
+		{
+			name:      "window longer than the schedule period starts at the latest occurrence",
+			window:    oadpv1alpha1.NodeAgentMaintenanceWindow{Name: "hourly", Schedule: "0 * * * *", Duration: metav1.Duration{Duration: 3 * time.Hour}},
+			now:       at("2025-01-06T10:30:00Z"),
+			wantStart: at("2025-01-06T10:00:00Z"),
+			wantNext:  at("2025-01-06T11:00:00Z"),
+		},
+		{
+			name:    "invalid schedule",
+			window:  oadpv1alpha1.NodeAgentMaintenanceWindow{Name: "bad", Schedule: "0 22 *", Duration: metav1.Duration{Duration: time.Hour}},
+			wantErr: `invalid schedule "0 22 *"`,
+		},
+		{
+			name:    "schedule with seconds is not a standard cron expression",
+			window:  oadpv1alpha1.NodeAgentMaintenanceWindow{Name: "bad", Schedule: "0 0 22 * * 1-5", Duration: metav1.Duration{Duration: time.Hour}},
+			wantErr: `invalid schedule "0 0 22 * * 1-5"`,
+		},
+		{
+			name:    "interval schedule has no fixed start",
+			window:  oadpv1alpha1.NodeAgentMaintenanceWindow{Name: "bad", Schedule: "@every 1h", Duration: metav1.Duration{Duration: time.Hour}},
+			wantErr: "@every intervals have no fixed start",
+		},
+		{
+			name:    "invalid time zone",
+			window:  oadpv1alpha1.NodeAgentMaintenanceWindow{Name: "bad", Schedule: "0 22 * * *", TimeZone: "Mars/Olympus", Duration: metav1.Duration{Duration: time.Hour}},
+			wantErr: `invalid timeZone "Mars/Olympus"`,
+		},
+		{
+			name:    "zero duration",
+			window:  oadpv1alpha1.NodeAgentMaintenanceWindow{Name: "bad", Schedule: "0 22 * * *"},
+			wantErr: "duration must be positive",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, next, err := maintenanceWindowOccurrence(tt.window, tt.now)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, tt.wantStart.Equal(start), "start %s, want %s", start, tt.wantStart)
+			require.True(t, tt.wantNext.Equal(next), "next %s, want %s", next, tt.wantNext)
+		})
+	}
+}
+
+func TestValidateNodeAgentMaintenanceWindows(t *testing.T) {
+	window := oadpv1alpha1.NodeAgentMaintenanceWindow{Name: "nightly", Schedule: "0 22 * * *", Duration: metav1.Duration{Duration: time.Hour}}
+	require.NoError(t, validateNodeAgentMaintenanceWindows([]oadpv1alpha1.NodeAgentMaintenanceWindow{window}))
+	require.ErrorContains(t,
+		validateNodeAgentMaintenanceWindows([]oadpv1alpha1.NodeAgentMaintenanceWindow{window, window}),
+		`maintenanceWindows name "nightly" is not unique`)
+	window.Schedule = "nightly"
+	require.ErrorContains(t,
+		validateNodeAgentMaintenanceWindows([]oadpv1alpha1.NodeAgentMaintenanceWindow{window}),
+		`maintenanceWindows "nightly": invalid schedule`)
+}
+
+func TestDPAReconciler_ReconcileNodeAgentConfigMap_MaintenanceWindows(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns"},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{DisableFsBackup: ptr.To(true)},
+				NodeAgent: &oadpv1alpha1.NodeAgentConfig{
+					NodeAgentCommonFields: oadpv1alpha1.NodeAgentCommonFields{Enable: ptr.To(true)},
+					MaintenanceWindows: []oadpv1alpha1.NodeAgentMaintenanceWindow{
+						{
+							Name:            "off-peak",
+							Schedule:        "0 22 * * *",
+							Duration:        metav1.Duration{Duration: 8 * time.Hour},
+							LoadConcurrency: &oadpv1alpha1.LoadConcurrency{GlobalConfig: 4},
+							LoadAffinityConfig: []*oadpv1alpha1.LoadAffinity{
+								{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"pool": "busy"}}},
+							},
+						},
+						{
+							Name:            "overlapping",
+							Schedule:        "0 23 * * *",
+							Duration:        metav1.Duration{Duration: time.Hour},
+							LoadConcurrency: &oadpv1alpha1.LoadConcurrency{GlobalConfig: 8},
+						},
+					},
+				},
+			},
+		},
+	}
+	fakeClock := clocktesting.NewFakePassiveClock(time.Date(2025, 1, 6, 21, 0, 0, 0, time.UTC))
+	fakeClient := getFakeClientFromObjectsForTest(t, dpa)
+	recorder := record.NewFakeRecorder(10)
+	r := &DataProtectionApplicationReconciler{
+		Client:         fakeClient,
+		Scheme:         fakeClient.Scheme(),
+		dpa:            dpa,
+		Log:            logr.Discard(),
+		Context:        newContextForTest(),
+		NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+		EventRecorder:  recorder,
+		Clock:          fakeClock,
+	}
+	nodeAgentConfig := func() map[string]interface{} {
+		configMap := &corev1.ConfigMap{}
+		require.NoError(t, fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: common.NodeAgentConfigMapPrefix + "test-dpa"}, configMap))
+		config := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal([]byte(configMap.Data["node-agent-config"]), &config))
+		return config
+	}
+
+	// before the window, no settings require the ConfigMap
+	ok, err := r.ReconcileNodeAgentConfigMap(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Nil(t, dpa.Status.NodeAgentMaintenanceWindow)
+	require.Equal(t, time.Hour, r.nodeAgentMaintenanceWindowRequeueAfter())
+
+	fakeClock.SetTime(time.Date(2025, 1, 6, 23, 30, 0, 0, time.UTC))
+	ok, err = r.ReconcileNodeAgentConfigMap(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, &oadpv1alpha1.NodeAgentMaintenanceWindowStatus{
+		Name:  "off-peak",
+		Start: metav1.NewTime(time.Date(2025, 1, 6, 22, 0, 0, 0, time.UTC)),
+		End:   metav1.NewTime(time.Date(2025, 1, 7, 6, 0, 0, 0, time.UTC)),
+	}, dpa.Status.NodeAgentMaintenanceWindow)
+	config := nodeAgentConfig()
+	require.Equal(t, map[string]interface{}{"globalConfig": float64(4)}, config["loadConcurrency"], "the first active window applies")
+	require.NotNil(t, config["loadAffinity"])
+	require.Equal(t, 30*time.Minute, r.nodeAgentMaintenanceWindowRequeueAfter(), "the overlapping window ends first")
+	require.Contains(t, <-recorder.Events, "NodeAgentMaintenanceWindowStarted")
+	require.Contains(t, <-recorder.Events, "CreatedNodeAgentConfigMap")
+
+	fakeClock.SetTime(time.Date(2025, 1, 7, 6, 0, 0, 0, time.UTC))
+	ok, err = r.ReconcileNodeAgentConfigMap(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Nil(t, dpa.Status.NodeAgentMaintenanceWindow)
+	require.Contains(t, <-recorder.Events, "NodeAgentMaintenanceWindowEnded")
+	configMap := &corev1.ConfigMap{}
+	err = fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: common.NodeAgentConfigMapPrefix + "test-dpa"}, configMap)
+	require.Error(t, err, "ConfigMap without settings is deleted after the window")
+	require.Equal(t, 16*time.Hour, r.nodeAgentMaintenanceWindowRequeueAfter())
+}
+
+func TestDPAReconciler_buildNodeAgentDaemonset_MaintenanceWindowRollsOut(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: testNamespaceName},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{},
+				NodeAgent: &oadpv1alpha1.NodeAgentConfig{
+					NodeAgentCommonFields: oadpv1alpha1.NodeAgentCommonFields{Enable: ptr.To(true)},
+					UploaderType:          "kopia",
+					NodeAgentConfigMapSettings: oadpv1alpha1.NodeAgentConfigMapSettings{
+						LoadConcurrency: &oadpv1alpha1.LoadConcurrency{GlobalConfig: 1},
+					},
+					MaintenanceWindows: []oadpv1alpha1.NodeAgentMaintenanceWindow{{
+						Name:            "off-peak",
+						Schedule:        "0 22 * * *",
+						Duration:        metav1.Duration{Duration: 8 * time.Hour},
+						LoadConcurrency: &oadpv1alpha1.LoadConcurrency{GlobalConfig: 4},
+					}},
+				},
+			},
+		},
+	}
+	fakeClock := clocktesting.NewFakePassiveClock(time.Date(2025, 1, 6, 21, 0, 0, 0, time.UTC))
+	fakeClient := getFakeClientFromObjectsForTest(t, dpa, testGenericInfrastructure)
+	r := &DataProtectionApplicationReconciler{
+		Client:         fakeClient,
+		Scheme:         fakeClient.Scheme(),
+		dpa:            dpa,
+		Log:            logr.Discard(),
+		Context:        newContextForTest(),
+		NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+		EventRecorder:  record.NewFakeRecorder(10),
+		Clock:          fakeClock,
+	}
+	configHash := func() string {
+		_, err := r.ReconcileNodeAgentConfigMap(r.Log)
+		require.NoError(t, err)
+		ds, err := r.buildNodeAgentDaemonset(testNodeAgentDaemonSet.DeepCopy())
+		require.NoError(t, err)
+		return ds.Spec.Template.Annotations[NodeAgentConfigHashAnnotation]
+	}
+
+	beforeWindow := configHash()
+	require.NotEmpty(t, beforeWindow)
+
+	// the node-agent reads its ConfigMap only at startup, a window starting or ending must change the pod template
+	fakeClock.SetTime(time.Date(2025, 1, 6, 23, 0, 0, 0, time.UTC))
+	inWindow := configHash()
+	require.NotEqual(t, beforeWindow, inWindow)
+
+	fakeClock.SetTime(time.Date(2025, 1, 7, 6, 0, 0, 0, time.UTC))
+	require.Equal(t, beforeWindow, configHash())
+}
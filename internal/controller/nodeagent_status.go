@@ -0,0 +1,329 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+const (
+	// defaultNodeAgentConcurrency is the concurrency of the NodeAgent without loadConcurrency, as in Velero
+	defaultNodeAgentConcurrency = 1
+	// maxUnprotectedPods limits the pods listed in status.nodeAgent.unprotectedPods
+	maxUnprotectedPods = 20
+	// maxNodeAgentNodes limits the nodes listed in status.nodeAgent.nodes
+	maxNodeAgentNodes = 100
+	// maxUnprotectedPodNodes limits the nodes without a ready NodeAgent whose pods are listed
+	maxUnprotectedPodNodes = 50
+	// maxPodsPerNode limits the pods listed on each node without a ready NodeAgent
+	maxPodsPerNode = 500
+	// podNodeNameField is the field selector of the node of a pod
+	podNodeNameField = "spec.nodeName"
+	// unprotectedPodResyncInterval is how often the pods of unchanged nodes without a ready NodeAgent are listed again
+	unprotectedPodResyncInterval = 10 * time.Minute
+)
+
+// nodeAgentConcurrency returns the data path concurrency of the NodeAgent of a node and the index of the
+// loadConcurrency.perNodeConfig rule setting it, following Velero: the smallest number of the matching rules,
+// or the global number when no rule matches.
+func nodeAgentConcurrency(loadConcurrency *oadpv1alpha1.LoadConcurrency, node *corev1.Node) (int, *int) {
+	if loadConcurrency == nil {
+		return defaultNodeAgentConcurrency, nil
+	}
+	concurrency := loadConcurrency.GlobalConfig
+	if concurrency <= 0 {
+		concurrency = defaultNodeAgentConcurrency
+	}
+
+	var rule *int
+	ruleNumber := math.MaxInt32
+	for i, perNodeConfig := range loadConcurrency.PerNodeConfig {
+		selector, err := metav1.LabelSelectorAsSelector(&perNodeConfig.NodeSelector)
+		if err != nil || perNodeConfig.Number <= 0 {
+			continue
+		}
+		if selector.Matches(labels.Set(node.Labels)) && perNodeConfig.Number < ruleNumber {
+			ruleNumber = perNodeConfig.Number
+			rule = ptr.To(i)
+		}
+	}
+	if rule != nil {
+		return ruleNumber, rule
+	}
+	return concurrency, nil
+}
+
+// matchesLoadAffinity returns whether the node matches one of the loadAffinity rules, true when there are none
+func matchesLoadAffinity(loadAffinity []*oadpv1alpha1.LoadAffinity, node *corev1.Node) bool {
+	if len(loadAffinity) == 0 {
+		return true
+	}
+	for _, affinity := range loadAffinity {
+		if affinity == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&affinity.NodeSelector)
+		if err == nil && selector.Matches(labels.Set(node.Labels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeAgentExclusion returns why the NodeAgent does not run on the node with the loadAffinity in effect, which
+// is the one of the active maintenance window during a window, empty when it does
+func nodeAgentExclusion(dpa *oadpv1alpha1.DataProtectionApplication, loadAffinity []*oadpv1alpha1.LoadAffinity, node *corev1.Node) oadpv1alpha1.NodeAgentNodeReason {
+	nodeAgent := dpa.Spec.Configuration.NodeAgent
+	if !matchesLoadAffinity(nodeAgent.LoadAffinityConfig, node) || !matchesLoadAffinity(loadAffinity, node) {
+		return oadpv1alpha1.NodeAgentNodeExcludedByLoadAffinity
+	}
+	if nodeAgent.PodConfig != nil && len(nodeAgent.PodConfig.NodeSelector) > 0 &&
+		!labels.SelectorFromSet(nodeAgent.PodConfig.NodeSelector).Matches(labels.Set(node.Labels)) {
+		return oadpv1alpha1.NodeAgentNodeExcludedByLoadAffinity
+	}
+
+	var tolerations []corev1.Toleration
+	if nodeAgent.PodConfig != nil {
+		tolerations = nodeAgent.PodConfig.Tolerations
+	}
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for j := range tolerations {
+			if tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return oadpv1alpha1.NodeAgentNodeTaintNotTolerated
+		}
+	}
+	return ""
+}
+
+// nodeAgentPodStatus returns the readiness, restarts and, when not ready, the reason of a NodeAgent pod
+func nodeAgentPodStatus(pod *corev1.Pod) (bool, int32, oadpv1alpha1.NodeAgentNodeReason) {
+	var restarts int32
+	crashLooping := false
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.Name != common.NodeAgent {
+			continue
+		}
+		restarts = containerStatus.RestartCount
+		crashLooping = containerStatus.State.Waiting != nil && containerStatus.State.Waiting.Reason == "CrashLoopBackOff"
+	}
+	if pod.Status.Phase != corev1.PodRunning && !crashLooping {
+		return false, restarts, oadpv1alpha1.NodeAgentNodePending
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+			return true, restarts, ""
+		}
+	}
+	if crashLooping {
+		return false, restarts, oadpv1alpha1.NodeAgentNodeCrashLoopBackOff
+	}
+	return false, restarts, oadpv1alpha1.NodeAgentNodeNotReady
+}
+
+// unprotectedPod returns the pod as an UnprotectedPod when it mounts persistent volume claims, nil otherwise
+func unprotectedPod(pod *corev1.Pod) *oadpv1alpha1.UnprotectedPod {
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return nil
+	}
+	var claims []string
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			claims = append(claims, volume.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	if len(claims) == 0 {
+		return nil
+	}
+	unprotected := &oadpv1alpha1.UnprotectedPod{
+		Namespace:              pod.Namespace,
+		Name:                   pod.Name,
+		Node:                   pod.Spec.NodeName,
+		PersistentVolumeClaims: claims,
+	}
+	if owner := metav1.GetControllerOf(pod); owner != nil {
+		unprotected.Workload = owner.Kind + "/" + owner.Name
+	}
+	return unprotected
+}
+
+// nodeAgentNodeChanged returns whether a node update changes the NodeAgent status: the labels matched by the
+// loadAffinity and loadConcurrency rules, or the taints
+func nodeAgentNodeChanged(oldNode, newNode *corev1.Node) bool {
+	return !equality.Semantic.DeepEqual(oldNode.Labels, newNode.Labels) || !equality.Semantic.DeepEqual(oldNode.Spec.Taints, newNode.Spec.Taints)
+}
+
+// nodeAgentDaemonSetStatusChanged returns whether the pod counts of a DaemonSet status changed, as NodeAgent pods
+// are scheduled, become ready or stop being ready
+func nodeAgentDaemonSetStatusChanged(oldDaemonSet, newDaemonSet *appsv1.DaemonSet) bool {
+	return oldDaemonSet.Status.DesiredNumberScheduled != newDaemonSet.Status.DesiredNumberScheduled ||
+		oldDaemonSet.Status.CurrentNumberScheduled != newDaemonSet.Status.CurrentNumberScheduled ||
+		oldDaemonSet.Status.NumberReady != newDaemonSet.Status.NumberReady ||
+		oldDaemonSet.Status.NumberUnavailable != newDaemonSet.Status.NumberUnavailable
+}
+
+// nodeAgentDPARequests maps a node to the DPAs with the NodeAgent enabled, so their status follows the nodes
+func (r *DataProtectionApplicationReconciler) nodeAgentDPARequests(ctx context.Context, object client.Object) []reconcile.Request {
+	dpaList := &oadpv1alpha1.DataProtectionApplicationList{}
+	if err := r.List(ctx, dpaList); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range dpaList.Items {
+		if isNodeAgentEnabled(&dpaList.Items[i]) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&dpaList.Items[i])})
+		}
+	}
+	return requests
+}
+
+// ReconcileNodeAgentStatus reports in status.nodeAgent whether the nodes of the cluster run a ready NodeAgent,
+// its restarts and data path concurrency, and the pods mounting persistent volume claims on nodes without a
+// ready NodeAgent, whose file system backups would fail. Nodes are read from the cache of the manager, and only
+// the pods of the nodes without a ready NodeAgent are listed, when those nodes change or once per
+// unprotectedPodResyncInterval.
+func (r *DataProtectionApplicationReconciler) ReconcileNodeAgentStatus(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	if !isNodeAgentEnabled(dpa) {
+		dpa.Status.NodeAgent = nil
+		return true, nil
+	}
+	settings, _ := r.nodeAgentConfigMapSettings(r.now())
+
+	nodes := &corev1.NodeList{}
+	if err := r.List(r.Context, nodes); err != nil {
+		return false, fmt.Errorf("unable to list nodes for the NodeAgent status: %w", err)
+	}
+	nodeAgentPods := &corev1.PodList{}
+	if err := r.List(r.Context, nodeAgentPods, client.InNamespace(dpa.Namespace), client.MatchingLabels(nodeAgentMatchLabels)); err != nil {
+		return false, fmt.Errorf("unable to list NodeAgent pods: %w", err)
+	}
+	podOfNode := map[string]*corev1.Pod{}
+	for i := range nodeAgentPods.Items {
+		pod := &nodeAgentPods.Items[i]
+		if pod.Spec.NodeName != "" && pod.DeletionTimestamp == nil {
+			podOfNode[pod.Spec.NodeName] = pod
+		}
+	}
+
+	status := &oadpv1alpha1.NodeAgentStatus{NodeCount: len(nodes.Items)}
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		nodeStatus := oadpv1alpha1.NodeAgentNodeStatus{Node: node.Name}
+		nodeStatus.Concurrency, nodeStatus.ConcurrencyRule = nodeAgentConcurrency(settings.LoadConcurrency, node)
+		reason := nodeAgentExclusion(dpa, settings.LoadAffinityConfig, node)
+		if pod, found := podOfNode[node.Name]; found {
+			nodeStatus.Scheduled = true
+			nodeStatus.Pod = pod.Name
+			nodeStatus.Ready, nodeStatus.Restarts, nodeStatus.Reason = nodeAgentPodStatus(pod)
+			if nodeStatus.Ready && reason == oadpv1alpha1.NodeAgentNodeExcludedByLoadAffinity {
+				nodeStatus.Reason = reason
+			}
+		} else if reason != "" {
+			nodeStatus.Reason = reason
+		} else {
+			nodeStatus.Scheduled = true
+			nodeStatus.Reason = oadpv1alpha1.NodeAgentNodePending
+		}
+		status.Nodes = append(status.Nodes, nodeStatus)
+	}
+	sort.Slice(status.Nodes, func(i, j int) bool {
+		if status.Nodes[i].Ready != status.Nodes[j].Ready {
+			return !status.Nodes[i].Ready
+		}
+		return status.Nodes[i].Node < status.Nodes[j].Node
+	})
+
+	var withoutNodeAgent []string
+	for _, nodeStatus := range status.Nodes {
+		if !nodeStatus.Ready && len(withoutNodeAgent) < maxUnprotectedPodNodes {
+			withoutNodeAgent = append(withoutNodeAgent, nodeStatus.Node)
+		}
+	}
+	if len(status.Nodes) > maxNodeAgentNodes {
+		status.Nodes = status.Nodes[:maxNodeAgentNodes]
+	}
+
+	if len(withoutNodeAgent) > 0 && !r.unprotectedPodScanDue(withoutNodeAgent) {
+		previous := dpa.Status.NodeAgent
+		status.UnprotectedPodCount = previous.UnprotectedPodCount
+		status.UnprotectedPods = previous.UnprotectedPods
+		status.LastUnprotectedPodScan = previous.LastUnprotectedPodScan
+	} else if len(withoutNodeAgent) > 0 {
+		clusterClient := r.ClusterWideClient
+		if clusterClient == nil {
+			clusterClient = r.Client
+		}
+		var unprotected []oadpv1alpha1.UnprotectedPod
+		for _, nodeName := range withoutNodeAgent {
+			pods := &corev1.PodList{}
+			if err := clusterClient.List(r.Context, pods, client.MatchingFields{podNodeNameField: nodeName}, client.Limit(maxPodsPerNode)); err != nil {
+				return false, fmt.Errorf("unable to list the pods of node %s for the NodeAgent status: %w", nodeName, err)
+			}
+			for i := range pods.Items {
+				if u := unprotectedPod(&pods.Items[i]); u != nil {
+					unprotected = append(unprotected, *u)
+				}
+			}
+		}
+		sort.Slice(unprotected, func(i, j int) bool {
+			if unprotected[i].Namespace != unprotected[j].Namespace {
+				return unprotected[i].Namespace < unprotected[j].Namespace
+			}
+			return unprotected[i].Name < unprotected[j].Name
+		})
+		status.UnprotectedPodCount = len(unprotected)
+		if len(unprotected) > maxUnprotectedPods {
+			unprotected = unprotected[:maxUnprotectedPods]
+		}
+		status.UnprotectedPods = unprotected
+		status.LastUnprotectedPodScan = &metav1.Time{Time: r.now()}
+		if status.UnprotectedPodCount > 0 {
+			log.Info("Pods with persistent volume claims run on nodes without a ready NodeAgent", "count", status.UnprotectedPodCount)
+		}
+	}
+
+	dpa.Status.NodeAgent = status
+	return true, nil
+}
+
+// unprotectedPodScanDue returns true when the pods of the nodes without a ready NodeAgent were never listed,
+// were listed for other nodes, or were listed unprotectedPodResyncInterval ago.
+func (r *DataProtectionApplicationReconciler) unprotectedPodScanDue(withoutNodeAgent []string) bool {
+	previous := r.dpa.Status.NodeAgent
+	if previous == nil || previous.LastUnprotectedPodScan == nil ||
+		r.now().Sub(previous.LastUnprotectedPodScan.Time) >= unprotectedPodResyncInterval {
+		return true
+	}
+	var scanned []string
+	for _, nodeStatus := range previous.Nodes {
+		if !nodeStatus.Ready && len(scanned) < maxUnprotectedPodNodes {
+			scanned = append(scanned, nodeStatus.Node)
+		}
+	}
+	return !equality.Semantic.DeepEqual(scanned, withoutNodeAgent)
+}
@@ -0,0 +1,233 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func TestNodeAgentConcurrency(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker", Labels: map[string]string{"pool": "storage", "zone": "a"}}}
+	tests := []struct {
+		name            string
+		loadConcurrency *oadpv1alpha1.LoadConcurrency
+		wantConcurrency int
+		wantRule        *int
+	}{
+		{
+			name:            "no loadConcurrency",
+			wantConcurrency: 1,
+		},
+		{
+			name:            "invalid global number",
+			loadConcurrency: &oadpv1alpha1.LoadConcurrency{GlobalConfig: -1},
+			wantConcurrency: 1,
+		},
+		{
+			name: "no matching rule",
+			loadConcurrency: &oadpv1alpha1.LoadConcurrency{GlobalConfig: 3, PerNodeConfig: []oadpv1alpha1.RuledConfigs{
+				{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"pool": "compute"}}, Number: 5},
+			}},
+			wantConcurrency: 3,
+		},
+		{
+			name: "smallest matching rule",
+			loadConcurrency: &oadpv1alpha1.LoadConcurrency{GlobalConfig: 3, PerNodeConfig: []oadpv1alpha1.RuledConfigs{
+				{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"pool": "storage"}}, Number: 5},
+				{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"zone": "a"}}, Number: 0},
+				{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"zone": "a"}}, Number: 2},
+			}},
+			wantConcurrency: 2,
+			wantRule:        ptr.To(2),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			concurrency, rule := nodeAgentConcurrency(tt.loadConcurrency, node)
+			require.Equal(t, tt.wantConcurrency, concurrency)
+			require.Equal(t, tt.wantRule, rule)
+		})
+	}
+}
+
+func TestDPAReconciler_ReconcileNodeAgentStatus(t *testing.T) {
+	newNode := func(name string, labels map[string]string, taints ...corev1.Taint) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Spec:       corev1.NodeSpec{Taints: taints},
+		}
+	}
+	newNodeAgentPod := func(node string, ready bool, restarts int32, waiting string) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-agent-" + node, Namespace: "test-ns", Labels: nodeAgentMatchLabels},
+			Spec:       corev1.PodSpec{NodeName: node},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}},
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:         common.NodeAgent,
+					RestartCount: restarts,
+				}},
+			},
+		}
+		if ready {
+			pod.Status.Conditions[0].Status = corev1.ConditionTrue
+		}
+		if waiting != "" {
+			pod.Status.ContainerStatuses[0].State.Waiting = &corev1.ContainerStateWaiting{Reason: waiting}
+		}
+		return pod
+	}
+	newWorkloadPod := func(name, node string, claims ...string) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "app",
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "db", UID: "db", Controller: ptr.To(true)}},
+			},
+			Spec:   corev1.PodSpec{NodeName: node},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+		for _, claim := range claims {
+			pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+				Name:         claim,
+				VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claim}},
+			})
+		}
+		return pod
+	}
+
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns"},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{},
+				NodeAgent: &oadpv1alpha1.NodeAgentConfig{
+					NodeAgentCommonFields: oadpv1alpha1.NodeAgentCommonFields{Enable: ptr.To(true)},
+					NodeAgentConfigMapSettings: oadpv1alpha1.NodeAgentConfigMapSettings{
+						LoadAffinityConfig: []*oadpv1alpha1.LoadAffinity{
+							{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"backup": "true"}}},
+						},
+						LoadConcurrency: &oadpv1alpha1.LoadConcurrency{GlobalConfig: 2, PerNodeConfig: []oadpv1alpha1.RuledConfigs{
+							{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"pool": "storage"}}, Number: 4},
+						}},
+					},
+				},
+			},
+		},
+	}
+	backupNode := map[string]string{"backup": "true"}
+	testScheme, err := getSchemeForFakeClient()
+	require.NoError(t, err)
+	fakeClient := fake.NewClientBuilder().WithScheme(testScheme).WithIndex(&corev1.Pod{}, podNodeNameField, func(object client.Object) []string {
+		return []string{object.(*corev1.Pod).Spec.NodeName}
+	}).WithObjects(
+		dpa,
+		newNode("excluded", nil),
+		newNode("crashing", backupNode),
+		newNode("healthy", map[string]string{"backup": "true", "pool": "storage"}),
+		newNode("pending", backupNode),
+		newNode("tainted", backupNode, corev1.Taint{Key: "dedicated", Value: "infra", Effect: corev1.TaintEffectNoSchedule}),
+		newNodeAgentPod("healthy", true, 1, ""),
+		newNodeAgentPod("crashing", false, 7, "CrashLoopBackOff"),
+		newWorkloadPod("db-0", "healthy", "data-db-0"),
+		newWorkloadPod("db-1", "crashing", "data-db-1", "logs-db-1"),
+		newWorkloadPod("db-2", "excluded", "data-db-2"),
+		newWorkloadPod("web", "excluded"),
+	).Build()
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	fakeClock := clocktesting.NewFakePassiveClock(now)
+	r := &DataProtectionApplicationReconciler{
+		Client:        fakeClient,
+		Scheme:        fakeClient.Scheme(),
+		dpa:           dpa,
+		Log:           logr.Discard(),
+		Context:       newContextForTest(),
+		EventRecorder: newEventRecorder(),
+		Clock:         fakeClock,
+	}
+
+	ok, err := r.ReconcileNodeAgentStatus(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 5, dpa.Status.NodeAgent.NodeCount)
+	require.Equal(t, []oadpv1alpha1.NodeAgentNodeStatus{
+		{Node: "crashing", Scheduled: true, Pod: "node-agent-crashing", Restarts: 7, Reason: oadpv1alpha1.NodeAgentNodeCrashLoopBackOff, Concurrency: 2},
+		{Node: "excluded", Reason: oadpv1alpha1.NodeAgentNodeExcludedByLoadAffinity, Concurrency: 2},
+		{Node: "pending", Scheduled: true, Reason: oadpv1alpha1.NodeAgentNodePending, Concurrency: 2},
+		{Node: "tainted", Reason: oadpv1alpha1.NodeAgentNodeTaintNotTolerated, Concurrency: 2},
+		{Node: "healthy", Scheduled: true, Ready: true, Pod: "node-agent-healthy", Restarts: 1, Concurrency: 4, ConcurrencyRule: ptr.To(0)},
+	}, dpa.Status.NodeAgent.Nodes)
+	require.Equal(t, 2, dpa.Status.NodeAgent.UnprotectedPodCount)
+	require.Equal(t, []oadpv1alpha1.UnprotectedPod{
+		{Namespace: "app", Name: "db-1", Workload: "StatefulSet/db", Node: "crashing", PersistentVolumeClaims: []string{"data-db-1", "logs-db-1"}},
+		{Namespace: "app", Name: "db-2", Workload: "StatefulSet/db", Node: "excluded", PersistentVolumeClaims: []string{"data-db-2"}},
+	}, dpa.Status.NodeAgent.UnprotectedPods)
+	require.Equal(t, &metav1.Time{Time: now}, dpa.Status.NodeAgent.LastUnprotectedPodScan)
+
+	// the pods of the same nodes without a ready NodeAgent are not listed again before the resync interval
+	require.NoError(t, fakeClient.Create(r.Context, newWorkloadPod("db-3", "pending", "data-db-3")))
+	ok, err = r.ReconcileNodeAgentStatus(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 2, dpa.Status.NodeAgent.UnprotectedPodCount)
+	require.Len(t, dpa.Status.NodeAgent.UnprotectedPods, 2)
+	require.Equal(t, &metav1.Time{Time: now}, dpa.Status.NodeAgent.LastUnprotectedPodScan)
+
+	// the maintenance window restricts the data path to the storage pool with its own concurrency
+	dpa.Spec.Configuration.NodeAgent.MaintenanceWindows = []oadpv1alpha1.NodeAgentMaintenanceWindow{{
+		Name:     "storage-only",
+		Schedule: "0 * * * *",
+		Duration: metav1.Duration{Duration: time.Hour},
+		LoadAffinityConfig: []*oadpv1alpha1.LoadAffinity{
+			{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"pool": "storage"}}},
+		},
+		LoadConcurrency: &oadpv1alpha1.LoadConcurrency{GlobalConfig: 8},
+	}}
+	ok, err = r.ReconcileNodeAgentStatus(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []oadpv1alpha1.NodeAgentNodeStatus{
+		{Node: "crashing", Scheduled: true, Pod: "node-agent-crashing", Restarts: 7, Reason: oadpv1alpha1.NodeAgentNodeCrashLoopBackOff, Concurrency: 8},
+		{Node: "excluded", Reason: oadpv1alpha1.NodeAgentNodeExcludedByLoadAffinity, Concurrency: 8},
+		{Node: "pending", Reason: oadpv1alpha1.NodeAgentNodeExcludedByLoadAffinity, Concurrency: 8},
+		{Node: "tainted", Reason: oadpv1alpha1.NodeAgentNodeExcludedByLoadAffinity, Concurrency: 8},
+		{Node: "healthy", Scheduled: true, Ready: true, Pod: "node-agent-healthy", Restarts: 1, Concurrency: 8},
+	}, dpa.Status.NodeAgent.Nodes)
+	dpa.Spec.Configuration.NodeAgent.MaintenanceWindows = nil
+	require.Equal(t, 2, dpa.Status.NodeAgent.UnprotectedPodCount)
+
+	// the pods are listed again after the resync interval
+	fakeClock.SetTime(now.Add(unprotectedPodResyncInterval))
+	ok, err = r.ReconcileNodeAgentStatus(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 3, dpa.Status.NodeAgent.UnprotectedPodCount)
+	require.Equal(t, &metav1.Time{Time: now.Add(unprotectedPodResyncInterval)}, dpa.Status.NodeAgent.LastUnprotectedPodScan)
+
+	// and as soon as another node has no ready NodeAgent
+	nodeAgentPod := &corev1.Pod{}
+	require.NoError(t, fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: "node-agent-healthy"}, nodeAgentPod))
+	require.NoError(t, fakeClient.Delete(r.Context, nodeAgentPod))
+	ok, err = r.ReconcileNodeAgentStatus(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 4, dpa.Status.NodeAgent.UnprotectedPodCount)
+
+	dpa.Spec.Configuration.NodeAgent.Enable = ptr.To(false)
+	ok, err = r.ReconcileNodeAgentStatus(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Nil(t, dpa.Status.NodeAgent)
+}
@@ -3,6 +3,8 @@ package controller
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -1454,6 +1456,8 @@ func TestDPAReconciler_buildNodeAgentDaemonset(t *testing.T) {
 					"--node-agent-configmap=node-agent-test-DPA-CR",
 				},
 				labels: map[string]string{"openshift.io/node-agent-cm-version": "999"},
+				annotations: map[string]string{NodeAgentConfigHashAnnotation: sha256Hex(
+					`{"loadConcurrency":{"globalConfig":10,"perNodeConfig":[{"nodeSelector":{"matchLabels":{"app":"velero"}},"number":1}]},"privilegedFsBackup":true}`)},
 			}),
 		},
 		{
@@ -1495,6 +1499,8 @@ func TestDPAReconciler_buildNodeAgentDaemonset(t *testing.T) {
 					"--node-agent-configmap=node-agent-test-DPA-CR",
 				},
 				labels: map[string]string{"openshift.io/node-agent-cm-version": "999"},
+				annotations: map[string]string{NodeAgentConfigHashAnnotation: sha256Hex(
+					`{"loadAffinity":[{"nodeSelector":{"matchLabels":{"foos":"bars"}}}],"privilegedFsBackup":true}`)},
 			}),
 		},
 		{
@@ -1559,6 +1565,12 @@ func TestDPAReconciler_buildNodeAgentDaemonset(t *testing.T) {
 	}
 }
 
+// sha256Hex returns the hex encoded SHA-256 of the content
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
 func createTestBuiltNodeAgentCM(data map[string]string) *corev1.ConfigMap {
 	// Normalize multi-line JSON values
 	for key, value := range data {
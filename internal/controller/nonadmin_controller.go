@@ -5,6 +5,7 @@ import (
 	"os"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -15,6 +16,7 @@ import (
 	k8serror "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -28,6 +30,10 @@ const (
 	controlPlaneKey    = "control-plane"
 
 	dpaResourceVersionAnnotation = oadpv1alpha1.OadpOperatorLabel + "-dpa-resource-version"
+	// podConfigLabelsAnnotation and podConfigAnnotationsAnnotation record on the Deployment the pod template keys
+	// applied from the podConfig, so the keys removed from the podConfig are removed from the pod template
+	podConfigLabelsAnnotation      = oadpv1alpha1.OadpOperatorLabel + "-pod-config-labels"
+	podConfigAnnotationsAnnotation = oadpv1alpha1.OadpOperatorLabel + "-pod-config-annotations"
 )
 
 var (
@@ -178,6 +184,12 @@ func ensureRequiredSpecs(deploymentObject *appsv1.Deployment, dpa *oadpv1alpha1.
 		})
 	}
 
+	podConfig := &oadpv1alpha1.PodConfig{}
+	if dpa.Spec.NonAdmin != nil && dpa.Spec.NonAdmin.PodConfig != nil {
+		podConfig = dpa.Spec.NonAdmin.PodConfig
+	}
+	envVars = common.AppendUniqueEnvVars(envVars, podConfig.Env)
+
 	if len(dpaResourceVersion) == 0 ||
 		!reflect.DeepEqual(dpa.Spec.NonAdmin, previousNonAdminConfiguration) ||
 		(dpa.Spec.Configuration.Velero.Args != nil &&
@@ -204,6 +216,15 @@ func ensureRequiredSpecs(deploymentObject *appsv1.Deployment, dpa *oadpv1alpha1.
 		templateObjectLabels[controlPlaneKey] = controlPlaneLabel[controlPlaneKey]
 		deploymentObject.Spec.Template.SetLabels(templateObjectLabels)
 	}
+	// add custom pod labels
+	removeStalePodConfigKeys(deploymentObject, podConfigLabelsAnnotation, deploymentObject.Spec.Template.Labels, podConfig.Labels, controlPlaneKey)
+	if podConfig.Labels != nil {
+		templateLabels, err := common.AppendUniqueKeyTOfTMaps(deploymentObject.Spec.Template.GetLabels(), podConfig.Labels)
+		if err != nil {
+			return fmt.Errorf("non admin deployment template custom label: %v", err)
+		}
+		deploymentObject.Spec.Template.SetLabels(templateLabels)
+	}
 
 	templateObjectAnnotations := deploymentObject.Spec.Template.GetAnnotations()
 	if templateObjectAnnotations == nil {
@@ -212,6 +233,12 @@ func ensureRequiredSpecs(deploymentObject *appsv1.Deployment, dpa *oadpv1alpha1.
 		templateObjectAnnotations[dpaResourceVersionAnnotation] = podAnnotations[dpaResourceVersionAnnotation]
 		deploymentObject.Spec.Template.SetAnnotations(templateObjectAnnotations)
 	}
+	removeStalePodConfigKeys(deploymentObject, podConfigAnnotationsAnnotation, deploymentObject.Spec.Template.Annotations, podConfig.Annotations, dpaResourceVersionAnnotation)
+	for key, value := range podConfig.Annotations {
+		if key != dpaResourceVersionAnnotation {
+			deploymentObject.Spec.Template.Annotations[key] = value
+		}
+	}
 
 	nonAdminContainerFound := false
 	if len(deploymentObject.Spec.Template.Spec.Containers) == 0 {
@@ -220,6 +247,7 @@ func ensureRequiredSpecs(deploymentObject *appsv1.Deployment, dpa *oadpv1alpha1.
 			Image:                    image,
 			ImagePullPolicy:          imagePullPolicy,
 			Env:                      envVars,
+			Resources:                podConfig.ResourceAllocations,
 			TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
 		}}
 		nonAdminContainerFound = true
@@ -230,6 +258,7 @@ func ensureRequiredSpecs(deploymentObject *appsv1.Deployment, dpa *oadpv1alpha1.
 				nonAdminContainer.Image = image
 				nonAdminContainer.ImagePullPolicy = imagePullPolicy
 				nonAdminContainer.Env = envVars
+				nonAdminContainer.Resources = podConfig.ResourceAllocations
 				nonAdminContainer.TerminationMessagePolicy = corev1.TerminationMessageFallbackToLogsOnError
 				nonAdminContainerFound = true
 				break
@@ -241,9 +270,33 @@ func ensureRequiredSpecs(deploymentObject *appsv1.Deployment, dpa *oadpv1alpha1.
 	}
 	deploymentObject.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyAlways
 	deploymentObject.Spec.Template.Spec.ServiceAccountName = nonAdminObjectName
+	deploymentObject.Spec.Template.Spec.NodeSelector = podConfig.NodeSelector
+	deploymentObject.Spec.Template.Spec.Tolerations = podConfig.Tolerations
+	applyPodSchedulingConfig(&deploymentObject.Spec.Template.Spec, podConfig)
 	return nil
 }
 
+// removeStalePodConfigKeys deletes from the pod template metadata the keys applied from the podConfig, recorded in the
+// trackingAnnotation of the Deployment, which are no longer in the podConfig, and records the keys of the podConfig.
+// The reservedKey set by the operator is never deleted.
+func removeStalePodConfigKeys(deploymentObject *appsv1.Deployment, trackingAnnotation string, metadata map[string]string, podConfigKeys map[string]string, reservedKey string) {
+	if previous := deploymentObject.Annotations[trackingAnnotation]; previous != "" {
+		for _, key := range strings.Split(previous, ",") {
+			if _, found := podConfigKeys[key]; !found && key != reservedKey {
+				delete(metadata, key)
+			}
+		}
+	}
+	if len(podConfigKeys) == 0 {
+		delete(deploymentObject.Annotations, trackingAnnotation)
+		return
+	}
+	if deploymentObject.Annotations == nil {
+		deploymentObject.Annotations = map[string]string{}
+	}
+	deploymentObject.Annotations[trackingAnnotation] = strings.Join(sets.List(sets.KeySet(podConfigKeys)), ",")
+}
+
 func (r *DataProtectionApplicationReconciler) checkNonAdminEnabled() bool {
 	if r.dpa.Spec.NonAdmin != nil && r.dpa.Spec.NonAdmin.Enable != nil {
 		return *r.dpa.Spec.NonAdmin.Enable
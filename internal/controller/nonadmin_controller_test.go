@@ -437,6 +437,66 @@ func TestEnsureRequiredSpecs(t *testing.T) {
 	}
 }
 
+func TestEnsureRequiredSpecs_PodConfigKeysRemoved(t *testing.T) {
+	deployment := createTestDeployment("test-pod-config-keys")
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{ResourceVersion: "1"},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{Velero: &oadpv1alpha1.VeleroConfig{}},
+			NonAdmin: &oadpv1alpha1.NonAdmin{
+				Enable: ptr.To(true),
+				PodConfig: &oadpv1alpha1.PodConfig{
+					Labels:      map[string]string{"team": "backup", "tier": "gold"},
+					Annotations: map[string]string{"example.com/owner": "backup", "example.com/ticket": "1"},
+				},
+			},
+		},
+	}
+	if err := ensureRequiredSpecs(deployment, dpa, defaultNonAdminImage, corev1.PullAlways); err != nil {
+		t.Fatalf("ensureRequiredSpecs() errored out: %v", err)
+	}
+	if deployment.Spec.Template.Labels["tier"] != "gold" || deployment.Spec.Template.Annotations["example.com/ticket"] != "1" {
+		t.Errorf("podConfig labels and annotations not applied: %v %v", deployment.Spec.Template.Labels, deployment.Spec.Template.Annotations)
+	}
+	if deployment.Annotations[podConfigLabelsAnnotation] != "team,tier" {
+		t.Errorf("applied podConfig labels not recorded: %v", deployment.Annotations)
+	}
+
+	// a label set outside of the podConfig is kept
+	deployment.Spec.Template.Labels["external"] = "true"
+	dpa.Spec.NonAdmin.PodConfig = &oadpv1alpha1.PodConfig{
+		Labels:      map[string]string{"team": "backup"},
+		Annotations: map[string]string{"example.com/owner": "backup"},
+	}
+	if err := ensureRequiredSpecs(deployment, dpa, defaultNonAdminImage, corev1.PullAlways); err != nil {
+		t.Fatalf("ensureRequiredSpecs() errored out: %v", err)
+	}
+	if _, found := deployment.Spec.Template.Labels["tier"]; found {
+		t.Errorf("label removed from the podConfig still in the pod template: %v", deployment.Spec.Template.Labels)
+	}
+	if _, found := deployment.Spec.Template.Annotations["example.com/ticket"]; found {
+		t.Errorf("annotation removed from the podConfig still in the pod template: %v", deployment.Spec.Template.Annotations)
+	}
+	if deployment.Spec.Template.Labels["team"] != "backup" || deployment.Spec.Template.Labels["external"] != "true" ||
+		deployment.Spec.Template.Labels[controlPlaneKey] != nonAdminObjectName {
+		t.Errorf("unexpected pod template labels: %v", deployment.Spec.Template.Labels)
+	}
+
+	dpa.Spec.NonAdmin.PodConfig = nil
+	if err := ensureRequiredSpecs(deployment, dpa, defaultNonAdminImage, corev1.PullAlways); err != nil {
+		t.Fatalf("ensureRequiredSpecs() errored out: %v", err)
+	}
+	if _, found := deployment.Spec.Template.Labels["team"]; found {
+		t.Errorf("label of the removed podConfig still in the pod template: %v", deployment.Spec.Template.Labels)
+	}
+	if _, found := deployment.Spec.Template.Annotations["example.com/owner"]; found {
+		t.Errorf("annotation of the removed podConfig still in the pod template: %v", deployment.Spec.Template.Annotations)
+	}
+	if _, found := deployment.Annotations[podConfigLabelsAnnotation]; found {
+		t.Errorf("tracking annotation kept without podConfig labels: %v", deployment.Annotations)
+	}
+}
+
 func TestDPAReconcilerCheckNonAdminEnabled(t *testing.T) {
 	tests := []struct {
 		name   string
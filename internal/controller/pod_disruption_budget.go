@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+// veleroPodDisruptionBudgetName is the name of the PodDisruptionBudget of the Velero Deployment
+const veleroPodDisruptionBudgetName = common.Velero
+
+// veleroPodDisruptionBudgetEnabled returns whether the operator owns a PodDisruptionBudget for the Velero Deployment
+func veleroPodDisruptionBudgetEnabled(dpa *oadpv1alpha1.DataProtectionApplication) bool {
+	if dpa.Spec.Configuration == nil || dpa.Spec.Configuration.Velero == nil {
+		return false
+	}
+	budget := dpa.Spec.Configuration.Velero.PodDisruptionBudget
+	return budget == nil || budget.Enable == nil || *budget.Enable
+}
+
+// protectInProgressOperations returns whether the Velero pod can not be evicted while operations are in progress.
+// It is opt-in: a backup left InProgress by a Velero crash would otherwise block node drains until Velero restarts.
+func protectInProgressOperations(dpa *oadpv1alpha1.DataProtectionApplication) bool {
+	budget := dpa.Spec.Configuration.Velero.PodDisruptionBudget
+	return budget != nil && budget.ProtectInProgressOperations != nil && *budget.ProtectInProgressOperations
+}
+
+// veleroOperationInProgress returns whether the object is a backup or restore in progress. isOperation is false when
+// the object is not a backup or restore.
+func veleroOperationInProgress(object client.Object) (inProgress bool, isOperation bool) {
+	switch operation := object.(type) {
+	case *velerov1.Backup:
+		return operation.Status.Phase == velerov1.BackupPhaseInProgress, true
+	case *velerov1.Restore:
+		return operation.Status.Phase == velerov1.RestorePhaseInProgress, true
+	}
+	return false, false
+}
+
+// veleroOperationsInProgress returns whether a backup or restore of the DPA namespace is in progress
+func (r *DataProtectionApplicationReconciler) veleroOperationsInProgress() (bool, error) {
+	backups := &velerov1.BackupList{}
+	if err := r.List(r.Context, backups, client.InNamespace(r.NamespacedName.Namespace)); err != nil {
+		return false, err
+	}
+	for i := range backups.Items {
+		if inProgress, _ := veleroOperationInProgress(&backups.Items[i]); inProgress {
+			return true, nil
+		}
+	}
+	restores := &velerov1.RestoreList{}
+	if err := r.List(r.Context, restores, client.InNamespace(r.NamespacedName.Namespace)); err != nil {
+		return false, err
+	}
+	for i := range restores.Items {
+		if inProgress, _ := veleroOperationInProgress(&restores.Items[i]); inProgress {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// veleroOperationDPARequests maps a backup or restore to the DPAs of its namespace, so the Velero
+// PodDisruptionBudget follows the operations in progress
+func (r *DataProtectionApplicationReconciler) veleroOperationDPARequests(ctx context.Context, object client.Object) []reconcile.Request {
+	dpaList := &oadpv1alpha1.DataProtectionApplicationList{}
+	if err := r.List(ctx, dpaList, client.InNamespace(object.GetNamespace())); err != nil {
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(dpaList.Items))
+	for _, dpa := range dpaList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name}})
+	}
+	return requests
+}
+
+// ReconcileVeleroPodDisruptionBudget reconciles the PodDisruptionBudget of the Velero Deployment. It allows the
+// eviction of the Velero pod, unless a backup or restore is in progress and protectInProgressOperations is enabled.
+func (r *DataProtectionApplicationReconciler) ReconcileVeleroPodDisruptionBudget(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      veleroPodDisruptionBudgetName,
+			Namespace: r.NamespacedName.Namespace,
+		},
+	}
+
+	if !veleroPodDisruptionBudgetEnabled(dpa) {
+		if err := r.Get(r.Context, client.ObjectKeyFromObject(pdb), pdb); err != nil {
+			if k8serror.IsNotFound(err) {
+				return true, nil
+			}
+			return false, err
+		}
+		if !metav1.IsControlledBy(pdb, dpa) {
+			return true, nil
+		}
+		if err := r.Delete(r.Context, pdb); err != nil && !k8serror.IsNotFound(err) {
+			return false, err
+		}
+		r.EventRecorder.Event(pdb, corev1.EventTypeNormal, "DeletedVeleroPodDisruptionBudget",
+			fmt.Sprintf("Velero PodDisruptionBudget %s/%s deleted", pdb.Namespace, pdb.Name))
+		return true, nil
+	}
+
+	inProgress := false
+	if protectInProgressOperations(dpa) {
+		var err error
+		if inProgress, err = r.veleroOperationsInProgress(); err != nil {
+			return false, err
+		}
+	}
+	maxUnavailable := intstr.FromInt32(1)
+	if inProgress {
+		maxUnavailable = intstr.FromInt32(0)
+	}
+
+	op, err := controllerutil.CreateOrPatch(r.Context, r.Client, pdb, func() error {
+		if err := controllerutil.SetControllerReference(dpa, pdb, r.Scheme); err != nil {
+			return err
+		}
+		pdb.Labels = getDpaAppLabels(dpa)
+		pdb.Spec.Selector = veleroLabelSelector.DeepCopy()
+		pdb.Spec.MinAvailable = nil
+		pdb.Spec.MaxUnavailable = &maxUnavailable
+		pdb.Spec.UnhealthyPodEvictionPolicy = ptr.To(policyv1.AlwaysAllow)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if op == controllerutil.OperationResultCreated || op == controllerutil.OperationResultUpdated {
+		log.Info("Velero PodDisruptionBudget reconciled", "operation", op, "operationsInProgress", inProgress)
+		r.EventRecorder.Event(pdb,
+			corev1.EventTypeNormal,
+			"VeleroPodDisruptionBudgetReconciled",
+			fmt.Sprintf("performed %s on Velero PodDisruptionBudget %s/%s, maxUnavailable %s", op, pdb.Namespace, pdb.Name, maxUnavailable.String()),
+		)
+	}
+	return true, nil
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestDPAReconciler_ReconcileVeleroPodDisruptionBudget(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns", UID: "test-dpa-uid"},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{Velero: &oadpv1alpha1.VeleroConfig{}},
+		},
+	}
+	backup := &velerov1.Backup{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "test-ns"},
+		Status:     velerov1.BackupStatus{Phase: velerov1.BackupPhaseInProgress},
+	}
+	otherNamespaceRestore := &velerov1.Restore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "other-ns"},
+		Status:     velerov1.RestoreStatus{Phase: velerov1.RestorePhaseInProgress},
+	}
+	fakeClient := getFakeClientFromObjectsForTest(t, dpa, backup, otherNamespaceRestore)
+	r := &DataProtectionApplicationReconciler{
+		Client:         fakeClient,
+		Scheme:         fakeClient.Scheme(),
+		dpa:            dpa,
+		Log:            logr.Discard(),
+		Context:        newContextForTest(),
+		NamespacedName: types.NamespacedName{Namespace: dpa.Namespace, Name: dpa.Name},
+		EventRecorder:  newEventRecorder(),
+	}
+	getPDB := func() (*policyv1.PodDisruptionBudget, error) {
+		pdb := &policyv1.PodDisruptionBudget{}
+		err := fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: veleroPodDisruptionBudgetName}, pdb)
+		return pdb, err
+	}
+
+	ok, err := r.ReconcileVeleroPodDisruptionBudget(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	pdb, err := getPDB()
+	require.NoError(t, err)
+	require.Equal(t, veleroLabelSelector, pdb.Spec.Selector)
+	require.Equal(t, ptr.To(intstr.FromInt32(1)), pdb.Spec.MaxUnavailable, "operations in progress are not protected by default")
+	require.True(t, metav1.IsControlledBy(pdb, dpa))
+
+	dpa.Spec.Configuration.Velero.PodDisruptionBudget = &oadpv1alpha1.VeleroPodDisruptionBudget{ProtectInProgressOperations: ptr.To(true)}
+	ok, err = r.ReconcileVeleroPodDisruptionBudget(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	pdb, err = getPDB()
+	require.NoError(t, err)
+	require.Equal(t, ptr.To(intstr.FromInt32(0)), pdb.Spec.MaxUnavailable, "the backup in progress is protected")
+
+	backup.Status.Phase = velerov1.BackupPhaseCompleted
+	require.NoError(t, fakeClient.Update(r.Context, backup))
+	ok, err = r.ReconcileVeleroPodDisruptionBudget(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	pdb, err = getPDB()
+	require.NoError(t, err)
+	require.Equal(t, ptr.To(intstr.FromInt32(1)), pdb.Spec.MaxUnavailable)
+
+	backup.Status.Phase = velerov1.BackupPhaseInProgress
+	require.NoError(t, fakeClient.Update(r.Context, backup))
+	dpa.Spec.Configuration.Velero.PodDisruptionBudget = &oadpv1alpha1.VeleroPodDisruptionBudget{ProtectInProgressOperations: ptr.To(false)}
+	ok, err = r.ReconcileVeleroPodDisruptionBudget(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	pdb, err = getPDB()
+	require.NoError(t, err)
+	require.Equal(t, ptr.To(intstr.FromInt32(1)), pdb.Spec.MaxUnavailable)
+
+	dpa.Spec.Configuration.Velero.PodDisruptionBudget.Enable = ptr.To(false)
+	ok, err = r.ReconcileVeleroPodDisruptionBudget(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, err = getPDB()
+	require.True(t, k8serror.IsNotFound(err))
+}
+
+func TestVeleroPredicate_VeleroOperations(t *testing.T) {
+	scheme, err := getSchemeForFakeClient()
+	require.NoError(t, err)
+	predicate := veleroPredicate(scheme)
+	backup := func(phase velerov1.BackupPhase) *velerov1.Backup {
+		return &velerov1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "test-ns", Generation: 1},
+			Status:     velerov1.BackupStatus{Phase: phase},
+		}
+	}
+
+	require.True(t, predicate.Update(event.UpdateEvent{ObjectOld: backup(velerov1.BackupPhaseNew), ObjectNew: backup(velerov1.BackupPhaseInProgress)}))
+	require.True(t, predicate.Update(event.UpdateEvent{ObjectOld: backup(velerov1.BackupPhaseInProgress), ObjectNew: backup(velerov1.BackupPhaseFailed)}))
+	require.False(t, predicate.Update(event.UpdateEvent{ObjectOld: backup(velerov1.BackupPhaseInProgress), ObjectNew: backup(velerov1.BackupPhaseInProgress)}))
+	require.False(t, predicate.Update(event.UpdateEvent{ObjectOld: backup(velerov1.BackupPhaseCompleted), ObjectNew: backup(velerov1.BackupPhaseDeleting)}))
+	require.False(t, predicate.Create(event.CreateEvent{Object: backup(velerov1.BackupPhaseNew)}))
+
+	restore := &velerov1.Restore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "test-ns"},
+		Status:     velerov1.RestoreStatus{Phase: velerov1.RestorePhaseInProgress},
+	}
+	require.True(t, predicate.Delete(event.DeleteEvent{Object: backup(velerov1.BackupPhaseInProgress)}), "deleting a backup in progress releases the PodDisruptionBudget")
+	require.True(t, predicate.Delete(event.DeleteEvent{Object: restore}))
+	require.True(t, predicate.Delete(event.DeleteEvent{Object: backup(velerov1.BackupPhaseCompleted), DeleteStateUnknown: true}))
+	require.False(t, predicate.Delete(event.DeleteEvent{Object: backup(velerov1.BackupPhaseCompleted)}))
+}
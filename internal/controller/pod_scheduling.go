@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/vmware-tanzu/velero/pkg/util/kube"
+	corev1 "k8s.io/api/core/v1"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+// repositoryMaintenanceGlobalKey is the repository maintenance ConfigMap key applying to all repositories, the only
+// key Velero reads the priorityClassName of maintenance jobs from
+const repositoryMaintenanceGlobalKey = "global"
+
+// applyPodSchedulingConfig sets the priorityClassName, topologySpreadConstraints and affinity of the podConfig on the
+// pod spec, resetting them when the podConfig does not set them
+func applyPodSchedulingConfig(podSpec *corev1.PodSpec, podConfig *oadpv1alpha1.PodConfig) {
+	if podConfig == nil {
+		podConfig = &oadpv1alpha1.PodConfig{}
+	}
+	podSpec.PriorityClassName = podConfig.PriorityClassName
+	podSpec.TopologySpreadConstraints = podConfig.TopologySpreadConstraints
+	podSpec.Affinity = podConfig.Affinity.DeepCopy()
+}
+
+// applyLoadAffinity sets the nodeAffinity derived from the loadAffinity on the pod spec, keeping the pod affinity and
+// anti-affinity of the podConfig
+func applyLoadAffinity(podSpec *corev1.PodSpec, loadAffinity []*oadpv1alpha1.LoadAffinity) {
+	if loadAffinity == nil {
+		return
+	}
+	veleroAffinityStruct := make([]*kube.LoadAffinity, len(loadAffinity))
+	for i, aff := range loadAffinity {
+		veleroAffinityStruct[i] = &kube.LoadAffinity{
+			NodeSelector: aff.NodeSelector,
+		}
+	}
+	affinity := kube.ToSystemAffinity(veleroAffinityStruct)
+	if affinity == nil {
+		return
+	}
+	if podSpec.Affinity == nil {
+		podSpec.Affinity = &corev1.Affinity{}
+	}
+	podSpec.Affinity.NodeAffinity = affinity.NodeAffinity
+}
+
+// validatePodSchedulingConfig checks the podConfig affinity does not conflict with the loadAffinity of the component
+func validatePodSchedulingConfig(field string, podConfig *oadpv1alpha1.PodConfig, loadAffinity []*oadpv1alpha1.LoadAffinity) error {
+	if podConfig == nil || podConfig.Affinity == nil || podConfig.Affinity.NodeAffinity == nil || len(loadAffinity) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s.podConfig.affinity.nodeAffinity can not be set together with %s.loadAffinity", field, field)
+}
+
+// veleroPriorityClassName returns the priorityClassName of the Velero podConfig
+func veleroPriorityClassName(config *oadpv1alpha1.ApplicationConfig) string {
+	if config == nil || config.Velero == nil || config.Velero.PodConfig == nil {
+		return ""
+	}
+	return config.Velero.PodConfig.PriorityClassName
+}
+
+// repositoryMaintenanceConfigs returns the repository maintenance configurations written to the ConfigMap: the DPA
+// ones, with the global configuration defaulting the maintenance job priorityClassName to the Velero one
+func repositoryMaintenanceConfigs(config *oadpv1alpha1.ApplicationConfig) map[string]oadpv1alpha1.RepositoryMaintenanceConfig {
+	if config == nil {
+		return nil
+	}
+	configs := make(map[string]oadpv1alpha1.RepositoryMaintenanceConfig, len(config.RepositoryMaintenance)+1)
+	for key, maintenanceConfig := range config.RepositoryMaintenance {
+		configs[key] = maintenanceConfig
+	}
+	if priorityClassName := veleroPriorityClassName(config); priorityClassName != "" {
+		global := configs[repositoryMaintenanceGlobalKey]
+		if global.PriorityClassName == "" {
+			global.PriorityClassName = priorityClassName
+			configs[repositoryMaintenanceGlobalKey] = global
+		}
+	}
+	return configs
+}
@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/velero/pkg/util/kube"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+var (
+	testTopologySpreadConstraints = []corev1.TopologySpreadConstraint{{
+		MaxSkew:           1,
+		TopologyKey:       corev1.LabelTopologyZone,
+		WhenUnsatisfiable: corev1.ScheduleAnyway,
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "velero"}},
+	}}
+	testPodAntiAffinity = &corev1.PodAntiAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+			Weight: 100,
+			PodAffinityTerm: corev1.PodAffinityTerm{
+				TopologyKey:   corev1.LabelHostname,
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "database"}},
+			},
+		}},
+	}
+)
+
+func TestApplyPodSchedulingConfig(t *testing.T) {
+	podConfig := &oadpv1alpha1.PodConfig{
+		PriorityClassName:         "backup-critical",
+		TopologySpreadConstraints: testTopologySpreadConstraints,
+		Affinity:                  &corev1.Affinity{PodAntiAffinity: testPodAntiAffinity},
+	}
+	podSpec := &corev1.PodSpec{}
+	applyPodSchedulingConfig(podSpec, podConfig)
+	applyLoadAffinity(podSpec, []*oadpv1alpha1.LoadAffinity{
+		{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"backup": "true"}}},
+	})
+	require.Equal(t, "backup-critical", podSpec.PriorityClassName)
+	require.Equal(t, testTopologySpreadConstraints, podSpec.TopologySpreadConstraints)
+	require.Equal(t, testPodAntiAffinity, podSpec.Affinity.PodAntiAffinity)
+	require.Equal(t, kube.ToSystemAffinity([]*kube.LoadAffinity{
+		{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"backup": "true"}}},
+	}).NodeAffinity, podSpec.Affinity.NodeAffinity)
+	require.Nil(t, podConfig.Affinity.NodeAffinity, "the podConfig is not modified")
+
+	applyPodSchedulingConfig(podSpec, nil)
+	applyLoadAffinity(podSpec, nil)
+	require.Empty(t, podSpec.PriorityClassName)
+	require.Nil(t, podSpec.TopologySpreadConstraints)
+	require.Nil(t, podSpec.Affinity)
+}
+
+func TestValidatePodSchedulingConfig(t *testing.T) {
+	loadAffinity := []*oadpv1alpha1.LoadAffinity{
+		{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"backup": "true"}}},
+	}
+	podAntiAffinity := &oadpv1alpha1.PodConfig{Affinity: &corev1.Affinity{PodAntiAffinity: testPodAntiAffinity}}
+	nodeAffinity := &oadpv1alpha1.PodConfig{Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}}}
+
+	require.NoError(t, validatePodSchedulingConfig("spec.configuration.velero", nil, loadAffinity))
+	require.NoError(t, validatePodSchedulingConfig("spec.configuration.velero", podAntiAffinity, loadAffinity))
+	require.NoError(t, validatePodSchedulingConfig("spec.configuration.velero", nodeAffinity, nil))
+	require.EqualError(t, validatePodSchedulingConfig("spec.configuration.velero", nodeAffinity, loadAffinity),
+		"spec.configuration.velero.podConfig.affinity.nodeAffinity can not be set together with spec.configuration.velero.loadAffinity")
+}
+
+func TestRepositoryMaintenanceConfigs(t *testing.T) {
+	perRepository := oadpv1alpha1.RepositoryMaintenanceConfig{
+		LoadAffinityConfig: []*oadpv1alpha1.LoadAffinity{
+			{NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"pool": "maintenance"}}},
+		},
+	}
+	tests := []struct {
+		name   string
+		config *oadpv1alpha1.ApplicationConfig
+		want   map[string]oadpv1alpha1.RepositoryMaintenanceConfig
+	}{
+		{
+			name:   "no configuration",
+			config: &oadpv1alpha1.ApplicationConfig{Velero: &oadpv1alpha1.VeleroConfig{}},
+			want:   map[string]oadpv1alpha1.RepositoryMaintenanceConfig{},
+		},
+		{
+			name: "velero priority class adds a global configuration",
+			config: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{PodConfig: &oadpv1alpha1.PodConfig{PriorityClassName: "backup-critical"}},
+				RepositoryMaintenance: map[string]oadpv1alpha1.RepositoryMaintenanceConfig{
+					"kopia": perRepository,
+				},
+			},
+			want: map[string]oadpv1alpha1.RepositoryMaintenanceConfig{
+				"kopia":                        perRepository,
+				repositoryMaintenanceGlobalKey: {PriorityClassName: "backup-critical"},
+			},
+		},
+		{
+			name: "global priority class is kept",
+			config: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{PodConfig: &oadpv1alpha1.PodConfig{PriorityClassName: "backup-critical"}},
+				RepositoryMaintenance: map[string]oadpv1alpha1.RepositoryMaintenanceConfig{
+					repositoryMaintenanceGlobalKey: {PriorityClassName: "maintenance-low"},
+				},
+			},
+			want: map[string]oadpv1alpha1.RepositoryMaintenanceConfig{
+				repositoryMaintenanceGlobalKey: {PriorityClassName: "maintenance-low"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, repositoryMaintenanceConfigs(tt.config))
+			require.Equal(t, len(tt.want) > 0, isRepositoryMaintenanceCmRequired(tt.config))
+		})
+	}
+}
+
+func TestEnsureRequiredSpecs_PodConfig(t *testing.T) {
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: nonAdminObjectName, Namespace: "test-ns"}}
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{ResourceVersion: "123"},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{Velero: &oadpv1alpha1.VeleroConfig{}},
+			NonAdmin: &oadpv1alpha1.NonAdmin{
+				Enable: ptr.To(true),
+				PodConfig: &oadpv1alpha1.PodConfig{
+					Labels:                    map[string]string{"team": "backup"},
+					NodeSelector:              map[string]string{"node-role.kubernetes.io/infra": ""},
+					Tolerations:               []corev1.Toleration{{Key: "node-role.kubernetes.io/infra", Effect: corev1.TaintEffectNoSchedule}},
+					Env:                       []corev1.EnvVar{{Name: "GOMEMLIMIT", Value: "200MiB"}},
+					PriorityClassName:         "backup-critical",
+					TopologySpreadConstraints: testTopologySpreadConstraints,
+					Affinity:                  &corev1.Affinity{PodAntiAffinity: testPodAntiAffinity},
+				},
+			},
+		},
+	}
+	require.NoError(t, ensureRequiredSpecs(deployment, dpa, defaultNonAdminImage, corev1.PullAlways))
+	podSpec := deployment.Spec.Template.Spec
+	require.Equal(t, "backup", deployment.Spec.Template.Labels["team"])
+	require.Equal(t, dpa.Spec.NonAdmin.PodConfig.NodeSelector, podSpec.NodeSelector)
+	require.Equal(t, dpa.Spec.NonAdmin.PodConfig.Tolerations, podSpec.Tolerations)
+	require.Contains(t, podSpec.Containers[0].Env, corev1.EnvVar{Name: "GOMEMLIMIT", Value: "200MiB"})
+	require.Equal(t, "backup-critical", podSpec.PriorityClassName)
+	require.Equal(t, testTopologySpreadConstraints, podSpec.TopologySpreadConstraints)
+	require.Equal(t, testPodAntiAffinity, podSpec.Affinity.PodAntiAffinity)
+
+	dpa.Spec.NonAdmin.PodConfig = nil
+	require.NoError(t, ensureRequiredSpecs(deployment, dpa, defaultNonAdminImage, corev1.PullAlways))
+	podSpec = deployment.Spec.Template.Spec
+	require.Nil(t, podSpec.NodeSelector)
+	require.Nil(t, podSpec.Tolerations)
+	require.Empty(t, podSpec.PriorityClassName)
+	require.Nil(t, podSpec.Affinity)
+}
@@ -1,6 +1,9 @@
 package controller
 
 import (
+	configv1 "github.com/openshift/api/config/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -13,17 +16,49 @@ func veleroPredicate(scheme *runtime.Scheme) predicate.Predicate {
 	return predicate.Funcs{
 		// Update returns true if the Update event should be processed
 		UpdateFunc: func(e event.UpdateEvent) bool {
+			// backups and restores starting or ending update the Velero PodDisruptionBudget
+			if wasInProgress, isOperation := veleroOperationInProgress(e.ObjectOld); isOperation {
+				inProgress, _ := veleroOperationInProgress(e.ObjectNew)
+				return wasInProgress != inProgress
+			}
+			// nodes and the NodeAgent pods becoming ready update status.nodeAgent
+			if oldNode, isNode := e.ObjectOld.(*corev1.Node); isNode {
+				return nodeAgentNodeChanged(oldNode, e.ObjectNew.(*corev1.Node))
+			}
+			// the cluster proxy and its effective configuration are propagated to the pods
+			if oldProxy, isProxy := e.ObjectOld.(*configv1.Proxy); isProxy {
+				return clusterProxyChanged(oldProxy, e.ObjectNew.(*configv1.Proxy))
+			}
+			if oldDaemonSet, isDaemonSet := e.ObjectOld.(*appsv1.DaemonSet); isDaemonSet && nodeAgentDaemonSetStatusChanged(oldDaemonSet, e.ObjectNew.(*appsv1.DaemonSet)) {
+				return isObjectOurs(scheme, e.ObjectOld)
+			}
 			if e.ObjectOld.GetGeneration() == e.ObjectNew.GetGeneration() {
-				return false
+				// secrets and the trusted CA bundle have no generation, their content changes must still roll out the pods that mount them
+				_, isSecret := e.ObjectNew.(*corev1.Secret)
+				if !isSecret && !isTrustedCABundleConfigMap(e.ObjectNew) || e.ObjectOld.GetResourceVersion() == e.ObjectNew.GetResourceVersion() {
+					return false
+				}
 			}
 			return isObjectOurs(scheme, e.ObjectOld)
 		},
 		// Create returns true if the Create event should be processed
 		CreateFunc: func(e event.CreateEvent) bool {
+			switch e.Object.(type) {
+			case *corev1.Node, *configv1.Proxy:
+				return true
+			}
 			return isObjectOurs(scheme, e.Object)
 		},
 		// Delete returns true if the Delete event should be processed
 		DeleteFunc: func(e event.DeleteEvent) bool {
+			// backups and restores deleted in progress, or whose last state is unknown, release the Velero PodDisruptionBudget
+			if inProgress, isOperation := veleroOperationInProgress(e.Object); isOperation {
+				return inProgress || e.DeleteStateUnknown
+			}
+			switch e.Object.(type) {
+			case *corev1.Node, *configv1.Proxy:
+				return true
+			}
 			return !e.DeleteStateUnknown && isObjectOurs(scheme, e.Object)
 		},
 	}
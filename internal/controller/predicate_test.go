@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestVeleroPredicateUpdate(t *testing.T) {
+	labels := map[string]string{oadpv1alpha1.OadpOperatorLabel: "True"}
+	meta := func(resourceVersion string, generation int64, labels map[string]string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: "obj", Namespace: "test-ns", ResourceVersion: resourceVersion, Generation: generation, Labels: labels}
+	}
+
+	tests := []struct {
+		name   string
+		old    client.Object
+		new    client.Object
+		expect bool
+	}{
+		{
+			name:   "labelled secret content change",
+			old:    &corev1.Secret{ObjectMeta: meta("1", 0, labels)},
+			new:    &corev1.Secret{ObjectMeta: meta("2", 0, labels)},
+			expect: true,
+		},
+		{
+			name: "labelled secret resync",
+			old:  &corev1.Secret{ObjectMeta: meta("1", 0, labels)},
+			new:  &corev1.Secret{ObjectMeta: meta("1", 0, labels)},
+		},
+		{
+			name: "unlabelled secret content change",
+			old:  &corev1.Secret{ObjectMeta: meta("1", 0, nil)},
+			new:  &corev1.Secret{ObjectMeta: meta("2", 0, nil)},
+		},
+		{
+			name: "deployment status change",
+			old:  &appsv1.Deployment{ObjectMeta: meta("1", 1, labels)},
+			new:  &appsv1.Deployment{ObjectMeta: meta("2", 1, labels)},
+		},
+		{
+			name:   "deployment spec change",
+			old:    &appsv1.Deployment{ObjectMeta: meta("1", 1, labels)},
+			new:    &appsv1.Deployment{ObjectMeta: meta("2", 2, labels)},
+			expect: true,
+		},
+		{
+			name:   "node agent daemonset ready pods change",
+			old:    &appsv1.DaemonSet{ObjectMeta: meta("1", 1, labels), Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, NumberReady: 2}},
+			new:    &appsv1.DaemonSet{ObjectMeta: meta("2", 1, labels), Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, NumberReady: 3}},
+			expect: true,
+		},
+		{
+			name: "node agent daemonset observed generation change",
+			old:  &appsv1.DaemonSet{ObjectMeta: meta("1", 1, labels), Status: appsv1.DaemonSetStatus{NumberReady: 3}},
+			new:  &appsv1.DaemonSet{ObjectMeta: meta("2", 1, labels), Status: appsv1.DaemonSetStatus{NumberReady: 3, ObservedGeneration: 1}},
+		},
+		{
+			name: "unlabelled daemonset ready pods change",
+			old:  &appsv1.DaemonSet{ObjectMeta: meta("1", 1, nil), Status: appsv1.DaemonSetStatus{NumberReady: 2}},
+			new:  &appsv1.DaemonSet{ObjectMeta: meta("2", 1, nil), Status: appsv1.DaemonSetStatus{NumberReady: 3}},
+		},
+		{
+			name:   "node labels change",
+			old:    &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", ResourceVersion: "1"}},
+			new:    &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", ResourceVersion: "2", Labels: map[string]string{"backup": "true"}}},
+			expect: true,
+		},
+		{
+			name:   "node taints change",
+			old:    &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", ResourceVersion: "1"}},
+			new:    &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", ResourceVersion: "2"}, Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "dedicated", Effect: corev1.TaintEffectNoSchedule}}}},
+			expect: true,
+		},
+		{
+			name:   "cluster proxy spec change",
+			old:    &configv1.Proxy{ObjectMeta: metav1.ObjectMeta{Name: Cluster, ResourceVersion: "1", Generation: 1}},
+			new:    &configv1.Proxy{ObjectMeta: metav1.ObjectMeta{Name: Cluster, ResourceVersion: "2", Generation: 2}, Spec: configv1.ProxySpec{HTTPSProxy: "http://proxy:3128"}},
+			expect: true,
+		},
+		{
+			name:   "cluster proxy effective configuration change",
+			old:    &configv1.Proxy{ObjectMeta: metav1.ObjectMeta{Name: Cluster, ResourceVersion: "1", Generation: 1}},
+			new:    &configv1.Proxy{ObjectMeta: metav1.ObjectMeta{Name: Cluster, ResourceVersion: "2", Generation: 1}, Status: configv1.ProxyStatus{NoProxy: ".svc"}},
+			expect: true,
+		},
+		{
+			name: "cluster proxy resync",
+			old:  &configv1.Proxy{ObjectMeta: metav1.ObjectMeta{Name: Cluster, ResourceVersion: "1", Generation: 1}},
+			new:  &configv1.Proxy{ObjectMeta: metav1.ObjectMeta{Name: Cluster, ResourceVersion: "2", Generation: 1}},
+		},
+		{
+			name: "node heartbeat",
+			old:  &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", ResourceVersion: "1"}},
+			new:  &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", ResourceVersion: "2"}, Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, veleroPredicate(scheme.Scheme).Update(event.UpdateEvent{ObjectOld: tt.old, ObjectNew: tt.new}))
+		})
+	}
+}
@@ -22,6 +22,7 @@ import (
 	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
 	"github.com/openshift/oadp-operator/pkg/common"
 	"github.com/openshift/oadp-operator/pkg/credentials"
+	"github.com/openshift/oadp-operator/pkg/credentials/azurecreds"
 )
 
 // Registry Env var keys
@@ -309,6 +310,9 @@ func (r *DataProtectionApplicationReconciler) parseAWSSecret(secret corev1.Secre
 		roleArnKey              = "role_arn"
 		webIdentityTokenFileKey = "web_identity_token_file"
 		stsRegionalEndpointsKey = "sts_regional_endpoints"
+		// IAM Roles Anywhere and EKS Pod Identity fields
+		credentialProcessKey = "credential_process"
+		credentialSourceKey  = "credential_source"
 	)
 	if err != nil {
 		return AWSAccessKey, AWSSecretKey, errors.New("parseAWSSecret faulty regex: keyNameRegex")
@@ -334,6 +338,14 @@ func (r *DataProtectionApplicationReconciler) parseAWSSecret(secret corev1.Secre
 	if err != nil {
 		return AWSAccessKey, AWSSecretKey, errors.New("parseAWSSecret faulty regex: stsRegionalEndpointsRegex")
 	}
+	credentialProcessRegex, err := regexp.Compile(`\b` + credentialProcessKey + `\b`)
+	if err != nil {
+		return AWSAccessKey, AWSSecretKey, errors.New("parseAWSSecret faulty regex: credentialProcessRegex")
+	}
+	credentialSourceRegex, err := regexp.Compile(`\b` + credentialSourceKey + `\b`)
+	if err != nil {
+		return AWSAccessKey, AWSSecretKey, errors.New("parseAWSSecret faulty regex: credentialSourceRegex")
+	}
 	for index, line := range splitString {
 		if line == "" {
 			continue
@@ -373,6 +385,13 @@ func (r *DataProtectionApplicationReconciler) parseAWSSecret(secret corev1.Secre
 						// but don't error out
 						return "", "", nil
 					}
+					// credential_process (IAM Roles Anywhere) and credential_source (EKS Pod Identity) profiles
+					// get their credentials in the Velero pod, like STS profiles
+					if credentialProcessRegex.MatchString(profLine) || credentialSourceRegex.MatchString(profLine) {
+						hasStsFields = true
+						r.Log.Info(fmt.Sprintf("Detected credential process or credential source in profile %s", matchProfile))
+						return "", "", nil
+					}
 				}
 
 				// If not an STS profile, continue with normal AWS credential parsing
@@ -436,93 +455,18 @@ func (r *DataProtectionApplicationReconciler) parseAWSSecret(secret corev1.Secre
 }
 
 func (r *DataProtectionApplicationReconciler) parseAzureSecret(secret corev1.Secret, secretKey string) (azureCredentials, error) {
-
-	azcreds := azureCredentials{}
-
-	splitString := strings.Split(string(secret.Data[secretKey]), "\n")
-	keyNameRegex, err := regexp.Compile(`\[.*\]`) //ignore lines such as [default]
-	if err != nil {
-		return azcreds, errors.New("parseAzureSecret faulty regex: keyNameRegex")
-	}
-	azureStorageKeyRegex, err := regexp.Compile(`\bAZURE_STORAGE_ACCOUNT_ACCESS_KEY\b`)
-	if err != nil {
-		return azcreds, errors.New("parseAzureSecret faulty regex: azureStorageKeyRegex")
-	}
-	azureTenantIdRegex, err := regexp.Compile(`\bAZURE_TENANT_ID\b`)
-	if err != nil {
-		return azcreds, errors.New("parseAzureSecret faulty regex: azureTenantIdRegex")
-	}
-	azureClientIdRegex, err := regexp.Compile(`\bAZURE_CLIENT_ID\b`)
-	if err != nil {
-		return azcreds, errors.New("parseAzureSecret faulty regex: azureClientIdRegex")
-	}
-	azureClientSecretRegex, err := regexp.Compile(`\bAZURE_CLIENT_SECRET\b`)
-	if err != nil {
-		return azcreds, errors.New("parseAzureSecret faulty regex: azureClientSecretRegex")
-	}
-	azureResourceGroupRegex, err := regexp.Compile(`\bAZURE_RESOURCE_GROUP\b`)
-	if err != nil {
-		return azcreds, errors.New("parseAzureSecret faulty regex: azureResourceGroupRegex")
-	}
-	azureSubscriptionIdRegex, err := regexp.Compile(`\bAZURE_SUBSCRIPTION_ID\b`)
-	if err != nil {
-		return azcreds, errors.New("parseAzureSecret faulty regex: azureSubscriptionIdRegex")
-	}
-	for _, line := range splitString {
-		if line == "" {
-			continue
-		}
-		if keyNameRegex.MatchString(line) {
-			continue
-		}
-		// check for storage key
-		matchedStorageKey := azureStorageKeyRegex.MatchString(line)
-		matchedSubscriptionId := azureSubscriptionIdRegex.MatchString(line)
-		matchedTenantId := azureTenantIdRegex.MatchString(line)
-		matchedCliendId := azureClientIdRegex.MatchString(line)
-		matchedClientsecret := azureClientSecretRegex.MatchString(line)
-		matchedResourceGroup := azureResourceGroupRegex.MatchString(line)
-
-		switch {
-		case matchedStorageKey:
-			storageKeyValue, err := r.getMatchedKeyValue("AZURE_STORAGE_ACCOUNT_ACCESS_KEY", line)
-			if err != nil {
-				return azcreds, err
-			}
-			azcreds.strorageAccountKey = storageKeyValue
-		case matchedSubscriptionId:
-			subscriptionIdValue, err := r.getMatchedKeyValue("AZURE_SUBSCRIPTION_ID", line)
-			if err != nil {
-				return azcreds, err
-			}
-			azcreds.subscriptionID = subscriptionIdValue
-		case matchedCliendId:
-			clientIdValue, err := r.getMatchedKeyValue("AZURE_CLIENT_ID", line)
-			if err != nil {
-				return azcreds, err
-			}
-			azcreds.clientID = clientIdValue
-		case matchedClientsecret:
-			clientSecretValue, err := r.getMatchedKeyValue("AZURE_CLIENT_SECRET", line)
-			if err != nil {
-				return azcreds, err
-			}
-			azcreds.clientSecret = clientSecretValue
-		case matchedResourceGroup:
-			resourceGroupValue, err := r.getMatchedKeyValue("AZURE_RESOURCE_GROUP", line)
-			if err != nil {
-				return azcreds, err
-			}
-			azcreds.resourceGroup = resourceGroupValue
-		case matchedTenantId:
-			tenantIdValue, err := r.getMatchedKeyValue("AZURE_TENANT_ID", line)
-			if err != nil {
-				return azcreds, err
-			}
-			azcreds.tenantID = tenantIdValue
-		}
-	}
-	return azcreds, nil
+	creds := azurecreds.Parse(secret.Data, secretKey)
+	if err := creds.Validate(); err != nil {
+		return azureCredentials{}, err
+	}
+	return azureCredentials{
+		subscriptionID:     creds.SubscriptionID,
+		tenantID:           creds.TenantID,
+		clientID:           creds.ClientID,
+		clientSecret:       creds.ClientSecret,
+		resourceGroup:      creds.ResourceGroupName,
+		strorageAccountKey: creds.StorageAccountKey,
+	}, nil
 }
 
 // Return value to the right of = sign with quotations and spaces removed.
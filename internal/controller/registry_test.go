@@ -633,6 +633,24 @@ func TestDPAReconciler_parseAWSSecret(t *testing.T) {
 			wantSecretKey: "",
 			wantErr:       false,
 		},
+		{
+			name: "successful parse with IAM Roles Anywhere profile",
+			secret: corev1.Secret{
+				Data: map[string][]byte{"cloud": []byte("[default]\ncredential_process = aws_signing_helper credential-process --certificate /c/tls.crt --private-key /c/tls.key --trust-anchor-arn ta --profile-arn p --role-arn r\n")},
+			},
+			secretKey:    "cloud",
+			matchProfile: "default",
+			wantErr:      false,
+		},
+		{
+			name: "successful parse with EKS Pod Identity profile",
+			secret: corev1.Secret{
+				Data: map[string][]byte{"cloud": []byte("[default]\ncredential_source = EcsContainer\n")},
+			},
+			secretKey:    "cloud",
+			matchProfile: "default",
+			wantErr:      false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -697,6 +715,17 @@ func TestDPAReconciler_parseAzureSecret(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "client secret without tenant ID",
+			secret: corev1.Secret{
+				Data: map[string][]byte{
+					"cloud": []byte("AZURE_CLIENT_ID=" + testClientID + "\n" +
+						"AZURE_CLIENT_SECRET=" + testClientSecret),
+				},
+			},
+			secretKey: "cloud",
+			wantErr:   true,
+		},
 	}
 
 	for _, tt := range tests {
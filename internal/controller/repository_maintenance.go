@@ -17,7 +17,7 @@ import (
 )
 
 func isRepositoryMaintenanceCmRequired(config *oadpv1alpha1.ApplicationConfig) bool {
-	return config != nil && config.RepositoryMaintenance != nil && len(config.RepositoryMaintenance) > 0
+	return len(repositoryMaintenanceConfigs(config)) > 0
 }
 
 // updateRepositoryMaintenanceCM handles the creation or update of the RepositoryMaintenance ConfigMap with all required data.
@@ -42,7 +42,7 @@ func (r *DataProtectionApplicationReconciler) updateRepositoryMaintenanceCM(cm *
 	// TODO: This implementation of ConfigMap is different from the Node-Agent
 	// to to match the upstream implementation
 	// https://github.com/vmware-tanzu/velero/issues/9159
-	for key, config := range r.dpa.Spec.Configuration.RepositoryMaintenance {
+	for key, config := range repositoryMaintenanceConfigs(r.dpa.Spec.Configuration) {
 		configJSON, err := json.Marshal(config)
 		if err != nil {
 			return fmt.Errorf("failed to serialize repository maintenance config for key %s: %w", key, err)
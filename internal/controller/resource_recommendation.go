@@ -0,0 +1,361 @@
+package controller
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+const (
+	// resourceRecommendationSampleInterval is the time between two usage samples
+	resourceRecommendationSampleInterval = 5 * time.Minute
+	// resourceRecommendationWindow is the duration of a usage window, recommendations derive from the peaks of the
+	// current and previous windows so usage older than two windows is forgotten
+	resourceRecommendationWindow = 7 * 24 * time.Hour
+	// resourceRecommendationMinSamples is the number of samples, one hour of usage, before a recommendation is applied
+	resourceRecommendationMinSamples = 12
+	// resourceRecommendationMargin is added to the peak usage for the recommended requests
+	resourceRecommendationMargin = 0.15
+	// resourceRecommendationMemoryLimitRatio is the recommended memory limit relative to the request, leaving room
+	// for the bursts of large Kopia uploads
+	resourceRecommendationMemoryLimitRatio = 2
+	// resourceRecommendationTolerance is the relative change of a recommended value below which the published
+	// recommendation is kept, so pods are not restarted for small usage variations
+	resourceRecommendationTolerance = 0.1
+)
+
+// podMetricsListGVK is the metrics API list of pod usage
+var podMetricsListGVK = schema.GroupVersionKind{Group: "metrics.k8s.io", Version: "v1beta1", Kind: "PodMetricsList"}
+
+// resourceRecommendationsEnabled returns whether spec.resourceRecommendations is enabled
+func resourceRecommendationsEnabled(dpa *oadpv1alpha1.DataProtectionApplication) bool {
+	return dpa.Spec.ResourceRecommendations != nil && dpa.Spec.ResourceRecommendations.Enable
+}
+
+// resourceBounds returns the admin bounds of the recommendations of a component
+func resourceBounds(dpa *oadpv1alpha1.DataProtectionApplication, component string) *oadpv1alpha1.ResourceBounds {
+	if dpa.Spec.ResourceRecommendations == nil {
+		return nil
+	}
+	if component == common.NodeAgent {
+		return dpa.Spec.ResourceRecommendations.NodeAgent
+	}
+	return dpa.Spec.ResourceRecommendations.Velero
+}
+
+// resourceRecommendation returns the recommendation status of a component, nil when there is none
+func resourceRecommendation(dpa *oadpv1alpha1.DataProtectionApplication, component string) *oadpv1alpha1.ResourceRecommendationStatus {
+	for i := range dpa.Status.ResourceRecommendations {
+		if dpa.Status.ResourceRecommendations[i].Component == component {
+			return &dpa.Status.ResourceRecommendations[i]
+		}
+	}
+	return nil
+}
+
+// applyResourceRecommendation replaces the requests and limits of reqs by the applied recommendation of the component
+func applyResourceRecommendation(dpa *oadpv1alpha1.DataProtectionApplication, component string, reqs corev1.ResourceRequirements) corev1.ResourceRequirements {
+	if !resourceRecommendationsEnabled(dpa) || !dpa.Spec.ResourceRecommendations.Apply {
+		return reqs
+	}
+	recommendation := resourceRecommendation(dpa, component)
+	if recommendation == nil || !recommendation.Applied {
+		return reqs
+	}
+	bounds := resourceBounds(dpa, component)
+	if reqs.Requests == nil {
+		reqs.Requests = corev1.ResourceList{}
+	}
+	for name, quantity := range recommendation.Requests {
+		reqs.Requests[name] = boundQuantity(name, quantity, bounds)
+	}
+	for name, quantity := range recommendation.Limits {
+		if reqs.Limits == nil {
+			reqs.Limits = corev1.ResourceList{}
+		}
+		limit := boundQuantity(name, quantity, bounds)
+		if request, found := reqs.Requests[name]; found && limit.Cmp(request) < 0 {
+			limit = request
+		}
+		reqs.Limits[name] = limit
+	}
+	return reqs
+}
+
+// boundQuantity clamps the quantity of a resource to the bounds
+func boundQuantity(name corev1.ResourceName, quantity resource.Quantity, bounds *oadpv1alpha1.ResourceBounds) resource.Quantity {
+	if bounds == nil {
+		return quantity
+	}
+	if minimum, found := bounds.MinAllowed[name]; found && quantity.Cmp(minimum) < 0 {
+		return minimum.DeepCopy()
+	}
+	if maximum, found := bounds.MaxAllowed[name]; found && quantity.Cmp(maximum) > 0 {
+		return maximum.DeepCopy()
+	}
+	return quantity
+}
+
+// recommendResources returns the requests and limits recommended for a peak usage: the peak with a margin as
+// requests, twice the memory request as memory limit, and for the NodeAgent the Kopia cache as ephemeral storage
+// request. CPU is not limited so data movement can use idle CPU.
+func recommendResources(peak corev1.ResourceList, cacheLimitMB *int64, bounds *oadpv1alpha1.ResourceBounds) (corev1.ResourceList, corev1.ResourceList) {
+	requests := corev1.ResourceList{}
+	limits := corev1.ResourceList{}
+	if cpu, found := peak[corev1.ResourceCPU]; found {
+		milliCPU := int64(math.Ceil(float64(cpu.MilliValue()) * (1 + resourceRecommendationMargin)))
+		requests[corev1.ResourceCPU] = boundQuantity(corev1.ResourceCPU, *resource.NewMilliQuantity(milliCPU, resource.DecimalSI), bounds)
+	}
+	if memory, found := peak[corev1.ResourceMemory]; found {
+		request := boundQuantity(corev1.ResourceMemory, mebibytes(float64(memory.Value())*(1+resourceRecommendationMargin)), bounds)
+		requests[corev1.ResourceMemory] = request
+		limit := boundQuantity(corev1.ResourceMemory, mebibytes(float64(request.Value())*resourceRecommendationMemoryLimitRatio), bounds)
+		if limit.Cmp(request) < 0 {
+			limit = request
+		}
+		limits[corev1.ResourceMemory] = limit
+	}
+	if cacheLimitMB != nil && *cacheLimitMB > 0 {
+		cache := mebibytes(float64(*cacheLimitMB*1024*1024) * (1 + resourceRecommendationMargin))
+		requests[corev1.ResourceEphemeralStorage] = boundQuantity(corev1.ResourceEphemeralStorage, cache, bounds)
+	}
+	return requests, limits
+}
+
+// mebibytes returns the bytes rounded up to a whole number of MiB
+func mebibytes(bytes float64) resource.Quantity {
+	const mebibyte = 1024 * 1024
+	return *resource.NewQuantity(int64(math.Ceil(bytes/mebibyte))*mebibyte, resource.BinarySI)
+}
+
+// resourceListsClose returns whether the lists have the same resources and each value of current is within the
+// tolerance of the value of published
+func resourceListsClose(published, current corev1.ResourceList) bool {
+	if len(published) != len(current) {
+		return false
+	}
+	for name, quantity := range current {
+		previous, found := published[name]
+		if !found {
+			return false
+		}
+		previousValue := previous.AsApproximateFloat64()
+		if math.Abs(quantity.AsApproximateFloat64()-previousValue) > previousValue*resourceRecommendationTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// maxResourceList returns the highest value of each resource of the lists
+func maxResourceList(lists ...corev1.ResourceList) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for _, list := range lists {
+		for name, quantity := range list {
+			if current, found := result[name]; !found || quantity.Cmp(current) > 0 {
+				result[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	return result
+}
+
+// containerUsage returns the highest cpu and memory usage of the container across the pods, nil without metrics
+func containerUsage(podMetrics *unstructured.UnstructuredList, pods map[string]bool, container string) (corev1.ResourceList, error) {
+	var usage corev1.ResourceList
+	for _, metrics := range podMetrics.Items {
+		if !pods[metrics.GetName()] {
+			continue
+		}
+		containers, _, err := unstructured.NestedSlice(metrics.Object, "containers")
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range containers {
+			containerMetrics, ok := c.(map[string]interface{})
+			if !ok || containerMetrics["name"] != container {
+				continue
+			}
+			containerUsage, _, err := unstructured.NestedStringMap(containerMetrics, "usage")
+			if err != nil {
+				return nil, err
+			}
+			sample := corev1.ResourceList{}
+			for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+				if value, found := containerUsage[string(name)]; found {
+					quantity, err := resource.ParseQuantity(value)
+					if err != nil {
+						return nil, fmt.Errorf("invalid %s usage of pod %s: %w", name, metrics.GetName(), err)
+					}
+					sample[name] = quantity
+				}
+			}
+			usage = maxResourceList(usage, sample)
+		}
+	}
+	return usage, nil
+}
+
+// oomKilledUsage returns the memory limit of the container as its memory usage when its last termination, in one of
+// the pods, was an out of memory kill, so the usage peaks between two samples are not missed. Nil without such pods.
+func oomKilledUsage(pods []corev1.Pod, container string) corev1.ResourceList {
+	var usage corev1.ResourceList
+	for _, pod := range pods {
+		var limit *resource.Quantity
+		for _, c := range pod.Spec.Containers {
+			if quantity, found := c.Resources.Limits[corev1.ResourceMemory]; c.Name == container && found {
+				limit = &quantity
+			}
+		}
+		if limit == nil {
+			continue
+		}
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			terminated := containerStatus.LastTerminationState.Terminated
+			if containerStatus.Name == container && terminated != nil && terminated.Reason == "OOMKilled" {
+				usage = maxResourceList(usage, corev1.ResourceList{corev1.ResourceMemory: *limit})
+			}
+		}
+	}
+	return usage
+}
+
+// ReconcileResourceRecommendations samples the usage of the Velero and NodeAgent containers from the metrics API
+// every resourceRecommendationSampleInterval and publishes the recommended requests and limits in status.
+// getVeleroResourceReqs and getNodeAgentResourceReqs use the recommendations once applied. Applying or updating a
+// recommendation restarts the pods, so it waits until no backup or restore is in progress.
+func (r *DataProtectionApplicationReconciler) ReconcileResourceRecommendations(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	if !resourceRecommendationsEnabled(dpa) {
+		dpa.Status.ResourceRecommendations = nil
+		return true, nil
+	}
+	now := r.now()
+	if len(dpa.Status.ResourceRecommendations) > 0 {
+		lastSample := dpa.Status.ResourceRecommendations[0].LastSample
+		if lastSample != nil && now.Sub(lastSample.Time) < resourceRecommendationSampleInterval {
+			return true, nil
+		}
+	}
+
+	podMetrics := &unstructured.UnstructuredList{}
+	podMetrics.SetGroupVersionKind(podMetricsListGVK)
+	if err := r.List(r.Context, podMetrics, client.InNamespace(dpa.Namespace)); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			log.Info("spec.resourceRecommendations requires the metrics API, metrics.k8s.io is not available in the cluster")
+			return true, nil
+		}
+		return false, fmt.Errorf("unable to list pod metrics for resource recommendations: %w", err)
+	}
+
+	components := []struct {
+		name          string
+		labels        map[string]string
+		enabled       bool
+		kopiaSettings bool
+	}{
+		{name: common.Velero, labels: veleroLabelSelector.MatchLabels, enabled: true},
+		{name: common.NodeAgent, labels: nodeAgentMatchLabels, enabled: isNodeAgentEnabled(dpa), kopiaSettings: true},
+	}
+	deferApply := false
+	if dpa.Spec.ResourceRecommendations.Apply {
+		inProgress, err := r.veleroOperationsInProgress()
+		if err != nil {
+			return false, fmt.Errorf("unable to list backups and restores for resource recommendations: %w", err)
+		}
+		deferApply = inProgress
+	}
+	var statuses []oadpv1alpha1.ResourceRecommendationStatus
+	for _, component := range components {
+		if !component.enabled {
+			continue
+		}
+		pods := &corev1.PodList{}
+		if err := r.List(r.Context, pods, client.InNamespace(dpa.Namespace), client.MatchingLabels(component.labels)); err != nil {
+			return false, fmt.Errorf("unable to list %s pods for resource recommendations: %w", component.name, err)
+		}
+		podNames := map[string]bool{}
+		for _, pod := range pods.Items {
+			podNames[pod.Name] = true
+		}
+		usage, err := containerUsage(podMetrics, podNames, component.name)
+		if err != nil {
+			return false, err
+		}
+		usage = maxResourceList(usage, oomKilledUsage(pods.Items, component.name))
+
+		status := oadpv1alpha1.ResourceRecommendationStatus{Component: component.name, WindowStart: metav1.NewTime(now)}
+		if previous := resourceRecommendation(dpa, component.name); previous != nil {
+			status = *previous.DeepCopy()
+		}
+		if len(usage) == 0 {
+			// no running pod reports usage yet, keep the recommendation
+			statuses = append(statuses, status)
+			continue
+		}
+		if now.Sub(status.WindowStart.Time) >= resourceRecommendationWindow {
+			status.PreviousPeak = status.Peak
+			status.Peak = nil
+			status.WindowStart = metav1.NewTime(now)
+		}
+		status.Peak = maxResourceList(status.Peak, usage)
+		status.Samples++
+		status.LastSample = &metav1.Time{Time: now}
+
+		var cacheLimitMB *int64
+		if component.kopiaSettings {
+			cacheLimitMB = dpa.Spec.Configuration.NodeAgent.CacheLimitMB
+		}
+		requests, limits := recommendResources(maxResourceList(status.Peak, status.PreviousPeak), cacheLimitMB, resourceBounds(dpa, component.name))
+		if !resourceListsClose(status.Requests, requests) || !resourceListsClose(status.Limits, limits) {
+			if status.Applied && deferApply {
+				log.Info("Deferring the updated resource recommendation while a backup or restore is in progress", "component", component.name)
+			} else {
+				status.Requests = requests
+				status.Limits = limits
+				if status.Applied {
+					r.EventRecorder.Event(dpa, corev1.EventTypeNormal, "ResourceRecommendationUpdated",
+						fmt.Sprintf("applying updated resource recommendation to %s", component.name))
+				}
+			}
+		}
+		applied := dpa.Spec.ResourceRecommendations.Apply && status.Samples >= resourceRecommendationMinSamples && (status.Applied || !deferApply)
+		if applied && !status.Applied {
+			r.EventRecorder.Event(dpa, corev1.EventTypeNormal, "ResourceRecommendationApplied",
+				fmt.Sprintf("applying resource recommendation to %s", component.name))
+		}
+		status.Applied = applied
+		statuses = append(statuses, status)
+	}
+
+	dpa.Status.ResourceRecommendations = statuses
+	return true, nil
+}
+
+// resourceRecommendationRequeueAfter returns the time until the next usage sample, zero when recommendations are
+// disabled
+func (r *DataProtectionApplicationReconciler) resourceRecommendationRequeueAfter() time.Duration {
+	if r.dpa == nil || !resourceRecommendationsEnabled(r.dpa) {
+		return 0
+	}
+	if len(r.dpa.Status.ResourceRecommendations) == 0 || r.dpa.Status.ResourceRecommendations[0].LastSample == nil {
+		return resourceRecommendationSampleInterval
+	}
+	remaining := resourceRecommendationSampleInterval - r.now().Sub(r.dpa.Status.ResourceRecommendations[0].LastSample.Time)
+	if remaining < time.Second {
+		return time.Second
+	}
+	return remaining
+}
@@ -0,0 +1,320 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func resourceStrings(list corev1.ResourceList) map[corev1.ResourceName]string {
+	strings := map[corev1.ResourceName]string{}
+	for name, quantity := range list {
+		strings[name] = quantity.String()
+	}
+	return strings
+}
+
+func TestRecommendResources(t *testing.T) {
+	peak := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("200m"),
+		corev1.ResourceMemory: resource.MustParse("100Mi"),
+	}
+	requests, limits := recommendResources(peak, ptr.To(int64(1024)), nil)
+	require.Equal(t, "230m", ptr.To(requests[corev1.ResourceCPU]).String())
+	require.Equal(t, "115Mi", ptr.To(requests[corev1.ResourceMemory]).String())
+	require.Equal(t, "1178Mi", ptr.To(requests[corev1.ResourceEphemeralStorage]).String(), "the Kopia cache with a margin")
+	require.Equal(t, "230Mi", ptr.To(limits[corev1.ResourceMemory]).String())
+	require.NotContains(t, limits, corev1.ResourceCPU)
+
+	requests, limits = recommendResources(peak, nil, &oadpv1alpha1.ResourceBounds{
+		MinAllowed: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+		MaxAllowed: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("200Mi")},
+	})
+	require.Equal(t, "500m", ptr.To(requests[corev1.ResourceCPU]).String())
+	require.Equal(t, "115Mi", ptr.To(requests[corev1.ResourceMemory]).String())
+	require.Equal(t, "200Mi", ptr.To(limits[corev1.ResourceMemory]).String())
+	require.NotContains(t, requests, corev1.ResourceEphemeralStorage)
+}
+
+func TestResourceListsClose(t *testing.T) {
+	published := corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("100Mi")}
+	require.True(t, resourceListsClose(published, corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("109Mi")}))
+	require.False(t, resourceListsClose(published, corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("111Mi")}))
+	require.False(t, resourceListsClose(published, corev1.ResourceList{
+		corev1.ResourceMemory: resource.MustParse("100Mi"),
+		corev1.ResourceCPU:    resource.MustParse("100m"),
+	}))
+}
+
+func TestApplyResourceRecommendation(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			ResourceRecommendations: &oadpv1alpha1.ResourceRecommendations{
+				Enable: true,
+				Apply:  true,
+				NodeAgent: &oadpv1alpha1.ResourceBounds{
+					MaxAllowed: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+			},
+		},
+		Status: oadpv1alpha1.DataProtectionApplicationStatus{
+			ResourceRecommendations: []oadpv1alpha1.ResourceRecommendationStatus{
+				{
+					Component: common.NodeAgent,
+					Requests:  corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+					Limits:    corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+					Applied:   true,
+				},
+				{
+					Component: common.Velero,
+					Requests:  corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+				},
+			},
+		},
+	}
+	reqs := applyResourceRecommendation(dpa, common.NodeAgent, *defaultContainerResourceRequirements.DeepCopy())
+	require.Equal(t, corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+		Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+	}, reqs, "recommendations are bounded when the bounds change")
+	require.Equal(t, defaultContainerResourceRequirements, applyResourceRecommendation(dpa, common.Velero, *defaultContainerResourceRequirements.DeepCopy()),
+		"a recommendation is used once applied")
+
+	dpa.Spec.ResourceRecommendations.Apply = false
+	require.Equal(t, defaultContainerResourceRequirements, applyResourceRecommendation(dpa, common.NodeAgent, *defaultContainerResourceRequirements.DeepCopy()))
+}
+
+func TestDPAReconciler_ReconcileResourceRecommendations(t *testing.T) {
+	newPodMetrics := func(name, container, cpu, memory string) *unstructured.Unstructured {
+		podMetrics := &unstructured.Unstructured{Object: map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{
+				"name":  container,
+				"usage": map[string]interface{}{"cpu": cpu, "memory": memory},
+			}},
+		}}
+		podMetrics.SetGroupVersionKind(podMetricsListGVK.GroupVersion().WithKind("PodMetrics"))
+		podMetrics.SetName(name)
+		podMetrics.SetNamespace("test-ns")
+		return podMetrics
+	}
+	newPod := func(name string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns", Labels: labels}}
+	}
+
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns"},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{},
+				NodeAgent: &oadpv1alpha1.NodeAgentConfig{
+					NodeAgentCommonFields: oadpv1alpha1.NodeAgentCommonFields{Enable: ptr.To(true)},
+					KopiaRepoOptions:      oadpv1alpha1.KopiaRepoOptions{CacheLimitMB: ptr.To(int64(2048))},
+				},
+			},
+			ResourceRecommendations: &oadpv1alpha1.ResourceRecommendations{Enable: true, Apply: true},
+		},
+	}
+	veleroMetrics := newPodMetrics("velero-abc", common.Velero, "100m", "200Mi")
+	nodeAgentMetrics := newPodMetrics("node-agent-a", common.NodeAgent, "1", "1Gi")
+	fakeClient := getFakeClientFromObjectsForTest(t,
+		dpa,
+		newPod("velero-abc", veleroLabelSelector.MatchLabels),
+		newPod("node-agent-a", nodeAgentMatchLabels),
+		newPod("node-agent-b", nodeAgentMatchLabels),
+		veleroMetrics,
+		nodeAgentMetrics,
+		newPodMetrics("node-agent-b", common.NodeAgent, "500m", "2Gi"),
+		newPodMetrics("other", common.NodeAgent, "8", "16Gi"),
+	)
+	start := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	fakeClock := clocktesting.NewFakePassiveClock(start)
+	r := &DataProtectionApplicationReconciler{
+		Client:        fakeClient,
+		Scheme:        fakeClient.Scheme(),
+		dpa:           dpa,
+		Log:           logr.Discard(),
+		Context:       newContextForTest(),
+		EventRecorder: newEventRecorder(),
+		Clock:         fakeClock,
+	}
+	reconcile := func() {
+		ok, err := r.ReconcileResourceRecommendations(r.Log)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	reconcile()
+	nodeAgent := resourceRecommendation(dpa, common.NodeAgent)
+	require.NotNil(t, nodeAgent)
+	require.Equal(t, corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("1"),
+		corev1.ResourceMemory: resource.MustParse("2Gi"),
+	}, nodeAgent.Peak, "the highest usage across the NodeAgent pods")
+	require.Equal(t, map[corev1.ResourceName]string{
+		corev1.ResourceCPU:              "1150m",
+		corev1.ResourceMemory:           "2356Mi",
+		corev1.ResourceEphemeralStorage: "2356Mi",
+	}, resourceStrings(nodeAgent.Requests))
+	require.False(t, nodeAgent.Applied)
+	require.Equal(t, 1, nodeAgent.Samples)
+	require.Equal(t, resourceRecommendationSampleInterval, r.resourceRecommendationRequeueAfter())
+
+	// no new sample before the interval
+	fakeClock.SetTime(start.Add(time.Minute))
+	reconcile()
+	require.Equal(t, 1, resourceRecommendation(dpa, common.NodeAgent).Samples)
+	require.Equal(t, 4*time.Minute, r.resourceRecommendationRequeueAfter())
+
+	for i := 1; i < resourceRecommendationMinSamples; i++ {
+		fakeClock.SetTime(start.Add(time.Duration(i) * resourceRecommendationSampleInterval))
+		reconcile()
+	}
+	velero := resourceRecommendation(dpa, common.Velero)
+	require.True(t, velero.Applied)
+	reqs, err := r.getVeleroResourceReqs()
+	require.NoError(t, err)
+	require.Equal(t, "115m", ptr.To(reqs.Requests[corev1.ResourceCPU]).String())
+	require.Equal(t, "230Mi", ptr.To(reqs.Requests[corev1.ResourceMemory]).String())
+	require.Equal(t, "460Mi", ptr.To(reqs.Limits[corev1.ResourceMemory]).String())
+
+	// small variations keep the published recommendation
+	veleroMetrics.Object["containers"] = []interface{}{map[string]interface{}{
+		"name": common.Velero, "usage": map[string]interface{}{"cpu": "105m", "memory": "200Mi"},
+	}}
+	require.NoError(t, fakeClient.Update(r.Context, veleroMetrics))
+	fakeClock.SetTime(start.Add(resourceRecommendationMinSamples * resourceRecommendationSampleInterval))
+	reconcile()
+	require.Equal(t, "105m", ptr.To(resourceRecommendation(dpa, common.Velero).Peak[corev1.ResourceCPU]).String())
+	require.Equal(t, "115m", ptr.To(resourceRecommendation(dpa, common.Velero).Requests[corev1.ResourceCPU]).String())
+
+	// usage older than two windows is forgotten
+	nodeAgentMetrics.Object["containers"] = []interface{}{map[string]interface{}{
+		"name": common.NodeAgent, "usage": map[string]interface{}{"cpu": "100m", "memory": "100Mi"},
+	}}
+	require.NoError(t, fakeClient.Update(r.Context, nodeAgentMetrics))
+	require.NoError(t, fakeClient.Delete(r.Context, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "node-agent-b", Namespace: "test-ns"}}))
+	fakeClock.SetTime(start.Add(resourceRecommendationWindow))
+	reconcile()
+	nodeAgent = resourceRecommendation(dpa, common.NodeAgent)
+	require.Equal(t, "2Gi", ptr.To(nodeAgent.PreviousPeak[corev1.ResourceMemory]).String())
+	require.Equal(t, "100Mi", ptr.To(nodeAgent.Peak[corev1.ResourceMemory]).String())
+	require.Equal(t, "2356Mi", ptr.To(nodeAgent.Requests[corev1.ResourceMemory]).String(), "the previous window still counts")
+	fakeClock.SetTime(start.Add(2 * resourceRecommendationWindow))
+	reconcile()
+	require.Equal(t, "115Mi", ptr.To(resourceRecommendation(dpa, common.NodeAgent).Requests[corev1.ResourceMemory]).String())
+
+	dpa.Spec.ResourceRecommendations.Enable = false
+	reconcile()
+	require.Nil(t, dpa.Status.ResourceRecommendations)
+	require.Zero(t, r.resourceRecommendationRequeueAfter())
+}
+
+func TestDPAReconciler_ReconcileResourceRecommendations_DeferredDuringOperations(t *testing.T) {
+	start := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns"},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration:           &oadpv1alpha1.ApplicationConfig{Velero: &oadpv1alpha1.VeleroConfig{}},
+			ResourceRecommendations: &oadpv1alpha1.ResourceRecommendations{Enable: true, Apply: true},
+		},
+		Status: oadpv1alpha1.DataProtectionApplicationStatus{
+			ResourceRecommendations: []oadpv1alpha1.ResourceRecommendationStatus{{
+				Component:   common.Velero,
+				Requests:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("115m"), corev1.ResourceMemory: resource.MustParse("230Mi")},
+				Limits:      corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("460Mi")},
+				Peak:        corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("200Mi")},
+				WindowStart: metav1.NewTime(start),
+				LastSample:  &metav1.Time{Time: start},
+				Samples:     resourceRecommendationMinSamples - 1,
+			}},
+		},
+	}
+	// the Velero container was killed out of memory at its 1Gi limit, between two usage samples
+	veleroPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "velero-abc", Namespace: "test-ns", Labels: veleroLabelSelector.MatchLabels},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:      common.Velero,
+			Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}},
+		}}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:                 common.Velero,
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled"}},
+		}}},
+	}
+	veleroMetrics := &unstructured.Unstructured{Object: map[string]interface{}{
+		"containers": []interface{}{map[string]interface{}{
+			"name":  common.Velero,
+			"usage": map[string]interface{}{"cpu": "100m", "memory": "200Mi"},
+		}},
+	}}
+	veleroMetrics.SetGroupVersionKind(podMetricsListGVK.GroupVersion().WithKind("PodMetrics"))
+	veleroMetrics.SetName("velero-abc")
+	veleroMetrics.SetNamespace("test-ns")
+	backup := &velerov1.Backup{
+		ObjectMeta: metav1.ObjectMeta{Name: "daily", Namespace: "test-ns"},
+		Status:     velerov1.BackupStatus{Phase: velerov1.BackupPhaseInProgress},
+	}
+	fakeClient := getFakeClientFromObjectsForTest(t, dpa, veleroPod, veleroMetrics, backup)
+	fakeClock := clocktesting.NewFakePassiveClock(start)
+	r := &DataProtectionApplicationReconciler{
+		Client:         fakeClient,
+		Scheme:         fakeClient.Scheme(),
+		dpa:            dpa,
+		Log:            logr.Discard(),
+		Context:        newContextForTest(),
+		NamespacedName: types.NamespacedName{Namespace: "test-ns", Name: "test-dpa"},
+		EventRecorder:  newEventRecorder(),
+		Clock:          fakeClock,
+	}
+	reconcile := func(offset time.Duration) *oadpv1alpha1.ResourceRecommendationStatus {
+		fakeClock.SetTime(start.Add(offset))
+		ok, err := r.ReconcileResourceRecommendations(r.Log)
+		require.NoError(t, err)
+		require.True(t, ok)
+		return resourceRecommendation(dpa, common.Velero)
+	}
+
+	velero := reconcile(resourceRecommendationSampleInterval)
+	require.Equal(t, "1Gi", ptr.To(velero.Peak[corev1.ResourceMemory]).String(), "the limit of the out of memory kill")
+	require.Equal(t, "1178Mi", ptr.To(velero.Requests[corev1.ResourceMemory]).String())
+	require.False(t, velero.Applied, "not applied while the backup is in progress")
+
+	backup.Status.Phase = velerov1.BackupPhaseCompleted
+	require.NoError(t, fakeClient.Update(r.Context, backup))
+	velero = reconcile(2 * resourceRecommendationSampleInterval)
+	require.True(t, velero.Applied)
+
+	// a higher usage during a restore keeps the applied recommendation until the restore ends
+	require.NoError(t, fakeClient.Create(r.Context, &velerov1.Restore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "test-ns"},
+		Status:     velerov1.RestoreStatus{Phase: velerov1.RestorePhaseInProgress},
+	}))
+	veleroMetrics.Object["containers"] = []interface{}{map[string]interface{}{
+		"name": common.Velero, "usage": map[string]interface{}{"cpu": "1", "memory": "200Mi"},
+	}}
+	require.NoError(t, fakeClient.Update(r.Context, veleroMetrics))
+	velero = reconcile(3 * resourceRecommendationSampleInterval)
+	require.Equal(t, "1", ptr.To(velero.Peak[corev1.ResourceCPU]).String())
+	require.Equal(t, "115m", ptr.To(velero.Requests[corev1.ResourceCPU]).String())
+	require.True(t, velero.Applied)
+
+	require.NoError(t, fakeClient.Delete(r.Context, &velerov1.Restore{ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "test-ns"}}))
+	velero = reconcile(4 * resourceRecommendationSampleInterval)
+	require.Equal(t, "1150m", ptr.To(velero.Requests[corev1.ResourceCPU]).String())
+}
@@ -0,0 +1,751 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/robfig/cron/v3"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	"github.com/vmware-tanzu/velero/pkg/util/collections"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+const (
+	// RestoreVerificationLabel is set on the scratch namespaces, Restores and check pods of a RestoreVerification,
+	// its value is the RestoreVerification name
+	RestoreVerificationLabel = "oadp.openshift.io/restore-verification"
+	// RestoreVerificationNamespaceLabel is set on the scratch namespaces, its value is the RestoreVerification namespace
+	RestoreVerificationNamespaceLabel = "oadp.openshift.io/restore-verification-namespace"
+	// restoreVerificationFinalizer deletes the scratch namespaces of a deleted RestoreVerification
+	restoreVerificationFinalizer = "oadp.openshift.io/restore-verification-cleanup"
+	// restoreVerificationPollInterval is how often a verification in progress is checked
+	restoreVerificationPollInterval = 10 * time.Second
+	// defaultRestoreVerificationTimeout is the readiness and check timeout when unset
+	defaultRestoreVerificationTimeout = 10 * time.Minute
+)
+
+var (
+	restoreVerificationLabels = []string{"namespace", "name"}
+
+	restoreVerificationLastRun = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "oadp_restore_verification_last_run_timestamp_seconds",
+		Help: "Completion time of the last restore verification",
+	}, restoreVerificationLabels)
+	restoreVerificationLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "oadp_restore_verification_last_success_timestamp_seconds",
+		Help: "Completion time of the last successful restore verification",
+	}, restoreVerificationLabels)
+	restoreVerificationLastSucceeded = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "oadp_restore_verification_last_run_succeeded",
+		Help: "1 when the last restore verification succeeded, 0 when it failed",
+	}, restoreVerificationLabels)
+	restoreVerificationLastDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "oadp_restore_verification_last_run_duration_seconds",
+		Help: "Duration of the last restore verification, from its start to its result",
+	}, restoreVerificationLabels)
+	restoreVerificationRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "oadp_restore_verification_runs_total",
+		Help: "Number of restore verifications, by result",
+	}, []string{"namespace", "name", "result"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(restoreVerificationLastRun, restoreVerificationLastSuccess, restoreVerificationLastSucceeded,
+		restoreVerificationLastDuration, restoreVerificationRuns)
+}
+
+// RestoreVerificationReconciler reconciles a RestoreVerification object
+type RestoreVerificationReconciler struct {
+	client.Client
+	Scheme            *runtime.Scheme
+	EventRecorder     record.EventRecorder
+	ClusterWideClient client.Client
+	// Clock is the time source of the verification runs, the real clock when nil
+	Clock clock.PassiveClock
+}
+
+//+kubebuilder:rbac:groups=oadp.openshift.io,resources=restoreverifications,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=oadp.openshift.io,resources=restoreverifications/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=oadp.openshift.io,resources=restoreverifications/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch
+
+func (r *RestoreVerificationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("restoreverification", req.NamespacedName)
+
+	verification := &oadpv1alpha1.RestoreVerification{}
+	if err := r.Get(ctx, req.NamespacedName, verification); err != nil {
+		if apierrors.IsNotFound(err) {
+			deleteRestoreVerificationMetrics(req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if !verification.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalize(ctx, verification)
+	}
+	if controllerutil.AddFinalizer(verification, restoreVerificationFinalizer) {
+		if err := r.Update(ctx, verification); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	var requeueAfter time.Duration
+	err := validateRestoreVerification(verification)
+	if err != nil {
+		apimeta.SetStatusCondition(&verification.Status.Conditions, metav1.Condition{
+			Type:    oadpv1alpha1.ConditionVerified,
+			Status:  metav1.ConditionFalse,
+			Reason:  oadpv1alpha1.VerifiedReasonInvalidSpec,
+			Message: err.Error(),
+		})
+		// the spec must change to fix the error
+		err = nil
+	} else {
+		switch verification.Status.Phase {
+		case "":
+			requeueAfter, err = r.startRun(ctx, logger, verification)
+		case oadpv1alpha1.RestoreVerificationPhaseRestoring:
+			requeueAfter, err = r.checkRestore(ctx, verification)
+		case oadpv1alpha1.RestoreVerificationPhaseWaitingForWorkloads:
+			requeueAfter, err = r.checkWorkloads(ctx, verification)
+		case oadpv1alpha1.RestoreVerificationPhaseRunningChecks:
+			requeueAfter, err = r.checkPods(ctx, verification)
+		case oadpv1alpha1.RestoreVerificationPhaseCleaningUp:
+			requeueAfter, err = r.cleanUp(ctx, verification)
+		}
+	}
+	if err != nil {
+		logger.Error(err, "Error verifying the restore", "phase", verification.Status.Phase)
+	}
+	if statusErr := r.Status().Update(ctx, verification); statusErr != nil && err == nil {
+		err = statusErr
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, err
+}
+
+// validateRestoreVerification validates the cron expression and the checks of the spec
+func validateRestoreVerification(verification *oadpv1alpha1.RestoreVerification) error {
+	if verification.Spec.Schedule != "" {
+		if _, err := cron.ParseStandard(verification.Spec.Schedule); err != nil {
+			return fmt.Errorf("spec.schedule is invalid: %w", err)
+		}
+	}
+	names := map[string]bool{}
+	for _, check := range verification.Spec.Checks {
+		if names[check.Name] {
+			return fmt.Errorf("spec.checks name %q is not unique", check.Name)
+		}
+		names[check.Name] = true
+	}
+	return nil
+}
+
+// nextRestoreVerificationRun returns when the next run of a cron schedule starts, the zero time without schedule
+func nextRestoreVerificationRun(verification *oadpv1alpha1.RestoreVerification) time.Time {
+	if verification.Spec.Schedule == "" {
+		return time.Time{}
+	}
+	schedule, err := cron.ParseStandard(verification.Spec.Schedule)
+	if err != nil {
+		return time.Time{}
+	}
+	last := verification.CreationTimestamp.Time
+	if verification.Status.LastRun != nil {
+		last = verification.Status.LastRun.StartTime.Time
+	}
+	return schedule.Next(last)
+}
+
+// latestScheduleBackup returns the completed backup of the Schedule with the latest completion, nil without one
+func (r *RestoreVerificationReconciler) latestScheduleBackup(ctx context.Context, verification *oadpv1alpha1.RestoreVerification) (*velerov1.Backup, error) {
+	backups := &velerov1.BackupList{}
+	if err := r.List(ctx, backups, client.InNamespace(verification.Namespace),
+		client.MatchingLabels{velerov1.ScheduleNameLabel: verification.Spec.ScheduleName}); err != nil {
+		return nil, err
+	}
+	var latest *velerov1.Backup
+	for i := range backups.Items {
+		backup := &backups.Items[i]
+		if backup.Status.Phase != velerov1.BackupPhaseCompleted || backup.Status.CompletionTimestamp == nil {
+			continue
+		}
+		if latest == nil || backup.Status.CompletionTimestamp.After(latest.Status.CompletionTimestamp.Time) {
+			latest = backup
+		}
+	}
+	return latest, nil
+}
+
+// scratchNamespaceName returns the scratch namespace of a namespace of the backup, a hash replaces the end of the
+// names longer than a namespace name
+func scratchNamespaceName(verification *oadpv1alpha1.RestoreVerification, namespace string) string {
+	name := verification.Name + "-" + namespace
+	if len(name) <= validation.DNS1123LabelMaxLength {
+		return name
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(name))
+	suffix := fmt.Sprintf("-%08x", hash.Sum32())
+	return strings.TrimRight(name[:validation.DNS1123LabelMaxLength-len(suffix)], "-") + suffix
+}
+
+// restoreVerificationNamespaces returns the namespaces of the backup to restore. The namespaces listed in the spec
+// must be included in the backup, an empty restore of a namespace missing from the backup would verify nothing.
+func restoreVerificationNamespaces(verification *oadpv1alpha1.RestoreVerification, backup *velerov1.Backup) ([]string, error) {
+	if len(verification.Spec.IncludedNamespaces) > 0 {
+		backupNamespaces := collections.NewIncludesExcludes().Includes(backup.Spec.IncludedNamespaces...).Excludes(backup.Spec.ExcludedNamespaces...)
+		for _, namespace := range verification.Spec.IncludedNamespaces {
+			if !backupNamespaces.ShouldInclude(namespace) {
+				return nil, fmt.Errorf("namespace %s is not included in backup %s", namespace, backup.Name)
+			}
+		}
+		return verification.Spec.IncludedNamespaces, nil
+	}
+	if len(backup.Spec.IncludedNamespaces) == 0 {
+		return nil, fmt.Errorf("backup %s includes all namespaces, spec.includedNamespaces must list the namespaces to restore", backup.Name)
+	}
+	for _, namespace := range backup.Spec.IncludedNamespaces {
+		if strings.ContainsAny(namespace, "*?[{") {
+			return nil, fmt.Errorf("backup %s includes namespaces by wildcard, spec.includedNamespaces must list the namespaces to restore", backup.Name)
+		}
+	}
+	return backup.Spec.IncludedNamespaces, nil
+}
+
+// startRun starts a verification of the latest backup of the Schedule when one is due: with a cron schedule at the
+// next run time, else when the Schedule has a completed backup not verified yet
+func (r *RestoreVerificationReconciler) startRun(ctx context.Context, logger logr.Logger, verification *oadpv1alpha1.RestoreVerification) (time.Duration, error) {
+	now := r.now()
+	next := nextRestoreVerificationRun(verification)
+	verification.Status.NextRunTime = nil
+	if !next.IsZero() {
+		verification.Status.NextRunTime = &metav1.Time{Time: next}
+		if now.Before(next) {
+			return next.Sub(now), nil
+		}
+	}
+
+	backup, err := r.latestScheduleBackup(ctx, verification)
+	if err != nil {
+		return 0, err
+	}
+	if next.IsZero() && (backup == nil || verification.Status.LastRun != nil && verification.Status.LastRun.Backup == backup.Name) {
+		// the next completed backup of the Schedule starts a run
+		return 0, nil
+	}
+
+	// the scratch namespaces kept by a failed run are deleted first
+	kept, err := r.scratchNamespaces(ctx, verification)
+	if err != nil {
+		return 0, err
+	}
+	if len(kept) > 0 {
+		return restoreVerificationPollInterval, r.deleteNamespaces(ctx, kept)
+	}
+	if err := r.deletePreviousRestores(ctx, verification); err != nil {
+		return 0, err
+	}
+
+	run := &oadpv1alpha1.RestoreVerificationRun{StartTime: metav1.Time{Time: now}}
+	verification.Status.CurrentRun = run
+	verification.Status.NextRunTime = nil
+	if backup == nil {
+		return r.finishRun(verification, oadpv1alpha1.RestoreVerificationResultFailed,
+			fmt.Sprintf("Schedule %s has no completed backup", verification.Spec.ScheduleName)), nil
+	}
+	run.Backup = backup.Name
+	namespaces, err := restoreVerificationNamespaces(verification, backup)
+	if err != nil {
+		return r.finishRun(verification, oadpv1alpha1.RestoreVerificationResultFailed, err.Error()), nil
+	}
+	run.NamespaceMapping = map[string]string{}
+	for _, namespace := range namespaces {
+		run.NamespaceMapping[namespace] = scratchNamespaceName(verification, namespace)
+	}
+
+	// the scratch namespaces are created before the restore, so that they are labeled as scratch namespaces and do
+	// not carry the labels selecting the namespaces to back up
+	clusterClient := r.clusterClient()
+	for _, namespace := range namespaces {
+		scratch := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name: run.NamespaceMapping[namespace],
+			Labels: map[string]string{
+				RestoreVerificationLabel:          verification.Name,
+				RestoreVerificationNamespaceLabel: verification.Namespace,
+			},
+		}}
+		if err := clusterClient.Create(ctx, scratch); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				return r.finishRun(verification, oadpv1alpha1.RestoreVerificationResultFailed,
+					fmt.Sprintf("scratch namespace %s already exists", scratch.Name)), nil
+			}
+			return 0, err
+		}
+	}
+
+	restore := &velerov1.Restore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", verification.Name, now.UTC().Format("20060102150405")),
+			Namespace: verification.Namespace,
+			Labels:    map[string]string{RestoreVerificationLabel: verification.Name},
+		},
+		Spec: velerov1.RestoreSpec{
+			BackupName:         backup.Name,
+			IncludedNamespaces: namespaces,
+			NamespaceMapping:   run.NamespaceMapping,
+			RestorePVs:         ptr.To(true),
+		},
+	}
+	if err := controllerutil.SetControllerReference(verification, restore, r.Scheme); err != nil {
+		return 0, err
+	}
+	if err := r.Create(ctx, restore); err != nil {
+		// the run fails so that its scratch namespaces are deleted and the next run starts over
+		logger.Error(err, "unable to create the restore of the verification", "restore", restore.Name)
+		return r.finishRun(verification, oadpv1alpha1.RestoreVerificationResultFailed,
+			fmt.Sprintf("unable to create restore %s: %v", restore.Name, err)), nil
+	}
+	run.Restore = restore.Name
+	verification.Status.Phase = oadpv1alpha1.RestoreVerificationPhaseRestoring
+	logger.Info("Started restore verification", "backup", backup.Name, "restore", restore.Name)
+	r.EventRecorder.Event(verification, corev1.EventTypeNormal, "RestoreVerificationStarted",
+		fmt.Sprintf("restoring backup %s into the scratch namespaces with restore %s", backup.Name, restore.Name))
+	return restoreVerificationPollInterval, nil
+}
+
+// checkRestore waits for the completion of the restore
+func (r *RestoreVerificationReconciler) checkRestore(ctx context.Context, verification *oadpv1alpha1.RestoreVerification) (time.Duration, error) {
+	run := verification.Status.CurrentRun
+	restore := &velerov1.Restore{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: verification.Namespace, Name: run.Restore}, restore); err != nil {
+		if apierrors.IsNotFound(err) {
+			return r.finishRun(verification, oadpv1alpha1.RestoreVerificationResultFailed, fmt.Sprintf("restore %s was deleted", run.Restore)), nil
+		}
+		return 0, err
+	}
+	switch restore.Status.Phase {
+	case velerov1.RestorePhaseCompleted:
+		if restore.Status.Progress == nil || restore.Status.Progress.ItemsRestored == 0 {
+			return r.finishRun(verification, oadpv1alpha1.RestoreVerificationResultFailed, fmt.Sprintf("restore %s restored no items", run.Restore)), nil
+		}
+		run.RestoreCompletionTime = &metav1.Time{Time: r.now()}
+		verification.Status.Phase = oadpv1alpha1.RestoreVerificationPhaseWaitingForWorkloads
+		return time.Second, nil
+	case velerov1.RestorePhasePartiallyFailed, velerov1.RestorePhaseFailed, velerov1.RestorePhaseFailedValidation:
+		message := fmt.Sprintf("restore %s %s with %d errors", run.Restore, restore.Status.Phase, restore.Status.Errors)
+		if restore.Status.FailureReason != "" {
+			message += ": " + restore.Status.FailureReason
+		}
+		return r.finishRun(verification, oadpv1alpha1.RestoreVerificationResultFailed, message), nil
+	}
+	return restoreVerificationPollInterval, nil
+}
+
+// notReadyWorkloads returns the Deployments, StatefulSets and DaemonSets of the scratch namespaces which are not ready
+func (r *RestoreVerificationReconciler) notReadyWorkloads(ctx context.Context, run *oadpv1alpha1.RestoreVerificationRun) ([]string, error) {
+	clusterClient := r.clusterClient()
+	var notReady []string
+	for _, namespace := range sortedValues(run.NamespaceMapping) {
+		deployments := &appsv1.DeploymentList{}
+		if err := clusterClient.List(ctx, deployments, client.InNamespace(namespace)); err != nil {
+			return nil, err
+		}
+		for _, deployment := range deployments.Items {
+			replicas := ptr.Deref(deployment.Spec.Replicas, 1)
+			if deployment.Status.ObservedGeneration < deployment.Generation || deployment.Status.AvailableReplicas < replicas {
+				notReady = append(notReady, fmt.Sprintf("Deployment %s/%s %d/%d available", namespace, deployment.Name, deployment.Status.AvailableReplicas, replicas))
+			}
+		}
+		statefulSets := &appsv1.StatefulSetList{}
+		if err := clusterClient.List(ctx, statefulSets, client.InNamespace(namespace)); err != nil {
+			return nil, err
+		}
+		for _, statefulSet := range statefulSets.Items {
+			replicas := ptr.Deref(statefulSet.Spec.Replicas, 1)
+			if statefulSet.Status.ObservedGeneration < statefulSet.Generation || statefulSet.Status.ReadyReplicas < replicas {
+				notReady = append(notReady, fmt.Sprintf("StatefulSet %s/%s %d/%d ready", namespace, statefulSet.Name, statefulSet.Status.ReadyReplicas, replicas))
+			}
+		}
+		daemonSets := &appsv1.DaemonSetList{}
+		if err := clusterClient.List(ctx, daemonSets, client.InNamespace(namespace)); err != nil {
+			return nil, err
+		}
+		for _, daemonSet := range daemonSets.Items {
+			if daemonSet.Status.ObservedGeneration < daemonSet.Generation || daemonSet.Status.NumberReady < daemonSet.Status.DesiredNumberScheduled {
+				notReady = append(notReady, fmt.Sprintf("DaemonSet %s/%s %d/%d ready", namespace, daemonSet.Name, daemonSet.Status.NumberReady, daemonSet.Status.DesiredNumberScheduled))
+			}
+		}
+	}
+	return notReady, nil
+}
+
+// checkWorkloads waits for the restored workloads to be ready and starts the check pods
+func (r *RestoreVerificationReconciler) checkWorkloads(ctx context.Context, verification *oadpv1alpha1.RestoreVerification) (time.Duration, error) {
+	run := verification.Status.CurrentRun
+	notReady, err := r.notReadyWorkloads(ctx, run)
+	if err != nil {
+		return 0, err
+	}
+	now := r.now()
+	if len(notReady) > 0 {
+		timeout := durationOrDefault(verification.Spec.ReadinessTimeout, defaultRestoreVerificationTimeout)
+		if now.Sub(run.RestoreCompletionTime.Time) > timeout {
+			return r.finishRun(verification, oadpv1alpha1.RestoreVerificationResultFailed,
+				fmt.Sprintf("workloads not ready after %s: %s", timeout, strings.Join(notReady, ", "))), nil
+		}
+		return restoreVerificationPollInterval, nil
+	}
+	run.WorkloadsReadyTime = &metav1.Time{Time: now}
+	if len(verification.Spec.Checks) == 0 {
+		return r.finishRun(verification, oadpv1alpha1.RestoreVerificationResultSucceeded, "restored workloads are ready"), nil
+	}
+
+	clusterClient := r.clusterClient()
+	run.Checks = nil
+	for _, check := range verification.Spec.Checks {
+		pod, err := restoreVerificationCheckPod(verification, check)
+		if err != nil {
+			return r.finishRun(verification, oadpv1alpha1.RestoreVerificationResultFailed, err.Error()), nil
+		}
+		if err := clusterClient.Create(ctx, pod); err != nil && !apierrors.IsAlreadyExists(err) {
+			return 0, err
+		}
+		run.Checks = append(run.Checks, oadpv1alpha1.RestoreVerificationCheckStatus{Name: check.Name, Pod: pod.Namespace + "/" + pod.Name})
+	}
+	verification.Status.Phase = oadpv1alpha1.RestoreVerificationPhaseRunningChecks
+	return restoreVerificationPollInterval, nil
+}
+
+// restoreVerificationCheckPod returns the pod of a check, in the scratch namespace of its namespace. The pod follows
+// the restricted pod security standard.
+func restoreVerificationCheckPod(verification *oadpv1alpha1.RestoreVerification, check oadpv1alpha1.RestoreVerificationCheck) (*corev1.Pod, error) {
+	run := verification.Status.CurrentRun
+	source := check.Namespace
+	if source == "" {
+		source = sortedKeys(run.NamespaceMapping)[0]
+	}
+	scratch, found := run.NamespaceMapping[source]
+	if !found {
+		return nil, fmt.Errorf("check %s namespace %s is not restored", check.Name, source)
+	}
+	env := append([]corev1.EnvVar{
+		{Name: "SOURCE_NAMESPACE", Value: source},
+		{Name: "SCRATCH_NAMESPACE", Value: scratch},
+	}, check.Env...)
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      verification.Name + "-check-" + check.Name,
+			Namespace: scratch,
+			Labels:    map[string]string{RestoreVerificationLabel: verification.Name},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:                corev1.RestartPolicyNever,
+			AutomountServiceAccountToken: ptr.To(false),
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   ptr.To(true),
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+			Containers: []corev1.Container{{
+				Name:    "check",
+				Image:   check.Image,
+				Command: check.Command,
+				Args:    check.Args,
+				Env:     env,
+				SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: ptr.To(false),
+					Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				},
+				TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+			}},
+		},
+	}, nil
+}
+
+// checkPods waits for the check pods to complete
+func (r *RestoreVerificationReconciler) checkPods(ctx context.Context, verification *oadpv1alpha1.RestoreVerification) (time.Duration, error) {
+	run := verification.Status.CurrentRun
+	clusterClient := r.clusterClient()
+	timedOut := r.now().Sub(run.WorkloadsReadyTime.Time) > durationOrDefault(verification.Spec.CheckTimeout, defaultRestoreVerificationTimeout)
+	pending := false
+	var failed []string
+	for i := range run.Checks {
+		check := &run.Checks[i]
+		if check.Result == "" {
+			namespace, name, _ := strings.Cut(check.Pod, "/")
+			pod := &corev1.Pod{}
+			err := clusterClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, pod)
+			switch {
+			case apierrors.IsNotFound(err):
+				check.Result, check.Message = oadpv1alpha1.RestoreVerificationResultFailed, "check pod was deleted"
+			case err != nil:
+				return 0, err
+			case pod.Status.Phase == corev1.PodSucceeded:
+				check.Result = oadpv1alpha1.RestoreVerificationResultSucceeded
+			case pod.Status.Phase == corev1.PodFailed:
+				check.Result, check.Message = oadpv1alpha1.RestoreVerificationResultFailed, podTerminationMessage(pod)
+			case timedOut:
+				check.Result, check.Message = oadpv1alpha1.RestoreVerificationResultFailed, "check pod did not complete in time"
+			default:
+				pending = true
+			}
+		}
+		if check.Result == oadpv1alpha1.RestoreVerificationResultFailed {
+			failed = append(failed, check.Name)
+		}
+	}
+	if pending {
+		return restoreVerificationPollInterval, nil
+	}
+	if len(failed) > 0 {
+		return r.finishRun(verification, oadpv1alpha1.RestoreVerificationResultFailed, "checks failed: "+strings.Join(failed, ", ")), nil
+	}
+	return r.finishRun(verification, oadpv1alpha1.RestoreVerificationResultSucceeded, "restored workloads are ready and all checks succeeded"), nil
+}
+
+// podTerminationMessage returns the termination message of the container of a failed pod, else its reason
+func podTerminationMessage(pod *corev1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if terminated := status.State.Terminated; terminated != nil {
+			if message := strings.TrimSpace(terminated.Message); message != "" {
+				return message
+			}
+			return fmt.Sprintf("exit code %d: %s", terminated.ExitCode, terminated.Reason)
+		}
+	}
+	if pod.Status.Message != "" {
+		return pod.Status.Message
+	}
+	return "check pod failed"
+}
+
+// finishRun records the result of the current run, its event and metrics, and starts the deletion of the scratch
+// namespaces, kept on failure when keepOnFailure is set
+func (r *RestoreVerificationReconciler) finishRun(verification *oadpv1alpha1.RestoreVerification, result oadpv1alpha1.RestoreVerificationResult, message string) time.Duration {
+	run := verification.Status.CurrentRun
+	now := r.now()
+	run.Result = result
+	run.Message = message
+	run.CompletionTime = &metav1.Time{Time: now}
+
+	condition := metav1.Condition{
+		Type:    oadpv1alpha1.ConditionVerified,
+		Status:  metav1.ConditionTrue,
+		Reason:  oadpv1alpha1.VerifiedReasonSucceeded,
+		Message: message,
+	}
+	eventType := corev1.EventTypeNormal
+	if result == oadpv1alpha1.RestoreVerificationResultSucceeded {
+		verification.Status.LastSuccessfulRunTime = run.CompletionTime.DeepCopy()
+	} else {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = oadpv1alpha1.VerifiedReasonFailed
+		eventType = corev1.EventTypeWarning
+	}
+	apimeta.SetStatusCondition(&verification.Status.Conditions, condition)
+	r.EventRecorder.Event(verification, eventType, "RestoreVerification"+string(result),
+		fmt.Sprintf("verification of backup %s %s: %s", run.Backup, strings.ToLower(string(result)), message))
+	setRestoreVerificationMetrics(verification)
+
+	if result == oadpv1alpha1.RestoreVerificationResultFailed && verification.Spec.KeepOnFailure {
+		verification.Status.LastRun = run
+		verification.Status.CurrentRun = nil
+		verification.Status.Phase = ""
+		return time.Second
+	}
+	verification.Status.Phase = oadpv1alpha1.RestoreVerificationPhaseCleaningUp
+	return time.Second
+}
+
+// cleanUp deletes the scratch namespaces of the current run and waits for their deletion
+func (r *RestoreVerificationReconciler) cleanUp(ctx context.Context, verification *oadpv1alpha1.RestoreVerification) (time.Duration, error) {
+	namespaces, err := r.scratchNamespaces(ctx, verification)
+	if err != nil {
+		return 0, err
+	}
+	if len(namespaces) > 0 {
+		return restoreVerificationPollInterval, r.deleteNamespaces(ctx, namespaces)
+	}
+	verification.Status.LastRun = verification.Status.CurrentRun
+	verification.Status.CurrentRun = nil
+	verification.Status.Phase = ""
+	return time.Second, nil
+}
+
+// scratchNamespaces returns the scratch namespaces of the verification
+func (r *RestoreVerificationReconciler) scratchNamespaces(ctx context.Context, verification *oadpv1alpha1.RestoreVerification) ([]corev1.Namespace, error) {
+	namespaces := &corev1.NamespaceList{}
+	if err := r.clusterClient().List(ctx, namespaces, client.MatchingLabels{
+		RestoreVerificationLabel:          verification.Name,
+		RestoreVerificationNamespaceLabel: verification.Namespace,
+	}); err != nil {
+		return nil, err
+	}
+	return namespaces.Items, nil
+}
+
+// deleteNamespaces deletes the namespaces not being deleted yet
+func (r *RestoreVerificationReconciler) deleteNamespaces(ctx context.Context, namespaces []corev1.Namespace) error {
+	for i := range namespaces {
+		if namespaces[i].DeletionTimestamp != nil {
+			continue
+		}
+		if err := r.clusterClient().Delete(ctx, &namespaces[i]); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// deletePreviousRestores deletes the Restores of the previous runs, the Restore of the last run is kept until the
+// next run for troubleshooting
+func (r *RestoreVerificationReconciler) deletePreviousRestores(ctx context.Context, verification *oadpv1alpha1.RestoreVerification) error {
+	restores := &velerov1.RestoreList{}
+	if err := r.List(ctx, restores, client.InNamespace(verification.Namespace), client.MatchingLabels{RestoreVerificationLabel: verification.Name}); err != nil {
+		return err
+	}
+	for i := range restores.Items {
+		if !metav1.IsControlledBy(&restores.Items[i], verification) {
+			continue
+		}
+		if err := r.Delete(ctx, &restores.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// finalize deletes the scratch namespaces of a deleted verification, its Restores are garbage collected
+func (r *RestoreVerificationReconciler) finalize(ctx context.Context, verification *oadpv1alpha1.RestoreVerification) error {
+	if !controllerutil.ContainsFinalizer(verification, restoreVerificationFinalizer) {
+		return nil
+	}
+	namespaces, err := r.scratchNamespaces(ctx, verification)
+	if err != nil {
+		return err
+	}
+	if err := r.deleteNamespaces(ctx, namespaces); err != nil {
+		return err
+	}
+	controllerutil.RemoveFinalizer(verification, restoreVerificationFinalizer)
+	return r.Update(ctx, verification)
+}
+
+// setRestoreVerificationMetrics sets the metrics of the result of the current run
+func setRestoreVerificationMetrics(verification *oadpv1alpha1.RestoreVerification) {
+	run := verification.Status.CurrentRun
+	labelValues := []string{verification.Namespace, verification.Name}
+	succeeded := run.Result == oadpv1alpha1.RestoreVerificationResultSucceeded
+	restoreVerificationLastRun.WithLabelValues(labelValues...).Set(float64(run.CompletionTime.Unix()))
+	restoreVerificationLastSucceeded.WithLabelValues(labelValues...).Set(boolToFloat64(succeeded))
+	restoreVerificationLastDuration.WithLabelValues(labelValues...).Set(run.CompletionTime.Sub(run.StartTime.Time).Seconds())
+	if succeeded {
+		restoreVerificationLastSuccess.WithLabelValues(labelValues...).Set(float64(run.CompletionTime.Unix()))
+	}
+	restoreVerificationRuns.WithLabelValues(verification.Namespace, verification.Name, string(run.Result)).Inc()
+}
+
+// deleteRestoreVerificationMetrics deletes the metrics of a verification
+func deleteRestoreVerificationMetrics(key types.NamespacedName) {
+	labels := prometheus.Labels{"namespace": key.Namespace, "name": key.Name}
+	for _, gauge := range []*prometheus.GaugeVec{restoreVerificationLastRun, restoreVerificationLastSuccess, restoreVerificationLastSucceeded, restoreVerificationLastDuration} {
+		gauge.DeletePartialMatch(labels)
+	}
+	restoreVerificationRuns.DeletePartialMatch(labels)
+}
+
+func durationOrDefault(duration metav1.Duration, defaultDuration time.Duration) time.Duration {
+	if duration.Duration <= 0 {
+		return defaultDuration
+	}
+	return duration.Duration
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedValues(m map[string]string) []string {
+	values := make([]string, 0, len(m))
+	for _, value := range m {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+func (r *RestoreVerificationReconciler) clusterClient() client.Client {
+	if r.ClusterWideClient == nil {
+		return r.Client
+	}
+	return r.ClusterWideClient
+}
+
+// now returns the current time of the reconciler clock
+func (r *RestoreVerificationReconciler) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
+	return r.Clock.Now()
+}
+
+// restoreVerificationRequests returns the verifications of the Schedule of a backup
+func (r *RestoreVerificationReconciler) restoreVerificationRequests(ctx context.Context, object client.Object) []reconcile.Request {
+	scheduleName := object.GetLabels()[velerov1.ScheduleNameLabel]
+	if scheduleName == "" {
+		return nil
+	}
+	verifications := &oadpv1alpha1.RestoreVerificationList{}
+	if err := r.List(ctx, verifications, client.InNamespace(object.GetNamespace())); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, verification := range verifications.Items {
+		if verification.Spec.ScheduleName == scheduleName {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: verification.Namespace, Name: verification.Name}})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RestoreVerificationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&oadpv1alpha1.RestoreVerification{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&velerov1.Restore{}).
+		Watches(&velerov1.Backup{}, handler.EnqueueRequestsFromMapFunc(r.restoreVerificationRequests), builder.WithPredicates(backupCompliancePredicate())).
+		Complete(r)
+}
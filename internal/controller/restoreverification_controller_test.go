@@ -0,0 +1,361 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestScratchNamespaceName(t *testing.T) {
+	verification := &oadpv1alpha1.RestoreVerification{ObjectMeta: metav1.ObjectMeta{Name: "verify"}}
+	require.Equal(t, "verify-app", scratchNamespaceName(verification, "app"))
+
+	long := scratchNamespaceName(verification, strings.Repeat("a", 60))
+	require.Len(t, long, 63)
+	require.True(t, strings.HasPrefix(long, "verify-aaa"))
+	require.NotEqual(t, long, scratchNamespaceName(verification, strings.Repeat("a", 61)), "truncated names keep distinct")
+}
+
+func TestRestoreVerificationNamespaces(t *testing.T) {
+	verification := &oadpv1alpha1.RestoreVerification{}
+	backup := &velerov1.Backup{ObjectMeta: metav1.ObjectMeta{Name: "daily-1"}, Spec: velerov1.BackupSpec{IncludedNamespaces: []string{"app", "db"}}}
+
+	namespaces, err := restoreVerificationNamespaces(verification, backup)
+	require.NoError(t, err)
+	require.Equal(t, []string{"app", "db"}, namespaces)
+
+	backup.Spec.IncludedNamespaces = []string{"team-*"}
+	_, err = restoreVerificationNamespaces(verification, backup)
+	require.ErrorContains(t, err, "wildcard")
+
+	verification.Spec.IncludedNamespaces = []string{"team-a"}
+	namespaces, err = restoreVerificationNamespaces(verification, backup)
+	require.NoError(t, err)
+	require.Equal(t, []string{"team-a"}, namespaces)
+
+	verification.Spec.IncludedNamespaces = []string{"team-a", "app"}
+	_, err = restoreVerificationNamespaces(verification, backup)
+	require.EqualError(t, err, "namespace app is not included in backup daily-1")
+
+	backup.Spec.IncludedNamespaces = nil
+	backup.Spec.ExcludedNamespaces = []string{"team-b"}
+	verification.Spec.IncludedNamespaces = []string{"team-b"}
+	_, err = restoreVerificationNamespaces(verification, backup)
+	require.EqualError(t, err, "namespace team-b is not included in backup daily-1")
+
+	_, err = restoreVerificationNamespaces(&oadpv1alpha1.RestoreVerification{}, &velerov1.Backup{})
+	require.ErrorContains(t, err, "includes all namespaces")
+}
+
+func TestNextRestoreVerificationRun(t *testing.T) {
+	created := time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC)
+	verification := &oadpv1alpha1.RestoreVerification{
+		ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)},
+	}
+	require.True(t, nextRestoreVerificationRun(verification).IsZero(), "each new backup is verified without schedule")
+
+	verification.Spec.Schedule = "0 6 * * *"
+	require.Equal(t, time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC), nextRestoreVerificationRun(verification).UTC())
+
+	verification.Status.LastRun = &oadpv1alpha1.RestoreVerificationRun{StartTime: metav1.NewTime(time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC))}
+	require.Equal(t, time.Date(2026, 10, 20, 6, 0, 0, 0, time.UTC), nextRestoreVerificationRun(verification).UTC())
+
+	verification.Spec.Schedule = "every day"
+	require.Error(t, validateRestoreVerification(verification))
+}
+
+func TestRestoreVerificationReconciler_Reconcile(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	verification := &oadpv1alpha1.RestoreVerification{
+		ObjectMeta: metav1.ObjectMeta{Name: "verify", Namespace: "openshift-adp", UID: "verify-uid"},
+		Spec: oadpv1alpha1.RestoreVerificationSpec{
+			ScheduleName:     "daily",
+			ReadinessTimeout: metav1.Duration{Duration: 10 * time.Minute},
+			CheckTimeout:     metav1.Duration{Duration: 5 * time.Minute},
+			Checks: []oadpv1alpha1.RestoreVerificationCheck{
+				{Name: "query", Image: "quay.io/example/check:latest", Command: []string{"/check"}},
+			},
+		},
+	}
+	backup := func(name string, completed time.Time) *velerov1.Backup {
+		return &velerov1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "openshift-adp", Labels: map[string]string{velerov1.ScheduleNameLabel: "daily"}},
+			Spec:       velerov1.BackupSpec{IncludedNamespaces: []string{"app"}},
+			Status:     velerov1.BackupStatus{Phase: velerov1.BackupPhaseCompleted, CompletionTimestamp: &metav1.Time{Time: completed}},
+		}
+	}
+	scheme, err := getSchemeForFakeClient()
+	require.NoError(t, err)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(verification).WithObjects(verification,
+		backup("daily-1", now.Add(-time.Hour)),
+	).Build()
+	fakeClock := clocktesting.NewFakeClock(now)
+	eventRecorder := record.NewFakeRecorder(10)
+	r := &RestoreVerificationReconciler{
+		Client:        fakeClient,
+		Scheme:        fakeClient.Scheme(),
+		EventRecorder: eventRecorder,
+		Clock:         fakeClock,
+	}
+	ctx := newContextForTest()
+	key := types.NamespacedName{Namespace: "openshift-adp", Name: "verify"}
+	reconcile := func() ctrl.Result {
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		require.NoError(t, err)
+		require.NoError(t, fakeClient.Get(ctx, key, verification))
+		return result
+	}
+
+	// the latest backup is restored into a scratch namespace
+	reconcile()
+	require.Contains(t, verification.Finalizers, restoreVerificationFinalizer)
+	require.Equal(t, oadpv1alpha1.RestoreVerificationPhaseRestoring, verification.Status.Phase)
+	run := verification.Status.CurrentRun
+	require.Equal(t, "daily-1", run.Backup)
+	require.Equal(t, map[string]string{"app": "verify-app"}, run.NamespaceMapping)
+
+	scratch := &corev1.Namespace{}
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKey{Name: "verify-app"}, scratch))
+	require.Equal(t, "verify", scratch.Labels[RestoreVerificationLabel])
+	require.Equal(t, "openshift-adp", scratch.Labels[RestoreVerificationNamespaceLabel])
+
+	restore := &velerov1.Restore{}
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKey{Namespace: "openshift-adp", Name: run.Restore}, restore))
+	require.Equal(t, "verify-20261018120000", restore.Name)
+	require.True(t, metav1.IsControlledBy(restore, verification))
+	require.Equal(t, "daily-1", restore.Spec.BackupName)
+	require.Equal(t, []string{"app"}, restore.Spec.IncludedNamespaces)
+	require.Equal(t, map[string]string{"app": "verify-app"}, restore.Spec.NamespaceMapping)
+
+	// the restore completes, the restored Deployment is not available yet
+	restore.Status.Phase = velerov1.RestorePhaseCompleted
+	restore.Status.Progress = &velerov1.RestoreProgress{TotalItems: 12, ItemsRestored: 12}
+	require.NoError(t, fakeClient.Update(ctx, restore))
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "verify-app"},
+		Spec:       appsv1.DeploymentSpec{Replicas: ptr.To(int32(2))},
+	}
+	require.NoError(t, fakeClient.Create(ctx, deployment))
+	reconcile()
+	require.Equal(t, oadpv1alpha1.RestoreVerificationPhaseWaitingForWorkloads, verification.Status.Phase)
+	require.Equal(t, restoreVerificationPollInterval, reconcile().RequeueAfter)
+	require.Equal(t, oadpv1alpha1.RestoreVerificationPhaseWaitingForWorkloads, verification.Status.Phase)
+
+	// the Deployment is available, the check pod starts
+	deployment.Status.AvailableReplicas = 2
+	require.NoError(t, fakeClient.Status().Update(ctx, deployment))
+	fakeClock.Step(time.Minute)
+	reconcile()
+	require.Equal(t, oadpv1alpha1.RestoreVerificationPhaseRunningChecks, verification.Status.Phase)
+	require.Equal(t, []oadpv1alpha1.RestoreVerificationCheckStatus{{Name: "query", Pod: "verify-app/verify-check-query"}}, verification.Status.CurrentRun.Checks)
+
+	pod := &corev1.Pod{}
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKey{Namespace: "verify-app", Name: "verify-check-query"}, pod))
+	require.Equal(t, corev1.RestartPolicyNever, pod.Spec.RestartPolicy)
+	require.Equal(t, ptr.To(true), pod.Spec.SecurityContext.RunAsNonRoot)
+	require.Equal(t, []corev1.EnvVar{{Name: "SOURCE_NAMESPACE", Value: "app"}, {Name: "SCRATCH_NAMESPACE", Value: "verify-app"}}, pod.Spec.Containers[0].Env)
+
+	// the check fails, the scratch namespace is deleted
+	pod.Status.Phase = corev1.PodFailed
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: "table orders is empty"}}}}
+	require.NoError(t, fakeClient.Status().Update(ctx, pod))
+	fakeClock.Step(time.Minute)
+	reconcile()
+	require.Equal(t, oadpv1alpha1.RestoreVerificationPhaseCleaningUp, verification.Status.Phase)
+	require.Equal(t, oadpv1alpha1.RestoreVerificationResultFailed, verification.Status.CurrentRun.Result)
+	require.Equal(t, "table orders is empty", verification.Status.CurrentRun.Checks[0].Message)
+	condition := apimeta.FindStatusCondition(verification.Status.Conditions, oadpv1alpha1.ConditionVerified)
+	require.NotNil(t, condition)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Equal(t, "checks failed: query", condition.Message)
+	require.Equal(t, float64(0), testutil.ToFloat64(restoreVerificationLastSucceeded.WithLabelValues("openshift-adp", "verify")))
+	require.Equal(t, float64(120), testutil.ToFloat64(restoreVerificationLastDuration.WithLabelValues("openshift-adp", "verify")))
+	require.Equal(t, float64(1), testutil.ToFloat64(restoreVerificationRuns.WithLabelValues("openshift-adp", "verify", "Failed")))
+
+	reconcile()
+	err = fakeClient.Get(ctx, client.ObjectKey{Name: "verify-app"}, &corev1.Namespace{})
+	require.True(t, apierrors.IsNotFound(err))
+	reconcile()
+	require.Empty(t, verification.Status.Phase)
+	require.Nil(t, verification.Status.CurrentRun)
+	require.Equal(t, oadpv1alpha1.RestoreVerificationResultFailed, verification.Status.LastRun.Result)
+
+	// the same backup is not verified twice
+	require.Zero(t, reconcile().RequeueAfter)
+	require.Nil(t, verification.Status.CurrentRun)
+
+	// a new backup is verified, the Restore of the previous run is deleted
+	require.NoError(t, fakeClient.Create(ctx, backup("daily-2", now)))
+	fakeClock.Step(time.Hour)
+	reconcile()
+	require.Equal(t, "daily-2", verification.Status.CurrentRun.Backup)
+	err = fakeClient.Get(ctx, client.ObjectKey{Namespace: "openshift-adp", Name: restore.Name}, &velerov1.Restore{})
+	require.True(t, apierrors.IsNotFound(err))
+
+	// the scratch namespaces of a deleted verification are deleted
+	require.NoError(t, fakeClient.Delete(ctx, verification))
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	err = fakeClient.Get(ctx, client.ObjectKey{Name: "verify-app"}, &corev1.Namespace{})
+	require.True(t, apierrors.IsNotFound(err))
+	err = fakeClient.Get(ctx, key, verification)
+	require.True(t, apierrors.IsNotFound(err))
+
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	require.Zero(t, testutil.CollectAndCount(restoreVerificationLastSucceeded))
+	require.Len(t, eventRecorder.Events, 3)
+}
+
+func TestRestoreVerificationReconciler_ReadinessTimeout(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	verification := &oadpv1alpha1.RestoreVerification{
+		ObjectMeta: metav1.ObjectMeta{Name: "verify", Namespace: "openshift-adp", Finalizers: []string{restoreVerificationFinalizer}},
+		Spec: oadpv1alpha1.RestoreVerificationSpec{
+			ScheduleName:     "daily",
+			ReadinessTimeout: metav1.Duration{Duration: 10 * time.Minute},
+			KeepOnFailure:    true,
+		},
+		Status: oadpv1alpha1.RestoreVerificationStatus{
+			Phase: oadpv1alpha1.RestoreVerificationPhaseWaitingForWorkloads,
+			CurrentRun: &oadpv1alpha1.RestoreVerificationRun{
+				Backup:                "daily-1",
+				NamespaceMapping:      map[string]string{"db": "verify-db"},
+				StartTime:             metav1.NewTime(now.Add(-15 * time.Minute)),
+				RestoreCompletionTime: &metav1.Time{Time: now.Add(-11 * time.Minute)},
+			},
+		},
+	}
+	scheme, err := getSchemeForFakeClient()
+	require.NoError(t, err)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(verification).WithObjects(verification,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "verify-db", Labels: map[string]string{
+			RestoreVerificationLabel: "verify", RestoreVerificationNamespaceLabel: "openshift-adp",
+		}}},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "verify-db"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: ptr.To(int32(1))},
+		},
+	).Build()
+	r := &RestoreVerificationReconciler{
+		Client:        fakeClient,
+		Scheme:        fakeClient.Scheme(),
+		EventRecorder: record.NewFakeRecorder(5),
+		Clock:         clocktesting.NewFakePassiveClock(now),
+	}
+	key := types.NamespacedName{Namespace: "openshift-adp", Name: "verify"}
+
+	_, err = r.Reconcile(newContextForTest(), ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Get(newContextForTest(), key, verification))
+	require.Empty(t, verification.Status.Phase, "failed verifications are kept with keepOnFailure")
+	require.Equal(t, oadpv1alpha1.RestoreVerificationResultFailed, verification.Status.LastRun.Result)
+	require.Equal(t, "workloads not ready after 10m0s: StatefulSet verify-db/postgres 0/1 ready", verification.Status.LastRun.Message)
+	require.NoError(t, fakeClient.Get(newContextForTest(), client.ObjectKey{Name: "verify-db"}, &corev1.Namespace{}))
+}
+
+func TestRestoreVerificationReconciler_EmptyRestore(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	verification := &oadpv1alpha1.RestoreVerification{
+		ObjectMeta: metav1.ObjectMeta{Name: "verify", Namespace: "openshift-adp", Finalizers: []string{restoreVerificationFinalizer}},
+		Spec: oadpv1alpha1.RestoreVerificationSpec{
+			ScheduleName:  "daily",
+			KeepOnFailure: true,
+		},
+		Status: oadpv1alpha1.RestoreVerificationStatus{
+			Phase: oadpv1alpha1.RestoreVerificationPhaseRestoring,
+			CurrentRun: &oadpv1alpha1.RestoreVerificationRun{
+				Backup:           "daily-1",
+				Restore:          "verify-20261018115500",
+				NamespaceMapping: map[string]string{"app": "verify-app"},
+				StartTime:        metav1.NewTime(now.Add(-5 * time.Minute)),
+			},
+		},
+	}
+	scheme, err := getSchemeForFakeClient()
+	require.NoError(t, err)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(verification).WithObjects(verification,
+		&velerov1.Restore{
+			ObjectMeta: metav1.ObjectMeta{Name: "verify-20261018115500", Namespace: "openshift-adp"},
+			Status: velerov1.RestoreStatus{
+				Phase:    velerov1.RestorePhaseCompleted,
+				Progress: &velerov1.RestoreProgress{},
+			},
+		},
+	).Build()
+	r := &RestoreVerificationReconciler{
+		Client:        fakeClient,
+		Scheme:        fakeClient.Scheme(),
+		EventRecorder: record.NewFakeRecorder(5),
+		Clock:         clocktesting.NewFakePassiveClock(now),
+	}
+	key := types.NamespacedName{Namespace: "openshift-adp", Name: "verify"}
+
+	_, err = r.Reconcile(newContextForTest(), ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Get(newContextForTest(), key, verification))
+	require.Equal(t, oadpv1alpha1.RestoreVerificationResultFailed, verification.Status.LastRun.Result)
+	require.Equal(t, "restore verify-20261018115500 restored no items", verification.Status.LastRun.Message)
+}
+
+func TestRestoreVerificationReconciler_RestoreCreationFailure(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	verification := &oadpv1alpha1.RestoreVerification{
+		ObjectMeta: metav1.ObjectMeta{Name: "verify", Namespace: "openshift-adp", Finalizers: []string{restoreVerificationFinalizer}},
+		Spec:       oadpv1alpha1.RestoreVerificationSpec{ScheduleName: "daily"},
+	}
+	scheme, err := getSchemeForFakeClient()
+	require.NoError(t, err)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(verification).WithObjects(verification,
+		&velerov1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "daily-1", Namespace: "openshift-adp", Labels: map[string]string{velerov1.ScheduleNameLabel: "daily"}},
+			Spec:       velerov1.BackupSpec{IncludedNamespaces: []string{"app"}},
+			Status:     velerov1.BackupStatus{Phase: velerov1.BackupPhaseCompleted, CompletionTimestamp: &metav1.Time{Time: now.Add(-time.Hour)}},
+		},
+	).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if _, isRestore := obj.(*velerov1.Restore); isRestore {
+				return apierrors.NewForbidden(velerov1.Resource("restores"), obj.GetName(), errors.New("quota exceeded"))
+			}
+			return c.Create(ctx, obj, opts...)
+		},
+	}).Build()
+	r := &RestoreVerificationReconciler{
+		Client:        fakeClient,
+		Scheme:        fakeClient.Scheme(),
+		EventRecorder: record.NewFakeRecorder(5),
+		Clock:         clocktesting.NewFakePassiveClock(now),
+	}
+	key := types.NamespacedName{Namespace: "openshift-adp", Name: "verify"}
+
+	_, err = r.Reconcile(newContextForTest(), ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Get(newContextForTest(), key, verification))
+	require.Equal(t, oadpv1alpha1.RestoreVerificationPhaseCleaningUp, verification.Status.Phase, "the scratch namespaces are deleted")
+	require.Equal(t, oadpv1alpha1.RestoreVerificationResultFailed, verification.Status.CurrentRun.Result)
+	require.Contains(t, verification.Status.CurrentRun.Message, "unable to create restore verify-20261018120000")
+	condition := apimeta.FindStatusCondition(verification.Status.Conditions, oadpv1alpha1.ConditionVerified)
+	require.NotNil(t, condition)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+}
@@ -0,0 +1,234 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/robfig/cron/v3"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+const (
+	// SchedulePolicyLabel is set on the Velero Schedules generated for spec.schedulePolicies, its value is the policy
+	SchedulePolicyLabel = "oadp.openshift.io/schedule-policy"
+	// ScheduleTierLabel is set on the Velero Schedules generated for spec.schedulePolicies, its value is the tier
+	ScheduleTierLabel = "oadp.openshift.io/schedule-tier"
+	// schedulePolicyResyncPeriod is how often the namespaces selected by the policies are resolved again, namespace
+	// label changes do not trigger a reconcile
+	schedulePolicyResyncPeriod = 5 * time.Minute
+)
+
+// schedulePolicyScheduleName returns the name of the Velero Schedule of a tier
+func schedulePolicyScheduleName(policy oadpv1alpha1.SchedulePolicy, tier oadpv1alpha1.ScheduleTier) string {
+	return policy.Name + "-" + tier.Name
+}
+
+// validateSchedulePolicies validates the names, cron expressions, retentions and namespace selectors of the policies
+func validateSchedulePolicies(policies []oadpv1alpha1.SchedulePolicy) error {
+	policyNames := map[string]bool{}
+	scheduleNames := map[string]string{}
+	for _, policy := range policies {
+		if policyNames[policy.Name] {
+			return fmt.Errorf("spec.schedulePolicies name %q is not unique", policy.Name)
+		}
+		policyNames[policy.Name] = true
+		if _, err := metav1.LabelSelectorAsSelector(&policy.NamespaceSelector); err != nil {
+			return fmt.Errorf("spec.schedulePolicies %q namespaceSelector is invalid: %w", policy.Name, err)
+		}
+		if len(policy.Tiers) == 0 {
+			return fmt.Errorf("spec.schedulePolicies %q must define at least one tier", policy.Name)
+		}
+		tierNames := map[string]bool{}
+		for _, tier := range policy.Tiers {
+			if tierNames[tier.Name] {
+				return fmt.Errorf("spec.schedulePolicies %q tier name %q is not unique", policy.Name, tier.Name)
+			}
+			tierNames[tier.Name] = true
+			// Velero validates the schedules with the standard cron parser
+			if _, err := cron.ParseStandard(tier.Schedule); err != nil {
+				return fmt.Errorf("spec.schedulePolicies %q tier %q schedule is invalid: %w", policy.Name, tier.Name, err)
+			}
+			if tier.TTL.Duration <= 0 {
+				return fmt.Errorf("spec.schedulePolicies %q tier %q ttl must be positive", policy.Name, tier.Name)
+			}
+			name := schedulePolicyScheduleName(policy, tier)
+			if other, found := scheduleNames[name]; found {
+				return fmt.Errorf("spec.schedulePolicies %q tier %q and %s generate the same Schedule %q", policy.Name, tier.Name, other, name)
+			}
+			scheduleNames[name] = fmt.Sprintf("%q tier %q", policy.Name, tier.Name)
+		}
+	}
+	return nil
+}
+
+// schedulePolicyNamespaces returns the sorted names of the namespaces selected by a policy
+func (r *DataProtectionApplicationReconciler) schedulePolicyNamespaces(policy oadpv1alpha1.SchedulePolicy) ([]string, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&policy.NamespaceSelector)
+	if err != nil {
+		return nil, err
+	}
+	clusterClient := r.ClusterWideClient
+	if clusterClient == nil {
+		clusterClient = r.Client
+	}
+	namespaces := &corev1.NamespaceList{}
+	if err := clusterClient.List(r.Context, namespaces, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(namespaces.Items))
+	for _, namespace := range namespaces.Items {
+		// the scratch namespaces of restore verifications are not backed up
+		if namespace.DeletionTimestamp != nil || namespace.Labels[RestoreVerificationLabel] != "" {
+			continue
+		}
+		names = append(names, namespace.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// lastSuccessfulScheduleBackup returns the completed backup of a Schedule with the latest completion, nil when the
+// Schedule has no completed backup
+func (r *DataProtectionApplicationReconciler) lastSuccessfulScheduleBackup(scheduleName string) (*velerov1.Backup, error) {
+	backups := &velerov1.BackupList{}
+	if err := r.List(r.Context, backups, client.InNamespace(r.dpa.Namespace), client.MatchingLabels{velerov1.ScheduleNameLabel: scheduleName}); err != nil {
+		return nil, err
+	}
+	var last *velerov1.Backup
+	for i := range backups.Items {
+		backup := &backups.Items[i]
+		if backup.Status.Phase != velerov1.BackupPhaseCompleted || backup.Status.CompletionTimestamp == nil {
+			continue
+		}
+		if last == nil || backup.Status.CompletionTimestamp.After(last.Status.CompletionTimestamp.Time) {
+			last = backup
+		}
+	}
+	return last, nil
+}
+
+// ReconcileSchedulePolicies reconciles a Velero Schedule for each tier of spec.schedulePolicies, deletes the
+// Schedules of removed policies and tiers, and reports the last successful backup of each tier
+func (r *DataProtectionApplicationReconciler) ReconcileSchedulePolicies(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	if len(dpa.Spec.SchedulePolicies) == 0 && len(dpa.Status.SchedulePolicies) == 0 {
+		return true, nil
+	}
+
+	desired := map[string]bool{}
+	var statuses []oadpv1alpha1.SchedulePolicyStatus
+	for _, policy := range dpa.Spec.SchedulePolicies {
+		namespaces, err := r.schedulePolicyNamespaces(policy)
+		if err != nil {
+			log.Error(err, "Error listing the namespaces of the schedule policy", "policy", policy.Name)
+			return false, err
+		}
+		// a Schedule without included namespaces backs up the whole cluster, it is paused instead
+		paused := len(namespaces) == 0
+		policyStatus := oadpv1alpha1.SchedulePolicyStatus{Name: policy.Name, Namespaces: namespaces}
+
+		for _, tier := range policy.Tiers {
+			name := schedulePolicyScheduleName(policy, tier)
+			desired[name] = true
+
+			schedule := &velerov1.Schedule{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: dpa.Namespace,
+				},
+			}
+			op, err := controllerutil.CreateOrUpdate(r.Context, r.Client, schedule, func() error {
+				labels := getDpaAppLabels(dpa)
+				labels[SchedulePolicyLabel] = policy.Name
+				labels[ScheduleTierLabel] = tier.Name
+				schedule.Labels = labels
+				schedule.Spec.Schedule = tier.Schedule
+				schedule.Spec.Paused = paused
+				schedule.Spec.Template = velerov1.BackupSpec{
+					IncludedNamespaces:       namespaces,
+					StorageLocation:          policy.StorageLocation,
+					TTL:                      tier.TTL,
+					SnapshotMoveData:         schedulePolicyFlag(tier.SnapshotMoveData, policy.SnapshotMoveData),
+					DefaultVolumesToFsBackup: schedulePolicyFlag(tier.DefaultVolumesToFsBackup, policy.DefaultVolumesToFsBackup),
+				}
+				return controllerutil.SetControllerReference(dpa, schedule, r.Scheme)
+			})
+			if err != nil {
+				log.Error(err, "Error reconciling Schedule", "policy", policy.Name, "tier", tier.Name)
+				return false, err
+			}
+			if op == controllerutil.OperationResultCreated || op == controllerutil.OperationResultUpdated {
+				r.EventRecorder.Event(dpa,
+					corev1.EventTypeNormal,
+					"SchedulePolicyScheduleReconciled",
+					fmt.Sprintf("performed %s on %s policy Schedule %s/%s", op, policy.Name, dpa.Namespace, name),
+				)
+			}
+
+			tierStatus := oadpv1alpha1.ScheduleTierStatus{Name: tier.Name, Schedule: name, Paused: paused}
+			last, err := r.lastSuccessfulScheduleBackup(name)
+			if err != nil {
+				return false, err
+			}
+			if last != nil {
+				tierStatus.LastSuccessfulBackup = last.Name
+				tierStatus.LastSuccessfulBackupTime = last.Status.CompletionTimestamp.DeepCopy()
+			}
+			policyStatus.Tiers = append(policyStatus.Tiers, tierStatus)
+		}
+		statuses = append(statuses, policyStatus)
+	}
+
+	existing := &velerov1.ScheduleList{}
+	if err := r.List(r.Context, existing, client.InNamespace(dpa.Namespace), client.HasLabels{SchedulePolicyLabel}); err != nil {
+		return false, err
+	}
+	for i := range existing.Items {
+		schedule := &existing.Items[i]
+		if desired[schedule.Name] || !metav1.IsControlledBy(schedule, dpa) {
+			continue
+		}
+		if err := r.Delete(r.Context, schedule); err != nil && !k8serror.IsNotFound(err) {
+			log.Error(err, "Error deleting Schedule", "name", schedule.Name)
+			return false, err
+		}
+		r.EventRecorder.Event(dpa,
+			corev1.EventTypeNormal,
+			"SchedulePolicyScheduleDeleted",
+			fmt.Sprintf("deleted Schedule %s/%s", schedule.Namespace, schedule.Name),
+		)
+	}
+
+	dpa.Status.SchedulePolicies = statuses
+	return true, nil
+}
+
+// schedulePolicyFlag returns the tier flag, else the policy flag
+func schedulePolicyFlag(tierFlag, policyFlag *bool) *bool {
+	if tierFlag != nil {
+		return ptr.To(*tierFlag)
+	}
+	if policyFlag != nil {
+		return ptr.To(*policyFlag)
+	}
+	return nil
+}
+
+// schedulePolicyRequeueAfter returns when the namespaces selected by the schedule policies are resolved again, zero
+// without policies
+func (r *DataProtectionApplicationReconciler) schedulePolicyRequeueAfter() time.Duration {
+	if r.dpa == nil || len(r.dpa.Spec.SchedulePolicies) == 0 {
+		return 0
+	}
+	return schedulePolicyResyncPeriod
+}
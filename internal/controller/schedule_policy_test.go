@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestValidateSchedulePolicies(t *testing.T) {
+	tier := oadpv1alpha1.ScheduleTier{Name: "hourly", Schedule: "0 * * * *", TTL: metav1.Duration{Duration: 24 * time.Hour}}
+	tests := []struct {
+		name     string
+		policies []oadpv1alpha1.SchedulePolicy
+		wantErr  string
+	}{
+		{
+			name:     "valid policy",
+			policies: []oadpv1alpha1.SchedulePolicy{{Name: "gold", Tiers: []oadpv1alpha1.ScheduleTier{tier}}},
+		},
+		{
+			name: "duplicate policy",
+			policies: []oadpv1alpha1.SchedulePolicy{
+				{Name: "gold", Tiers: []oadpv1alpha1.ScheduleTier{tier}},
+				{Name: "gold", Tiers: []oadpv1alpha1.ScheduleTier{tier}},
+			},
+			wantErr: `spec.schedulePolicies name "gold" is not unique`,
+		},
+		{
+			name:     "duplicate tier",
+			policies: []oadpv1alpha1.SchedulePolicy{{Name: "gold", Tiers: []oadpv1alpha1.ScheduleTier{tier, tier}}},
+			wantErr:  `spec.schedulePolicies "gold" tier name "hourly" is not unique`,
+		},
+		{
+			name: "invalid schedule",
+			policies: []oadpv1alpha1.SchedulePolicy{{Name: "gold", Tiers: []oadpv1alpha1.ScheduleTier{
+				{Name: "hourly", Schedule: "every hour", TTL: tier.TTL},
+			}}},
+			wantErr: `spec.schedulePolicies "gold" tier "hourly" schedule is invalid`,
+		},
+		{
+			name: "no ttl",
+			policies: []oadpv1alpha1.SchedulePolicy{{Name: "gold", Tiers: []oadpv1alpha1.ScheduleTier{
+				{Name: "hourly", Schedule: "@hourly"},
+			}}},
+			wantErr: `spec.schedulePolicies "gold" tier "hourly" ttl must be positive`,
+		},
+		{
+			name: "same Schedule name",
+			policies: []oadpv1alpha1.SchedulePolicy{
+				{Name: "gold-db", Tiers: []oadpv1alpha1.ScheduleTier{tier}},
+				{Name: "gold", Tiers: []oadpv1alpha1.ScheduleTier{{Name: "db-hourly", Schedule: "@hourly", TTL: tier.TTL}}},
+			},
+			wantErr: `spec.schedulePolicies "gold" tier "db-hourly" and "gold-db" tier "hourly" generate the same Schedule "gold-db-hourly"`,
+		},
+		{
+			name: "invalid selector",
+			policies: []oadpv1alpha1.SchedulePolicy{{
+				Name:              "gold",
+				NamespaceSelector: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "tier", Operator: "Near"}}},
+				Tiers:             []oadpv1alpha1.ScheduleTier{tier},
+			}},
+			wantErr: `spec.schedulePolicies "gold" namespaceSelector is invalid`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSchedulePolicies(tt.policies)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestDPAReconciler_ReconcileSchedulePolicies(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns", UID: "test-uid"},
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			SchedulePolicies: []oadpv1alpha1.SchedulePolicy{
+				{
+					Name:              "gold",
+					NamespaceSelector: metav1.LabelSelector{MatchLabels: map[string]string{"backup": "gold"}},
+					StorageLocation:   "default",
+					SnapshotMoveData:  ptr.To(true),
+					Tiers: []oadpv1alpha1.ScheduleTier{
+						{Name: "hourly", Schedule: "0 * * * *", TTL: metav1.Duration{Duration: 24 * time.Hour}},
+						{Name: "daily", Schedule: "0 2 * * *", TTL: metav1.Duration{Duration: 168 * time.Hour}, DefaultVolumesToFsBackup: ptr.To(true)},
+					},
+				},
+				{
+					Name:              "silver",
+					NamespaceSelector: metav1.LabelSelector{MatchLabels: map[string]string{"backup": "silver"}},
+					Tiers: []oadpv1alpha1.ScheduleTier{
+						{Name: "weekly", Schedule: "0 3 * * 0", TTL: metav1.Duration{Duration: 672 * time.Hour}},
+					},
+				},
+			},
+		},
+	}
+	namespace := func(name, tier string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"backup": tier}}}
+	}
+	completion := metav1.NewTime(time.Date(2026, 10, 18, 10, 0, 30, 0, time.UTC))
+	backup := func(name string, phase velerov1.BackupPhase, completed *metav1.Time) *velerov1.Backup {
+		return &velerov1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns", Labels: map[string]string{velerov1.ScheduleNameLabel: "gold-hourly"}},
+			Status:     velerov1.BackupStatus{Phase: phase, CompletionTimestamp: completed},
+		}
+	}
+	removedTier := &velerov1.Schedule{ObjectMeta: metav1.ObjectMeta{
+		Name: "gold-monthly", Namespace: "test-ns",
+		Labels: map[string]string{SchedulePolicyLabel: "gold", ScheduleTierLabel: "monthly"},
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: oadpv1alpha1.GroupVersion.String(), Kind: "DataProtectionApplication", Name: "test-dpa", UID: "test-uid", Controller: ptr.To(true),
+		}},
+	}}
+	fakeClient := getFakeClientFromObjectsForTest(t, dpa, removedTier,
+		namespace("app-b", "gold"), namespace("app-a", "gold"), namespace("app-c", "bronze"),
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "verify-app-a", Labels: map[string]string{"backup": "gold", RestoreVerificationLabel: "verify"}}},
+		backup("gold-hourly-20261018090000", velerov1.BackupPhaseCompleted, ptr.To(metav1.NewTime(completion.Add(-time.Hour)))),
+		backup("gold-hourly-20261018100000", velerov1.BackupPhaseCompleted, &completion),
+		backup("gold-hourly-20261018110000", velerov1.BackupPhasePartiallyFailed, ptr.To(metav1.NewTime(completion.Add(time.Hour)))),
+	)
+	r := &DataProtectionApplicationReconciler{
+		Client:        fakeClient,
+		Scheme:        fakeClient.Scheme(),
+		dpa:           dpa,
+		Log:           logr.Discard(),
+		Context:       newContextForTest(),
+		EventRecorder: newEventRecorder(),
+	}
+
+	ok, err := r.ReconcileSchedulePolicies(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, schedulePolicyResyncPeriod, r.schedulePolicyRequeueAfter())
+
+	hourly := &velerov1.Schedule{}
+	require.NoError(t, fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: "gold-hourly"}, hourly))
+	require.True(t, metav1.IsControlledBy(hourly, dpa))
+	require.Equal(t, "gold", hourly.Labels[SchedulePolicyLabel])
+	require.Equal(t, "hourly", hourly.Labels[ScheduleTierLabel])
+	require.Equal(t, "0 * * * *", hourly.Spec.Schedule)
+	require.False(t, hourly.Spec.Paused)
+	require.Equal(t, velerov1.BackupSpec{
+		IncludedNamespaces: []string{"app-a", "app-b"},
+		StorageLocation:    "default",
+		TTL:                metav1.Duration{Duration: 24 * time.Hour},
+		SnapshotMoveData:   ptr.To(true),
+	}, hourly.Spec.Template)
+
+	daily := &velerov1.Schedule{}
+	require.NoError(t, fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: "gold-daily"}, daily))
+	require.Equal(t, ptr.To(true), daily.Spec.Template.DefaultVolumesToFsBackup, "the tier overrides the policy")
+	require.Equal(t, ptr.To(true), daily.Spec.Template.SnapshotMoveData)
+
+	weekly := &velerov1.Schedule{}
+	require.NoError(t, fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: "silver-weekly"}, weekly))
+	require.True(t, weekly.Spec.Paused, "a policy selecting no namespace does not back up the whole cluster")
+	require.Empty(t, weekly.Spec.Template.IncludedNamespaces)
+
+	err = fakeClient.Get(r.Context, client.ObjectKey{Namespace: "test-ns", Name: "gold-monthly"}, &velerov1.Schedule{})
+	require.True(t, k8serror.IsNotFound(err), "the Schedule of a removed tier is deleted")
+
+	lastBackupTime := dpa.Status.SchedulePolicies[0].Tiers[0].LastSuccessfulBackupTime
+	require.NotNil(t, lastBackupTime)
+	require.True(t, lastBackupTime.Equal(&completion), "the latest completed backup, failed backups are ignored")
+	dpa.Status.SchedulePolicies[0].Tiers[0].LastSuccessfulBackupTime = nil
+	require.Equal(t, []oadpv1alpha1.SchedulePolicyStatus{
+		{
+			Name:       "gold",
+			Namespaces: []string{"app-a", "app-b"},
+			Tiers: []oadpv1alpha1.ScheduleTierStatus{
+				{Name: "hourly", Schedule: "gold-hourly", LastSuccessfulBackup: "gold-hourly-20261018100000"},
+				{Name: "daily", Schedule: "gold-daily"},
+			},
+		},
+		{
+			Name:       "silver",
+			Namespaces: []string{},
+			Tiers:      []oadpv1alpha1.ScheduleTierStatus{{Name: "weekly", Schedule: "silver-weekly", Paused: true}},
+		},
+	}, dpa.Status.SchedulePolicies)
+
+	// removing the policies deletes their Schedules
+	dpa.Spec.SchedulePolicies = nil
+	ok, err = r.ReconcileSchedulePolicies(r.Log)
+	require.NoError(t, err)
+	require.True(t, ok)
+	schedules := &velerov1.ScheduleList{}
+	require.NoError(t, fakeClient.List(r.Context, schedules, client.InNamespace("test-ns")))
+	require.Empty(t, schedules.Items)
+	require.Nil(t, dpa.Status.SchedulePolicies)
+	require.Zero(t, r.schedulePolicyRequeueAfter())
+}
@@ -1,17 +1,26 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
 	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
 )
 
+// CloudIdentityLabel is set on the secrets generated for spec.cloudIdentities, its value is the identity name.
+const CloudIdentityLabel = "oadp.openshift.io/cloud-identity"
+
 // ReconcileAzureWorkloadIdentitySecret ensures the Azure workload identity secret exists.
 // These environment variables are required to trigger Velero's workload identity credential flow:
 // https://github.com/vmware-tanzu/velero/blob/5c0cb58f6a4f95eb93c18e7e8f8d3d14b94d6805/pkg/util/azure/credential.go#L48-L54
@@ -30,11 +39,9 @@ import (
 // eliminating the need for temporary credential files as used by AWS/GCP providers.
 func (r *DataProtectionApplicationReconciler) ReconcileAzureWorkloadIdentitySecret(log logr.Logger) (bool, error) {
 	dpa := r.dpa
-	azureClientID := os.Getenv(stsflow.ClientIDEnvKey)
-
-	// Only create secret if Azure workload identity environment variables are present
-	azureTenantID := os.Getenv(stsflow.TenantIDEnvKey)
-	if azureClientID == "" || azureTenantID == "" || os.Getenv(stsflow.SubscriptionIDEnvKey) == "" {
+	// Only create secret if Azure workload identity is configured by environment variables or spec.cloudIdentities
+	azureClientID, azureTenantID, found := azureWorkloadIdentity(dpa)
+	if !found {
 		// No Azure workload identity configured, nothing to do
 		return true, nil
 	}
@@ -79,3 +86,248 @@ func (r *DataProtectionApplicationReconciler) ReconcileAzureWorkloadIdentitySecr
 
 	return true, nil
 }
+
+// azureWorkloadIdentity returns the Azure client used for workload identity. The standardized flow environment
+// variables take precedence over an Azure identity in spec.cloudIdentities.
+func azureWorkloadIdentity(dpa *oadpv1alpha1.DataProtectionApplication) (string, string, bool) {
+	clientID, tenantID := os.Getenv(stsflow.ClientIDEnvKey), os.Getenv(stsflow.TenantIDEnvKey)
+	if clientID != "" && tenantID != "" && os.Getenv(stsflow.SubscriptionIDEnvKey) != "" {
+		return clientID, tenantID, true
+	}
+	if dpa == nil {
+		return "", "", false
+	}
+	for _, identity := range dpa.Spec.CloudIdentities {
+		if identity.Azure != nil {
+			return identity.Azure.ClientID, identity.Azure.TenantID, true
+		}
+	}
+	return "", "", false
+}
+
+// cloudIdentityProvider returns the provider and secret data of a cloud identity
+func cloudIdentityProvider(identity oadpv1alpha1.CloudIdentity) (string, map[string]string, error) {
+	var provider string
+	var data map[string]string
+	count := 0
+	if identity.AWS != nil {
+		awsData, err := awsCloudIdentitySecretData(identity.Name, identity.AWS)
+		if err != nil {
+			return "", nil, err
+		}
+		provider, data = AWSProvider, awsData
+		count++
+	}
+	if identity.GCP != nil {
+		provider, data = GCPProvider, stsflow.GCPSecretData(identity.GCP.ServiceAccountEmail, identity.GCP.ProjectNumber, identity.GCP.PoolID, identity.GCP.ProviderID)
+		count++
+	}
+	if identity.Azure != nil {
+		provider, data = AzureProvider, stsflow.AzureSecretData(identity.Azure.ClientID, identity.Azure.TenantID, identity.Azure.SubscriptionID)
+		count++
+	}
+	if count != 1 {
+		return "", nil, fmt.Errorf("cloud identity %s must set exactly one of aws, gcp or azure", identity.Name)
+	}
+	return provider, data, nil
+}
+
+// awsCloudIdentitySecretData returns the shared config file of an AWS cloud identity for its credential mode:
+// web identity, IAM Roles Anywhere or EKS Pod Identity.
+func awsCloudIdentitySecretData(name string, identity *oadpv1alpha1.AWSCloudIdentity) (map[string]string, error) {
+	switch {
+	case identity.PodIdentity && identity.RolesAnywhere != nil:
+		return nil, fmt.Errorf("cloud identity %s must set at most one of aws.rolesAnywhere or aws.podIdentity", name)
+	case identity.PodIdentity:
+		if identity.RoleARN != "" {
+			return nil, fmt.Errorf("cloud identity %s: aws.roleARN must be empty with aws.podIdentity, the role is set by the pod identity association", name)
+		}
+		return stsflow.AWSPodIdentitySecretData(), nil
+	case identity.RoleARN == "":
+		return nil, fmt.Errorf("cloud identity %s: aws.roleARN must be set", name)
+	case identity.RolesAnywhere != nil:
+		rolesAnywhere := identity.RolesAnywhere
+		if rolesAnywhere.TrustAnchorARN == "" || rolesAnywhere.ProfileARN == "" || rolesAnywhere.CertificateSecret == "" {
+			return nil, fmt.Errorf("cloud identity %s: aws.rolesAnywhere must set trustAnchorARN, profileARN and certificateSecret", name)
+		}
+		return stsflow.AWSRolesAnywhereSecretData(rolesAnywhere.SigningHelperPath, rolesAnywhere.CertificateSecret,
+			rolesAnywhere.TrustAnchorARN, rolesAnywhere.ProfileARN, identity.RoleARN), nil
+	default:
+		return stsflow.AWSSecretData(identity.RoleARN), nil
+	}
+}
+
+// validateRolesAnywhereCertificate checks that the certificate secret of an IAM Roles Anywhere identity
+// holds the certificate and private key mounted for the signing helper.
+func (r *DataProtectionApplicationReconciler) validateRolesAnywhereCertificate(identity oadpv1alpha1.CloudIdentity) error {
+	if identity.AWS == nil || identity.AWS.RolesAnywhere == nil {
+		return nil
+	}
+	secretName := identity.AWS.RolesAnywhere.CertificateSecret
+	secret := &corev1.Secret{}
+	if err := r.Get(r.Context, types.NamespacedName{Namespace: r.dpa.Namespace, Name: secretName}, secret); err != nil {
+		return fmt.Errorf("cloud identity %s: unable to get certificate secret %s: %w", identity.Name, secretName, err)
+	}
+	for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+		if len(secret.Data[key]) == 0 {
+			return fmt.Errorf("cloud identity %s: certificate secret %s has no %s", identity.Name, secretName, key)
+		}
+	}
+	return nil
+}
+
+// validateCloudIdentities checks that identity names are unique, that each identity sets one provider and
+// that all Azure identities use the same client, because Velero reads the Azure workload identity from its environment.
+func validateCloudIdentities(dpa *oadpv1alpha1.DataProtectionApplication) error {
+	names := map[string]bool{}
+	var azure *oadpv1alpha1.AzureCloudIdentity
+	for _, identity := range dpa.Spec.CloudIdentities {
+		if identity.Name == "" {
+			return errors.New("cloud identity name must not be empty")
+		}
+		if names[identity.Name] {
+			return fmt.Errorf("cloud identity %s is defined more than once", identity.Name)
+		}
+		names[identity.Name] = true
+		if _, _, err := cloudIdentityProvider(identity); err != nil {
+			return err
+		}
+		if identity.Azure == nil {
+			continue
+		}
+		if azure != nil && (azure.ClientID != identity.Azure.ClientID || azure.TenantID != identity.Azure.TenantID) {
+			return fmt.Errorf("cloud identity %s: all Azure identities must use the same clientID and tenantID", identity.Name)
+		}
+		azure = identity.Azure
+	}
+	if azure != nil {
+		if clientID, _, found := azureWorkloadIdentity(nil); found && clientID != azure.ClientID {
+			return fmt.Errorf("cloud identities for Azure must use the client %s configured for the operator", clientID)
+		}
+	}
+
+	for _, bsl := range dpa.Spec.BackupLocations {
+		if bsl.Velero != nil {
+			if err := validateCloudIdentityReference(dpa, bsl.Velero.Provider, bsl.Velero.Credential); err != nil {
+				return err
+			}
+		}
+	}
+	for _, vsl := range dpa.Spec.SnapshotLocations {
+		if vsl.Velero != nil {
+			if err := validateCloudIdentityReference(dpa, vsl.Velero.Provider, vsl.Velero.Credential); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateCloudIdentityReference checks that a BSL or VSL credential referencing a cloud identity uses
+// the provider and secret key of that identity.
+func validateCloudIdentityReference(dpa *oadpv1alpha1.DataProtectionApplication, provider string, credential *corev1.SecretKeySelector) error {
+	if credential == nil {
+		return nil
+	}
+	for _, identity := range dpa.Spec.CloudIdentities {
+		if identity.Name != credential.Name {
+			continue
+		}
+		identityProvider, data, _ := cloudIdentityProvider(identity)
+		if strings.TrimPrefix(provider, "velero.io/") != identityProvider {
+			return fmt.Errorf("cloud identity %s is a %s identity and cannot be used with provider %s", identity.Name, identityProvider, provider)
+		}
+		if _, found := data[credential.Key]; !found {
+			for key := range data {
+				return fmt.Errorf("cloud identity %s must be referenced with key %s", identity.Name, key)
+			}
+		}
+	}
+	return nil
+}
+
+// ReconcileCloudIdentities generates a secret for each identity in spec.cloudIdentities, so BSLs and VSLs can use
+// different short-lived credentials by referencing the secret name in their credential.
+// Secrets of identities removed from the spec are deleted.
+// It runs before ValidateDataProtectionCR so the generated secrets exist when the BSLs and VSLs are validated.
+func (r *DataProtectionApplicationReconciler) ReconcileCloudIdentities(log logr.Logger) (bool, error) {
+	dpa := r.dpa
+	if err := validateCloudIdentities(dpa); err != nil {
+		return false, err
+	}
+
+	var statuses []oadpv1alpha1.CloudIdentityStatus
+	desired := map[string]bool{}
+	for _, identity := range dpa.Spec.CloudIdentities {
+		if err := r.validateRolesAnywhereCertificate(identity); err != nil {
+			return false, err
+		}
+		provider, data, _ := cloudIdentityProvider(identity)
+		desired[identity.Name] = true
+
+		existing := &corev1.Secret{}
+		err := r.Get(r.Context, types.NamespacedName{Namespace: dpa.Namespace, Name: identity.Name}, existing)
+		if err != nil && !k8serror.IsNotFound(err) {
+			return false, err
+		}
+		if err == nil && !metav1.IsControlledBy(existing, dpa) {
+			return false, fmt.Errorf("cloud identity %s: secret %s already exists and is not managed by the DataProtectionApplication", identity.Name, identity.Name)
+		}
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      identity.Name,
+				Namespace: dpa.Namespace,
+			},
+		}
+		op, err := controllerutil.CreateOrUpdate(r.Context, r.Client, secret, func() error {
+			secret.Labels = getDpaAppLabels(dpa)
+			secret.Labels[stsflow.STSSecretLabelKey] = stsflow.STSSecretLabelValue
+			secret.Labels[CloudIdentityLabel] = identity.Name
+
+			secret.Data = make(map[string][]byte, len(data))
+			for key, value := range data {
+				secret.Data[key] = []byte(value)
+			}
+			return controllerutil.SetControllerReference(dpa, secret, r.Scheme)
+		})
+		if err != nil {
+			log.Error(err, "Error reconciling cloud identity secret", "identity", identity.Name)
+			return false, err
+		}
+		if op == controllerutil.OperationResultCreated || op == controllerutil.OperationResultUpdated {
+			r.EventRecorder.Event(secret,
+				corev1.EventTypeNormal,
+				"CloudIdentitySecretReconciled",
+				fmt.Sprintf("performed %s on %s cloud identity secret %s/%s", op, provider, secret.Namespace, secret.Name),
+			)
+		}
+
+		for key := range data {
+			statuses = append(statuses, oadpv1alpha1.CloudIdentityStatus{Name: identity.Name, Provider: provider, Key: key})
+		}
+	}
+
+	secrets := &corev1.SecretList{}
+	if err := r.List(r.Context, secrets, client.InNamespace(dpa.Namespace), client.HasLabels{CloudIdentityLabel}); err != nil {
+		return false, err
+	}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if desired[secret.Name] || !metav1.IsControlledBy(secret, dpa) {
+			continue
+		}
+		if err := r.Delete(r.Context, secret); err != nil && !k8serror.IsNotFound(err) {
+			log.Error(err, "Error deleting cloud identity secret", "secret", secret.Name)
+			return false, err
+		}
+		r.EventRecorder.Event(dpa,
+			corev1.EventTypeNormal,
+			"CloudIdentitySecretDeleted",
+			fmt.Sprintf("deleted cloud identity secret %s/%s", secret.Namespace, secret.Name),
+		)
+	}
+
+	dpa.Status.CloudIdentities = statuses
+	return true, nil
+}
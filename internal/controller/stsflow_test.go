@@ -6,11 +6,16 @@ import (
 	"testing"
 
 	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
@@ -51,6 +56,29 @@ func TestDPAReconciler_ReconcileAzureWorkloadIdentitySecret(t *testing.T) {
 			},
 			wantError: false,
 		},
+		{
+			name: "Azure cloud identity - should create secret",
+			dpa: &oadpv1alpha1.DataProtectionApplication{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-dpa",
+					Namespace: "test-ns",
+				},
+				Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+					CloudIdentities: []oadpv1alpha1.CloudIdentity{{
+						Name:  "azure-backup",
+						Azure: &oadpv1alpha1.AzureCloudIdentity{ClientID: "spec-client-id", TenantID: "spec-tenant-id", SubscriptionID: "spec-subscription-id"},
+					}},
+				},
+			},
+			envVars:    map[string]string{},
+			wantSecret: true,
+			wantSecretData: map[string]string{
+				"AZURE_CLIENT_ID":            "spec-client-id",
+				"AZURE_TENANT_ID":            "spec-tenant-id",
+				"AZURE_FEDERATED_TOKEN_FILE": stsflow.WebIdentityTokenPath,
+			},
+			wantError: false,
+		},
 		{
 			name: "No Azure credentials - should not create secret",
 			dpa: &oadpv1alpha1.DataProtectionApplication{
@@ -186,3 +214,197 @@ func TestDPAReconciler_ReconcileAzureWorkloadIdentitySecret(t *testing.T) {
 		})
 	}
 }
+
+func TestDPAReconciler_ReconcileCloudIdentities(t *testing.T) {
+	newDPA := func(identities ...oadpv1alpha1.CloudIdentity) *oadpv1alpha1.DataProtectionApplication {
+		return &oadpv1alpha1.DataProtectionApplication{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-dpa", Namespace: "test-ns", UID: "test-uid"},
+			Spec:       oadpv1alpha1.DataProtectionApplicationSpec{CloudIdentities: identities},
+		}
+	}
+	s3Role := oadpv1alpha1.CloudIdentity{Name: "s3-role", AWS: &oadpv1alpha1.AWSCloudIdentity{RoleARN: "arn:aws:iam::123456789012:role/s3"}}
+	ebsRole := oadpv1alpha1.CloudIdentity{Name: "ebs-role", AWS: &oadpv1alpha1.AWSCloudIdentity{RoleARN: "arn:aws:iam::123456789012:role/ebs"}}
+	gcpPool := oadpv1alpha1.CloudIdentity{Name: "gcp-pool", GCP: &oadpv1alpha1.GCPCloudIdentity{
+		ServiceAccountEmail: "velero@project.iam.gserviceaccount.com", ProjectNumber: "1", PoolID: "pool", ProviderID: "provider",
+	}}
+	rolesAnywhere := oadpv1alpha1.CloudIdentity{Name: "roles-anywhere", AWS: &oadpv1alpha1.AWSCloudIdentity{
+		RoleARN: "arn:aws:iam::123456789012:role/s3",
+		RolesAnywhere: &oadpv1alpha1.AWSRolesAnywhere{
+			TrustAnchorARN:    "arn:aws:rolesanywhere:us-east-1:123456789012:trust-anchor/ta",
+			ProfileARN:        "arn:aws:rolesanywhere:us-east-1:123456789012:profile/p",
+			CertificateSecret: "velero-cert",
+		},
+	}}
+	certificateSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "velero-cert", Namespace: "test-ns"},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("cert"), corev1.TLSPrivateKeyKey: []byte("key")},
+	}
+	podIdentity := oadpv1alpha1.CloudIdentity{Name: "pod-identity", AWS: &oadpv1alpha1.AWSCloudIdentity{PodIdentity: true}}
+
+	tests := []struct {
+		name          string
+		dpa           *oadpv1alpha1.DataProtectionApplication
+		objects       []client.Object
+		wantStatus    []oadpv1alpha1.CloudIdentityStatus
+		wantSecrets   map[string]string
+		wantDeleted   []string
+		wantErrorText string
+	}{
+		{
+			name: "one secret per identity",
+			dpa:  newDPA(s3Role, ebsRole, gcpPool),
+			wantStatus: []oadpv1alpha1.CloudIdentityStatus{
+				{Name: "s3-role", Provider: AWSProvider, Key: stsflow.AWSSecretCredentialsKey},
+				{Name: "ebs-role", Provider: AWSProvider, Key: stsflow.AWSSecretCredentialsKey},
+				{Name: "gcp-pool", Provider: GCPProvider, Key: stsflow.GcpSecretJSONKey},
+			},
+			wantSecrets: map[string]string{
+				"s3-role":  "role_arn = arn:aws:iam::123456789012:role/s3",
+				"ebs-role": "role_arn = arn:aws:iam::123456789012:role/ebs",
+				"gcp-pool": "workloadIdentityPools/pool/providers/provider",
+			},
+		},
+		{
+			name: "removed identity secret is deleted",
+			dpa:  newDPA(s3Role),
+			objects: []client.Object{
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+					Name: "ebs-role", Namespace: "test-ns",
+					Labels:          map[string]string{CloudIdentityLabel: "ebs-role"},
+					OwnerReferences: []metav1.OwnerReference{{APIVersion: oadpv1alpha1.GroupVersion.String(), Kind: "DataProtectionApplication", Name: "test-dpa", UID: "test-uid", Controller: ptr.To(true)}},
+				}},
+			},
+			wantStatus:  []oadpv1alpha1.CloudIdentityStatus{{Name: "s3-role", Provider: AWSProvider, Key: stsflow.AWSSecretCredentialsKey}},
+			wantSecrets: map[string]string{"s3-role": "role_arn = arn:aws:iam::123456789012:role/s3"},
+			wantDeleted: []string{"ebs-role"},
+		},
+		{
+			name:    "IAM Roles Anywhere and EKS Pod Identity",
+			dpa:     newDPA(rolesAnywhere, podIdentity),
+			objects: []client.Object{certificateSecret},
+			wantStatus: []oadpv1alpha1.CloudIdentityStatus{
+				{Name: "roles-anywhere", Provider: AWSProvider, Key: stsflow.AWSSecretCredentialsKey},
+				{Name: "pod-identity", Provider: AWSProvider, Key: stsflow.AWSSecretCredentialsKey},
+			},
+			wantSecrets: map[string]string{
+				"roles-anywhere": "credential_process = aws_signing_helper credential-process --certificate /credentials-rolesanywhere/velero-cert/tls.crt --private-key /credentials-rolesanywhere/velero-cert/tls.key",
+				"pod-identity":   "credential_source = EcsContainer",
+			},
+		},
+		{
+			name:          "IAM Roles Anywhere certificate secret is missing",
+			dpa:           newDPA(rolesAnywhere),
+			wantErrorText: "cloud identity roles-anywhere: unable to get certificate secret velero-cert",
+		},
+		{
+			name: "IAM Roles Anywhere certificate secret without private key",
+			dpa:  newDPA(rolesAnywhere),
+			objects: []client.Object{&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "velero-cert", Namespace: "test-ns"},
+				Data:       map[string][]byte{corev1.TLSCertKey: []byte("cert")},
+			}},
+			wantErrorText: "certificate secret velero-cert has no tls.key",
+		},
+		{
+			name:          "EKS Pod Identity with a role",
+			dpa:           newDPA(oadpv1alpha1.CloudIdentity{Name: "pod-identity", AWS: &oadpv1alpha1.AWSCloudIdentity{PodIdentity: true, RoleARN: "arn:aws:iam::123456789012:role/s3"}}),
+			wantErrorText: "aws.roleARN must be empty with aws.podIdentity",
+		},
+		{
+			name: "EKS Pod Identity and IAM Roles Anywhere",
+			dpa: newDPA(oadpv1alpha1.CloudIdentity{Name: "both", AWS: &oadpv1alpha1.AWSCloudIdentity{
+				PodIdentity: true, RoleARN: "arn:aws:iam::123456789012:role/s3", RolesAnywhere: rolesAnywhere.AWS.RolesAnywhere,
+			}}),
+			wantErrorText: "cloud identity both must set at most one of aws.rolesAnywhere or aws.podIdentity",
+		},
+		{
+			name:          "AWS identity without role",
+			dpa:           newDPA(oadpv1alpha1.CloudIdentity{Name: "no-role", AWS: &oadpv1alpha1.AWSCloudIdentity{}}),
+			wantErrorText: "cloud identity no-role: aws.roleARN must be set",
+		},
+		{
+			name:          "existing secret is not overwritten",
+			dpa:           newDPA(s3Role),
+			objects:       []client.Object{&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "s3-role", Namespace: "test-ns"}}},
+			wantErrorText: "secret s3-role already exists and is not managed by the DataProtectionApplication",
+		},
+		{
+			name:          "identity without provider",
+			dpa:           newDPA(oadpv1alpha1.CloudIdentity{Name: "empty"}),
+			wantErrorText: "cloud identity empty must set exactly one of aws, gcp or azure",
+		},
+		{
+			name: "Azure identities with different clients",
+			dpa: newDPA(
+				oadpv1alpha1.CloudIdentity{Name: "azure-a", Azure: &oadpv1alpha1.AzureCloudIdentity{ClientID: "a", TenantID: "t", SubscriptionID: "s"}},
+				oadpv1alpha1.CloudIdentity{Name: "azure-b", Azure: &oadpv1alpha1.AzureCloudIdentity{ClientID: "b", TenantID: "t", SubscriptionID: "s"}},
+			),
+			wantErrorText: "all Azure identities must use the same clientID and tenantID",
+		},
+		{
+			name: "BSL references an identity with the wrong key",
+			dpa: func() *oadpv1alpha1.DataProtectionApplication {
+				dpa := newDPA(s3Role)
+				dpa.Spec.BackupLocations = []oadpv1alpha1.BackupLocation{{Velero: &velerov1.BackupStorageLocationSpec{
+					Provider:   AWSProvider,
+					Credential: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "s3-role"}, Key: "cloud"},
+				}}}
+				return dpa
+			}(),
+			wantErrorText: "cloud identity s3-role must be referenced with key credentials",
+		},
+		{
+			name: "VSL references an identity of another provider",
+			dpa: func() *oadpv1alpha1.DataProtectionApplication {
+				dpa := newDPA(gcpPool)
+				dpa.Spec.SnapshotLocations = []oadpv1alpha1.SnapshotLocation{{Velero: &velerov1.VolumeSnapshotLocationSpec{
+					Provider:   AWSProvider,
+					Credential: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "gcp-pool"}, Key: stsflow.GcpSecretJSONKey},
+				}}}
+				return dpa
+			}(),
+			wantErrorText: "cloud identity gcp-pool is a gcp identity and cannot be used with provider aws",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := getFakeClientFromObjectsForTest(t, append(tt.objects, tt.dpa)...)
+			r := &DataProtectionApplicationReconciler{
+				Client:        fakeClient,
+				Scheme:        fakeClient.Scheme(),
+				dpa:           tt.dpa,
+				Log:           logr.Discard(),
+				Context:       newContextForTest(),
+				EventRecorder: newEventRecorder(),
+			}
+
+			ok, err := r.ReconcileCloudIdentities(r.Log)
+			if tt.wantErrorText != "" {
+				require.ErrorContains(t, err, tt.wantErrorText)
+				require.False(t, ok)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, tt.wantStatus, tt.dpa.Status.CloudIdentities)
+
+			for name, content := range tt.wantSecrets {
+				secret := &corev1.Secret{}
+				require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Namespace: "test-ns", Name: name}, secret))
+				require.Equal(t, stsflow.STSSecretLabelValue, secret.Labels[stsflow.STSSecretLabelKey])
+				require.Equal(t, name, secret.Labels[CloudIdentityLabel])
+				require.True(t, metav1.IsControlledBy(secret, tt.dpa))
+				require.Len(t, secret.Data, 1)
+				for _, value := range secret.Data {
+					require.Contains(t, string(value), content)
+				}
+			}
+			for _, name := range tt.wantDeleted {
+				err := fakeClient.Get(context.Background(), types.NamespacedName{Namespace: "test-ns", Name: name}, &corev1.Secret{})
+				require.True(t, k8serror.IsNotFound(err), "secret %s must be deleted", name)
+			}
+		})
+	}
+}
@@ -25,19 +25,31 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
 	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 
 	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/tlsprofile"
 )
 
-// buildTLSConfig creates a TLS configuration based on the DPT spec and BSL spec.
+// buildTLSConfig creates a TLS configuration based on the DPT spec, BSL spec and cluster proxy. The minimum version
+// and cipher suites follow the cluster TLS security profile, if any.
 // Priority order:
 // 1. If skipTLSVerify is true → InsecureSkipVerify: true
-// 2. If BSL has caCert → Use custom CA cert
-// 3. Otherwise → Use system certs (default)
-func buildTLSConfig(dpt *oadpv1alpha1.DataProtectionTest, bsl *velerov1.BackupStorageLocationSpec, logger logr.Logger) (*tls.Config, error) {
+// 2. If BSL has caCert → Use custom CA cert, with the custom trusted CA bundle of the cluster
+// 3. If the cluster trusts a custom CA → Use the trusted CA bundle of the cluster, system certs included
+// 4. Otherwise → Use system certs (default)
+func buildTLSConfig(dpt *oadpv1alpha1.DataProtectionTest, bsl *velerov1.BackupStorageLocationSpec, clusterProxy *clusterProxyConfig, tlsProfile *configv1.TLSProfileSpec, logger logr.Logger) (*tls.Config, error) {
 	tlsConfig := &tls.Config{}
 
+	if tlsProfile != nil {
+		applyTLSProfile, _, err := tlsprofile.TLSConfigFunc(*tlsProfile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cluster TLS security profile: %w", err)
+		}
+		applyTLSProfile(tlsConfig)
+	}
+
 	// Priority 1: Check if skipTLSVerify is set
 	if dpt.Spec.SkipTLSVerify {
 		logger.Info("TLS verification disabled via skipTLSVerify")
@@ -58,24 +70,39 @@ func buildTLSConfig(dpt *oadpv1alpha1.DataProtectionTest, bsl *velerov1.BackupSt
 			return nil, fmt.Errorf("failed to parse CA certificate")
 		}
 
+		if clusterProxy.hasTrustedCABundle() {
+			caCertPool.AppendCertsFromPEM(clusterProxy.trustedCABundle)
+		}
 		tlsConfig.RootCAs = caCertPool
 		logger.Info("Successfully configured custom CA certificate")
 		return tlsConfig, nil
 	}
 
-	// Priority 3: Use system certificates (default behavior)
+	// Priority 3: Check for the custom trusted CA bundle of the cluster
+	if clusterProxy.hasTrustedCABundle() {
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(clusterProxy.trustedCABundle) {
+			return nil, fmt.Errorf("failed to parse the cluster trusted CA bundle")
+		}
+		tlsConfig.RootCAs = caCertPool
+		logger.Info("Using the cluster trusted CA bundle")
+		return tlsConfig, nil
+	}
+
+	// Priority 4: Use system certificates (default behavior)
 	logger.Info("Using system default certificates")
 	return tlsConfig, nil
 }
 
-// buildHTTPClientWithTLS creates an HTTP client with the appropriate TLS configuration
-func buildHTTPClientWithTLS(dpt *oadpv1alpha1.DataProtectionTest, bsl *velerov1.BackupStorageLocationSpec, logger logr.Logger) (*http.Client, error) {
-	tlsConfig, err := buildTLSConfig(dpt, bsl, logger)
+// buildHTTPClientWithTLS creates an HTTP client with the appropriate TLS configuration, through the cluster proxy
+func buildHTTPClientWithTLS(dpt *oadpv1alpha1.DataProtectionTest, bsl *velerov1.BackupStorageLocationSpec, clusterProxy *clusterProxyConfig, tlsProfile *configv1.TLSProfileSpec, logger logr.Logger) (*http.Client, error) {
+	tlsConfig, err := buildTLSConfig(dpt, bsl, clusterProxy, tlsProfile, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build TLS config: %w", err)
 	}
 
 	transport := &http.Transport{
+		Proxy:           clusterProxy.proxyFunc(),
 		TLSClientConfig: tlsConfig,
 	}
 
@@ -86,15 +113,16 @@ func buildHTTPClientWithTLS(dpt *oadpv1alpha1.DataProtectionTest, bsl *velerov1.
 	return client, nil
 }
 
-// buildAWSSessionWithTLS creates an AWS session with the appropriate TLS configuration
-func buildAWSSessionWithTLS(dpt *oadpv1alpha1.DataProtectionTest, bsl *velerov1.BackupStorageLocationSpec, region, endpoint string, logger logr.Logger) (*session.Session, error) {
-	tlsConfig, err := buildTLSConfig(dpt, bsl, logger)
+// buildAWSSessionWithTLS creates an AWS session with the appropriate TLS configuration, through the cluster proxy
+func buildAWSSessionWithTLS(dpt *oadpv1alpha1.DataProtectionTest, bsl *velerov1.BackupStorageLocationSpec, clusterProxy *clusterProxyConfig, tlsProfile *configv1.TLSProfileSpec, region, endpoint string, logger logr.Logger) (*session.Session, error) {
+	tlsConfig, err := buildTLSConfig(dpt, bsl, clusterProxy, tlsProfile, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build TLS config: %w", err)
 	}
 
 	httpClient := &http.Client{
 		Transport: &http.Transport{
+			Proxy:           clusterProxy.proxyFunc(),
 			TLSClientConfig: tlsConfig,
 		},
 	}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"context"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/openshift/oadp-operator/pkg/tlsprofile"
+)
+
+//+kubebuilder:rbac:groups=config.openshift.io,resources=apiservers,verbs=get;list;watch
+
+// TLSSecurityProfileWatcher watches the TLS security profile of the cluster APIServer configuration. The metrics and
+// webhook servers of the operator can not change their TLS configuration once started, so OnProfileChange restarts
+// the operator when the profile differs from the one it was started with.
+type TLSSecurityProfileWatcher struct {
+	client.Client
+	// InitialProfileSpec is the profile applied to the metrics and webhook servers at startup
+	InitialProfileSpec configv1.TLSProfileSpec
+	// OnProfileChange stops the manager, the operator pod is then restarted with the new profile
+	OnProfileChange func()
+}
+
+func (w *TLSSecurityProfileWatcher) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	spec, err := tlsprofile.GetAPIServerProfileSpec(ctx, w.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// empty and nil cipher lists are equal
+	if equality.Semantic.DeepEqual(spec, w.InitialProfileSpec) {
+		return ctrl.Result{}, nil
+	}
+	logger.Info("Cluster TLS security profile changed, restarting the operator",
+		"minTLSVersion", spec.MinTLSVersion, "previousMinTLSVersion", w.InitialProfileSpec.MinTLSVersion)
+	w.OnProfileChange()
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the watcher of the cluster APIServer configuration with the Manager.
+func (w *TLSSecurityProfileWatcher) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("tlssecurityprofilewatcher").
+		For(&configv1.APIServer{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(object client.Object) bool {
+			return object.GetName() == tlsprofile.APIServerName
+		}))).
+		Complete(w)
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/openshift/oadp-operator/pkg/tlsprofile"
+)
+
+func TestTLSSecurityProfileWatcher_Reconcile(t *testing.T) {
+	apiServer := &configv1.APIServer{ObjectMeta: metav1.ObjectMeta{Name: tlsprofile.APIServerName}}
+	fakeClient := getFakeClientFromObjectsForTest(t, apiServer)
+	restarts := 0
+	watcher := &TLSSecurityProfileWatcher{
+		Client:             fakeClient,
+		InitialProfileSpec: *configv1.TLSProfiles[configv1.TLSProfileIntermediateType],
+		OnProfileChange:    func() { restarts++ },
+	}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: tlsprofile.APIServerName}}
+
+	_, err := watcher.Reconcile(newContextForTest(), request)
+	require.NoError(t, err)
+	require.Zero(t, restarts, "no profile is the default intermediate profile")
+
+	apiServer.Spec.TLSSecurityProfile = &configv1.TLSSecurityProfile{
+		Type:         configv1.TLSProfileIntermediateType,
+		Intermediate: &configv1.IntermediateTLSProfile{},
+	}
+	require.NoError(t, fakeClient.Update(newContextForTest(), apiServer))
+	_, err = watcher.Reconcile(newContextForTest(), request)
+	require.NoError(t, err)
+	require.Zero(t, restarts, "the explicit intermediate profile is unchanged")
+
+	apiServer.Spec.TLSSecurityProfile = &configv1.TLSSecurityProfile{Type: configv1.TLSProfileModernType, Modern: &configv1.ModernTLSProfile{}}
+	require.NoError(t, fakeClient.Update(newContextForTest(), apiServer))
+	_, err = watcher.Reconcile(newContextForTest(), request)
+	require.NoError(t, err)
+	require.Equal(t, 1, restarts)
+}
@@ -100,6 +100,27 @@ func (r *DataProtectionApplicationReconciler) ValidateDataProtectionCR(log logr.
 		}
 	}
 
+	if r.dpa.Spec.Configuration.NodeAgent != nil {
+		if err := validateNodeAgentMaintenanceWindows(r.dpa.Spec.Configuration.NodeAgent.MaintenanceWindows); err != nil {
+			return false, err
+		}
+		if err := validatePodSchedulingConfig("spec.configuration.nodeAgent", r.dpa.Spec.Configuration.NodeAgent.PodConfig, r.dpa.Spec.Configuration.NodeAgent.LoadAffinityConfig); err != nil {
+			return false, err
+		}
+	}
+	if r.dpa.Spec.Configuration.Velero != nil {
+		if err := validatePodSchedulingConfig("spec.configuration.velero", r.dpa.Spec.Configuration.Velero.PodConfig, r.dpa.Spec.Configuration.Velero.LoadAffinityConfig); err != nil {
+			return false, err
+		}
+	}
+
+	if err := validateSchedulePolicies(r.dpa.Spec.SchedulePolicies); err != nil {
+		return false, err
+	}
+	if err := validateNetworkPolicyConfig(r.dpa.Spec.NetworkPolicy); err != nil {
+		return false, err
+	}
+
 	// ENSURE UPGRADES --------------------------------------------------------
 	// check for VSM/Volsync DataMover (OADP 1.2 or below) syntax
 	if r.dpa.Spec.Features != nil && r.dpa.Spec.Features.DataMover != nil {
@@ -342,7 +363,7 @@ func (r *DataProtectionApplicationReconciler) ValidateVeleroPlugins() (bool, err
 			secretNamesToValidate := mapset.NewSet[string]()
 			// check specified credentials in backup locations exists in the cluster
 			for _, location := range dpa.Spec.BackupLocations {
-				if location.Velero != nil {
+				if location.Velero != nil && location.ExternalCredential == nil {
 					provider := strings.TrimPrefix(location.Velero.Provider, veleroIOPrefix)
 					if provider == string(plugin) && location.Velero != nil {
 						if location.Velero.Credential != nil {
@@ -355,7 +376,7 @@ func (r *DataProtectionApplicationReconciler) ValidateVeleroPlugins() (bool, err
 			}
 			// check specified credentials in snapshot locations exists in the cluster
 			for _, location := range dpa.Spec.SnapshotLocations {
-				if location.Velero != nil {
+				if location.Velero != nil && location.ExternalCredential == nil {
 					provider := strings.TrimPrefix(location.Velero.Provider, veleroIOPrefix)
 					if provider == string(plugin) && location.Velero != nil {
 						if location.Velero.Credential != nil {
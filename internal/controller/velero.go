@@ -13,7 +13,6 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/vmware-tanzu/velero/pkg/install"
 	"github.com/vmware-tanzu/velero/pkg/util/boolptr"
-	"github.com/vmware-tanzu/velero/pkg/util/kube"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -264,18 +263,8 @@ func (r *DataProtectionApplicationReconciler) customizeVeleroDeployment(veleroDe
 			veleroDeployment.Spec.Template.Spec.NodeSelector = dpa.Spec.Configuration.Velero.PodConfig.NodeSelector
 		}
 	}
-
-	if dpa.Spec.Configuration.Velero.LoadAffinityConfig != nil {
-		veleroAffinityStruct := make([]*kube.LoadAffinity, len(dpa.Spec.Configuration.Velero.LoadAffinityConfig))
-
-		for i, aff := range dpa.Spec.Configuration.Velero.LoadAffinityConfig {
-			veleroAffinityStruct[i] = &kube.LoadAffinity{
-				NodeSelector: aff.NodeSelector,
-			}
-		}
-		affinity := kube.ToSystemAffinity(veleroAffinityStruct)
-		veleroDeployment.Spec.Template.Spec.Affinity = affinity
-	}
+	applyPodSchedulingConfig(&veleroDeployment.Spec.Template.Spec, dpa.Spec.Configuration.Velero.PodConfig)
+	applyLoadAffinity(&veleroDeployment.Spec.Template.Spec, dpa.Spec.Configuration.Velero.LoadAffinityConfig)
 
 	veleroDeployment.Spec.Template.Spec.Volumes = append(veleroDeployment.Spec.Template.Spec.Volumes,
 		corev1.Volume{
@@ -458,14 +447,16 @@ func (r *DataProtectionApplicationReconciler) customizeVeleroDeployment(veleroDe
 		}
 	}
 
-	// Process CA certificates from BackupStorageLocations if backupImages is true or nil (nil means true)
-	if dpa.BackupImages() {
+	// Process CA certificates from BackupStorageLocations if backupImages is true or nil (nil means true),
+	// or when the cluster trusts a custom CA
+	if dpa.BackupImages() || r.clusterProxy.hasTrustedCABundle() {
 		if err := r.processCACertificatesForVelero(veleroDeployment, veleroContainer); err != nil {
 			return fmt.Errorf("failed to process CA certificates: %w", err)
 		}
 	}
 
-	return nil
+	// roll out the Velero pod when a mounted credential or CA bundle changes
+	return r.setCredentialsHashAnnotation(&veleroDeployment.Spec.Template, veleroDeployment.Namespace)
 }
 
 // add plugin specific specs to velero deployment
@@ -543,6 +534,20 @@ func (r *DataProtectionApplicationReconciler) appendPluginSpecificSpecs(veleroDe
 				})
 		}
 	}
+	// mount the credentials of locations using an external secret store
+	externalVolumes, externalMounts := credentials.ExternalCredentialVolumes(dpa)
+	veleroDeployment.Spec.Template.Spec.Volumes = append(veleroDeployment.Spec.Template.Spec.Volumes, externalVolumes...)
+	veleroContainer.VolumeMounts = append(veleroContainer.VolumeMounts, externalMounts...)
+	// mount the credentials of locations using mountCredential
+	locationVolumes, locationMounts := credentials.LocationCredentialVolumes(dpa)
+	veleroDeployment.Spec.Template.Spec.Volumes = append(veleroDeployment.Spec.Template.Spec.Volumes, locationVolumes...)
+	veleroContainer.VolumeMounts = append(veleroContainer.VolumeMounts, locationMounts...)
+	// mount the IAM Roles Anywhere certificates and EKS Pod Identity token of the AWS cloud identities
+	awsIdentityVolumes, awsIdentityMounts := credentials.AWSCloudIdentityVolumes(dpa)
+	veleroDeployment.Spec.Template.Spec.Volumes = append(veleroDeployment.Spec.Template.Spec.Volumes, awsIdentityVolumes...)
+	veleroContainer.VolumeMounts = append(veleroContainer.VolumeMounts, awsIdentityMounts...)
+	veleroContainer.Env = common.AppendUniqueEnvVars(veleroContainer.Env, credentials.AWSCloudIdentityEnv(dpa))
+
 	// append custom plugin init containers
 	if dpa.Spec.Configuration.Velero.CustomPlugins != nil {
 		for _, plugin := range dpa.Spec.Configuration.Velero.CustomPlugins {
@@ -633,8 +638,8 @@ func (r *DataProtectionApplicationReconciler) customizeVeleroContainer(veleroCon
 	if dpa.Spec.Configuration != nil && dpa.Spec.Configuration.Velero != nil && dpa.Spec.Configuration.Velero.PodConfig != nil && dpa.Spec.Configuration.Velero.PodConfig.Env != nil {
 		veleroContainer.Env = common.AppendUniqueEnvVars(veleroContainer.Env, dpa.Spec.Configuration.Velero.PodConfig.Env)
 	}
-	// Append proxy settings to the container from environment variables
-	veleroContainer.Env = common.AppendUniqueEnvVars(veleroContainer.Env, proxy.ReadProxyVarsFromEnv())
+	// Append proxy settings to the container from environment variables, then from the cluster proxy
+	veleroContainer.Env = common.AppendUniqueEnvVars(veleroContainer.Env, proxy.ReadProxyVarsFromEnv(), r.clusterProxy.envVars())
 	if dpa.BackupImages() {
 		veleroContainer.Env = common.AppendUniqueEnvVars(veleroContainer.Env, []corev1.EnvVar{{
 			Name:  "OPENSHIFT_IMAGESTREAM_BACKUP",
@@ -643,8 +648,7 @@ func (r *DataProtectionApplicationReconciler) customizeVeleroContainer(veleroCon
 	}
 
 	// Add Azure workload identity environment variables if using Azure STS
-	azureClientID := os.Getenv(stsflow.ClientIDEnvKey)
-	if azureClientID != "" && os.Getenv(stsflow.TenantIDEnvKey) != "" && os.Getenv(stsflow.SubscriptionIDEnvKey) != "" {
+	if _, _, found := azureWorkloadIdentity(dpa); found {
 		// Use envFrom to reference the secret containing Azure workload identity env vars
 		if veleroContainer.EnvFrom == nil {
 			veleroContainer.EnvFrom = []corev1.EnvFromSource{}
@@ -824,21 +828,29 @@ func getResourceReqs(dpa *corev1.ResourceRequirements) (corev1.ResourceRequireme
 	return resourcesReqs, nil
 }
 
-// Get Velero Resource Requirements
+// Get Velero Resource Requirements, with the applied resource recommendation
 func (r *DataProtectionApplicationReconciler) getVeleroResourceReqs() (corev1.ResourceRequirements, error) {
 	dpa := r.dpa
 	if dpa.Spec.Configuration.Velero != nil && dpa.Spec.Configuration.Velero.PodConfig != nil {
-		return getResourceReqs(&dpa.Spec.Configuration.Velero.PodConfig.ResourceAllocations)
+		reqs, err := getResourceReqs(&dpa.Spec.Configuration.Velero.PodConfig.ResourceAllocations)
+		if err != nil {
+			return reqs, err
+		}
+		return applyResourceRecommendation(dpa, common.Velero, reqs), nil
 	}
-	return *defaultContainerResourceRequirements.DeepCopy(), nil
+	return applyResourceRecommendation(dpa, common.Velero, *defaultContainerResourceRequirements.DeepCopy()), nil
 }
 
-// Get NodeAgent Resource Requirements
+// Get NodeAgent Resource Requirements, with the applied resource recommendation
 func getNodeAgentResourceReqs(dpa *oadpv1alpha1.DataProtectionApplication) (corev1.ResourceRequirements, error) {
 	if dpa.Spec.Configuration.NodeAgent != nil && dpa.Spec.Configuration.NodeAgent.PodConfig != nil {
-		return getResourceReqs(&dpa.Spec.Configuration.NodeAgent.PodConfig.ResourceAllocations)
+		reqs, err := getResourceReqs(&dpa.Spec.Configuration.NodeAgent.PodConfig.ResourceAllocations)
+		if err != nil {
+			return reqs, err
+		}
+		return applyResourceRecommendation(dpa, common.NodeAgent, reqs), nil
 	}
-	return *defaultContainerResourceRequirements.DeepCopy(), nil
+	return applyResourceRecommendation(dpa, common.NodeAgent, *defaultContainerResourceRequirements.DeepCopy()), nil
 }
 
 // noDefaultCredentials determines if a provider needs the default credentials.
@@ -861,11 +873,11 @@ func (r DataProtectionApplicationReconciler) noDefaultCredentials() (map[string]
 		}
 	} else {
 		for _, bsl := range dpa.Spec.BackupLocations {
-			if bsl.Velero != nil && bsl.Velero.Credential == nil {
+			if bsl.Velero != nil && bsl.Velero.Credential == nil && bsl.ExternalCredential == nil {
 				bslProvider := strings.TrimPrefix(bsl.Velero.Provider, veleroIOPrefix)
 				providerNeedsDefaultCreds[bslProvider] = true
 			}
-			if bsl.Velero != nil && bsl.Velero.Credential != nil {
+			if bsl.Velero != nil && (bsl.Velero.Credential != nil || bsl.ExternalCredential != nil) {
 				bslProvider := strings.TrimPrefix(bsl.Velero.Provider, veleroIOPrefix)
 				if _, found := providerNeedsDefaultCreds[bslProvider]; !found {
 					providerNeedsDefaultCreds[bslProvider] = false
@@ -890,7 +902,7 @@ func (r DataProtectionApplicationReconciler) noDefaultCredentials() (map[string]
 			// To handle the case where we want to manually hand the credentials for a cloud storage created
 			// Bucket credentials via configuration. Only AWS is supported
 			provider := strings.TrimPrefix(vsl.Velero.Provider, veleroIOPrefix)
-			if vsl.Velero.Credential != nil || provider == string(oadpv1alpha1.AWSBucketProvider) && hasCloudStorage {
+			if vsl.Velero.Credential != nil || vsl.ExternalCredential != nil || provider == string(oadpv1alpha1.AWSBucketProvider) && hasCloudStorage {
 				if _, found := providerNeedsDefaultCreds[provider]; !found {
 					providerNeedsDefaultCreds[provider] = false
 				}
@@ -964,6 +976,12 @@ func (r *DataProtectionApplicationReconciler) processCACertificatesForVelero(vel
 	}
 	veleroContainer.Env = append(veleroContainer.Env, awsCaBundleEnv)
 
+	// The bundle holds the cluster trusted CAs, it replaces the system certificates of every client of the Velero
+	// server, the plugins and the repository maintenance jobs that inherit the Velero environment
+	if r.clusterProxy.hasTrustedCABundle() {
+		veleroContainer.Env = common.AppendUniqueEnvVars(veleroContainer.Env, []corev1.EnvVar{{Name: sslCertFileEnvVar, Value: caBundleFullPath}})
+	}
+
 	r.Log.Info("Configured CA certificate bundle for Velero", "configMap", configMapName, "mountPath", caBundleFullPath)
 	return nil
 }
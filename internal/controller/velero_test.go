@@ -4,8 +4,10 @@ import (
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/hex"
 	"encoding/pem"
 	"fmt"
 	"math/big"
@@ -633,6 +635,12 @@ type TestBuiltVeleroDeploymentOptions struct {
 	loadAffinity     []corev1.NodeSelectorTerm
 }
 
+// caBundleCredentialsHash returns the credentials hash of a pod template mounting only the CA bundle ConfigMap with caBundle
+func caBundleCredentialsHash(caBundle string) string {
+	hash := sha256.Sum256([]byte(configMapRefKind + "/" + caBundleConfigMapName + "\x00" + caBundleFileName + "\x00" + caBundle + "\x00"))
+	return hex.EncodeToString(hash[:])
+}
+
 func createTestBuiltVeleroDeployment(options TestBuiltVeleroDeploymentOptions) *appsv1.Deployment {
 	testBuiltVeleroDeployment := &appsv1.Deployment{
 		ObjectMeta: baseObjectMeta,
@@ -2447,6 +2455,7 @@ func TestDPAReconciler_buildVeleroDeployment(t *testing.T) {
 					defaultRestoreResourcePriorities,
 					defaultDisableInformerCache,
 				},
+				annotations: map[string]string{CredentialsHashAnnotation: caBundleCredentialsHash(string(awsTestCACert))},
 				volumes: []corev1.Volume{
 					{
 						Name: caCertVolumeName,
@@ -2523,6 +2532,7 @@ func TestDPAReconciler_buildVeleroDeployment(t *testing.T) {
 					defaultRestoreResourcePriorities,
 					defaultDisableInformerCache,
 				},
+				annotations: map[string]string{CredentialsHashAnnotation: caBundleCredentialsHash(string(awsTestCACert) + string(dummy2TestCACert))},
 				volumes: []corev1.Volume{
 					{
 						Name: caCertVolumeName,
@@ -2610,6 +2620,7 @@ func TestDPAReconciler_buildVeleroDeployment(t *testing.T) {
 					defaultRestoreResourcePriorities,
 					defaultDisableInformerCache,
 				},
+				annotations: map[string]string{CredentialsHashAnnotation: caBundleCredentialsHash(string(awsTestCACert) + string(dummy2TestCACert))},
 				volumes: []corev1.Volume{
 					{
 						Name: caCertVolumeName,
@@ -2696,6 +2707,7 @@ func TestDPAReconciler_buildVeleroDeployment(t *testing.T) {
 					defaultRestoreResourcePriorities,
 					defaultDisableInformerCache,
 				},
+				annotations: map[string]string{CredentialsHashAnnotation: caBundleCredentialsHash(string(cloudStorageTestCACert))},
 				volumes: []corev1.Volume{
 					{
 						Name: caCertVolumeName,
@@ -2802,6 +2814,7 @@ func TestDPAReconciler_buildVeleroDeployment(t *testing.T) {
 					defaultRestoreResourcePriorities,
 					defaultDisableInformerCache,
 				},
+				annotations: map[string]string{CredentialsHashAnnotation: caBundleCredentialsHash(string(dummy2TestCACert) + string(cloudStorageTestCACert))},
 				volumes: []corev1.Volume{
 					{
 						Name: caCertVolumeName,
@@ -2835,12 +2848,13 @@ func TestDPAReconciler_buildVeleroDeployment(t *testing.T) {
 				t.Errorf("error in creating fake client, likely programmer error")
 			}
 			r := DataProtectionApplicationReconciler{
-				Client:        fakeClient,
-				dpa:           test.dpa,
-				Scheme:        fakeClient.Scheme(),
-				Log:           logr.Discard(),
-				Context:       newContextForTest(),
-				EventRecorder: record.NewFakeRecorder(10),
+				Client:               fakeClient,
+				dpa:                  test.dpa,
+				Scheme:               fakeClient.Scheme(),
+				Log:                  logr.Discard(),
+				Context:              newContextForTest(),
+				EventRecorder:        record.NewFakeRecorder(10),
+				systemCACertificates: func() []byte { return nil },
 			}
 			if test.dpa != nil {
 				r.NamespacedName = types.NamespacedName{
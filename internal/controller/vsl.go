@@ -54,6 +54,10 @@ var validAzureKeys = map[string]bool{
 func (r *DataProtectionApplicationReconciler) LabelVSLSecrets(log logr.Logger) (bool, error) {
 	dpa := r.dpa
 	for _, vsl := range dpa.Spec.SnapshotLocations {
+		// credentials of an external secret store are not Secrets
+		if vsl.ExternalCredential != nil {
+			continue
+		}
 		provider := strings.TrimPrefix(vsl.Velero.Provider, veleroIOPrefix)
 		switch provider {
 		case "aws":
@@ -175,6 +179,19 @@ func (r *DataProtectionApplicationReconciler) ValidateVolumeSnapshotLocations()
 			}
 		}
 
+		if vslSpec.MountCredential {
+			if err := validateMountCredential(vslYAMLPath, vslSpec.Velero.Credential, vslSpec.ExternalCredential, vslSpec.Velero.Config); err != nil {
+				return false, err
+			}
+		}
+
+		if vslSpec.ExternalCredential != nil {
+			if err := r.validateExternalCredential(vslYAMLPath, vslSpec.ExternalCredential, vslSpec.Velero.Credential, vslSpec.Velero.Config); err != nil {
+				return false, err
+			}
+			continue
+		}
+
 		if err := r.ensureVslSecretDataExists(&vslSpec); err != nil {
 			return false, err
 		}
@@ -228,6 +245,16 @@ func (r *DataProtectionApplicationReconciler) ReconcileVolumeSnapshotLocations(l
 			}
 
 			vsl.Spec = *vslSpec.Velero
+			// Use the credentials mounted from the external secret store
+			if vslSpec.ExternalCredential != nil {
+				vsl.Spec.Config = externalCredentialConfig(vsl.Spec.Config, vslSpec.ExternalCredential)
+				vsl.Spec.Credential = nil
+			}
+			// Use the credential secret key mounted for this location only
+			if vslSpec.MountCredential && vslSpec.Velero.Credential != nil {
+				vsl.Spec.Config = mountedCredentialConfig(vsl.Spec.Config, vslSpec.Velero.Credential)
+				vsl.Spec.Credential = nil
+			}
 			return nil
 		})
 		if err != nil {
@@ -1,16 +1,19 @@
 package bucket
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/cloudprovider"
 )
 
 type awsBucketClient struct {
@@ -18,15 +21,15 @@ type awsBucketClient struct {
 	client client.Client
 }
 
-func (a awsBucketClient) Exists() (bool, error) {
-	s3Client, err := a.getS3Client()
+func (a awsBucketClient) Exists(ctx context.Context) (bool, error) {
+	s3Client, err := a.getS3Client(ctx)
 	if err != nil {
 		return false, err
 	}
 	input := &s3.HeadBucketInput{
 		Bucket: aws.String(a.bucket.Spec.Name),
 	}
-	_, err = s3Client.HeadBucket(input)
+	_, err = s3Client.HeadBucketWithContext(ctx, input)
 	if err != nil {
 		if aerr, ok := err.(awserr.Error); ok {
 			switch aerr.Code() {
@@ -44,7 +47,7 @@ func (a awsBucketClient) Exists() (bool, error) {
 		}
 	}
 
-	err = a.tagBucket()
+	err = a.tagBucket(ctx)
 	if err != nil {
 		return true, err
 	}
@@ -52,8 +55,8 @@ func (a awsBucketClient) Exists() (bool, error) {
 	return true, nil
 }
 
-func (a awsBucketClient) Create() (bool, error) {
-	s3Client, err := a.getS3Client()
+func (a awsBucketClient) Create(ctx context.Context) (bool, error) {
+	s3Client, err := a.getS3Client(ctx)
 	if err != nil {
 		return false, err
 	}
@@ -71,13 +74,13 @@ func (a awsBucketClient) Create() (bool, error) {
 		return false, fmt.Errorf("unable to validate %v bucket creation configuration: %v", a.bucket.Spec.Name, err)
 	}
 
-	_, err = s3Client.CreateBucket(createBucketInput)
+	_, err = s3Client.CreateBucketWithContext(ctx, createBucketInput)
 	if err != nil {
 		return false, err
 	}
 
 	// tag Bucket.
-	err = a.tagBucket()
+	err = a.tagBucket(ctx)
 	if err != nil {
 		return true, err
 	}
@@ -85,20 +88,20 @@ func (a awsBucketClient) Create() (bool, error) {
 	return true, nil
 }
 
-func (a awsBucketClient) tagBucket() error {
-	s3Client, err := a.getS3Client()
+func (a awsBucketClient) tagBucket(ctx context.Context) error {
+	s3Client, err := a.getS3Client(ctx)
 	// Clear bucket tags.
 	if err != nil {
 		return err
 	}
 	deleteInput := &s3.DeleteBucketTaggingInput{Bucket: aws.String(a.bucket.Spec.Name)}
-	_, err = s3Client.DeleteBucketTagging(deleteInput)
+	_, err = s3Client.DeleteBucketTaggingWithContext(ctx, deleteInput)
 	if err != nil {
 		return err
 	}
 	input := CreateBucketTaggingInput(a.bucket.Spec.Name, a.bucket.Spec.Tags)
 
-	_, err = s3Client.PutBucketTagging(input)
+	_, err = s3Client.PutBucketTaggingWithContext(ctx, input)
 	if err != nil {
 		return err
 	}
@@ -124,22 +127,35 @@ func CreateBucketTaggingInput(bucketname string, tags map[string]string) *s3.Put
 	return putInput
 }
 
-func (a awsBucketClient) getS3Client() (s3iface.S3API, error) {
+func (a awsBucketClient) getS3Client(ctx context.Context) (s3iface.S3API, error) {
 	awsConfig := &aws.Config{Region: &a.bucket.Spec.Region}
-	cred, err := getCredentialFromCloudStorageSecret(a.client, a.bucket)
+	cred, err := defaultCredentialProvider.Credentials(ctx, a.client, a.bucket)
 	if err != nil {
 		return nil, err
 	}
 
 	opts := session.Options{
-		Config:            *awsConfig,
-		SharedConfigFiles: []string{cred},
+		Config: *awsConfig,
 	}
 
 	if a.bucket.Spec.EnableSharedConfig != nil && *a.bucket.Spec.EnableSharedConfig {
 		opts.SharedConfigState = session.SharedConfigEnable
 	}
 
+	// Static keys are passed in memory, any other profile (assume role, web identity, shared config)
+	// is resolved by the SDK from a credentials file
+	profile := awsProfile(a.bucket)
+	if accessKey, secretKey, sessionToken, ok := awsStaticCredentials(cred, profile); ok && opts.SharedConfigState != session.SharedConfigEnable {
+		opts.Config.Credentials = credentials.NewStaticCredentials(accessKey, secretKey, sessionToken)
+	} else {
+		credFile, err := defaultCredentialProvider.CredentialsFile(ctx, a.client, a.bucket)
+		if err != nil {
+			return nil, err
+		}
+		opts.SharedConfigFiles = []string{credFile}
+		opts.Profile = profile
+	}
+
 	s, err := session.NewSessionWithOptions(opts)
 	if err != nil {
 		return nil, err
@@ -147,8 +163,29 @@ func (a awsBucketClient) getS3Client() (s3iface.S3API, error) {
 	return s3.New(s), nil
 }
 
-func (a awsBucketClient) Delete() (bool, error) {
-	s3Client, err := a.getS3Client()
+// awsProfile returns the profile of the CloudStorage config, "default" when it is not set
+func awsProfile(cloudStorage v1alpha1.CloudStorage) string {
+	if profile := cloudStorage.Spec.Config["profile"]; profile != "" {
+		return profile
+	}
+	return "default"
+}
+
+// awsStaticCredentials returns the keys of profile when it only holds static credentials
+func awsStaticCredentials(data []byte, profile string) (accessKey, secretKey, sessionToken string, ok bool) {
+	settings, found := cloudprovider.ParseAWSProfile(data, profile)
+	if !found || settings["role_arn"] != "" || settings["credential_process"] != "" {
+		return "", "", "", false
+	}
+	accessKey, secretKey = settings["aws_access_key_id"], settings["aws_secret_access_key"]
+	if accessKey == "" || secretKey == "" {
+		return "", "", "", false
+	}
+	return accessKey, secretKey, settings["aws_session_token"], true
+}
+
+func (a awsBucketClient) Delete(ctx context.Context) (bool, error) {
+	s3Client, err := a.getS3Client(ctx)
 	if err != nil {
 		return false, err
 	}
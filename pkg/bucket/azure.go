@@ -5,14 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
-	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
@@ -20,7 +18,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/openshift/oadp-operator/api/v1alpha1"
-	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
+	"github.com/openshift/oadp-operator/pkg/credentials/azurecreds"
 )
 
 // azureServiceClient abstracts the Azure blob service client for testing
@@ -73,8 +71,8 @@ type azureBucketClient struct {
 }
 
 // Exists checks if the container exists in the storage account
-func (a *azureBucketClient) Exists() (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (a *azureBucketClient) Exists(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	containerName := a.bucket.Spec.Name
@@ -82,7 +80,7 @@ func (a *azureBucketClient) Exists() (bool, error) {
 		return false, fmt.Errorf("invalid container name: %w", err)
 	}
 
-	azureClient, err := a.createAzureClient()
+	azureClient, err := a.createAzureClient(ctx)
 	if err != nil {
 		return false, fmt.Errorf("failed to create Azure client: %w", err)
 	}
@@ -114,8 +112,8 @@ func (a *azureBucketClient) Exists() (bool, error) {
 }
 
 // Create creates a new container with the specified configuration
-func (a *azureBucketClient) Create() (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+func (a *azureBucketClient) Create(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
 	containerName := a.bucket.Spec.Name
@@ -124,7 +122,7 @@ func (a *azureBucketClient) Create() (bool, error) {
 	}
 
 	// Check if container already exists
-	exists, err := a.Exists()
+	exists, err := a.Exists(ctx)
 	if err != nil {
 		return false, fmt.Errorf("failed to check container existence: %w", err)
 	}
@@ -132,7 +130,7 @@ func (a *azureBucketClient) Create() (bool, error) {
 		return true, nil // Idempotent behavior
 	}
 
-	azureClient, err := a.createAzureClient()
+	azureClient, err := a.createAzureClient(ctx)
 	if err != nil {
 		return false, fmt.Errorf("failed to create Azure client: %w", err)
 	}
@@ -182,8 +180,8 @@ func (a *azureBucketClient) Create() (bool, error) {
 }
 
 // Delete removes the container (idempotent operation)
-func (a *azureBucketClient) Delete() (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+func (a *azureBucketClient) Delete(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
 	containerName := a.bucket.Spec.Name
@@ -191,7 +189,7 @@ func (a *azureBucketClient) Delete() (bool, error) {
 		return false, fmt.Errorf("invalid container name: %w", err)
 	}
 
-	azureClient, err := a.createAzureClient()
+	azureClient, err := a.createAzureClient(ctx)
 	if err != nil {
 		return false, fmt.Errorf("failed to create Azure client: %w", err)
 	}
@@ -225,8 +223,8 @@ func (a *azureBucketClient) Delete() (bool, error) {
 
 // createAzureClient creates an Azure blob service client with appropriate authentication
 //
-// Unlike AWS and GCP providers which use getCredentialFromCloudStorageSecret to create
-// temporary credential files, Azure handles credentials directly without file intermediaries.
+// Unlike AWS and GCP providers which read the creation secret through the CredentialProvider,
+// Azure handles credentials directly from the secret values.
 //
 // This approach is designed to be compatible with Velero's Azure credential handling
 // without requiring upstream changes to Velero. Velero expects Azure credentials as:
@@ -234,17 +232,15 @@ func (a *azureBucketClient) Delete() (bool, error) {
 // - Environment variables (for Workload Identity)
 // - Direct secret values (for storage keys and service principals)
 //
-// By handling credentials directly in our Azure implementation, we maintain compatibility
-// with Velero's expectations while avoiding the complexity of temporary credential files.
-// The Azure SDK supports creating credentials programmatically from values, making the
-// file-based approach unnecessary.
-//
-// The three authentication methods supported are:
+// The authentication method is resolved by the azurecreds package, with the same precedence
+// as DataProtectionTests and backup storage location validation:
 // 1. Storage Account Key - uses NewSharedKeyCredential
-// 2. Workload Identity (federated tokens) - uses NewWorkloadIdentityCredential
-// 3. Service Principal - uses NewClientSecretCredential
-func (a *azureBucketClient) createAzureClient() (azureServiceClient, error) {
-	secret, err := a.getSecret()
+// 2. Service Principal - uses NewClientSecretCredential or NewClientCertificateCredential
+// 3. Workload Identity (federated tokens) - uses NewWorkloadIdentityCredential
+// 4. Managed Identity - uses NewManagedIdentityCredential for the user-assigned identity AZURE_CLIENT_ID
+// 5. Otherwise - uses NewDefaultAzureCredential
+func (a *azureBucketClient) createAzureClient(ctx context.Context) (azureServiceClient, error) {
+	secret, err := a.getSecret(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get secret: %w", err)
 	}
@@ -258,222 +254,34 @@ func (a *azureBucketClient) createAzureClient() (azureServiceClient, error) {
 		return nil, fmt.Errorf("invalid storage account name: %w", err)
 	}
 
-	serviceURL := fmt.Sprintf("https://%s.blob.core.windows.net/", storageAccountName)
-
-	// Use factory if provided (for testing)
-	if a.clientFactory != nil {
-		// Try storage account key authentication first (higher priority)
-		if accessKey, ok := secret.Data["AZURE_STORAGE_ACCOUNT_ACCESS_KEY"]; ok && len(accessKey) > 0 {
-			credential, err := azblob.NewSharedKeyCredential(storageAccountName, string(accessKey))
-			if err != nil {
-				return nil, fmt.Errorf("failed to create shared key credential: %w", err)
-			}
-			return a.clientFactory(serviceURL, nil, credential)
-		}
-
-		// For other auth methods, we'll pass the token credential
-		var tokenCred azcore.TokenCredential
-		var err error
-
-		if a.hasWorkloadIdentityCredentials(secret) {
-			tokenCred, err = a.createWorkloadIdentityCredential(secret)
-		} else if a.hasServicePrincipalCredentials(secret) {
-			tokenCred, err = a.createServicePrincipalCredential(secret)
-		} else {
-			tokenCred, err = azidentity.NewDefaultAzureCredential(nil)
-		}
-
-		if err != nil {
-			return nil, err
-		}
-
-		return a.clientFactory(serviceURL, tokenCred, nil)
-	}
-
-	// Default implementation (production code)
-	// Try storage account key authentication first (higher priority)
-	if accessKey, ok := secret.Data["AZURE_STORAGE_ACCOUNT_ACCESS_KEY"]; ok && len(accessKey) > 0 {
-		credential, err := azblob.NewSharedKeyCredential(storageAccountName, string(accessKey))
-		if err != nil {
-			return nil, fmt.Errorf("failed to create shared key credential: %w", err)
-		}
-
-		azClient, err := azblob.NewClientWithSharedKeyCredential(serviceURL, credential, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create Azure client with shared key: %w", err)
-		}
-
-		return &realAzureServiceClient{client: azClient}, nil
-	}
-
-	// Check if Azure Workload Identity is configured (federated tokens)
-	if a.hasWorkloadIdentityCredentials(secret) {
-		credential, err := a.createWorkloadIdentityCredential(secret)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create workload identity credential: %w", err)
-		}
-
-		azClient, err := azblob.NewClient(serviceURL, credential, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create Azure client with workload identity: %w", err)
-		}
-
-		return &realAzureServiceClient{client: azClient}, nil
-	}
-
-	// Fall back to service principal authentication
-	if a.hasServicePrincipalCredentials(secret) {
-		credential, err := a.createServicePrincipalCredential(secret)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create service principal credential: %w", err)
-		}
-
-		azClient, err := azblob.NewClient(serviceURL, credential, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create Azure client with service principal: %w", err)
-		}
-
-		return &realAzureServiceClient{client: azClient}, nil
-	}
-
-	// Try DefaultAzureCredential as last resort (supports various auth methods including managed identity)
-	credential, err := azidentity.NewDefaultAzureCredential(nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create default Azure credential: %w", err)
+	creds := azurecreds.FromSecret(secret, a.bucket.Spec.CreationSecret.Key)
+	creds.StorageAccountName = storageAccountName
+	if err := creds.Validate(); err != nil {
+		return nil, err
 	}
-
-	azClient, err := azblob.NewClient(serviceURL, credential, nil)
+	tokenCred, sharedKey, err := creds.BlobCredential()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Azure client with default credential: %w", err)
-	}
-
-	return &realAzureServiceClient{client: azClient}, nil
-}
-
-// hasWorkloadIdentityCredentials checks if the secret contains workload identity credentials
-func (a *azureBucketClient) hasWorkloadIdentityCredentials(secret *corev1.Secret) bool {
-	// Check if this is an STS-type secret created by OADP operator
-	if labels, ok := secret.Labels[stsflow.STSSecretLabelKey]; ok && labels == stsflow.STSSecretLabelValue {
-		// For Azure STS secrets, check if it has the azurekey field
-		if azureKey, ok := secret.Data["azurekey"]; ok && len(azureKey) > 0 {
-			// Parse the azurekey to ensure it has the required fields
-			keyStr := string(azureKey)
-			return strings.Contains(keyStr, "AZURE_CLIENT_ID") &&
-				strings.Contains(keyStr, "AZURE_TENANT_ID") &&
-				strings.Contains(keyStr, "AZURE_SUBSCRIPTION_ID")
-		}
+		return nil, fmt.Errorf("failed to create %s credential: %w", creds.Method(), err)
 	}
 
-	// Check if AZURE_FEDERATED_TOKEN_FILE is in secret data
-	if _, ok := secret.Data["AZURE_FEDERATED_TOKEN_FILE"]; ok {
-		// Check if required fields are in the secret
-		requiredFields := []string{"AZURE_TENANT_ID", "AZURE_CLIENT_ID"}
-		for _, field := range requiredFields {
-			if _, ok := secret.Data[field]; !ok {
-				return false
-			}
-		}
-		return true
-	}
+	serviceURL := azurecreds.ServiceURL(storageAccountName)
 
-	// Also check for environment variable (for backward compatibility)
-	if os.Getenv("AZURE_FEDERATED_TOKEN_FILE") != "" {
-		// Check if required fields are in the secret
-		requiredFields := []string{"AZURE_TENANT_ID", "AZURE_CLIENT_ID"}
-		for _, field := range requiredFields {
-			if _, ok := secret.Data[field]; !ok {
-				return false
-			}
-		}
-		return true
+	// Use factory if provided (for testing)
+	if a.clientFactory != nil {
+		return a.clientFactory(serviceURL, tokenCred, sharedKey)
 	}
-	return false
-}
 
-// createWorkloadIdentityCredential creates a workload identity credential for federated tokens
-func (a *azureBucketClient) createWorkloadIdentityCredential(secret *corev1.Secret) (azcore.TokenCredential, error) {
-	var tenantID, clientID, tokenFile string
-
-	// First try to parse from azurekey field (STS secret format)
-	if azureKey, ok := secret.Data["azurekey"]; ok && len(azureKey) > 0 {
-		// Parse the environment variable format
-		keyStr := string(azureKey)
-		lines := strings.Split(keyStr, "\n")
-		envVars := make(map[string]string)
-
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line == "" {
-				continue
-			}
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				envVars[parts[0]] = parts[1]
-			}
-		}
-
-		tenantID = envVars["AZURE_TENANT_ID"]
-		clientID = envVars["AZURE_CLIENT_ID"]
-
-		if tenantID == "" || clientID == "" {
-			return nil, fmt.Errorf("missing AZURE_TENANT_ID or AZURE_CLIENT_ID in azurekey")
-		}
-	} else {
-		// Fall back to individual fields
-		tenantID = string(secret.Data["AZURE_TENANT_ID"])
-		clientID = string(secret.Data["AZURE_CLIENT_ID"])
-	}
-
-	// Check for federated token file in order of priority
-	// 1. From secret data
-	if tokenFileData, ok := secret.Data["AZURE_FEDERATED_TOKEN_FILE"]; ok && len(tokenFileData) > 0 {
-		tokenFile = string(tokenFileData)
-	} else if envTokenFile := os.Getenv("AZURE_FEDERATED_TOKEN_FILE"); envTokenFile != "" {
-		// 2. From environment variable
-		tokenFile = envTokenFile
+	var azClient *azblob.Client
+	if sharedKey != nil {
+		azClient, err = azblob.NewClientWithSharedKeyCredential(serviceURL, sharedKey, nil)
 	} else {
-		// 3. Default for OpenShift environments
-		tokenFile = "/var/run/secrets/openshift/serviceaccount/token"
-	}
-
-	// WorkloadIdentityCredential handles federated tokens automatically
-	options := &azidentity.WorkloadIdentityCredentialOptions{
-		ClientID:      clientID,
-		TenantID:      tenantID,
-		TokenFilePath: tokenFile,
-	}
-
-	credential, err := azidentity.NewWorkloadIdentityCredential(options)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create workload identity credential: %w", err)
+		azClient, err = azblob.NewClient(serviceURL, tokenCred, nil)
 	}
-
-	return credential, nil
-}
-
-// hasServicePrincipalCredentials checks if the secret contains service principal credentials
-func (a *azureBucketClient) hasServicePrincipalCredentials(secret *corev1.Secret) bool {
-	requiredFields := []string{"AZURE_TENANT_ID", "AZURE_CLIENT_ID", "AZURE_CLIENT_SECRET"}
-	for _, field := range requiredFields {
-		if _, ok := secret.Data[field]; !ok {
-			return false
-		}
-	}
-	return true
-}
-
-// createServicePrincipalCredential creates a service principal credential
-func (a *azureBucketClient) createServicePrincipalCredential(secret *corev1.Secret) (azcore.TokenCredential, error) {
-	tenantID := string(secret.Data["AZURE_TENANT_ID"])
-	clientID := string(secret.Data["AZURE_CLIENT_ID"])
-	clientSecret := string(secret.Data["AZURE_CLIENT_SECRET"])
-
-	credential, err := azidentity.NewClientSecretCredential(tenantID, clientID, clientSecret, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create client secret credential: %w", err)
+		return nil, fmt.Errorf("failed to create Azure client with %s credential: %w", creds.Method(), err)
 	}
 
-	return credential, nil
+	return &realAzureServiceClient{client: azClient}, nil
 }
 
 // validateAndConvertTags validates tags meet Azure requirements
@@ -495,14 +303,14 @@ func (a *azureBucketClient) validateAndConvertTags() error {
 }
 
 // getSecret retrieves the secret referenced in the CloudStorage spec
-func (a *azureBucketClient) getSecret() (*corev1.Secret, error) {
+func (a *azureBucketClient) getSecret(ctx context.Context) (*corev1.Secret, error) {
 	secret := &corev1.Secret{}
 	secretKey := client.ObjectKey{
 		Namespace: a.bucket.Namespace,
 		Name:      a.bucket.Spec.CreationSecret.Name,
 	}
 
-	if err := a.client.Get(context.Background(), secretKey, secret); err != nil {
+	if err := a.client.Get(ctx, secretKey, secret); err != nil {
 		return nil, fmt.Errorf("failed to get secret %s/%s: %w",
 			a.bucket.Namespace, a.bucket.Spec.CreationSecret.Name, err)
 	}
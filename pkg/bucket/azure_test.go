@@ -15,7 +15,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/openshift/oadp-operator/api/v1alpha1"
-	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
 )
 
 func TestValidateContainerName(t *testing.T) {
@@ -276,149 +275,6 @@ func TestIsRetryableError(t *testing.T) {
 	assert.True(t, isRetryableError(networkErr2))
 }
 
-func TestHasWorkloadIdentityCredentials(t *testing.T) {
-	azureClient := &azureBucketClient{}
-
-	tests := []struct {
-		name         string
-		secretData   map[string][]byte
-		secretLabels map[string]string
-		expected     bool
-	}{
-		{
-			name: "valid STS secret with azurekey",
-			secretData: map[string][]byte{
-				"azurekey": []byte(`
-AZURE_SUBSCRIPTION_ID=12345678-1234-1234-1234-123456789012
-AZURE_TENANT_ID=87654321-4321-4321-4321-210987654321
-AZURE_CLIENT_ID=abcdef12-3456-7890-abcd-ef1234567890
-AZURE_CLOUD_NAME=AzurePublicCloud
-`),
-			},
-			secretLabels: map[string]string{
-				stsflow.STSSecretLabelKey: stsflow.STSSecretLabelValue,
-			},
-			expected: true,
-		},
-		{
-			name: "STS secret missing required fields",
-			secretData: map[string][]byte{
-				"azurekey": []byte(`
-AZURE_SUBSCRIPTION_ID=12345678-1234-1234-1234-123456789012
-AZURE_CLOUD_NAME=AzurePublicCloud
-`),
-			},
-			secretLabels: map[string]string{
-				stsflow.STSSecretLabelKey: stsflow.STSSecretLabelValue,
-			},
-			expected: false,
-		},
-		{
-			name: "STS secret without label",
-			secretData: map[string][]byte{
-				"azurekey": []byte(`
-AZURE_SUBSCRIPTION_ID=12345678-1234-1234-1234-123456789012
-AZURE_TENANT_ID=87654321-4321-4321-4321-210987654321
-AZURE_CLIENT_ID=abcdef12-3456-7890-abcd-ef1234567890
-`),
-			},
-			expected: false,
-		},
-		{
-			name: "secret with AZURE_FEDERATED_TOKEN_FILE",
-			secretData: map[string][]byte{
-				"AZURE_TENANT_ID":            []byte("tenant-id"),
-				"AZURE_CLIENT_ID":            []byte("client-id"),
-				"AZURE_FEDERATED_TOKEN_FILE": []byte("/var/run/secrets/openshift/serviceaccount/token"),
-			},
-			expected: true,
-		},
-		{
-			name: "secret with AZURE_FEDERATED_TOKEN_FILE missing tenant",
-			secretData: map[string][]byte{
-				"AZURE_CLIENT_ID":            []byte("client-id"),
-				"AZURE_FEDERATED_TOKEN_FILE": []byte("/var/run/secrets/openshift/serviceaccount/token"),
-			},
-			expected: false,
-		},
-		{
-			name:       "empty secret",
-			secretData: map[string][]byte{},
-			expected:   false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			secret := &corev1.Secret{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: tt.secretLabels,
-				},
-				Data: tt.secretData,
-			}
-			result := azureClient.hasWorkloadIdentityCredentials(secret)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
-}
-
-func TestHasServicePrincipalCredentials(t *testing.T) {
-	azureClient := &azureBucketClient{}
-
-	tests := []struct {
-		name       string
-		secretData map[string][]byte
-		expected   bool
-	}{
-		{
-			name: "valid service principal credentials",
-			secretData: map[string][]byte{
-				"AZURE_TENANT_ID":     []byte("tenant-id"),
-				"AZURE_CLIENT_ID":     []byte("client-id"),
-				"AZURE_CLIENT_SECRET": []byte("client-secret"),
-			},
-			expected: true,
-		},
-		{
-			name: "missing tenant ID",
-			secretData: map[string][]byte{
-				"AZURE_CLIENT_ID":     []byte("client-id"),
-				"AZURE_CLIENT_SECRET": []byte("client-secret"),
-			},
-			expected: false,
-		},
-		{
-			name: "missing client ID",
-			secretData: map[string][]byte{
-				"AZURE_TENANT_ID":     []byte("tenant-id"),
-				"AZURE_CLIENT_SECRET": []byte("client-secret"),
-			},
-			expected: false,
-		},
-		{
-			name: "missing client secret",
-			secretData: map[string][]byte{
-				"AZURE_TENANT_ID": []byte("tenant-id"),
-				"AZURE_CLIENT_ID": []byte("client-id"),
-			},
-			expected: false,
-		},
-		{
-			name:       "empty secret",
-			secretData: map[string][]byte{},
-			expected:   false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			secret := &corev1.Secret{Data: tt.secretData}
-			result := azureClient.hasServicePrincipalCredentials(secret)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
-}
-
 // Mock implementations for testing
 
 type mockAzureServiceClient struct {
@@ -576,7 +432,7 @@ func TestAzureBucketClient_Delete(t *testing.T) {
 			}
 
 			// Test Delete method
-			result, err := client.Delete()
+			result, err := client.Delete(context.Background())
 
 			// Verify results
 			assert.Equal(t, tt.expectedResult, result)
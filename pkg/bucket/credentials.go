@@ -0,0 +1,183 @@
+package bucket
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
+)
+
+// defaultCredentialProvider is the credential provider used by the bucket clients returned by NewClient
+var defaultCredentialProvider = NewCredentialProvider("")
+
+// EvictCredentials removes the cached credentials of the CloudStorage from the default credential provider
+func EvictCredentials(cloudStorage types.NamespacedName) {
+	defaultCredentialProvider.Evict(cloudStorage)
+}
+
+// credentialEntry is the cached credential of a CloudStorage
+type credentialEntry struct {
+	secret          types.NamespacedName
+	resourceVersion string
+	data            []byte
+	// file is only set once a client required the credential on disk
+	file string
+}
+
+// CredentialProvider resolves the credentials of CloudStorage buckets.
+//
+// Credentials are cached per CloudStorage and refreshed whenever the secret they were read from changes
+// resourceVersion. They are handed to the SDKs in memory; a file is only written for clients that must
+// read the credential from disk, and it is wiped when the secret changes or the entry is evicted.
+// CredentialProvider is safe for concurrent use.
+type CredentialProvider struct {
+	mu      sync.Mutex
+	dir     string
+	entries map[types.NamespacedName]*credentialEntry
+}
+
+// NewCredentialProvider returns a CredentialProvider writing credential files in dir,
+// or in the default temporary directory if dir is empty.
+func NewCredentialProvider(dir string) *CredentialProvider {
+	return &CredentialProvider{
+		dir:     dir,
+		entries: map[types.NamespacedName]*credentialEntry{},
+	}
+}
+
+// Credentials returns the credential of the CloudStorage
+func (p *CredentialProvider) Credentials(ctx context.Context, c client.Client, cloudStorage v1alpha1.CloudStorage) ([]byte, error) {
+	secret, data, err := credentialsFromCloudStorageSecret(ctx, c, cloudStorage)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.entry(cloudStorage, secret, data).data, nil
+}
+
+// CredentialsFile returns the path of a file holding the credential of the CloudStorage.
+// The file belongs to the provider and must not be removed by the caller.
+func (p *CredentialProvider) CredentialsFile(ctx context.Context, c client.Client, cloudStorage v1alpha1.CloudStorage) (string, error) {
+	secret, data, err := credentialsFromCloudStorageSecret(ctx, c, cloudStorage)
+	if err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry := p.entry(cloudStorage, secret, data)
+	if entry.file != "" {
+		return entry.file, nil
+	}
+	f, err := os.CreateTemp(p.dir, fmt.Sprintf("cloudstorage-%s-%s-", cloudStorage.Namespace, cloudStorage.Name))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := f.Chmod(0600); err != nil {
+		wipeFile(f.Name())
+		return "", err
+	}
+	if _, err := f.Write(entry.data); err != nil {
+		wipeFile(f.Name())
+		return "", err
+	}
+	entry.file = f.Name()
+	return entry.file, nil
+}
+
+// Evict removes the cached credential of the CloudStorage and wipes its file
+func (p *CredentialProvider) Evict(cloudStorage types.NamespacedName) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if entry, ok := p.entries[cloudStorage]; ok {
+		wipeFile(entry.file)
+		delete(p.entries, cloudStorage)
+	}
+}
+
+// Close evicts every cached credential
+func (p *CredentialProvider) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for name, entry := range p.entries {
+		wipeFile(entry.file)
+		delete(p.entries, name)
+	}
+}
+
+// entry returns the cached credential of the CloudStorage, replacing it if the secret changed.
+// It must be called with the lock held.
+func (p *CredentialProvider) entry(cloudStorage v1alpha1.CloudStorage, secret *corev1.Secret, data []byte) *credentialEntry {
+	secretName := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}
+	cloudStorageName := types.NamespacedName{Namespace: cloudStorage.Namespace, Name: cloudStorage.Name}
+	if entry, ok := p.entries[cloudStorageName]; ok {
+		if entry.secret == secretName && entry.resourceVersion == secret.ResourceVersion {
+			return entry
+		}
+		wipeFile(entry.file)
+	}
+	entry := &credentialEntry{
+		secret:          secretName,
+		resourceVersion: secret.ResourceVersion,
+		data:            data,
+	}
+	p.entries[cloudStorageName] = entry
+	return entry
+}
+
+// credentialsFromCloudStorageSecret reads the creation secret of the CloudStorage, or the standardized STS
+// secret when the operator runs in the STS flow, and returns it with the credential it holds
+func credentialsFromCloudStorageSecret(ctx context.Context, c client.Client, cloudStorage v1alpha1.CloudStorage) (*corev1.Secret, []byte, error) {
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{
+		Name:      cloudStorage.Spec.CreationSecret.Name,
+		Namespace: cloudStorage.Namespace,
+	}, secret)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if stsSecret, err := stsflow.STSStandardizedFlow(); err == nil && stsSecret != "" {
+		secret = &corev1.Secret{}
+		err := c.Get(ctx, types.NamespacedName{
+			Name:      stsSecret,
+			Namespace: cloudStorage.Namespace,
+		}, secret)
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := SharedCredentialsFromSecret(secret)
+		if err != nil {
+			return nil, nil, err
+		}
+		return secret, data, nil
+	}
+
+	data, ok := secret.Data[cloudStorage.Spec.CreationSecret.Key]
+	if !ok {
+		return nil, nil, fmt.Errorf("secret %s/%s does not contain key %s", secret.Namespace, secret.Name, cloudStorage.Spec.CreationSecret.Key)
+	}
+	// copy the data so that the cache never aliases objects of the client cache
+	return secret, append([]byte(nil), data...), nil
+}
+
+// wipeFile overwrites the file with zeros before removing it
+func wipeFile(name string) {
+	if name == "" {
+		return
+	}
+	if info, err := os.Stat(name); err == nil {
+		_ = os.WriteFile(name, make([]byte, info.Size()), 0600)
+	}
+	_ = os.Remove(name)
+}
@@ -0,0 +1,183 @@
+package bucket
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func newCredentialTestCloudStorage() v1alpha1.CloudStorage {
+	return v1alpha1.CloudStorage{
+		ObjectMeta: metav1.ObjectMeta{Name: "bucket", Namespace: "openshift-adp"},
+		Spec: v1alpha1.CloudStorageSpec{
+			CreationSecret: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "cloud-credentials"},
+				Key:                  "cloud",
+			},
+		},
+	}
+}
+
+func TestCredentialProvider(t *testing.T) {
+	ctx := context.Background()
+	cloudStorage := newCredentialTestCloudStorage()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials", Namespace: "openshift-adp"},
+		Data:       map[string][]byte{"cloud": []byte("first")},
+	}
+	c := fake.NewClientBuilder().WithObjects(secret).Build()
+	provider := NewCredentialProvider(t.TempDir())
+
+	data, err := provider.Credentials(ctx, c, cloudStorage)
+	require.NoError(t, err)
+	require.Equal(t, "first", string(data))
+
+	file, err := provider.CredentialsFile(ctx, c, cloudStorage)
+	require.NoError(t, err)
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.Equal(t, "first", string(content))
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// the file is reused while the secret is unchanged
+	sameFile, err := provider.CredentialsFile(ctx, c, cloudStorage)
+	require.NoError(t, err)
+	require.Equal(t, file, sameFile)
+
+	// a new resourceVersion refreshes the credential and wipes the old file
+	secret.Data["cloud"] = []byte("second")
+	require.NoError(t, c.Update(ctx, secret))
+	data, err = provider.Credentials(ctx, c, cloudStorage)
+	require.NoError(t, err)
+	require.Equal(t, "second", string(data))
+	_, err = os.Stat(file)
+	require.True(t, os.IsNotExist(err), "expected %s to be removed", file)
+
+	newFile, err := provider.CredentialsFile(ctx, c, cloudStorage)
+	require.NoError(t, err)
+	require.NotEqual(t, file, newFile)
+	content, err = os.ReadFile(newFile)
+	require.NoError(t, err)
+	require.Equal(t, "second", string(content))
+
+	// eviction wipes the file
+	provider.Evict(types.NamespacedName{Namespace: cloudStorage.Namespace, Name: cloudStorage.Name})
+	_, err = os.Stat(newFile)
+	require.True(t, os.IsNotExist(err), "expected %s to be removed", newFile)
+	require.Empty(t, provider.entries)
+}
+
+func TestCredentialProvider_Errors(t *testing.T) {
+	ctx := context.Background()
+	cloudStorage := newCredentialTestCloudStorage()
+	provider := NewCredentialProvider(t.TempDir())
+
+	_, err := provider.Credentials(ctx, fake.NewClientBuilder().Build(), cloudStorage)
+	require.Error(t, err)
+
+	c := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials", Namespace: "openshift-adp"},
+		Data:       map[string][]byte{"other": []byte("data")},
+	}).Build()
+	_, err = provider.Credentials(ctx, c, cloudStorage)
+	require.ErrorContains(t, err, "does not contain key cloud")
+	require.Empty(t, provider.entries)
+}
+
+func TestCredentialProvider_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	cloudStorage := newCredentialTestCloudStorage()
+	c := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cloud-credentials", Namespace: "openshift-adp"},
+		Data:       map[string][]byte{"cloud": []byte("data")},
+	}).Build()
+	dir := t.TempDir()
+	provider := NewCredentialProvider(dir)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := provider.CredentialsFile(ctx, c, cloudStorage); err != nil {
+				t.Error(err)
+			}
+			if _, err := provider.Credentials(ctx, c, cloudStorage); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	provider.Close()
+	files, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func TestAWSStaticCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		profile string
+		wantOK  bool
+	}{
+		{
+			name:    "static keys",
+			data:    "[default]\naws_access_key_id=key\naws_secret_access_key=secret\n",
+			profile: "default",
+			wantOK:  true,
+		},
+		{
+			name:    "web identity role",
+			data:    "[default]\nrole_arn=arn:aws:iam::123456789012:role/test\nweb_identity_token_file=/var/run/secrets/token\n",
+			profile: "default",
+		},
+		{
+			name:    "credential process",
+			data:    "[default]\ncredential_process=/bin/creds\n",
+			profile: "default",
+		},
+		{
+			name:    "other profile only",
+			data:    "[backup]\naws_access_key_id=key\naws_secret_access_key=secret\n",
+			profile: "default",
+		},
+		{
+			name:    "configured profile",
+			data:    "[default]\nrole_arn=arn:aws:iam::123456789012:role/test\n[backup]\naws_access_key_id=key\naws_secret_access_key=secret\n",
+			profile: "backup",
+			wantOK:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accessKey, secretKey, _, ok := awsStaticCredentials([]byte(tt.data), tt.profile)
+			require.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				require.Equal(t, "key", accessKey)
+				require.Equal(t, "secret", secretKey)
+			}
+		})
+	}
+}
+
+func TestAWSProfile(t *testing.T) {
+	require.Equal(t, "default", awsProfile(v1alpha1.CloudStorage{}))
+	require.Equal(t, "backup", awsProfile(v1alpha1.CloudStorage{Spec: v1alpha1.CloudStorageSpec{Config: map[string]string{"profile": "backup"}}}))
+}
@@ -25,11 +25,11 @@ type gcpBucketClient struct {
 	client client.Client
 }
 
-func (g gcpBucketClient) Exists() (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (g gcpBucketClient) Exists(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	gcsClient, _, err := g.getGCSClient()
+	gcsClient, _, err := g.getGCSClient(ctx)
 	if err != nil {
 		return false, err
 	}
@@ -46,7 +46,7 @@ func (g gcpBucketClient) Exists() (bool, error) {
 	}
 
 	// Tag bucket if it exists
-	err = g.tagBucket(gcsClient)
+	err = g.tagBucket(ctx, gcsClient)
 	if err != nil {
 		return true, err
 	}
@@ -54,8 +54,8 @@ func (g gcpBucketClient) Exists() (bool, error) {
 	return true, nil
 }
 
-func (g gcpBucketClient) Create() (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+func (g gcpBucketClient) Create(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
 	// Validate bucket name
@@ -63,7 +63,7 @@ func (g gcpBucketClient) Create() (bool, error) {
 		return false, err
 	}
 
-	gcsClient, projectID, err := g.getGCSClient()
+	gcsClient, projectID, err := g.getGCSClient(ctx)
 	if err != nil {
 		return false, err
 	}
@@ -101,11 +101,11 @@ func (g gcpBucketClient) Create() (bool, error) {
 	return true, nil
 }
 
-func (g gcpBucketClient) Delete() (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Second) // Longer timeout for object deletion
+func (g gcpBucketClient) Delete(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 300*time.Second) // Longer timeout for object deletion
 	defer cancel()
 
-	gcsClient, _, err := g.getGCSClient()
+	gcsClient, _, err := g.getGCSClient(ctx)
 	if err != nil {
 		return false, err
 	}
@@ -148,26 +148,24 @@ func (g gcpBucketClient) Delete() (bool, error) {
 
 // getGCSClient creates a GCS client with authentication
 // Supports both traditional service account keys and GCP Workload Identity Federation (WIF)
-func (g gcpBucketClient) getGCSClient() (*storage.Client, string, error) {
-	ctx := context.Background()
-
-	// Get credential file from secret
+func (g gcpBucketClient) getGCSClient(ctx context.Context) (*storage.Client, string, error) {
+	// Get credential JSON from secret
 	// This could be either a service account key or WIF external account credentials
-	credFile, err := getCredentialFromCloudStorageSecret(g.client, g.bucket)
+	credJSON, err := defaultCredentialProvider.Credentials(ctx, g.client, g.bucket)
 	if err != nil {
 		return nil, "", err
 	}
 
 	// Parse credentials to extract project ID
 	// Handles both service account and WIF credential formats
-	projectID, err := g.extractProjectID(credFile)
+	projectID, err := g.extractProjectID(credJSON)
 	if err != nil {
 		return nil, "", err
 	}
 
 	// Create GCS client with credentials
 	// The GCS client automatically handles both service account and WIF credentials
-	gcsClient, err := storage.NewClient(ctx, option.WithCredentialsFile(credFile))
+	gcsClient, err := storage.NewClient(ctx, option.WithCredentialsJSON(credJSON))
 	if err != nil {
 		return nil, "", err
 	}
@@ -202,13 +200,8 @@ type externalAccountKey struct {
 	} `json:"credential_source"`
 }
 
-// extractProjectID parses the credential JSON file to extract project ID
-func (g gcpBucketClient) extractProjectID(credentialFile string) (string, error) {
-	data, err := os.ReadFile(credentialFile)
-	if err != nil {
-		return "", fmt.Errorf("error reading credential file: %v", err)
-	}
-
+// extractProjectID parses the credential JSON to extract project ID
+func (g gcpBucketClient) extractProjectID(data []byte) (string, error) {
 	// First, check the type of credentials
 	var typeCheck struct {
 		Type string `json:"type"`
@@ -326,8 +319,7 @@ func (g gcpBucketClient) convertTagsToLabels() map[string]string {
 }
 
 // tagBucket applies tags to an existing bucket
-func (g gcpBucketClient) tagBucket(gcsClient *storage.Client) error {
-	ctx := context.Background()
+func (g gcpBucketClient) tagBucket(ctx context.Context, gcsClient *storage.Client) error {
 	bucket := gcsClient.Bucket(g.bucket.Spec.Name)
 
 	// Update labels
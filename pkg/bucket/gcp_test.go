@@ -2,7 +2,6 @@ package bucket
 
 import (
 	"encoding/json"
-	"os"
 	"strings"
 	"testing"
 
@@ -49,7 +48,7 @@ func TestValidateBucketName(t *testing.T) {
 }
 
 func TestExtractProjectID(t *testing.T) {
-	// Create temporary service account file
+	// Service account key JSON
 	sa := serviceAccountKey{
 		Type:        "service_account",
 		ProjectID:   "test-project-123",
@@ -63,16 +62,8 @@ func TestExtractProjectID(t *testing.T) {
 	data, err := json.Marshal(sa)
 	require.NoError(t, err)
 
-	tmpFile, err := os.CreateTemp("", "test-sa-*.json")
-	require.NoError(t, err)
-	defer os.Remove(tmpFile.Name())
-
-	_, err = tmpFile.Write(data)
-	require.NoError(t, err)
-	tmpFile.Close()
-
 	client := gcpBucketClient{}
-	projectID, err := client.extractProjectID(tmpFile.Name())
+	projectID, err := client.extractProjectID(data)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "test-project-123", projectID)
@@ -86,35 +77,14 @@ func TestExtractProjectID(t *testing.T) {
 	data, err = json.Marshal(saNoProject)
 	require.NoError(t, err)
 
-	tmpFile2, err := os.CreateTemp("", "test-sa-no-project-*.json")
-	require.NoError(t, err)
-	defer os.Remove(tmpFile2.Name())
-
-	_, err = tmpFile2.Write(data)
-	require.NoError(t, err)
-	tmpFile2.Close()
-
-	_, err = client.extractProjectID(tmpFile2.Name())
+	_, err = client.extractProjectID(data)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "project_id not found")
 
 	// Test invalid JSON
-	tmpFile3, err := os.CreateTemp("", "test-sa-invalid-*.json")
-	require.NoError(t, err)
-	defer os.Remove(tmpFile3.Name())
-
-	_, err = tmpFile3.Write([]byte("invalid json"))
-	require.NoError(t, err)
-	tmpFile3.Close()
-
-	_, err = client.extractProjectID(tmpFile3.Name())
+	_, err = client.extractProjectID([]byte("invalid json"))
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "error parsing credential JSON")
-
-	// Test non-existent file
-	_, err = client.extractProjectID("/non/existent/file.json")
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "error reading credential file")
 }
 
 func TestConvertTagsToLabels(t *testing.T) {
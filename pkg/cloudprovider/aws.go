@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -21,8 +22,12 @@ const maxTestSizeBytes = 200 * 1024 * 1024
 
 type AWSProvider struct {
 	s3Client *s3.S3
+	// credentialType is set when the session uses credentials exchanged through IAM Roles Anywhere or EKS Pod Identity
+	credentialType string
 }
 
+var _ TokenExchanger = &AWSProvider{}
+
 // NewAWSProvider creates an AWSProvider using region, endpoint, and credentials.
 func NewAWSProvider(region, endpoint, accessKey, secretKey string) *AWSProvider {
 	awsConfig := &aws.Config{
@@ -148,3 +153,62 @@ func (a *AWSProvider) GetBucketMetadata(ctx context.Context, bucket string, log
 
 	return result, nil
 }
+
+// PutObject writes data to key in the bucket.
+func (a *AWSProvider) PutObject(ctx context.Context, bucket, key string, data []byte) error {
+	_, err := a.s3Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write object %s: %w", key, err)
+	}
+	return nil
+}
+
+// DownloadTest uploads a test object and measures how long it takes to download it.
+// The test object is deleted afterwards.
+func (a *AWSProvider) DownloadTest(ctx context.Context, config oadpv1alpha1.UploadSpeedTestConfig, bucket string, log logr.Logger) (int64, time.Duration, error) {
+	payload, timeoutDuration, err := downloadTestPayload(config)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	key := fmt.Sprintf("dpt-download-test-%d", time.Now().UnixNano())
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, timeoutDuration)
+	defer cancel()
+
+	log.Info("Uploading download test object", "bytes", len(payload))
+	if err := a.PutObject(ctxWithTimeout, bucket, key, payload); err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		_, _ = a.s3Client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+	}()
+
+	log.Info("Downloading from bucket...")
+	start := time.Now()
+
+	out, err := a.s3Client.GetObjectWithContext(ctxWithTimeout, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return 0, time.Since(start), fmt.Errorf("download failed: %w", err)
+	}
+	defer out.Body.Close()
+
+	n, err := io.Copy(io.Discard, out.Body)
+	duration := time.Since(start)
+	if err != nil {
+		return 0, duration, fmt.Errorf("download failed: %w", err)
+	}
+
+	speed := speedMbps(n, duration)
+	log.Info("Download completed", "duration", duration.String(), "speedMbps", speed)
+	return speed, duration, nil
+}
@@ -0,0 +1,130 @@
+package cloudprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+// AWSSnapshotAPI exercises EBS volume and snapshot operations.
+type AWSSnapshotAPI struct {
+	ec2Client ec2iface.EC2API
+	region    string
+}
+
+// NewAWSSnapshotAPI creates an AWSSnapshotAPI from a session configured with the VSL region and credentials.
+func NewAWSSnapshotAPI(sess *session.Session) *AWSSnapshotAPI {
+	return &AWSSnapshotAPI{
+		ec2Client: ec2.New(sess),
+		region:    aws.StringValue(sess.Config.Region),
+	}
+}
+
+func (a *AWSSnapshotAPI) Region() string {
+	return a.region
+}
+
+func (a *AWSSnapshotAPI) ListVolumes(ctx context.Context) (int, error) {
+	out, err := a.ec2Client.DescribeVolumesWithContext(ctx, &ec2.DescribeVolumesInput{
+		MaxResults: aws.Int64(5),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(out.Volumes), nil
+}
+
+func (a *AWSSnapshotAPI) DescribeVolume(ctx context.Context, volumeID string) error {
+	out, err := a.ec2Client.DescribeVolumesWithContext(ctx, &ec2.DescribeVolumesInput{
+		VolumeIds: []*string{aws.String(volumeID)},
+	})
+	if err != nil {
+		return err
+	}
+	if len(out.Volumes) == 0 {
+		return fmt.Errorf("volume %s not found in region %s", volumeID, a.region)
+	}
+	return nil
+}
+
+func (a *AWSSnapshotAPI) CreateVolume(ctx context.Context, name, zone string, sizeGB int64) (string, error) {
+	out, err := a.ec2Client.CreateVolumeWithContext(ctx, &ec2.CreateVolumeInput{
+		AvailabilityZone:  aws.String(zone),
+		Size:              aws.Int64(sizeGB),
+		VolumeType:        aws.String(ec2.VolumeTypeGp3),
+		TagSpecifications: awsTagSpecifications(ec2.ResourceTypeVolume, name),
+	})
+	if err != nil {
+		return "", err
+	}
+	volumeID := aws.StringValue(out.VolumeId)
+
+	if err := a.ec2Client.WaitUntilVolumeAvailableWithContext(ctx, &ec2.DescribeVolumesInput{
+		VolumeIds: []*string{aws.String(volumeID)},
+	}); err != nil {
+		return volumeID, fmt.Errorf("volume %s did not become available: %w", volumeID, err)
+	}
+	return volumeID, nil
+}
+
+func (a *AWSSnapshotAPI) DeleteVolume(ctx context.Context, volumeID string) error {
+	_, err := a.ec2Client.DeleteVolumeWithContext(ctx, &ec2.DeleteVolumeInput{
+		VolumeId: aws.String(volumeID),
+	})
+	return err
+}
+
+func (a *AWSSnapshotAPI) CreateSnapshot(ctx context.Context, name, volumeID string) (string, error) {
+	out, err := a.ec2Client.CreateSnapshotWithContext(ctx, &ec2.CreateSnapshotInput{
+		VolumeId:          aws.String(volumeID),
+		Description:       aws.String("OADP DataProtectionTest snapshot " + name),
+		TagSpecifications: awsTagSpecifications(ec2.ResourceTypeSnapshot, name),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(out.SnapshotId), nil
+}
+
+func (a *AWSSnapshotAPI) WaitForSnapshot(ctx context.Context, snapshotID string) error {
+	return a.ec2Client.WaitUntilSnapshotCompletedWithContext(ctx, &ec2.DescribeSnapshotsInput{
+		SnapshotIds: []*string{aws.String(snapshotID)},
+	})
+}
+
+func (a *AWSSnapshotAPI) DeleteSnapshot(ctx context.Context, snapshotID string) error {
+	_, err := a.ec2Client.DeleteSnapshotWithContext(ctx, &ec2.DeleteSnapshotInput{
+		SnapshotId: aws.String(snapshotID),
+	})
+	return err
+}
+
+func (a *AWSSnapshotAPI) IsPermissionDenied(err error) bool {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return false
+	}
+	switch awsErr.Code() {
+	case "UnauthorizedOperation", "AccessDenied", "AccessDeniedException", "AuthFailure":
+		return true
+	}
+	return false
+}
+
+func awsTagSpecifications(resourceType, name string) []*ec2.TagSpecification {
+	return []*ec2.TagSpecification{
+		{
+			ResourceType: aws.String(resourceType),
+			Tags: []*ec2.Tag{
+				{Key: aws.String("Name"), Value: aws.String(name)},
+				{Key: aws.String(NativeSnapshotTagKey), Value: aws.String("true")},
+			},
+		},
+	}
+}
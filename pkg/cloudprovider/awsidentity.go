@@ -0,0 +1,322 @@
+package cloudprovider
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+const (
+	// AWSRolesAnywhereCredentialType is the credential type of credentials obtained through IAM Roles Anywhere
+	AWSRolesAnywhereCredentialType = "rolesanywhere"
+	// AWSPodIdentityCredentialType is the credential type of credentials served by the EKS Pod Identity agent
+	AWSPodIdentityCredentialType = "pod_identity"
+	// AWSWebIdentityCredentialType is the credential type of credentials obtained by assuming a role with a service
+	// account token, as configured by the OpenShift STS flow
+	AWSWebIdentityCredentialType = "web_identity"
+
+	rolesAnywhereService         = "rolesanywhere"
+	rolesAnywhereSessionDuration = 3600
+)
+
+// AWSRolesAnywhereProcess is the IAM Roles Anywhere configuration of an aws_signing_helper credential_process
+type AWSRolesAnywhereProcess struct {
+	Certificate    string
+	PrivateKey     string
+	TrustAnchorARN string
+	ProfileARN     string
+	RoleARN        string
+}
+
+// ParseAWSRolesAnywhereProcess parses the credential_process of a shared config profile running
+// "aws_signing_helper credential-process". Only the flags needed to create a session are read.
+func ParseAWSRolesAnywhereProcess(credentialProcess string) (*AWSRolesAnywhereProcess, error) {
+	fields := strings.Fields(credentialProcess)
+	if len(fields) < 2 || fields[1] != "credential-process" {
+		return nil, fmt.Errorf("credential_process %q does not run the AWS signing helper credential-process command", credentialProcess)
+	}
+	process := &AWSRolesAnywhereProcess{}
+	flags := map[string]*string{
+		"--certificate":      &process.Certificate,
+		"--private-key":      &process.PrivateKey,
+		"--trust-anchor-arn": &process.TrustAnchorARN,
+		"--profile-arn":      &process.ProfileARN,
+		"--role-arn":         &process.RoleARN,
+	}
+	for i := 2; i < len(fields)-1; i++ {
+		if value, found := flags[fields[i]]; found {
+			*value = fields[i+1]
+			i++
+		}
+	}
+	for flag, value := range flags {
+		if *value == "" {
+			return nil, fmt.Errorf("credential_process is missing %s", flag)
+		}
+	}
+	return process, nil
+}
+
+// RolesAnywhereEndpoint returns the IAM Roles Anywhere endpoint of the region of the trust anchor
+func (p *AWSRolesAnywhereProcess) RolesAnywhereEndpoint() (string, string, error) {
+	parsed, err := arn.Parse(p.TrustAnchorARN)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid trust anchor ARN: %w", err)
+	}
+	return fmt.Sprintf("https://rolesanywhere.%s.amazonaws.com", parsed.Region), parsed.Region, nil
+}
+
+// RolesAnywhereCredentials creates an IAM Roles Anywhere session for the role of the process, signing the
+// CreateSession request with the certificate and private key, and returns the temporary credentials.
+// A failure is returned as a *TokenExchangeError.
+func RolesAnywhereCredentials(ctx context.Context, httpClient *http.Client, endpoint, region string, process *AWSRolesAnywhereProcess, certificatePEM, privateKeyPEM []byte) (credentials.Value, error) {
+	value, err := rolesAnywhereCredentials(ctx, httpClient, endpoint, region, process, certificatePEM, privateKeyPEM, time.Now().UTC())
+	if err != nil {
+		return credentials.Value{}, &TokenExchangeError{CredentialType: AWSRolesAnywhereCredentialType, Err: err}
+	}
+	return value, nil
+}
+
+func rolesAnywhereCredentials(ctx context.Context, httpClient *http.Client, endpoint, region string, process *AWSRolesAnywhereProcess, certificatePEM, privateKeyPEM []byte, now time.Time) (credentials.Value, error) {
+	certificate, err := parseCertificate(certificatePEM)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	if now.Before(certificate.NotBefore) || now.After(certificate.NotAfter) {
+		return credentials.Value{}, fmt.Errorf("certificate is only valid from %s to %s", certificate.NotBefore.Format(time.RFC3339), certificate.NotAfter.Format(time.RFC3339))
+	}
+	signer, algorithm, err := parsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"durationSeconds": rolesAnywhereSessionDuration,
+		"profileArn":      process.ProfileARN,
+		"roleArn":         process.RoleARN,
+		"trustAnchorArn":  process.TrustAnchorARN,
+	})
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	endpointURL, err := url.Parse(strings.TrimSuffix(endpoint, "/") + "/sessions")
+	if err != nil {
+		return credentials.Value{}, fmt.Errorf("invalid IAM Roles Anywhere endpoint: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return credentials.Value{}, err
+	}
+	amzDate := now.Format("20060102T150405Z")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Host", endpointURL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-X509", base64.StdEncoding.EncodeToString(certificate.Raw))
+
+	// SigV4 with the X.509 certificate serial number as access key, see
+	// https://docs.aws.amazon.com/rolesanywhere/latest/userguide/authentication-sign-process.html
+	signedHeaders := "content-type;host;x-amz-date;x-amz-x509"
+	canonicalRequest := strings.Join([]string{
+		http.MethodPost,
+		"/sessions",
+		"",
+		"content-type:application/json",
+		"host:" + endpointURL.Host,
+		"x-amz-date:" + amzDate,
+		"x-amz-x509:" + req.Header.Get("X-Amz-X509"),
+		"",
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", amzDate[:8], region, rolesAnywhereService)
+	stringToSign := strings.Join([]string{algorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+	digest := sha256.Sum256([]byte(stringToSign))
+	signature, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return credentials.Value{}, fmt.Errorf("failed to sign CreateSession request: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm, certificate.SerialNumber.String(), scope, signedHeaders, hex.EncodeToString(signature)))
+
+	var session struct {
+		CredentialSet []struct {
+			Credentials struct {
+				AccessKeyID     string `json:"accessKeyId"`
+				SecretAccessKey string `json:"secretAccessKey"`
+				SessionToken    string `json:"sessionToken"`
+			} `json:"credentials"`
+		} `json:"credentialSet"`
+	}
+	if err := doJSON(httpClient, req, &session); err != nil {
+		return credentials.Value{}, err
+	}
+	if len(session.CredentialSet) == 0 || session.CredentialSet[0].Credentials.AccessKeyID == "" {
+		return credentials.Value{}, fmt.Errorf("CreateSession returned no credentials")
+	}
+	creds := session.CredentialSet[0].Credentials
+	return credentials.Value{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		ProviderName:    AWSRolesAnywhereCredentialType,
+	}, nil
+}
+
+// PodIdentityCredentials requests the credentials of the pod identity association of a service account from the
+// EKS Pod Identity agent at endpoint, authorized by a token of that service account.
+// A failure is returned as a *TokenExchangeError.
+func PodIdentityCredentials(ctx context.Context, httpClient *http.Client, endpoint, token string) (credentials.Value, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return credentials.Value{}, &TokenExchangeError{CredentialType: AWSPodIdentityCredentialType, Err: err}
+	}
+	req.Header.Set("Authorization", token)
+	var creds struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string `json:"SecretAccessKey"`
+		Token           string `json:"Token"`
+	}
+	if err := doJSON(httpClient, req, &creds); err != nil {
+		return credentials.Value{}, &TokenExchangeError{CredentialType: AWSPodIdentityCredentialType, Err: err}
+	}
+	if creds.AccessKeyID == "" {
+		return credentials.Value{}, &TokenExchangeError{CredentialType: AWSPodIdentityCredentialType, Err: fmt.Errorf("agent returned no credentials")}
+	}
+	return credentials.Value{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.Token,
+		ProviderName:    AWSPodIdentityCredentialType,
+	}, nil
+}
+
+// WebIdentityCredentials assumes the role with a service account token through STS AssumeRoleWithWebIdentity and
+// returns the temporary credentials. endpoint overrides the regional STS endpoint when set.
+// A failure is returned as a *TokenExchangeError.
+func WebIdentityCredentials(ctx context.Context, endpoint, region, roleARN, token string) (credentials.Value, error) {
+	config := &aws.Config{
+		Region:      aws.String(region),
+		Credentials: credentials.AnonymousCredentials,
+	}
+	if endpoint != "" {
+		config.Endpoint = aws.String(endpoint)
+	}
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return credentials.Value{}, &TokenExchangeError{CredentialType: AWSWebIdentityCredentialType, Err: err}
+	}
+	out, err := sts.New(sess).AssumeRoleWithWebIdentityWithContext(ctx, &sts.AssumeRoleWithWebIdentityInput{
+		RoleArn:          aws.String(roleARN),
+		RoleSessionName:  aws.String(credentialProbeSessionName),
+		WebIdentityToken: aws.String(token),
+	})
+	if err != nil {
+		return credentials.Value{}, &TokenExchangeError{CredentialType: AWSWebIdentityCredentialType, Err: err}
+	}
+	if out.Credentials == nil {
+		return credentials.Value{}, &TokenExchangeError{CredentialType: AWSWebIdentityCredentialType, Err: fmt.Errorf("AssumeRoleWithWebIdentity returned no credentials")}
+	}
+	return credentials.Value{
+		AccessKeyID:     aws.StringValue(out.Credentials.AccessKeyId),
+		SecretAccessKey: aws.StringValue(out.Credentials.SecretAccessKey),
+		SessionToken:    aws.StringValue(out.Credentials.SessionToken),
+		ProviderName:    AWSWebIdentityCredentialType,
+	}, nil
+}
+
+// NewAWSProviderWithExchangedCredentials creates an AWSProvider with a pre-configured session whose credentials
+// were exchanged for credentialType, such as AWSRolesAnywhereCredentialType or AWSPodIdentityCredentialType.
+func NewAWSProviderWithExchangedCredentials(sess *session.Session, credentialType string) *AWSProvider {
+	provider := NewAWSProviderWithSession(sess)
+	provider.credentialType = credentialType
+	return provider
+}
+
+// ExchangedCredentialType returns the type of the exchanged credentials used by the provider, if any
+func (a *AWSProvider) ExchangedCredentialType() string {
+	return a.credentialType
+}
+
+func doJSON(httpClient *http.Client, req *http.Request, out interface{}) error {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("%s returned %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(data)))
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse response of %s: %w", req.URL.Host, err)
+	}
+	return nil
+}
+
+func parseCertificate(certificatePEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certificatePEM)
+	if block == nil {
+		return nil, fmt.Errorf("certificate is not PEM encoded")
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return certificate, nil
+}
+
+// parsePrivateKey returns the signer of an RSA or ECDSA private key and its IAM Roles Anywhere signing algorithm
+func parsePrivateKey(privateKeyPEM []byte) (crypto.Signer, string, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, "", fmt.Errorf("private key is not PEM encoded")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			if key, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
+				return nil, "", fmt.Errorf("failed to parse private key")
+			}
+		}
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, "AWS4-X509-RSA-SHA256", nil
+	case *ecdsa.PrivateKey:
+		return k, "AWS4-X509-ECDSA-SHA256", nil
+	default:
+		return nil, "", fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,139 @@
+package cloudprovider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testCredentialProcess = "aws_signing_helper credential-process --certificate /credentials-rolesanywhere/cert/tls.crt " +
+	"--private-key /credentials-rolesanywhere/cert/tls.key " +
+	"--trust-anchor-arn arn:aws:rolesanywhere:eu-west-1:123456789012:trust-anchor/ta " +
+	"--profile-arn arn:aws:rolesanywhere:eu-west-1:123456789012:profile/p " +
+	"--role-arn arn:aws:iam::123456789012:role/velero"
+
+func TestParseAWSRolesAnywhereProcess(t *testing.T) {
+	process, err := ParseAWSRolesAnywhereProcess(testCredentialProcess)
+	require.NoError(t, err)
+	require.Equal(t, &AWSRolesAnywhereProcess{
+		Certificate:    "/credentials-rolesanywhere/cert/tls.crt",
+		PrivateKey:     "/credentials-rolesanywhere/cert/tls.key",
+		TrustAnchorARN: "arn:aws:rolesanywhere:eu-west-1:123456789012:trust-anchor/ta",
+		ProfileARN:     "arn:aws:rolesanywhere:eu-west-1:123456789012:profile/p",
+		RoleARN:        "arn:aws:iam::123456789012:role/velero",
+	}, process)
+
+	endpoint, region, err := process.RolesAnywhereEndpoint()
+	require.NoError(t, err)
+	require.Equal(t, "https://rolesanywhere.eu-west-1.amazonaws.com", endpoint)
+	require.Equal(t, "eu-west-1", region)
+
+	_, err = ParseAWSRolesAnywhereProcess("/bin/get-credentials --json")
+	require.ErrorContains(t, err, "does not run the AWS signing helper")
+
+	_, err = ParseAWSRolesAnywhereProcess(strings.Split(testCredentialProcess, " --role-arn")[0])
+	require.ErrorContains(t, err, "credential_process is missing --role-arn")
+}
+
+func TestRolesAnywhereCredentials(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	now := time.Now().UTC()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(4242),
+		Subject:      pkix.Name{CommonName: "velero"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	certificatePEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+
+	process, err := ParseAWSRolesAnywhereProcess(testCredentialProcess)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/sessions", r.URL.Path)
+		require.Equal(t, base64.StdEncoding.EncodeToString(der), r.Header.Get("X-Amz-X509"))
+		body, _ := io.ReadAll(r.Body)
+		var request map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &request))
+		require.Equal(t, process.RoleARN, request["roleArn"])
+
+		// verify the signature with the certificate public key
+		authorization := r.Header.Get("Authorization")
+		require.True(t, strings.HasPrefix(authorization, "AWS4-X509-ECDSA-SHA256 Credential=4242/"), authorization)
+		signature, err := hex.DecodeString(authorization[strings.Index(authorization, "Signature=")+len("Signature="):])
+		require.NoError(t, err)
+		amzDate := r.Header.Get("X-Amz-Date")
+		bodySum := sha256.Sum256(body)
+		canonicalRequest := strings.Join([]string{"POST", "/sessions", "",
+			"content-type:application/json", "host:" + r.Host, "x-amz-date:" + amzDate, "x-amz-x509:" + r.Header.Get("X-Amz-X509"), "",
+			"content-type;host;x-amz-date;x-amz-x509", hex.EncodeToString(bodySum[:])}, "\n")
+		canonicalSum := sha256.Sum256([]byte(canonicalRequest))
+		stringToSign := strings.Join([]string{"AWS4-X509-ECDSA-SHA256", amzDate, amzDate[:8] + "/eu-west-1/rolesanywhere/aws4_request", hex.EncodeToString(canonicalSum[:])}, "\n")
+		digest := sha256.Sum256([]byte(stringToSign))
+		require.True(t, ecdsa.VerifyASN1(&key.PublicKey, digest[:], signature), "signature does not verify")
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"credentialSet":[{"credentials":{"accessKeyId":"AKIA","secretAccessKey":"secret","sessionToken":"token"}}]}`))
+	}))
+	defer server.Close()
+
+	value, err := RolesAnywhereCredentials(context.Background(), server.Client(), server.URL, "eu-west-1", process, certificatePEM, keyPEM)
+	require.NoError(t, err)
+	require.Equal(t, "AKIA", value.AccessKeyID)
+	require.Equal(t, "token", value.SessionToken)
+
+	// an expired certificate is a token exchange failure
+	_, err = rolesAnywhereCredentials(context.Background(), server.Client(), server.URL, "eu-west-1", process, certificatePEM, keyPEM, now.Add(2*time.Hour))
+	require.ErrorContains(t, err, "certificate is only valid from")
+	_, err = RolesAnywhereCredentials(context.Background(), server.Client(), server.URL, "eu-west-1", process, []byte("not pem"), keyPEM)
+	var exchangeErr *TokenExchangeError
+	require.True(t, errors.As(err, &exchangeErr))
+	require.Equal(t, AWSRolesAnywhereCredentialType, exchangeErr.CredentialType)
+}
+
+func TestPodIdentityCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "velero-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte("no pod identity association"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"AccessKeyId":"ASIA","SecretAccessKey":"secret","Token":"session","Expiration":"2030-01-01T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	value, err := PodIdentityCredentials(context.Background(), server.Client(), server.URL, "velero-token")
+	require.NoError(t, err)
+	require.Equal(t, "ASIA", value.AccessKeyID)
+	require.Equal(t, "session", value.SessionToken)
+
+	_, err = PodIdentityCredentials(context.Background(), server.Client(), server.URL, "other-token")
+	var exchangeErr *TokenExchangeError
+	require.True(t, errors.As(err, &exchangeErr))
+	require.Equal(t, AWSPodIdentityCredentialType, exchangeErr.CredentialType)
+	require.ErrorContains(t, err, "no pod identity association")
+}
@@ -4,17 +4,15 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"os"
-	"strings"
+	"io"
 	"time"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
-	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/storage/armstorage"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/go-logr/logr"
 
 	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/credentials/azurecreds"
 	"github.com/openshift/oadp-operator/pkg/utils"
 )
 
@@ -22,118 +20,34 @@ const (
 	maxTestSizeBytesAzure = 200 * 1024 * 1024
 )
 
-type AzureCredentials struct {
-	SubscriptionID     string
-	TenantID           string
-	ClientID           string
-	ClientSecret       string
-	ResourceGroupName  string
-	StorageAccountName string
-	StorageAccountKey  string
-	CertificatePath    string
-}
+// AzureCredentials are the Azure credentials of a BSL or VSL secret
+type AzureCredentials = azurecreds.Credentials
 
 type AzureProvider struct {
 	creds  AzureCredentials
 	client *azblob.Client
 }
 
-// parseCloudCredentials parses environment variable format (e.g., from BSL secret 'cloud' key)
-func parseCloudCredentials(cloudData string) map[string]string {
-	envVars := make(map[string]string)
-	lines := strings.Split(cloudData, "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" && strings.Contains(line, "=") {
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				key := strings.TrimSpace(parts[0])
-				value := strings.TrimSpace(parts[1])
-				envVars[key] = value
-			}
-		}
-	}
-
-	return envVars
-}
-
+// ParseAzureCredentials parses the 'cloud' key of a Velero secret, or the individual keys of the secret
 func ParseAzureCredentials(data map[string][]byte) AzureCredentials {
-	creds := AzureCredentials{}
-
-	// Check if we have the 'cloud' key (Velero BSL format)
-	if cloudData, exists := data["cloud"]; exists && len(cloudData) > 0 {
-		envVars := parseCloudCredentials(string(cloudData))
-
-		creds.SubscriptionID = envVars["AZURE_SUBSCRIPTION_ID"]
-		creds.TenantID = envVars["AZURE_TENANT_ID"]
-		creds.ClientID = envVars["AZURE_CLIENT_ID"]
-		creds.ClientSecret = envVars["AZURE_CLIENT_SECRET"]
-		creds.ResourceGroupName = envVars["AZURE_RESOURCE_GROUP"]
-		creds.StorageAccountName = envVars["AZURE_STORAGE_ACCOUNT_ID"]
-		creds.StorageAccountKey = envVars["AZURE_STORAGE_ACCOUNT_ACCESS_KEY"]
-		creds.CertificatePath = envVars["AZURE_CLIENT_CERTIFICATE_PATH"]
-	} else {
-		// Fall back to individual keys format
-		creds.SubscriptionID = string(data["AZURE_SUBSCRIPTION_ID"])
-		creds.TenantID = string(data["AZURE_TENANT_ID"])
-		creds.ClientID = string(data["AZURE_CLIENT_ID"])
-		creds.ClientSecret = string(data["AZURE_CLIENT_SECRET"])
-		creds.ResourceGroupName = string(data["AZURE_RESOURCE_GROUP"])
-		creds.StorageAccountName = string(data["AZURE_STORAGE_ACCOUNT_ID"])
-		creds.StorageAccountKey = string(data["AZURE_STORAGE_ACCOUNT_ACCESS_KEY"])
-		creds.CertificatePath = string(data["AZURE_CLIENT_CERTIFICATE_PATH"])
-	}
-
-	return creds
+	return azurecreds.Parse(data, "cloud")
 }
 
 func NewAzureProvider(creds AzureCredentials) (*AzureProvider, error) {
-	var err error
+	tokenCred, sharedKeyCred, err := creds.BlobCredential()
+	if err != nil {
+		return nil, err
+	}
+
 	var client *azblob.Client
-	var tokenCred azcore.TokenCredential
-
-	if creds.StorageAccountKey != "" {
-		sharedKeyCred, err := azblob.NewSharedKeyCredential(creds.StorageAccountName, creds.StorageAccountKey)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create shared key credential: %w", err)
-		}
-		serviceURL := fmt.Sprintf("https://%s.blob.core.windows.net/", creds.StorageAccountName)
+	serviceURL := azurecreds.ServiceURL(creds.StorageAccountName)
+	if sharedKeyCred != nil {
 		client, err = azblob.NewClientWithSharedKeyCredential(serviceURL, sharedKeyCred, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create azure client: %w", err)
-		}
-	} else if creds.ClientSecret != "" {
-		tokenCred, err = azidentity.NewClientSecretCredential(creds.TenantID, creds.ClientID, creds.ClientSecret, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create client secret credential: %w", err)
-		}
-	} else if creds.CertificatePath != "" {
-		certData, err := os.ReadFile(creds.CertificatePath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read certificate file: %w", err)
-		}
-		certs, key, err := azidentity.ParseCertificates(certData, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse certificates: %w", err)
-		}
-		tokenCred, err = azidentity.NewClientCertificateCredential(creds.TenantID, creds.ClientID, certs, key, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create client certificate credential: %w", err)
-		}
 	} else {
-		tokenCred, err = azidentity.NewDefaultAzureCredential(nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create default azure credential: %w", err)
-		}
-	}
-
-	if client == nil {
-		serviceURL := fmt.Sprintf("https://%s.blob.core.windows.net/", creds.StorageAccountName)
 		client, err = azblob.NewClient(serviceURL, tokenCred, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create azure client: %w", err)
-		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create azure client: %w", err)
 	}
 
 	return &AzureProvider{
@@ -182,44 +96,66 @@ func (a *AzureProvider) UploadTest(ctx context.Context, config oadpv1alpha1.Uplo
 	return int64(speedMbps), duration, nil
 }
 
-func (a *AzureProvider) IsStorageAccountKeyAuth() bool {
-	return a.creds.StorageAccountKey != ""
+// PutObject writes data to the blob key in the container.
+func (a *AzureProvider) PutObject(ctx context.Context, bucket, key string, data []byte) error {
+	if _, err := a.client.UploadBuffer(ctx, bucket, key, data, &azblob.UploadBufferOptions{}); err != nil {
+		return fmt.Errorf("failed to write blob %s: %w", key, err)
+	}
+	return nil
+}
+
+// DownloadTest uploads a test blob and measures how long it takes to download it.
+// The test blob is deleted afterwards.
+func (a *AzureProvider) DownloadTest(ctx context.Context, config oadpv1alpha1.UploadSpeedTestConfig, bucket string, log logr.Logger) (int64, time.Duration, error) {
+	payload, timeoutDuration, err := downloadTestPayload(config)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	key := fmt.Sprintf("dpt-download-test-%d", time.Now().UnixNano())
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, timeoutDuration)
+	defer cancel()
+
+	log.Info("Uploading download test blob", "bytes", len(payload))
+	if err := a.PutObject(ctxWithTimeout, bucket, key, payload); err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		_, _ = a.client.DeleteBlob(ctx, bucket, key, nil)
+	}()
+
+	log.Info("Downloading from container...")
+	start := time.Now()
+
+	resp, err := a.client.DownloadStream(ctxWithTimeout, bucket, key, nil)
+	if err != nil {
+		return 0, time.Since(start), fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	n, err := io.Copy(io.Discard, resp.Body)
+	duration := time.Since(start)
+	if err != nil {
+		return 0, duration, fmt.Errorf("download failed: %w", err)
+	}
+
+	speed := speedMbps(n, duration)
+	log.Info("Download completed", "duration", duration.String(), "speedMbps", speed)
+	return speed, duration, nil
+}
+
+// IsStorageAccountKeyOnlyAuth reports whether the credentials only hold a storage account key, which cannot
+// authenticate the Azure Resource Manager requests reading the bucket metadata
+func (a *AzureProvider) IsStorageAccountKeyOnlyAuth() bool {
+	return a.creds.Method() == azurecreds.AuthMethodSharedKey && !a.creds.HasIdentity()
 }
 
 //nolint:unparam // The bucket parameter is unused because in Azure, versioning and encryption are properties of the storage account, not the container.
 func (a *AzureProvider) GetBucketMetadata(ctx context.Context, bucket string, log logr.Logger) (*oadpv1alpha1.BucketMetadata, error) {
-	var err error
-	var tokenCred azcore.TokenCredential
-
-	// Choose the correct auth method for ARM APIs
-	if a.creds.StorageAccountKey != "" {
-		tokenCred, err = azidentity.NewDefaultAzureCredential(nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create default azure credential: %w", err)
-		}
-	} else if a.creds.ClientSecret != "" {
-		tokenCred, err = azidentity.NewClientSecretCredential(a.creds.TenantID, a.creds.ClientID, a.creds.ClientSecret, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create client secret credential: %w", err)
-		}
-	} else if a.creds.CertificatePath != "" {
-		certData, err := os.ReadFile(a.creds.CertificatePath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read certificate file: %w", err)
-		}
-		certs, key, err := azidentity.ParseCertificates(certData, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse certificates: %w", err)
-		}
-		tokenCred, err = azidentity.NewClientCertificateCredential(a.creds.TenantID, a.creds.ClientID, certs, key, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create client certificate credential: %w", err)
-		}
-	} else {
-		tokenCred, err = azidentity.NewDefaultAzureCredential(nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create default azure credential: %w", err)
-		}
+	// Azure Resource Manager requests use the Microsoft Entra credential, even with a storage account key
+	tokenCred, err := a.creds.TokenCredential()
+	if err != nil {
+		return nil, err
 	}
 
 	result := &oadpv1alpha1.BucketMetadata{}
@@ -0,0 +1,269 @@
+package cloudprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+const (
+	azureComputeAPIVersion    = "2023-04-02"
+	azureResourcesAPIVersion  = "2021-04-01"
+	azureSnapshotPollInterval = 5 * time.Second
+)
+
+// AzureSnapshotAPI exercises managed disk and snapshot operations through the Azure Resource Manager REST API.
+type AzureSnapshotAPI struct {
+	client         *arm.Client
+	subscriptionID string
+	resourceGroup  string
+	incremental    bool
+}
+
+// NewAzureSnapshotAPI creates an AzureSnapshotAPI for the resource group of the VSL.
+func NewAzureSnapshotAPI(creds AzureCredentials, subscriptionID, resourceGroup string, incremental bool) (*AzureSnapshotAPI, error) {
+	if subscriptionID == "" {
+		return nil, fmt.Errorf("azure subscription ID is required")
+	}
+	if resourceGroup == "" {
+		return nil, fmt.Errorf("azure resource group is required")
+	}
+
+	tokenCred, err := creds.TokenCredential()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := arm.NewClient("oadp-operator/dpt", "v1.0.0", tokenCred, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create azure resource manager client: %w", err)
+	}
+
+	return &AzureSnapshotAPI{
+		client:         client,
+		subscriptionID: subscriptionID,
+		resourceGroup:  resourceGroup,
+		incremental:    incremental,
+	}, nil
+}
+
+func (a *AzureSnapshotAPI) Region() string {
+	return a.resourceGroup
+}
+
+// ZoneOptional reports that scratch disks are created in the location of the resource group.
+func (a *AzureSnapshotAPI) ZoneOptional() bool {
+	return true
+}
+
+func (a *AzureSnapshotAPI) ListVolumes(ctx context.Context) (int, error) {
+	var list struct {
+		Value []map[string]any `json:"value"`
+	}
+	if err := a.do(ctx, http.MethodGet, a.resourceID("disks", ""), azureComputeAPIVersion, nil, &list, http.StatusOK); err != nil {
+		return 0, err
+	}
+	return len(list.Value), nil
+}
+
+func (a *AzureSnapshotAPI) DescribeVolume(ctx context.Context, volumeID string) error {
+	_, err := a.diskLocation(ctx, volumeID)
+	return err
+}
+
+func (a *AzureSnapshotAPI) CreateVolume(ctx context.Context, name, zone string, sizeGB int64) (string, error) {
+	var group struct {
+		Location string `json:"location"`
+	}
+	groupID := fmt.Sprintf("/subscriptions/%s/resourcegroups/%s", a.subscriptionID, a.resourceGroup)
+	if err := a.do(ctx, http.MethodGet, groupID, azureResourcesAPIVersion, nil, &group, http.StatusOK); err != nil {
+		return "", err
+	}
+
+	disk := map[string]any{
+		"location": group.Location,
+		"tags":     map[string]string{NativeSnapshotTagKey: "true"},
+		"sku":      map[string]string{"name": "Standard_LRS"},
+		"properties": map[string]any{
+			"creationData": map[string]string{"createOption": "Empty"},
+			"diskSizeGB":   sizeGB,
+		},
+	}
+	if zone != "" {
+		disk["zones"] = []string{zone}
+	}
+
+	diskID := a.resourceID("disks", name)
+	if accepted, err := a.doLongRunningAccepted(ctx, http.MethodPut, diskID, disk); err != nil {
+		if accepted {
+			return diskID, fmt.Errorf("disk %s did not become available: %w", diskID, err)
+		}
+		return "", err
+	}
+	return diskID, nil
+}
+
+func (a *AzureSnapshotAPI) DeleteVolume(ctx context.Context, volumeID string) error {
+	return a.doLongRunning(ctx, http.MethodDelete, a.diskID(volumeID), nil)
+}
+
+func (a *AzureSnapshotAPI) CreateSnapshot(ctx context.Context, name, volumeID string) (string, error) {
+	location, err := a.diskLocation(ctx, volumeID)
+	if err != nil {
+		return "", err
+	}
+
+	snapshot := map[string]any{
+		"location": location,
+		"tags":     map[string]string{NativeSnapshotTagKey: "true"},
+		"properties": map[string]any{
+			"creationData": map[string]string{
+				"createOption":     "Copy",
+				"sourceResourceId": a.diskID(volumeID),
+			},
+			"incremental": a.incremental,
+		},
+	}
+
+	// the snapshot completion is awaited in WaitForSnapshot
+	snapshotID := a.resourceID("snapshots", name)
+	if err := a.do(ctx, http.MethodPut, snapshotID, azureComputeAPIVersion, snapshot, nil, http.StatusOK, http.StatusCreated, http.StatusAccepted); err != nil {
+		return "", err
+	}
+	return snapshotID, nil
+}
+
+func (a *AzureSnapshotAPI) WaitForSnapshot(ctx context.Context, snapshotID string) error {
+	for {
+		var snapshot struct {
+			Properties struct {
+				ProvisioningState string   `json:"provisioningState"`
+				CompletionPercent *float64 `json:"completionPercent"`
+			} `json:"properties"`
+		}
+		if err := a.do(ctx, http.MethodGet, snapshotID, azureComputeAPIVersion, nil, &snapshot, http.StatusOK); err != nil {
+			return err
+		}
+
+		state := snapshot.Properties.ProvisioningState
+		switch {
+		case state == "Failed":
+			return fmt.Errorf("snapshot %s failed", snapshotID)
+		case state == "Succeeded" && (snapshot.Properties.CompletionPercent == nil || *snapshot.Properties.CompletionPercent >= 100):
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for snapshot %s, last state %s: %w", snapshotID, state, ctx.Err())
+		case <-time.After(azureSnapshotPollInterval):
+		}
+	}
+}
+
+func (a *AzureSnapshotAPI) DeleteSnapshot(ctx context.Context, snapshotID string) error {
+	return a.doLongRunning(ctx, http.MethodDelete, snapshotID, nil)
+}
+
+func (a *AzureSnapshotAPI) IsPermissionDenied(err error) bool {
+	var respErr *azcore.ResponseError
+	if !errors.As(err, &respErr) {
+		return false
+	}
+	return respErr.StatusCode == http.StatusForbidden ||
+		respErr.ErrorCode == "AuthorizationFailed" ||
+		respErr.ErrorCode == "LinkedAuthorizationFailed"
+}
+
+// resourceID returns the ID of a Microsoft.Compute resource (or collection when name is empty) in the resource group.
+func (a *AzureSnapshotAPI) resourceID(resourceType, name string) string {
+	id := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/%s", a.subscriptionID, a.resourceGroup, resourceType)
+	if name != "" {
+		id += "/" + name
+	}
+	return id
+}
+
+// diskID accepts a disk name in the resource group or a full resource ID.
+func (a *AzureSnapshotAPI) diskID(volumeID string) string {
+	if strings.HasPrefix(volumeID, "/subscriptions/") {
+		return volumeID
+	}
+	return a.resourceID("disks", volumeID)
+}
+
+func (a *AzureSnapshotAPI) diskLocation(ctx context.Context, volumeID string) (string, error) {
+	var disk struct {
+		Location string `json:"location"`
+	}
+	if err := a.do(ctx, http.MethodGet, a.diskID(volumeID), azureComputeAPIVersion, nil, &disk, http.StatusOK); err != nil {
+		return "", err
+	}
+	return disk.Location, nil
+}
+
+// send issues a request against the resource manager endpoint and returns the response if its status is expected.
+func (a *AzureSnapshotAPI) send(ctx context.Context, method, resourceID, apiVersion string, body any, statusCodes ...int) (*http.Response, error) {
+	req, err := runtime.NewRequest(ctx, method, runtime.JoinPaths(a.client.Endpoint(), resourceID))
+	if err != nil {
+		return nil, err
+	}
+	query := req.Raw().URL.Query()
+	query.Set("api-version", apiVersion)
+	req.Raw().URL.RawQuery = query.Encode()
+	req.Raw().Header.Set("Accept", "application/json")
+	if body != nil {
+		if err := runtime.MarshalAsJSON(req, body); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := a.client.Pipeline().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if !runtime.HasStatusCode(resp, statusCodes...) {
+		return nil, runtime.NewResponseError(resp)
+	}
+	return resp, nil
+}
+
+func (a *AzureSnapshotAPI) do(ctx context.Context, method, resourceID, apiVersion string, body, result any, statusCodes ...int) error {
+	resp, err := a.send(ctx, method, resourceID, apiVersion, body, statusCodes...)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		runtime.Drain(resp)
+		return nil
+	}
+	return runtime.UnmarshalAsJSON(resp, result)
+}
+
+// doLongRunning issues a create or delete request and polls the operation until it is done.
+func (a *AzureSnapshotAPI) doLongRunning(ctx context.Context, method, resourceID string, body any) error {
+	_, err := a.doLongRunningAccepted(ctx, method, resourceID, body)
+	return err
+}
+
+// doLongRunningAccepted is doLongRunning also reporting whether the request was accepted, so that a resource
+// created by a request whose completion failed can still be deleted.
+func (a *AzureSnapshotAPI) doLongRunningAccepted(ctx context.Context, method, resourceID string, body any) (bool, error) {
+	resp, err := a.send(ctx, method, resourceID, azureComputeAPIVersion, body, http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusNoContent)
+	if err != nil {
+		return false, err
+	}
+	poller, err := runtime.NewPoller[map[string]any](resp, a.client.Pipeline(), nil)
+	if err != nil {
+		return true, err
+	}
+	_, err = poller.PollUntilDone(ctx, &runtime.PollUntilDoneOptions{Frequency: azureSnapshotPollInterval})
+	return true, err
+}
@@ -129,35 +129,3 @@ AZURE_STORAGE_ACCOUNT_ACCESS_KEY=test-storage-key`
 		t.Errorf("Expected StorageAccountKey to be 'test-storage-key', got '%s'", creds.StorageAccountKey)
 	}
 }
-
-func TestParseCloudCredentials(t *testing.T) {
-	cloudData := `AZURE_SUBSCRIPTION_ID=test-sub
-AZURE_TENANT_ID=test-tenant
-
-# This is a comment
-AZURE_CLIENT_ID=test-client
-INVALID_LINE_WITHOUT_EQUALS
-AZURE_CLIENT_SECRET=test-secret`
-
-	envVars := parseCloudCredentials(cloudData)
-
-	expected := map[string]string{
-		"AZURE_SUBSCRIPTION_ID": "test-sub",
-		"AZURE_TENANT_ID":       "test-tenant",
-		"AZURE_CLIENT_ID":       "test-client",
-		"AZURE_CLIENT_SECRET":   "test-secret",
-	}
-
-	for key, expectedValue := range expected {
-		if value, exists := envVars[key]; !exists {
-			t.Errorf("Expected key '%s' to exist", key)
-		} else if value != expectedValue {
-			t.Errorf("Expected %s to be '%s', got '%s'", key, expectedValue, value)
-		}
-	}
-
-	// Should not include invalid lines
-	if _, exists := envVars["INVALID_LINE_WITHOUT_EQUALS"]; exists {
-		t.Error("Should not include invalid lines without equals sign")
-	}
-}
@@ -0,0 +1,272 @@
+package cloudprovider
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/oadp-operator/pkg/credentials/azurecreds"
+)
+
+const (
+	// CredentialCheckNotSupported is returned when a credential cannot be exchanged for a token by the operator,
+	// e.g. a storage account key or a profile without keys or role.
+	CredentialCheckNotSupported = "credential check not supported"
+
+	// ServiceAccountTokenAudience is the audience of the projected token used by AWS STS and Azure workload identity
+	ServiceAccountTokenAudience = "openshift"
+
+	awsDefaultSTSRegion = "us-east-1"
+)
+
+// CredentialProber performs the provider calls of the credential health check.
+// Implementations must not persist the credentials they are given.
+type CredentialProber interface {
+	// AWSCallerIdentity calls STS GetCallerIdentity with static keys and returns the caller ARN
+	AWSCallerIdentity(ctx context.Context, creds AWSStaticCredentials, region string) (string, error)
+	// AWSAssumeRoleWithWebIdentity exchanges a service account token for role credentials and returns the assumed role ARN
+	AWSAssumeRoleWithWebIdentity(ctx context.Context, roleARN, token, region string) (string, error)
+	// AzureToken acquires an Azure AD token for the service principal, or for the workload identity when federatedToken is set
+	AzureToken(ctx context.Context, creds AzureCredentials, federatedToken string) error
+	// AzureClientSecretExpiry returns the end date of the client secret of the application, or nil when it cannot be read
+	AzureClientSecretExpiry(ctx context.Context, creds AzureCredentials) (*time.Time, error)
+	// GCPToken exchanges a service account key, or an external account config with subjectToken, for an access token
+	GCPToken(ctx context.Context, credentialsJSON []byte, subjectToken string) error
+	// GCPKeyExpiry returns the validBeforeTime of the service account key, or nil when it cannot be read
+	GCPKeyExpiry(ctx context.Context, credentialsJSON []byte) (*time.Time, error)
+}
+
+// AWSStaticCredentials are the keys of an AWS shared credentials profile.
+type AWSStaticCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// CredentialCheckOptions configures CheckCredentials.
+type CredentialCheckOptions struct {
+	// Profile is the AWS profile of the location, "default" when empty
+	Profile string
+	// Region is the AWS region of the location, used for the STS endpoint
+	Region string
+	// ServiceAccountToken returns a token of the Velero service account for the given audience.
+	// It is required to check AWS STS, Azure workload identity and GCP workload identity federation credentials.
+	ServiceAccountToken func(ctx context.Context, audience string) (string, error)
+	// SecretLabels are the labels of the secret, which mark the Azure secrets of the standardized STS flow
+	SecretLabels map[string]string
+}
+
+// CredentialCheckResult is the outcome of a credential health check.
+type CredentialCheckResult struct {
+	// Identity is the authenticated principal: a caller or role ARN, an Azure client ID or a GCP service account
+	Identity string
+	// ExpiresAt is the expiry of the long-lived secret (Azure client secret or GCP key), if known
+	ExpiresAt *time.Time
+	// Skipped is set when the credential type cannot be checked
+	Skipped bool
+	// Err is the authentication error, if any
+	Err error
+}
+
+// CheckCredentials authenticates with the credential stored in data, the value of a BSL or VSL secret key, for provider.
+func CheckCredentials(ctx context.Context, prober CredentialProber, provider string, data []byte, opts CredentialCheckOptions) CredentialCheckResult {
+	switch {
+	case strings.Contains(provider, "aws"):
+		return checkAWSCredentials(ctx, prober, data, opts)
+	case strings.Contains(provider, "azure"):
+		return checkAzureCredentials(ctx, prober, data, opts)
+	case strings.Contains(provider, "gcp"):
+		return checkGCPCredentials(ctx, prober, data, opts)
+	default:
+		return CredentialCheckResult{Skipped: true, Err: fmt.Errorf("%s for provider %s", CredentialCheckNotSupported, provider)}
+	}
+}
+
+func checkAWSCredentials(ctx context.Context, prober CredentialProber, data []byte, opts CredentialCheckOptions) CredentialCheckResult {
+	profileName := opts.Profile
+	if profileName == "" {
+		profileName = "default"
+	}
+	region := opts.Region
+	if region == "" {
+		region = awsDefaultSTSRegion
+	}
+
+	profile, found := ParseAWSProfile(data, profileName)
+	if !found {
+		return CredentialCheckResult{Err: fmt.Errorf("profile %s not found in AWS credentials", profileName)}
+	}
+
+	if roleARN := profile["role_arn"]; roleARN != "" && profile["web_identity_token_file"] != "" {
+		token, err := serviceAccountToken(ctx, opts, ServiceAccountTokenAudience)
+		if err != nil {
+			return CredentialCheckResult{Identity: roleARN, Err: err}
+		}
+		identity, err := prober.AWSAssumeRoleWithWebIdentity(ctx, roleARN, token, region)
+		if err != nil {
+			return CredentialCheckResult{Identity: roleARN, Err: fmt.Errorf("AssumeRoleWithWebIdentity failed: %w", err)}
+		}
+		return CredentialCheckResult{Identity: identity}
+	}
+
+	creds := AWSStaticCredentials{
+		AccessKeyID:     profile["aws_access_key_id"],
+		SecretAccessKey: profile["aws_secret_access_key"],
+		SessionToken:    profile["aws_session_token"],
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return CredentialCheckResult{Skipped: true, Err: fmt.Errorf("%s for AWS profile %s without keys or web identity role", CredentialCheckNotSupported, profileName)}
+	}
+	identity, err := prober.AWSCallerIdentity(ctx, creds, region)
+	if err != nil {
+		return CredentialCheckResult{Err: fmt.Errorf("GetCallerIdentity failed: %w", err)}
+	}
+	return CredentialCheckResult{Identity: identity}
+}
+
+// checkAzureCredentials authenticates with the method Velero and the bucket client use for the secret.
+func checkAzureCredentials(ctx context.Context, prober CredentialProber, data []byte, opts CredentialCheckOptions) CredentialCheckResult {
+	creds := azurecreds.FromSecret(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Labels: opts.SecretLabels},
+		Data:       map[string][]byte{"cloud": data},
+	}, "cloud")
+	if err := creds.Validate(); err != nil {
+		return CredentialCheckResult{Err: err}
+	}
+	result := CredentialCheckResult{Identity: creds.ClientID}
+
+	switch method := creds.Method(); method {
+	case azurecreds.AuthMethodSharedKey:
+		return CredentialCheckResult{Skipped: true, Err: fmt.Errorf("%s for Azure storage account keys", CredentialCheckNotSupported)}
+	case azurecreds.AuthMethodWorkloadIdentity:
+		token, err := serviceAccountToken(ctx, opts, ServiceAccountTokenAudience)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		if err := prober.AzureToken(ctx, creds, token); err != nil {
+			result.Err = fmt.Errorf("workload identity token acquisition failed: %w", err)
+		}
+		return result
+	case azurecreds.AuthMethodServicePrincipal:
+		if err := prober.AzureToken(ctx, creds, ""); err != nil {
+			result.Err = fmt.Errorf("service principal token acquisition failed: %w", err)
+			return result
+		}
+		if creds.ClientSecret != "" {
+			// reading the application requires Microsoft Graph permissions the principal may not have
+			if expiresAt, err := prober.AzureClientSecretExpiry(ctx, creds); err == nil {
+				result.ExpiresAt = expiresAt
+			}
+		}
+		return result
+	default:
+		// the managed identity named by the client ID, or the DefaultAzureCredential chain without one
+		if !creds.HasIdentity() {
+			result.Identity = string(method)
+		}
+		if err := prober.AzureToken(ctx, creds, ""); err != nil {
+			result.Err = fmt.Errorf("%s token acquisition failed: %w", method, err)
+		}
+		return result
+	}
+}
+
+func checkGCPCredentials(ctx context.Context, prober CredentialProber, data []byte, opts CredentialCheckOptions) CredentialCheckResult {
+	var key struct {
+		Type        string `json:"type"`
+		ClientEmail string `json:"client_email"`
+	}
+	if err := json.Unmarshal(data, &key); err != nil {
+		return CredentialCheckResult{Err: fmt.Errorf("failed to parse GCP credentials: %w", err)}
+	}
+
+	switch key.Type {
+	case "service_account":
+		result := CredentialCheckResult{Identity: key.ClientEmail}
+		if err := prober.GCPToken(ctx, data, ""); err != nil {
+			result.Err = fmt.Errorf("service account token exchange failed: %w", err)
+			return result
+		}
+		// reading the key requires iam.serviceAccountKeys.get, which the service account may not have
+		if expiresAt, err := prober.GCPKeyExpiry(ctx, data); err == nil {
+			result.ExpiresAt = expiresAt
+		}
+		return result
+	case "external_account":
+		// the audience and endpoints are checked before a Velero service account token is requested for the audience
+		account, err := ParseGCPExternalAccount(data)
+		if err != nil {
+			return CredentialCheckResult{Err: err}
+		}
+		result := CredentialCheckResult{Identity: gcpImpersonatedServiceAccount(account.ServiceAccountImpersonationURL)}
+		token, err := serviceAccountToken(ctx, opts, account.Audience)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		if err := prober.GCPToken(ctx, data, token); err != nil {
+			result.Err = fmt.Errorf("workload identity federation token exchange failed: %w", err)
+		}
+		return result
+	default:
+		return CredentialCheckResult{Skipped: true, Err: fmt.Errorf("%s for GCP credential type %q", CredentialCheckNotSupported, key.Type)}
+	}
+}
+
+func serviceAccountToken(ctx context.Context, opts CredentialCheckOptions, audience string) (string, error) {
+	if opts.ServiceAccountToken == nil {
+		return "", fmt.Errorf("service account token source is not configured")
+	}
+	token, err := opts.ServiceAccountToken(ctx, audience)
+	if err != nil {
+		return "", fmt.Errorf("failed to request service account token: %w", err)
+	}
+	return token, nil
+}
+
+// ParseAWSProfile returns the keys of profile in an AWS shared credentials or config file.
+func ParseAWSProfile(data []byte, profile string) (map[string]string, bool) {
+	values := map[string]string{}
+	found, inProfile := false, false
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(strings.Trim(line, "[]"))
+			name = strings.TrimSpace(strings.TrimPrefix(name, "profile "))
+			inProfile = name == profile
+			found = found || inProfile
+			continue
+		}
+		if !inProfile {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		values[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return values, found
+}
+
+// gcpImpersonatedServiceAccount extracts the service account email from an impersonation URL
+// such as https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/<email>:generateAccessToken.
+func gcpImpersonatedServiceAccount(url string) string {
+	_, account, found := strings.Cut(url, "/serviceAccounts/")
+	if !found {
+		return ""
+	}
+	account, _, _ = strings.Cut(account, ":")
+	return account
+}
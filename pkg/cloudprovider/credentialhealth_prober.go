@@ -0,0 +1,222 @@
+package cloudprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"golang.org/x/oauth2/google"
+	"golang.org/x/oauth2/google/externalaccount"
+	"google.golang.org/api/iam/v1"
+	"google.golang.org/api/option"
+)
+
+const (
+	azureManagementScope       = "https://management.azure.com/.default"
+	azureGraphScope            = "https://graph.microsoft.com/.default"
+	azureGraphEndpoint         = "https://graph.microsoft.com/v1.0"
+	gcpCloudPlatformScope      = "https://www.googleapis.com/auth/cloud-platform"
+	credentialProbeSessionName = "oadp-credential-health-check"
+)
+
+// SDKCredentialProber implements CredentialProber with the cloud provider SDKs.
+type SDKCredentialProber struct {
+	// HTTPClient is used for Microsoft Graph requests, http.DefaultClient when nil
+	HTTPClient *http.Client
+}
+
+var _ CredentialProber = &SDKCredentialProber{}
+
+func (p *SDKCredentialProber) AWSCallerIdentity(ctx context.Context, creds AWSStaticCredentials, region string) (string, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String(region),
+		Credentials: credentials.NewStaticCredentials(creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create AWS session: %w", err)
+	}
+	out, err := sts.New(sess).GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(out.Arn), nil
+}
+
+func (p *SDKCredentialProber) AWSAssumeRoleWithWebIdentity(ctx context.Context, roleARN, token, region string) (string, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String(region),
+		Credentials: credentials.AnonymousCredentials,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create AWS session: %w", err)
+	}
+	out, err := sts.New(sess).AssumeRoleWithWebIdentityWithContext(ctx, &sts.AssumeRoleWithWebIdentityInput{
+		RoleArn:          aws.String(roleARN),
+		RoleSessionName:  aws.String(credentialProbeSessionName),
+		WebIdentityToken: aws.String(token),
+	})
+	if err != nil {
+		return "", err
+	}
+	if out.AssumedRoleUser != nil {
+		return aws.StringValue(out.AssumedRoleUser.Arn), nil
+	}
+	return roleARN, nil
+}
+
+func (p *SDKCredentialProber) AzureToken(ctx context.Context, creds AzureCredentials, federatedToken string) error {
+	tokenCred, err := p.azureTokenCredential(creds, federatedToken)
+	if err != nil {
+		return err
+	}
+	_, err = tokenCred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{azureManagementScope}})
+	return err
+}
+
+func (p *SDKCredentialProber) AzureClientSecretExpiry(ctx context.Context, creds AzureCredentials) (*time.Time, error) {
+	tokenCred, err := p.azureTokenCredential(creds, "")
+	if err != nil {
+		return nil, err
+	}
+	token, err := tokenCred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{azureGraphScope}})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/applications(appId='%s')?$select=passwordCredentials", azureGraphEndpoint, url.PathEscape(creds.ClientID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	req.Header.Set("Accept", "application/json")
+
+	httpClient := p.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("microsoft graph returned %s", resp.Status)
+	}
+
+	var app struct {
+		PasswordCredentials []azurePasswordCredential `json:"passwordCredentials"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&app); err != nil {
+		return nil, fmt.Errorf("failed to decode application: %w", err)
+	}
+	return azureClientSecretEndDate(app.PasswordCredentials, creds.ClientSecret), nil
+}
+
+func (p *SDKCredentialProber) GCPToken(ctx context.Context, credentialsJSON []byte, subjectToken string) error {
+	if subjectToken != "" {
+		account, err := ParseGCPExternalAccount(credentialsJSON)
+		if err != nil {
+			return err
+		}
+		tokenSource, err := account.TokenSource(ctx, subjectToken)
+		if err != nil {
+			return err
+		}
+		_, err = tokenSource.Token()
+		return err
+	}
+
+	creds, err := google.CredentialsFromJSON(ctx, credentialsJSON, gcpCloudPlatformScope)
+	if err != nil {
+		return fmt.Errorf("failed to parse service account key: %w", err)
+	}
+	_, err = creds.TokenSource.Token()
+	return err
+}
+
+func (p *SDKCredentialProber) GCPKeyExpiry(ctx context.Context, credentialsJSON []byte) (*time.Time, error) {
+	var key struct {
+		ClientEmail  string `json:"client_email"`
+		PrivateKeyID string `json:"private_key_id"`
+	}
+	if err := json.Unmarshal(credentialsJSON, &key); err != nil {
+		return nil, fmt.Errorf("failed to parse service account key: %w", err)
+	}
+	if key.ClientEmail == "" || key.PrivateKeyID == "" {
+		return nil, fmt.Errorf("client_email and private_key_id are required")
+	}
+
+	service, err := iam.NewService(ctx, option.WithCredentialsJSON(credentialsJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCP IAM client: %w", err)
+	}
+	saKey, err := service.Projects.ServiceAccounts.Keys.Get(fmt.Sprintf("projects/-/serviceAccounts/%s/keys/%s", key.ClientEmail, key.PrivateKeyID)).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	if saKey.ValidBeforeTime == "" {
+		return nil, nil
+	}
+	validBefore, err := time.Parse(time.RFC3339, saKey.ValidBeforeTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key validBeforeTime: %w", err)
+	}
+	return &validBefore, nil
+}
+
+// azureTokenCredential uses the workload identity of the Velero service account when federatedToken is set.
+func (p *SDKCredentialProber) azureTokenCredential(creds AzureCredentials, federatedToken string) (azcore.TokenCredential, error) {
+	if federatedToken == "" {
+		return creds.TokenCredential()
+	}
+	tokenCred, err := azidentity.NewClientAssertionCredential(creds.TenantID, creds.ClientID, func(context.Context) (string, error) {
+		return federatedToken, nil
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client assertion credential: %w", err)
+	}
+	return tokenCred, nil
+}
+
+// azurePasswordCredential is a client secret of a Microsoft Entra application.
+type azurePasswordCredential struct {
+	Hint        string     `json:"hint"`
+	EndDateTime *time.Time `json:"endDateTime"`
+}
+
+// azureClientSecretEndDate returns the end date of the password credential whose hint (the first
+// characters of the secret) matches clientSecret, or the earliest end date when none matches.
+func azureClientSecretEndDate(passwords []azurePasswordCredential, clientSecret string) *time.Time {
+	var earliest *time.Time
+	for _, password := range passwords {
+		if password.EndDateTime == nil {
+			continue
+		}
+		if password.Hint != "" && strings.HasPrefix(clientSecret, password.Hint) {
+			return password.EndDateTime
+		}
+		if earliest == nil || password.EndDateTime.Before(*earliest) {
+			earliest = password.EndDateTime
+		}
+	}
+	return earliest
+}
+
+// staticSubjectToken supplies the service account token to the external account token source.
+type staticSubjectToken string
+
+func (s staticSubjectToken) SubjectToken(ctx context.Context, options externalaccount.SupplierOptions) (string, error) {
+	return string(s), nil
+}
@@ -0,0 +1,307 @@
+package cloudprovider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/oadp-operator/pkg/credentials/azurecreds"
+	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
+)
+
+// fakeCredentialProber records the credentials it receives and returns the configured results.
+type fakeCredentialProber struct {
+	err          error
+	expiresAt    *time.Time
+	awsCreds     AWSStaticCredentials
+	awsRegion    string
+	azureCreds   AzureCredentials
+	subjectToken string
+}
+
+func (f *fakeCredentialProber) AWSCallerIdentity(ctx context.Context, creds AWSStaticCredentials, region string) (string, error) {
+	f.awsCreds, f.awsRegion = creds, region
+	return "arn:aws:iam::123456789012:user/velero", f.err
+}
+
+func (f *fakeCredentialProber) AWSAssumeRoleWithWebIdentity(ctx context.Context, roleARN, token, region string) (string, error) {
+	f.subjectToken, f.awsRegion = token, region
+	return "arn:aws:sts::123456789012:assumed-role/velero/oadp-credential-health-check", f.err
+}
+
+func (f *fakeCredentialProber) AzureToken(ctx context.Context, creds AzureCredentials, federatedToken string) error {
+	f.azureCreds, f.subjectToken = creds, federatedToken
+	return f.err
+}
+
+func (f *fakeCredentialProber) AzureClientSecretExpiry(ctx context.Context, creds AzureCredentials) (*time.Time, error) {
+	return f.expiresAt, nil
+}
+
+func (f *fakeCredentialProber) GCPToken(ctx context.Context, credentialsJSON []byte, subjectToken string) error {
+	f.subjectToken = subjectToken
+	return f.err
+}
+
+func (f *fakeCredentialProber) GCPKeyExpiry(ctx context.Context, credentialsJSON []byte) (*time.Time, error) {
+	return f.expiresAt, nil
+}
+
+func TestCheckCredentials(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	var requestedAudience string
+	tokenSource := func(ctx context.Context, audience string) (string, error) {
+		requestedAudience = audience
+		return "token-for-" + audience, nil
+	}
+
+	tests := []struct {
+		name               string
+		provider           string
+		data               string
+		opts               CredentialCheckOptions
+		proberErr          error
+		expectIdentity     string
+		expectErr          string
+		expectSkipped      bool
+		expectExpiry       bool
+		expectSubjectToken string
+		expectRegion       string
+		expectAzureMethod  azurecreds.AuthMethod
+		expectNoToken      bool
+	}{
+		{
+			name:           "AWS static keys of the selected profile",
+			provider:       "aws",
+			data:           "[default]\naws_access_key_id=AKIA1\naws_secret_access_key=s1\n[backup]\naws_access_key_id = \"AKIA2\"\naws_secret_access_key = s2\n",
+			opts:           CredentialCheckOptions{Profile: "backup", Region: "eu-west-1"},
+			expectIdentity: "arn:aws:iam::123456789012:user/velero",
+			expectRegion:   "eu-west-1",
+		},
+		{
+			name:      "AWS rejected keys",
+			provider:  "aws",
+			data:      "[default]\naws_access_key_id=AKIA1\naws_secret_access_key=s1\n",
+			proberErr: errors.New("InvalidClientTokenId"),
+			expectErr: "GetCallerIdentity failed: InvalidClientTokenId",
+		},
+		{
+			name:               "AWS STS role uses the service account token",
+			provider:           "aws",
+			data:               "[default]\nrole_arn = arn:aws:iam::123456789012:role/velero\nweb_identity_token_file = /var/run/secrets/openshift/serviceaccount/token\n",
+			opts:               CredentialCheckOptions{ServiceAccountToken: tokenSource},
+			expectIdentity:     "arn:aws:sts::123456789012:assumed-role/velero/oadp-credential-health-check",
+			expectSubjectToken: "token-for-openshift",
+			expectRegion:       "us-east-1",
+		},
+		{
+			name:      "AWS STS role without token source",
+			provider:  "aws",
+			data:      "[default]\nrole_arn = arn:aws:iam::123456789012:role/velero\nweb_identity_token_file = /token\n",
+			expectErr: "service account token source is not configured",
+		},
+		{
+			name:      "AWS missing profile",
+			provider:  "aws",
+			data:      "[default]\naws_access_key_id=AKIA1\naws_secret_access_key=s1\n",
+			opts:      CredentialCheckOptions{Profile: "other"},
+			expectErr: "profile other not found",
+		},
+		{
+			name:           "Azure service principal with expiring secret",
+			provider:       "azure",
+			data:           "AZURE_TENANT_ID=tenant\nAZURE_CLIENT_ID=client\nAZURE_CLIENT_SECRET=secret\n",
+			expectIdentity: "client",
+			expectExpiry:   true,
+		},
+		{
+			name:               "Azure workload identity",
+			provider:           "azure",
+			data:               "AZURE_TENANT_ID=tenant\nAZURE_CLIENT_ID=client\nAZURE_FEDERATED_TOKEN_FILE=/var/run/secrets/openshift/serviceaccount/token\n",
+			opts:               CredentialCheckOptions{ServiceAccountToken: tokenSource},
+			expectIdentity:     "client",
+			expectSubjectToken: "token-for-openshift",
+			expectAzureMethod:  azurecreds.AuthMethodWorkloadIdentity,
+		},
+		{
+			name:     "Azure workload identity of the standardized STS flow",
+			provider: "azure",
+			data:     "AZURE_TENANT_ID=tenant\nAZURE_CLIENT_ID=client\n",
+			opts: CredentialCheckOptions{
+				ServiceAccountToken: tokenSource,
+				SecretLabels:        map[string]string{stsflow.STSSecretLabelKey: stsflow.STSSecretLabelValue},
+			},
+			expectIdentity:     "client",
+			expectSubjectToken: "token-for-openshift",
+			expectAzureMethod:  azurecreds.AuthMethodWorkloadIdentity,
+		},
+		{
+			name:              "Azure managed identity with only a client ID",
+			provider:          "azure",
+			data:              "AZURE_CLIENT_ID=client\n",
+			opts:              CredentialCheckOptions{ServiceAccountToken: tokenSource},
+			expectIdentity:    "client",
+			expectAzureMethod: azurecreds.AuthMethodManagedIdentity,
+			expectNoToken:     true,
+		},
+		{
+			name:              "Azure managed identity with a client and tenant ID",
+			provider:          "azure",
+			data:              "AZURE_TENANT_ID=tenant\nAZURE_CLIENT_ID=client\n",
+			opts:              CredentialCheckOptions{ServiceAccountToken: tokenSource},
+			expectIdentity:    "client",
+			expectAzureMethod: azurecreds.AuthMethodManagedIdentity,
+			expectNoToken:     true,
+		},
+		{
+			name:              "Azure default credential chain without identity",
+			provider:          "azure",
+			data:              "AZURE_SUBSCRIPTION_ID=subscription\nAZURE_RESOURCE_GROUP=group\n",
+			opts:              CredentialCheckOptions{ServiceAccountToken: tokenSource},
+			expectIdentity:    string(azurecreds.AuthMethodDefault),
+			expectAzureMethod: azurecreds.AuthMethodDefault,
+			expectNoToken:     true,
+		},
+		{
+			name:      "Azure rejected managed identity",
+			provider:  "azure",
+			data:      "AZURE_CLIENT_ID=client\n",
+			proberErr: errors.New("no managed identity endpoint"),
+			expectErr: "ManagedIdentity token acquisition failed",
+		},
+		{
+			name:      "Azure client secret without tenant",
+			provider:  "azure",
+			data:      "AZURE_CLIENT_ID=client\nAZURE_CLIENT_SECRET=secret\n",
+			expectErr: "service principal credentials require",
+		},
+		{
+			name:      "Azure rejected service principal",
+			provider:  "azure",
+			data:      "AZURE_TENANT_ID=tenant\nAZURE_CLIENT_ID=client\nAZURE_CLIENT_SECRET=secret\n",
+			proberErr: errors.New("AADSTS7000222: client secret expired"),
+			expectErr: "service principal token acquisition failed",
+		},
+		{
+			name:          "Azure storage account key is not checked",
+			provider:      "azure",
+			data:          "AZURE_STORAGE_ACCOUNT_ACCESS_KEY=key\n",
+			expectSkipped: true,
+			expectErr:     CredentialCheckNotSupported,
+		},
+		{
+			name:          "Azure shared key takes precedence over the identity",
+			provider:      "azure",
+			data:          "AZURE_TENANT_ID=tenant\nAZURE_CLIENT_ID=client\nAZURE_STORAGE_ACCOUNT_ACCESS_KEY=key\n",
+			opts:          CredentialCheckOptions{ServiceAccountToken: tokenSource},
+			expectSkipped: true,
+			expectErr:     CredentialCheckNotSupported,
+			expectNoToken: true,
+		},
+		{
+			name:           "GCP service account key",
+			provider:       "gcp",
+			data:           `{"type":"service_account","client_email":"velero@project.iam.gserviceaccount.com"}`,
+			expectIdentity: "velero@project.iam.gserviceaccount.com",
+			expectExpiry:   true,
+		},
+		{
+			name:     "GCP workload identity federation uses the config audience",
+			provider: "gcp",
+			data: `{"type":"external_account","audience":"//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/pool/providers/provider",
+				"token_url":"https://sts.googleapis.com/v1/token",
+				"service_account_impersonation_url":"https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/velero@project.iam.gserviceaccount.com:generateAccessToken"}`,
+			opts:               CredentialCheckOptions{ServiceAccountToken: tokenSource},
+			expectIdentity:     "velero@project.iam.gserviceaccount.com",
+			expectSubjectToken: "token-for-//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/pool/providers/provider",
+		},
+		{
+			name:     "GCP workload identity federation with the API server audience is refused",
+			provider: "gcp",
+			data: `{"type":"external_account","audience":"https://kubernetes.default.svc",
+				"token_url":"https://sts.googleapis.com/v1/token"}`,
+			opts:          CredentialCheckOptions{ServiceAccountToken: tokenSource},
+			expectErr:     "is not a workload identity pool provider",
+			expectNoToken: true,
+		},
+		{
+			name:     "GCP workload identity federation with a hostile token_url is refused",
+			provider: "gcp",
+			data: `{"type":"external_account","audience":"//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/pool/providers/provider",
+				"token_url":"https://attacker.example.com/v1/token"}`,
+			opts:          CredentialCheckOptions{ServiceAccountToken: tokenSource},
+			expectErr:     "is not the GCP security token service",
+			expectNoToken: true,
+		},
+		{
+			name:      "GCP invalid JSON",
+			provider:  "gcp",
+			data:      "not json",
+			expectErr: "failed to parse GCP credentials",
+		},
+		{
+			name:          "unsupported provider",
+			provider:      "openshift",
+			expectSkipped: true,
+			expectErr:     CredentialCheckNotSupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestedAudience = ""
+			prober := &fakeCredentialProber{err: tt.proberErr, expiresAt: &expiresAt}
+			result := CheckCredentials(context.Background(), prober, tt.provider, []byte(tt.data), tt.opts)
+
+			require.Equal(t, tt.expectSkipped, result.Skipped)
+			if tt.expectNoToken {
+				require.Empty(t, requestedAudience, "no service account token may be requested")
+			}
+			if tt.expectErr != "" {
+				require.ErrorContains(t, result.Err, tt.expectErr)
+				return
+			}
+			require.NoError(t, result.Err)
+			require.Equal(t, tt.expectIdentity, result.Identity)
+			require.Equal(t, tt.expectExpiry, result.ExpiresAt != nil)
+			require.Equal(t, tt.expectSubjectToken, prober.subjectToken)
+			if tt.expectRegion != "" {
+				require.Equal(t, tt.expectRegion, prober.awsRegion)
+			}
+			if tt.expectAzureMethod != "" {
+				require.Equal(t, tt.expectAzureMethod, prober.azureCreds.Method())
+			}
+		})
+	}
+}
+
+func TestParseAWSProfile(t *testing.T) {
+	data := []byte("# comment\n[default]\naws_access_key_id=AKIA1\n\n[profile backup]\nregion = us-west-2\naws_session_token='token'\n")
+
+	profile, found := ParseAWSProfile(data, "default")
+	require.True(t, found)
+	require.Equal(t, map[string]string{"aws_access_key_id": "AKIA1"}, profile)
+
+	profile, found = ParseAWSProfile(data, "backup")
+	require.True(t, found)
+	require.Equal(t, map[string]string{"region": "us-west-2", "aws_session_token": "token"}, profile)
+
+	_, found = ParseAWSProfile(data, "missing")
+	require.False(t, found)
+}
+
+func TestAzureClientSecretEndDate(t *testing.T) {
+	early := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	passwords := []azurePasswordCredential{
+		{Hint: "abc", EndDateTime: &late},
+		{Hint: "xyz", EndDateTime: &early},
+	}
+
+	require.Equal(t, &late, azureClientSecretEndDate(passwords, "abc-secret"))
+	require.Equal(t, &early, azureClientSecretEndDate(passwords, "unknown"))
+	require.Nil(t, azureClientSecretEndDate(nil, "abc-secret"))
+}
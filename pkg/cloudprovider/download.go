@@ -0,0 +1,38 @@
+package cloudprovider
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/utils"
+)
+
+// downloadTestPayload returns the payload and timeout for a download test, applying the same
+// size limit and default timeout as the upload test.
+func downloadTestPayload(config oadpv1alpha1.UploadSpeedTestConfig) ([]byte, time.Duration, error) {
+	testDataBytes, err := utils.ParseFileSize(config.FileSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid file size: %w", err)
+	}
+
+	if testDataBytes > maxTestSizeBytes {
+		return nil, 0, fmt.Errorf("test file size %d exceeds max allowed %dMB (due to pod mem limit)", testDataBytes, maxTestSizeBytes/1024/1024)
+	}
+
+	timeoutDuration := 30 * time.Second
+	if config.Timeout.Duration != 0 {
+		timeoutDuration = config.Timeout.Duration
+	}
+
+	return bytes.Repeat([]byte("0"), int(testDataBytes)), timeoutDuration, nil
+}
+
+// speedMbps converts transferred bytes and duration to megabits per second.
+func speedMbps(bytes int64, duration time.Duration) int64 {
+	if duration <= 0 {
+		return 0
+	}
+	return int64((float64(bytes*8) / duration.Seconds()) / 1_000_000)
+}
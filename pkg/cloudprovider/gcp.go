@@ -2,20 +2,150 @@ package cloudprovider
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/url"
+	"regexp"
 	"time"
 
 	"cloud.google.com/go/storage"
 	"github.com/go-logr/logr"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google/externalaccount"
 	"google.golang.org/api/option"
 
 	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
 	"github.com/openshift/oadp-operator/pkg/utils"
 )
 
+const (
+	// GCPServiceAccountCredentialType is the type of GCP service account key credentials
+	GCPServiceAccountCredentialType = "service_account"
+	// GCPExternalAccountCredentialType is the type of GCP workload identity federation credentials
+	GCPExternalAccountCredentialType = "external_account"
+
+	// gcpSecurityTokenServiceHost is the only security token service external_account credentials are exchanged with
+	gcpSecurityTokenServiceHost = "sts.googleapis.com"
+	// gcpIAMCredentialsHost is the only service account impersonation endpoint of external_account credentials
+	gcpIAMCredentialsHost = "iamcredentials.googleapis.com"
+)
+
+// gcpWorkloadIdentityPoolAudience matches the audience of a workload identity pool provider. The Velero service
+// account token is requested for this audience, so it must not be usable against any other service.
+var gcpWorkloadIdentityPoolAudience = regexp.MustCompile(`^//iam\.googleapis\.com/projects/[^/]+/locations/[^/]+/workloadIdentityPools/[^/]+/providers/[^/]+$`)
+
 type GCPProvider struct {
 	client *storage.Client
 	bucket string
+	// credentialType is set to GCPExternalAccountCredentialType when the client uses an exchanged federated token
+	credentialType string
+}
+
+var _ TokenExchanger = &GCPProvider{}
+
+// TokenExchangeError reports a failure to exchange a federated token for a cloud access token,
+// as opposed to a failure to access the bucket with that access token.
+type TokenExchangeError struct {
+	// CredentialType is the type of the exchanged credentials
+	CredentialType string
+	Err            error
+}
+
+func (e *TokenExchangeError) Error() string {
+	return fmt.Sprintf("%s token exchange failed: %v", e.CredentialType, e.Err)
+}
+
+func (e *TokenExchangeError) Unwrap() error {
+	return e.Err
+}
+
+// GCPExternalAccount is the workload identity federation configuration of GCP external_account credentials.
+// The credential_source of the configuration is ignored: the subject token is always a token of the Velero
+// service account requested by the operator, never a file named by the secret.
+type GCPExternalAccount struct {
+	Type                           string `json:"type"`
+	Audience                       string `json:"audience"`
+	SubjectTokenType               string `json:"subject_token_type"`
+	TokenURL                       string `json:"token_url"`
+	ServiceAccountImpersonationURL string `json:"service_account_impersonation_url"`
+}
+
+// GCPCredentialType returns the type of GCP credentials JSON, such as service_account or external_account
+func GCPCredentialType(credentialsJSON []byte) (string, error) {
+	var credentials struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(credentialsJSON, &credentials); err != nil {
+		return "", fmt.Errorf("failed to parse GCP credentials: %w", err)
+	}
+	return credentials.Type, nil
+}
+
+// ParseGCPExternalAccount parses GCP external_account credentials JSON. Only the workload identity pool audience,
+// the GCP security token service and the GCP IAM credentials service are accepted, so that a service account
+// token issued for the audience is never sent anywhere else.
+func ParseGCPExternalAccount(credentialsJSON []byte) (*GCPExternalAccount, error) {
+	account := &GCPExternalAccount{}
+	if err := json.Unmarshal(credentialsJSON, account); err != nil {
+		return nil, fmt.Errorf("failed to parse external account config: %w", err)
+	}
+	if account.Type != GCPExternalAccountCredentialType {
+		return nil, fmt.Errorf("credentials type is %q, expected %q", account.Type, GCPExternalAccountCredentialType)
+	}
+	if account.Audience == "" || account.TokenURL == "" {
+		return nil, fmt.Errorf("external account config requires audience and token_url")
+	}
+	if !gcpWorkloadIdentityPoolAudience.MatchString(account.Audience) {
+		return nil, fmt.Errorf("audience %q is not a workload identity pool provider", account.Audience)
+	}
+	if !isGCPEndpoint(account.TokenURL, gcpSecurityTokenServiceHost) {
+		return nil, fmt.Errorf("token_url %q is not the GCP security token service https://%s", account.TokenURL, gcpSecurityTokenServiceHost)
+	}
+	if account.ServiceAccountImpersonationURL != "" && !isGCPEndpoint(account.ServiceAccountImpersonationURL, gcpIAMCredentialsHost) {
+		return nil, fmt.Errorf("service_account_impersonation_url %q is not the GCP IAM credentials service https://%s", account.ServiceAccountImpersonationURL, gcpIAMCredentialsHost)
+	}
+	return account, nil
+}
+
+// isGCPEndpoint reports whether rawURL is an https URL of host
+func isGCPEndpoint(rawURL, host string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "https" && u.Host == host && u.User == nil
+}
+
+// TokenSource returns a token source exchanging subjectToken, a service account token issued for the
+// audience of the account, with the GCP security token service
+func (a *GCPExternalAccount) TokenSource(ctx context.Context, subjectToken string) (oauth2.TokenSource, error) {
+	return externalaccount.NewTokenSource(ctx, externalaccount.Config{
+		Audience:                       a.Audience,
+		SubjectTokenType:               a.SubjectTokenType,
+		TokenURL:                       a.TokenURL,
+		ServiceAccountImpersonationURL: a.ServiceAccountImpersonationURL,
+		SubjectTokenSupplier:           staticSubjectToken(subjectToken),
+		Scopes:                         []string{gcpCloudPlatformScope},
+	})
+}
+
+// GCPExternalAccountTokenSource exchanges subjectToken for an access token with the workload identity federation
+// credentials JSON and returns the token source of the exchanged token. A failed exchange is returned as a
+// *TokenExchangeError.
+func GCPExternalAccountTokenSource(ctx context.Context, credentialsJSON []byte, subjectToken string) (oauth2.TokenSource, error) {
+	account, err := ParseGCPExternalAccount(credentialsJSON)
+	if err != nil {
+		return nil, err
+	}
+	tokenSource, err := account.TokenSource(ctx, subjectToken)
+	if err != nil {
+		return nil, &TokenExchangeError{CredentialType: GCPExternalAccountCredentialType, Err: err}
+	}
+	if _, err := tokenSource.Token(); err != nil {
+		return nil, &TokenExchangeError{CredentialType: GCPExternalAccountCredentialType, Err: err}
+	}
+	return tokenSource, nil
 }
 
 // NewGCPProvider creates a GCPProvider using service account credentials
@@ -31,6 +161,32 @@ func NewGCPProvider(ctx context.Context, bucket string, credentialsJSON []byte)
 	}, nil
 }
 
+// NewGCPProviderWithExternalAccount creates a GCPProvider using workload identity federation credentials.
+// subjectToken is exchanged for an access token before the client is created, and a failed exchange is
+// returned as a *TokenExchangeError.
+func NewGCPProviderWithExternalAccount(ctx context.Context, bucket string, credentialsJSON []byte, subjectToken string) (*GCPProvider, error) {
+	tokenSource, err := GCPExternalAccountTokenSource(ctx, credentialsJSON, subjectToken)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := storage.NewClient(ctx, option.WithTokenSource(tokenSource))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCP storage client: %w", err)
+	}
+
+	return &GCPProvider{
+		client:         client,
+		bucket:         bucket,
+		credentialType: GCPExternalAccountCredentialType,
+	}, nil
+}
+
+// ExchangedCredentialType returns GCPExternalAccountCredentialType when the provider uses workload identity federation
+func (g *GCPProvider) ExchangedCredentialType() string {
+	return g.credentialType
+}
+
 // UploadTest performs a test upload and returns calculated speed and test duration
 func (g *GCPProvider) UploadTest(ctx context.Context, config oadpv1alpha1.UploadSpeedTestConfig, bucket string, log logr.Logger) (int64, time.Duration, error) {
 	log.Info("Starting GCP upload speed test", "fileSize", config.FileSize, "timeout", config.Timeout.Duration.String())
@@ -94,6 +250,60 @@ func (g *GCPProvider) UploadTest(ctx context.Context, config oadpv1alpha1.Upload
 	return speedMbps, duration, nil
 }
 
+// PutObject writes data to key in the bucket.
+func (g *GCPProvider) PutObject(ctx context.Context, bucket, key string, data []byte) error {
+	w := g.client.Bucket(bucket).Object(key).NewWriter(ctx)
+	w.ContentType = "application/json"
+
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write object %s: %w", key, err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to close writer for object %s: %w", key, err)
+	}
+	return nil
+}
+
+// DownloadTest uploads a test object and measures how long it takes to download it.
+// The test object is deleted afterwards.
+func (g *GCPProvider) DownloadTest(ctx context.Context, config oadpv1alpha1.UploadSpeedTestConfig, bucket string, log logr.Logger) (int64, time.Duration, error) {
+	payload, timeoutDuration, err := downloadTestPayload(config)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	objectName := fmt.Sprintf("dpt-download-test-%d", time.Now().UnixNano())
+	downloadCtx, cancel := context.WithTimeout(ctx, timeoutDuration)
+	defer cancel()
+
+	log.Info("Uploading GCP download test object", "bytes", len(payload))
+	if err := g.PutObject(downloadCtx, bucket, objectName, payload); err != nil {
+		return 0, 0, err
+	}
+	obj := g.client.Bucket(bucket).Object(objectName)
+	defer func() {
+		_ = obj.Delete(ctx)
+	}()
+
+	start := time.Now()
+	reader, err := obj.NewReader(downloadCtx)
+	if err != nil {
+		return 0, time.Since(start), fmt.Errorf("download failed: %w", err)
+	}
+	defer reader.Close()
+
+	n, err := io.Copy(io.Discard, reader)
+	duration := time.Since(start)
+	if err != nil {
+		return 0, duration, fmt.Errorf("download failed: %w", err)
+	}
+
+	log.Info("GCP download test completed", "bytesRead", n, "duration", duration.String())
+	return speedMbps(n, duration), duration, nil
+}
+
 // GetBucketMetadata retrieves the encryption and versioning config for a bucket
 func (g *GCPProvider) GetBucketMetadata(ctx context.Context, bucket string, log logr.Logger) (*oadpv1alpha1.BucketMetadata, error) {
 	log.Info("Retrieving GCP bucket metadata", "bucket", bucket)
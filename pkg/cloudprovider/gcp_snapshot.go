@@ -0,0 +1,211 @@
+package cloudprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+const gcpSnapshotPollInterval = 5 * time.Second
+
+// GCPSnapshotAPI exercises GCE persistent disk and snapshot operations.
+type GCPSnapshotAPI struct {
+	service          *compute.Service
+	project          string
+	zone             string
+	snapshotLocation string
+}
+
+// NewGCPSnapshotAPI creates a GCPSnapshotAPI for the project of the disks using service account credentials.
+// zone is where the scratch disk is created and volumes are described.
+func NewGCPSnapshotAPI(ctx context.Context, credentialsJSON []byte, project, zone, snapshotLocation string) (*GCPSnapshotAPI, error) {
+	return newGCPSnapshotAPI(ctx, option.WithCredentialsJSON(credentialsJSON), project, zone, snapshotLocation)
+}
+
+// NewGCPSnapshotAPIWithExternalAccount creates a GCPSnapshotAPI using workload identity federation credentials.
+// subjectToken is exchanged for an access token before the client is created, and a failed exchange is
+// returned as a *TokenExchangeError.
+func NewGCPSnapshotAPIWithExternalAccount(ctx context.Context, credentialsJSON []byte, subjectToken, project, zone, snapshotLocation string) (*GCPSnapshotAPI, error) {
+	tokenSource, err := GCPExternalAccountTokenSource(ctx, credentialsJSON, subjectToken)
+	if err != nil {
+		return nil, err
+	}
+	return newGCPSnapshotAPI(ctx, option.WithTokenSource(tokenSource), project, zone, snapshotLocation)
+}
+
+func newGCPSnapshotAPI(ctx context.Context, credentials option.ClientOption, project, zone, snapshotLocation string) (*GCPSnapshotAPI, error) {
+	service, err := compute.NewService(ctx, credentials)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCP compute client: %w", err)
+	}
+	return &GCPSnapshotAPI{
+		service:          service,
+		project:          project,
+		zone:             zone,
+		snapshotLocation: snapshotLocation,
+	}, nil
+}
+
+func (g *GCPSnapshotAPI) Region() string {
+	return g.project
+}
+
+func (g *GCPSnapshotAPI) ListVolumes(ctx context.Context) (int, error) {
+	if g.zone != "" {
+		list, err := g.service.Disks.List(g.project, g.zone).MaxResults(5).Context(ctx).Do()
+		if err != nil {
+			return 0, err
+		}
+		return len(list.Items), nil
+	}
+
+	list, err := g.service.Disks.AggregatedList(g.project).MaxResults(5).Context(ctx).Do()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, scoped := range list.Items {
+		count += len(scoped.Disks)
+	}
+	return count, nil
+}
+
+func (g *GCPSnapshotAPI) DescribeVolume(ctx context.Context, volumeID string) error {
+	if g.zone == "" {
+		return fmt.Errorf("availabilityZone is required to describe GCP disk %s", volumeID)
+	}
+	_, err := g.service.Disks.Get(g.project, g.zone, volumeID).Context(ctx).Do()
+	return err
+}
+
+func (g *GCPSnapshotAPI) CreateVolume(ctx context.Context, name, zone string, sizeGB int64) (string, error) {
+	op, err := g.service.Disks.Insert(g.project, zone, &compute.Disk{
+		Name:   name,
+		SizeGb: sizeGB,
+		Labels: gcpLabels(),
+	}).Context(ctx).Do()
+	if err != nil {
+		return "", err
+	}
+	if err := g.waitZoneOperation(ctx, zone, op); err != nil {
+		return name, err
+	}
+	return name, nil
+}
+
+func (g *GCPSnapshotAPI) DeleteVolume(ctx context.Context, volumeID string) error {
+	op, err := g.service.Disks.Delete(g.project, g.zone, volumeID).Context(ctx).Do()
+	if err != nil {
+		return err
+	}
+	return g.waitZoneOperation(ctx, g.zone, op)
+}
+
+func (g *GCPSnapshotAPI) CreateSnapshot(ctx context.Context, name, volumeID string) (string, error) {
+	if g.zone == "" {
+		return "", fmt.Errorf("availabilityZone is required to snapshot GCP disk %s", volumeID)
+	}
+	snapshot := &compute.Snapshot{
+		Name:        name,
+		Description: "OADP DataProtectionTest snapshot",
+		Labels:      gcpLabels(),
+	}
+	if g.snapshotLocation != "" {
+		snapshot.StorageLocations = []string{g.snapshotLocation}
+	}
+
+	op, err := g.service.Disks.CreateSnapshot(g.project, g.zone, volumeID, snapshot).Context(ctx).Do()
+	if err != nil {
+		return "", err
+	}
+	if err := g.waitZoneOperation(ctx, g.zone, op); err != nil {
+		return name, err
+	}
+	return name, nil
+}
+
+func (g *GCPSnapshotAPI) WaitForSnapshot(ctx context.Context, snapshotID string) error {
+	for {
+		snapshot, err := g.service.Snapshots.Get(g.project, snapshotID).Context(ctx).Do()
+		if err != nil {
+			return err
+		}
+		switch snapshot.Status {
+		case "READY":
+			return nil
+		case "FAILED":
+			return fmt.Errorf("snapshot %s failed", snapshotID)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for snapshot %s, last status %s: %w", snapshotID, snapshot.Status, ctx.Err())
+		case <-time.After(gcpSnapshotPollInterval):
+		}
+	}
+}
+
+func (g *GCPSnapshotAPI) DeleteSnapshot(ctx context.Context, snapshotID string) error {
+	op, err := g.service.Snapshots.Delete(g.project, snapshotID).Context(ctx).Do()
+	if err != nil {
+		return err
+	}
+	for op.Status != "DONE" {
+		if op, err = g.service.GlobalOperations.Wait(g.project, op.Name).Context(ctx).Do(); err != nil {
+			return err
+		}
+	}
+	return gcpOperationErr(op)
+}
+
+func (g *GCPSnapshotAPI) IsPermissionDenied(err error) bool {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusForbidden
+	}
+	var opErr *gcpOperationError
+	if errors.As(err, &opErr) {
+		return strings.Contains(opErr.code, "PERMISSION")
+	}
+	return false
+}
+
+// waitZoneOperation waits for a zonal operation to finish and returns its error, if any.
+func (g *GCPSnapshotAPI) waitZoneOperation(ctx context.Context, zone string, op *compute.Operation) error {
+	var err error
+	for op.Status != "DONE" {
+		if op, err = g.service.ZoneOperations.Wait(g.project, zone, op.Name).Context(ctx).Do(); err != nil {
+			return err
+		}
+	}
+	return gcpOperationErr(op)
+}
+
+// gcpOperationError is the first error of a finished compute operation.
+type gcpOperationError struct {
+	code    string
+	message string
+}
+
+func (e *gcpOperationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.code, e.message)
+}
+
+func gcpOperationErr(op *compute.Operation) error {
+	if op.Error == nil || len(op.Error.Errors) == 0 {
+		return nil
+	}
+	return &gcpOperationError{code: op.Error.Errors[0].Code, message: op.Error.Errors[0].Message}
+}
+
+func gcpLabels() map[string]string {
+	// GCE label keys cannot contain "." or "/"
+	return map[string]string{"oadp-dpt": "true"}
+}
@@ -2,6 +2,7 @@ package cloudprovider
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -181,3 +182,90 @@ func TestGCPProvider_Close(t *testing.T) {
 		t.Errorf("Unexpected error closing provider with nil client: %v", err)
 	}
 }
+
+func TestParseGCPExternalAccount(t *testing.T) {
+	const audience = "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/pool/providers/provider"
+	externalAccount := func(audience, tokenURL, impersonationURL string) string {
+		return fmt.Sprintf(`{
+			"type": "external_account",
+			"audience": %q,
+			"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+			"token_url": %q,
+			"service_account_impersonation_url": %q,
+			"credential_source": {"file": "/var/run/secrets/kubernetes.io/serviceaccount/token"}
+		}`, audience, tokenURL, impersonationURL)
+	}
+	tests := []struct {
+		name            string
+		credentialsJSON string
+		expectError     bool
+	}{
+		{
+			name:            "workload identity federation",
+			credentialsJSON: externalAccount(audience, "https://sts.googleapis.com/v1/token", ""),
+		},
+		{
+			name: "workload identity federation with service account impersonation",
+			credentialsJSON: externalAccount(audience, "https://sts.googleapis.com/v1/token",
+				"https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/velero@project.iam.gserviceaccount.com:generateAccessToken"),
+		},
+		{
+			name:            "hostile token_url",
+			credentialsJSON: externalAccount(audience, "https://attacker.example.com/v1/token", ""),
+			expectError:     true,
+		},
+		{
+			name:            "token_url on a lookalike host",
+			credentialsJSON: externalAccount(audience, "https://sts.googleapis.com.attacker.example.com/v1/token", ""),
+			expectError:     true,
+		},
+		{
+			name:            "plain http token_url",
+			credentialsJSON: externalAccount(audience, "http://sts.googleapis.com/v1/token", ""),
+			expectError:     true,
+		},
+		{
+			name:            "hostile service account impersonation URL",
+			credentialsJSON: externalAccount(audience, "https://sts.googleapis.com/v1/token", "https://attacker.example.com/generateAccessToken"),
+			expectError:     true,
+		},
+		{
+			name:            "Kubernetes API server audience",
+			credentialsJSON: externalAccount("https://kubernetes.default.svc", "https://sts.googleapis.com/v1/token", ""),
+			expectError:     true,
+		},
+		{
+			name:            "service account key",
+			credentialsJSON: `{"type": "service_account"}`,
+			expectError:     true,
+		},
+		{
+			name:            "missing audience",
+			credentialsJSON: `{"type": "external_account", "token_url": "https://sts.googleapis.com/v1/token"}`,
+			expectError:     true,
+		},
+		{
+			name:            "invalid JSON",
+			credentialsJSON: `invalid json`,
+			expectError:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account, err := ParseGCPExternalAccount([]byte(tt.credentialsJSON))
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if account.Audience != audience {
+				t.Errorf("expected audience %q, got %q", audience, account.Audience)
+			}
+		})
+	}
+}
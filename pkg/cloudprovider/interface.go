@@ -17,3 +17,29 @@ type CloudProvider interface {
 	// GetBucketMetadata retrieves the encryption and versioning config for a bucket
 	GetBucketMetadata(ctx context.Context, bucket string, log logr.Logger) (*oadpv1alpha1.BucketMetadata, error)
 }
+
+// CapabilityProber is implemented by providers that can probe optional features of
+// an S3-compatible endpoint, such as flexible checksums and object lock.
+type CapabilityProber interface {
+	// ProbeCapabilities detects the features supported by the bucket; vendor is the detected S3 vendor name
+	ProbeCapabilities(ctx context.Context, bucket, vendor string, log logr.Logger) (*oadpv1alpha1.S3Capabilities, error)
+}
+
+// ObjectWriter is implemented by providers that can store a small object in the bucket.
+type ObjectWriter interface {
+	// PutObject writes data to key in the bucket, replacing any existing object
+	PutObject(ctx context.Context, bucket, key string, data []byte) error
+}
+
+// DownloadTester is implemented by providers that can measure download speed.
+type DownloadTester interface {
+	// DownloadTest uploads a test object, then downloads it and returns the calculated speed and download duration
+	DownloadTest(ctx context.Context, config oadpv1alpha1.UploadSpeedTestConfig, bucket string, log logr.Logger) (int64, time.Duration, error)
+}
+
+// TokenExchanger is implemented by providers that may authenticate by exchanging a federated token,
+// such as a Kubernetes service account token, for a cloud access token.
+type TokenExchanger interface {
+	// ExchangedCredentialType returns the type of the exchanged credential, or "" if the provider did not exchange a token
+	ExchangedCredentialType() string
+}
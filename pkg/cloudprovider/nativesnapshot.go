@@ -0,0 +1,186 @@
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+const (
+	// PermissionAllowed means the operation succeeded
+	PermissionAllowed = "Allowed"
+	// PermissionDenied means the provider rejected the operation as unauthorized
+	PermissionDenied = "Denied"
+	// PermissionError means the operation failed for a reason other than authorization
+	PermissionError = "Error"
+
+	// NativeSnapshotTagKey tags the scratch disks and snapshots created by the test
+	NativeSnapshotTagKey = "oadp.openshift.io/dpt"
+
+	defaultNativeSnapshotTimeout = 10 * time.Minute
+	nativeSnapshotCleanupTimeout = 5 * time.Minute
+)
+
+// SnapshotAPI is the set of volume and snapshot operations exercised by the native snapshot test.
+// Implementations wrap a single cloud compute client scoped to the VSL region.
+type SnapshotAPI interface {
+	// Region returns the AWS region, GCP project or Azure resource group the API is scoped to
+	Region() string
+	// ListVolumes lists the first page of volumes and returns how many were found
+	ListVolumes(ctx context.Context) (int, error)
+	// DescribeVolume reads a single volume
+	DescribeVolume(ctx context.Context, volumeID string) error
+	// CreateVolume creates an empty disk and waits until it can be snapshotted. The disk ID is returned with the
+	// error when the disk was created but did not become available, so that it is deleted.
+	CreateVolume(ctx context.Context, name, zone string, sizeGB int64) (string, error)
+	// DeleteVolume deletes a disk created by CreateVolume
+	DeleteVolume(ctx context.Context, volumeID string) error
+	// CreateSnapshot starts a snapshot of the volume and returns its ID, also with the error when the snapshot
+	// was created
+	CreateSnapshot(ctx context.Context, name, volumeID string) (string, error)
+	// WaitForSnapshot blocks until the snapshot is complete
+	WaitForSnapshot(ctx context.Context, snapshotID string) error
+	// DeleteSnapshot deletes the snapshot
+	DeleteSnapshot(ctx context.Context, snapshotID string) error
+	// IsPermissionDenied reports whether err is an authorization failure
+	IsPermissionDenied(err error) bool
+}
+
+// NativeSnapshotTestOptions configures RunNativeSnapshotTest.
+type NativeSnapshotTestOptions struct {
+	// Name is used for the scratch disk and the snapshot
+	Name string
+	// VolumeID of an existing volume; a scratch disk is created when empty
+	VolumeID string
+	// AvailabilityZone of the scratch disk
+	AvailabilityZone string
+	// ScratchDiskSizeGB is the size of the scratch disk
+	ScratchDiskSizeGB int64
+	// Timeout bounds creating the scratch disk and waiting for the snapshot
+	Timeout time.Duration
+}
+
+// RunNativeSnapshotTest lists and describes volumes, then snapshots the given volume or a scratch disk
+// and deletes what it created. Every operation is recorded with its permission result and duration.
+// The test continues after a failed list or describe so that all missing permissions are reported at once.
+func RunNativeSnapshotTest(ctx context.Context, api SnapshotAPI, opts NativeSnapshotTestOptions, log logr.Logger) *oadpv1alpha1.VolumeSnapshotLocationTestStatus {
+	status := &oadpv1alpha1.VolumeSnapshotLocationTestStatus{
+		Region: api.Region(),
+		Status: "Passed",
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = defaultNativeSnapshotTimeout
+	}
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// cleanup must run even when the test timed out
+	cleanupCtx, cleanupCancel := context.WithTimeout(context.WithoutCancel(ctx), nativeSnapshotCleanupTimeout)
+	defer cleanupCancel()
+
+	record := func(operation string, fn func() error) error {
+		start := time.Now()
+		err := fn()
+		result := oadpv1alpha1.SnapshotOperationStatus{
+			Operation:  operation,
+			Permission: PermissionAllowed,
+			Duration:   time.Since(start).Truncate(time.Millisecond).String(),
+		}
+		if err != nil {
+			result.Permission = PermissionError
+			if api.IsPermissionDenied(err) {
+				result.Permission = PermissionDenied
+			}
+			result.ErrorMessage = err.Error()
+			status.Status = "Failed"
+			if status.ErrorMessage == "" {
+				status.ErrorMessage = fmt.Sprintf("%s failed: %v", operation, err)
+			}
+			log.Error(err, "native snapshot operation failed", "operation", operation, "permission", result.Permission)
+		} else {
+			log.Info("native snapshot operation succeeded", "operation", operation, "duration", result.Duration)
+		}
+		status.Operations = append(status.Operations, result)
+		return err
+	}
+
+	_ = record("ListVolumes", func() error {
+		count, err := api.ListVolumes(ctxWithTimeout)
+		status.VolumesFound = count
+		return err
+	})
+
+	volumeID := opts.VolumeID
+	if volumeID == "" {
+		if opts.AvailabilityZone == "" && requiresZone(api) {
+			status.Status = "Failed"
+			status.ErrorMessage = "availabilityZone is required to create a scratch volume"
+			return status
+		}
+		sizeGB := opts.ScratchDiskSizeGB
+		if sizeGB < 1 {
+			sizeGB = 1
+		}
+		err := record("CreateVolume", func() error {
+			var err error
+			volumeID, err = api.CreateVolume(ctxWithTimeout, opts.Name, opts.AvailabilityZone, sizeGB)
+			return err
+		})
+		// the volume is returned with an error when it was created but did not become available
+		if volumeID != "" {
+			status.ScratchVolume = true
+			status.VolumeID = volumeID
+			defer func() {
+				_ = record("DeleteVolume", func() error { return api.DeleteVolume(cleanupCtx, volumeID) })
+			}()
+		}
+		if err != nil {
+			return status
+		}
+	}
+	status.VolumeID = volumeID
+
+	_ = record("DescribeVolume", func() error { return api.DescribeVolume(ctxWithTimeout, volumeID) })
+
+	snapshotStart := time.Now()
+	var snapshotID string
+	err := record("CreateSnapshot", func() error {
+		var err error
+		snapshotID, err = api.CreateSnapshot(ctxWithTimeout, opts.Name, volumeID)
+		return err
+	})
+	// the snapshot is returned with an error when it was created but did not become ready
+	if snapshotID != "" {
+		status.SnapshotID = snapshotID
+		defer func() {
+			_ = record("DeleteSnapshot", func() error { return api.DeleteSnapshot(cleanupCtx, snapshotID) })
+		}()
+	}
+	if err != nil {
+		return status
+	}
+
+	if err := record("WaitForSnapshot", func() error { return api.WaitForSnapshot(ctxWithTimeout, snapshotID) }); err == nil {
+		status.SnapshotReadyDuration = time.Since(snapshotStart).Truncate(time.Millisecond).String()
+	}
+
+	return status
+}
+
+// zoneOptional is implemented by snapshot APIs that can create a scratch disk without an availability zone.
+type zoneOptional interface {
+	ZoneOptional() bool
+}
+
+func requiresZone(api SnapshotAPI) bool {
+	if z, ok := api.(zoneOptional); ok {
+		return !z.ZoneOptional()
+	}
+	return true
+}
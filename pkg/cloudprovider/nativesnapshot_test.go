@@ -0,0 +1,227 @@
+package cloudprovider
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/googleapi"
+)
+
+var errDenied = errors.New("denied")
+
+// fakeSnapshotAPI records the operations it receives and fails the ones listed in errs. A failed create returns no
+// ID unless the operation is listed in idOnError, like a resource created but not available before the timeout.
+type fakeSnapshotAPI struct {
+	errs         map[string]error
+	idOnError    map[string]bool
+	zoneOptional bool
+	calls        []string
+}
+
+func (f *fakeSnapshotAPI) create(operation, id string) (string, error) {
+	err := f.call(operation)
+	if err != nil && !f.idOnError[operation] {
+		return "", err
+	}
+	return id, err
+}
+
+func (f *fakeSnapshotAPI) call(operation string) error {
+	f.calls = append(f.calls, operation)
+	return f.errs[operation]
+}
+
+func (f *fakeSnapshotAPI) Region() string { return "us-east-1" }
+
+func (f *fakeSnapshotAPI) ListVolumes(ctx context.Context) (int, error) {
+	return 3, f.call("ListVolumes")
+}
+
+func (f *fakeSnapshotAPI) DescribeVolume(ctx context.Context, volumeID string) error {
+	return f.call("DescribeVolume")
+}
+
+func (f *fakeSnapshotAPI) CreateVolume(ctx context.Context, name, zone string, sizeGB int64) (string, error) {
+	return f.create("CreateVolume", "vol-scratch")
+}
+
+func (f *fakeSnapshotAPI) DeleteVolume(ctx context.Context, volumeID string) error {
+	return f.call("DeleteVolume")
+}
+
+func (f *fakeSnapshotAPI) CreateSnapshot(ctx context.Context, name, volumeID string) (string, error) {
+	return f.create("CreateSnapshot", "snap-1")
+}
+
+func (f *fakeSnapshotAPI) WaitForSnapshot(ctx context.Context, snapshotID string) error {
+	return f.call("WaitForSnapshot")
+}
+
+func (f *fakeSnapshotAPI) DeleteSnapshot(ctx context.Context, snapshotID string) error {
+	return f.call("DeleteSnapshot")
+}
+
+func (f *fakeSnapshotAPI) IsPermissionDenied(err error) bool {
+	return errors.Is(err, errDenied)
+}
+
+func (f *fakeSnapshotAPI) ZoneOptional() bool {
+	return f.zoneOptional
+}
+
+func TestRunNativeSnapshotTest(t *testing.T) {
+	tests := []struct {
+		name              string
+		opts              NativeSnapshotTestOptions
+		errs              map[string]error
+		idOnError         map[string]bool
+		zoneOptional      bool
+		expectStatus      string
+		expectCalls       []string
+		expectPermissions map[string]string
+		expectVolumeID    string
+		expectScratch     bool
+		expectSnapshotID  string
+		expectReady       bool
+	}{
+		{
+			name:           "scratch volume",
+			opts:           NativeSnapshotTestOptions{Name: "oadp-dpt-1", AvailabilityZone: "us-east-1a"},
+			expectStatus:   "Passed",
+			expectCalls:    []string{"ListVolumes", "CreateVolume", "DescribeVolume", "CreateSnapshot", "WaitForSnapshot", "DeleteSnapshot", "DeleteVolume"},
+			expectVolumeID: "vol-scratch",
+			expectScratch:  true,
+			expectReady:    true,
+		},
+		{
+			name:           "existing volume is not deleted",
+			opts:           NativeSnapshotTestOptions{Name: "oadp-dpt-1", VolumeID: "vol-123"},
+			expectStatus:   "Passed",
+			expectCalls:    []string{"ListVolumes", "DescribeVolume", "CreateSnapshot", "WaitForSnapshot", "DeleteSnapshot"},
+			expectVolumeID: "vol-123",
+			expectReady:    true,
+		},
+		{
+			name:         "denied list and describe are reported and the test continues",
+			opts:         NativeSnapshotTestOptions{Name: "oadp-dpt-1", VolumeID: "vol-123"},
+			errs:         map[string]error{"ListVolumes": errDenied, "DescribeVolume": errors.New("throttled")},
+			expectStatus: "Failed",
+			expectCalls:  []string{"ListVolumes", "DescribeVolume", "CreateSnapshot", "WaitForSnapshot", "DeleteSnapshot"},
+			expectPermissions: map[string]string{
+				"ListVolumes":    PermissionDenied,
+				"DescribeVolume": PermissionError,
+				"CreateSnapshot": PermissionAllowed,
+			},
+			expectVolumeID: "vol-123",
+			expectReady:    true,
+		},
+		{
+			name:              "denied snapshot still deletes the scratch volume",
+			opts:              NativeSnapshotTestOptions{Name: "oadp-dpt-1", AvailabilityZone: "us-east-1a"},
+			errs:              map[string]error{"CreateSnapshot": errDenied},
+			expectStatus:      "Failed",
+			expectCalls:       []string{"ListVolumes", "CreateVolume", "DescribeVolume", "CreateSnapshot", "DeleteVolume"},
+			expectPermissions: map[string]string{"CreateSnapshot": PermissionDenied, "DeleteVolume": PermissionAllowed},
+			expectVolumeID:    "vol-scratch",
+			expectScratch:     true,
+		},
+		{
+			name:           "snapshot timeout still deletes the snapshot",
+			opts:           NativeSnapshotTestOptions{Name: "oadp-dpt-1", VolumeID: "vol-123"},
+			errs:           map[string]error{"WaitForSnapshot": context.DeadlineExceeded},
+			expectStatus:   "Failed",
+			expectCalls:    []string{"ListVolumes", "DescribeVolume", "CreateSnapshot", "WaitForSnapshot", "DeleteSnapshot"},
+			expectVolumeID: "vol-123",
+		},
+		{
+			name:           "scratch volume created but not available is deleted",
+			opts:           NativeSnapshotTestOptions{Name: "oadp-dpt-1", AvailabilityZone: "us-east-1a"},
+			errs:           map[string]error{"CreateVolume": context.DeadlineExceeded},
+			idOnError:      map[string]bool{"CreateVolume": true},
+			expectStatus:   "Failed",
+			expectCalls:    []string{"ListVolumes", "CreateVolume", "DeleteVolume"},
+			expectVolumeID: "vol-scratch",
+			expectScratch:  true,
+		},
+		{
+			name:         "failed scratch volume creation deletes nothing",
+			opts:         NativeSnapshotTestOptions{Name: "oadp-dpt-1", AvailabilityZone: "us-east-1a"},
+			errs:         map[string]error{"CreateVolume": errDenied},
+			expectStatus: "Failed",
+			expectCalls:  []string{"ListVolumes", "CreateVolume"},
+		},
+		{
+			name:             "snapshot created but not ready is deleted with the scratch volume",
+			opts:             NativeSnapshotTestOptions{Name: "oadp-dpt-1", AvailabilityZone: "us-east-1a"},
+			errs:             map[string]error{"CreateSnapshot": context.DeadlineExceeded},
+			idOnError:        map[string]bool{"CreateSnapshot": true},
+			expectStatus:     "Failed",
+			expectCalls:      []string{"ListVolumes", "CreateVolume", "DescribeVolume", "CreateSnapshot", "DeleteSnapshot", "DeleteVolume"},
+			expectVolumeID:   "vol-scratch",
+			expectScratch:    true,
+			expectSnapshotID: "snap-1",
+		},
+		{
+			name:         "scratch volume requires a zone",
+			opts:         NativeSnapshotTestOptions{Name: "oadp-dpt-1"},
+			expectStatus: "Failed",
+			expectCalls:  []string{"ListVolumes"},
+		},
+		{
+			name:           "scratch volume without zone when the provider does not need one",
+			opts:           NativeSnapshotTestOptions{Name: "oadp-dpt-1"},
+			zoneOptional:   true,
+			expectStatus:   "Passed",
+			expectCalls:    []string{"ListVolumes", "CreateVolume", "DescribeVolume", "CreateSnapshot", "WaitForSnapshot", "DeleteSnapshot", "DeleteVolume"},
+			expectVolumeID: "vol-scratch",
+			expectScratch:  true,
+			expectReady:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeSnapshotAPI{errs: tt.errs, idOnError: tt.idOnError, zoneOptional: tt.zoneOptional}
+			status := RunNativeSnapshotTest(context.Background(), api, tt.opts, logr.Discard())
+
+			require.Equal(t, tt.expectStatus, status.Status, status.ErrorMessage)
+			require.Equal(t, tt.expectCalls, api.calls)
+			require.Equal(t, "us-east-1", status.Region)
+			require.Equal(t, 3, status.VolumesFound)
+			require.Equal(t, tt.expectVolumeID, status.VolumeID)
+			require.Equal(t, tt.expectScratch, status.ScratchVolume)
+			require.Equal(t, tt.expectReady, status.SnapshotReadyDuration != "")
+			if tt.expectSnapshotID != "" {
+				require.Equal(t, tt.expectSnapshotID, status.SnapshotID)
+			}
+			if tt.expectStatus == "Failed" {
+				require.NotEmpty(t, status.ErrorMessage)
+			}
+
+			permissions := map[string]string{}
+			for _, operation := range status.Operations {
+				permissions[operation.Operation] = operation.Permission
+				require.NotEmpty(t, operation.Duration)
+			}
+			for operation, permission := range tt.expectPermissions {
+				require.Equal(t, permission, permissions[operation], operation)
+			}
+		})
+	}
+}
+
+func TestSnapshotAPIIsPermissionDenied(t *testing.T) {
+	aws := &AWSSnapshotAPI{}
+	require.True(t, aws.IsPermissionDenied(awserr.New("UnauthorizedOperation", "not authorized", nil)))
+	require.False(t, aws.IsPermissionDenied(awserr.New("InvalidVolume.NotFound", "not found", nil)))
+	require.False(t, aws.IsPermissionDenied(errors.New("timeout")))
+
+	gcp := &GCPSnapshotAPI{}
+	require.True(t, gcp.IsPermissionDenied(&googleapi.Error{Code: 403}))
+	require.True(t, gcp.IsPermissionDenied(&gcpOperationError{code: "PERMISSIONS_ERROR"}))
+	require.False(t, gcp.IsPermissionDenied(&googleapi.Error{Code: 404}))
+}
@@ -0,0 +1,277 @@
+package cloudprovider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1" //nolint:gosec // S3 SHA1 checksums are part of the API, not used for security
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/go-logr/logr"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+// ChecksumAlgorithmConfigKey is the BSL config key used by the Velero AWS plugin to select the upload checksum.
+const ChecksumAlgorithmConfigKey = "checksumAlgorithm"
+
+// Capability states reported in S3Capabilities.
+const (
+	CapabilityEnabled     = "Enabled"
+	CapabilityDisabled    = "Disabled"
+	CapabilityUnsupported = "Unsupported"
+)
+
+// probeChecksumAlgorithms are probed in order; the first supported one is recommended.
+var probeChecksumAlgorithms = []string{
+	s3.ChecksumAlgorithmCrc32,
+	s3.ChecksumAlgorithmCrc32c,
+	s3.ChecksumAlgorithmSha1,
+	s3.ChecksumAlgorithmSha256,
+}
+
+// ProbeCapabilities detects flexible checksum support, object lock, versioning and conditional
+// writes on the bucket by issuing small requests. Probe objects are deleted afterwards.
+// Failures of individual probes are collected in the returned ErrorMessage.
+func (a *AWSProvider) ProbeCapabilities(ctx context.Context, bucket, vendor string, log logr.Logger) (*oadpv1alpha1.S3Capabilities, error) {
+	result := &oadpv1alpha1.S3Capabilities{
+		MultipartLimits: S3VendorMultipartLimits(vendor),
+	}
+	var probeErrs []string
+
+	versioning, err := a.probeVersioning(ctx, bucket)
+	if err != nil {
+		probeErrs = append(probeErrs, err.Error())
+	}
+	result.Versioning = versioning
+
+	objectLock, err := a.probeObjectLock(ctx, bucket)
+	if err != nil {
+		probeErrs = append(probeErrs, err.Error())
+	}
+	result.ObjectLock = objectLock
+
+	checksumErrs := 0
+	for _, algorithm := range probeChecksumAlgorithms {
+		supported, err := a.probeChecksum(ctx, bucket, algorithm)
+		if err != nil {
+			checksumErrs++
+			probeErrs = append(probeErrs, err.Error())
+			continue
+		}
+		log.Info("Probed checksum algorithm", "algorithm", algorithm, "supported", supported)
+		if supported {
+			result.ChecksumAlgorithms = append(result.ChecksumAlgorithms, algorithm)
+		}
+	}
+
+	conditional, err := a.probeConditionalWrites(ctx, bucket)
+	if err != nil {
+		probeErrs = append(probeErrs, err.Error())
+	} else {
+		result.ConditionalWrites = aws.Bool(conditional)
+	}
+
+	// Only recommend a checksum setting when every algorithm could be probed,
+	// otherwise an access problem could be mistaken for missing support.
+	if checksumErrs == 0 {
+		recommended := ""
+		if len(result.ChecksumAlgorithms) > 0 {
+			recommended = result.ChecksumAlgorithms[0]
+		}
+		result.RecommendedConfig = map[string]string{
+			ChecksumAlgorithmConfigKey: recommended,
+		}
+	}
+
+	log.Info("Probed S3 capabilities", "vendor", vendor, "checksumAlgorithms", result.ChecksumAlgorithms,
+		"objectLock", result.ObjectLock, "versioning", result.Versioning)
+
+	if len(probeErrs) > 0 {
+		result.ErrorMessage = strings.Join(probeErrs, "; ")
+		return result, errors.New(result.ErrorMessage)
+	}
+	return result, nil
+}
+
+func (a *AWSProvider) probeVersioning(ctx context.Context, bucket string) (string, error) {
+	out, err := a.s3Client.GetBucketVersioningWithContext(ctx, &s3.GetBucketVersioningInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		if isUnsupportedS3Operation(err) {
+			return CapabilityUnsupported, nil
+		}
+		return "", fmt.Errorf("versioning probe failed: %w", err)
+	}
+	if out.Status == nil || *out.Status == "" {
+		return "None", nil
+	}
+	return *out.Status, nil
+}
+
+func (a *AWSProvider) probeObjectLock(ctx context.Context, bucket string) (string, error) {
+	out, err := a.s3Client.GetObjectLockConfigurationWithContext(ctx, &s3.GetObjectLockConfigurationInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "ObjectLockConfigurationNotFoundError" {
+			return CapabilityDisabled, nil
+		}
+		if isUnsupportedS3Operation(err) {
+			return CapabilityUnsupported, nil
+		}
+		return "", fmt.Errorf("object lock probe failed: %w", err)
+	}
+	if out.ObjectLockConfiguration != nil && aws.StringValue(out.ObjectLockConfiguration.ObjectLockEnabled) == s3.ObjectLockEnabledEnabled {
+		return CapabilityEnabled, nil
+	}
+	return CapabilityDisabled, nil
+}
+
+// probeChecksum uploads a small object with the given checksum and reports whether the
+// endpoint stored it, i.e. returns the same checksum when asked for it.
+func (a *AWSProvider) probeChecksum(ctx context.Context, bucket, algorithm string) (bool, error) {
+	payload := []byte("oadp checksum probe")
+	key := fmt.Sprintf("dpt-checksum-probe-%s-%d", strings.ToLower(algorithm), time.Now().UnixNano())
+
+	input := &s3.PutObjectInput{
+		Bucket:            aws.String(bucket),
+		Key:               aws.String(key),
+		Body:              bytes.NewReader(payload),
+		ChecksumAlgorithm: aws.String(algorithm),
+	}
+	sum := checksumOf(algorithm, payload)
+	switch algorithm {
+	case s3.ChecksumAlgorithmCrc32:
+		input.ChecksumCRC32 = aws.String(sum)
+	case s3.ChecksumAlgorithmCrc32c:
+		input.ChecksumCRC32C = aws.String(sum)
+	case s3.ChecksumAlgorithmSha1:
+		input.ChecksumSHA1 = aws.String(sum)
+	case s3.ChecksumAlgorithmSha256:
+		input.ChecksumSHA256 = aws.String(sum)
+	}
+
+	if _, err := a.s3Client.PutObjectWithContext(ctx, input); err != nil {
+		if isRejectedS3Request(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s checksum probe failed: %w", algorithm, err)
+	}
+	defer a.deleteProbeObject(ctx, bucket, key)
+
+	head, err := a.s3Client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(bucket),
+		Key:          aws.String(key),
+		ChecksumMode: aws.String(s3.ChecksumModeEnabled),
+	})
+	if err != nil {
+		return false, fmt.Errorf("%s checksum probe failed: %w", algorithm, err)
+	}
+
+	var returned *string
+	switch algorithm {
+	case s3.ChecksumAlgorithmCrc32:
+		returned = head.ChecksumCRC32
+	case s3.ChecksumAlgorithmCrc32c:
+		returned = head.ChecksumCRC32C
+	case s3.ChecksumAlgorithmSha1:
+		returned = head.ChecksumSHA1
+	case s3.ChecksumAlgorithmSha256:
+		returned = head.ChecksumSHA256
+	}
+	return aws.StringValue(returned) == sum, nil
+}
+
+// probeConditionalWrites writes the same key twice with "If-None-Match: *".
+// Endpoints that support conditional writes reject the second request with 412.
+func (a *AWSProvider) probeConditionalWrites(ctx context.Context, bucket string) (bool, error) {
+	key := fmt.Sprintf("dpt-conditional-probe-%d", time.Now().UnixNano())
+	put := func() error {
+		_, err := a.s3Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+			Body:   bytes.NewReader([]byte("oadp conditional write probe")),
+		}, request.WithSetRequestHeaders(map[string]string{"If-None-Match": "*"}))
+		return err
+	}
+
+	if err := put(); err != nil {
+		if isRejectedS3Request(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("conditional write probe failed: %w", err)
+	}
+	defer a.deleteProbeObject(ctx, bucket, key)
+
+	err := put()
+	if err == nil {
+		return false, nil
+	}
+	if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusPreconditionFailed {
+		return true, nil
+	}
+	if isRejectedS3Request(err) {
+		return false, nil
+	}
+	return false, fmt.Errorf("conditional write probe failed: %w", err)
+}
+
+func (a *AWSProvider) deleteProbeObject(ctx context.Context, bucket, key string) {
+	_, _ = a.s3Client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+}
+
+// checksumOf returns the base64 encoded checksum in the format used by the x-amz-checksum-* headers.
+func checksumOf(algorithm string, payload []byte) string {
+	var h hash.Hash
+	switch algorithm {
+	case s3.ChecksumAlgorithmCrc32:
+		h = crc32.NewIEEE()
+	case s3.ChecksumAlgorithmCrc32c:
+		h = crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	case s3.ChecksumAlgorithmSha1:
+		h = sha1.New() //nolint:gosec
+	default:
+		h = sha256.New()
+	}
+	h.Write(payload)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// isUnsupportedS3Operation reports whether the endpoint does not implement the requested API.
+func isUnsupportedS3Operation(err error) bool {
+	if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusNotImplemented {
+		return true
+	}
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code() == "NotImplemented" || aerr.Code() == "MethodNotAllowed"
+	}
+	return false
+}
+
+// isRejectedS3Request reports whether the endpoint refused the request because of
+// headers it does not understand, as opposed to authorization or connectivity problems.
+func isRejectedS3Request(err error) bool {
+	if isUnsupportedS3Operation(err) {
+		return true
+	}
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		return reqErr.StatusCode() == http.StatusBadRequest
+	}
+	return false
+}
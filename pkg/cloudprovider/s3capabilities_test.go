@@ -0,0 +1,171 @@
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3Server is a minimal path-style S3 endpoint used to exercise capability probes.
+type fakeS3Server struct {
+	mu      sync.Mutex
+	objects map[string]http.Header
+
+	// storeChecksums returns x-amz-checksum-* headers on HEAD when enabled.
+	storeChecksums bool
+	// rejectChecksums fails uploads carrying a checksum with 400.
+	rejectChecksums bool
+	// conditionalWrites honors If-None-Match: * on PUT.
+	conditionalWrites bool
+	// objectLock is returned by GetObjectLockConfiguration; "" means not configured
+	// and "NotImplemented" makes the endpoint return 501.
+	objectLock string
+	// denyWrites fails every PUT with 403.
+	denyWrites bool
+}
+
+func (f *fakeS3Server) writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>%s</Code><Message>%s</Message></Error>`, code, code)
+}
+
+func (f *fakeS3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodGet && query.Has("versioning"):
+		fmt.Fprint(w, `<VersioningConfiguration><Status>Enabled</Status></VersioningConfiguration>`)
+	case r.Method == http.MethodGet && query.Has("object-lock"):
+		switch f.objectLock {
+		case "":
+			f.writeError(w, http.StatusNotFound, "ObjectLockConfigurationNotFoundError")
+		case "NotImplemented":
+			f.writeError(w, http.StatusNotImplemented, "NotImplemented")
+		default:
+			fmt.Fprintf(w, `<ObjectLockConfiguration><ObjectLockEnabled>%s</ObjectLockEnabled></ObjectLockConfiguration>`, f.objectLock)
+		}
+	case r.Method == http.MethodPut:
+		if f.denyWrites {
+			f.writeError(w, http.StatusForbidden, "AccessDenied")
+			return
+		}
+		stored := http.Header{}
+		for key, values := range r.Header {
+			if strings.HasPrefix(key, "X-Amz-Checksum-") {
+				if f.rejectChecksums {
+					f.writeError(w, http.StatusBadRequest, "InvalidArgument")
+					return
+				}
+				stored[key] = values
+			}
+		}
+		if _, exists := f.objects[r.URL.Path]; exists && f.conditionalWrites && r.Header.Get("If-None-Match") == "*" {
+			f.writeError(w, http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+		f.objects[r.URL.Path] = stored
+	case r.Method == http.MethodHead:
+		stored, exists := f.objects[r.URL.Path]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if f.storeChecksums && r.Header.Get("X-Amz-Checksum-Mode") == "ENABLED" {
+			for key, values := range stored {
+				w.Header()[key] = values
+			}
+		}
+	case r.Method == http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestAWSProviderProbeCapabilities(t *testing.T) {
+	tests := []struct {
+		name                 string
+		server               *fakeS3Server
+		vendor               string
+		expectError          bool
+		expectChecksums      []string
+		expectObjectLock     string
+		expectConditional    *bool
+		expectRecommendation map[string]string
+	}{
+		{
+			name:                 "endpoint supporting every capability",
+			server:               &fakeS3Server{storeChecksums: true, conditionalWrites: true, objectLock: "Enabled"},
+			vendor:               S3VendorAWS,
+			expectChecksums:      []string{"CRC32", "CRC32C", "SHA1", "SHA256"},
+			expectObjectLock:     CapabilityEnabled,
+			expectConditional:    boolPtr(true),
+			expectRecommendation: map[string]string{ChecksumAlgorithmConfigKey: "CRC32"},
+		},
+		{
+			name:                 "endpoint ignoring checksums and conditional headers",
+			server:               &fakeS3Server{},
+			vendor:               S3VendorCeph,
+			expectObjectLock:     CapabilityDisabled,
+			expectConditional:    boolPtr(false),
+			expectRecommendation: map[string]string{ChecksumAlgorithmConfigKey: ""},
+		},
+		{
+			name:                 "endpoint rejecting checksums without object lock API",
+			server:               &fakeS3Server{rejectChecksums: true, conditionalWrites: true, objectLock: "NotImplemented"},
+			vendor:               S3VendorMinIO,
+			expectObjectLock:     CapabilityUnsupported,
+			expectConditional:    boolPtr(true),
+			expectRecommendation: map[string]string{ChecksumAlgorithmConfigKey: ""},
+		},
+		{
+			name:             "access denied does not produce a recommendation",
+			server:           &fakeS3Server{denyWrites: true},
+			vendor:           S3VendorAWS,
+			expectError:      true,
+			expectObjectLock: CapabilityDisabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.server.objects = map[string]http.Header{}
+			ts := httptest.NewServer(tt.server)
+			defer ts.Close()
+
+			provider := NewAWSProvider("us-east-1", ts.URL, "access", "secret")
+			capabilities, err := provider.ProbeCapabilities(context.Background(), "bucket", tt.vendor, logr.Discard())
+
+			require.NotNil(t, capabilities)
+			if tt.expectError {
+				require.Error(t, err)
+				require.NotEmpty(t, capabilities.ErrorMessage)
+			} else {
+				require.NoError(t, err)
+				require.Empty(t, capabilities.ErrorMessage)
+			}
+			require.Equal(t, tt.expectChecksums, capabilities.ChecksumAlgorithms)
+			require.Equal(t, tt.expectObjectLock, capabilities.ObjectLock)
+			require.Equal(t, "Enabled", capabilities.Versioning)
+			require.Equal(t, tt.expectConditional, capabilities.ConditionalWrites)
+			require.Equal(t, tt.expectRecommendation, capabilities.RecommendedConfig)
+			require.Equal(t, S3VendorMultipartLimits(tt.vendor), capabilities.MultipartLimits)
+			require.Empty(t, tt.server.objects, "probe objects must be deleted")
+		})
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,203 @@
+package cloudprovider
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+// Known S3-compatible vendor names reported in DataProtectionTest status.
+const (
+	S3VendorAWS         = "AWS"
+	S3VendorMinIO       = "MinIO"
+	S3VendorCeph        = "Ceph"
+	S3VendorNooBaa      = "NooBaa"
+	S3VendorIBMCOS      = "IBM COS"
+	S3VendorDellECS     = "Dell ECS"
+	S3VendorStorageGRID = "StorageGRID"
+	S3VendorWasabi      = "Wasabi"
+	S3VendorR2          = "Cloudflare R2"
+	S3VendorB2          = "Backblaze B2"
+	S3VendorUnknown     = "Unknown"
+)
+
+const (
+	mib = int64(1024 * 1024)
+	gib = 1024 * mib
+)
+
+// S3VendorFingerprint identifies an S3-compatible vendor from the endpoint URL
+// and the response headers of an unauthenticated HEAD request to it.
+type S3VendorFingerprint struct {
+	// Name is the vendor name reported when Match returns true.
+	Name string
+	// Match reports whether the endpoint and response headers belong to this vendor.
+	// header keys are canonicalized; server is the lowercased Server header.
+	Match func(endpoint *url.URL, header http.Header, server string) bool
+	// MultipartLimits are the documented multipart upload limits of the vendor, if known.
+	MultipartLimits *oadpv1alpha1.MultipartLimits
+}
+
+var (
+	s3VendorFingerprintsMu sync.RWMutex
+	// s3VendorFingerprints is evaluated in order, so specific vendors must come
+	// before AWS, whose x-amz-request-id header is also sent by most S3-compatible servers.
+	s3VendorFingerprints = []S3VendorFingerprint{
+		{
+			Name: S3VendorNooBaa,
+			Match: func(endpoint *url.URL, header http.Header, server string) bool {
+				return strings.Contains(server, "noobaa") || hasHeaderPrefix(header, "X-Noobaa-") ||
+					strings.Contains(hostOf(endpoint), "s3-openshift-storage")
+			},
+			MultipartLimits: defaultMultipartLimits(),
+		},
+		{
+			Name: S3VendorIBMCOS,
+			Match: func(endpoint *url.URL, header http.Header, server string) bool {
+				return strings.Contains(server, "cleversafe") || strings.Contains(server, "ibm") ||
+					hasHeaderPrefix(header, "Ibm-") ||
+					strings.HasSuffix(hostOf(endpoint), "cloud-object-storage.appdomain.cloud")
+			},
+			MultipartLimits: defaultMultipartLimits(),
+		},
+		{
+			Name: S3VendorDellECS,
+			Match: func(endpoint *url.URL, header http.Header, server string) bool {
+				return strings.Contains(server, "vipr") || hasHeaderPrefix(header, "X-Emc-")
+			},
+			MultipartLimits: defaultMultipartLimits(),
+		},
+		{
+			Name: S3VendorStorageGRID,
+			Match: func(endpoint *url.URL, header http.Header, server string) bool {
+				return strings.Contains(server, "storagegrid") || hasHeaderPrefix(header, "X-Ntap-Sg-")
+			},
+			MultipartLimits: defaultMultipartLimits(),
+		},
+		{
+			Name: S3VendorWasabi,
+			Match: func(endpoint *url.URL, header http.Header, server string) bool {
+				return strings.Contains(server, "wasabi") || strings.HasSuffix(hostOf(endpoint), "wasabisys.com")
+			},
+			MultipartLimits: defaultMultipartLimits(),
+		},
+		{
+			Name: S3VendorR2,
+			Match: func(endpoint *url.URL, header http.Header, server string) bool {
+				return strings.HasSuffix(hostOf(endpoint), "r2.cloudflarestorage.com") ||
+					(server == "cloudflare" && header.Get("Cf-Ray") != "")
+			},
+			MultipartLimits: defaultMultipartLimits(),
+		},
+		{
+			Name: S3VendorB2,
+			Match: func(endpoint *url.URL, header http.Header, server string) bool {
+				return strings.HasSuffix(hostOf(endpoint), "backblazeb2.com") || hasHeaderPrefix(header, "X-Bz-")
+			},
+			// Backblaze documents its limits in decimal units.
+			MultipartLimits: &oadpv1alpha1.MultipartLimits{
+				MaxParts:         10000,
+				MinPartSizeBytes: 5 * 1000 * 1000,
+				MaxPartSizeBytes: 5 * 1000 * 1000 * 1000,
+			},
+		},
+		{
+			Name: S3VendorMinIO,
+			Match: func(endpoint *url.URL, header http.Header, server string) bool {
+				return strings.Contains(server, "minio") || header.Get("X-Minio-Region") != ""
+			},
+			MultipartLimits: defaultMultipartLimits(),
+		},
+		{
+			Name: S3VendorCeph,
+			Match: func(endpoint *url.URL, header http.Header, server string) bool {
+				return strings.Contains(server, "ceph") || header.Get("X-Rgw-Request-Id") != ""
+			},
+			MultipartLimits: defaultMultipartLimits(),
+		},
+		{
+			Name: S3VendorAWS,
+			Match: func(endpoint *url.URL, header http.Header, server string) bool {
+				return strings.Contains(server, "amazon") || strings.HasSuffix(hostOf(endpoint), "amazonaws.com") ||
+					header.Get("X-Amz-Request-Id") != ""
+			},
+			MultipartLimits: defaultMultipartLimits(),
+		},
+	}
+)
+
+// RegisterS3VendorFingerprint adds a vendor fingerprint that is evaluated before the built-in ones.
+// Registering a fingerprint with the name of an existing one replaces it.
+func RegisterS3VendorFingerprint(fp S3VendorFingerprint) {
+	s3VendorFingerprintsMu.Lock()
+	defer s3VendorFingerprintsMu.Unlock()
+
+	fingerprints := []S3VendorFingerprint{fp}
+	for _, existing := range s3VendorFingerprints {
+		if existing.Name != fp.Name {
+			fingerprints = append(fingerprints, existing)
+		}
+	}
+	s3VendorFingerprints = fingerprints
+}
+
+// DetectS3Vendor returns the name of the first fingerprint matching the endpoint and response headers.
+// When nothing matches, the lowercased Server header is returned, or "Unknown" if it is empty.
+func DetectS3Vendor(endpoint *url.URL, header http.Header) string {
+	server := strings.ToLower(header.Get("Server"))
+
+	s3VendorFingerprintsMu.RLock()
+	defer s3VendorFingerprintsMu.RUnlock()
+
+	for _, fp := range s3VendorFingerprints {
+		if fp.Match != nil && fp.Match(endpoint, header, server) {
+			return fp.Name
+		}
+	}
+
+	if server != "" {
+		return server
+	}
+	return S3VendorUnknown
+}
+
+// S3VendorMultipartLimits returns the documented multipart upload limits for a vendor, or nil if unknown.
+func S3VendorMultipartLimits(vendor string) *oadpv1alpha1.MultipartLimits {
+	s3VendorFingerprintsMu.RLock()
+	defer s3VendorFingerprintsMu.RUnlock()
+
+	for _, fp := range s3VendorFingerprints {
+		if fp.Name == vendor && fp.MultipartLimits != nil {
+			return fp.MultipartLimits.DeepCopy()
+		}
+	}
+	return nil
+}
+
+// defaultMultipartLimits returns the multipart limits of the AWS S3 API, which most vendors mirror.
+func defaultMultipartLimits() *oadpv1alpha1.MultipartLimits {
+	return &oadpv1alpha1.MultipartLimits{
+		MaxParts:         10000,
+		MinPartSizeBytes: 5 * mib,
+		MaxPartSizeBytes: 5 * gib,
+	}
+}
+
+func hostOf(endpoint *url.URL) string {
+	if endpoint == nil {
+		return ""
+	}
+	return strings.ToLower(endpoint.Hostname())
+}
+
+func hasHeaderPrefix(header http.Header, prefix string) bool {
+	for key := range header {
+		if strings.HasPrefix(http.CanonicalHeaderKey(key), prefix) {
+			return true
+		}
+	}
+	return false
+}
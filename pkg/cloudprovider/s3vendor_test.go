@@ -0,0 +1,172 @@
+package cloudprovider
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectS3Vendor(t *testing.T) {
+	tests := []struct {
+		name           string
+		endpoint       string
+		headers        map[string]string
+		expectedVendor string
+	}{
+		{
+			name:           "AWS via Server header",
+			endpoint:       "https://s3.us-east-1.amazonaws.com",
+			headers:        map[string]string{"Server": "AmazonS3"},
+			expectedVendor: S3VendorAWS,
+		},
+		{
+			name:           "AWS via x-amz-request-id only",
+			endpoint:       "https://s3.example.com",
+			headers:        map[string]string{"x-amz-request-id": "abc"},
+			expectedVendor: S3VendorAWS,
+		},
+		{
+			name:           "MinIO wins over x-amz-request-id",
+			endpoint:       "https://minio.example.com",
+			headers:        map[string]string{"x-amz-request-id": "abc", "x-minio-region": "us-east-1"},
+			expectedVendor: S3VendorMinIO,
+		},
+		{
+			name:           "Ceph RGW wins over x-amz-request-id",
+			endpoint:       "https://rgw.example.com",
+			headers:        map[string]string{"x-amz-request-id": "abc", "x-rgw-request-id": "def"},
+			expectedVendor: S3VendorCeph,
+		},
+		{
+			name:           "NooBaa via Server header",
+			endpoint:       "https://s3.example.com",
+			headers:        map[string]string{"Server": "NooBaa", "x-amz-request-id": "abc"},
+			expectedVendor: S3VendorNooBaa,
+		},
+		{
+			name:           "NooBaa via MCG route host",
+			endpoint:       "https://s3-openshift-storage.apps.cluster.example.com",
+			expectedVendor: S3VendorNooBaa,
+		},
+		{
+			name:           "IBM COS via Server header",
+			endpoint:       "https://s3.example.com",
+			headers:        map[string]string{"Server": "Cleversafe"},
+			expectedVendor: S3VendorIBMCOS,
+		},
+		{
+			name:           "IBM COS via public endpoint",
+			endpoint:       "https://s3.us-south.cloud-object-storage.appdomain.cloud",
+			expectedVendor: S3VendorIBMCOS,
+		},
+		{
+			name:           "Dell ECS via x-emc header",
+			endpoint:       "https://ecs.example.com:9021",
+			headers:        map[string]string{"x-emc-mtime": "1700000000"},
+			expectedVendor: S3VendorDellECS,
+		},
+		{
+			name:           "Dell ECS via Server header",
+			endpoint:       "https://ecs.example.com:9021",
+			headers:        map[string]string{"Server": "ViPR/1.0"},
+			expectedVendor: S3VendorDellECS,
+		},
+		{
+			name:           "StorageGRID via Server header",
+			endpoint:       "https://grid.example.com:10443",
+			headers:        map[string]string{"Server": "StorageGRID/11.8.0"},
+			expectedVendor: S3VendorStorageGRID,
+		},
+		{
+			name:           "StorageGRID via trace header",
+			endpoint:       "https://grid.example.com:10443",
+			headers:        map[string]string{"x-ntap-sg-trace-id": "123"},
+			expectedVendor: S3VendorStorageGRID,
+		},
+		{
+			name:           "Wasabi via host",
+			endpoint:       "https://s3.us-east-2.wasabisys.com",
+			headers:        map[string]string{"x-amz-request-id": "abc"},
+			expectedVendor: S3VendorWasabi,
+		},
+		{
+			name:           "Cloudflare R2 via host",
+			endpoint:       "https://account.r2.cloudflarestorage.com",
+			headers:        map[string]string{"Server": "cloudflare"},
+			expectedVendor: S3VendorR2,
+		},
+		{
+			name:           "Backblaze B2 via host",
+			endpoint:       "https://s3.us-west-004.backblazeb2.com",
+			headers:        map[string]string{"x-amz-request-id": "abc"},
+			expectedVendor: S3VendorB2,
+		},
+		{
+			name:           "Unknown vendor falls back to Server header",
+			endpoint:       "https://s3.example.com",
+			headers:        map[string]string{"Server": "SomethingElse"},
+			expectedVendor: "somethingelse",
+		},
+		{
+			name:           "No headers at all",
+			endpoint:       "https://s3.example.com",
+			expectedVendor: S3VendorUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint, err := url.Parse(tt.endpoint)
+			require.NoError(t, err)
+			header := http.Header{}
+			for k, v := range tt.headers {
+				header.Set(k, v)
+			}
+			require.Equal(t, tt.expectedVendor, DetectS3Vendor(endpoint, header))
+		})
+	}
+}
+
+func TestRegisterS3VendorFingerprint(t *testing.T) {
+	original := s3VendorFingerprints
+	t.Cleanup(func() { s3VendorFingerprints = original })
+
+	endpoint, err := url.Parse("https://s3.example.com")
+	require.NoError(t, err)
+	header := http.Header{}
+	header.Set("Server", "MinIO")
+	header.Set("X-Acme-Version", "2")
+
+	RegisterS3VendorFingerprint(S3VendorFingerprint{
+		Name: "Acme",
+		Match: func(_ *url.URL, header http.Header, _ string) bool {
+			return header.Get("X-Acme-Version") != ""
+		},
+	})
+	require.Equal(t, "Acme", DetectS3Vendor(endpoint, header), "registered fingerprints take precedence")
+
+	RegisterS3VendorFingerprint(S3VendorFingerprint{
+		Name: S3VendorMinIO,
+		Match: func(_ *url.URL, _ http.Header, _ string) bool {
+			return false
+		},
+	})
+	require.Len(t, s3VendorFingerprints, len(original)+1, "fingerprint with an existing name replaces it")
+	require.Nil(t, S3VendorMultipartLimits(S3VendorMinIO))
+}
+
+func TestS3VendorMultipartLimits(t *testing.T) {
+	limits := S3VendorMultipartLimits(S3VendorAWS)
+	require.NotNil(t, limits)
+	require.Equal(t, int64(10000), limits.MaxParts)
+	require.Equal(t, 5*mib, limits.MinPartSizeBytes)
+	require.Equal(t, 5*gib, limits.MaxPartSizeBytes)
+
+	limits.MaxParts = 1
+	require.Equal(t, int64(10000), S3VendorMultipartLimits(S3VendorAWS).MaxParts, "returned limits must be a copy")
+
+	require.Equal(t, int64(5_000_000), S3VendorMultipartLimits(S3VendorB2).MinPartSizeBytes)
+	require.Nil(t, S3VendorMultipartLimits("somethingelse"))
+}
@@ -0,0 +1,120 @@
+package credentials
+
+import (
+	"path"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
+)
+
+const (
+	// PodIdentityTokenVolumeName is the name of the projected service account token volume used by EKS Pod Identity
+	PodIdentityTokenVolumeName = "eks-pod-identity-token"
+
+	rolesAnywhereVolumePrefix = "rolesanywhere-"
+)
+
+// RolesAnywhereVolumeName returns the name of the volume mounting the certificate secret of an IAM Roles Anywhere identity
+func RolesAnywhereVolumeName(secretName string) string {
+	return volumeName(rolesAnywhereVolumePrefix, secretName)
+}
+
+// rolesAnywhereCertificateSecrets returns the certificate secrets of the IAM Roles Anywhere cloud identities,
+// sorted and without duplicates.
+func rolesAnywhereCertificateSecrets(dpa *oadpv1alpha1.DataProtectionApplication) []string {
+	seen := map[string]bool{}
+	for _, identity := range dpa.Spec.CloudIdentities {
+		if identity.AWS != nil && identity.AWS.RolesAnywhere != nil && identity.AWS.RolesAnywhere.CertificateSecret != "" {
+			seen[identity.AWS.RolesAnywhere.CertificateSecret] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UsesAWSPodIdentity returns true if an AWS cloud identity of the DPA uses EKS Pod Identity
+func UsesAWSPodIdentity(dpa *oadpv1alpha1.DataProtectionApplication) bool {
+	for _, identity := range dpa.Spec.CloudIdentities {
+		if identity.AWS != nil && identity.AWS.PodIdentity {
+			return true
+		}
+	}
+	return false
+}
+
+// AWSCloudIdentityVolumes returns the volumes, and their mounts, needed by the AWS cloud identities of the DPA:
+// the certificate secret of each IAM Roles Anywhere identity, and the EKS Pod Identity token.
+func AWSCloudIdentityVolumes(dpa *oadpv1alpha1.DataProtectionApplication) ([]corev1.Volume, []corev1.VolumeMount) {
+	var volumes []corev1.Volume
+	var mounts []corev1.VolumeMount
+	for _, secretName := range rolesAnywhereCertificateSecrets(dpa) {
+		name := RolesAnywhereVolumeName(secretName)
+		volumes = append(volumes, corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: secretName,
+					Items: []corev1.KeyToPath{
+						{Key: corev1.TLSCertKey, Path: corev1.TLSCertKey},
+						{Key: corev1.TLSPrivateKeyKey, Path: corev1.TLSPrivateKeyKey},
+					},
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      name,
+			MountPath: path.Join(stsflow.RolesAnywhereMountPath, secretName),
+			ReadOnly:  true,
+		})
+	}
+	if UsesAWSPodIdentity(dpa) {
+		volumes = append(volumes, corev1.Volume{
+			Name: PodIdentityTokenVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Projected: &corev1.ProjectedVolumeSource{
+					Sources: []corev1.VolumeProjection{
+						{
+							ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+								Audience:          stsflow.PodIdentityTokenAudience,
+								ExpirationSeconds: ptr.To(int64(stsflow.PodIdentityTokenExpirationSeconds)),
+								Path:              stsflow.PodIdentityTokenFileName,
+							},
+						},
+					},
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      PodIdentityTokenVolumeName,
+			MountPath: stsflow.PodIdentityTokenMountPath,
+			ReadOnly:  true,
+		})
+	}
+	return volumes, mounts
+}
+
+// AWSCloudIdentityEnv returns the container credentials environment of EKS Pod Identity,
+// or nil if no AWS cloud identity of the DPA uses it.
+func AWSCloudIdentityEnv(dpa *oadpv1alpha1.DataProtectionApplication) []corev1.EnvVar {
+	if !UsesAWSPodIdentity(dpa) {
+		return nil
+	}
+	return []corev1.EnvVar{
+		{
+			Name:  stsflow.PodIdentityCredentialsFullURIEnvKey,
+			Value: stsflow.PodIdentityCredentialsFullURI,
+		},
+		{
+			Name:  stsflow.PodIdentityAuthorizationTokenEnvKey,
+			Value: path.Join(stsflow.PodIdentityTokenMountPath, stsflow.PodIdentityTokenFileName),
+		},
+	}
+}
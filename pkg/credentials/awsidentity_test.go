@@ -0,0 +1,73 @@
+package credentials
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func TestAppendCloudProviderVolumes_AWSCloudIdentities(t *testing.T) {
+	rolesAnywhere := func(name, secret string) oadpv1alpha1.CloudIdentity {
+		return oadpv1alpha1.CloudIdentity{Name: name, AWS: &oadpv1alpha1.AWSCloudIdentity{
+			RoleARN:       "arn:aws:iam::123456789012:role/" + name,
+			RolesAnywhere: &oadpv1alpha1.AWSRolesAnywhere{TrustAnchorARN: "ta", ProfileARN: "p", CertificateSecret: secret},
+		}}
+	}
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{DefaultPlugins: []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginAWS}},
+			},
+			CloudIdentities: []oadpv1alpha1.CloudIdentity{
+				rolesAnywhere("s3", "velero-cert"),
+				rolesAnywhere("ebs", "velero-cert"),
+				{Name: "pod-identity", AWS: &oadpv1alpha1.AWSCloudIdentity{PodIdentity: true}},
+			},
+		},
+	}
+	ds := &appsv1.DaemonSet{}
+	ds.Spec.Template.Spec.Containers = []corev1.Container{{Name: common.NodeAgent}}
+
+	AppendCloudProviderVolumes(dpa, ds, map[string]bool{"aws": false})
+
+	container := ds.Spec.Template.Spec.Containers[0]
+	require.Contains(t, container.VolumeMounts, corev1.VolumeMount{
+		Name: "rolesanywhere-velero-cert", MountPath: "/credentials-rolesanywhere/velero-cert", ReadOnly: true,
+	})
+	require.Contains(t, container.VolumeMounts, corev1.VolumeMount{
+		Name: PodIdentityTokenVolumeName, MountPath: "/var/run/secrets/pods.eks.amazonaws.com/serviceaccount", ReadOnly: true,
+	})
+	require.Equal(t, []corev1.EnvVar{
+		{Name: "AWS_CONTAINER_CREDENTIALS_FULL_URI", Value: "http://169.254.170.23/v1/credentials"},
+		{Name: "AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE", Value: "/var/run/secrets/pods.eks.amazonaws.com/serviceaccount/eks-pod-identity-token"},
+	}, container.Env)
+
+	var certificateVolumes int
+	for _, volume := range ds.Spec.Template.Spec.Volumes {
+		switch volume.Name {
+		case "rolesanywhere-velero-cert":
+			certificateVolumes++
+			require.Equal(t, "velero-cert", volume.Secret.SecretName)
+		case PodIdentityTokenVolumeName:
+			require.Equal(t, "pods.eks.amazonaws.com", volume.Projected.Sources[0].ServiceAccountToken.Audience)
+		}
+	}
+	require.Equal(t, 1, certificateVolumes, "a certificate secret shared by identities is mounted once")
+}
+
+func TestAWSCloudIdentityVolumes_WebIdentity(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			CloudIdentities: []oadpv1alpha1.CloudIdentity{{Name: "s3", AWS: &oadpv1alpha1.AWSCloudIdentity{RoleARN: "arn"}}},
+		},
+	}
+	volumes, mounts := AWSCloudIdentityVolumes(dpa)
+	require.Empty(t, volumes)
+	require.Empty(t, mounts)
+	require.Nil(t, AWSCloudIdentityEnv(dpa))
+}
@@ -0,0 +1,287 @@
+// Package azurecreds resolves Azure credentials from OADP secrets.
+//
+// CloudStorage buckets, DataProtectionTests, backup storage location validation and the registry all read
+// Azure secrets through this package so that every component picks the same authentication method for the
+// same secret. The precedence matches the Velero Azure plugin:
+//
+//  1. shared key, when a storage account access key is set
+//  2. service principal, when a client secret or client certificate is set with the tenant and client IDs
+//  3. workload identity, when a federated token file is available with the tenant and client IDs
+//  4. managed identity, using the client ID as user-assigned identity when set
+package azurecreds
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
+)
+
+// Keys of Azure credentials, both as lines of an env-formatted credentials file and as secret data keys
+const (
+	SubscriptionIDKey        = "AZURE_SUBSCRIPTION_ID"
+	TenantIDKey              = "AZURE_TENANT_ID"
+	ClientIDKey              = "AZURE_CLIENT_ID"
+	ClientSecretKey          = "AZURE_CLIENT_SECRET"
+	ClientCertificatePathKey = "AZURE_CLIENT_CERTIFICATE_PATH"
+	ResourceGroupKey         = "AZURE_RESOURCE_GROUP"
+	StorageAccountIDKey      = "AZURE_STORAGE_ACCOUNT_ID"
+	StorageAccountKey        = "AZURE_STORAGE_ACCOUNT"
+	StorageAccountAccessKey  = "AZURE_STORAGE_ACCOUNT_ACCESS_KEY"
+	FederatedTokenFileKey    = "AZURE_FEDERATED_TOKEN_FILE"
+
+	// DefaultFederatedTokenFile is the projected service account token of OpenShift workload identity
+	DefaultFederatedTokenFile = "/var/run/secrets/openshift/serviceaccount/token"
+
+	// StorageAccountConfigKey is the location config naming the storage account
+	StorageAccountConfigKey = "storageAccount"
+	// StorageAccountKeyEnvVarConfigKey is the location config naming the variable holding the storage account key
+	StorageAccountKeyEnvVarConfigKey = "storageAccountKeyEnvVar"
+	// ResourceGroupConfigKey is the location config naming the resource group of the storage account
+	ResourceGroupConfigKey = "resourceGroup"
+	// SubscriptionIDConfigKey is the location config naming the subscription of the storage account
+	SubscriptionIDConfigKey = "subscriptionId"
+)
+
+// AuthMethod is the way credentials authenticate with Azure
+type AuthMethod string
+
+const (
+	AuthMethodSharedKey        AuthMethod = "SharedKey"
+	AuthMethodServicePrincipal AuthMethod = "ServicePrincipal"
+	AuthMethodWorkloadIdentity AuthMethod = "WorkloadIdentity"
+	AuthMethodManagedIdentity  AuthMethod = "ManagedIdentity"
+	// AuthMethodDefault is the DefaultAzureCredential chain of secrets naming no identity: the environment, workload
+	// identity and the managed identity of the node
+	AuthMethodDefault AuthMethod = "DefaultAzureCredential"
+)
+
+// getenv is replaced in tests
+var getenv = os.Getenv
+
+// Credentials are the Azure credentials of a secret
+type Credentials struct {
+	SubscriptionID     string
+	TenantID           string
+	ClientID           string
+	ClientSecret       string
+	CertificatePath    string
+	ResourceGroupName  string
+	StorageAccountName string
+	StorageAccountKey  string
+	FederatedTokenFile string
+}
+
+// ParseEnv parses an env-formatted credentials file. Blank lines, comments, section headers such as
+// [default] and export prefixes are ignored, and values are unquoted.
+func ParseEnv(data []byte) map[string]string {
+	env := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			continue
+		}
+		env[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return env
+}
+
+// Parse returns the credentials of secret data. The env-formatted credentials file under key takes
+// precedence, fields it does not set are read from individual data keys.
+func Parse(data map[string][]byte, key string) Credentials {
+	env := map[string]string{}
+	if key != "" {
+		env = ParseEnv(data[key])
+	}
+	value := func(names ...string) string {
+		for _, name := range names {
+			if v := env[name]; v != "" {
+				return v
+			}
+		}
+		for _, name := range names {
+			if v := strings.TrimSpace(string(data[name])); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	return Credentials{
+		SubscriptionID:     value(SubscriptionIDKey),
+		TenantID:           value(TenantIDKey),
+		ClientID:           value(ClientIDKey),
+		ClientSecret:       value(ClientSecretKey),
+		CertificatePath:    value(ClientCertificatePathKey),
+		ResourceGroupName:  value(ResourceGroupKey),
+		StorageAccountName: value(StorageAccountIDKey, StorageAccountKey),
+		StorageAccountKey:  value(StorageAccountAccessKey),
+		FederatedTokenFile: value(FederatedTokenFileKey),
+	}
+}
+
+// FromSecret returns the credentials of the secret. Secrets generated by the standardized STS flow use
+// workload identity with the OpenShift projected token when no federated token file is configured.
+func FromSecret(secret *corev1.Secret, key string) Credentials {
+	creds := Parse(secret.Data, key)
+	if secret.Labels[stsflow.STSSecretLabelKey] == stsflow.STSSecretLabelValue &&
+		creds.FederatedTokenFile == "" && getenv(FederatedTokenFileKey) == "" {
+		creds.FederatedTokenFile = DefaultFederatedTokenFile
+	}
+	return creds
+}
+
+// ForLocation returns the credentials used by Velero for a backup or snapshot location. As in the Velero
+// Azure plugin, the storage account key is only used when the storageAccountKeyEnvVar config names it, and
+// the location config takes precedence over the secret for the storage account, resource group and subscription.
+func ForLocation(data map[string][]byte, key string, config map[string]string) Credentials {
+	creds := Parse(data, key)
+	creds.StorageAccountKey = ""
+	if envVar := config[StorageAccountKeyEnvVarConfigKey]; envVar != "" {
+		creds.StorageAccountKey = ParseEnv(data[key])[envVar]
+	}
+	if account := config[StorageAccountConfigKey]; account != "" {
+		creds.StorageAccountName = account
+	}
+	if resourceGroup := config[ResourceGroupConfigKey]; resourceGroup != "" {
+		creds.ResourceGroupName = resourceGroup
+	}
+	if subscriptionID := config[SubscriptionIDConfigKey]; subscriptionID != "" {
+		creds.SubscriptionID = subscriptionID
+	}
+	return creds
+}
+
+// Method returns the method authenticating requests to the blob service
+func (c Credentials) Method() AuthMethod {
+	if c.StorageAccountKey != "" {
+		return AuthMethodSharedKey
+	}
+	return c.TokenMethod()
+}
+
+// TokenMethod returns the Microsoft Entra method authenticating Azure Resource Manager requests,
+// which cannot use a storage account key
+func (c Credentials) TokenMethod() AuthMethod {
+	hasIdentity := c.TenantID != "" && c.ClientID != ""
+	switch {
+	case hasIdentity && (c.ClientSecret != "" || c.CertificatePath != ""):
+		return AuthMethodServicePrincipal
+	case hasIdentity && c.TokenFile() != "":
+		return AuthMethodWorkloadIdentity
+	case c.ClientID != "":
+		return AuthMethodManagedIdentity
+	default:
+		return AuthMethodDefault
+	}
+}
+
+// TokenFile returns the federated token file of workload identity, from the secret or the environment
+func (c Credentials) TokenFile() string {
+	if c.FederatedTokenFile != "" {
+		return c.FederatedTokenFile
+	}
+	return getenv(FederatedTokenFileKey)
+}
+
+// HasIdentity reports whether the credentials name a Microsoft Entra identity, rather than relying on a
+// storage account key or the system-assigned managed identity of the node
+func (c Credentials) HasIdentity() bool {
+	return c.ClientID != ""
+}
+
+// Validate reports credentials that set some fields of a method without the ones it requires
+func (c Credentials) Validate() error {
+	if (c.ClientSecret != "" || c.CertificatePath != "") && (c.TenantID == "" || c.ClientID == "") {
+		return fmt.Errorf("service principal credentials require %s and %s", TenantIDKey, ClientIDKey)
+	}
+	if c.FederatedTokenFile != "" && (c.TenantID == "" || c.ClientID == "") {
+		return fmt.Errorf("workload identity credentials require %s and %s", TenantIDKey, ClientIDKey)
+	}
+	return nil
+}
+
+// TokenCredential returns the Microsoft Entra credential of TokenMethod
+func (c Credentials) TokenCredential() (azcore.TokenCredential, error) {
+	switch c.TokenMethod() {
+	case AuthMethodServicePrincipal:
+		if c.ClientSecret != "" {
+			credential, err := azidentity.NewClientSecretCredential(c.TenantID, c.ClientID, c.ClientSecret, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create client secret credential: %w", err)
+			}
+			return credential, nil
+		}
+		certData, err := os.ReadFile(c.CertificatePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read certificate file: %w", err)
+		}
+		certs, key, err := azidentity.ParseCertificates(certData, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificates: %w", err)
+		}
+		credential, err := azidentity.NewClientCertificateCredential(c.TenantID, c.ClientID, certs, key, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client certificate credential: %w", err)
+		}
+		return credential, nil
+	case AuthMethodWorkloadIdentity:
+		credential, err := azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+			ClientID:      c.ClientID,
+			TenantID:      c.TenantID,
+			TokenFilePath: c.TokenFile(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create workload identity credential: %w", err)
+		}
+		return credential, nil
+	case AuthMethodManagedIdentity:
+		credential, err := azidentity.NewManagedIdentityCredential(&azidentity.ManagedIdentityCredentialOptions{
+			ID: azidentity.ClientID(c.ClientID),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create managed identity credential: %w", err)
+		}
+		return credential, nil
+	default:
+		credential, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create default Azure credential: %w", err)
+		}
+		return credential, nil
+	}
+}
+
+// BlobCredential returns the credential of Method for the blob service: a shared key credential for
+// AuthMethodSharedKey, a token credential otherwise
+func (c Credentials) BlobCredential() (azcore.TokenCredential, *azblob.SharedKeyCredential, error) {
+	if c.Method() == AuthMethodSharedKey {
+		if c.StorageAccountName == "" {
+			return nil, nil, fmt.Errorf("storage account name is required for shared key authentication")
+		}
+		credential, err := azblob.NewSharedKeyCredential(c.StorageAccountName, c.StorageAccountKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create shared key credential: %w", err)
+		}
+		return nil, credential, nil
+	}
+	credential, err := c.TokenCredential()
+	if err != nil {
+		return nil, nil, err
+	}
+	return credential, nil, nil
+}
+
+// ServiceURL returns the blob service URL of the storage account
+func ServiceURL(storageAccount string) string {
+	return fmt.Sprintf("https://%s.blob.core.windows.net/", storageAccount)
+}
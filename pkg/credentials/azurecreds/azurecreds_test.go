@@ -0,0 +1,400 @@
+package azurecreds
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
+)
+
+// setEnv replaces the environment read by the package for the duration of the test
+func setEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	previous := getenv
+	getenv = func(key string) string { return env[key] }
+	t.Cleanup(func() { getenv = previous })
+}
+
+func TestParseEnv(t *testing.T) {
+	cloudData := `[default]
+AZURE_SUBSCRIPTION_ID=test-sub
+AZURE_TENANT_ID = test-tenant
+
+# This is a comment
+AZURE_CLIENT_ID="test-client"
+export AZURE_RESOURCE_GROUP='test-rg'
+INVALID_LINE_WITHOUT_EQUALS
+AZURE_CLIENT_SECRET=test=secret`
+
+	require.Equal(t, map[string]string{
+		"AZURE_SUBSCRIPTION_ID": "test-sub",
+		"AZURE_TENANT_ID":       "test-tenant",
+		"AZURE_CLIENT_ID":       "test-client",
+		"AZURE_RESOURCE_GROUP":  "test-rg",
+		"AZURE_CLIENT_SECRET":   "test=secret",
+	}, ParseEnv([]byte(cloudData)))
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string][]byte
+		key  string
+		want Credentials
+	}{
+		{
+			name: "env-formatted credentials file",
+			data: map[string][]byte{
+				"cloud": []byte("AZURE_SUBSCRIPTION_ID=sub\nAZURE_TENANT_ID=tenant\nAZURE_CLIENT_ID=client\nAZURE_CLIENT_SECRET=secret\n" +
+					"AZURE_RESOURCE_GROUP=rg\nAZURE_STORAGE_ACCOUNT_ID=account\nAZURE_STORAGE_ACCOUNT_ACCESS_KEY=key\nAZURE_CLIENT_CERTIFICATE_PATH=/cert.pem\n"),
+			},
+			key: "cloud",
+			want: Credentials{
+				SubscriptionID:     "sub",
+				TenantID:           "tenant",
+				ClientID:           "client",
+				ClientSecret:       "secret",
+				CertificatePath:    "/cert.pem",
+				ResourceGroupName:  "rg",
+				StorageAccountName: "account",
+				StorageAccountKey:  "key",
+			},
+		},
+		{
+			name: "individual keys",
+			data: map[string][]byte{
+				"AZURE_TENANT_ID":            []byte("tenant"),
+				"AZURE_CLIENT_ID":            []byte("client"),
+				"AZURE_STORAGE_ACCOUNT":      []byte("account"),
+				"AZURE_FEDERATED_TOKEN_FILE": []byte("/token"),
+			},
+			key: "cloud",
+			want: Credentials{
+				TenantID:           "tenant",
+				ClientID:           "client",
+				StorageAccountName: "account",
+				FederatedTokenFile: "/token",
+			},
+		},
+		{
+			name: "empty credentials file falls back to individual keys",
+			data: map[string][]byte{
+				"cloud":                 []byte(""),
+				"AZURE_SUBSCRIPTION_ID": []byte("sub"),
+				"AZURE_TENANT_ID":       []byte("tenant"),
+			},
+			key:  "cloud",
+			want: Credentials{SubscriptionID: "sub", TenantID: "tenant"},
+		},
+		{
+			name: "credentials file takes precedence over individual keys",
+			data: map[string][]byte{
+				"azurekey":        []byte("AZURE_CLIENT_ID=from-file\n"),
+				"AZURE_CLIENT_ID": []byte("from-key"),
+				"AZURE_TENANT_ID": []byte("tenant"),
+			},
+			key:  "azurekey",
+			want: Credentials{ClientID: "from-file", TenantID: "tenant"},
+		},
+		{
+			name: "storage account ID takes precedence over storage account",
+			data: map[string][]byte{
+				"cloud": []byte("AZURE_STORAGE_ACCOUNT=second\nAZURE_STORAGE_ACCOUNT_ID=first\n"),
+			},
+			key:  "cloud",
+			want: Credentials{StorageAccountName: "first"},
+		},
+		{
+			name: "no key reads individual keys only",
+			data: map[string][]byte{
+				"cloud":           []byte("AZURE_CLIENT_ID=from-file\n"),
+				"AZURE_CLIENT_ID": []byte("from-key"),
+			},
+			want: Credentials{ClientID: "from-key"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Parse(tt.data, tt.key))
+		})
+	}
+}
+
+func TestCredentials_Method(t *testing.T) {
+	stsLabels := map[string]string{stsflow.STSSecretLabelKey: stsflow.STSSecretLabelValue}
+	tests := []struct {
+		name          string
+		data          map[string][]byte
+		labels        map[string]string
+		env           map[string]string
+		wantMethod    AuthMethod
+		wantToken     AuthMethod
+		wantTokenFile string
+	}{
+		{
+			name:       "storage account key",
+			data:       map[string][]byte{"AZURE_STORAGE_ACCOUNT_ACCESS_KEY": []byte("key")},
+			wantMethod: AuthMethodSharedKey,
+			wantToken:  AuthMethodDefault,
+		},
+		{
+			name: "storage account key takes precedence over service principal",
+			data: map[string][]byte{
+				"cloud": []byte("AZURE_STORAGE_ACCOUNT_ACCESS_KEY=key\nAZURE_TENANT_ID=tenant\nAZURE_CLIENT_ID=client\nAZURE_CLIENT_SECRET=secret\n"),
+			},
+			wantMethod: AuthMethodSharedKey,
+			wantToken:  AuthMethodServicePrincipal,
+		},
+		{
+			name: "service principal with client secret",
+			data: map[string][]byte{
+				"AZURE_TENANT_ID":     []byte("tenant"),
+				"AZURE_CLIENT_ID":     []byte("client"),
+				"AZURE_CLIENT_SECRET": []byte("secret"),
+			},
+			wantMethod: AuthMethodServicePrincipal,
+			wantToken:  AuthMethodServicePrincipal,
+		},
+		{
+			name: "service principal with client certificate",
+			data: map[string][]byte{
+				"cloud": []byte("AZURE_TENANT_ID=tenant\nAZURE_CLIENT_ID=client\nAZURE_CLIENT_CERTIFICATE_PATH=/cert.pem\n"),
+			},
+			wantMethod: AuthMethodServicePrincipal,
+			wantToken:  AuthMethodServicePrincipal,
+		},
+		{
+			name: "service principal takes precedence over workload identity of the environment",
+			data: map[string][]byte{
+				"AZURE_TENANT_ID":     []byte("tenant"),
+				"AZURE_CLIENT_ID":     []byte("client"),
+				"AZURE_CLIENT_SECRET": []byte("secret"),
+			},
+			env:           map[string]string{FederatedTokenFileKey: "/env/token"},
+			wantMethod:    AuthMethodServicePrincipal,
+			wantToken:     AuthMethodServicePrincipal,
+			wantTokenFile: "/env/token",
+		},
+		{
+			name: "service principal missing client secret",
+			data: map[string][]byte{
+				"AZURE_TENANT_ID": []byte("tenant"),
+				"AZURE_CLIENT_ID": []byte("client"),
+			},
+			wantMethod: AuthMethodManagedIdentity,
+			wantToken:  AuthMethodManagedIdentity,
+		},
+		{
+			name: "service principal missing tenant ID",
+			data: map[string][]byte{
+				"AZURE_CLIENT_ID":     []byte("client"),
+				"AZURE_CLIENT_SECRET": []byte("secret"),
+			},
+			wantMethod: AuthMethodManagedIdentity,
+			wantToken:  AuthMethodManagedIdentity,
+		},
+		{
+			name: "workload identity with federated token file in secret",
+			data: map[string][]byte{
+				"AZURE_TENANT_ID":            []byte("tenant"),
+				"AZURE_CLIENT_ID":            []byte("client"),
+				"AZURE_FEDERATED_TOKEN_FILE": []byte("/secret/token"),
+			},
+			env:           map[string]string{FederatedTokenFileKey: "/env/token"},
+			wantMethod:    AuthMethodWorkloadIdentity,
+			wantToken:     AuthMethodWorkloadIdentity,
+			wantTokenFile: "/secret/token",
+		},
+		{
+			name: "workload identity missing tenant ID",
+			data: map[string][]byte{
+				"AZURE_CLIENT_ID":            []byte("client"),
+				"AZURE_FEDERATED_TOKEN_FILE": []byte("/secret/token"),
+			},
+			wantMethod:    AuthMethodManagedIdentity,
+			wantToken:     AuthMethodManagedIdentity,
+			wantTokenFile: "/secret/token",
+		},
+		{
+			name: "workload identity with federated token file in environment",
+			data: map[string][]byte{
+				"AZURE_TENANT_ID": []byte("tenant"),
+				"AZURE_CLIENT_ID": []byte("client"),
+			},
+			env:           map[string]string{FederatedTokenFileKey: "/env/token"},
+			wantMethod:    AuthMethodWorkloadIdentity,
+			wantToken:     AuthMethodWorkloadIdentity,
+			wantTokenFile: "/env/token",
+		},
+		{
+			name: "STS secret uses the OpenShift projected token",
+			data: map[string][]byte{
+				"azurekey": []byte("AZURE_SUBSCRIPTION_ID=sub\nAZURE_TENANT_ID=tenant\nAZURE_CLIENT_ID=client\nAZURE_CLOUD_NAME=AzurePublicCloud\n"),
+			},
+			labels:        stsLabels,
+			wantMethod:    AuthMethodWorkloadIdentity,
+			wantToken:     AuthMethodWorkloadIdentity,
+			wantTokenFile: DefaultFederatedTokenFile,
+		},
+		{
+			name: "STS secret missing required fields",
+			data: map[string][]byte{
+				"azurekey": []byte("AZURE_SUBSCRIPTION_ID=sub\nAZURE_CLOUD_NAME=AzurePublicCloud\n"),
+			},
+			labels:        stsLabels,
+			wantMethod:    AuthMethodDefault,
+			wantToken:     AuthMethodDefault,
+			wantTokenFile: DefaultFederatedTokenFile,
+		},
+		{
+			name: "STS azurekey without label and token file",
+			data: map[string][]byte{
+				"azurekey": []byte("AZURE_SUBSCRIPTION_ID=sub\nAZURE_TENANT_ID=tenant\nAZURE_CLIENT_ID=client\n"),
+			},
+			wantMethod: AuthMethodManagedIdentity,
+			wantToken:  AuthMethodManagedIdentity,
+		},
+		{
+			name:       "empty secret",
+			data:       map[string][]byte{},
+			wantMethod: AuthMethodDefault,
+			wantToken:  AuthMethodDefault,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.env)
+			key := "cloud"
+			if _, ok := tt.data["azurekey"]; ok {
+				key = stsflow.AzureSecretKey
+			}
+			creds := FromSecret(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Labels: tt.labels},
+				Data:       tt.data,
+			}, key)
+			require.Equal(t, tt.wantMethod, creds.Method())
+			require.Equal(t, tt.wantToken, creds.TokenMethod())
+			require.Equal(t, tt.wantTokenFile, creds.TokenFile())
+		})
+	}
+}
+
+func TestForLocation(t *testing.T) {
+	data := map[string][]byte{
+		"cloud": []byte("AZURE_SUBSCRIPTION_ID=sub\nAZURE_RESOURCE_GROUP=rg\nAZURE_STORAGE_ACCOUNT_ACCESS_KEY=key\nCUSTOM_KEY=custom\n"),
+	}
+
+	creds := ForLocation(data, "cloud", nil)
+	require.Empty(t, creds.StorageAccountKey, "the key is only used when storageAccountKeyEnvVar names it")
+	require.Equal(t, "sub", creds.SubscriptionID)
+	require.Equal(t, "rg", creds.ResourceGroupName)
+	require.Equal(t, AuthMethodDefault, creds.Method())
+
+	creds = ForLocation(data, "cloud", map[string]string{
+		StorageAccountKeyEnvVarConfigKey: "CUSTOM_KEY",
+		StorageAccountConfigKey:          "account",
+		ResourceGroupConfigKey:           "config-rg",
+		SubscriptionIDConfigKey:          "config-sub",
+	})
+	require.Equal(t, Credentials{
+		SubscriptionID:     "config-sub",
+		ResourceGroupName:  "config-rg",
+		StorageAccountName: "account",
+		StorageAccountKey:  "custom",
+	}, creds)
+	require.Equal(t, AuthMethodSharedKey, creds.Method())
+
+	creds = ForLocation(data, "cloud", map[string]string{StorageAccountKeyEnvVarConfigKey: "MISSING"})
+	require.Empty(t, creds.StorageAccountKey)
+}
+
+func TestCredentials_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		creds   Credentials
+		wantErr string
+	}{
+		{name: "empty"},
+		{name: "storage account key", creds: Credentials{StorageAccountKey: "key"}},
+		{name: "service principal", creds: Credentials{TenantID: "tenant", ClientID: "client", ClientSecret: "secret"}},
+		{name: "user-assigned managed identity", creds: Credentials{ClientID: "client"}},
+		{
+			name:    "client secret without tenant",
+			creds:   Credentials{ClientID: "client", ClientSecret: "secret"},
+			wantErr: "service principal credentials require AZURE_TENANT_ID and AZURE_CLIENT_ID",
+		},
+		{
+			name:    "client certificate without client",
+			creds:   Credentials{TenantID: "tenant", CertificatePath: "/cert.pem"},
+			wantErr: "service principal credentials require AZURE_TENANT_ID and AZURE_CLIENT_ID",
+		},
+		{
+			name:    "federated token file without client",
+			creds:   Credentials{TenantID: "tenant", FederatedTokenFile: "/token"},
+			wantErr: "workload identity credentials require AZURE_TENANT_ID and AZURE_CLIENT_ID",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.creds.Validate()
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestCredentials_TokenCredential(t *testing.T) {
+	setEnv(t, nil)
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("token"), 0600))
+
+	credential, err := Credentials{TenantID: "tenant", ClientID: "client", ClientSecret: "secret"}.TokenCredential()
+	require.NoError(t, err)
+	require.IsType(t, &azidentity.ClientSecretCredential{}, credential)
+
+	credential, err = Credentials{TenantID: "tenant", ClientID: "client", FederatedTokenFile: tokenFile}.TokenCredential()
+	require.NoError(t, err)
+	require.IsType(t, &azidentity.WorkloadIdentityCredential{}, credential)
+
+	credential, err = Credentials{ClientID: "client"}.TokenCredential()
+	require.NoError(t, err)
+	require.IsType(t, &azidentity.ManagedIdentityCredential{}, credential)
+
+	credential, err = Credentials{}.TokenCredential()
+	require.NoError(t, err)
+	require.IsType(t, &azidentity.DefaultAzureCredential{}, credential)
+
+	_, err = Credentials{TenantID: "tenant", ClientID: "client", CertificatePath: filepath.Join(t.TempDir(), "missing.pem")}.TokenCredential()
+	require.ErrorContains(t, err, "failed to read certificate file")
+}
+
+func TestCredentials_BlobCredential(t *testing.T) {
+	setEnv(t, nil)
+
+	tokenCred, sharedKey, err := Credentials{StorageAccountName: "account", StorageAccountKey: "a2V5"}.BlobCredential()
+	require.NoError(t, err)
+	require.Nil(t, tokenCred)
+	require.NotNil(t, sharedKey)
+	require.Equal(t, "account", sharedKey.AccountName())
+
+	_, _, err = Credentials{StorageAccountKey: "a2V5"}.BlobCredential()
+	require.EqualError(t, err, "storage account name is required for shared key authentication")
+
+	tokenCred, sharedKey, err = Credentials{TenantID: "tenant", ClientID: "client", ClientSecret: "secret"}.BlobCredential()
+	require.NoError(t, err)
+	require.Nil(t, sharedKey)
+	require.IsType(t, &azidentity.ClientSecretCredential{}, tokenCred)
+}
+
+func TestServiceURL(t *testing.T) {
+	require.Equal(t, "https://account.blob.core.windows.net/", ServiceURL("account"))
+}
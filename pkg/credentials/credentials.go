@@ -276,6 +276,28 @@ func AppendCloudProviderVolumes(dpa *oadpv1alpha1.DataProtectionApplication, ds
 		}
 
 	}
+
+	// mount the credentials of locations using an external secret store
+	externalVolumes, externalMounts := ExternalCredentialVolumes(dpa)
+	ds.Spec.Template.Spec.Volumes = append(ds.Spec.Template.Spec.Volumes, externalVolumes...)
+	if nodeAgentContainer != nil {
+		nodeAgentContainer.VolumeMounts = append(nodeAgentContainer.VolumeMounts, externalMounts...)
+	}
+
+	// mount the credentials of locations using mountCredential
+	locationVolumes, locationMounts := LocationCredentialVolumes(dpa)
+	ds.Spec.Template.Spec.Volumes = append(ds.Spec.Template.Spec.Volumes, locationVolumes...)
+	if nodeAgentContainer != nil {
+		nodeAgentContainer.VolumeMounts = append(nodeAgentContainer.VolumeMounts, locationMounts...)
+	}
+
+	// mount the IAM Roles Anywhere certificates and EKS Pod Identity token of the AWS cloud identities
+	awsIdentityVolumes, awsIdentityMounts := AWSCloudIdentityVolumes(dpa)
+	ds.Spec.Template.Spec.Volumes = append(ds.Spec.Template.Spec.Volumes, awsIdentityVolumes...)
+	if nodeAgentContainer != nil {
+		nodeAgentContainer.VolumeMounts = append(nodeAgentContainer.VolumeMounts, awsIdentityMounts...)
+		nodeAgentContainer.Env = common.AppendUniqueEnvVars(nodeAgentContainer.Env, AWSCloudIdentityEnv(dpa))
+	}
 }
 
 // TODO: remove duplicate func in registry.go - refactoring away registry.go later
@@ -0,0 +1,216 @@
+package credentials
+
+import (
+	"sort"
+
+	"github.com/openshift/oadp-operator/pkg/credentials/stsflow"
+)
+
+const (
+	// CredentialsRequestAPIVersion is the API version of CredentialsRequests and their provider specs
+	CredentialsRequestAPIVersion = "cloudcredential.openshift.io/v1"
+	// CredentialsRequestKind is the kind of the Cloud Credential Operator CredentialsRequest
+	CredentialsRequestKind = "CredentialsRequest"
+	// VeleroServiceAccountName is the service account the requested credentials are bound to
+	VeleroServiceAccountName = "velero"
+)
+
+// CloudPermissions are the operations the credentials of a provider must allow
+type CloudPermissions struct {
+	// ObjectStorage is set when a backup location of the provider stores backups in Buckets
+	ObjectStorage bool
+	// Buckets are the buckets (AWS, GCP) or containers (Azure) of the backup locations, empty when a location
+	// does not name its bucket
+	Buckets []string
+	// CreateBuckets is set when a backup location uses a CloudStorage that the operator creates
+	CreateBuckets bool
+	// DeleteBuckets is set when a CloudStorage of the provider is deleted with its CR
+	DeleteBuckets bool
+	// Snapshots is set when a snapshot location of the provider snapshots volumes
+	Snapshots bool
+}
+
+var (
+	awsObjectActions = []string{
+		"s3:GetObject",
+		"s3:DeleteObject",
+		"s3:PutObject",
+		"s3:AbortMultipartUpload",
+		"s3:ListMultipartUploadParts",
+	}
+	awsBucketActions = []string{
+		"s3:ListBucket",
+		"s3:GetBucketVersioning",
+		"s3:ListBucketVersions",
+		"s3:ListBucketMultipartUploads",
+		"s3:GetBucketNotification",
+		"s3:PutBucketNotification",
+	}
+	awsCreateBucketActions = []string{
+		"s3:CreateBucket",
+		"s3:PutBucketTagging",
+		"s3:DeleteBucketTagging",
+	}
+	awsDeleteBucketActions = []string{
+		"s3:DeleteBucket",
+	}
+	awsSnapshotActions = []string{
+		"ec2:DescribeVolumes",
+		"ec2:DescribeSnapshots",
+		"ec2:CreateTags",
+		"ec2:CreateVolume",
+		"ec2:CreateSnapshot",
+		"ec2:DeleteSnapshot",
+	}
+
+	gcpObjectPermissions = []string{
+		"storage.buckets.get",
+		"storage.objects.create",
+		"storage.objects.delete",
+		"storage.objects.get",
+		"storage.objects.list",
+		"iam.serviceAccounts.signBlob",
+	}
+	gcpCreateBucketPermissions = []string{
+		"storage.buckets.create",
+		"storage.buckets.update",
+	}
+	gcpDeleteBucketPermissions = []string{
+		"storage.buckets.delete",
+	}
+	gcpSnapshotPermissions = []string{
+		"compute.disks.get",
+		"compute.disks.create",
+		"compute.disks.createSnapshot",
+		"compute.snapshots.get",
+		"compute.snapshots.create",
+		"compute.snapshots.useReadOnly",
+		"compute.snapshots.delete",
+		"compute.zones.get",
+	}
+
+	// Azure built-in roles: blob data access covers creating and deleting containers,
+	// volume snapshots need to snapshot disks and to create disks from snapshots on restore
+	azureObjectStorageRoles = []string{"Storage Blob Data Contributor"}
+	azureSnapshotRoles      = []string{"Disk Snapshot Contributor", "Virtual Machine Contributor"}
+)
+
+// AWSProviderSpec returns the AWSProviderSpec of a CredentialsRequest granting the permissions.
+// Bucket permissions are scoped to the buckets, or to all buckets when a location does not name its bucket.
+func AWSProviderSpec(permissions CloudPermissions) map[string]interface{} {
+	var statements []interface{}
+	statement := func(actions []string, resource string) {
+		statements = append(statements, map[string]interface{}{
+			"effect":   "Allow",
+			"action":   toInterfaceSlice(actions),
+			"resource": resource,
+		})
+	}
+	if permissions.ObjectStorage {
+		bucketActions := append([]string{}, awsBucketActions...)
+		if permissions.CreateBuckets {
+			bucketActions = append(bucketActions, awsCreateBucketActions...)
+		}
+		if permissions.DeleteBuckets {
+			bucketActions = append(bucketActions, awsDeleteBucketActions...)
+		}
+		for _, bucket := range bucketsOrWildcard(permissions.Buckets) {
+			statement(awsObjectActions, "arn:aws:s3:::"+bucket+"/*")
+			statement(bucketActions, "arn:aws:s3:::"+bucket)
+		}
+	}
+	if permissions.Snapshots {
+		statement(awsSnapshotActions, "*")
+	}
+	return map[string]interface{}{
+		"apiVersion":       CredentialsRequestAPIVersion,
+		"kind":             "AWSProviderSpec",
+		"statementEntries": statements,
+	}
+}
+
+// GCPProviderSpec returns the GCPProviderSpec of a CredentialsRequest granting the permissions
+func GCPProviderSpec(permissions CloudPermissions) map[string]interface{} {
+	var granted []string
+	if permissions.ObjectStorage {
+		granted = append(granted, gcpObjectPermissions...)
+		if permissions.CreateBuckets {
+			granted = append(granted, gcpCreateBucketPermissions...)
+		}
+		if permissions.DeleteBuckets {
+			granted = append(granted, gcpDeleteBucketPermissions...)
+		}
+	}
+	if permissions.Snapshots {
+		granted = append(granted, gcpSnapshotPermissions...)
+	}
+	return map[string]interface{}{
+		"apiVersion":       CredentialsRequestAPIVersion,
+		"kind":             "GCPProviderSpec",
+		"permissions":      toInterfaceSlice(granted),
+		"skipServiceCheck": true,
+	}
+}
+
+// AzureProviderSpec returns the AzureProviderSpec of a CredentialsRequest granting the permissions as role bindings
+func AzureProviderSpec(permissions CloudPermissions) map[string]interface{} {
+	var roles []string
+	if permissions.ObjectStorage {
+		roles = append(roles, azureObjectStorageRoles...)
+	}
+	if permissions.Snapshots {
+		roles = append(roles, azureSnapshotRoles...)
+	}
+	roleBindings := make([]interface{}, 0, len(roles))
+	for _, role := range roles {
+		roleBindings = append(roleBindings, map[string]interface{}{"role": role})
+	}
+	return map[string]interface{}{
+		"apiVersion":   CredentialsRequestAPIVersion,
+		"kind":         "AzureProviderSpec",
+		"roleBindings": roleBindings,
+	}
+}
+
+// CredentialsRequestSpec returns the spec of a CredentialsRequest writing the credentials of providerSpec to the
+// secret in namespace, for the velero service account. cloudTokenPath is the projected token used by short-lived
+// credentials created with ccoctl.
+func CredentialsRequestSpec(providerSpec map[string]interface{}, secretName, namespace string) map[string]interface{} {
+	return map[string]interface{}{
+		"providerSpec": providerSpec,
+		"secretRef": map[string]interface{}{
+			"name":      secretName,
+			"namespace": namespace,
+		},
+		"serviceAccountNames": []interface{}{VeleroServiceAccountName},
+		"cloudTokenPath":      stsflow.WebIdentityTokenPath,
+	}
+}
+
+// bucketsOrWildcard returns the sorted buckets without duplicates, or the wildcard when a bucket is unknown
+func bucketsOrWildcard(buckets []string) []string {
+	seen := map[string]bool{}
+	for _, bucket := range buckets {
+		if bucket == "" {
+			return []string{"*"}
+		}
+		seen[bucket] = true
+	}
+	if len(seen) == 0 {
+		return []string{"*"}
+	}
+	sorted := make([]string, 0, len(seen))
+	for bucket := range seen {
+		sorted = append(sorted, bucket)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func toInterfaceSlice(values []string) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		result = append(result, value)
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package credentials
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAWSProviderSpec(t *testing.T) {
+	spec := AWSProviderSpec(CloudPermissions{
+		ObjectStorage: true,
+		Buckets:       []string{"b", "a", "a"},
+		CreateBuckets: true,
+	})
+	require.Equal(t, "AWSProviderSpec", spec["kind"])
+	statements := spec["statementEntries"].([]interface{})
+	require.Len(t, statements, 4, "object and bucket statements for each bucket")
+	bucketStatement := statements[1].(map[string]interface{})
+	require.Equal(t, "arn:aws:s3:::a", bucketStatement["resource"])
+	require.Contains(t, bucketStatement["action"], "s3:CreateBucket")
+	require.NotContains(t, bucketStatement["action"], "s3:DeleteBucket")
+
+	spec = AWSProviderSpec(CloudPermissions{ObjectStorage: true, Buckets: []string{"a", ""}, Snapshots: true})
+	statements = spec["statementEntries"].([]interface{})
+	require.Len(t, statements, 3)
+	require.Equal(t, "arn:aws:s3:::*/*", statements[0].(map[string]interface{})["resource"], "unknown bucket widens to all buckets")
+	require.Contains(t, statements[2].(map[string]interface{})["action"], "ec2:CreateSnapshot")
+}
+
+func TestGCPProviderSpec(t *testing.T) {
+	spec := GCPProviderSpec(CloudPermissions{Snapshots: true})
+	permissions := spec["permissions"].([]interface{})
+	require.Contains(t, permissions, "compute.disks.createSnapshot")
+	require.NotContains(t, permissions, "storage.objects.create")
+
+	spec = GCPProviderSpec(CloudPermissions{ObjectStorage: true, DeleteBuckets: true})
+	permissions = spec["permissions"].([]interface{})
+	require.Contains(t, permissions, "storage.objects.create")
+	require.Contains(t, permissions, "storage.buckets.delete")
+	require.NotContains(t, permissions, "storage.buckets.create")
+}
+
+func TestAzureProviderSpec(t *testing.T) {
+	spec := AzureProviderSpec(CloudPermissions{ObjectStorage: true, Snapshots: true})
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"role": "Storage Blob Data Contributor"},
+		map[string]interface{}{"role": "Disk Snapshot Contributor"},
+		map[string]interface{}{"role": "Virtual Machine Contributor"},
+	}, spec["roleBindings"])
+}
+
+func TestCredentialsRequestSpec(t *testing.T) {
+	spec := CredentialsRequestSpec(GCPProviderSpec(CloudPermissions{ObjectStorage: true}), "cloud-credentials-gcp", "openshift-adp")
+	require.Equal(t, map[string]interface{}{"name": "cloud-credentials-gcp", "namespace": "openshift-adp"}, spec["secretRef"])
+	require.Equal(t, []interface{}{"velero"}, spec["serviceAccountNames"])
+}
@@ -0,0 +1,94 @@
+package credentials
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+const (
+	// SecretsStoreCSIDriver is the name of the Secrets Store CSI driver
+	SecretsStoreCSIDriver = "secrets-store.csi.k8s.io"
+	// ExternalCredentialsMountPath is the directory under which each SecretProviderClass volume is mounted
+	ExternalCredentialsMountPath = "/credentials-external"
+
+	externalCredentialVolumePrefix = "spc-"
+	maxVolumeNameLength            = 63
+)
+
+// ExternalCredentialVolumeName returns the name of the volume mounting the SecretProviderClass.
+// Names that do not fit in a volume name are shortened with a hash suffix.
+func ExternalCredentialVolumeName(secretProviderClass string) string {
+	return volumeName(externalCredentialVolumePrefix, secretProviderClass)
+}
+
+// volumeName returns prefix followed by name, shortened with a hash suffix if it does not fit in a volume name
+func volumeName(prefix, name string) string {
+	if len(prefix+name) <= maxVolumeNameLength {
+		return prefix + name
+	}
+	sum := sha256.Sum256([]byte(name))
+	suffix := "-" + hex.EncodeToString(sum[:])[:8]
+	return (prefix + name)[:maxVolumeNameLength-len(suffix)] + suffix
+}
+
+// ExternalCredentialFile returns the path of the credentials file of the external credential in the Velero and NodeAgent pods
+func ExternalCredentialFile(credential *oadpv1alpha1.ExternalCredential) string {
+	return path.Join(ExternalCredentialsMountPath, credential.SecretProviderClass, credential.Key)
+}
+
+// ExternalCredentialSecretProviderClasses returns the SecretProviderClasses referenced by the backup and snapshot
+// locations of the DPA, sorted and without duplicates.
+func ExternalCredentialSecretProviderClasses(dpa *oadpv1alpha1.DataProtectionApplication) []string {
+	seen := map[string]bool{}
+	for _, bsl := range dpa.Spec.BackupLocations {
+		if bsl.ExternalCredential != nil {
+			seen[bsl.ExternalCredential.SecretProviderClass] = true
+		}
+	}
+	for _, vsl := range dpa.Spec.SnapshotLocations {
+		if vsl.ExternalCredential != nil {
+			seen[vsl.ExternalCredential.SecretProviderClass] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExternalCredentialVolumes returns the Secrets Store CSI volumes, and their mounts, for the SecretProviderClasses
+// referenced by the backup and snapshot locations of the DPA
+func ExternalCredentialVolumes(dpa *oadpv1alpha1.DataProtectionApplication) ([]corev1.Volume, []corev1.VolumeMount) {
+	var volumes []corev1.Volume
+	var mounts []corev1.VolumeMount
+	for _, secretProviderClass := range ExternalCredentialSecretProviderClasses(dpa) {
+		name := ExternalCredentialVolumeName(secretProviderClass)
+		volumes = append(volumes, corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				CSI: &corev1.CSIVolumeSource{
+					Driver:   SecretsStoreCSIDriver,
+					ReadOnly: ptr.To(true),
+					VolumeAttributes: map[string]string{
+						"secretProviderClass": secretProviderClass,
+					},
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      name,
+			MountPath: path.Join(ExternalCredentialsMountPath, secretProviderClass),
+			ReadOnly:  true,
+		})
+	}
+	return volumes, mounts
+}
@@ -0,0 +1,60 @@
+package credentials
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func TestExternalCredentialVolumeName(t *testing.T) {
+	require.Equal(t, "spc-aws-backup", ExternalCredentialVolumeName("aws-backup"))
+
+	long := strings.Repeat("a", 100)
+	name := ExternalCredentialVolumeName(long)
+	require.Len(t, name, 63)
+	require.True(t, strings.HasPrefix(name, "spc-aaaa"))
+	require.NotEqual(t, name, ExternalCredentialVolumeName(long+"b"), "shortened names must stay unique")
+}
+
+func TestAppendCloudProviderVolumes_ExternalCredential(t *testing.T) {
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{DefaultPlugins: []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginAWS}},
+			},
+			BackupLocations: []oadpv1alpha1.BackupLocation{
+				{Velero: &velerov1.BackupStorageLocationSpec{Provider: "aws"}, ExternalCredential: &oadpv1alpha1.ExternalCredential{SecretProviderClass: "vault-aws", Key: "cloud"}},
+				{Velero: &velerov1.BackupStorageLocationSpec{Provider: "aws"}, ExternalCredential: &oadpv1alpha1.ExternalCredential{SecretProviderClass: "vault-aws", Key: "other"}},
+			},
+			SnapshotLocations: []oadpv1alpha1.SnapshotLocation{
+				{Velero: &velerov1.VolumeSnapshotLocationSpec{Provider: "aws"}, ExternalCredential: &oadpv1alpha1.ExternalCredential{SecretProviderClass: "vault-ebs", Key: "cloud"}},
+			},
+		},
+	}
+	ds := &appsv1.DaemonSet{}
+	ds.Spec.Template.Spec.Containers = []corev1.Container{{Name: common.NodeAgent}}
+
+	AppendCloudProviderVolumes(dpa, ds, map[string]bool{"aws": false})
+
+	volumes := ds.Spec.Template.Spec.Volumes
+	require.Len(t, volumes, 2)
+	require.Equal(t, "spc-vault-aws", volumes[0].Name)
+	require.NotNil(t, volumes[0].CSI)
+	require.Equal(t, SecretsStoreCSIDriver, volumes[0].CSI.Driver)
+	require.Equal(t, map[string]string{"secretProviderClass": "vault-aws"}, volumes[0].CSI.VolumeAttributes)
+	require.True(t, *volumes[0].CSI.ReadOnly)
+	require.Equal(t, "spc-vault-ebs", volumes[1].Name)
+
+	require.Equal(t, []corev1.VolumeMount{
+		{Name: "spc-vault-aws", MountPath: "/credentials-external/vault-aws", ReadOnly: true},
+		{Name: "spc-vault-ebs", MountPath: "/credentials-external/vault-ebs", ReadOnly: true},
+	}, ds.Spec.Template.Spec.Containers[0].VolumeMounts)
+	require.Equal(t, "/credentials-external/vault-aws/cloud", ExternalCredentialFile(dpa.Spec.BackupLocations[0].ExternalCredential))
+}
@@ -0,0 +1,96 @@
+package credentials
+
+import (
+	"path"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+const (
+	// LocationCredentialsMountPath is the directory under which each secret of a location using mountCredential is mounted
+	LocationCredentialsMountPath = "/credentials-location"
+
+	locationCredentialVolumePrefix = "location-"
+)
+
+// LocationCredentialVolumeName returns the name of the volume mounting the credential secret of locations using mountCredential
+func LocationCredentialVolumeName(secretName string) string {
+	return volumeName(locationCredentialVolumePrefix, secretName)
+}
+
+// LocationCredentialFile returns the path of the mounted credential of a location in the Velero and NodeAgent pods
+func LocationCredentialFile(credential *corev1.SecretKeySelector) string {
+	return path.Join(LocationCredentialsMountPath, credential.Name, credential.Key)
+}
+
+// mountedLocationCredentials returns the credential secrets of the backup and snapshot locations using mountCredential,
+// with the keys they reference, sorted and without duplicates.
+func mountedLocationCredentials(dpa *oadpv1alpha1.DataProtectionApplication) map[string][]string {
+	seen := map[string]map[string]bool{}
+	add := func(credential *corev1.SecretKeySelector) {
+		if credential == nil || credential.Name == "" || credential.Key == "" {
+			return
+		}
+		if seen[credential.Name] == nil {
+			seen[credential.Name] = map[string]bool{}
+		}
+		seen[credential.Name][credential.Key] = true
+	}
+	for _, bsl := range dpa.Spec.BackupLocations {
+		if bsl.MountCredential && bsl.Velero != nil {
+			add(bsl.Velero.Credential)
+		}
+	}
+	for _, vsl := range dpa.Spec.SnapshotLocations {
+		if vsl.MountCredential && vsl.Velero != nil {
+			add(vsl.Velero.Credential)
+		}
+	}
+	secrets := make(map[string][]string, len(seen))
+	for name, keys := range seen {
+		for key := range keys {
+			secrets[name] = append(secrets[name], key)
+		}
+		sort.Strings(secrets[name])
+	}
+	return secrets
+}
+
+// LocationCredentialVolumes returns the secret volumes, and their mounts, of the locations using mountCredential.
+// Each secret is mounted once, projecting only the keys referenced by the locations.
+func LocationCredentialVolumes(dpa *oadpv1alpha1.DataProtectionApplication) ([]corev1.Volume, []corev1.VolumeMount) {
+	secrets := mountedLocationCredentials(dpa)
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var volumes []corev1.Volume
+	var mounts []corev1.VolumeMount
+	for _, secretName := range names {
+		items := make([]corev1.KeyToPath, 0, len(secrets[secretName]))
+		for _, key := range secrets[secretName] {
+			items = append(items, corev1.KeyToPath{Key: key, Path: key})
+		}
+		name := LocationCredentialVolumeName(secretName)
+		volumes = append(volumes, corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: secretName,
+					Items:      items,
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      name,
+			MountPath: path.Join(LocationCredentialsMountPath, secretName),
+			ReadOnly:  true,
+		})
+	}
+	return volumes, mounts
+}
@@ -0,0 +1,71 @@
+package credentials
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/common"
+)
+
+func TestLocationCredentialVolumeName(t *testing.T) {
+	require.Equal(t, "location-team-a", LocationCredentialVolumeName("team-a"))
+
+	long := strings.Repeat("a", 100)
+	name := LocationCredentialVolumeName(long)
+	require.Len(t, name, 63)
+	require.True(t, strings.HasPrefix(name, "location-aaaa"))
+	require.NotEqual(t, name, LocationCredentialVolumeName(long+"b"), "shortened names must stay unique")
+}
+
+func TestAppendCloudProviderVolumes_MountCredential(t *testing.T) {
+	credential := func(name, key string) *corev1.SecretKeySelector {
+		return &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key}
+	}
+	dpa := &oadpv1alpha1.DataProtectionApplication{
+		Spec: oadpv1alpha1.DataProtectionApplicationSpec{
+			Configuration: &oadpv1alpha1.ApplicationConfig{
+				Velero: &oadpv1alpha1.VeleroConfig{DefaultPlugins: []oadpv1alpha1.DefaultPlugin{oadpv1alpha1.DefaultPluginAWS}},
+			},
+			BackupLocations: []oadpv1alpha1.BackupLocation{
+				{Velero: &velerov1.BackupStorageLocationSpec{Provider: "aws", Credential: credential("team-b", "cloud")}, MountCredential: true},
+				{Velero: &velerov1.BackupStorageLocationSpec{Provider: "aws", Credential: credential("team-a", "s3")}, MountCredential: true},
+				{Velero: &velerov1.BackupStorageLocationSpec{Provider: "aws", Credential: credential("team-c", "cloud")}},
+			},
+			SnapshotLocations: []oadpv1alpha1.SnapshotLocation{
+				{Velero: &velerov1.VolumeSnapshotLocationSpec{Provider: "aws", Credential: credential("team-a", "ebs")}, MountCredential: true},
+			},
+		},
+	}
+	ds := &appsv1.DaemonSet{}
+	ds.Spec.Template.Spec.Containers = []corev1.Container{{Name: common.NodeAgent}}
+
+	AppendCloudProviderVolumes(dpa, ds, map[string]bool{"aws": false})
+
+	require.Equal(t, []corev1.Volume{
+		{
+			Name: "location-team-a",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+				SecretName: "team-a",
+				Items:      []corev1.KeyToPath{{Key: "ebs", Path: "ebs"}, {Key: "s3", Path: "s3"}},
+			}},
+		},
+		{
+			Name: "location-team-b",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+				SecretName: "team-b",
+				Items:      []corev1.KeyToPath{{Key: "cloud", Path: "cloud"}},
+			}},
+		},
+	}, ds.Spec.Template.Spec.Volumes)
+	require.Equal(t, []corev1.VolumeMount{
+		{Name: "location-team-a", MountPath: "/credentials-location/team-a", ReadOnly: true},
+		{Name: "location-team-b", MountPath: "/credentials-location/team-b", ReadOnly: true},
+	}, ds.Spec.Template.Spec.Containers[0].VolumeMounts)
+	require.Equal(t, "/credentials-location/team-a/s3", LocationCredentialFile(dpa.Spec.BackupLocations[1].Velero.Credential))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -38,6 +39,23 @@ const (
 	// WebIdentityTokenPath mount present on operator CSV
 	WebIdentityTokenPath = "/var/run/secrets/openshift/serviceaccount/token"
 
+	// RolesAnywhereMountPath is the directory under which the certificate secret of each IAM Roles Anywhere
+	// cloud identity is mounted in the Velero and NodeAgent pods
+	RolesAnywhereMountPath = "/credentials-rolesanywhere"
+	// DefaultAWSSigningHelperPath is the AWS signing helper run by the credential_process of IAM Roles Anywhere profiles
+	DefaultAWSSigningHelperPath = "aws_signing_helper"
+
+	// EKS Pod Identity container credentials, see
+	// https://docs.aws.amazon.com/eks/latest/userguide/pod-id-how-it-works.html
+	PodIdentityCredentialsFullURIEnvKey   = "AWS_CONTAINER_CREDENTIALS_FULL_URI"
+	PodIdentityAuthorizationTokenEnvKey   = "AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"
+	PodIdentityCredentialsFullURI         = "http://169.254.170.23/v1/credentials"
+	PodIdentityTokenAudience              = "pods.eks.amazonaws.com"
+	PodIdentityTokenMountPath             = "/var/run/secrets/pods.eks.amazonaws.com/serviceaccount"
+	PodIdentityTokenFileName              = "eks-pod-identity-token"
+	PodIdentityTokenExpirationSeconds     = 86400
+	PodIdentityContainerCredentialsSource = "EcsContainer"
+
 	// Azure workload identity secret name
 	AzureWorkloadIdentitySecretName = "azure-workload-identity-env"
 	// Cloud Provider Secret Keys - standard key names for cloud credentials
@@ -56,6 +74,9 @@ const (
 	// GCP Secret key name
 	GcpSecretJSONKey = "service_account.json"
 
+	// Azure Secret key name
+	AzureSecretKey = "azurekey"
+
 	VeleroAWSSecretName   = "cloud-credentials"
 	VeleroAzureSecretName = "cloud-credentials-azure"
 	VeleroGCPSecretName   = "cloud-credentials-gcp"
@@ -136,32 +157,11 @@ func STSStandardizedFlow() (string, error) {
 
 func CreateOrUpdateSTSAWSSecret(setupLog logr.Logger, roleARN string, secretNS string, kubeconf *rest.Config) error {
 	// AWS STS credentials format
-	return CreateOrUpdateSTSSecret(setupLog, VeleroAWSSecretName, map[string]string{
-		AWSSecretCredentialsKey: fmt.Sprintf(`[default]
-sts_regional_endpoints = regional
-role_arn = %s
-web_identity_token_file = %s`, roleARN, WebIdentityTokenPath),
-	}, secretNS, kubeconf)
+	return CreateOrUpdateSTSSecret(setupLog, VeleroAWSSecretName, AWSSecretData(roleARN), secretNS, kubeconf)
 }
 
 func CreateOrUpdateSTSGCPSecret(setupLog logr.Logger, serviceAccountEmail, projectNumber, poolId, providerId, secretNS string, kubeconf *rest.Config) error {
-	audience := fmt.Sprintf("//iam.googleapis.com/projects/%s/locations/global/workloadIdentityPools/%s/providers/%s", projectNumber, poolId, providerId)
-	// GCP external account credentials format for Workload Identity Federation
-	return CreateOrUpdateSTSSecret(setupLog, VeleroGCPSecretName, map[string]string{
-		GcpSecretJSONKey: fmt.Sprintf(`{
-	"type": "external_account",
-	"audience": "%s",
-	"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
-	"token_url": "https://sts.googleapis.com/v1/token",
-	"service_account_impersonation_url": "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/%s:generateAccessToken",
-	"credential_source": {
-		"file": "%s",
-		"format": {
-			"type": "text"
-		}
-	}
-}`, audience, serviceAccountEmail, WebIdentityTokenPath),
-	}, secretNS, kubeconf)
+	return CreateOrUpdateSTSSecret(setupLog, VeleroGCPSecretName, GCPSecretData(serviceAccountEmail, projectNumber, poolId, providerId), secretNS, kubeconf)
 }
 
 func CreateOrUpdateSTSAzureSecret(setupLog logr.Logger, azureClientId, azureTenantId, azureSubscriptionId, secretNS string, kubeconf *rest.Config) error {
@@ -183,14 +183,7 @@ func CreateOrUpdateSTSAzureSecret(setupLog logr.Logger, azureClientId, azureTena
 
 // CreateOrUpdateSTSAzureSecretWithClients is a testable version that accepts injected clients
 func CreateOrUpdateSTSAzureSecretWithClients(setupLog logr.Logger, azureClientId, azureTenantId, azureSubscriptionId, secretNS string, clientInstance client.Client, clientset kubernetes.Interface) error {
-	// Azure federated identity credentials format
-	err := CreateOrUpdateSTSSecretWithClients(setupLog, VeleroAzureSecretName, map[string]string{
-		"azurekey": fmt.Sprintf(`
-AZURE_SUBSCRIPTION_ID=%s
-AZURE_TENANT_ID=%s
-AZURE_CLIENT_ID=%s
-AZURE_CLOUD_NAME=AzurePublicCloud
-`, azureSubscriptionId, azureTenantId, azureClientId)}, secretNS, clientInstance, clientset)
+	err := CreateOrUpdateSTSSecretWithClients(setupLog, VeleroAzureSecretName, AzureSecretData(azureClientId, azureTenantId, azureSubscriptionId), secretNS, clientInstance, clientset)
 
 	if err != nil {
 		return err
@@ -204,6 +197,77 @@ AZURE_CLOUD_NAME=AzurePublicCloud
 
 	return nil
 }
+
+// AWSSecretData returns the shared config file content that assumes roleARN with the projected service account token.
+func AWSSecretData(roleARN string) map[string]string {
+	return map[string]string{
+		AWSSecretCredentialsKey: fmt.Sprintf(`[default]
+sts_regional_endpoints = regional
+role_arn = %s
+web_identity_token_file = %s`, roleARN, WebIdentityTokenPath),
+	}
+}
+
+// AWSRolesAnywhereSecretData returns the shared config file content that obtains credentials for roleARN through
+// IAM Roles Anywhere, running the signing helper with the certificate secret mounted under RolesAnywhereMountPath.
+func AWSRolesAnywhereSecretData(signingHelperPath, certificateSecret, trustAnchorARN, profileARN, roleARN string) map[string]string {
+	if signingHelperPath == "" {
+		signingHelperPath = DefaultAWSSigningHelperPath
+	}
+	certificateDir := path.Join(RolesAnywhereMountPath, certificateSecret)
+	return map[string]string{
+		AWSSecretCredentialsKey: fmt.Sprintf(`[default]
+credential_process = %s credential-process --certificate %s --private-key %s --trust-anchor-arn %s --profile-arn %s --role-arn %s`,
+			signingHelperPath,
+			path.Join(certificateDir, corev1.TLSCertKey),
+			path.Join(certificateDir, corev1.TLSPrivateKeyKey),
+			trustAnchorARN, profileARN, roleARN),
+	}
+}
+
+// AWSPodIdentitySecretData returns the shared config file content that uses the container credentials
+// served by the EKS Pod Identity agent.
+func AWSPodIdentitySecretData() map[string]string {
+	return map[string]string{
+		AWSSecretCredentialsKey: fmt.Sprintf(`[default]
+credential_source = %s`, PodIdentityContainerCredentialsSource),
+	}
+}
+
+// GCPSecretData returns the external account credentials that impersonate serviceAccountEmail through the
+// workload identity pool provider using the projected service account token.
+func GCPSecretData(serviceAccountEmail, projectNumber, poolId, providerId string) map[string]string {
+	audience := fmt.Sprintf("//iam.googleapis.com/projects/%s/locations/global/workloadIdentityPools/%s/providers/%s", projectNumber, poolId, providerId)
+	// GCP external account credentials format for Workload Identity Federation
+	return map[string]string{
+		GcpSecretJSONKey: fmt.Sprintf(`{
+	"type": "external_account",
+	"audience": "%s",
+	"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+	"token_url": "https://sts.googleapis.com/v1/token",
+	"service_account_impersonation_url": "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/%s:generateAccessToken",
+	"credential_source": {
+		"file": "%s",
+		"format": {
+			"type": "text"
+		}
+	}
+}`, audience, serviceAccountEmail, WebIdentityTokenPath),
+	}
+}
+
+// AzureSecretData returns the Azure federated identity credentials format
+func AzureSecretData(azureClientId, azureTenantId, azureSubscriptionId string) map[string]string {
+	return map[string]string{
+		AzureSecretKey: fmt.Sprintf(`
+AZURE_SUBSCRIPTION_ID=%s
+AZURE_TENANT_ID=%s
+AZURE_CLIENT_ID=%s
+AZURE_CLOUD_NAME=AzurePublicCloud
+`, azureSubscriptionId, azureTenantId, azureClientId),
+	}
+}
+
 func CreateOrUpdateSTSSecret(setupLog logr.Logger, secretName string, credStringData map[string]string, secretNS string, kubeconf *rest.Config) error {
 	clientInstance, err := client.New(kubeconf, client.Options{})
 	if err != nil {
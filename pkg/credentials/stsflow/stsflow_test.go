@@ -196,6 +196,19 @@ web_identity_token_file = ` + WebIdentityTokenPath
 	assert.Contains(t, secretResult.StringData["credentials"], WebIdentityTokenPath)
 }
 
+func TestAWSRolesAnywhereSecretData(t *testing.T) {
+	data := AWSRolesAnywhereSecretData("", "velero-cert", "arn:trust-anchor", "arn:profile", "arn:role")
+	assert.Equal(t, `[default]
+credential_process = aws_signing_helper credential-process --certificate /credentials-rolesanywhere/velero-cert/tls.crt --private-key /credentials-rolesanywhere/velero-cert/tls.key --trust-anchor-arn arn:trust-anchor --profile-arn arn:profile --role-arn arn:role`, data[AWSSecretCredentialsKey])
+
+	data = AWSRolesAnywhereSecretData("/plugins/aws_signing_helper", "velero-cert", "arn:trust-anchor", "arn:profile", "arn:role")
+	assert.Contains(t, data[AWSSecretCredentialsKey], "credential_process = /plugins/aws_signing_helper credential-process")
+}
+
+func TestAWSPodIdentitySecretData(t *testing.T) {
+	assert.Equal(t, map[string]string{AWSSecretCredentialsKey: "[default]\ncredential_source = EcsContainer"}, AWSPodIdentitySecretData())
+}
+
 func TestCreateOrUpdateSTSGCPSecret(t *testing.T) {
 	testNamespace := "test-namespace"
 	testLogger := zap.New(zap.UseDevMode(true))
@@ -0,0 +1,390 @@
+// Package dptreport defines the versioned, machine-readable JSON report
+// exported for each DataProtectionTest run.
+package dptreport
+
+import (
+	"encoding/json"
+	"time"
+
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+	"github.com/openshift/oadp-operator/pkg/utils"
+)
+
+// SchemaVersion is the version of the report format. It must be bumped on
+// any incompatible change to the JSON structure.
+const SchemaVersion = "oadp.openshift.io/dpt-report/v1"
+
+// Report is the JSON document exported for a DataProtectionTest run.
+// Speeds are in megabits per second and durations in seconds.
+type Report struct {
+	SchemaVersion string          `json:"schemaVersion"`
+	GeneratedAt   time.Time       `json:"generatedAt"`
+	Cluster       ClusterIdentity `json:"cluster"`
+	Test          TestIdentity    `json:"test"`
+	Parameters    Parameters      `json:"parameters"`
+	Results       Results         `json:"results"`
+}
+
+// ClusterIdentity identifies the cluster the test ran on.
+type ClusterIdentity struct {
+	// ID is the OpenShift ClusterVersion clusterID, or the kube-system namespace UID on other clusters.
+	ID string `json:"id,omitempty"`
+	// Source is the object the ID was read from: "ClusterVersion" or "Namespace/kube-system".
+	Source string `json:"source,omitempty"`
+}
+
+// TestIdentity identifies the DataProtectionTest.
+type TestIdentity struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	UID       string `json:"uid,omitempty"`
+}
+
+// Parameters are the test parameters taken from the DataProtectionTest spec.
+type Parameters struct {
+	BackupLocationName  string                `json:"backupLocationName,omitempty"`
+	Provider            string                `json:"provider,omitempty"`
+	Bucket              string                `json:"bucket,omitempty"`
+	Prefix              string                `json:"prefix,omitempty"`
+	SkipTLSVerify       bool                  `json:"skipTLSVerify"`
+	UploadFileSizeBytes *int64                `json:"uploadFileSizeBytes,omitempty"`
+	UploadTimeoutSecs   *float64              `json:"uploadTimeoutSeconds,omitempty"`
+	SnapshotTests       []SnapshotParameters  `json:"snapshotTests,omitempty"`
+	BackupLocations     *BackupLocationParams `json:"backupLocationSelector,omitempty"`
+}
+
+// SnapshotParameters are the parameters of a single CSI snapshot test.
+type SnapshotParameters struct {
+	PersistentVolumeClaimName      string   `json:"persistentVolumeClaimName"`
+	PersistentVolumeClaimNamespace string   `json:"persistentVolumeClaimNamespace"`
+	SnapshotClassName              string   `json:"snapshotClassName"`
+	TimeoutSecs                    *float64 `json:"timeoutSeconds,omitempty"`
+}
+
+// BackupLocationParams are the parameters of a multi-location test.
+type BackupLocationParams struct {
+	All            bool              `json:"all,omitempty"`
+	MatchLabels    map[string]string `json:"matchLabels,omitempty"`
+	MaxConcurrency int               `json:"maxConcurrency,omitempty"`
+}
+
+// Results are the typed results of the run.
+type Results struct {
+	Phase                 string                 `json:"phase"`
+	ErrorMessage          string                 `json:"errorMessage,omitempty"`
+	FailureReasons        []string               `json:"failureReasons,omitempty"`
+	S3Vendor              string                 `json:"s3Vendor,omitempty"`
+	EndpointLatencySecs   *float64               `json:"endpointLatencySeconds,omitempty"`
+	Upload                *UploadResult          `json:"upload,omitempty"`
+	Download              *DownloadResult        `json:"download,omitempty"`
+	Bucket                *BucketResult          `json:"bucket,omitempty"`
+	S3Capabilities        *S3CapabilitiesResult  `json:"s3Capabilities,omitempty"`
+	Snapshots             []SnapshotResult       `json:"snapshots,omitempty"`
+	SnapshotsPassed       int                    `json:"snapshotsPassed"`
+	SnapshotsTotal        int                    `json:"snapshotsTotal"`
+	NativeSnapshot        *NativeSnapshotResult  `json:"nativeSnapshot,omitempty"`
+	BackupLocations       []BackupLocationResult `json:"backupLocations,omitempty"`
+	BackupLocationsPassed int                    `json:"backupLocationsPassed"`
+	BackupLocationsTotal  int                    `json:"backupLocationsTotal"`
+}
+
+// UploadResult is the result of an upload speed test.
+type UploadResult struct {
+	Success      bool     `json:"success"`
+	SpeedMbps    int64    `json:"speedMbps"`
+	DurationSecs *float64 `json:"durationSeconds,omitempty"`
+	ErrorMessage string   `json:"errorMessage,omitempty"`
+}
+
+// DownloadResult is the result of a download speed test.
+type DownloadResult struct {
+	Success      bool     `json:"success"`
+	SpeedMbps    int64    `json:"speedMbps"`
+	DurationSecs *float64 `json:"durationSeconds,omitempty"`
+	ErrorMessage string   `json:"errorMessage,omitempty"`
+}
+
+// BucketResult is the encryption and versioning state of the bucket.
+type BucketResult struct {
+	EncryptionAlgorithm string `json:"encryptionAlgorithm,omitempty"`
+	VersioningStatus    string `json:"versioningStatus,omitempty"`
+	ErrorMessage        string `json:"errorMessage,omitempty"`
+}
+
+// S3CapabilitiesResult is the result of probing an S3-compatible endpoint.
+type S3CapabilitiesResult struct {
+	ChecksumAlgorithms []string          `json:"checksumAlgorithms,omitempty"`
+	ObjectLock         string            `json:"objectLock,omitempty"`
+	Versioning         string            `json:"versioning,omitempty"`
+	ConditionalWrites  *bool             `json:"conditionalWrites,omitempty"`
+	MaxParts           int64             `json:"multipartMaxParts,omitempty"`
+	MinPartSizeBytes   int64             `json:"multipartMinPartSizeBytes,omitempty"`
+	MaxPartSizeBytes   int64             `json:"multipartMaxPartSizeBytes,omitempty"`
+	RecommendedConfig  map[string]string `json:"recommendedConfig,omitempty"`
+	ErrorMessage       string            `json:"errorMessage,omitempty"`
+}
+
+// SnapshotResult is the result of a single CSI snapshot test.
+type SnapshotResult struct {
+	PersistentVolumeClaimName      string   `json:"persistentVolumeClaimName"`
+	PersistentVolumeClaimNamespace string   `json:"persistentVolumeClaimNamespace"`
+	Status                         string   `json:"status"`
+	ReadyDurationSecs              *float64 `json:"readyDurationSeconds,omitempty"`
+	ErrorMessage                   string   `json:"errorMessage,omitempty"`
+}
+
+// NativeSnapshotResult is the result of a VolumeSnapshotLocation native snapshot test.
+type NativeSnapshotResult struct {
+	VolumeSnapshotLocation string                    `json:"volumeSnapshotLocation"`
+	Provider               string                    `json:"provider,omitempty"`
+	Region                 string                    `json:"region,omitempty"`
+	Status                 string                    `json:"status"`
+	VolumesFound           int                       `json:"volumesFound"`
+	VolumeID               string                    `json:"volumeID,omitempty"`
+	ScratchVolume          bool                      `json:"scratchVolume"`
+	SnapshotID             string                    `json:"snapshotID,omitempty"`
+	ReadyDurationSecs      *float64                  `json:"snapshotReadyDurationSeconds,omitempty"`
+	Operations             []NativeSnapshotOperation `json:"operations,omitempty"`
+	ErrorMessage           string                    `json:"errorMessage,omitempty"`
+}
+
+// NativeSnapshotOperation is the permission and timing of a single cloud API operation.
+type NativeSnapshotOperation struct {
+	Operation    string   `json:"operation"`
+	Permission   string   `json:"permission"`
+	DurationSecs *float64 `json:"durationSeconds,omitempty"`
+	ErrorMessage string   `json:"errorMessage,omitempty"`
+}
+
+// BackupLocationResult is the result for a single BSL of a multi-location test.
+type BackupLocationResult struct {
+	Name         string                `json:"name"`
+	Provider     string                `json:"provider,omitempty"`
+	Status       string                `json:"status"`
+	S3Vendor     string                `json:"s3Vendor,omitempty"`
+	Upload       *UploadResult         `json:"upload,omitempty"`
+	Download     *DownloadResult       `json:"download,omitempty"`
+	Bucket       *BucketResult         `json:"bucket,omitempty"`
+	Capabilities *S3CapabilitiesResult `json:"s3Capabilities,omitempty"`
+	ErrorMessage string                `json:"errorMessage,omitempty"`
+}
+
+// Build creates the report for a DataProtectionTest whose status holds the run results.
+// backupLocationSpec is the resolved BSL spec in single-location mode and nil otherwise.
+func Build(dpt *oadpv1alpha1.DataProtectionTest, backupLocationSpec *velerov1.BackupStorageLocationSpec, cluster ClusterIdentity, now time.Time) *Report {
+	report := &Report{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   now.UTC(),
+		Cluster:       cluster,
+		Test: TestIdentity{
+			Name:      dpt.Name,
+			Namespace: dpt.Namespace,
+			UID:       string(dpt.UID),
+		},
+		Parameters: buildParameters(dpt, backupLocationSpec),
+	}
+
+	status := dpt.Status
+	results := Results{
+		Phase:               status.Phase,
+		ErrorMessage:        status.ErrorMessage,
+		FailureReasons:      status.FailureReasons,
+		S3Vendor:            status.S3Vendor,
+		EndpointLatencySecs: seconds(status.EndpointLatency),
+		Bucket:              bucketResult(status.BucketMetadata),
+		S3Capabilities:      capabilitiesResult(status.S3Capabilities),
+	}
+
+	if dpt.Spec.UploadSpeedTestConfig != nil && dpt.Spec.BackupLocationSelector == nil {
+		results.Upload = uploadResult(status.UploadTest)
+		results.Download = downloadResult(status.DownloadTest)
+	}
+
+	for _, snapshot := range status.SnapshotTests {
+		results.Snapshots = append(results.Snapshots, SnapshotResult{
+			PersistentVolumeClaimName:      snapshot.PersistentVolumeClaimName,
+			PersistentVolumeClaimNamespace: snapshot.PersistentVolumeClaimNamespace,
+			Status:                         snapshot.Status,
+			ReadyDurationSecs:              seconds(snapshot.ReadyDuration),
+			ErrorMessage:                   snapshot.ErrorMessage,
+		})
+		if snapshot.Status == "Ready" {
+			results.SnapshotsPassed++
+		}
+	}
+	results.SnapshotsTotal = len(status.SnapshotTests)
+	results.NativeSnapshot = nativeSnapshotResult(status.VolumeSnapshotLocationTest)
+
+	for _, location := range status.BackupLocationResults {
+		result := BackupLocationResult{
+			Name:         location.Name,
+			Provider:     location.Provider,
+			Status:       location.Status,
+			S3Vendor:     location.S3Vendor,
+			Bucket:       bucketResult(location.BucketMetadata),
+			Capabilities: capabilitiesResult(location.S3Capabilities),
+			ErrorMessage: location.ErrorMessage,
+		}
+		if location.UploadTest != nil {
+			result.Upload = uploadResult(*location.UploadTest)
+		}
+		result.Download = downloadResult(location.DownloadTest)
+		results.BackupLocations = append(results.BackupLocations, result)
+		if location.Status == "Passed" {
+			results.BackupLocationsPassed++
+		}
+	}
+	results.BackupLocationsTotal = len(status.BackupLocationResults)
+
+	report.Results = results
+	return report
+}
+
+// Marshal encodes the report as indented JSON.
+func (r *Report) Marshal() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+func buildParameters(dpt *oadpv1alpha1.DataProtectionTest, backupLocationSpec *velerov1.BackupStorageLocationSpec) Parameters {
+	params := Parameters{
+		BackupLocationName: dpt.Spec.BackupLocationName,
+		SkipTLSVerify:      dpt.Spec.SkipTLSVerify,
+	}
+
+	if backupLocationSpec != nil {
+		params.Provider = backupLocationSpec.Provider
+		if backupLocationSpec.ObjectStorage != nil {
+			params.Bucket = backupLocationSpec.ObjectStorage.Bucket
+			params.Prefix = backupLocationSpec.ObjectStorage.Prefix
+		}
+	}
+
+	if cfg := dpt.Spec.UploadSpeedTestConfig; cfg != nil {
+		if size, err := utils.ParseFileSize(cfg.FileSize); err == nil {
+			params.UploadFileSizeBytes = &size
+		}
+		if cfg.Timeout.Duration > 0 {
+			timeout := cfg.Timeout.Duration.Seconds()
+			params.UploadTimeoutSecs = &timeout
+		}
+	}
+
+	for _, cfg := range dpt.Spec.CSIVolumeSnapshotTestConfigs {
+		snapshot := SnapshotParameters{
+			PersistentVolumeClaimName:      cfg.VolumeSnapshotSource.PersistentVolumeClaimName,
+			PersistentVolumeClaimNamespace: cfg.VolumeSnapshotSource.PersistentVolumeClaimNamespace,
+			SnapshotClassName:              cfg.SnapshotClassName,
+		}
+		if cfg.Timeout.Duration > 0 {
+			timeout := cfg.Timeout.Duration.Seconds()
+			snapshot.TimeoutSecs = &timeout
+		}
+		params.SnapshotTests = append(params.SnapshotTests, snapshot)
+	}
+
+	if selector := dpt.Spec.BackupLocationSelector; selector != nil {
+		params.BackupLocations = &BackupLocationParams{
+			All:            selector.All,
+			MaxConcurrency: selector.MaxConcurrency,
+		}
+		if selector.LabelSelector != nil {
+			params.BackupLocations.MatchLabels = selector.LabelSelector.MatchLabels
+		}
+	}
+
+	return params
+}
+
+func uploadResult(upload oadpv1alpha1.UploadTestStatus) *UploadResult {
+	return &UploadResult{
+		Success:      upload.Success,
+		SpeedMbps:    upload.SpeedMbps,
+		DurationSecs: seconds(upload.Duration),
+		ErrorMessage: upload.ErrorMessage,
+	}
+}
+
+func downloadResult(download *oadpv1alpha1.DownloadTestStatus) *DownloadResult {
+	if download == nil {
+		return nil
+	}
+	return &DownloadResult{
+		Success:      download.Success,
+		SpeedMbps:    download.SpeedMbps,
+		DurationSecs: seconds(download.Duration),
+		ErrorMessage: download.ErrorMessage,
+	}
+}
+
+func nativeSnapshotResult(vslTest *oadpv1alpha1.VolumeSnapshotLocationTestStatus) *NativeSnapshotResult {
+	if vslTest == nil {
+		return nil
+	}
+	result := &NativeSnapshotResult{
+		VolumeSnapshotLocation: vslTest.Name,
+		Provider:               vslTest.Provider,
+		Region:                 vslTest.Region,
+		Status:                 vslTest.Status,
+		VolumesFound:           vslTest.VolumesFound,
+		VolumeID:               vslTest.VolumeID,
+		ScratchVolume:          vslTest.ScratchVolume,
+		SnapshotID:             vslTest.SnapshotID,
+		ReadyDurationSecs:      seconds(vslTest.SnapshotReadyDuration),
+		ErrorMessage:           vslTest.ErrorMessage,
+	}
+	for _, operation := range vslTest.Operations {
+		result.Operations = append(result.Operations, NativeSnapshotOperation{
+			Operation:    operation.Operation,
+			Permission:   operation.Permission,
+			DurationSecs: seconds(operation.Duration),
+			ErrorMessage: operation.ErrorMessage,
+		})
+	}
+	return result
+}
+
+func bucketResult(meta *oadpv1alpha1.BucketMetadata) *BucketResult {
+	if meta == nil {
+		return nil
+	}
+	return &BucketResult{
+		EncryptionAlgorithm: meta.EncryptionAlgorithm,
+		VersioningStatus:    meta.VersioningStatus,
+		ErrorMessage:        meta.ErrorMessage,
+	}
+}
+
+func capabilitiesResult(capabilities *oadpv1alpha1.S3Capabilities) *S3CapabilitiesResult {
+	if capabilities == nil {
+		return nil
+	}
+	result := &S3CapabilitiesResult{
+		ChecksumAlgorithms: capabilities.ChecksumAlgorithms,
+		ObjectLock:         capabilities.ObjectLock,
+		Versioning:         capabilities.Versioning,
+		ConditionalWrites:  capabilities.ConditionalWrites,
+		RecommendedConfig:  capabilities.RecommendedConfig,
+		ErrorMessage:       capabilities.ErrorMessage,
+	}
+	if limits := capabilities.MultipartLimits; limits != nil {
+		result.MaxParts = limits.MaxParts
+		result.MinPartSizeBytes = limits.MinPartSizeBytes
+		result.MaxPartSizeBytes = limits.MaxPartSizeBytes
+	}
+	return result
+}
+
+// seconds converts a duration string from the DPT status into seconds, or nil if it is empty or invalid.
+func seconds(duration string) *float64 {
+	if duration == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return nil
+	}
+	s := d.Seconds()
+	return &s
+}
@@ -0,0 +1,148 @@
+package dptreport
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	oadpv1alpha1 "github.com/openshift/oadp-operator/api/v1alpha1"
+)
+
+func TestBuild(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+	dpt := &oadpv1alpha1.DataProtectionTest{
+		ObjectMeta: metav1.ObjectMeta{Name: "dpt", Namespace: "openshift-adp", UID: "dpt-uid"},
+		Spec: oadpv1alpha1.DataProtectionTestSpec{
+			BackupLocationName: "default",
+			UploadSpeedTestConfig: &oadpv1alpha1.UploadSpeedTestConfig{
+				FileSize: "10MB",
+				Timeout:  metav1.Duration{Duration: 90 * time.Second},
+			},
+			CSIVolumeSnapshotTestConfigs: []oadpv1alpha1.CSIVolumeSnapshotTestConfig{
+				{
+					SnapshotClassName: "csi-snapclass",
+					Timeout:           metav1.Duration{Duration: 2 * time.Minute},
+					VolumeSnapshotSource: oadpv1alpha1.VolumeSnapshotSource{
+						PersistentVolumeClaimName:      "mysql",
+						PersistentVolumeClaimNamespace: "mysql-persistent",
+					},
+				},
+			},
+		},
+		Status: oadpv1alpha1.DataProtectionTestStatus{
+			Phase:           "Complete",
+			S3Vendor:        "AWS",
+			EndpointLatency: "250ms",
+			UploadTest: oadpv1alpha1.UploadTestStatus{
+				SpeedMbps: 540,
+				Duration:  "1.5s",
+				Success:   true,
+			},
+			DownloadTest:   &oadpv1alpha1.DownloadTestStatus{SpeedMbps: 800, Duration: "1s", Success: true},
+			BucketMetadata: &oadpv1alpha1.BucketMetadata{EncryptionAlgorithm: "AES256", VersioningStatus: "Enabled"},
+			FailureReasons: []string{"upload speed 540 Mbps is below minimum 1000 Mbps"},
+			S3Capabilities: &oadpv1alpha1.S3Capabilities{
+				ChecksumAlgorithms: []string{"CRC32"},
+				MultipartLimits:    &oadpv1alpha1.MultipartLimits{MaxParts: 10000},
+			},
+			SnapshotTests: []oadpv1alpha1.SnapshotTestStatus{
+				{PersistentVolumeClaimName: "mysql", PersistentVolumeClaimNamespace: "mysql-persistent", Status: "Ready", ReadyDuration: "12s"},
+				{PersistentVolumeClaimName: "mongo", PersistentVolumeClaimNamespace: "mongo-persistent", Status: "Failed", ErrorMessage: "timeout"},
+			},
+			VolumeSnapshotLocationTest: &oadpv1alpha1.VolumeSnapshotLocationTestStatus{
+				Name:                  "default-vsl",
+				Provider:              "aws",
+				Region:                "us-east-1",
+				Status:                "Passed",
+				VolumeID:              "vol-123",
+				SnapshotReadyDuration: "1m30s",
+				Operations: []oadpv1alpha1.SnapshotOperationStatus{
+					{Operation: "CreateSnapshot", Permission: "Allowed", Duration: "500ms"},
+				},
+			},
+		},
+	}
+	bslSpec := &velerov1.BackupStorageLocationSpec{
+		Provider: "aws",
+		StorageType: velerov1.StorageType{
+			ObjectStorage: &velerov1.ObjectStorageLocation{Bucket: "bucket", Prefix: "velero"},
+		},
+	}
+
+	report := Build(dpt, bslSpec, ClusterIdentity{ID: "cluster-id", Source: "ClusterVersion"}, now)
+
+	require.Equal(t, SchemaVersion, report.SchemaVersion)
+	require.Equal(t, time.UTC, report.GeneratedAt.Location())
+	require.Equal(t, "cluster-id", report.Cluster.ID)
+	require.Equal(t, "dpt-uid", report.Test.UID)
+
+	require.Equal(t, "aws", report.Parameters.Provider)
+	require.Equal(t, "bucket", report.Parameters.Bucket)
+	require.Equal(t, int64(10<<20), *report.Parameters.UploadFileSizeBytes)
+	require.Equal(t, 90.0, *report.Parameters.UploadTimeoutSecs)
+	require.Len(t, report.Parameters.SnapshotTests, 1)
+	require.Equal(t, 120.0, *report.Parameters.SnapshotTests[0].TimeoutSecs)
+
+	require.Equal(t, 0.25, *report.Results.EndpointLatencySecs)
+	require.Equal(t, int64(540), report.Results.Upload.SpeedMbps)
+	require.Equal(t, 1.5, *report.Results.Upload.DurationSecs)
+	require.Equal(t, int64(800), report.Results.Download.SpeedMbps)
+	require.Equal(t, 1.0, *report.Results.Download.DurationSecs)
+	require.Equal(t, []string{"upload speed 540 Mbps is below minimum 1000 Mbps"}, report.Results.FailureReasons)
+	require.Equal(t, "AES256", report.Results.Bucket.EncryptionAlgorithm)
+	require.Equal(t, int64(10000), report.Results.S3Capabilities.MaxParts)
+	require.Equal(t, 12.0, *report.Results.Snapshots[0].ReadyDurationSecs)
+	require.Nil(t, report.Results.Snapshots[1].ReadyDurationSecs)
+	require.Equal(t, 1, report.Results.SnapshotsPassed)
+	require.Equal(t, 2, report.Results.SnapshotsTotal)
+	require.Equal(t, "default-vsl", report.Results.NativeSnapshot.VolumeSnapshotLocation)
+	require.Equal(t, 90.0, *report.Results.NativeSnapshot.ReadyDurationSecs)
+	require.Equal(t, 0.5, *report.Results.NativeSnapshot.Operations[0].DurationSecs)
+
+	data, err := report.Marshal()
+	require.NoError(t, err)
+
+	// numeric fields must be encoded as JSON numbers
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	results := decoded["results"].(map[string]interface{})
+	require.IsType(t, float64(0), results["endpointLatencySeconds"])
+	upload := results["upload"].(map[string]interface{})
+	require.IsType(t, float64(0), upload["speedMbps"])
+	require.IsType(t, float64(0), upload["durationSeconds"])
+}
+
+func TestBuildBackupLocationSelector(t *testing.T) {
+	dpt := &oadpv1alpha1.DataProtectionTest{
+		ObjectMeta: metav1.ObjectMeta{Name: "dpt", Namespace: "openshift-adp"},
+		Spec: oadpv1alpha1.DataProtectionTestSpec{
+			BackupLocationSelector: &oadpv1alpha1.BackupLocationSelector{
+				LabelSelector:  &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}},
+				MaxConcurrency: 2,
+			},
+			UploadSpeedTestConfig: &oadpv1alpha1.UploadSpeedTestConfig{FileSize: "1MB"},
+		},
+		Status: oadpv1alpha1.DataProtectionTestStatus{
+			Phase: "Complete",
+			BackupLocationResults: []oadpv1alpha1.BackupLocationTestResult{
+				{Name: "a", Status: "Passed", UploadTest: &oadpv1alpha1.UploadTestStatus{SpeedMbps: 10, Duration: "2s", Success: true}},
+				{Name: "b", Status: "Failed", ErrorMessage: "cloud provider init failed"},
+			},
+		},
+	}
+
+	report := Build(dpt, nil, ClusterIdentity{}, time.Now())
+
+	require.Nil(t, report.Results.Upload, "top-level upload result is only set for single-location tests")
+	require.Equal(t, map[string]string{"tier": "gold"}, report.Parameters.BackupLocations.MatchLabels)
+	require.Equal(t, 2, report.Parameters.BackupLocations.MaxConcurrency)
+	require.Len(t, report.Results.BackupLocations, 2)
+	require.Equal(t, 2.0, *report.Results.BackupLocations[0].Upload.DurationSecs)
+	require.Nil(t, report.Results.BackupLocations[1].Upload)
+	require.Equal(t, 1, report.Results.BackupLocationsPassed)
+	require.Equal(t, 2, report.Results.BackupLocationsTotal)
+}
@@ -0,0 +1,133 @@
+// Package tlsprofile reads the TLS security profile of the cluster APIServer configuration and converts it to
+// crypto/tls settings, so the OADP servers and clients follow the cluster TLS policy.
+package tlsprofile
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	configv1 "github.com/openshift/api/config/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// APIServerName is the name of the cluster APIServer configuration
+const APIServerName = "cluster"
+
+// DefaultProfileType is the profile of clusters without a TLS security profile, as in OpenShift
+const DefaultProfileType = configv1.TLSProfileIntermediateType
+
+// openSSLCipherSuites maps the OpenSSL cipher names of the TLS security profiles to the TLS 1.2 cipher suites of
+// crypto/tls. TLS 1.3 cipher suites are not configurable in crypto/tls.
+var openSSLCipherSuites = map[string]uint16{
+	"ECDHE-ECDSA-AES128-GCM-SHA256": tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	"ECDHE-RSA-AES128-GCM-SHA256":   tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	"ECDHE-ECDSA-AES256-GCM-SHA384": tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	"ECDHE-RSA-AES256-GCM-SHA384":   tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	"ECDHE-ECDSA-CHACHA20-POLY1305": tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	"ECDHE-RSA-CHACHA20-POLY1305":   tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+	"ECDHE-ECDSA-AES128-SHA256":     tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256,
+	"ECDHE-RSA-AES128-SHA256":       tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256,
+	"ECDHE-ECDSA-AES128-SHA":        tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+	"ECDHE-RSA-AES128-SHA":          tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	"ECDHE-ECDSA-AES256-SHA":        tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+	"ECDHE-RSA-AES256-SHA":          tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	"ECDHE-RSA-DES-CBC3-SHA":        tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA,
+	"AES128-GCM-SHA256":             tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	"AES256-GCM-SHA384":             tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	"AES128-SHA256":                 tls.TLS_RSA_WITH_AES_128_CBC_SHA256,
+	"AES128-SHA":                    tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	"AES256-SHA":                    tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+	"DES-CBC3-SHA":                  tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+}
+
+// tls13CipherSuites are the TLS 1.3 cipher suites of the profiles, always enabled by crypto/tls
+var tls13CipherSuites = map[string]bool{
+	"TLS_AES_128_GCM_SHA256":       true,
+	"TLS_AES_256_GCM_SHA384":       true,
+	"TLS_CHACHA20_POLY1305_SHA256": true,
+}
+
+// tlsVersions maps the protocol versions of the profiles to crypto/tls
+var tlsVersions = map[configv1.TLSProtocolVersion]uint16{
+	configv1.VersionTLS10: tls.VersionTLS10,
+	configv1.VersionTLS11: tls.VersionTLS11,
+	configv1.VersionTLS12: tls.VersionTLS12,
+	configv1.VersionTLS13: tls.VersionTLS13,
+}
+
+// ProfileSpec returns the ciphers and minimum version of a TLS security profile, the default profile when nil
+func ProfileSpec(profile *configv1.TLSSecurityProfile) configv1.TLSProfileSpec {
+	if profile == nil || profile.Type == "" {
+		return *configv1.TLSProfiles[DefaultProfileType]
+	}
+	if profile.Type == configv1.TLSProfileCustomType {
+		if profile.Custom == nil {
+			return *configv1.TLSProfiles[DefaultProfileType]
+		}
+		return profile.Custom.TLSProfileSpec
+	}
+	if spec, found := configv1.TLSProfiles[profile.Type]; found {
+		return *spec
+	}
+	return *configv1.TLSProfiles[DefaultProfileType]
+}
+
+// GetAPIServerProfileSpec returns the TLS profile of the cluster APIServer configuration. Clusters without
+// APIServer configuration, such as non OpenShift clusters, get the default profile.
+func GetAPIServerProfileSpec(ctx context.Context, c client.Reader) (configv1.TLSProfileSpec, error) {
+	apiServer := &configv1.APIServer{}
+	if err := c.Get(ctx, types.NamespacedName{Name: APIServerName}, apiServer); err != nil {
+		if k8serror.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return ProfileSpec(nil), nil
+		}
+		return configv1.TLSProfileSpec{}, err
+	}
+	return ProfileSpec(apiServer.Spec.TLSSecurityProfile), nil
+}
+
+// TLSVersion returns the crypto/tls version of a profile protocol version
+func TLSVersion(version configv1.TLSProtocolVersion) (uint16, error) {
+	tlsVersion, found := tlsVersions[version]
+	if !found {
+		return 0, fmt.Errorf("unsupported TLS version %q", version)
+	}
+	return tlsVersion, nil
+}
+
+// CipherSuites returns the crypto/tls cipher suites of the OpenSSL cipher names of a profile, and the names
+// crypto/tls does not support. The TLS 1.3 cipher suites are neither returned nor unsupported.
+func CipherSuites(ciphers []string) (cipherSuites []uint16, unsupported []string) {
+	for _, cipher := range ciphers {
+		if tls13CipherSuites[cipher] {
+			continue
+		}
+		if id, found := openSSLCipherSuites[cipher]; found {
+			cipherSuites = append(cipherSuites, id)
+			continue
+		}
+		unsupported = append(unsupported, cipher)
+	}
+	return cipherSuites, unsupported
+}
+
+// TLSConfigFunc returns the function applying the minimum version and cipher suites of a profile to a TLS
+// configuration, for the TLSOpts of the controller-runtime servers. The cipher suites crypto/tls does not support
+// are returned, they are ignored.
+func TLSConfigFunc(spec configv1.TLSProfileSpec) (func(*tls.Config), []string, error) {
+	minVersion, err := TLSVersion(spec.MinTLSVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+	cipherSuites, unsupported := CipherSuites(spec.Ciphers)
+	return func(config *tls.Config) {
+		config.MinVersion = minVersion
+		// crypto/tls does not allow the configuration of the TLS 1.3 cipher suites
+		if minVersion < tls.VersionTLS13 {
+			config.CipherSuites = cipherSuites
+		}
+	}, unsupported, nil
+}
@@ -0,0 +1,70 @@
+package tlsprofile
+
+import (
+	"context"
+	"crypto/tls"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestProfileSpec(t *testing.T) {
+	custom := configv1.TLSProfileSpec{Ciphers: []string{"ECDHE-RSA-AES128-GCM-SHA256"}, MinTLSVersion: configv1.VersionTLS12}
+	require.Equal(t, *configv1.TLSProfiles[configv1.TLSProfileIntermediateType], ProfileSpec(nil))
+	require.Equal(t, *configv1.TLSProfiles[configv1.TLSProfileModernType], ProfileSpec(&configv1.TLSSecurityProfile{Type: configv1.TLSProfileModernType}))
+	require.Equal(t, custom, ProfileSpec(&configv1.TLSSecurityProfile{
+		Type:   configv1.TLSProfileCustomType,
+		Custom: &configv1.CustomTLSProfile{TLSProfileSpec: custom},
+	}))
+	require.Equal(t, *configv1.TLSProfiles[configv1.TLSProfileIntermediateType], ProfileSpec(&configv1.TLSSecurityProfile{Type: configv1.TLSProfileCustomType}))
+}
+
+func TestGetAPIServerProfileSpec(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, configv1.AddToScheme(scheme))
+	ctx := context.Background()
+
+	spec, err := GetAPIServerProfileSpec(ctx, fake.NewClientBuilder().WithScheme(scheme).Build())
+	require.NoError(t, err)
+	require.Equal(t, ProfileSpec(nil), spec, "no APIServer configuration")
+
+	apiServer := &configv1.APIServer{
+		ObjectMeta: metav1.ObjectMeta{Name: APIServerName},
+		Spec:       configv1.APIServerSpec{TLSSecurityProfile: &configv1.TLSSecurityProfile{Type: configv1.TLSProfileOldType}},
+	}
+	spec, err = GetAPIServerProfileSpec(ctx, fake.NewClientBuilder().WithScheme(scheme).WithObjects(apiServer).Build())
+	require.NoError(t, err)
+	require.Equal(t, configv1.VersionTLS10, spec.MinTLSVersion)
+}
+
+func TestTLSConfigFunc(t *testing.T) {
+	apply, unsupported, err := TLSConfigFunc(*configv1.TLSProfiles[configv1.TLSProfileIntermediateType])
+	require.NoError(t, err)
+	require.Equal(t, []string{"DHE-RSA-AES128-GCM-SHA256", "DHE-RSA-AES256-GCM-SHA384"}, unsupported, "crypto/tls has no DHE cipher suites")
+	config := &tls.Config{}
+	apply(config)
+	require.Equal(t, uint16(tls.VersionTLS12), config.MinVersion)
+	require.Equal(t, []uint16{
+		tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+		tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+	}, config.CipherSuites)
+
+	apply, unsupported, err = TLSConfigFunc(*configv1.TLSProfiles[configv1.TLSProfileModernType])
+	require.NoError(t, err)
+	require.Empty(t, unsupported)
+	config = &tls.Config{}
+	apply(config)
+	require.Equal(t, uint16(tls.VersionTLS13), config.MinVersion)
+	require.Nil(t, config.CipherSuites)
+
+	_, _, err = TLSConfigFunc(configv1.TLSProfileSpec{MinTLSVersion: "VersionTLS14"})
+	require.EqualError(t, err, `unsupported TLS version "VersionTLS14"`)
+}
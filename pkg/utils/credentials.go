@@ -97,6 +97,38 @@ func ParseAWSSecret(secret corev1.Secret, secretKey, matchProfile string) (strin
 	return AWSAccessKey, AWSSecretKey, nil
 }
 
+// ParseAWSProfileSettings returns the settings of a profile in the AWS shared config file stored in the secret key,
+// such as credential_process or credential_source. Values keep their inner spaces.
+func ParseAWSProfileSettings(secret corev1.Secret, secretKey, matchProfile string) (map[string]string, error) {
+	var settings map[string]string
+	for _, line := range strings.Split(string(secret.Data[secretKey]), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if settings != nil {
+				break
+			}
+			profileName := strings.TrimSpace(strings.Trim(line, "[] "))
+			if profileName == matchProfile {
+				settings = map[string]string{}
+			}
+			continue
+		}
+		if settings == nil {
+			continue
+		}
+		if key, value, found := strings.Cut(line, "="); found {
+			settings[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	if settings == nil {
+		return nil, fmt.Errorf("profile %s not found in secret %s", matchProfile, secret.Name)
+	}
+	return settings, nil
+}
+
 // Return value to the right of = sign with quotations and spaces removed.
 func getMatchedKeyValue(key string, s string) (string, error) {
 	for _, removeChar := range []string{"\"", "'", " "} {